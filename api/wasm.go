@@ -17,6 +17,9 @@ const (
 	ExternTypeTable  ExternType = 0x01
 	ExternTypeMemory ExternType = 0x02
 	ExternTypeGlobal ExternType = 0x03
+	// ExternTypeTag is only valid when the exception handling proposal is enabled.
+	// See wazero.RuntimeConfig WithFeatureExceptionHandling
+	ExternTypeTag ExternType = 0x04
 )
 
 // The below are exported to consolidate parsing behavior for external types.
@@ -29,6 +32,8 @@ const (
 	ExternTypeMemoryName = "memory"
 	// ExternTypeGlobalName is the name of the WebAssembly 1.0 (20191205) Text Format field for ExternTypeGlobal.
 	ExternTypeGlobalName = "global"
+	// ExternTypeTagName is the name of the Text Format field for ExternTypeTag in the exception handling proposal.
+	ExternTypeTagName = "tag"
 )
 
 // ExternTypeName returns the name of the WebAssembly 1.0 (20191205) Text Format field of the given type.
@@ -44,6 +49,8 @@ func ExternTypeName(et ExternType) string {
 		return ExternTypeMemoryName
 	case ExternTypeGlobal:
 		return ExternTypeGlobalName
+	case ExternTypeTag:
+		return ExternTypeTagName
 	}
 	return fmt.Sprintf("%#x", et)
 }
@@ -95,6 +102,10 @@ const (
 	//
 	// Note: The usage of this type is toggled with WithFeatureBulkMemoryOperations.
 	ValueTypeExternref ValueType = 0x6f
+	// ValueTypeFuncref is a reference to a function, which is only used as the type of a Table.
+	//
+	// Note: Unlike ValueTypeExternref, this cannot be used in the signature of a function defined in Go.
+	ValueTypeFuncref ValueType = 0x70
 )
 
 // ValueTypeName returns the type name of the given ValueType as a string.
@@ -113,6 +124,8 @@ func ValueTypeName(t ValueType) string {
 		return "f64"
 	case ValueTypeExternref:
 		return "externref"
+	case ValueTypeFuncref:
+		return "funcref"
 	}
 	return "unknown"
 }
@@ -132,12 +145,16 @@ type Module interface {
 	Name() string
 
 	// Memory returns a memory defined in this module or nil if there are none wasn't.
+	//
+	// Note: When the module has more than one memory, this is the first, at index zero. Use ExportedMemory to reach
+	// the others.
 	Memory() Memory
 
 	// ExportedFunction returns a function exported from this module or nil if it wasn't.
 	ExportedFunction(name string) Function
 
-	// TODO: Table
+	// ExportedTable returns a table exported from this module or nil if it wasn't.
+	ExportedTable(name string) Table
 
 	// ExportedMemory returns a memory exported from this module or nil if it wasn't.
 	//
@@ -150,6 +167,11 @@ type Module interface {
 	// ExportedGlobal a global exported from this module or nil if it wasn't.
 	ExportedGlobal(name string) Global
 
+	// ExportedTag returns a tag exported from this module or nil if it wasn't.
+	//
+	// See Tag
+	ExportedTag(name string) Tag
+
 	// CloseWithExitCode releases resources allocated for this Module. Use a non-zero exitCode parameter to indicate a
 	// failure to ExportedFunction callers. When the context is nil, it defaults to context.Background.
 	//
@@ -199,6 +221,188 @@ type Function interface {
 	Call(ctx context.Context, params ...uint64) ([]uint64, error)
 }
 
+// GoModuleFunction is a host function which reads its parameters from, and writes its results to, the stack, instead
+// of being called with reflection like a Go func exported by wazero.ModuleBuilder ExportFunction. This avoids
+// allocating on each call, which matters for functions called often, such as those writing to a file.
+//
+// The signature of the function is explicit, via wazero.ModuleBuilder ExportGoModuleFunction. Its parameters are
+// at the beginning of the stack, encoded according to their ValueType. Ex. DecodeF32(stack[1]) is the second
+// parameter, if its type is ValueTypeF32. Results must be written to the beginning of the stack, replacing the
+// parameters. The length of the stack is the larger of the count of parameters and results.
+//
+// Ex. This adds two i32 parameters, and returns the sum as an i32 result:
+//	add := api.GoModuleFunc(func(ctx context.Context, mod api.Module, stack []uint64) {
+//		x, y := uint32(stack[0]), uint32(stack[1])
+//		stack[0] = uint64(x + y)
+//	})
+//
+// Notes:
+//	* The stack is only valid until the function returns, as it is reused by later calls.
+//	* The module is the caller's, like the api.Module param of a Go func exported by ExportFunction.
+//	* To abort the guest, panic, as there is no error result. The call returns a sys.StackTraceError wrapping it.
+type GoModuleFunction interface {
+	Call(ctx context.Context, mod Module, stack []uint64)
+}
+
+// GoModuleFunc is a convenience for defining an inlined GoModuleFunction.
+type GoModuleFunc func(ctx context.Context, mod Module, stack []uint64)
+
+// Call implements GoModuleFunction.Call.
+func (f GoModuleFunc) Call(ctx context.Context, mod Module, stack []uint64) {
+	f(ctx, mod, stack)
+}
+
+// Table is a WebAssembly table of references exported from an instantiated module (wazero.Runtime InstantiateModule),
+// such as the target of "call_indirect".
+//
+// Ex. To replace the function at index 1 of a dispatch table:
+//	table := module.ExportedTable("dispatch")
+//	if ok := table.SetFunction(ctx, 1, module.ExportedFunction("handle_v2")); !ok {
+//		// out of range or not a funcref table
+//	}
+//
+// Note: This is an interface for decoupling, not third-party implementations. All implementations are in wazero.
+//
+// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#table-instances%E2%91%A0
+type Table interface {
+	// Type is the type of references in this table: ValueTypeFuncref or ValueTypeExternref.
+	Type() ValueType
+
+	// Size returns the count of elements in this table. When the context is nil, it defaults to context.Background.
+	Size(context.Context) uint32
+
+	// Grow increases the size of this table by delta elements, which are initially null. The return val is the
+	// previous size, or false if the delta was ignored as it exceeds the maximum size of this table. When the context
+	// is nil, it defaults to context.Background.
+	//
+	// Note: This is the same as the "table.grow" instruction defined in the WebAssembly Core Specification 2.0, except
+	// returns false instead of -1.
+	Grow(ctx context.Context, delta uint32) (previousSize uint32, ok bool)
+
+	// Get returns the externref at the offset, encoded as documented on ValueTypeExternref, or false if out of range
+	// or this is not a ValueTypeExternref table. A null reference is zero.
+	Get(ctx context.Context, offset uint32) (externref uint64, ok bool)
+
+	// Set writes the externref at the offset, or returns false if out of range or this is not a ValueTypeExternref
+	// table. Use zero to write a null reference.
+	Set(ctx context.Context, offset uint32, externref uint64) bool
+
+	// GetFunction returns the function at the offset, or false if out of range or this is not a ValueTypeFuncref
+	// table. The function is nil for a null reference.
+	GetFunction(ctx context.Context, offset uint32) (fn Function, ok bool)
+
+	// SetFunction writes the function at the offset, or returns false if out of range or this is not a
+	// ValueTypeFuncref table. Use a nil function to write a null reference.
+	//
+	// Note: The function must be from an open module instantiated by the same wazero.Runtime, such as the result of
+	// Module.ExportedFunction. Otherwise, this returns false.
+	SetFunction(ctx context.Context, offset uint32, fn Function) bool
+}
+
+// Tag is a WebAssembly tag exported from an instantiated module (wazero.Runtime InstantiateModule), which classifies
+// the exceptions of the exception handling proposal. An exception has the values of the parameter types of its tag.
+//
+// Ex. To throw an exception into the guest from a host function:
+//	errorTag := module.ExportedTag("error")
+//	panic(sys.NewException(errorTag, api.EncodeI32(errno)))
+//
+// Note: This is an interface for decoupling, not third-party implementations. All implementations are in wazero.
+//
+// See https://github.com/WebAssembly/exception-handling/blob/main/proposals/exception-handling/Exceptions.md
+type Tag interface {
+	fmt.Stringer
+
+	// ParamTypes are the possibly empty sequence of value types of the values of exceptions with this tag.
+	//
+	// See ValueType documentation for encoding rules.
+	ParamTypes() []ValueType
+}
+
+// FunctionDefinition is a WebAssembly function imported or defined by a module, available pre-instantiation.
+//
+// Note: This is an interface for decoupling, not third-party implementations. All implementations are in wazero.
+//
+// See wazero.CompiledModule ImportedFunctions and ExportedFunctions
+type FunctionDefinition interface {
+	// ModuleName is the possibly empty name of the module defining this function.
+	ModuleName() string
+
+	// Index is the position in the module's function index namespace, imports first.
+	Index() uint32
+
+	// Import returns true with the module and name when this function is imported.
+	Import() (moduleName, name string, isImport bool)
+
+	// ExportNames include all exported names for this function, or nil if it isn't exported.
+	ExportNames() []string
+
+	// Name is the module-defined name of the function from the name section, which is not necessarily the same as
+	// its export name. This is empty if the function has no name.
+	Name() string
+
+	// ParamTypes are the possibly empty sequence of value types accepted by this function.
+	ParamTypes() []ValueType
+
+	// ParamNames are index-correlated with ParamTypes or nil if not available for one or more parameters.
+	//
+	// Note: Only the name section of defined functions includes parameter names. The WebAssembly name section does
+	// not define result names.
+	ParamNames() []string
+
+	// ResultTypes are the possibly empty sequence of value types returned by this function.
+	ResultTypes() []ValueType
+}
+
+// MemoryDefinition is a WebAssembly memory imported or defined by a module, available pre-instantiation.
+//
+// Note: This is an interface for decoupling, not third-party implementations. All implementations are in wazero.
+//
+// See wazero.CompiledModule ExportedMemories
+type MemoryDefinition interface {
+	// ModuleName is the possibly empty name of the module defining this memory.
+	ModuleName() string
+
+	// Index is the position in the module's memory index namespace, imports first.
+	Index() uint32
+
+	// Import returns true with the module and name when this memory is imported.
+	Import() (moduleName, name string, isImport bool)
+
+	// ExportNames include all exported names for this memory, or nil if it isn't exported.
+	ExportNames() []string
+
+	// Min is the minimum amount of pages (65536 bytes per page) of this memory.
+	Min() uint32
+
+	// Max is the maximum amount of pages of this memory, and true if the module encoded one.
+	Max() (uint32, bool)
+}
+
+// GlobalDefinition is a WebAssembly global imported or defined by a module, available pre-instantiation.
+//
+// Note: This is an interface for decoupling, not third-party implementations. All implementations are in wazero.
+//
+// See wazero.CompiledModule ExportedGlobals
+type GlobalDefinition interface {
+	// ModuleName is the possibly empty name of the module defining this global.
+	ModuleName() string
+
+	// Index is the position in the module's global index namespace, imports first.
+	Index() uint32
+
+	// Import returns true with the module and name when this global is imported.
+	Import() (moduleName, name string, isImport bool)
+
+	// ExportNames include all exported names for this global, or nil if it isn't exported.
+	ExportNames() []string
+
+	// Type describes the numeric type of the global.
+	Type() ValueType
+
+	// Mutable is true if the value of the global can change at runtime.
+	Mutable() bool
+}
+
 // Global is a WebAssembly 1.0 (20191205) global exported from an instantiated module (wazero.Runtime InstantiateModule).
 //
 // Ex. If the value is not mutable, you can read it once:
@@ -357,6 +561,28 @@ type Memory interface {
 	Write(ctx context.Context, offset uint32, v []byte) bool
 }
 
+// Memory64 is a Memory whose offsets can exceed the range of uint32, such as one defined with 64-bit indices by the
+// memory64 proposal. Memories instantiated by wazero implement it, so it is available via a type assertion:
+//
+//	mem64 := mod.Memory().(api.Memory64)
+//
+// Note: The uint32 offsets of Memory only reach the first 4GiB of a larger memory, and Memory.Size saturates at
+// math.MaxUint32 bytes.
+//
+// See https://github.com/WebAssembly/memory64/blob/main/proposals/memory64/Overview.md
+type Memory64 interface {
+	Memory
+
+	// Size64 returns the size in bytes available, which can exceed math.MaxUint32.
+	Size64(context.Context) uint64
+
+	// Read64 is like Memory.Read, except the offset and byteCount can exceed math.MaxUint32.
+	Read64(ctx context.Context, offset, byteCount uint64) ([]byte, bool)
+
+	// Write64 is like Memory.Write, except the offset can exceed math.MaxUint32.
+	Write64(ctx context.Context, offset uint64, v []byte) bool
+}
+
 // EncodeExternref encodes the input as a ValueTypeExternref.
 //
 // See DecodeExternref
@@ -449,3 +675,19 @@ type ImportRenamer func(externType ExternType, oldModule, oldName string) (newMo
 //
 // See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#grow-mem
 type MemorySizer func(minPages uint32, maxPages *uint32) (min, capacity, max uint32)
+
+// MemoryLimitExceeded is notified when a memory would grow past the limit set by the host, which is lower than the
+// maximum the module defined. The growth fails regardless, so memory.grow returns -1 and Memory.Grow returns false.
+// Returning a non-nil error additionally makes the "memory.grow" instruction fail with it, so the reason is returned
+// by the api.Function Call in progress.
+//
+// requestedPages is currentPages plus the pages to grow by, saturated at math.MaxUint32.
+//
+// Ex. To log growth past the limit:
+//	logLimit := func(ctx context.Context, currentPages, requestedPages, limitPages uint32) error {
+//		log.Printf("memory limited to %d pages: %d -> %d", limitPages, currentPages, requestedPages)
+//		return nil
+//	}
+//
+// See wazero.RuntimeConfig WithMemoryLimitPages and wazero.ModuleConfig WithMemoryLimitPages
+type MemoryLimitExceeded func(ctx context.Context, currentPages, requestedPages, limitPages uint32) error
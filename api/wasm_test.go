@@ -19,6 +19,7 @@ func TestExternTypeName(t *testing.T) {
 		{"table", ExternTypeTable, "table"},
 		{"mem", ExternTypeMemory, "memory"},
 		{"global", ExternTypeGlobal, "global"},
+		{"tag", ExternTypeTag, "tag"},
 		{"unknown", 100, "0x64"},
 	}
 
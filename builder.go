@@ -80,6 +80,20 @@ type ModuleBuilder interface {
 	//
 	// If both parameters exist, they must be in order at positions zero and one.
 	//
+	// Host functions may also have a trailing result of type error, which isn't a WebAssembly result. When it is not
+	// nil, the guest is aborted: the api.Function Call in progress returns a sys.StackTraceError wrapping it, with the
+	// WebAssembly stack trace. Otherwise, the other results are returned normally.
+	//
+	// Ex. This aborts the guest when reading memory fails:
+	//
+	//	readUint32 := func(ctx context.Context, m api.Module, offset uint32) (uint32, error) {
+	//		v, ok := m.Memory().ReadUint32Le(ctx, offset)
+	//		if !ok {
+	//			return 0, fmt.Errorf("offset %d is out of range", offset)
+	//		}
+	//		return v, nil
+	//	}
+	//
 	// Ex. This uses propagates context properly when calling other functions exported in the api.Module:
 	//	callRead := func(ctx context.Context, m api.Module, offset, byteCount uint32) uint32 {
 	//		fn = m.ExportedFunction("__read")
@@ -92,6 +106,29 @@ type ModuleBuilder interface {
 	// ExportFunctions is a convenience that calls ExportFunction for each key/value in the provided map.
 	ExportFunctions(nameToGoFunc map[string]interface{}) ModuleBuilder
 
+	// ExportGoModuleFunction adds a function written in Go, which reads its parameters from, and writes its results to,
+	// a stack. Unlike ExportFunction, this doesn't use reflection, so doesn't allocate on each call.
+	// If a function is already exported with the same name, this overwrites it.
+	//
+	// Parameters
+	//
+	//	* name - the name to export. Ex "fd_write"
+	//	* fn - the function to export.
+	//	* params - the WebAssembly value types of its parameters, in order.
+	//	* results - the WebAssembly value types of its results, in order.
+	//
+	// Ex. This is the same as the first example of ExportFunction:
+	//
+	//	addInts := api.GoModuleFunc(func(ctx context.Context, m api.Module, stack []uint64) {
+	//		x, y := uint32(stack[0]), uint32(stack[1])
+	//		stack[0] = uint64(x + y)
+	//	})
+	//	builder.ExportGoModuleFunction("add_ints", addInts,
+	//		[]api.ValueType{api.ValueTypeI32, api.ValueTypeI32}, []api.ValueType{api.ValueTypeI32})
+	//
+	// See api.GoModuleFunction
+	ExportGoModuleFunction(name string, fn api.GoModuleFunction, params, results []api.ValueType) ModuleBuilder
+
 	// ExportMemory adds linear memory, which a WebAssembly module can import and become available via api.Memory.
 	// If a memory is already exported with the same name, this overwrites it.
 	//
@@ -107,7 +144,8 @@ type ModuleBuilder interface {
 	// Notes
 	//
 	//	* This is allowed to grow to (4GiB) limited by api.MemorySizer. To bound it, use ExportMemoryWithMax.
-	//	* Version 1.0 (20191205) of the WebAssembly spec allows at most one memory per module.
+	//	* Version 1.0 (20191205) of the WebAssembly spec allows at most one memory per module. More are allowed with
+	//	  RuntimeConfig.WithFeatureMultiMemory, in the order of their names.
 	//
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#memory-section%E2%91%A0
 	ExportMemory(name string, minPages uint32) ModuleBuilder
@@ -121,6 +159,47 @@ type ModuleBuilder interface {
 	// Note: api.MemorySizer determines the capacity.
 	ExportMemoryWithMax(name string, minPages, maxPages uint32) ModuleBuilder
 
+	// ExportSharedMemory is like ExportMemoryWithMax, except the memory is shared: module instances importing it can be
+	// called concurrently and use atomic instructions to synchronize.
+	//
+	// For example, the WebAssembly Text Format of the threads proposal below is the equivalent of this builder method:
+	//	// (memory (export "memory") 1 10 shared)
+	//	builder.ExportSharedMemory("memory", 1, 10)
+	//
+	// Notes
+	//
+	//	* This requires RuntimeConfig.WithFeatureThreads.
+	//	* The address space of maxPages is reserved, so that growing doesn't move the memory while in use. Only the pages
+	//	  the memory grows to are allocated, except on platforms other than Linux, macOS and Windows, where maxPages is
+	//	  allocated up front and limited to 16384 pages (1 GiB), unless lowered with WithMemoryLimitPages.
+	ExportSharedMemory(name string, minPages, maxPages uint32) ModuleBuilder
+
+	// ExportTable adds a table, which a WebAssembly module can import and become available via api.Table.
+	// If a table is already exported with the same name, this overwrites it.
+	//
+	// Parameters
+	//
+	//	* name - the name to export. Ex "__indirect_function_table"
+	//	* refType - the type of references in the table: api.ValueTypeFuncref or api.ValueTypeExternref.
+	//	* minSize - the possibly zero initial count of elements, which are null.
+	//
+	// For example, the WebAssembly 2.0 Text Format below is the equivalent of this builder method:
+	//	// (table (export "table") 10 funcref)
+	//	builder.ExportTable("table", api.ValueTypeFuncref, 10)
+	//
+	// Note: Version 1.0 (20191205) of the WebAssembly spec allows at most one table per module. More require
+	// RuntimeConfig.WithFeatureReferenceTypes.
+	//
+	// See https://www.w3.org/TR/2022/WD-wasm-core-2-20220419/syntax/modules.html#tables
+	ExportTable(name string, refType api.ValueType, minSize uint32) ModuleBuilder
+
+	// ExportTableWithMax is like ExportTable, but prevents the table from growing beyond maxSize elements.
+	//
+	// For example, the WebAssembly 2.0 Text Format below is the equivalent of this builder method:
+	//	// (table (export "table") 10 20 funcref)
+	//	builder.ExportTableWithMax("table", api.ValueTypeFuncref, 10, 20)
+	ExportTableWithMax(name string, refType api.ValueType, minSize, maxSize uint32) ModuleBuilder
+
 	// ExportGlobalI32 exports a global constant of type api.ValueTypeI32.
 	// If a global is already exported with the same name, this overwrites it.
 	//
@@ -188,6 +267,7 @@ type moduleBuilder struct {
 	moduleName   string
 	nameToGoFunc map[string]interface{}
 	nameToMemory map[string]*wasm.Memory
+	nameToTable  map[string]*wasm.Table
 	nameToGlobal map[string]*wasm.Global
 }
 
@@ -198,6 +278,7 @@ func (r *runtime) NewModuleBuilder(moduleName string) ModuleBuilder {
 		moduleName:   moduleName,
 		nameToGoFunc: map[string]interface{}{},
 		nameToMemory: map[string]*wasm.Memory{},
+		nameToTable:  map[string]*wasm.Table{},
 		nameToGlobal: map[string]*wasm.Global{},
 	}
 }
@@ -208,6 +289,12 @@ func (b *moduleBuilder) ExportFunction(name string, goFunc interface{}) ModuleBu
 	return b
 }
 
+// ExportGoModuleFunction implements ModuleBuilder.ExportGoModuleFunction
+func (b *moduleBuilder) ExportGoModuleFunction(name string, fn api.GoModuleFunction, params, results []api.ValueType) ModuleBuilder {
+	b.nameToGoFunc[name] = &wasm.HostFunc{ParamTypes: params, ResultTypes: results, Code: fn}
+	return b
+}
+
 // ExportFunctions implements ModuleBuilder.ExportFunctions
 func (b *moduleBuilder) ExportFunctions(nameToGoFunc map[string]interface{}) ModuleBuilder {
 	for k, v := range nameToGoFunc {
@@ -228,6 +315,24 @@ func (b *moduleBuilder) ExportMemoryWithMax(name string, minPages, maxPages uint
 	return b
 }
 
+// ExportSharedMemory implements ModuleBuilder.ExportSharedMemory
+func (b *moduleBuilder) ExportSharedMemory(name string, minPages, maxPages uint32) ModuleBuilder {
+	b.nameToMemory[name] = &wasm.Memory{Min: minPages, Max: maxPages, IsMaxEncoded: true, Shared: true}
+	return b
+}
+
+// ExportTable implements ModuleBuilder.ExportTable
+func (b *moduleBuilder) ExportTable(name string, refType api.ValueType, minSize uint32) ModuleBuilder {
+	b.nameToTable[name] = &wasm.Table{Min: minSize, Type: refType}
+	return b
+}
+
+// ExportTableWithMax implements ModuleBuilder.ExportTableWithMax
+func (b *moduleBuilder) ExportTableWithMax(name string, refType api.ValueType, minSize, maxSize uint32) ModuleBuilder {
+	b.nameToTable[name] = &wasm.Table{Min: minSize, Max: &maxSize, Type: refType}
+	return b
+}
+
 // ExportGlobalI32 implements ModuleBuilder.ExportGlobalI32
 func (b *moduleBuilder) ExportGlobalI32(name string, v int32) ModuleBuilder {
 	b.nameToGlobal[name] = &wasm.Global{
@@ -283,9 +388,15 @@ func (b *moduleBuilder) Compile(ctx context.Context, cConfig CompileConfig) (Com
 		if err := mem.Validate(); err != nil {
 			return nil, fmt.Errorf("memory[%s] %v", name, err)
 		}
+		if mem.Shared {
+			if err := b.r.enabledFeatures.Require(wasm.FeatureThreads); err != nil {
+				return nil, fmt.Errorf("memory[%s] shared memory invalid as %v", name, err)
+			}
+			mem.Cap = mem.Max
+		}
 	}
 
-	module, err := wasm.NewHostModule(b.moduleName, b.nameToGoFunc, b.nameToMemory, b.nameToGlobal, b.r.enabledFeatures)
+	module, err := wasm.NewHostModule(b.moduleName, b.nameToGoFunc, b.nameToMemory, b.nameToTable, b.nameToGlobal, b.r.enabledFeatures)
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,7 @@
 package wazero
 
 import (
+	"context"
 	"math"
 	"reflect"
 	"testing"
@@ -24,6 +25,8 @@ func TestNewModuleBuilder_Compile(t *testing.T) {
 		return 0
 	}
 	fnUint64_uint32 := reflect.ValueOf(uint64_uint32)
+	var goModuleFunc api.GoModuleFunction = api.GoModuleFunc(func(context.Context, api.Module, []uint64) {})
+	fnGoModuleFunc := reflect.ValueOf(goModuleFunc)
 
 	tests := []struct {
 		name     string
@@ -63,6 +66,25 @@ func TestNewModuleBuilder_Compile(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "ExportGoModuleFunction",
+			input: func(r Runtime) ModuleBuilder {
+				return r.NewModuleBuilder("").ExportGoModuleFunction("1", goModuleFunc, []api.ValueType{i64}, []api.ValueType{i32})
+			},
+			expected: &wasm.Module{
+				TypeSection: []*wasm.FunctionType{
+					{Params: []api.ValueType{i64}, Results: []api.ValueType{i32}, ParamNumInUint64: 1, ResultNumInUint64: 1},
+				},
+				FunctionSection:     []wasm.Index{0},
+				HostFunctionSection: []*reflect.Value{&fnGoModuleFunc},
+				ExportSection: []*wasm.Export{
+					{Name: "1", Type: wasm.ExternTypeFunc, Index: 0},
+				},
+				NameSection: &wasm.NameSection{
+					FunctionNames: wasm.NameMap{{Index: 0, Name: "1"}},
+				},
+			},
+		},
 		{
 			name: "ExportFunction overwrites existing",
 			input: func(r Runtime) ModuleBuilder {
@@ -159,7 +181,7 @@ func TestNewModuleBuilder_Compile(t *testing.T) {
 				return r.NewModuleBuilder("").ExportMemory("memory", 1)
 			},
 			expected: &wasm.Module{
-				MemorySection: &wasm.Memory{Min: 1, Cap: 1, Max: wasm.MemoryLimitPages},
+				MemorySection: []*wasm.Memory{{Min: 1, Cap: 1, Max: wasm.MemoryLimitPages}},
 				ExportSection: []*wasm.Export{
 					{Name: "memory", Type: wasm.ExternTypeMemory, Index: 0},
 				},
@@ -171,7 +193,7 @@ func TestNewModuleBuilder_Compile(t *testing.T) {
 				return r.NewModuleBuilder("").ExportMemory("memory", 1).ExportMemory("memory", 2)
 			},
 			expected: &wasm.Module{
-				MemorySection: &wasm.Memory{Min: 2, Cap: 2, Max: wasm.MemoryLimitPages},
+				MemorySection: []*wasm.Memory{{Min: 2, Cap: 2, Max: wasm.MemoryLimitPages}},
 				ExportSection: []*wasm.Export{
 					{Name: "memory", Type: wasm.ExternTypeMemory, Index: 0},
 				},
@@ -183,7 +205,7 @@ func TestNewModuleBuilder_Compile(t *testing.T) {
 				return r.NewModuleBuilder("").ExportMemoryWithMax("memory", 1, 1)
 			},
 			expected: &wasm.Module{
-				MemorySection: &wasm.Memory{Min: 1, Cap: 1, Max: 1, IsMaxEncoded: true},
+				MemorySection: []*wasm.Memory{{Min: 1, Cap: 1, Max: 1, IsMaxEncoded: true}},
 				ExportSection: []*wasm.Export{
 					{Name: "memory", Type: wasm.ExternTypeMemory, Index: 0},
 				},
@@ -195,7 +217,7 @@ func TestNewModuleBuilder_Compile(t *testing.T) {
 				return r.NewModuleBuilder("").ExportMemoryWithMax("memory", 1, 1).ExportMemoryWithMax("memory", 1, 2)
 			},
 			expected: &wasm.Module{
-				MemorySection: &wasm.Memory{Min: 1, Cap: 1, Max: 2, IsMaxEncoded: true},
+				MemorySection: []*wasm.Memory{{Min: 1, Cap: 1, Max: 2, IsMaxEncoded: true}},
 				ExportSection: []*wasm.Export{
 					{Name: "memory", Type: wasm.ExternTypeMemory, Index: 0},
 				},
@@ -377,7 +399,7 @@ func TestNewModuleBuilder_Compile_Errors(t *testing.T) {
 				return rt.NewModuleBuilder("").ExportMemory("memory", math.MaxUint32)
 			},
 			config:      NewCompileConfig(),
-			expectedErr: "memory[memory] min 4294967295 pages (3 Ti) over limit of 65536 pages (4 Gi)",
+			expectedErr: "memory[memory] min 4294967295 pages (255 Ti) over limit of 65536 pages (4 Gi)",
 		},
 		{
 			name: "memory cap < min", // only one test to avoid duplicating tests in module_test.go
@@ -389,6 +411,14 @@ func TestNewModuleBuilder_Compile_Errors(t *testing.T) {
 			}),
 			expectedErr: "memory[memory] capacity 1 pages (64 Ki) less than minimum 2 pages (128 Ki)",
 		},
+		{
+			name: "shared memory without threads",
+			input: func(rt Runtime) ModuleBuilder {
+				return rt.NewModuleBuilder("").ExportSharedMemory("memory", 1, 2)
+			},
+			config:      NewCompileConfig(),
+			expectedErr: "memory[memory] shared memory invalid as feature \"threads\" is disabled",
+		},
 	}
 
 	for _, tt := range tests {
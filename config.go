@@ -25,6 +25,61 @@ import (
 // Note: RuntimeConfig is immutable. Each WithXXX function returns a new instance including the corresponding change.
 type RuntimeConfig interface {
 
+	// WithCompilationCache persists the native code compiled from each module in the given directory, so that later
+	// processes can skip compilation. This defaults to "", which disables the cache.
+	//
+	// Entries are keyed by the module's content, and versioned by the wazero version, the platform, the CPU features
+	// and the settings which affect compilation, such as WithFuelMetering. Entries which are stale or corrupted, such
+	// as by a partial write, are detected by a checksum, and replaced by compiling again. Entries which are tampered
+	// with are detected by an HMAC-SHA256, keyed by a secret private to the current user, and also replaced.
+	//
+	// Ex. To re-use compiled code across process restarts:
+	//	rConfig = wazero.NewRuntimeConfig().WithCompilationCache(filepath.Join(os.TempDir(), "wazero"))
+	//
+	// Notes:
+	//	* This has no effect in NewRuntimeConfigInterpreter, which doesn't compile to native code.
+	//	* The directory is created on demand. It and its entries must be owned by the current user and not writable by
+	//	  others, or Runtime.CompileModule fails, as its contents are executed.
+	//	* The HMAC key is created on demand in the "wazero" directory of os.UserConfigDir, readable only by the current
+	//	  user. Deleting it invalidates all entries.
+	//	* Errors reading or writing the directory are returned by Runtime.CompileModule.
+	//	* Only functions whose native code can be relocated are cached. Ex. some br_table instructions on arm64.
+	WithCompilationCache(dir string) RuntimeConfig
+
+	// WithCoverage makes functions count how many times each of their basic blocks ran, to report with
+	// ModuleCoverage. This defaults to false as counting has a performance cost.
+	//
+	// Ex. To write an LCOV report of the code run by a module:
+	//	r := wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfig().WithCoverage(true))
+	//	compiled, _ := r.CompileModule(ctx, wasm, wazero.NewCompileConfig())
+	//	// ... instantiate and call the module
+	//	coverage, _ := wazero.ModuleCoverage(compiled)
+	//	_ = coverage.WriteLCOV(f)
+	//
+	// Note: When enabled, compiled code isn't persisted by WithCompilationCache, as it counts in memory of this
+	// process.
+	WithCoverage(bool) RuntimeConfig
+
+	// WithEnsureTermination makes a running function check whether its context.Context is done (cancelled or past
+	// its deadline). This defaults to false as checking has a small performance cost.
+	//
+	// When enabled, Wasm code periodically checks, at function entries and loop headers, whether the context passed to
+	// api.Function Call is done. If so, the call returns a sys.ContextDoneError wrapping context.Context Err. Unlike sys.ExitError, the module is
+	// not closed, so it can be called again with a fresh context.
+	//
+	// Ex. To terminate a function that runs longer than a second:
+	//	r := wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfig().WithEnsureTermination(true))
+	//	// ... instantiate module
+	//	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	//	defer cancel()
+	//	_, err := module.ExportedFunction("infinite_loop").Call(ctx)
+	//	if errors.Is(err, context.DeadlineExceeded) {
+	//		// ...
+	//	}
+	//
+	// Note: This doesn't interrupt host functions, which should check the context on their own.
+	WithEnsureTermination(bool) RuntimeConfig
+
 	// WithFeatureBulkMemoryOperations adds instructions modify ranges of memory or table entries
 	// ("bulk-memory-operations"). This defaults to false as the feature was not finished in WebAssembly 1.0.
 	//
@@ -116,6 +171,100 @@ type RuntimeConfig interface {
 	// See https://github.com/WebAssembly/spec/blob/main/proposals/simd/SIMD.md
 	WithFeatureSIMD(bool) RuntimeConfig
 
+	// WithFeatureThreads enables shared memories and atomic memory instructions ("threads"). This defaults to false
+	// as the feature was not in WebAssembly 1.0.
+	//
+	// Wazero doesn't start threads: the host runs functions concurrently in its own goroutines, usually of separate
+	// instances of a module importing the same shared memory. See ModuleBuilder.ExportSharedMemory
+	//
+	// See https://github.com/WebAssembly/threads/blob/main/proposals/threads/Overview.md
+	WithFeatureThreads(bool) RuntimeConfig
+
+	// WithFeatureTailCall enables the tail call instructions "return_call" and "return_call_indirect" ("tail-call").
+	// This defaults to false as the feature was not in WebAssembly 1.0.
+	//
+	// A tail call replaces the frame of the function making it, so recursion in tail position, common in code compiled
+	// from functional languages, doesn't grow the call stack or fail with wasmruntime.ErrRuntimeCallStackOverflow.
+	// This holds for functions with an experimental.FunctionListener, which is notified of the end of the function
+	// making the tail call before the called function begins.
+	//
+	// See https://github.com/WebAssembly/tail-call/blob/main/proposals/tail-call/Overview.md
+	WithFeatureTailCall(bool) RuntimeConfig
+
+	// WithFeatureExceptionHandling enables the tag section and the instructions "try", "catch", "catch_all", "throw",
+	// "rethrow" and "delegate" ("exception-handling"). This defaults to false as the feature was not in WebAssembly 1.0.
+	//
+	// Exceptions are classified by tags, which modules can import and export. A host function throws an exception into
+	// its caller by panicking with a sys.Exception, such as of a tag from api.Module ExportedTag. An exception which
+	// isn't caught is returned by api.Function Call as an error wrapping its sys.Exception.
+	//
+	// See https://github.com/WebAssembly/exception-handling/blob/main/proposals/exception-handling/Exceptions.md
+	WithFeatureExceptionHandling(bool) RuntimeConfig
+
+	// WithFeatureMultiMemory allows modules to define and import more than one memory, and adds a memory index to the
+	// immediates of memory instructions and data segments ("multi-memory"). This defaults to false as the feature was
+	// not in WebAssembly 1.0.
+	//
+	// api.Module Memory returns the memory at index zero. Other memories are reached with api.Module ExportedMemory.
+	//
+	// See https://github.com/WebAssembly/multi-memory/blob/main/proposals/multi-memory/Overview.md
+	WithFeatureMultiMemory(bool) RuntimeConfig
+
+	// WithFeatureMemory64 allows memories with 64-bit indices, which can be larger than 4GiB and are addressed with
+	// i64 values ("memory64"). This defaults to false as the feature was not in WebAssembly 1.0.
+	//
+	// The api.Memory of such a memory also implements api.Memory64, whose offsets can exceed uint32.
+	//
+	// See https://github.com/WebAssembly/memory64/blob/main/proposals/memory64/Overview.md
+	WithFeatureMemory64(bool) RuntimeConfig
+
+	// WithFeatureExtendedConst allows constant expressions, such as the initial value of a global or the offset of a
+	// data segment, to combine constants and imported globals with i32 and i64 add, sub and mul ("extended-const").
+	// This defaults to false as the feature was not in WebAssembly 1.0.
+	//
+	// For example, compilers emit `global.get $__memory_base i32.const 16 i32.add` as the offset of data segments in
+	// position-independent code.
+	//
+	// See https://github.com/WebAssembly/extended-const/blob/main/proposals/extended-const/Overview.md
+	WithFeatureExtendedConst(bool) RuntimeConfig
+
+	// WithFeatureRelaxedSIMD enables vector instructions whose results the specification leaves to the implementation,
+	// such as `f32x4.relaxed_madd`, in exchange for speed ("relaxed-simd"). This defaults to false as the feature was
+	// not in WebAssembly 1.0, and requires WithFeatureSIMD.
+	//
+	// Note: wazero fixes the result of each instruction, so it is the same regardless of the engine or platform.
+	//
+	// See https://github.com/WebAssembly/relaxed-simd/blob/main/proposals/relaxed-simd/Overview.md
+	WithFeatureRelaxedSIMD(bool) RuntimeConfig
+
+	// WithFuelMetering makes functions consume fuel as they run. This defaults to false as metering has a performance
+	// cost.
+	//
+	// When enabled, the amount of fuel available to a call is set with experimental.WithFuel, and the call fails with
+	// an error wrapping experimental.ErrOutOfFuel when it runs out. A call without fuel in its context is unlimited.
+	//
+	// Ex. To limit a call to 10000 units of fuel:
+	//	r := wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfig().WithFuelMetering(true))
+	//	// ... instantiate module
+	//	ctx, meter := experimental.WithFuel(context.Background(), 10000)
+	//	_, err := module.ExportedFunction("run").Call(ctx)
+	WithFuelMetering(bool) RuntimeConfig
+
+	// WithMemoryLimitPages limits the pages (65536 bytes per page) of any memory defined by a module instantiated by
+	// the Runtime, regardless of the maximum the module defined. This defaults to 4294967295 pages, which doesn't
+	// limit any memory: memories are at most 65536 pages (4GiB) in WebAssembly 1.0 (20191205), unless they have 64-bit
+	// indices (WithFeatureMemory64).
+	//
+	// Instantiating a module errs if the minimum pages of its memory are over the limit. Growing memory past the limit
+	// fails, so `memory.grow` returns -1 and api.Memory Grow returns false. See ModuleConfig.WithMemoryLimitExceeded
+	// to be notified when that happens.
+	//
+	// Ex. To limit each memory to 16MiB:
+	//	rConfig = wazero.NewRuntimeConfig().WithMemoryLimitPages(256)
+	//
+	// Note: A lower limit set with ModuleConfig.WithMemoryLimitPages takes precedence.
+	WithMemoryLimitPages(uint32) RuntimeConfig
+
 	// WithWasmCore1 enables features included in the WebAssembly Core Specification 1.0. Selecting this
 	// overwrites any currently accumulated features with only those included in this W3C recommendation.
 	//
@@ -147,13 +296,19 @@ func NewRuntimeConfig() RuntimeConfig {
 }
 
 type runtimeConfig struct {
-	enabledFeatures wasm.Features
-	newEngine       func(wasm.Features) wasm.Engine
+	enabledFeatures     wasm.Features
+	ensureTermination   bool
+	meterFuel           bool
+	coverage            bool
+	memoryLimitPages    uint32
+	compilationCacheDir string
+	newEngine           func(enabledFeatures wasm.Features, config wasm.EngineConfig) wasm.Engine
 }
 
 // engineLessConfig helps avoid copy/pasting the wrong defaults.
 var engineLessConfig = &runtimeConfig{
-	enabledFeatures: wasm.Features20191205,
+	enabledFeatures:  wasm.Features20191205,
+	memoryLimitPages: wasm.Memory64LimitPages,
 }
 
 // NewRuntimeConfigCompiler compiles WebAssembly modules into
@@ -183,6 +338,27 @@ func NewRuntimeConfigInterpreter() RuntimeConfig {
 	return &ret
 }
 
+// WithCompilationCache implements RuntimeConfig.WithCompilationCache
+func (c *runtimeConfig) WithCompilationCache(dir string) RuntimeConfig {
+	ret := *c // copy
+	ret.compilationCacheDir = dir
+	return &ret
+}
+
+// WithCoverage implements RuntimeConfig.WithCoverage
+func (c *runtimeConfig) WithCoverage(enabled bool) RuntimeConfig {
+	ret := *c // copy
+	ret.coverage = enabled
+	return &ret
+}
+
+// WithEnsureTermination implements RuntimeConfig.WithEnsureTermination
+func (c *runtimeConfig) WithEnsureTermination(enabled bool) RuntimeConfig {
+	ret := *c // copy
+	ret.ensureTermination = enabled
+	return &ret
+}
+
 // WithFeatureBulkMemoryOperations implements RuntimeConfig.WithFeatureBulkMemoryOperations
 func (c *runtimeConfig) WithFeatureBulkMemoryOperations(enabled bool) RuntimeConfig {
 	ret := *c // copy
@@ -236,6 +412,69 @@ func (c *runtimeConfig) WithFeatureSIMD(enabled bool) RuntimeConfig {
 	return &ret
 }
 
+// WithFeatureThreads implements RuntimeConfig.WithFeatureThreads
+func (c *runtimeConfig) WithFeatureThreads(enabled bool) RuntimeConfig {
+	ret := *c // copy
+	ret.enabledFeatures = ret.enabledFeatures.Set(wasm.FeatureThreads, enabled)
+	return &ret
+}
+
+// WithFeatureTailCall implements RuntimeConfig.WithFeatureTailCall
+func (c *runtimeConfig) WithFeatureTailCall(enabled bool) RuntimeConfig {
+	ret := *c // copy
+	ret.enabledFeatures = ret.enabledFeatures.Set(wasm.FeatureTailCall, enabled)
+	return &ret
+}
+
+// WithFeatureExceptionHandling implements RuntimeConfig.WithFeatureExceptionHandling
+func (c *runtimeConfig) WithFeatureExceptionHandling(enabled bool) RuntimeConfig {
+	ret := *c // copy
+	ret.enabledFeatures = ret.enabledFeatures.Set(wasm.FeatureExceptionHandling, enabled)
+	return &ret
+}
+
+// WithFeatureMultiMemory implements RuntimeConfig.WithFeatureMultiMemory
+func (c *runtimeConfig) WithFeatureMultiMemory(enabled bool) RuntimeConfig {
+	ret := *c // copy
+	ret.enabledFeatures = ret.enabledFeatures.Set(wasm.FeatureMultiMemory, enabled)
+	return &ret
+}
+
+// WithFeatureMemory64 implements RuntimeConfig.WithFeatureMemory64
+func (c *runtimeConfig) WithFeatureMemory64(enabled bool) RuntimeConfig {
+	ret := *c // copy
+	ret.enabledFeatures = ret.enabledFeatures.Set(wasm.FeatureMemory64, enabled)
+	return &ret
+}
+
+// WithFeatureExtendedConst implements RuntimeConfig.WithFeatureExtendedConst
+func (c *runtimeConfig) WithFeatureExtendedConst(enabled bool) RuntimeConfig {
+	ret := *c // copy
+	ret.enabledFeatures = ret.enabledFeatures.Set(wasm.FeatureExtendedConst, enabled)
+	return &ret
+}
+
+// WithFeatureRelaxedSIMD implements RuntimeConfig.WithFeatureRelaxedSIMD
+func (c *runtimeConfig) WithFeatureRelaxedSIMD(enabled bool) RuntimeConfig {
+	ret := *c // copy
+	ret.enabledFeatures = ret.enabledFeatures.Set(wasm.FeatureRelaxedSIMD, enabled)
+	return &ret
+}
+
+// WithFuelMetering implements RuntimeConfig.WithFuelMetering
+func (c *runtimeConfig) WithFuelMetering(enabled bool) RuntimeConfig {
+	ret := *c // copy
+	ret.meterFuel = enabled
+	return &ret
+}
+
+// WithMemoryLimitPages implements RuntimeConfig.WithMemoryLimitPages
+func (c *runtimeConfig) WithMemoryLimitPages(memoryLimitPages uint32) RuntimeConfig {
+	ret := *c // copy
+	ret.memoryLimitPages = memoryLimitPages
+	return &ret
+}
+
 // WithWasmCore1 implements RuntimeConfig.WithWasmCore1
 func (c *runtimeConfig) WithWasmCore1() RuntimeConfig {
 	ret := *c // copy
@@ -258,6 +497,33 @@ func (c *runtimeConfig) WithWasmCore2() RuntimeConfig {
 //
 // Note: Closing the wazero.Runtime closes any CompiledModule it compiled.
 type CompiledModule interface {
+	// ImportedFunctions returns all the imported functions (api.FunctionDefinition) in this module or nil if there are
+	// none. These are in the order of the function index namespace, which begins with imports.
+	//
+	// Ex. To list the host functions a module needs:
+	//	for _, f := range compiled.ImportedFunctions() {
+	//		moduleName, name, _ := f.Import()
+	//		fmt.Println(moduleName, name, f.ParamTypes(), f.ResultTypes())
+	//	}
+	ImportedFunctions() []api.FunctionDefinition
+
+	// ExportedFunctions returns all the exported functions (api.FunctionDefinition) in this module keyed on export
+	// name.
+	//
+	// Ex. To check whether a module exports `handle` with the signature (i32, i32) -> i32:
+	//	handle, ok := compiled.ExportedFunctions()["handle"]
+	//	ok = ok && reflect.DeepEqual(handle.ParamTypes(), []api.ValueType{api.ValueTypeI32, api.ValueTypeI32}) &&
+	//		reflect.DeepEqual(handle.ResultTypes(), []api.ValueType{api.ValueTypeI32})
+	ExportedFunctions() map[string]api.FunctionDefinition
+
+	// ExportedMemories returns all the exported memories (api.MemoryDefinition) in this module keyed on export name.
+	//
+	// Note: As of WebAssembly Core Specification 2.0, there can be at most one memory.
+	ExportedMemories() map[string]api.MemoryDefinition
+
+	// ExportedGlobals returns all the exported globals (api.GlobalDefinition) in this module keyed on export name.
+	ExportedGlobals() map[string]api.GlobalDefinition
+
 	// Close releases all the allocated resources for this CompiledModule.
 	//
 	// Note: It is safe to call Close while having outstanding calls from an api.Module instantiated from this.
@@ -273,6 +539,26 @@ type compiledModule struct {
 	closeWithModule bool
 }
 
+// ImportedFunctions implements CompiledModule.ImportedFunctions
+func (c *compiledModule) ImportedFunctions() []api.FunctionDefinition {
+	return c.module.ImportedFunctions()
+}
+
+// ExportedFunctions implements CompiledModule.ExportedFunctions
+func (c *compiledModule) ExportedFunctions() map[string]api.FunctionDefinition {
+	return c.module.ExportedFunctions()
+}
+
+// ExportedMemories implements CompiledModule.ExportedMemories
+func (c *compiledModule) ExportedMemories() map[string]api.MemoryDefinition {
+	return c.module.ExportedMemories()
+}
+
+// ExportedGlobals implements CompiledModule.ExportedGlobals
+func (c *compiledModule) ExportedGlobals() map[string]api.GlobalDefinition {
+	return c.module.ExportedGlobals()
+}
+
 // Close implements CompiledModule.Close
 func (c *compiledModule) Close(_ context.Context) error {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
@@ -398,6 +684,28 @@ type ModuleConfig interface {
 	//
 	WithFS(fs.FS) ModuleConfig
 
+	// WithMemoryLimitExceeded sets a function notified when the memory defined by the module would grow past its limit.
+	// No default. A nil function is invalid and ignored.
+	//
+	// The function can return an error to make the `memory.grow` instruction fail with it, for example to stop a
+	// module which keeps trying to allocate.
+	//
+	// See WithMemoryLimitPages and api.MemoryLimitExceeded
+	WithMemoryLimitExceeded(api.MemoryLimitExceeded) ModuleConfig
+
+	// WithMemoryLimitPages limits the pages (65536 bytes per page) of the memory defined by the module, regardless of
+	// the maximum the module defined. Defaults to the limit set with RuntimeConfig.WithMemoryLimitPages.
+	//
+	// Instantiating the module errs if the minimum pages of its memory are over the limit. Growing memory past the
+	// limit fails, so `memory.grow` returns -1 and api.Memory Grow returns false.
+	//
+	// Ex. To limit the memory of this instance to 1MiB:
+	//	module, _ := r.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().WithMemoryLimitPages(16))
+	//
+	// Note: The lower of this and RuntimeConfig.WithMemoryLimitPages applies. This doesn't limit an imported memory,
+	// as that is defined by another module.
+	WithMemoryLimitPages(uint32) ModuleConfig
+
 	// WithName configures the module name. Defaults to what was decoded or overridden via CompileConfig.WithModuleName.
 	WithName(string) ModuleConfig
 
@@ -482,6 +790,28 @@ type ModuleConfig interface {
 	// See WithNanotime
 	WithSysNanotime() ModuleConfig
 
+	// WithNanosleep configures how to pause the current goroutine for at
+	// least the configured nanoseconds. Defaults to return immediately.
+	//
+	// This is most commonly used by the function "poll_oneoff" in
+	// "wasi_snapshot_preview1", which implements sleep in guests.
+	//
+	// Ex. To sleep according to a fake clock in tests:
+	//	moduleConfig = moduleConfig.
+	//		WithNanosleep(func(ctx context.Context, ns int64) {
+	//			clock.advance(ns)
+	//		})
+	//
+	// Note: This does not default to time.Sleep as that violates sandboxing.
+	// Use WithSysNanosleep for a usable implementation.
+	WithNanosleep(sys.Nanosleep) ModuleConfig
+
+	// WithSysNanosleep uses time.Timer for sys.Nanosleep, which returns early
+	// when the context.Context of the call is done.
+	//
+	// See WithNanosleep
+	WithSysNanosleep() ModuleConfig
+
 	// WithRandSource configures a source of random bytes. Defaults to crypto/rand.Reader.
 	//
 	// This reader is most commonly used by the functions like "random_get" in "wasi_snapshot_preview1" or "seed" in
@@ -516,12 +846,15 @@ type moduleConfig struct {
 	walltimeResolution sys.ClockResolution
 	nanotimeTime       *sys.Nanotime
 	nanotimeResolution sys.ClockResolution
+	nanosleep          *sys.Nanosleep
 	args               []string
 	// environ is pair-indexed to retain order similar to os.Environ.
 	environ []string
 	// environKeys allow overwriting of existing values.
 	environKeys map[string]int
 	fs          *internalsys.FSConfig
+	// memoryLimit is the limit of the memory defined by the module.
+	memoryLimit wasm.MemoryLimit
 }
 
 // NewModuleConfig returns a ModuleConfig that can be used for configuring module instantiation.
@@ -530,7 +863,8 @@ func NewModuleConfig() ModuleConfig {
 		startFunctions: []string{"_start"},
 		environKeys:    map[string]int{},
 
-		fs: internalsys.NewFSConfig(),
+		fs:          internalsys.NewFSConfig(),
+		memoryLimit: wasm.MemoryLimit{Pages: wasm.Memory64LimitPages},
 	}
 }
 
@@ -561,6 +895,23 @@ func (c *moduleConfig) WithFS(fs fs.FS) ModuleConfig {
 	return &ret
 }
 
+// WithMemoryLimitExceeded implements ModuleConfig.WithMemoryLimitExceeded
+func (c *moduleConfig) WithMemoryLimitExceeded(onExceeded api.MemoryLimitExceeded) ModuleConfig {
+	if onExceeded == nil {
+		return c
+	}
+	ret := *c // copy
+	ret.memoryLimit.OnExceeded = onExceeded
+	return &ret
+}
+
+// WithMemoryLimitPages implements ModuleConfig.WithMemoryLimitPages
+func (c *moduleConfig) WithMemoryLimitPages(memoryLimitPages uint32) ModuleConfig {
+	ret := *c // copy
+	ret.memoryLimit.Pages = memoryLimitPages
+	return &ret
+}
+
 // WithName implements ModuleConfig.WithName
 func (c *moduleConfig) WithName(name string) ModuleConfig {
 	ret := *c // copy
@@ -626,6 +977,18 @@ func (c *moduleConfig) WithSysNanotime() ModuleConfig {
 	return c.WithNanotime(platform.Nanotime, sys.ClockResolution(1))
 }
 
+// WithNanosleep implements ModuleConfig.WithNanosleep
+func (c *moduleConfig) WithNanosleep(nanosleep sys.Nanosleep) ModuleConfig {
+	ret := *c // copy
+	ret.nanosleep = &nanosleep
+	return &ret
+}
+
+// WithSysNanosleep implements ModuleConfig.WithSysNanosleep
+func (c *moduleConfig) WithSysNanosleep() ModuleConfig {
+	return c.WithNanosleep(platform.Nanosleep)
+}
+
 // WithRandSource implements ModuleConfig.WithRandSource
 func (c *moduleConfig) WithRandSource(source io.Reader) ModuleConfig {
 	ret := *c // copy
@@ -674,6 +1037,7 @@ func (c *moduleConfig) toSysContext() (sysCtx *internalsys.Context, err error) {
 		c.randSource,
 		c.walltimeTime, c.walltimeResolution,
 		c.nanotimeTime, c.nanotimeResolution,
+		c.nanosleep,
 		preopens,
 	)
 }
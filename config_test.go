@@ -7,6 +7,7 @@ import (
 	"reflect"
 	"testing"
 	"testing/fstest"
+	"time"
 
 	"github.com/tetratelabs/wazero/api"
 	internalsys "github.com/tetratelabs/wazero/internal/sys"
@@ -21,6 +22,51 @@ func TestRuntimeConfig(t *testing.T) {
 		with     func(RuntimeConfig) RuntimeConfig
 		expected RuntimeConfig
 	}{
+		{
+			name: "compilation-cache",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithCompilationCache("/tmp/wazero")
+			},
+			expected: &runtimeConfig{
+				compilationCacheDir: "/tmp/wazero",
+			},
+		},
+		{
+			name: "coverage",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithCoverage(true)
+			},
+			expected: &runtimeConfig{
+				coverage: true,
+			},
+		},
+		{
+			name: "ensure-termination",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithEnsureTermination(true)
+			},
+			expected: &runtimeConfig{
+				ensureTermination: true,
+			},
+		},
+		{
+			name: "fuel-metering",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithFuelMetering(true)
+			},
+			expected: &runtimeConfig{
+				meterFuel: true,
+			},
+		},
+		{
+			name: "memory-limit-pages",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithMemoryLimitPages(16)
+			},
+			expected: &runtimeConfig{
+				memoryLimitPages: 16,
+			},
+		},
 		{
 			name: "bulk-memory-operations",
 			with: func(c RuntimeConfig) RuntimeConfig {
@@ -102,6 +148,69 @@ func TestRuntimeConfig(t *testing.T) {
 				enabledFeatures: wasm.FeatureSIMD,
 			},
 		},
+		{
+			name: "threads",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithFeatureThreads(true)
+			},
+			expected: &runtimeConfig{
+				enabledFeatures: wasm.FeatureThreads,
+			},
+		},
+		{
+			name: "tail-call",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithFeatureTailCall(true)
+			},
+			expected: &runtimeConfig{
+				enabledFeatures: wasm.FeatureTailCall,
+			},
+		},
+		{
+			name: "exception-handling",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithFeatureExceptionHandling(true)
+			},
+			expected: &runtimeConfig{
+				enabledFeatures: wasm.FeatureExceptionHandling,
+			},
+		},
+		{
+			name: "multi-memory",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithFeatureMultiMemory(true)
+			},
+			expected: &runtimeConfig{
+				enabledFeatures: wasm.FeatureMultiMemory,
+			},
+		},
+		{
+			name: "memory64",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithFeatureMemory64(true)
+			},
+			expected: &runtimeConfig{
+				enabledFeatures: wasm.FeatureMemory64,
+			},
+		},
+		{
+			name: "extended-const",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithFeatureExtendedConst(true)
+			},
+			expected: &runtimeConfig{
+				enabledFeatures: wasm.FeatureExtendedConst,
+			},
+		},
+		{
+			name: "relaxed-simd",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithFeatureRelaxedSIMD(true)
+			},
+			expected: &runtimeConfig{
+				enabledFeatures: wasm.FeatureRelaxedSIMD,
+			},
+		},
 	}
 	for _, tt := range tests {
 		tc := tt
@@ -279,6 +388,22 @@ func TestModuleConfig(t *testing.T) {
 				name: "wa0",
 			},
 		},
+		{
+			name: "WithMemoryLimitPages",
+			with: func(c ModuleConfig) ModuleConfig {
+				return c.WithMemoryLimitPages(16)
+			},
+			expected: &moduleConfig{
+				memoryLimit: wasm.MemoryLimit{Pages: 16},
+			},
+		},
+		{
+			name: "WithMemoryLimitExceeded nil",
+			with: func(c ModuleConfig) ModuleConfig {
+				return c.WithMemoryLimitExceeded(nil)
+			},
+			expected: &moduleConfig{},
+		},
 	}
 	for _, tt := range tests {
 		tc := tt
@@ -315,6 +440,7 @@ func TestModuleConfig_toSysContext(t *testing.T) {
 				nil,            // randSource
 				nil, 0,         // walltime, walltimeResolution
 				nil, 0, // nanotime, nanotimeResolution
+				nil, // nanosleep
 				nil, // openedFiles
 			),
 		},
@@ -331,6 +457,7 @@ func TestModuleConfig_toSysContext(t *testing.T) {
 				nil,                 // randSource
 				nil, 0,              // walltime, walltimeResolution
 				nil, 0, // nanotime, nanotimeResolution
+				nil, // nanosleep
 				nil, // openedFiles
 			),
 		},
@@ -347,6 +474,7 @@ func TestModuleConfig_toSysContext(t *testing.T) {
 				nil,                // randSource
 				nil, 0,             // walltime, walltimeResolution
 				nil, 0, // nanotime, nanotimeResolution
+				nil, // nanosleep
 				nil, // openedFiles
 			),
 		},
@@ -363,6 +491,7 @@ func TestModuleConfig_toSysContext(t *testing.T) {
 				nil,                 // randSource
 				nil, 0,              // walltime, walltimeResolution
 				nil, 0, // nanotime, nanotimeResolution
+				nil, // nanosleep
 				nil, // openedFiles
 			),
 		},
@@ -379,6 +508,7 @@ func TestModuleConfig_toSysContext(t *testing.T) {
 				nil,             // randSource
 				nil, 0,          // walltime, walltimeResolution
 				nil, 0, // nanotime, nanotimeResolution
+				nil, // nanosleep
 				nil, // openedFiles
 			),
 		},
@@ -395,6 +525,7 @@ func TestModuleConfig_toSysContext(t *testing.T) {
 				nil,            // randSource
 				nil, 0,         // walltime, walltimeResolution
 				nil, 0, // nanotime, nanotimeResolution
+				nil, // nanosleep
 				nil, // openedFiles
 			),
 		},
@@ -411,6 +542,7 @@ func TestModuleConfig_toSysContext(t *testing.T) {
 				nil,                     // randSource
 				nil, 0,                  // walltime, walltimeResolution
 				nil, 0, // nanotime, nanotimeResolution
+				nil, // nanosleep
 				nil, // openedFiles
 			),
 		},
@@ -427,6 +559,7 @@ func TestModuleConfig_toSysContext(t *testing.T) {
 				nil,                      // randSource
 				nil, 0,                   // walltime, walltimeResolution
 				nil, 0, // nanotime, nanotimeResolution
+				nil, // nanosleep
 				nil, // openedFiles
 			),
 		},
@@ -443,6 +576,7 @@ func TestModuleConfig_toSysContext(t *testing.T) {
 				nil,                     // randSource
 				nil, 0,                  // walltime, walltimeResolution
 				nil, 0, // nanotime, nanotimeResolution
+				nil, // nanosleep
 				nil, // openedFiles
 			),
 		},
@@ -459,6 +593,7 @@ func TestModuleConfig_toSysContext(t *testing.T) {
 				nil,            // randSource
 				nil, 0,         // walltime, walltimeResolution
 				nil, 0, // nanotime, nanotimeResolution
+				nil, // nanosleep
 				map[uint32]*internalsys.FileEntry{ // openedFiles
 					3: {Path: "/", FS: testFS},
 					4: {Path: ".", FS: testFS},
@@ -478,6 +613,7 @@ func TestModuleConfig_toSysContext(t *testing.T) {
 				nil,            // randSource
 				nil, 0,         // walltime, walltimeResolution
 				nil, 0, // nanotime, nanotimeResolution
+				nil, // nanosleep
 				map[uint32]*internalsys.FileEntry{ // openedFiles
 					3: {Path: "/", FS: testFS2},
 					4: {Path: ".", FS: testFS2},
@@ -497,6 +633,7 @@ func TestModuleConfig_toSysContext(t *testing.T) {
 				nil,            // randSource
 				nil, 0,         // walltime, walltimeResolution
 				nil, 0, // nanotime, nanotimeResolution
+				nil, // nanosleep
 				map[uint32]*internalsys.FileEntry{ // openedFiles
 					3: {Path: ".", FS: testFS},
 				},
@@ -515,6 +652,7 @@ func TestModuleConfig_toSysContext(t *testing.T) {
 				nil,            // randSource
 				nil, 0,         // walltime, walltimeResolution
 				nil, 0, // nanotime, nanotimeResolution
+				nil, // nanosleep
 				map[uint32]*internalsys.FileEntry{ // openedFiles
 					3: {Path: "/", FS: testFS},
 					4: {Path: ".", FS: testFS2},
@@ -534,6 +672,7 @@ func TestModuleConfig_toSysContext(t *testing.T) {
 				nil,            // randSource
 				nil, 0,         // walltime, walltimeResolution
 				nil, 0, // nanotime, nanotimeResolution
+				nil, // nanosleep
 				map[uint32]*internalsys.FileEntry{ // openedFiles
 					3: {Path: ".", FS: testFS},
 					4: {Path: "/", FS: testFS2},
@@ -697,6 +836,30 @@ func TestModuleConfig_toSysContext_WithNanotime(t *testing.T) {
 	})
 }
 
+// TestModuleConfig_toSysContext_WithNanosleep has to test differently because
+// we can't compare function pointers when functions are passed by value.
+func TestModuleConfig_toSysContext_WithNanosleep(t *testing.T) {
+	var slept []int64
+	sysCtx, err := NewModuleConfig().
+		WithNanosleep(func(ctx context.Context, ns int64) {
+			require.Equal(t, testCtx, ctx)
+			slept = append(slept, ns)
+		}).(*moduleConfig).toSysContext()
+	require.NoError(t, err)
+	sysCtx.Nanosleep(testCtx, 2)
+	sysCtx.Nanosleep(testCtx, 1)
+	require.Equal(t, []int64{2, 1}, slept)
+
+	t.Run("WithSysNanosleep returns when the context is done", func(t *testing.T) {
+		sysCtx, err := NewModuleConfig().WithSysNanosleep().(*moduleConfig).toSysContext()
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(testCtx)
+		cancel()
+		sysCtx.Nanosleep(ctx, time.Hour.Nanoseconds()) // returns immediately
+	})
+}
+
 func TestModuleConfig_toSysContext_Errors(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -759,6 +922,7 @@ func requireSysContext(
 	randSource io.Reader,
 	walltime *sys.Walltime, walltimeResolution sys.ClockResolution,
 	nanotime *sys.Nanotime, nanotimeResolution sys.ClockResolution,
+	nanosleep *sys.Nanosleep,
 	openedFiles map[uint32]*internalsys.FileEntry,
 ) *internalsys.Context {
 	sysCtx, err := internalsys.NewContext(
@@ -771,6 +935,7 @@ func requireSysContext(
 		randSource,
 		walltime, walltimeResolution,
 		nanotime, nanotimeResolution,
+		nanosleep,
 		openedFiles,
 	)
 	require.NoError(t, err)
@@ -0,0 +1,244 @@
+package wazero
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync/atomic"
+
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasmdebug"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// Coverage reports which code of a module ran, as counted since it was compiled with RuntimeConfig.WithCoverage.
+//
+// Counts include calls made by all instances of the module. Code is counted by basic block: a sequence of
+// instructions which runs from its beginning, up to a branch or the beginning of the next block. As a block is
+// counted when it begins, its count includes runs which trapped before its end.
+type Coverage interface {
+	// Functions returns the coverage of each function defined in the module, in the order of the function index
+	// namespace. Functions with a zero FunctionCoverage.Calls never ran.
+	Functions() []FunctionCoverage
+
+	// Lines returns the coverage of each source line of the module, sorted by file and line, or nil if the module has
+	// no DWARF debug information. A line's count is the maximum of the basic blocks which include code of it.
+	Lines() []LineCoverage
+
+	// WriteLCOV writes the coverage in the LCOV tracefile format, readable by tools such as `genhtml`.
+	//
+	// Each function is listed in the source file and line where it begins. Functions without DWARF debug information
+	// are listed at line zero of a source file named like the module in its name section.
+	//
+	// See https://github.com/linux-test-project/lcov/blob/master/man/geninfo.1
+	WriteLCOV(w io.Writer) error
+}
+
+// FunctionCoverage is the coverage of a function defined in a module.
+type FunctionCoverage struct {
+	// Index is the index of the function in the function index namespace, which begins with imported functions.
+	Index uint32
+
+	// Name is the function name as it is in stack traces, using the name section when present. Ex. "env.main" or
+	// "env.[7]"
+	Name string
+
+	// Calls is the count of calls of the function. This is the count of its first block.
+	Calls uint64
+
+	// Blocks are the basic blocks of the function in order of their offsets.
+	Blocks []BlockCoverage
+}
+
+// BlockCoverage is the coverage of a basic block in a function.
+type BlockCoverage struct {
+	// Offset is the offset of the first instruction of the block, in the function body.
+	//
+	// Note: This is the same offset as sys.StackFrame Offset.
+	Offset uint64
+
+	// Count is the count of runs of the block.
+	Count uint64
+}
+
+// LineCoverage is the coverage of a source line of a module, from its DWARF debug information.
+type LineCoverage struct {
+	// File is the name of the source file, as it is in sys.SourceLocation.
+	File string
+
+	// Line is the line number in the file, beginning at one.
+	Line uint64
+
+	// Count is the count of runs of the line.
+	Count uint64
+}
+
+// ModuleCoverage returns the coverage of the code run by the compiled module so far. This returns an error if the
+// module was compiled without RuntimeConfig.WithCoverage, closed, or isn't a Wasm module, such as one built with
+// ModuleBuilder.
+//
+// Note: This is safe to call while functions of the module run, but counts of functions running concurrently may be
+// behind.
+func ModuleCoverage(compiled CompiledModule) (Coverage, error) {
+	code, ok := compiled.(*compiledModule)
+	if !ok {
+		return nil, fmt.Errorf("unsupported wazero.CompiledModule implementation: %T", compiled)
+	}
+	m := code.module
+	if m.IsHostModule() {
+		return nil, errors.New("coverage of host modules isn't supported")
+	}
+	functions := code.compiledEngine.Coverage(m)
+	if functions == nil {
+		return nil, errors.New("coverage is disabled or the module is closed: see RuntimeConfig.WithCoverage")
+	}
+
+	ret := &coverage{}
+	for i, d := range m.DefinedFunctions() {
+		f := FunctionCoverage{Index: d.Index(), Name: wasmdebug.FuncName(d.ModuleName(), d.Name(), d.Index())}
+		for b, offset := range functions[i].BlockOffsets {
+			f.Blocks = append(f.Blocks, BlockCoverage{Offset: offset, Count: atomic.LoadUint64(&functions[i].Counts[b])})
+		}
+		if len(f.Blocks) > 0 {
+			f.Calls = f.Blocks[0].Count
+		}
+		ret.functions = append(ret.functions, f)
+	}
+	ret.addLines(m)
+	if m.NameSection != nil {
+		ret.moduleName = m.NameSection.ModuleName
+	}
+	return ret, nil
+}
+
+// coverage implements Coverage
+type coverage struct {
+	moduleName string
+	functions  []FunctionCoverage
+	lines      []LineCoverage
+
+	// functionLines are index-correlated with functions. Each is the first source line of the function, or the zero
+	// value if unknown.
+	functionLines []sys.SourceLocation
+}
+
+// Functions implements Coverage.Functions
+func (c *coverage) Functions() []FunctionCoverage {
+	return c.functions
+}
+
+// Lines implements Coverage.Lines
+func (c *coverage) Lines() []LineCoverage {
+	return c.lines
+}
+
+// addLines sets the lines from the DWARF line tables of the module, attributing each row to the block of the
+// function which includes its instruction.
+func (c *coverage) addLines(m *wasm.Module) {
+	c.functionLines = make([]sys.SourceLocation, len(c.functions))
+	firstOffsets := make([]uint64, len(c.functions))
+
+	type key struct {
+		file string
+		line uint64
+	}
+	counts := map[key]uint64{}
+	m.DWARFLines.Rows(func(instructionOffset uint64, loc sys.SourceLocation) {
+		// Find the first function whose body ends after the instruction. Rows before the body, such as of its local
+		// declarations, are attributed to the first block.
+		i := sort.Search(len(m.CodeSection), func(i int) bool {
+			code := m.CodeSection[i]
+			return instructionOffset < code.BodyOffsetInCodeSection+uint64(len(code.Body))
+		})
+		if i == len(m.CodeSection) {
+			return // not in a function, such as code removed by the linker.
+		}
+		f := &c.functions[i]
+		if len(f.Blocks) == 0 {
+			return
+		}
+
+		var offset uint64
+		if start := m.CodeSection[i].BodyOffsetInCodeSection; instructionOffset > start {
+			offset = instructionOffset - start
+		}
+		if c.functionLines[i].Line == 0 || offset < firstOffsets[i] {
+			c.functionLines[i], firstOffsets[i] = loc, offset
+		}
+
+		// The block is the last which begins at or before the instruction.
+		b := sort.Search(len(f.Blocks), func(b int) bool { return f.Blocks[b].Offset > offset }) - 1
+		if b < 0 {
+			b = 0
+		}
+		k := key{loc.File, loc.Line}
+		if count, ok := counts[k]; !ok || f.Blocks[b].Count > count {
+			counts[k] = f.Blocks[b].Count
+		}
+	})
+
+	for k, count := range counts {
+		c.lines = append(c.lines, LineCoverage{File: k.file, Line: k.line, Count: count})
+	}
+	sort.Slice(c.lines, func(i, j int) bool {
+		if c.lines[i].File != c.lines[j].File {
+			return c.lines[i].File < c.lines[j].File
+		}
+		return c.lines[i].Line < c.lines[j].Line
+	})
+}
+
+// WriteLCOV implements Coverage.WriteLCOV
+func (c *coverage) WriteLCOV(w io.Writer) error {
+	// Group functions by their source file, with those of unknown lines in the module.
+	files := map[string][]int{}
+	for i, loc := range c.functionLines {
+		file := loc.File
+		if loc.Line == 0 {
+			file = c.moduleName
+		}
+		files[file] = append(files[file], i)
+	}
+	lines := map[string][]LineCoverage{}
+	for _, l := range c.lines {
+		lines[l.File] = append(lines[l.File], l)
+	}
+	names := make([]string, 0, len(files)+len(lines))
+	for file := range files {
+		names = append(names, file)
+	}
+	for file := range lines {
+		if _, ok := files[file]; !ok {
+			names = append(names, file)
+		}
+	}
+	sort.Strings(names)
+
+	b := bufio.NewWriter(w)
+	for _, file := range names {
+		fmt.Fprintf(b, "TN:\nSF:%s\n", file)
+		var hit int
+		for _, i := range files[file] {
+			fmt.Fprintf(b, "FN:%d,%s\n", c.functionLines[i].Line, c.functions[i].Name)
+		}
+		for _, i := range files[file] {
+			fmt.Fprintf(b, "FNDA:%d,%s\n", c.functions[i].Calls, c.functions[i].Name)
+			if c.functions[i].Calls > 0 {
+				hit++
+			}
+		}
+		fmt.Fprintf(b, "FNF:%d\nFNH:%d\n", len(files[file]), hit)
+
+		hit = 0
+		for _, l := range lines[file] {
+			fmt.Fprintf(b, "DA:%d,%d\n", l.Line, l.Count)
+			if l.Count > 0 {
+				hit++
+			}
+		}
+		fmt.Fprintf(b, "LF:%d\nLH:%d\nend_of_record\n", len(lines[file]), hit)
+	}
+	return b.Flush()
+}
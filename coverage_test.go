@@ -0,0 +1,158 @@
+package wazero
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/platform"
+	"github.com/tetratelabs/wazero/internal/testing/dwarftestdata"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	binaryformat "github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+func TestModuleCoverage(t *testing.T) {
+	i32 := wasm.ValueTypeI32
+	bin := binaryformat.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}}, {}},
+		FunctionSection: []wasm.Index{0, 1},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{
+				wasm.OpcodeLocalGet, 0, // 0
+				wasm.OpcodeI32Const, 0, // 2
+				wasm.OpcodeI32LtS,   // 4
+				wasm.OpcodeIf, 0x7f, // 5: if (result i32)
+				wasm.OpcodeI32Const, 0, // 7
+				wasm.OpcodeLocalGet, 0, // 9
+				wasm.OpcodeI32Sub,      // 11
+				wasm.OpcodeElse,        // 12
+				wasm.OpcodeLocalGet, 0, // 13
+				wasm.OpcodeEnd, // 15
+				wasm.OpcodeEnd, // 16
+			}},
+			{Body: []byte{wasm.OpcodeEnd}},
+		},
+		ExportSection: []*wasm.Export{{Name: "abs", Type: wasm.ExternTypeFunc, Index: 0}},
+		NameSection: &wasm.NameSection{ModuleName: "math", FunctionNames: wasm.NameMap{
+			{Index: 0, Name: "abs"}, {Index: 1, Name: "unused"},
+		}},
+	})
+
+	configs := map[string]RuntimeConfig{"interpreter": NewRuntimeConfigInterpreter()}
+	if platform.CompilerSupported() {
+		configs["compiler"] = NewRuntimeConfigCompiler()
+	}
+	for name, c := range configs {
+		config := c
+		t.Run(name, func(t *testing.T) {
+			r := NewRuntimeWithConfig(config.WithCoverage(true))
+			defer r.Close(testCtx)
+
+			compiled, err := r.CompileModule(testCtx, bin, NewCompileConfig())
+			require.NoError(t, err)
+			mod, err := r.InstantiateModule(testCtx, compiled, NewModuleConfig())
+			require.NoError(t, err)
+
+			for _, x := range []int32{5, -5, 3} {
+				_, err = mod.ExportedFunction("abs").Call(testCtx, uint64(uint32(x)))
+				require.NoError(t, err)
+			}
+
+			coverage, err := ModuleCoverage(compiled)
+			require.NoError(t, err)
+			require.Equal(t, []FunctionCoverage{
+				{Index: 0, Name: "math.abs", Calls: 3, Blocks: []BlockCoverage{
+					{Offset: 0, Count: 3},
+					{Offset: 7, Count: 1},  // then
+					{Offset: 13, Count: 2}, // else
+					{Offset: 16, Count: 3}, // after if
+				}},
+				{Index: 1, Name: "math.unused", Calls: 0, Blocks: []BlockCoverage{{Offset: 0, Count: 0}}},
+			}, coverage.Functions())
+			require.Nil(t, coverage.Lines())
+
+			var lcov bytes.Buffer
+			require.NoError(t, coverage.WriteLCOV(&lcov))
+			require.Equal(t, `TN:
+SF:math
+FN:0,math.abs
+FN:0,math.unused
+FNDA:3,math.abs
+FNDA:0,math.unused
+FNF:2
+FNH:1
+LF:0
+LH:0
+end_of_record
+`, lcov.String())
+		})
+	}
+}
+
+func TestModuleCoverage_Lines(t *testing.T) {
+	r := NewRuntimeWithConfig(NewRuntimeConfigInterpreter().WithCoverage(true))
+	defer r.Close(testCtx)
+
+	compiled, err := r.CompileModule(testCtx, dwarftestdata.DWARFWasm, NewCompileConfig())
+	require.NoError(t, err)
+	mod, err := r.InstantiateModule(testCtx, compiled, NewModuleConfig())
+	require.NoError(t, err)
+
+	// run calls middle, which traps.
+	_, err = mod.ExportedFunction("run").Call(testCtx)
+	require.Error(t, err)
+
+	coverage, err := ModuleCoverage(compiled)
+	require.NoError(t, err)
+	require.Equal(t, []LineCoverage{
+		{File: "/testdata/dwarf.c", Line: 4, Count: 1},
+		{File: "/testdata/dwarf.c", Line: 7, Count: 1},
+		{File: "/testdata/dwarf.c", Line: 8, Count: 1},
+		{File: "/testdata/dwarf.c", Line: 12, Count: 1},
+		{File: "/testdata/dwarf.c", Line: 13, Count: 1},
+		{File: "/testdata/dwarf.c", Line: 14, Count: 1},
+	}, coverage.Lines())
+
+	var lcov bytes.Buffer
+	require.NoError(t, coverage.WriteLCOV(&lcov))
+	require.Equal(t, `TN:
+SF:/testdata/dwarf.c
+FN:7,dwarf.wasm.middle
+FN:12,dwarf.wasm.run
+FNDA:1,dwarf.wasm.middle
+FNDA:1,dwarf.wasm.run
+FNF:2
+FNH:2
+DA:4,1
+DA:7,1
+DA:8,1
+DA:12,1
+DA:13,1
+DA:14,1
+LF:6
+LH:6
+end_of_record
+`, lcov.String())
+}
+
+func TestModuleCoverage_Errors(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		r := NewRuntime()
+		defer r.Close(testCtx)
+
+		compiled, err := r.CompileModule(testCtx, binaryNamedZero, NewCompileConfig())
+		require.NoError(t, err)
+		_, err = ModuleCoverage(compiled)
+		require.EqualError(t, err, "coverage is disabled or the module is closed: see RuntimeConfig.WithCoverage")
+	})
+
+	t.Run("host module", func(t *testing.T) {
+		r := NewRuntimeWithConfig(NewRuntimeConfig().WithCoverage(true))
+		defer r.Close(testCtx)
+
+		compiled, err := r.NewModuleBuilder("env").ExportFunction("nop", func() {}).Compile(testCtx, NewCompileConfig())
+		require.NoError(t, err)
+		_, err = ModuleCoverage(compiled)
+		require.EqualError(t, err, "coverage of host modules isn't supported")
+	})
+}
@@ -0,0 +1,757 @@
+package component
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"unicode/utf8"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+// The canonical ABI lifts values from, and lowers them to, the core WebAssembly values and linear memory of a core
+// instance. This implements it for the UTF-8 string encoding.
+//
+// See https://github.com/WebAssembly/component-model/blob/main/design/mvp/CanonicalABI.md
+
+const (
+	// maxFlatParams is the maximum count of core parameters of a function, above which they are in linear memory.
+	maxFlatParams = 16
+	// maxFlatResults is the maximum count of core results of a function, above which they are in linear memory.
+	maxFlatResults = 1
+)
+
+// alignTo returns ptr rounded up to the alignment, which is a power of two.
+func alignTo(ptr, alignment uint32) uint32 {
+	return (ptr + alignment - 1) &^ (alignment - 1)
+}
+
+// cases returns the types of the cases of a variant, enum, option or result, which are nil when a case has no value.
+func (t *Type) cases() []*Type {
+	switch t.Kind {
+	case KindVariant, KindEnum:
+		ret := make([]*Type, len(t.Cases))
+		for i, c := range t.Cases {
+			ret[i] = c.Type
+		}
+		return ret
+	case KindOption:
+		return []*Type{nil, t.Elem}
+	case KindResult:
+		return []*Type{t.Ok, t.Err}
+	}
+	return nil
+}
+
+// discriminant returns the type of the index of the case of a variant of n cases.
+func discriminant(n int) *Type {
+	switch {
+	case n <= 1<<8:
+		return U8
+	case n <= 1<<16:
+		return U16
+	}
+	return U32
+}
+
+// maxCaseAlignment returns the alignment of the value of a variant, which is the largest of its cases.
+func maxCaseAlignment(cases []*Type) (ret uint32) {
+	ret = 1
+	for _, c := range cases {
+		if c != nil {
+			if a := c.alignment(); a > ret {
+				ret = a
+			}
+		}
+	}
+	return
+}
+
+// alignment returns the alignment of the type in linear memory.
+func (t *Type) alignment() uint32 {
+	switch t.Kind {
+	case KindBool, KindS8, KindU8:
+		return 1
+	case KindS16, KindU16:
+		return 2
+	case KindS32, KindU32, KindF32, KindChar, KindString, KindList:
+		return 4
+	case KindS64, KindU64, KindF64:
+		return 8
+	case KindRecord, KindTuple:
+		ret := uint32(1)
+		for _, f := range t.Fields {
+			if a := f.Type.alignment(); a > ret {
+				ret = a
+			}
+		}
+		return ret
+	}
+	cases := t.cases()
+	ret := discriminant(len(cases)).alignment()
+	if a := maxCaseAlignment(cases); a > ret {
+		ret = a
+	}
+	return ret
+}
+
+// size returns the size of the type in linear memory.
+func (t *Type) size() uint32 {
+	switch t.Kind {
+	case KindBool, KindS8, KindU8:
+		return 1
+	case KindS16, KindU16:
+		return 2
+	case KindS32, KindU32, KindF32, KindChar:
+		return 4
+	case KindS64, KindU64, KindF64, KindString, KindList:
+		return 8
+	case KindRecord, KindTuple:
+		var s uint32
+		for _, f := range t.Fields {
+			s = alignTo(s, f.Type.alignment()) + f.Type.size()
+		}
+		return alignTo(s, t.alignment())
+	}
+	cases := t.cases()
+	s := alignTo(discriminant(len(cases)).size(), maxCaseAlignment(cases))
+	var caseSize uint32
+	for _, c := range cases {
+		if c != nil && c.size() > caseSize {
+			caseSize = c.size()
+		}
+	}
+	return alignTo(s+caseSize, t.alignment())
+}
+
+// payloadOffset returns the offset of the value of a variant from its discriminant in linear memory.
+func (t *Type) payloadOffset(cases []*Type) uint32 {
+	return alignTo(discriminant(len(cases)).size(), maxCaseAlignment(cases))
+}
+
+// flatten appends the core value types of the type to ret.
+func (t *Type) flatten(ret []api.ValueType) []api.ValueType {
+	switch t.Kind {
+	case KindBool, KindS8, KindU8, KindS16, KindU16, KindS32, KindU32, KindChar:
+		return append(ret, api.ValueTypeI32)
+	case KindS64, KindU64:
+		return append(ret, api.ValueTypeI64)
+	case KindF32:
+		return append(ret, api.ValueTypeF32)
+	case KindF64:
+		return append(ret, api.ValueTypeF64)
+	case KindString, KindList:
+		return append(ret, api.ValueTypeI32, api.ValueTypeI32)
+	case KindRecord, KindTuple:
+		for _, f := range t.Fields {
+			ret = f.Type.flatten(ret)
+		}
+		return ret
+	}
+	// The values of all cases share the same core values, which are joined when their types differ.
+	var payload []api.ValueType
+	for _, c := range t.cases() {
+		if c == nil {
+			continue
+		}
+		for i, vt := range c.flatten(nil) {
+			if i < len(payload) {
+				payload[i] = join(payload[i], vt)
+			} else {
+				payload = append(payload, vt)
+			}
+		}
+	}
+	ret = append(ret, api.ValueTypeI32)
+	return append(ret, payload...)
+}
+
+// join returns the core value type which can hold both a and b.
+//
+// Note: Joined values don't need to be converted, as the core values of this package are encoded as uint64 with the
+// bits of floats, and zero-extended i32.
+func join(a, b api.ValueType) api.ValueType {
+	if a == b {
+		return a
+	} else if (a == api.ValueTypeI32 && b == api.ValueTypeF32) || (a == api.ValueTypeF32 && b == api.ValueTypeI32) {
+		return api.ValueTypeI32
+	}
+	return api.ValueTypeI64
+}
+
+// coreSignature returns the core parameters and results of a lifted function of the type, or of a lowered function
+// when lower is true.
+func (f *FuncType) coreSignature(lower bool) (params, results []api.ValueType) {
+	params = f.paramTuple().flatten(nil)
+	if len(params) > maxFlatParams {
+		params = []api.ValueType{api.ValueTypeI32}
+	}
+	results = f.resultTuple().flatten(nil)
+	if len(results) > maxFlatResults {
+		if lower {
+			// The caller passes the pointer to store the results at.
+			params, results = append(params, api.ValueTypeI32), nil
+		} else {
+			// The callee returns the pointer to load the results from.
+			results = []api.ValueType{api.ValueTypeI32}
+		}
+	}
+	return
+}
+
+// abi lifts and lowers values with the linear memory and realloc function of the canonical options of a function,
+// which are nil when the type of the function doesn't need them.
+type abi struct {
+	ctx     context.Context
+	memory  api.Memory
+	realloc api.Function
+}
+
+// errUnexpectedValue returns an error of a Go value which is not the Go value of a type.
+func errUnexpectedValue(t *Type, v interface{}) error {
+	return fmt.Errorf("cannot lower %T as %s", v, t)
+}
+
+// view returns the bytes of the linear memory in the range, or errs if it is out of range.
+func (a *abi) view(ptr, size uint32) ([]byte, error) {
+	if a.memory == nil {
+		return nil, errors.New("canonical option memory is required")
+	}
+	b, ok := a.memory.Read(a.ctx, ptr, size)
+	if !ok {
+		return nil, fmt.Errorf("out of bounds memory access: %d bytes at %d", size, ptr)
+	}
+	return b, nil
+}
+
+// alloc allocates the size bytes of the alignment with the realloc function, and returns the pointer to them.
+func (a *abi) alloc(alignment, size uint32) (uint32, error) {
+	if a.realloc == nil {
+		return 0, errors.New("canonical option realloc is required")
+	}
+	results, err := a.realloc.Call(a.ctx, 0, 0, uint64(alignment), uint64(size))
+	if err != nil {
+		return 0, err
+	}
+	ptr := uint32(results[0])
+	if ptr != alignTo(ptr, alignment) {
+		return 0, fmt.Errorf("realloc returned %d, which is not aligned to %d", ptr, alignment)
+	}
+	if _, err = a.view(ptr, size); err != nil {
+		return 0, err
+	}
+	return ptr, nil
+}
+
+// lowerFlat appends the core values of v of the type to ret.
+func (a *abi) lowerFlat(t *Type, v interface{}, ret []uint64) ([]uint64, error) {
+	switch t.Kind {
+	case KindRecord, KindTuple:
+		values, err := fieldValues(t, v)
+		if err != nil {
+			return nil, err
+		}
+		for i, f := range t.Fields {
+			if ret, err = a.lowerFlat(f.Type, values[i], ret); err != nil {
+				return nil, err
+			}
+		}
+		return ret, nil
+	case KindString:
+		s, ok := v.(string)
+		if !ok {
+			return nil, errUnexpectedValue(t, v)
+		}
+		ptr, err := a.lowerString(s)
+		if err != nil {
+			return nil, err
+		}
+		return append(ret, uint64(ptr), uint64(len(s))), nil
+	case KindList:
+		ptr, n, err := a.lowerList(t.Elem, v)
+		if err != nil {
+			return nil, err
+		}
+		return append(ret, uint64(ptr), uint64(n)), nil
+	case KindVariant, KindEnum, KindOption, KindResult:
+		cases := t.cases()
+		i, payload, err := t.caseOf(v)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, uint64(i))
+		end := len(ret) + len(t.flatten(nil)) - 1
+		if c := cases[i]; c != nil {
+			if ret, err = a.lowerFlat(c, payload, ret); err != nil {
+				return nil, err
+			}
+		}
+		// Cases with less core values than others leave the rest zero.
+		for len(ret) < end {
+			ret = append(ret, 0)
+		}
+		return ret, nil
+	}
+	i, err := lowerPrimitive(t, v)
+	if err != nil {
+		return nil, err
+	}
+	return append(ret, i), nil
+}
+
+// lowerPrimitive returns the core value of v of the primitive type other than string.
+func lowerPrimitive(t *Type, v interface{}) (ret uint64, err error) {
+	ok := false
+	switch t.Kind {
+	case KindBool:
+		var b bool
+		if b, ok = v.(bool); b {
+			ret = 1
+		}
+	case KindS8:
+		var i int8
+		i, ok = v.(int8)
+		ret = uint64(uint32(int32(i)))
+	case KindU8:
+		var i uint8
+		i, ok = v.(uint8)
+		ret = uint64(i)
+	case KindS16:
+		var i int16
+		i, ok = v.(int16)
+		ret = uint64(uint32(int32(i)))
+	case KindU16:
+		var i uint16
+		i, ok = v.(uint16)
+		ret = uint64(i)
+	case KindS32:
+		var i int32
+		i, ok = v.(int32)
+		ret = uint64(uint32(i))
+	case KindU32:
+		var i uint32
+		i, ok = v.(uint32)
+		ret = uint64(i)
+	case KindS64:
+		var i int64
+		i, ok = v.(int64)
+		ret = uint64(i)
+	case KindU64:
+		ret, ok = v.(uint64)
+	case KindF32:
+		var f float32
+		f, ok = v.(float32)
+		ret = api.EncodeF32(f)
+	case KindF64:
+		var f float64
+		f, ok = v.(float64)
+		ret = api.EncodeF64(f)
+	case KindChar:
+		var r rune
+		if r, ok = v.(rune); ok && !utf8.ValidRune(r) {
+			return 0, fmt.Errorf("invalid char %#x", r)
+		}
+		ret = uint64(uint32(r))
+	}
+	if !ok {
+		return 0, errUnexpectedValue(t, v)
+	}
+	return
+}
+
+// liftFlat returns the Go value of the type from the beginning of the core values, and the rest of them.
+func (a *abi) liftFlat(t *Type, values []uint64) (interface{}, []uint64, error) {
+	switch t.Kind {
+	case KindRecord, KindTuple:
+		fields := make([]interface{}, len(t.Fields))
+		for i, f := range t.Fields {
+			var err error
+			if fields[i], values, err = a.liftFlat(f.Type, values); err != nil {
+				return nil, nil, err
+			}
+		}
+		return fieldsValue(t, fields), values, nil
+	case KindString:
+		s, err := a.liftString(uint32(values[0]), uint32(values[1]))
+		return s, values[2:], err
+	case KindList:
+		l, err := a.liftList(t.Elem, uint32(values[0]), uint32(values[1]))
+		return l, values[2:], err
+	case KindVariant, KindEnum, KindOption, KindResult:
+		cases := t.cases()
+		i, n := uint32(values[0]), len(t.flatten(nil))-1
+		payloadValues := values[1 : 1+n]
+		values = values[1+n:]
+		if i >= uint32(len(cases)) {
+			return nil, nil, fmt.Errorf("invalid case %d of %s", i, t)
+		}
+		var payload interface{}
+		if c := cases[i]; c != nil {
+			var err error
+			if payload, _, err = a.liftFlat(c, payloadValues); err != nil {
+				return nil, nil, err
+			}
+		}
+		return t.caseValue(i, payload), values, nil
+	}
+	v, err := liftPrimitive(t, values[0])
+	return v, values[1:], err
+}
+
+// liftPrimitive returns the Go value of the primitive type other than string from its core value.
+func liftPrimitive(t *Type, v uint64) (interface{}, error) {
+	switch t.Kind {
+	case KindBool:
+		return uint32(v) != 0, nil
+	case KindS8:
+		return int8(v), nil
+	case KindU8:
+		return uint8(v), nil
+	case KindS16:
+		return int16(v), nil
+	case KindU16:
+		return uint16(v), nil
+	case KindS32:
+		return int32(v), nil
+	case KindU32:
+		return uint32(v), nil
+	case KindS64:
+		return int64(v), nil
+	case KindU64:
+		return v, nil
+	case KindF32:
+		return api.DecodeF32(v), nil
+	case KindF64:
+		return api.DecodeF64(v), nil
+	}
+	// KindChar
+	r := rune(uint32(v))
+	if uint32(v) > utf8.MaxRune || !utf8.ValidRune(r) {
+		return nil, fmt.Errorf("invalid char %#x", uint32(v))
+	}
+	return r, nil
+}
+
+// store stores v of the type in the linear memory at ptr, which is aligned.
+func (a *abi) store(t *Type, v interface{}, ptr uint32) error {
+	switch t.Kind {
+	case KindRecord, KindTuple:
+		values, err := fieldValues(t, v)
+		if err != nil {
+			return err
+		}
+		var offset uint32
+		for i, f := range t.Fields {
+			offset = alignTo(offset, f.Type.alignment())
+			if err = a.store(f.Type, values[i], ptr+offset); err != nil {
+				return err
+			}
+			offset += f.Type.size()
+		}
+		return nil
+	case KindString, KindList:
+		var p, n uint32
+		if s, ok := v.(string); ok && t.Kind == KindString {
+			var err error
+			if p, err = a.lowerString(s); err != nil {
+				return err
+			}
+			n = uint32(len(s))
+		} else if t.Kind == KindString {
+			return errUnexpectedValue(t, v)
+		} else {
+			var err error
+			if p, n, err = a.lowerList(t.Elem, v); err != nil {
+				return err
+			}
+		}
+		b, err := a.view(ptr, 8)
+		if err != nil {
+			return err
+		}
+		binary.LittleEndian.PutUint32(b, p)
+		binary.LittleEndian.PutUint32(b[4:], n)
+		return nil
+	case KindVariant, KindEnum, KindOption, KindResult:
+		cases := t.cases()
+		i, payload, err := t.caseOf(v)
+		if err != nil {
+			return err
+		}
+		if err = a.storePrimitive(discriminant(len(cases)), uint64(i), ptr); err != nil {
+			return err
+		}
+		if c := cases[i]; c != nil {
+			return a.store(c, payload, ptr+t.payloadOffset(cases))
+		}
+		return nil
+	}
+	i, err := lowerPrimitive(t, v)
+	if err != nil {
+		return err
+	}
+	return a.storePrimitive(t, i, ptr)
+}
+
+// storePrimitive stores the core value of the primitive type other than string in the linear memory at ptr.
+func (a *abi) storePrimitive(t *Type, v uint64, ptr uint32) error {
+	b, err := a.view(ptr, t.size())
+	if err != nil {
+		return err
+	}
+	switch len(b) {
+	case 1:
+		b[0] = byte(v)
+	case 2:
+		binary.LittleEndian.PutUint16(b, uint16(v))
+	case 4:
+		binary.LittleEndian.PutUint32(b, uint32(v))
+	default:
+		binary.LittleEndian.PutUint64(b, v)
+	}
+	return nil
+}
+
+// load returns the Go value of the type in the linear memory at ptr, which is aligned.
+func (a *abi) load(t *Type, ptr uint32) (interface{}, error) {
+	switch t.Kind {
+	case KindRecord, KindTuple:
+		fields := make([]interface{}, len(t.Fields))
+		var offset uint32
+		for i, f := range t.Fields {
+			offset = alignTo(offset, f.Type.alignment())
+			var err error
+			if fields[i], err = a.load(f.Type, ptr+offset); err != nil {
+				return nil, err
+			}
+			offset += f.Type.size()
+		}
+		return fieldsValue(t, fields), nil
+	case KindString, KindList:
+		b, err := a.view(ptr, 8)
+		if err != nil {
+			return nil, err
+		}
+		p, n := binary.LittleEndian.Uint32(b), binary.LittleEndian.Uint32(b[4:])
+		if t.Kind == KindString {
+			return a.liftString(p, n)
+		}
+		return a.liftList(t.Elem, p, n)
+	case KindVariant, KindEnum, KindOption, KindResult:
+		cases := t.cases()
+		i, err := a.loadPrimitive(discriminant(len(cases)), ptr)
+		if err != nil {
+			return nil, err
+		}
+		if i >= uint64(len(cases)) {
+			return nil, fmt.Errorf("invalid case %d of %s", i, t)
+		}
+		var payload interface{}
+		if c := cases[i]; c != nil {
+			if payload, err = a.load(c, ptr+t.payloadOffset(cases)); err != nil {
+				return nil, err
+			}
+		}
+		return t.caseValue(uint32(i), payload), nil
+	}
+	v, err := a.loadPrimitive(t, ptr)
+	if err != nil {
+		return nil, err
+	}
+	return liftPrimitive(t, v)
+}
+
+// loadPrimitive returns the core value of the primitive type other than string in the linear memory at ptr.
+func (a *abi) loadPrimitive(t *Type, ptr uint32) (uint64, error) {
+	b, err := a.view(ptr, t.size())
+	if err != nil {
+		return 0, err
+	}
+	switch len(b) {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.LittleEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.LittleEndian.Uint32(b)), nil
+	}
+	return binary.LittleEndian.Uint64(b), nil
+}
+
+// lowerString copies the string into linear memory allocated by realloc, and returns the pointer to it.
+func (a *abi) lowerString(s string) (uint32, error) {
+	if uint64(len(s)) > math.MaxUint32 {
+		return 0, fmt.Errorf("string of %d bytes is too long", len(s))
+	}
+	ptr, err := a.alloc(1, uint32(len(s)))
+	if err != nil {
+		return 0, err
+	}
+	b, _ := a.view(ptr, uint32(len(s))) // alloc checked the range.
+	copy(b, s)
+	return ptr, nil
+}
+
+// liftString returns the string of the UTF-8 bytes in the linear memory.
+func (a *abi) liftString(ptr, n uint32) (string, error) {
+	b, err := a.view(ptr, n)
+	if err != nil {
+		return "", err
+	}
+	if !utf8.Valid(b) {
+		return "", fmt.Errorf("invalid UTF-8 string at %d", ptr)
+	}
+	return string(b), nil
+}
+
+// lowerList stores the elements of the list into linear memory allocated by realloc, and returns the pointer to them
+// and their count.
+func (a *abi) lowerList(elem *Type, v interface{}) (ptr, n uint32, err error) {
+	var values []interface{}
+	b, isBytes := v.([]byte)
+	if isBytes && elem.Kind != KindU8 || !isBytes && elem.Kind == KindU8 {
+		return 0, 0, errUnexpectedValue(List(elem), v)
+	} else if !isBytes {
+		var ok bool
+		if values, ok = v.([]interface{}); !ok {
+			return 0, 0, errUnexpectedValue(List(elem), v)
+		}
+	}
+
+	count := uint64(len(values))
+	if isBytes {
+		count = uint64(len(b))
+	}
+	size := count * uint64(elem.size())
+	if size > math.MaxUint32 {
+		return 0, 0, fmt.Errorf("list of %d elements is too long", count)
+	}
+	if ptr, err = a.alloc(elem.alignment(), uint32(size)); err != nil {
+		return
+	}
+	if isBytes {
+		view, _ := a.view(ptr, uint32(size)) // alloc checked the range.
+		copy(view, b)
+		return ptr, uint32(count), nil
+	}
+	for i, e := range values {
+		if err = a.store(elem, e, ptr+uint32(i)*elem.size()); err != nil {
+			return
+		}
+	}
+	return ptr, uint32(count), nil
+}
+
+// liftList returns the Go value of the list of n elements in the linear memory at ptr.
+func (a *abi) liftList(elem *Type, ptr, n uint32) (interface{}, error) {
+	if ptr != alignTo(ptr, elem.alignment()) {
+		return nil, fmt.Errorf("list at %d is not aligned to %d", ptr, elem.alignment())
+	}
+	size := uint64(n) * uint64(elem.size())
+	if size > math.MaxUint32 {
+		return nil, fmt.Errorf("list of %d elements is too long", n)
+	}
+	b, err := a.view(ptr, uint32(size))
+	if err != nil {
+		return nil, err
+	}
+	if elem.Kind == KindU8 {
+		ret := make([]byte, len(b))
+		copy(ret, b)
+		return ret, nil
+	}
+	ret := make([]interface{}, n)
+	for i := range ret {
+		if ret[i], err = a.load(elem, ptr+uint32(i)*elem.size()); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+// fieldValues returns the values of the fields of the Go value of a record or tuple, in order.
+func fieldValues(t *Type, v interface{}) ([]interface{}, error) {
+	if t.Kind == KindTuple {
+		values, ok := v.([]interface{})
+		if !ok || len(values) != len(t.Fields) {
+			return nil, errUnexpectedValue(t, v)
+		}
+		return values, nil
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errUnexpectedValue(t, v)
+	}
+	values := make([]interface{}, len(t.Fields))
+	for i, f := range t.Fields {
+		if values[i], ok = m[f.Name]; !ok {
+			return nil, fmt.Errorf("missing field %s of %s", f.Name, t)
+		}
+	}
+	return values, nil
+}
+
+// fieldsValue returns the Go value of a record or tuple of the values of its fields, in order.
+func fieldsValue(t *Type, fields []interface{}) interface{} {
+	if t.Kind == KindTuple {
+		return fields
+	}
+	m := make(map[string]interface{}, len(fields))
+	for i, f := range t.Fields {
+		m[f.Name] = fields[i]
+	}
+	return m
+}
+
+// caseOf returns the index of the case of the Go value of a variant, enum, option or result, and the value of the
+// case.
+func (t *Type) caseOf(v interface{}) (uint32, interface{}, error) {
+	switch t.Kind {
+	case KindVariant:
+		if vv, ok := v.(VariantValue); ok {
+			for i, c := range t.Cases {
+				if c.Name == vv.Case {
+					return uint32(i), vv.Value, nil
+				}
+			}
+			return 0, nil, fmt.Errorf("unknown case %s of %s", vv.Case, t)
+		}
+	case KindEnum:
+		if name, ok := v.(string); ok {
+			for i, c := range t.Cases {
+				if c.Name == name {
+					return uint32(i), nil, nil
+				}
+			}
+			return 0, nil, fmt.Errorf("unknown case %s of %s", name, t)
+		}
+	case KindOption:
+		if v == nil {
+			return 0, nil, nil
+		}
+		return 1, v, nil
+	case KindResult:
+		if r, ok := v.(ResultValue); ok {
+			if r.IsErr {
+				return 1, r.Value, nil
+			}
+			return 0, r.Value, nil
+		}
+	}
+	return 0, nil, errUnexpectedValue(t, v)
+}
+
+// caseValue returns the Go value of the case of a variant, enum, option or result, with the value of the case.
+func (t *Type) caseValue(i uint32, payload interface{}) interface{} {
+	switch t.Kind {
+	case KindVariant:
+		return VariantValue{Case: t.Cases[i].Name, Value: payload}
+	case KindEnum:
+		return t.Cases[i].Name
+	case KindOption:
+		return payload
+	}
+	return ResultValue{IsErr: i == 1, Value: payload}
+}
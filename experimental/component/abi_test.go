@@ -0,0 +1,293 @@
+package component
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	binaryformat "github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+// testCtx is an arbitrary, non-default context. Non-nil also prevents linter errors.
+var testCtx = context.WithValue(context.Background(), struct{}{}, "arbitrary")
+
+// libcWasm exports a memory, and a "realloc" which bump allocates from offset 1024, never freeing.
+var libcWasm = binaryformat.EncodeModule(&wasm.Module{
+	TypeSection: []*wasm.FunctionType{
+		{Params: []wasm.ValueType{i32, i32, i32, i32}, Results: []wasm.ValueType{i32}},
+	},
+	FunctionSection: []wasm.Index{0},
+	MemorySection:   []*wasm.Memory{{Min: 1}},
+	GlobalSection: []*wasm.Global{{
+		Type: &wasm.GlobalType{ValType: i32, Mutable: true},
+		Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0x80, 0x08}}, // 1024
+	}},
+	ExportSection: []*wasm.Export{
+		{Type: wasm.ExternTypeMemory, Name: "memory", Index: 0},
+		{Type: wasm.ExternTypeFunc, Name: "realloc", Index: 0},
+	},
+	CodeSection: []*wasm.Code{{LocalTypes: []wasm.ValueType{i32}, Body: []byte{
+		// ret = (bump + align - 1) & -align
+		wasm.OpcodeGlobalGet, 0, wasm.OpcodeLocalGet, 2, wasm.OpcodeI32Add, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Sub,
+		wasm.OpcodeI32Const, 0, wasm.OpcodeLocalGet, 2, wasm.OpcodeI32Sub, wasm.OpcodeI32And, wasm.OpcodeLocalTee, 4,
+		// bump = ret + size
+		wasm.OpcodeLocalGet, 3, wasm.OpcodeI32Add, wasm.OpcodeGlobalSet, 0,
+		wasm.OpcodeLocalGet, 4, wasm.OpcodeEnd,
+	}}},
+})
+
+const i32 = wasm.ValueTypeI32
+
+// newTestABI returns an abi of the memory and realloc of libcWasm.
+func newTestABI(t *testing.T) *abi {
+	r := wazero.NewRuntime()
+	t.Cleanup(func() { _ = r.Close(testCtx) })
+
+	mod, err := r.InstantiateModuleFromBinary(testCtx, libcWasm)
+	require.NoError(t, err)
+	return &abi{ctx: testCtx, memory: mod.ExportedMemory("memory"), realloc: mod.ExportedFunction("realloc")}
+}
+
+func TestType_sizeAlignment(t *testing.T) {
+	tests := []struct {
+		input             *Type
+		size, alignment   uint32
+		expectedFlattened []api.ValueType
+	}{
+		{input: Bool, size: 1, alignment: 1, expectedFlattened: []api.ValueType{i32}},
+		{input: S16, size: 2, alignment: 2, expectedFlattened: []api.ValueType{i32}},
+		{input: Char, size: 4, alignment: 4, expectedFlattened: []api.ValueType{i32}},
+		{input: U64, size: 8, alignment: 8, expectedFlattened: []api.ValueType{api.ValueTypeI64}},
+		{input: F32, size: 4, alignment: 4, expectedFlattened: []api.ValueType{api.ValueTypeF32}},
+		{input: String, size: 8, alignment: 4, expectedFlattened: []api.ValueType{i32, i32}},
+		{input: List(U64), size: 8, alignment: 4, expectedFlattened: []api.ValueType{i32, i32}},
+		{
+			input: Record(Field{"a", U8}, Field{"b", U32}, Field{"c", U16}),
+			size:  12, alignment: 4,
+			expectedFlattened: []api.ValueType{i32, i32, i32},
+		},
+		{input: Tuple(U8, U64), size: 16, alignment: 8, expectedFlattened: []api.ValueType{i32, api.ValueTypeI64}},
+		{input: Tuple(), size: 0, alignment: 1},
+		{input: Enum("a", "b"), size: 1, alignment: 1, expectedFlattened: []api.ValueType{i32}},
+		{input: Option(U32), size: 8, alignment: 4, expectedFlattened: []api.ValueType{i32, i32}},
+		{input: Result(nil, nil), size: 1, alignment: 1, expectedFlattened: []api.ValueType{i32}},
+		{
+			input: Result(U32, String),
+			size:  12, alignment: 4,
+			expectedFlattened: []api.ValueType{i32, i32, i32},
+		},
+		{
+			// f32 and i32 join to i32, and others to i64.
+			input: Variant(Case{"a", F32}, Case{"b", U32}, Case{"c", F64}, Case{"d", Tuple(F32, F32)}),
+			size:  16, alignment: 8,
+			expectedFlattened: []api.ValueType{i32, api.ValueTypeI64, api.ValueTypeF32},
+		},
+		{
+			input: Enum(make([]string, 257)...),
+			size:  2, alignment: 2,
+			expectedFlattened: []api.ValueType{i32},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.input.String(), func(t *testing.T) {
+			require.Equal(t, tc.size, tc.input.size())
+			require.Equal(t, tc.alignment, tc.input.alignment())
+			require.Equal(t, tc.expectedFlattened, tc.input.flatten(nil))
+		})
+	}
+}
+
+func TestFuncType_coreSignature(t *testing.T) {
+	params := make([]Field, 17)
+	for i := range params {
+		params[i] = Field{Type: U32}
+	}
+
+	tests := []struct {
+		name                            string
+		input                           *FuncType
+		lower                           bool
+		expectedParams, expectedResults []api.ValueType
+	}{
+		{
+			name:            "flat",
+			input:           &FuncType{Params: []Field{{"a", String}}, Results: []*Type{U32}},
+			expectedParams:  []api.ValueType{i32, i32},
+			expectedResults: []api.ValueType{i32},
+		},
+		{
+			name:            "lift results in memory",
+			input:           &FuncType{Results: []*Type{String}},
+			expectedResults: []api.ValueType{i32},
+		},
+		{
+			name:           "lower results in memory",
+			input:          &FuncType{Params: []Field{{"a", U64}}, Results: []*Type{String}},
+			lower:          true,
+			expectedParams: []api.ValueType{api.ValueTypeI64, i32},
+		},
+		{
+			name:           "params in memory",
+			input:          &FuncType{Params: params},
+			expectedParams: []api.ValueType{i32},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			params, results := tc.input.coreSignature(tc.lower)
+			require.Equal(t, tc.expectedParams, params)
+			require.Equal(t, tc.expectedResults, results)
+		})
+	}
+}
+
+func TestABI(t *testing.T) {
+	a := newTestABI(t)
+	point := Record(Field{"x", S32}, Field{"y", S32})
+
+	tests := []struct {
+		name  string
+		input *Type
+		value interface{}
+	}{
+		{name: "bool", input: Bool, value: true},
+		{name: "s8", input: S8, value: int8(-2)},
+		{name: "u8", input: U8, value: uint8(0xfe)},
+		{name: "s16", input: S16, value: int16(-300)},
+		{name: "u16", input: U16, value: uint16(0xfffe)},
+		{name: "s32", input: S32, value: int32(-70000)},
+		{name: "u32", input: U32, value: uint32(0xfffffffe)},
+		{name: "s64", input: S64, value: int64(-1 << 40)},
+		{name: "u64", input: U64, value: uint64(1<<64 - 2)},
+		{name: "f32", input: F32, value: float32(1.5)},
+		{name: "f64", input: F64, value: -2.25},
+		{name: "char", input: Char, value: '☃'},
+		{name: "string", input: String, value: "hello ☃"},
+		{name: "empty string", input: String, value: ""},
+		{name: "list<u8>", input: List(U8), value: []byte{1, 2, 3}},
+		{name: "list<string>", input: List(String), value: []interface{}{"a", "bc"}},
+		{name: "record", input: point, value: map[string]interface{}{"x": int32(-1), "y": int32(2)}},
+		{name: "list<record>", input: List(point), value: []interface{}{
+			map[string]interface{}{"x": int32(1), "y": int32(2)},
+			map[string]interface{}{"x": int32(3), "y": int32(4)},
+		}},
+		{name: "tuple", input: Tuple(U8, String, F64), value: []interface{}{uint8(1), "two", 3.0}},
+		{name: "variant without value", input: Variant(Case{"a", nil}, Case{"b", F32}), value: VariantValue{Case: "a"}},
+		{name: "variant f32", input: Variant(Case{"a", U64}, Case{"b", F32}), value: VariantValue{Case: "b", Value: float32(-1)}},
+		{name: "variant string", input: Variant(Case{"a", U64}, Case{"b", String}), value: VariantValue{Case: "b", Value: "x"}},
+		{name: "enum", input: Enum("debug", "info"), value: "info"},
+		{name: "option none", input: Option(String), value: nil},
+		{name: "option some", input: Option(String), value: "some"},
+		{name: "result ok", input: Result(U32, String), value: ResultValue{Value: uint32(6)}},
+		{name: "result err", input: Result(U32, String), value: ResultValue{IsErr: true, Value: "empty"}},
+		{name: "result without values", input: Result(nil, nil), value: ResultValue{IsErr: true}},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			flat, err := a.lowerFlat(tc.input, tc.value, nil)
+			require.NoError(t, err)
+			require.Equal(t, len(tc.input.flatten(nil)), len(flat))
+			lifted, rest, err := a.liftFlat(tc.input, flat)
+			require.NoError(t, err)
+			require.Equal(t, 0, len(rest))
+			require.Equal(t, tc.value, lifted)
+
+			ptr, err := a.alloc(tc.input.alignment(), tc.input.size())
+			require.NoError(t, err)
+			require.NoError(t, a.store(tc.input, tc.value, ptr))
+			loaded, err := a.load(tc.input, ptr)
+			require.NoError(t, err)
+			require.Equal(t, tc.value, loaded)
+		})
+	}
+}
+
+func TestABI_layout(t *testing.T) {
+	a := newTestABI(t)
+
+	ptr, err := a.alloc(4, 12)
+	require.NoError(t, err)
+	require.NoError(t, a.store(Result(U32, String), ResultValue{IsErr: true, Value: "abc"}, ptr))
+
+	b, ok := a.memory.Read(testCtx, ptr, 12)
+	require.True(t, ok)
+	require.Equal(t, byte(1), b[0]) // discriminant
+	strPtr, _ := a.memory.ReadUint32Le(testCtx, ptr+4)
+	strLen, _ := a.memory.ReadUint32Le(testCtx, ptr+8)
+	require.Equal(t, uint32(3), strLen)
+	s, _ := a.memory.Read(testCtx, strPtr, strLen)
+	require.Equal(t, "abc", string(s))
+}
+
+func TestABI_Errors(t *testing.T) {
+	a := newTestABI(t)
+	require.True(t, a.memory.Write(testCtx, 0, []byte{0xff, 0xfe}))
+	require.True(t, a.memory.WriteUint32Le(testCtx, 8, 0xd800))
+
+	t.Run("lower", func(t *testing.T) {
+		tests := []struct {
+			name        string
+			input       *Type
+			value       interface{}
+			expectedErr string
+		}{
+			{name: "wrong Go type", input: U32, value: 1, expectedErr: "cannot lower int as u32"},
+			{name: "invalid char", input: Char, value: rune(0xd800), expectedErr: "invalid char 0xd800"},
+			{name: "missing field", input: Record(Field{"x", U8}), value: map[string]interface{}{}, expectedErr: "missing field x of record { x: u8 }"},
+			{name: "tuple length", input: Tuple(U8), value: []interface{}{}, expectedErr: "cannot lower []interface {} as tuple<u8>"},
+			{name: "unknown case", input: Variant(Case{"a", nil}), value: VariantValue{Case: "b"}, expectedErr: "unknown case b of variant { a }"},
+			{name: "unknown enum", input: Enum("a"), value: "b", expectedErr: "unknown case b of enum { a }"},
+			{name: "list<u8> as list", input: List(U8), value: []interface{}{uint8(1)}, expectedErr: "cannot lower []interface {} as list<u8>"},
+			{name: "bytes as list<u32>", input: List(U32), value: []byte{1}, expectedErr: "cannot lower []uint8 as list<u32>"},
+			{name: "list element", input: List(U32), value: []interface{}{"a"}, expectedErr: "cannot lower string as u32"},
+		}
+
+		for _, tt := range tests {
+			tc := tt
+			t.Run(tc.name, func(t *testing.T) {
+				_, err := a.lowerFlat(tc.input, tc.value, nil)
+				require.EqualError(t, err, tc.expectedErr)
+			})
+		}
+	})
+
+	t.Run("lift", func(t *testing.T) {
+		tests := []struct {
+			name        string
+			input       *Type
+			flat        []uint64
+			expectedErr string
+		}{
+			{name: "invalid char", input: Char, flat: []uint64{0x110000}, expectedErr: "invalid char 0x110000"},
+			{name: "invalid case", input: Option(U8), flat: []uint64{2, 0}, expectedErr: "invalid case 2 of option<u8>"},
+			{name: "invalid UTF-8", input: String, flat: []uint64{0, 2}, expectedErr: "invalid UTF-8 string at 0"},
+			{name: "string out of bounds", input: String, flat: []uint64{65535, 2}, expectedErr: "out of bounds memory access: 2 bytes at 65535"},
+			{name: "unaligned list", input: List(U32), flat: []uint64{1, 1}, expectedErr: "list at 1 is not aligned to 4"},
+			{name: "invalid char in list", input: List(Char), flat: []uint64{8, 1}, expectedErr: "invalid char 0xd800"},
+		}
+
+		for _, tt := range tests {
+			tc := tt
+			t.Run(tc.name, func(t *testing.T) {
+				_, _, err := a.liftFlat(tc.input, tc.flat)
+				require.EqualError(t, err, tc.expectedErr)
+			})
+		}
+	})
+
+	t.Run("no memory", func(t *testing.T) {
+		_, err := (&abi{ctx: testCtx}).lowerFlat(String, "a", nil)
+		require.EqualError(t, err, "canonical option realloc is required")
+		_, _, err = (&abi{ctx: testCtx}).liftFlat(String, []uint64{0, 0})
+		require.EqualError(t, err, "canonical option memory is required")
+	})
+}
@@ -0,0 +1,519 @@
+// Package component runs WebAssembly components: core modules composed with functions whose parameters and results are
+// high-level types, such as strings, lists and records, defined in the WebAssembly Interface Type (WIT) language.
+//
+// Components are compiled with a wazero.Runtime, and instantiated in a wazero.Namespace, which instantiates the core
+// modules embedded in the component. Imports of the component are functions defined in Go against a WIT interface,
+// and exports are called with Go values, which are lifted from and lowered to linear memory with the canonical ABI.
+// Ex.
+//
+//	logger, _ := component.ParseInterface(`interface logger {
+//		log: func(msg: string) -> u32
+//	}`)
+//	imports := component.NewImports().ExportInterface("logger", logger, map[string]component.HostFunction{
+//		"log": func(ctx context.Context, params []interface{}) ([]interface{}, error) {
+//			fmt.Println(params[0].(string))
+//			return []interface{}{uint32(len(params[0].(string)))}, nil
+//		},
+//	})
+//
+//	compiled, _ := component.Compile(ctx, r, wasm)
+//	inst, _ := component.Instantiate(ctx, r.NewNamespace(ctx), compiled, imports)
+//	results, _ := inst.ExportedFunction("greet").Call(ctx, "wazero")
+//
+// Note: This supports the subset of the component model needed by components built from core modules, such as those
+// of wit-component: value types except flags and resources, the UTF-8 string encoding, and no nested components.
+//
+// Note: This is an experimental API and may be changed or deleted at any time, so use with caution!
+package component
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// CompiledComponent is a component binary with its core modules compiled by a wazero.Runtime, which can be instantiated
+// any number of times with Instantiate.
+type CompiledComponent interface {
+	// Imports returns the types of the imported functions by name. Functions of imported instances are named like
+	// "instance#function".
+	Imports() map[string]*FuncType
+
+	// Exports returns the types of the exported functions by name. Functions of exported instances are named like
+	// "instance#function".
+	Exports() map[string]*FuncType
+
+	// Close releases the compiled core modules.
+	Close(ctx context.Context) error
+}
+
+// Exports are the exported functions of a component instance or of an instance it exports.
+type Exports interface {
+	// ExportedFunction returns the exported function of the name, or nil if there is none.
+	ExportedFunction(name string) Function
+}
+
+// Instance is an instantiated component.
+type Instance interface {
+	Exports
+
+	// ExportedInstance returns the exported instance of the name, or nil if there is none.
+	ExportedInstance(name string) Exports
+
+	// Close closes the core modules of the component in the namespace.
+	Close(ctx context.Context) error
+}
+
+// Function is a function exported by a component, which lifts its results from, and lowers its parameters to, the
+// core function it was lifted from.
+type Function interface {
+	// Type returns the type of the function.
+	Type() *FuncType
+
+	// Call calls the function with the Go values of its parameters, and returns the Go values of its results. See Type
+	// for the Go values of each type.
+	//
+	// Note: Calling a function of a closed instance returns an error.
+	Call(ctx context.Context, params ...interface{}) ([]interface{}, error)
+}
+
+// HostFunction is a function imported by a component, which is implemented in Go. It is called with the Go values of its
+// parameters, and returns the Go values of its results. See Type for the Go values of each type.
+//
+// Returning an error aborts the guest: the call to the Function which called the import returns an error wrapping it.
+type HostFunction func(ctx context.Context, params []interface{}) ([]interface{}, error)
+
+// Imports are the host functions which satisfy the imports of a component.
+//
+// Note: Exported functions are imports of the component, like a wazero.ModuleBuilder exports host functions to modules.
+type Imports interface {
+	// ExportFunction adds a function of the type, which satisfies an import of a function of the name.
+	ExportFunction(name string, typ *FuncType, fn HostFunction) Imports
+
+	// ExportInterface adds the functions of the interface, which satisfy an import of an instance of the name. The
+	// functions are by the names of the interface. Ex. "log" for `log: func(msg: string)`
+	ExportInterface(name string, iface *Interface, fns map[string]HostFunction) Imports
+}
+
+// NewImports returns Imports without any functions.
+func NewImports() Imports {
+	return &hostImports{funcs: map[string]*hostFunction{}, interfaces: map[string]map[string]*hostFunction{}}
+}
+
+type hostFunction struct {
+	typ *FuncType
+	fn  HostFunction
+}
+
+type hostImports struct {
+	funcs      map[string]*hostFunction
+	interfaces map[string]map[string]*hostFunction
+}
+
+// ExportFunction implements Imports.ExportFunction
+func (i *hostImports) ExportFunction(name string, typ *FuncType, fn HostFunction) Imports {
+	i.funcs[name] = &hostFunction{typ: typ, fn: fn}
+	return i
+}
+
+// ExportInterface implements Imports.ExportInterface
+func (i *hostImports) ExportInterface(name string, iface *Interface, fns map[string]HostFunction) Imports {
+	funcs := make(map[string]*hostFunction, len(fns))
+	for n, fn := range fns {
+		funcs[n] = &hostFunction{typ: iface.Functions[n], fn: fn}
+	}
+	i.interfaces[name] = funcs
+	return i
+}
+
+// lookup returns the host function which satisfies the imported function, or errs if there is none.
+func (i *hostImports) lookup(f *function) (*hostFunction, error) {
+	var hf *hostFunction
+	name := f.importName
+	if f.exportName == "" {
+		hf = i.funcs[name]
+	} else {
+		name = f.importName + "#" + f.exportName
+		hf = i.interfaces[f.importName][f.exportName]
+	}
+	if hf == nil || hf.fn == nil {
+		return nil, fmt.Errorf("import %s: not found", name)
+	} else if hf.typ == nil || !hf.typ.equal(f.typ) {
+		return nil, fmt.Errorf("import %s: type mismatch: %s != %s", name, hf.typ, f.typ)
+	}
+	return hf, nil
+}
+
+type compiledComponent struct {
+	r       wazero.Runtime
+	decoded *decodedComponent
+	// modules are the core modules compiled for each core instance which instantiates one, by index.
+	modules map[uint32]wazero.CompiledModule
+}
+
+// Compile decodes the component binary, and compiles its core modules with the runtime.
+func Compile(ctx context.Context, r wazero.Runtime, binary []byte) (CompiledComponent, error) {
+	d, err := decodeComponent(binary)
+	if err != nil {
+		return nil, fmt.Errorf("invalid component: %w", err)
+	}
+	c := &compiledComponent{r: r, decoded: d, modules: map[uint32]wazero.CompiledModule{}}
+	for _, s := range d.steps {
+		if s.coreInstance == nil {
+			continue
+		}
+		i := *s.coreInstance
+		inst := d.coreInstances[i]
+		// Each instantiation of a core module imports from different core instances, so is compiled separately.
+		config := wazero.NewCompileConfig().WithImportRenamer(d.importRenamer(inst))
+		m, err := r.CompileModule(ctx, d.coreModules[inst.module], config)
+		if err != nil {
+			_ = c.Close(ctx)
+			return nil, fmt.Errorf("core instance %d: %w", i, err)
+		}
+		c.modules[i] = m
+	}
+	return c, nil
+}
+
+// importRenamer returns the api.ImportRenamer which resolves the imports of the core instance to the modules of other
+// core instances in the namespace.
+func (d *decodedComponent) importRenamer(inst *coreInstance) api.ImportRenamer {
+	return func(_ api.ExternType, oldModule, oldName string) (string, string) {
+		i, ok := inst.args[oldModule]
+		if !ok {
+			return oldModule, oldName // instantiation fails as the module isn't in the namespace.
+		}
+		arg := d.coreInstances[i]
+		if arg.exports == nil {
+			return coreInstanceName(i), oldName
+		}
+		if ref, ok := arg.exports[oldName]; ok {
+			return ref.module, ref.name
+		}
+		return coreInstanceName(i), oldName
+	}
+}
+
+// Imports implements CompiledComponent.Imports
+func (c *compiledComponent) Imports() map[string]*FuncType {
+	ret := map[string]*FuncType{}
+	for _, imp := range c.decoded.imports {
+		if imp.fn != nil {
+			ret[imp.name] = imp.fn
+			continue
+		}
+		for n, fn := range imp.inst.funcs {
+			ret[imp.name+"#"+n] = fn
+		}
+	}
+	return ret
+}
+
+// Exports implements CompiledComponent.Exports
+func (c *compiledComponent) Exports() map[string]*FuncType {
+	ret := map[string]*FuncType{}
+	for name, e := range c.decoded.exports {
+		if e.sort == sortFunc {
+			ret[name] = c.decoded.funcs[e.index].typ
+			continue
+		}
+		for n, fn := range c.decoded.instances[e.index].typ.funcs {
+			ret[name+"#"+n] = fn
+		}
+	}
+	return ret
+}
+
+// Close implements CompiledComponent.Close
+func (c *compiledComponent) Close(ctx context.Context) (err error) {
+	for _, m := range c.modules {
+		if e := m.Close(ctx); e != nil && err == nil {
+			err = e
+		}
+	}
+	return
+}
+
+// Instantiate instantiates the compiled component in the namespace, with the host functions of the imports.
+//
+// The core modules of the component are instantiated in the namespace with names like "core[0]", and its imports are
+// host modules with names like "lower[0]". So, each component instance needs its own namespace.
+// Ex.
+//
+//	ns := r.NewNamespace(ctx)
+//	defer ns.Close(ctx)
+//	inst, _ := component.Instantiate(ctx, ns, compiled, imports)
+func Instantiate(ctx context.Context, ns wazero.Namespace, compiled CompiledComponent, imports Imports) (Instance, error) {
+	c, ok := compiled.(*compiledComponent)
+	if !ok {
+		return nil, fmt.Errorf("unsupported component.CompiledComponent implementation: %#v", compiled)
+	}
+	if imports == nil {
+		imports = NewImports()
+	}
+	d, imps := c.decoded, imports.(*hostImports)
+
+	inst := &componentInstance{ns: ns, decoded: d}
+	for _, s := range d.steps {
+		var mod api.Module
+		var err error
+		if s.coreInstance != nil {
+			i := *s.coreInstance
+			config := wazero.NewModuleConfig().WithName(coreInstanceName(i))
+			if mod, err = ns.InstantiateModule(ctx, c.modules[i], config); err != nil {
+				err = fmt.Errorf("core instance %d: %w", i, err)
+			}
+		} else {
+			mod, err = inst.lower(ctx, c.r, imps, d.lowerings[*s.lowering])
+		}
+		if err != nil {
+			_ = inst.Close(ctx)
+			return nil, err
+		}
+		inst.modules = append(inst.modules, mod)
+	}
+
+	inst.funcs = make([]Function, len(d.funcs))
+	for i, f := range d.funcs {
+		if f.lifted == nil {
+			continue // imported functions are only lowered.
+		}
+		var err error
+		if inst.funcs[i], err = inst.lift(f); err != nil {
+			_ = inst.Close(ctx)
+			return nil, err
+		}
+	}
+	return inst, nil
+}
+
+type componentInstance struct {
+	ns      wazero.Namespace
+	decoded *decodedComponent
+	modules []api.Module
+	// funcs are the lifted functions by index, which are nil for imported functions.
+	funcs []Function
+}
+
+// ExportedFunction implements Exports.ExportedFunction
+func (i *componentInstance) ExportedFunction(name string) Function {
+	if e, ok := i.decoded.exports[name]; ok && e.sort == sortFunc {
+		return i.funcs[e.index]
+	}
+	return nil
+}
+
+// ExportedInstance implements Instance.ExportedInstance
+func (i *componentInstance) ExportedInstance(name string) Exports {
+	if e, ok := i.decoded.exports[name]; ok && e.sort == sortInstance {
+		return &exportedInstance{c: i, inst: i.decoded.instances[e.index]}
+	}
+	return nil
+}
+
+// Close implements Instance.Close
+func (i *componentInstance) Close(ctx context.Context) (err error) {
+	// Close in reverse order, as modules are instantiated after the modules they import.
+	for j := len(i.modules) - 1; j >= 0; j-- {
+		if e := i.modules[j].Close(ctx); e != nil && err == nil {
+			err = e
+		}
+	}
+	return
+}
+
+type exportedInstance struct {
+	c    *componentInstance
+	inst *instance
+}
+
+// ExportedFunction implements Exports.ExportedFunction
+func (e *exportedInstance) ExportedFunction(name string) Function {
+	if f, ok := e.inst.funcs[name]; ok {
+		return e.c.funcs[f]
+	}
+	return nil
+}
+
+// coreModule returns the module of the core item in the namespace, or errs if it was closed.
+func (i *componentInstance) coreModule(ref *coreRef) (api.Module, error) {
+	if mod := i.ns.Module(ref.module); mod != nil {
+		return mod, nil
+	}
+	return nil, fmt.Errorf("module %s not instantiated", ref.module)
+}
+
+// coreFunction returns the core function, or errs if it doesn't exist.
+func (i *componentInstance) coreFunction(ref *coreRef) (api.Function, error) {
+	mod, err := i.coreModule(ref)
+	if err != nil {
+		return nil, err
+	}
+	if fn := mod.ExportedFunction(ref.name); fn != nil {
+		return fn, nil
+	}
+	return nil, fmt.Errorf("function %s.%s not exported", ref.module, ref.name)
+}
+
+// abi returns the abi of the canonical options, or errs if they refer to missing core items.
+func (i *componentInstance) abi(opts *canonOptions) (*abi, error) {
+	ret := &abi{}
+	if ref := opts.memory; ref != nil {
+		mod, err := i.coreModule(ref)
+		if err != nil {
+			return nil, err
+		}
+		if ret.memory = mod.ExportedMemory(ref.name); ret.memory == nil {
+			return nil, fmt.Errorf("memory %s.%s not exported", ref.module, ref.name)
+		}
+	}
+	if ref := opts.realloc; ref != nil {
+		var err error
+		if ret.realloc, err = i.coreFunction(ref); err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(ret.realloc.ParamTypes(), []api.ValueType{api.ValueTypeI32, api.ValueTypeI32, api.ValueTypeI32, api.ValueTypeI32}) ||
+			!reflect.DeepEqual(ret.realloc.ResultTypes(), []api.ValueType{api.ValueTypeI32}) {
+			return nil, fmt.Errorf("realloc %s.%s has an invalid signature", ref.module, ref.name)
+		}
+	}
+	return ret, nil
+}
+
+// lower instantiates the host module of the lowered import.
+func (i *componentInstance) lower(ctx context.Context, r wazero.Runtime, imps *hostImports, l *lowering) (api.Module, error) {
+	f := i.decoded.funcs[l.fn]
+	hf, err := imps.lookup(f)
+	if err != nil {
+		return nil, err
+	}
+	a, err := i.abi(&l.opts)
+	if err != nil {
+		return nil, fmt.Errorf("lower %s: %w", l.module, err)
+	}
+
+	params, results := f.typ.coreSignature(true)
+	paramTuple, resultTuple := f.typ.paramTuple(), f.typ.resultTuple()
+	flatParams := len(paramTuple.flatten(nil)) <= maxFlatParams
+	flatResults := len(resultTuple.flatten(nil)) <= maxFlatResults
+	fn := api.GoModuleFunc(func(ctx context.Context, _ api.Module, stack []uint64) {
+		a := &abi{ctx: ctx, memory: a.memory, realloc: a.realloc}
+		var args interface{}
+		var err error
+		if flatParams {
+			args, _, err = a.liftFlat(paramTuple, stack)
+		} else {
+			args, err = a.load(paramTuple, uint32(stack[0]))
+		}
+		if err != nil {
+			panic(err)
+		}
+
+		ret, err := hf.fn(ctx, args.([]interface{}))
+		if err != nil {
+			panic(err)
+		} else if len(ret) != len(f.typ.Results) {
+			panic(fmt.Errorf("expected %d results, but was %d", len(f.typ.Results), len(ret)))
+		}
+
+		if flatResults {
+			var values []uint64
+			if values, err = a.lowerFlat(resultTuple, ret, nil); err == nil {
+				copy(stack, values)
+			}
+		} else {
+			err = a.store(resultTuple, ret, uint32(stack[len(params)-1]))
+		}
+		if err != nil {
+			panic(err)
+		}
+	})
+	return r.NewModuleBuilder(l.module).ExportGoModuleFunction("func", fn, params, results).Instantiate(ctx, i.ns)
+}
+
+// lift returns the function lifted from its core function.
+func (i *componentInstance) lift(f *function) (Function, error) {
+	core, err := i.coreFunction(f.lifted)
+	if err != nil {
+		return nil, err
+	}
+	params, results := f.typ.coreSignature(false)
+	if !reflect.DeepEqual(core.ParamTypes(), params) || !reflect.DeepEqual(core.ResultTypes(), results) {
+		return nil, fmt.Errorf("lift %s.%s: core function type mismatch: %s", f.lifted.module, f.lifted.name, f.typ)
+	}
+	a, err := i.abi(&f.opts)
+	if err != nil {
+		return nil, fmt.Errorf("lift %s.%s: %w", f.lifted.module, f.lifted.name, err)
+	}
+	ret := &liftedFunction{typ: f.typ, core: core, memory: a.memory, realloc: a.realloc}
+	if f.opts.postReturn != nil {
+		if ret.postReturn, err = i.coreFunction(f.opts.postReturn); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+type liftedFunction struct {
+	typ                       *FuncType
+	core, realloc, postReturn api.Function
+	memory                    api.Memory
+}
+
+// Type implements Function.Type
+func (f *liftedFunction) Type() *FuncType {
+	return f.typ
+}
+
+// Call implements Function.Call
+func (f *liftedFunction) Call(ctx context.Context, params ...interface{}) ([]interface{}, error) {
+	if len(params) != len(f.typ.Params) {
+		return nil, fmt.Errorf("expected %d params, but passed %d", len(f.typ.Params), len(params))
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	a := &abi{ctx: ctx, memory: f.memory, realloc: f.realloc}
+
+	paramTuple, resultTuple := f.typ.paramTuple(), f.typ.resultTuple()
+	var args []uint64
+	var err error
+	if len(paramTuple.flatten(nil)) <= maxFlatParams {
+		args, err = a.lowerFlat(paramTuple, params, nil)
+	} else {
+		var ptr uint32
+		if ptr, err = a.alloc(paramTuple.alignment(), paramTuple.size()); err == nil {
+			err = a.store(paramTuple, params, ptr)
+			args = []uint64{uint64(ptr)}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := f.core.Call(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret interface{}
+	if len(resultTuple.flatten(nil)) <= maxFlatResults {
+		ret, _, err = a.liftFlat(resultTuple, results)
+	} else {
+		ret, err = a.load(resultTuple, uint32(results[0]))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if f.postReturn != nil {
+		if _, err = f.postReturn.Call(ctx, results...); err != nil {
+			return nil, err
+		}
+	}
+	return ret.([]interface{}), nil
+}
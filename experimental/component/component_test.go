@@ -0,0 +1,311 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/internal/leb128"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	binaryformat "github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+// mainWasm imports a memory from "libc" and "log" from "host", and exports functions lifted by greeterWasm.
+//
+//	(func (export "greet") (param $ptr i32) (param $len i32) (result i32) ;; returns the name
+//	(func (export "sum") (param $x i32) (param $y i32) (result i32)
+//	(func (export "total") (param $ptr i32) (param $len i32) (result i32) ;; result<u32, string> of a list<u32>
+var mainWasm = binaryformat.EncodeModule(&wasm.Module{
+	TypeSection: []*wasm.FunctionType{{Params: []wasm.ValueType{i32, i32}, Results: []wasm.ValueType{i32}}},
+	ImportSection: []*wasm.Import{
+		{Type: wasm.ExternTypeMemory, Module: "libc", Name: "memory", DescMem: &wasm.Memory{Min: 1}},
+		{Type: wasm.ExternTypeFunc, Module: "host", Name: "log", DescFunc: 0},
+	},
+	FunctionSection: []wasm.Index{0, 0, 0},
+	ExportSection: []*wasm.Export{
+		{Type: wasm.ExternTypeFunc, Name: "greet", Index: 1},
+		{Type: wasm.ExternTypeFunc, Name: "sum", Index: 2},
+		{Type: wasm.ExternTypeFunc, Name: "total", Index: 3},
+	},
+	CodeSection: []*wasm.Code{
+		{Body: []byte{
+			wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1, wasm.OpcodeCall, 0, wasm.OpcodeDrop,
+			// The results are the string at 16.
+			wasm.OpcodeI32Const, 16, wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Store, 2, 0,
+			wasm.OpcodeI32Const, 20, wasm.OpcodeLocalGet, 1, wasm.OpcodeI32Store, 2, 0,
+			wasm.OpcodeI32Const, 16, wasm.OpcodeEnd,
+		}},
+		{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1, wasm.OpcodeI32Add, wasm.OpcodeEnd}},
+		{LocalTypes: []wasm.ValueType{i32}, Body: []byte{
+			// if len == 0, return the error "empty" at 0.
+			wasm.OpcodeLocalGet, 1, wasm.OpcodeI32Eqz, wasm.OpcodeIf, 0x40,
+			wasm.OpcodeI32Const, 16, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Store, 2, 0,
+			wasm.OpcodeI32Const, 20, wasm.OpcodeI32Const, 0, wasm.OpcodeI32Store, 2, 0,
+			wasm.OpcodeI32Const, 24, wasm.OpcodeI32Const, 5, wasm.OpcodeI32Store, 2, 0,
+			wasm.OpcodeI32Const, 16, wasm.OpcodeReturn,
+			wasm.OpcodeEnd,
+			// sum the elements into the local 2.
+			wasm.OpcodeLoop, 0x40,
+			wasm.OpcodeLocalGet, 2, wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Load, 2, 0, wasm.OpcodeI32Add, wasm.OpcodeLocalSet, 2,
+			wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Const, 4, wasm.OpcodeI32Add, wasm.OpcodeLocalSet, 0,
+			wasm.OpcodeLocalGet, 1, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Sub, wasm.OpcodeLocalTee, 1, wasm.OpcodeBrIf, 0,
+			wasm.OpcodeEnd,
+			wasm.OpcodeI32Const, 16, wasm.OpcodeI32Const, 0, wasm.OpcodeI32Store, 2, 0,
+			wasm.OpcodeI32Const, 20, wasm.OpcodeLocalGet, 2, wasm.OpcodeI32Store, 2, 0,
+			wasm.OpcodeI32Const, 16, wasm.OpcodeEnd,
+		}},
+	},
+	DataSection: []*wasm.DataSegment{{
+		OffsetExpression: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+		Init:             []byte("empty"),
+	}},
+})
+
+func encodeVec(items ...[]byte) []byte {
+	ret := leb128.EncodeUint32(uint32(len(items)))
+	for _, i := range items {
+		ret = append(ret, i...)
+	}
+	return ret
+}
+
+func encodeString(s string) []byte {
+	return append(leb128.EncodeUint32(uint32(len(s))), s...)
+}
+
+// encodeName encodes the name of an import or export.
+func encodeName(s string) []byte {
+	return append([]byte{0x00}, encodeString(s)...)
+}
+
+func encodeSection(id byte, contents []byte) []byte {
+	return append(append([]byte{id}, leb128.EncodeUint32(uint32(len(contents)))...), contents...)
+}
+
+func encodeComponent(sections ...[]byte) []byte {
+	ret := append(append([]byte{}, magic...), versionAndLayer...)
+	for _, s := range sections {
+		ret = append(ret, s...)
+	}
+	return ret
+}
+
+func concat(b ...[]byte) (ret []byte) {
+	for _, i := range b {
+		ret = append(ret, i...)
+	}
+	return
+}
+
+// logType is the type of `log: func(msg: string) -> u32`.
+var logType = concat([]byte{0x40}, encodeVec(concat(encodeString("msg"), []byte{0x73})), []byte{0x00, 0x79})
+
+// greeterWasm is a component of libcWasm and mainWasm, equivalent to this WIT:
+//
+//	import logger: interface {
+//		log: func(msg: string) -> u32
+//	}
+//	export greet: func(name: string) -> string
+//	export math: interface {
+//		record point { x: s32, y: s32 }
+//		sum: func(p: point) -> s32
+//		total: func(values: list<u32>) -> result<u32, string>
+//	}
+var greeterWasm = encodeComponent(
+	encodeSection(sectionIDType, encodeVec(
+		logType, // type 0
+		concat([]byte{0x42}, encodeVec( // type 1: instance { log: func(msg: string) -> u32 }
+			concat([]byte{0x01}, logType),
+			concat([]byte{0x04}, encodeName("log"), []byte{sortFunc, 0x00}),
+		)),
+	)),
+	encodeSection(sectionIDImport, encodeVec(concat(encodeName("logger"), []byte{sortInstance, 0x01}))),
+	encodeSection(sectionIDAlias, encodeVec(concat([]byte{sortFunc, 0x00, 0x00}, encodeString("log")))), // func 0
+	encodeSection(sectionIDCoreModule, libcWasm),
+	encodeSection(sectionIDCoreModule, mainWasm),
+	encodeSection(sectionIDCoreInstance, encodeVec([]byte{0x00, 0x00, 0x00})), // core instance 0: libc
+	encodeSection(sectionIDAlias, encodeVec(
+		concat([]byte{sortCore, coreSortMemory, 0x01, 0x00}, encodeString("memory")), // core memory 0
+		concat([]byte{sortCore, coreSortFunc, 0x01, 0x00}, encodeString("realloc")),  // core func 0
+	)),
+	// core func 1: log lowered with the memory and realloc of libc
+	encodeSection(sectionIDCanon, encodeVec([]byte{0x01, 0x00, 0x00, 0x02, 0x03, 0x00, 0x04, 0x00})),
+	encodeSection(sectionIDCoreInstance, encodeVec(
+		concat([]byte{0x01}, encodeVec(concat(encodeString("log"), []byte{coreSortFunc, 0x01}))), // core instance 1
+		concat([]byte{0x00, 0x01}, encodeVec( // core instance 2: main
+			concat(encodeString("libc"), []byte{coreSortInstance, 0x00}),
+			concat(encodeString("host"), []byte{coreSortInstance, 0x01}),
+		)),
+	)),
+	encodeSection(sectionIDAlias, encodeVec(
+		concat([]byte{sortCore, coreSortFunc, 0x01, 0x02}, encodeString("greet")), // core func 2
+		concat([]byte{sortCore, coreSortFunc, 0x01, 0x02}, encodeString("sum")),   // core func 3
+		concat([]byte{sortCore, coreSortFunc, 0x01, 0x02}, encodeString("total")), // core func 4
+	)),
+	encodeSection(sectionIDType, encodeVec(
+		concat([]byte{0x40}, encodeVec(concat(encodeString("name"), []byte{0x73})), []byte{0x00, 0x73}), // type 2
+		concat([]byte{0x72}, encodeVec( // type 3: point
+			concat(encodeString("x"), []byte{0x7a}),
+			concat(encodeString("y"), []byte{0x7a}),
+		)),
+		concat([]byte{0x40}, encodeVec(concat(encodeString("p"), []byte{0x03})), []byte{0x00, 0x7a}), // type 4
+		[]byte{0x70, 0x79},                   // type 5: list<u32>
+		[]byte{0x6a, 0x01, 0x79, 0x01, 0x73}, // type 6: result<u32, string>
+		concat([]byte{0x40}, encodeVec(concat(encodeString("values"), []byte{0x05})), []byte{0x00, 0x06}), // type 7
+	)),
+	encodeSection(sectionIDCanon, encodeVec(
+		[]byte{0x00, 0x00, 0x02, 0x02, 0x03, 0x00, 0x04, 0x00, 0x02}, // func 1: greet
+		[]byte{0x00, 0x00, 0x03, 0x00, 0x04},                         // func 2: sum
+		[]byte{0x00, 0x00, 0x04, 0x02, 0x03, 0x00, 0x04, 0x00, 0x07}, // func 3: total
+	)),
+	encodeSection(sectionIDInstance, encodeVec(concat([]byte{0x01}, encodeVec( // instance 1
+		concat(encodeName("sum"), []byte{sortFunc, 0x02}),
+		concat(encodeName("total"), []byte{sortFunc, 0x03}),
+	)))),
+	encodeSection(sectionIDExport, encodeVec(
+		concat(encodeName("greet"), []byte{sortFunc, 0x01, 0x00}),
+		concat(encodeName("math"), []byte{sortInstance, 0x01, 0x00}),
+	)),
+)
+
+func TestComponent(t *testing.T) {
+	r := wazero.NewRuntime()
+	defer r.Close(testCtx)
+
+	compiled, err := Compile(testCtx, r, greeterWasm)
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	point := Record(Field{"x", S32}, Field{"y", S32})
+	require.Equal(t, map[string]*FuncType{
+		"logger#log": {Params: []Field{{"msg", String}}, Results: []*Type{U32}},
+	}, compiled.Imports())
+	require.Equal(t, map[string]*FuncType{
+		"greet":      {Params: []Field{{"name", String}}, Results: []*Type{String}},
+		"math#sum":   {Params: []Field{{"p", point}}, Results: []*Type{S32}},
+		"math#total": {Params: []Field{{"values", List(U32)}}, Results: []*Type{Result(U32, String)}},
+	}, compiled.Exports())
+
+	logger, err := ParseInterface(`interface logger { log: func(msg: string) -> u32 }`)
+	require.NoError(t, err)
+	var logged []string
+	imports := NewImports().ExportInterface("logger", logger, map[string]HostFunction{
+		"log": func(ctx context.Context, params []interface{}) ([]interface{}, error) {
+			msg := params[0].(string)
+			if msg == "fail" {
+				return nil, errors.New("cannot log fail")
+			}
+			logged = append(logged, msg)
+			return []interface{}{uint32(len(msg))}, nil
+		},
+	})
+
+	// Instantiate twice, to show the compiled component is reusable.
+	for i := 0; i < 2; i++ {
+		ns := r.NewNamespace(testCtx)
+		inst, err := Instantiate(testCtx, ns, compiled, imports)
+		require.NoError(t, err)
+
+		greet := inst.ExportedFunction("greet")
+		require.Equal(t, compiled.Exports()["greet"], greet.Type())
+		results, err := greet.Call(testCtx, "wazero ☃")
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{"wazero ☃"}, results)
+		require.Equal(t, []string{"wazero ☃"}, logged)
+		logged = nil
+
+		_, err = greet.Call(testCtx, "fail")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "cannot log fail")
+
+		_, err = greet.Call(testCtx, 1)
+		require.EqualError(t, err, "cannot lower int as string")
+		_, err = greet.Call(testCtx)
+		require.EqualError(t, err, "expected 1 params, but passed 0")
+
+		math := inst.ExportedInstance("math")
+		results, err = math.ExportedFunction("sum").Call(testCtx, map[string]interface{}{"x": int32(-1), "y": int32(3)})
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{int32(2)}, results)
+
+		total := math.ExportedFunction("total")
+		results, err = total.Call(testCtx, []interface{}{uint32(1), uint32(2), uint32(3)})
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{ResultValue{Value: uint32(6)}}, results)
+		results, err = total.Call(testCtx, []interface{}{})
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{ResultValue{IsErr: true, Value: "empty"}}, results)
+
+		require.Nil(t, inst.ExportedFunction("math"))
+		require.Nil(t, inst.ExportedInstance("greet"))
+		require.Nil(t, math.ExportedFunction("greet"))
+
+		require.NoError(t, inst.Close(testCtx))
+		_, err = greet.Call(testCtx, "closed")
+		require.Error(t, err)
+	}
+}
+
+func TestInstantiate_Errors(t *testing.T) {
+	r := wazero.NewRuntime()
+	defer r.Close(testCtx)
+
+	compiled, err := Compile(testCtx, r, greeterWasm)
+	require.NoError(t, err)
+
+	log := func(ctx context.Context, params []interface{}) ([]interface{}, error) { return nil, nil }
+	tests := []struct {
+		name        string
+		imports     Imports
+		expectedErr string
+	}{
+		{name: "no imports", expectedErr: "import logger#log: not found"},
+		{
+			name:        "function instead of instance",
+			imports:     NewImports().ExportFunction("logger", &FuncType{}, log),
+			expectedErr: "import logger#log: not found",
+		},
+		{
+			name: "type mismatch",
+			imports: NewImports().ExportInterface("logger", &Interface{Functions: map[string]*FuncType{
+				"log": {Params: []Field{{"message", String}}, Results: []*Type{U32}},
+			}}, map[string]HostFunction{"log": log}),
+			expectedErr: "import logger#log: type mismatch: func(message: string) -> u32 != func(msg: string) -> u32",
+		},
+		{
+			name:        "not in interface",
+			imports:     NewImports().ExportInterface("logger", &Interface{}, map[string]HostFunction{"log": log}),
+			expectedErr: "import logger#log: type mismatch: <nil> != func(msg: string) -> u32",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			ns := r.NewNamespace(testCtx)
+			defer ns.Close(testCtx)
+
+			_, err := Instantiate(testCtx, ns, compiled, tc.imports)
+			require.EqualError(t, err, tc.expectedErr)
+			// Modules instantiated before the error are closed.
+			require.Nil(t, ns.Module("core[0]"))
+		})
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	r := wazero.NewRuntime()
+	defer r.Close(testCtx)
+
+	_, err := Compile(testCtx, r, libcWasm)
+	require.EqualError(t, err, "invalid component: invalid version header: 0x01000000")
+
+	_, err = Compile(testCtx, r, encodeComponent(
+		encodeSection(sectionIDCoreModule, []byte("\x00asm")),
+		encodeSection(sectionIDCoreInstance, encodeVec([]byte{0x00, 0x00, 0x00})),
+	))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "core instance 0: ")
+}
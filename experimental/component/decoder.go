@@ -0,0 +1,955 @@
+package component
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/tetratelabs/wazero/internal/leb128"
+)
+
+// Magic is the 4 byte preamble (literally "\0asm") of the binary format, shared with core modules.
+var magic = []byte{0x00, 0x61, 0x73, 0x6D}
+
+// version is the version of the component binary format, and layer distinguishes components from core modules, whose
+// version is 1 and layer is 0.
+var versionAndLayer = []byte{0x0d, 0x00, 0x01, 0x00}
+
+// Section IDs of the component binary format.
+const (
+	sectionIDCustom       = 0
+	sectionIDCoreModule   = 1
+	sectionIDCoreInstance = 2
+	sectionIDCoreType     = 3
+	sectionIDComponent    = 4
+	sectionIDInstance     = 5
+	sectionIDAlias        = 6
+	sectionIDType         = 7
+	sectionIDCanon        = 8
+	sectionIDStart        = 9
+	sectionIDImport       = 10
+	sectionIDExport       = 11
+	sectionIDCount        = 12
+)
+
+// Sorts are the kinds of items of index spaces, which core items have two bytes of: sortCore and a core sort.
+const (
+	sortCore         byte = 0x00
+	sortFunc         byte = 0x01
+	sortType         byte = 0x03
+	sortInstance     byte = 0x05
+	coreSortFunc     byte = 0x00
+	coreSortTable    byte = 0x01
+	coreSortMemory   byte = 0x02
+	coreSortGlobal   byte = 0x03
+	coreSortInstance byte = 0x12
+)
+
+// coreRef is a core function, memory, table or global: the export of a module instantiated in the namespace.
+type coreRef struct {
+	module, name string
+}
+
+// coreInstance is a core instance, either of a module instantiated with its imports from other core instances, or of
+// inline exports of core items.
+type coreInstance struct {
+	// module is the index of the core module, or -1 for inline exports.
+	module int
+	// args are the core instances which satisfy the imports of the module by module name.
+	args map[string]uint32
+	// exports are the inline exports by name.
+	exports map[string]coreRef
+}
+
+// instanceType is the type of an instance, which is the functions and types it exports by name.
+type instanceType struct {
+	funcs map[string]*FuncType
+	types map[string]*typeDef
+}
+
+// typeDef is an entry of the type index space, which is exactly one of a value, function or instance type.
+type typeDef struct {
+	val  *Type
+	fn   *FuncType
+	inst *instanceType
+}
+
+// canonOptions are the canonical options of a lifted or lowered function.
+type canonOptions struct {
+	memory, realloc, postReturn *coreRef
+}
+
+// function is a function of the component, either imported or lifted from a core function.
+type function struct {
+	typ *FuncType
+
+	// importName is the name of the imported function, or of the imported instance which exports it as exportName.
+	importName, exportName string
+
+	// lifted is the core function lifted with options, when not imported.
+	lifted *coreRef
+	opts   canonOptions
+}
+
+// instance is an instance of the component, either imported or of inline exports of functions.
+type instance struct {
+	// importName is the name of the imported instance, when not inline.
+	importName string
+	typ        *instanceType
+
+	// funcs are the indexes of the functions of inline exports by name.
+	funcs map[string]uint32
+}
+
+// componentImport is an import of the component, which is a function or an instance.
+type componentImport struct {
+	name string
+	fn   *FuncType
+	inst *instanceType
+}
+
+// componentExport is an export of the component, which is a function or an instance.
+type componentExport struct {
+	sort  byte
+	index uint32
+}
+
+// lowering is a core function lowered from an imported function, which is a host module of the name.
+type lowering struct {
+	module string
+	fn     uint32
+	opts   canonOptions
+}
+
+// step is a step to instantiate a component, in the order of the binary: instantiating a core module or lowering a
+// function.
+type step struct {
+	coreInstance *uint32
+	lowering     *uint32
+}
+
+// decodedComponent is the index spaces of a component binary.
+type decodedComponent struct {
+	coreModules   [][]byte
+	coreInstances []*coreInstance
+	coreFuncs     []coreRef
+	coreMemories  []coreRef
+	coreTables    []coreRef
+	coreGlobals   []coreRef
+
+	types     []*typeDef
+	funcs     []*function
+	instances []*instance
+
+	imports   []*componentImport
+	exports   map[string]componentExport
+	lowerings []*lowering
+	steps     []step
+}
+
+// coreInstanceName is the name in the namespace of the module of a core instance.
+func coreInstanceName(i uint32) string {
+	return fmt.Sprintf("core[%d]", i)
+}
+
+// decodeComponent decodes the index spaces of a component binary.
+func decodeComponent(binary []byte) (*decodedComponent, error) {
+	r := bytes.NewReader(binary)
+	// Magic number.
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(r, buf); err != nil || !bytes.Equal(buf, magic) {
+		return nil, errors.New("invalid magic number")
+	}
+	if _, err := io.ReadFull(r, buf); err != nil || !bytes.Equal(buf, versionAndLayer) {
+		return nil, fmt.Errorf("invalid version header: %#x", buf)
+	}
+
+	d := &decodedComponent{exports: map[string]componentExport{}}
+	for {
+		sectionID, err := r.ReadByte()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("read section id: %w", err)
+		}
+		sectionSize, _, err := leb128.DecodeUint32(r)
+		if err != nil {
+			return nil, fmt.Errorf("get size of section %d: %v", sectionID, err)
+		} else if int(sectionSize) > r.Len() {
+			return nil, fmt.Errorf("section %d size %d exceeds the remaining %d bytes", sectionID, sectionSize, r.Len())
+		}
+		contents := make([]byte, sectionSize)
+		_, _ = r.Read(contents)
+		sr := bytes.NewReader(contents)
+
+		switch sectionID {
+		case sectionIDCustom:
+			continue
+		case sectionIDCoreModule:
+			d.coreModules = append(d.coreModules, contents)
+			continue
+		case sectionIDCoreInstance:
+			err = decodeVec(sr, d.decodeCoreInstance)
+		case sectionIDInstance:
+			err = decodeVec(sr, d.decodeInstance)
+		case sectionIDAlias:
+			err = decodeVec(sr, d.decodeAlias)
+		case sectionIDType:
+			err = decodeVec(sr, func(r *bytes.Reader) error {
+				t, err := decodeTypeDef(r, d.types, nil)
+				if err == nil {
+					d.types = append(d.types, t)
+				}
+				return err
+			})
+		case sectionIDCanon:
+			err = decodeVec(sr, d.decodeCanon)
+		case sectionIDImport:
+			err = decodeVec(sr, d.decodeImport)
+		case sectionIDExport:
+			err = decodeVec(sr, d.decodeExport)
+		case sectionIDCoreType:
+			err = errors.New("core types are not supported")
+		case sectionIDComponent:
+			err = errors.New("nested components are not supported")
+		case sectionIDStart:
+			err = errors.New("start functions are not supported")
+		default:
+			err = errors.New("unknown section")
+		}
+		if err == nil && sr.Len() > 0 {
+			err = fmt.Errorf("%d bytes remaining", sr.Len())
+		}
+		if err != nil {
+			return nil, fmt.Errorf("section %s: %w", sectionIDName(sectionID), err)
+		}
+	}
+	return d, nil
+}
+
+var sectionIDNames = [...]string{
+	sectionIDCustom:       "custom",
+	sectionIDCoreModule:   "core module",
+	sectionIDCoreInstance: "core instance",
+	sectionIDCoreType:     "core type",
+	sectionIDComponent:    "component",
+	sectionIDInstance:     "instance",
+	sectionIDAlias:        "alias",
+	sectionIDType:         "type",
+	sectionIDCanon:        "canon",
+	sectionIDStart:        "start",
+	sectionIDImport:       "import",
+	sectionIDExport:       "export",
+}
+
+func sectionIDName(sectionID byte) string {
+	if sectionID < sectionIDCount {
+		return sectionIDNames[sectionID]
+	}
+	return fmt.Sprintf("unknown(%d)", sectionID)
+}
+
+// decodeVec decodes a vector of items with decode.
+func decodeVec(r *bytes.Reader, decode func(r *bytes.Reader) error) error {
+	n, _, err := leb128.DecodeUint32(r)
+	if err != nil {
+		return fmt.Errorf("get size of vector: %w", err)
+	}
+	for i := uint32(0); i < n; i++ {
+		if err = decode(r); err != nil {
+			return fmt.Errorf("[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func decodeString(r *bytes.Reader) (string, error) {
+	n, _, err := leb128.DecodeUint32(r)
+	if err != nil {
+		return "", fmt.Errorf("get size of string: %w", err)
+	} else if int(n) > r.Len() {
+		return "", fmt.Errorf("string of %d bytes exceeds the remaining %d bytes", n, r.Len())
+	}
+	b := make([]byte, n)
+	_, _ = r.Read(b)
+	return string(b), nil
+}
+
+// decodeExternName decodes the name of an import or export, which is prefixed with 0x00.
+func decodeExternName(r *bytes.Reader) (string, error) {
+	if b, err := r.ReadByte(); err != nil {
+		return "", err
+	} else if b != 0x00 {
+		return "", fmt.Errorf("unsupported name kind %#x", b)
+	}
+	return decodeString(r)
+}
+
+func decodeIndex(r *bytes.Reader, what string, size int) (uint32, error) {
+	i, _, err := leb128.DecodeUint32(r)
+	if err != nil {
+		return 0, fmt.Errorf("read %s index: %w", what, err)
+	} else if int(i) >= size {
+		return 0, fmt.Errorf("%s index %d out of range", what, i)
+	}
+	return i, nil
+}
+
+func (d *decodedComponent) decodeCoreInstance(r *bytes.Reader) error {
+	kind, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	switch kind {
+	case 0x00: // instantiate
+		m, err := decodeIndex(r, "core module", len(d.coreModules))
+		if err != nil {
+			return err
+		}
+		inst := &coreInstance{module: int(m), args: map[string]uint32{}}
+		if err = decodeVec(r, func(r *bytes.Reader) error {
+			name, err := decodeString(r)
+			if err != nil {
+				return err
+			}
+			if sort, err := r.ReadByte(); err != nil {
+				return err
+			} else if sort != coreSortInstance {
+				return fmt.Errorf("argument %s is not a core instance", name)
+			}
+			inst.args[name], err = decodeIndex(r, "core instance", len(d.coreInstances))
+			return err
+		}); err != nil {
+			return err
+		}
+		i := uint32(len(d.coreInstances))
+		d.coreInstances = append(d.coreInstances, inst)
+		d.steps = append(d.steps, step{coreInstance: &i})
+		return nil
+	case 0x01: // inline exports
+		inst := &coreInstance{module: -1, exports: map[string]coreRef{}}
+		if err = decodeVec(r, func(r *bytes.Reader) error {
+			name, err := decodeString(r)
+			if err != nil {
+				return err
+			}
+			sort, err := r.ReadByte()
+			if err != nil {
+				return err
+			}
+			space, err := d.coreSpace(sort)
+			if err != nil {
+				return err
+			}
+			i, err := decodeIndex(r, "core item", len(*space))
+			if err != nil {
+				return err
+			}
+			inst.exports[name] = (*space)[i]
+			return nil
+		}); err != nil {
+			return err
+		}
+		d.coreInstances = append(d.coreInstances, inst)
+		return nil
+	}
+	return fmt.Errorf("invalid core instance kind %#x", kind)
+}
+
+// coreSpace returns the index space of the core sort.
+func (d *decodedComponent) coreSpace(sort byte) (*[]coreRef, error) {
+	switch sort {
+	case coreSortFunc:
+		return &d.coreFuncs, nil
+	case coreSortTable:
+		return &d.coreTables, nil
+	case coreSortMemory:
+		return &d.coreMemories, nil
+	case coreSortGlobal:
+		return &d.coreGlobals, nil
+	}
+	return nil, fmt.Errorf("unsupported core sort %#x", sort)
+}
+
+func (d *decodedComponent) decodeInstance(r *bytes.Reader) error {
+	kind, err := r.ReadByte()
+	if err != nil {
+		return err
+	} else if kind != 0x01 {
+		return errors.New("instantiating components is not supported")
+	}
+	inst := &instance{typ: &instanceType{funcs: map[string]*FuncType{}}, funcs: map[string]uint32{}}
+	if err = decodeVec(r, func(r *bytes.Reader) error {
+		name, err := decodeExternName(r)
+		if err != nil {
+			return err
+		}
+		if sort, err := r.ReadByte(); err != nil {
+			return err
+		} else if sort != sortFunc {
+			return fmt.Errorf("export %s: only functions are supported", name)
+		}
+		f, err := decodeIndex(r, "func", len(d.funcs))
+		if err != nil {
+			return err
+		}
+		inst.funcs[name] = f
+		inst.typ.funcs[name] = d.funcs[f].typ
+		return nil
+	}); err != nil {
+		return err
+	}
+	d.instances = append(d.instances, inst)
+	return nil
+}
+
+func (d *decodedComponent) decodeAlias(r *bytes.Reader) error {
+	sort, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	var coreSort byte
+	if sort == sortCore {
+		if coreSort, err = r.ReadByte(); err != nil {
+			return err
+		}
+	}
+	target, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+
+	switch target {
+	case 0x00: // export of an instance
+		i, err := decodeIndex(r, "instance", len(d.instances))
+		if err != nil {
+			return err
+		}
+		name, err := decodeString(r)
+		if err != nil {
+			return err
+		}
+		inst := d.instances[i]
+		if sort == sortType {
+			t, ok := inst.typ.types[name]
+			if !ok {
+				return fmt.Errorf("instance %d has no type %s", i, name)
+			}
+			d.types = append(d.types, t)
+			return nil
+		} else if sort != sortFunc {
+			return fmt.Errorf("alias of %s: only functions and types are supported", name)
+		}
+		if inst.funcs != nil {
+			f, ok := inst.funcs[name]
+			if !ok {
+				return fmt.Errorf("instance %d has no function %s", i, name)
+			}
+			d.funcs = append(d.funcs, d.funcs[f])
+			return nil
+		}
+		typ, ok := inst.typ.funcs[name]
+		if !ok {
+			return fmt.Errorf("instance %d has no function %s", i, name)
+		}
+		d.funcs = append(d.funcs, &function{typ: typ, importName: inst.importName, exportName: name})
+		return nil
+	case 0x01: // export of a core instance
+		if sort != sortCore {
+			return fmt.Errorf("invalid sort %#x of core export", sort)
+		}
+		i, err := decodeIndex(r, "core instance", len(d.coreInstances))
+		if err != nil {
+			return err
+		}
+		name, err := decodeString(r)
+		if err != nil {
+			return err
+		}
+		space, err := d.coreSpace(coreSort)
+		if err != nil {
+			return err
+		}
+		inst := d.coreInstances[i]
+		ref := coreRef{module: coreInstanceName(i), name: name}
+		if inst.exports != nil {
+			var ok bool
+			if ref, ok = inst.exports[name]; !ok {
+				return fmt.Errorf("core instance %d has no export %s", i, name)
+			}
+		}
+		*space = append(*space, ref)
+		return nil
+	case 0x02:
+		return errors.New("outer aliases are only supported in instance types")
+	}
+	return fmt.Errorf("invalid alias target %#x", target)
+}
+
+func (d *decodedComponent) decodeCanon(r *bytes.Reader) error {
+	kind, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	if b, err := r.ReadByte(); err != nil {
+		return err
+	} else if b != 0x00 {
+		return fmt.Errorf("invalid canon %#x %#x", kind, b)
+	}
+
+	switch kind {
+	case 0x00: // lift
+		f, err := decodeIndex(r, "core func", len(d.coreFuncs))
+		if err != nil {
+			return err
+		}
+		opts, err := d.decodeCanonOptions(r)
+		if err != nil {
+			return err
+		}
+		t, err := decodeIndex(r, "type", len(d.types))
+		if err != nil {
+			return err
+		} else if d.types[t].fn == nil {
+			return fmt.Errorf("type %d is not a function type", t)
+		}
+		ref := d.coreFuncs[f]
+		d.funcs = append(d.funcs, &function{typ: d.types[t].fn, lifted: &ref, opts: opts})
+		return nil
+	case 0x01: // lower
+		f, err := decodeIndex(r, "func", len(d.funcs))
+		if err != nil {
+			return err
+		}
+		if d.funcs[f].lifted != nil {
+			return errors.New("lowering a lifted function is not supported")
+		}
+		opts, err := d.decodeCanonOptions(r)
+		if err != nil {
+			return err
+		}
+		i := uint32(len(d.lowerings))
+		l := &lowering{module: fmt.Sprintf("lower[%d]", i), fn: f, opts: opts}
+		d.lowerings = append(d.lowerings, l)
+		d.coreFuncs = append(d.coreFuncs, coreRef{module: l.module, name: "func"})
+		d.steps = append(d.steps, step{lowering: &i})
+		return nil
+	}
+	return fmt.Errorf("unsupported canon %#x", kind)
+}
+
+func (d *decodedComponent) decodeCanonOptions(r *bytes.Reader) (opts canonOptions, err error) {
+	err = decodeVec(r, func(r *bytes.Reader) error {
+		opt, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		var space []coreRef
+		var target **coreRef
+		switch opt {
+		case 0x00: // string-encoding=utf8
+			return nil
+		case 0x01, 0x02:
+			return errors.New("only the UTF-8 string encoding is supported")
+		case 0x03:
+			space, target = d.coreMemories, &opts.memory
+		case 0x04:
+			space, target = d.coreFuncs, &opts.realloc
+		case 0x05:
+			space, target = d.coreFuncs, &opts.postReturn
+		default:
+			return fmt.Errorf("invalid canonical option %#x", opt)
+		}
+		i, err := decodeIndex(r, "core item", len(space))
+		if err != nil {
+			return err
+		}
+		ref := space[i]
+		*target = &ref
+		return nil
+	})
+	return
+}
+
+func (d *decodedComponent) decodeImport(r *bytes.Reader) error {
+	name, err := decodeExternName(r)
+	if err != nil {
+		return err
+	}
+	sort, t, err := decodeExternDesc(r, d.types)
+	if err != nil {
+		return fmt.Errorf("import %s: %w", name, err)
+	}
+	switch sort {
+	case sortFunc:
+		d.imports = append(d.imports, &componentImport{name: name, fn: t.fn})
+		d.funcs = append(d.funcs, &function{typ: t.fn, importName: name})
+	case sortInstance:
+		d.imports = append(d.imports, &componentImport{name: name, inst: t.inst})
+		d.instances = append(d.instances, &instance{importName: name, typ: t.inst})
+	case sortType:
+		d.types = append(d.types, t)
+	default:
+		return fmt.Errorf("import %s: only functions, instances and types are supported", name)
+	}
+	return nil
+}
+
+func (d *decodedComponent) decodeExport(r *bytes.Reader) error {
+	name, err := decodeExternName(r)
+	if err != nil {
+		return err
+	}
+	sort, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	if _, ok := d.exports[name]; ok {
+		return fmt.Errorf("duplicate export %s", name)
+	}
+
+	var i uint32
+	switch sort {
+	case sortFunc:
+		if i, err = decodeIndex(r, "func", len(d.funcs)); err == nil {
+			d.funcs = append(d.funcs, d.funcs[i])
+		}
+	case sortInstance:
+		if i, err = decodeIndex(r, "instance", len(d.instances)); err == nil {
+			d.instances = append(d.instances, d.instances[i])
+		}
+	case sortType:
+		if i, err = decodeIndex(r, "type", len(d.types)); err == nil {
+			d.types = append(d.types, d.types[i])
+		}
+	default:
+		return fmt.Errorf("export %s: only functions, instances and types are supported", name)
+	}
+	if err != nil {
+		return err
+	}
+
+	// An optional type ascription, which must be the same type as this doesn't support subtyping.
+	if b, err := r.ReadByte(); err != nil {
+		return err
+	} else if b == 0x01 {
+		if _, _, err = decodeExternDesc(r, d.types); err != nil {
+			return fmt.Errorf("export %s: %w", name, err)
+		}
+	} else if b != 0x00 {
+		return fmt.Errorf("export %s: invalid type ascription %#x", name, b)
+	}
+
+	if sort != sortType {
+		d.exports[name] = componentExport{sort: sort, index: i}
+	}
+	return nil
+}
+
+// decodeExternDesc decodes the type of an import or export, returning its sort. The type of a type bound is the type
+// it is equal to.
+func decodeExternDesc(r *bytes.Reader, types []*typeDef) (byte, *typeDef, error) {
+	sort, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	switch sort {
+	case sortFunc, sortInstance:
+		i, err := decodeIndex(r, "type", len(types))
+		if err != nil {
+			return 0, nil, err
+		}
+		t := types[i]
+		if sort == sortFunc && t.fn == nil {
+			return 0, nil, fmt.Errorf("type %d is not a function type", i)
+		} else if sort == sortInstance && t.inst == nil {
+			return 0, nil, fmt.Errorf("type %d is not an instance type", i)
+		}
+		return sort, t, nil
+	case sortType:
+		if b, err := r.ReadByte(); err != nil {
+			return 0, nil, err
+		} else if b != 0x00 {
+			return 0, nil, errors.New("resource types are not supported")
+		}
+		i, err := decodeIndex(r, "type", len(types))
+		if err != nil {
+			return 0, nil, err
+		}
+		return sort, types[i], nil
+	}
+	return 0, nil, fmt.Errorf("unsupported extern sort %#x", sort)
+}
+
+// decodeTypeDef decodes an entry of the type index space. outer is the type index space of the component enclosing an
+// instance type, or nil at the component level.
+func decodeTypeDef(r *bytes.Reader, types, outer []*typeDef) (*typeDef, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch b {
+	case 0x40:
+		fn, err := decodeFuncType(r, types)
+		if err != nil {
+			return nil, err
+		}
+		return &typeDef{fn: fn}, nil
+	case 0x42:
+		if outer != nil {
+			return nil, errors.New("nested instance types are not supported")
+		}
+		inst, err := decodeInstanceType(r, types)
+		if err != nil {
+			return nil, err
+		}
+		return &typeDef{inst: inst}, nil
+	case 0x41:
+		return nil, errors.New("component types are not supported")
+	}
+	if err = r.UnreadByte(); err != nil {
+		return nil, err
+	}
+	val, err := decodeDefValType(r, types)
+	if err != nil {
+		return nil, err
+	}
+	return &typeDef{val: val}, nil
+}
+
+func decodeFuncType(r *bytes.Reader, types []*typeDef) (*FuncType, error) {
+	params, err := decodeLabeledTypes(r, types)
+	if err != nil {
+		return nil, fmt.Errorf("params: %w", err)
+	}
+	ret := &FuncType{Params: params}
+	b, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch b {
+	case 0x00:
+		t, err := decodeValType(r, types)
+		if err != nil {
+			return nil, fmt.Errorf("result: %w", err)
+		}
+		ret.Results = []*Type{t}
+	case 0x01:
+		results, err := decodeLabeledTypes(r, types)
+		if err != nil {
+			return nil, fmt.Errorf("results: %w", err)
+		}
+		for _, f := range results {
+			ret.Results = append(ret.Results, f.Type)
+		}
+	default:
+		return nil, fmt.Errorf("invalid result list %#x", b)
+	}
+	return ret, nil
+}
+
+func decodeInstanceType(r *bytes.Reader, outer []*typeDef) (*instanceType, error) {
+	ret := &instanceType{funcs: map[string]*FuncType{}, types: map[string]*typeDef{}}
+	var types []*typeDef // The instance type has its own type index space.
+	err := decodeVec(r, func(r *bytes.Reader) error {
+		b, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		switch b {
+		case 0x01: // type
+			t, err := decodeTypeDef(r, types, outer)
+			if err != nil {
+				return err
+			}
+			types = append(types, t)
+			return nil
+		case 0x02: // alias
+			if sort, err := r.ReadByte(); err != nil {
+				return err
+			} else if sort != sortType {
+				return errors.New("only aliases of types are supported")
+			}
+			if target, err := r.ReadByte(); err != nil {
+				return err
+			} else if target != 0x02 {
+				return errors.New("only outer aliases are supported")
+			}
+			if count, _, err := leb128.DecodeUint32(r); err != nil {
+				return err
+			} else if count != 1 {
+				return fmt.Errorf("outer alias count %d out of range", count)
+			}
+			i, err := decodeIndex(r, "outer type", len(outer))
+			if err != nil {
+				return err
+			}
+			types = append(types, outer[i])
+			return nil
+		case 0x04: // export
+			name, err := decodeExternName(r)
+			if err != nil {
+				return err
+			}
+			sort, t, err := decodeExternDesc(r, types)
+			if err != nil {
+				return fmt.Errorf("export %s: %w", name, err)
+			}
+			switch sort {
+			case sortFunc:
+				ret.funcs[name] = t.fn
+			case sortType:
+				ret.types[name] = t
+				types = append(types, t)
+			default:
+				return fmt.Errorf("export %s: only functions and types are supported", name)
+			}
+			return nil
+		}
+		return fmt.Errorf("unsupported instance declaration %#x", b)
+	})
+	return ret, err
+}
+
+func decodeLabeledTypes(r *bytes.Reader, types []*typeDef) (ret []Field, err error) {
+	err = decodeVec(r, func(r *bytes.Reader) error {
+		name, err := decodeString(r)
+		if err != nil {
+			return err
+		}
+		t, err := decodeValType(r, types)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		ret = append(ret, Field{Name: name, Type: t})
+		return nil
+	})
+	return
+}
+
+// primitiveTypes are the primitive value types by their byte in the binary format.
+var primitiveTypes = map[byte]*Type{
+	0x7f: Bool, 0x7e: S8, 0x7d: U8, 0x7c: S16, 0x7b: U16, 0x7a: S32, 0x79: U32, 0x78: S64, 0x77: U64, 0x76: F32,
+	0x75: F64, 0x74: Char, 0x73: String,
+}
+
+// decodeValType decodes a primitive value type or the index of a value type.
+func decodeValType(r *bytes.Reader, types []*typeDef) (*Type, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := primitiveTypes[b]; ok {
+		return t, nil
+	}
+	if err = r.UnreadByte(); err != nil {
+		return nil, err
+	}
+	i, err := decodeIndex(r, "type", len(types))
+	if err != nil {
+		return nil, err
+	} else if types[i].val == nil {
+		return nil, fmt.Errorf("type %d is not a value type", i)
+	}
+	return types[i].val, nil
+}
+
+// decodeOptionalValType decodes a value type prefixed with 0x01, or 0x00 for none.
+func decodeOptionalValType(r *bytes.Reader, types []*typeDef) (*Type, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch b {
+	case 0x00:
+		return nil, nil
+	case 0x01:
+		return decodeValType(r, types)
+	}
+	return nil, fmt.Errorf("invalid optional value type %#x", b)
+}
+
+func decodeDefValType(r *bytes.Reader, types []*typeDef) (*Type, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := primitiveTypes[b]; ok {
+		return t, nil
+	}
+	switch b {
+	case 0x72:
+		fields, err := decodeLabeledTypes(r, types)
+		if err != nil {
+			return nil, fmt.Errorf("record: %w", err)
+		}
+		return Record(fields...), nil
+	case 0x71:
+		var cases []Case
+		if err = decodeVec(r, func(r *bytes.Reader) error {
+			name, err := decodeString(r)
+			if err != nil {
+				return err
+			}
+			t, err := decodeOptionalValType(r, types)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			if refines, err := r.ReadByte(); err != nil {
+				return err
+			} else if refines != 0x00 {
+				return fmt.Errorf("%s: refinements are not supported", name)
+			}
+			cases = append(cases, Case{Name: name, Type: t})
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("variant: %w", err)
+		}
+		return Variant(cases...), nil
+	case 0x70, 0x6b:
+		elem, err := decodeValType(r, types)
+		if err != nil {
+			return nil, err
+		}
+		if b == 0x70 {
+			return List(elem), nil
+		}
+		return Option(elem), nil
+	case 0x6f:
+		var elems []*Type
+		if err = decodeVec(r, func(r *bytes.Reader) error {
+			t, err := decodeValType(r, types)
+			elems = append(elems, t)
+			return err
+		}); err != nil {
+			return nil, fmt.Errorf("tuple: %w", err)
+		}
+		return Tuple(elems...), nil
+	case 0x6d:
+		var names []string
+		if err = decodeVec(r, func(r *bytes.Reader) error {
+			name, err := decodeString(r)
+			names = append(names, name)
+			return err
+		}); err != nil {
+			return nil, fmt.Errorf("enum: %w", err)
+		}
+		return Enum(names...), nil
+	case 0x6a:
+		ok, err := decodeOptionalValType(r, types)
+		if err != nil {
+			return nil, fmt.Errorf("result: %w", err)
+		}
+		e, err := decodeOptionalValType(r, types)
+		if err != nil {
+			return nil, fmt.Errorf("result: %w", err)
+		}
+		return Result(ok, e), nil
+	case 0x6e:
+		return nil, errors.New("flags are not supported")
+	case 0x69, 0x68:
+		return nil, errors.New("resources are not supported")
+	}
+	return nil, fmt.Errorf("invalid value type %#x", b)
+}
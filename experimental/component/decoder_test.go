@@ -0,0 +1,150 @@
+package component
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestDecodeComponent(t *testing.T) {
+	point := Record(Field{"x", S32}, Field{"y", S32})
+	pointType := concat([]byte{0x72}, encodeVec(
+		concat(encodeString("x"), []byte{0x7a}),
+		concat(encodeString("y"), []byte{0x7a}),
+	))
+
+	t.Run("empty", func(t *testing.T) {
+		d, err := decodeComponent(encodeComponent(encodeSection(sectionIDCustom, encodeString("name"))))
+		require.NoError(t, err)
+		require.Equal(t, &decodedComponent{exports: map[string]componentExport{}}, d)
+	})
+
+	t.Run("types of an imported instance", func(t *testing.T) {
+		// (type (instance (alias outer 1 0 (type)) (export "point" (type (eq 0))) (export "norm" (func ...))))
+		d, err := decodeComponent(encodeComponent(
+			encodeSection(sectionIDType, encodeVec(
+				pointType, // type 0
+				concat([]byte{0x42}, encodeVec( // type 1
+					[]byte{0x02, sortType, 0x02, 0x01, 0x00},
+					concat([]byte{0x04}, encodeName("point"), []byte{sortType, 0x00, 0x00}),
+					concat([]byte{0x01, 0x40}, encodeVec(concat(encodeString("p"), []byte{0x01})), []byte{0x01, 0x00}),
+					concat([]byte{0x04}, encodeName("norm"), []byte{sortFunc, 0x02}),
+				)),
+			)),
+			encodeSection(sectionIDImport, encodeVec(concat(encodeName("geometry"), []byte{sortInstance, 0x01}))),
+			encodeSection(sectionIDAlias, encodeVec(
+				concat([]byte{sortType, 0x00, 0x00}, encodeString("point")), // type 2
+				concat([]byte{sortFunc, 0x00, 0x00}, encodeString("norm")),  // func 0
+			)),
+			encodeSection(sectionIDExport, encodeVec(concat(encodeName("norm"), []byte{sortFunc, 0x00, 0x00}))),
+		))
+		require.NoError(t, err)
+
+		norm := &FuncType{Params: []Field{{"p", point}}}
+		require.Equal(t, point, d.types[2].val)
+		require.Equal(t, []*componentImport{{name: "geometry", inst: d.types[1].inst}}, d.imports)
+		require.Equal(t, norm, d.types[1].inst.funcs["norm"])
+		require.Equal(t, &function{typ: norm, importName: "geometry", exportName: "norm"}, d.funcs[0])
+		require.Equal(t, map[string]componentExport{"norm": {sort: sortFunc, index: 0}}, d.exports)
+		require.Equal(t, 2, len(d.funcs)) // exports add to the index space
+	})
+}
+
+func TestDecodeComponent_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       []byte
+		expectedErr string
+	}{
+		{name: "empty", input: []byte{}, expectedErr: "invalid magic number"},
+		{name: "core module", input: libcWasm, expectedErr: "invalid version header: 0x01000000"},
+		{
+			name:        "section too long",
+			input:       encodeComponent([]byte{sectionIDType, 0x05, 0x00}),
+			expectedErr: "section 7 size 5 exceeds the remaining 1 bytes",
+		},
+		{
+			name:        "bytes remaining",
+			input:       encodeComponent(encodeSection(sectionIDType, []byte{0x00, 0x00})),
+			expectedErr: "section type: 1 bytes remaining",
+		},
+		{
+			name:        "unknown section",
+			input:       encodeComponent(encodeSection(12, nil)),
+			expectedErr: "section unknown(12): unknown section",
+		},
+		{
+			name:        "nested component",
+			input:       encodeComponent(encodeSection(sectionIDComponent, nil)),
+			expectedErr: "section component: nested components are not supported",
+		},
+		{
+			name:        "start",
+			input:       encodeComponent(encodeSection(sectionIDStart, nil)),
+			expectedErr: "section start: start functions are not supported",
+		},
+		{
+			name:        "flags",
+			input:       encodeComponent(encodeSection(sectionIDType, encodeVec(concat([]byte{0x6e}, encodeVec())))),
+			expectedErr: "section type: [0]: flags are not supported",
+		},
+		{
+			name:        "type index out of range",
+			input:       encodeComponent(encodeSection(sectionIDType, encodeVec([]byte{0x70, 0x00}))),
+			expectedErr: "section type: [0]: type index 0 out of range",
+		},
+		{
+			name: "not a value type",
+			input: encodeComponent(encodeSection(sectionIDType, encodeVec(
+				concat([]byte{0x40}, encodeVec(), []byte{0x01}, encodeVec()),
+				[]byte{0x70, 0x00},
+			))),
+			expectedErr: "section type: [1]: type 0 is not a value type",
+		},
+		{
+			name: "import of a value type",
+			input: encodeComponent(
+				encodeSection(sectionIDType, encodeVec([]byte{0x73})),
+				encodeSection(sectionIDImport, encodeVec(concat(encodeName("f"), []byte{sortFunc, 0x00}))),
+			),
+			expectedErr: "section import: [0]: import f: type 0 is not a function type",
+		},
+		{
+			name: "utf16",
+			input: encodeComponent(
+				encodeSection(sectionIDType, encodeVec(concat([]byte{0x40}, encodeVec(), []byte{0x01}, encodeVec()))),
+				encodeSection(sectionIDImport, encodeVec(concat(encodeName("f"), []byte{sortFunc, 0x00}))),
+				encodeSection(sectionIDCanon, encodeVec([]byte{0x01, 0x00, 0x00, 0x01, 0x01})),
+			),
+			expectedErr: "section canon: [0]: [0]: only the UTF-8 string encoding is supported",
+		},
+		{
+			name: "missing core export",
+			input: encodeComponent(
+				encodeSection(sectionIDCoreInstance, encodeVec([]byte{0x01, 0x00})),
+				encodeSection(sectionIDAlias, encodeVec(concat([]byte{sortCore, coreSortFunc, 0x01, 0x00}, encodeString("f")))),
+			),
+			expectedErr: "section alias: [0]: core instance 0 has no export f",
+		},
+		{
+			name: "duplicate export",
+			input: encodeComponent(
+				encodeSection(sectionIDType, encodeVec(concat([]byte{0x40}, encodeVec(), []byte{0x01}, encodeVec()))),
+				encodeSection(sectionIDImport, encodeVec(concat(encodeName("f"), []byte{sortFunc, 0x00}))),
+				encodeSection(sectionIDExport, encodeVec(
+					concat(encodeName("g"), []byte{sortFunc, 0x00, 0x00}),
+					concat(encodeName("g"), []byte{sortFunc, 0x00, 0x00}),
+				)),
+			),
+			expectedErr: "section export: [1]: duplicate export g",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := decodeComponent(tc.input)
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,348 @@
+package component
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind is the kind of a Type.
+type Kind byte
+
+const (
+	KindBool Kind = iota + 1
+	KindS8
+	KindU8
+	KindS16
+	KindU16
+	KindS32
+	KindU32
+	KindS64
+	KindU64
+	KindF32
+	KindF64
+	KindChar
+	KindString
+	KindList
+	KindRecord
+	KindTuple
+	KindVariant
+	KindEnum
+	KindOption
+	KindResult
+)
+
+var kindNames = [...]string{
+	KindBool:    "bool",
+	KindS8:      "s8",
+	KindU8:      "u8",
+	KindS16:     "s16",
+	KindU16:     "u16",
+	KindS32:     "s32",
+	KindU32:     "u32",
+	KindS64:     "s64",
+	KindU64:     "u64",
+	KindF32:     "f32",
+	KindF64:     "f64",
+	KindChar:    "char",
+	KindString:  "string",
+	KindList:    "list",
+	KindRecord:  "record",
+	KindTuple:   "tuple",
+	KindVariant: "variant",
+	KindEnum:    "enum",
+	KindOption:  "option",
+	KindResult:  "result",
+}
+
+// String returns the name of the kind in WIT, ex. "u32" or "record".
+func (k Kind) String() string {
+	if int(k) < len(kindNames) && kindNames[k] != "" {
+		return kindNames[k]
+	}
+	return fmt.Sprintf("unknown(%d)", k)
+}
+
+// Type is a value type of the component model. Primitive types are the variables of the same name, ex. String, and
+// others are created with the functions of the same name, ex. List(String).
+//
+// Types are structural: two records are the same type when their fields have the same names and types, regardless of
+// the name of the type in WIT.
+//
+// The Go values of each kind of type, which are the parameters and results of Function and HostFunction, are:
+//	* bool: bool
+//	* s8, s16, s32 and s64: int8, int16, int32 and int64
+//	* u8, u16, u32 and u64: uint8, uint16, uint32 and uint64
+//	* f32 and f64: float32 and float64
+//	* char: rune
+//	* string: string
+//	* list: []interface{} of the values of the element, except []byte for list<u8>
+//	* record: map[string]interface{} of the values of the fields by their names
+//	* tuple: []interface{} of the values of the types
+//	* variant: VariantValue
+//	* enum: string, the name of the case
+//	* option: nil for none, otherwise the value of the type. So, option<option<T>> can't be represented.
+//	* result: ResultValue
+type Type struct {
+	Kind Kind
+
+	// Elem is the type of the elements of a list, or the type of the value of an option.
+	Elem *Type
+
+	// Fields are the fields of a record, or the types of a tuple, which have no names.
+	Fields []Field
+
+	// Cases are the cases of a variant, or the names of the cases of an enum, which have no types.
+	Cases []Case
+
+	// Ok and Err are the types of the values of a result, which are nil when the case has no value.
+	Ok, Err *Type
+}
+
+// Field is a field of a record or a parameter of a function.
+type Field struct {
+	Name string
+	Type *Type
+}
+
+// Case is a case of a variant. Type is nil when the case has no value.
+type Case struct {
+	Name string
+	Type *Type
+}
+
+// VariantValue is the Go value of a variant: the name of its case, and the value of the case, which is nil when the
+// case has no type.
+type VariantValue struct {
+	Case  string
+	Value interface{}
+}
+
+// ResultValue is the Go value of a result: whether it is the error case, and the value of the case, which is nil when
+// the case has no type.
+type ResultValue struct {
+	IsErr bool
+	Value interface{}
+}
+
+// Primitive types.
+var (
+	Bool   = &Type{Kind: KindBool}
+	S8     = &Type{Kind: KindS8}
+	U8     = &Type{Kind: KindU8}
+	S16    = &Type{Kind: KindS16}
+	U16    = &Type{Kind: KindU16}
+	S32    = &Type{Kind: KindS32}
+	U32    = &Type{Kind: KindU32}
+	S64    = &Type{Kind: KindS64}
+	U64    = &Type{Kind: KindU64}
+	F32    = &Type{Kind: KindF32}
+	F64    = &Type{Kind: KindF64}
+	Char   = &Type{Kind: KindChar}
+	String = &Type{Kind: KindString}
+)
+
+// List returns the type of a list of elem.
+func List(elem *Type) *Type {
+	return &Type{Kind: KindList, Elem: elem}
+}
+
+// Record returns the type of a record of the fields, in order.
+func Record(fields ...Field) *Type {
+	return &Type{Kind: KindRecord, Fields: fields}
+}
+
+// Tuple returns the type of a tuple of the types, in order.
+func Tuple(types ...*Type) *Type {
+	fields := make([]Field, len(types))
+	for i, t := range types {
+		fields[i].Type = t
+	}
+	return &Type{Kind: KindTuple, Fields: fields}
+}
+
+// Variant returns the type of a variant of the cases, in order.
+func Variant(cases ...Case) *Type {
+	return &Type{Kind: KindVariant, Cases: cases}
+}
+
+// Enum returns the type of an enum of the names of its cases, in order.
+func Enum(names ...string) *Type {
+	cases := make([]Case, len(names))
+	for i, n := range names {
+		cases[i].Name = n
+	}
+	return &Type{Kind: KindEnum, Cases: cases}
+}
+
+// Option returns the type of an optional value of the type.
+func Option(t *Type) *Type {
+	return &Type{Kind: KindOption, Elem: t}
+}
+
+// Result returns the type of a result of ok or err, which are nil when the case has no value.
+func Result(ok, err *Type) *Type {
+	return &Type{Kind: KindResult, Ok: ok, Err: err}
+}
+
+// String returns the type in WIT, except records, variants and enums are written inline as they have no names. Ex.
+// "list<string>" or "record { x: u32, y: u32 }"
+func (t *Type) String() string {
+	var b strings.Builder
+	t.write(&b)
+	return b.String()
+}
+
+func (t *Type) write(b *strings.Builder) {
+	switch t.Kind {
+	case KindList, KindOption:
+		b.WriteString(t.Kind.String())
+		b.WriteByte('<')
+		t.Elem.write(b)
+		b.WriteByte('>')
+	case KindRecord:
+		b.WriteString("record {")
+		for i, f := range t.Fields {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteByte(' ')
+			b.WriteString(f.Name)
+			b.WriteString(": ")
+			f.Type.write(b)
+		}
+		b.WriteString(" }")
+	case KindTuple:
+		b.WriteString("tuple<")
+		for i, f := range t.Fields {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			f.Type.write(b)
+		}
+		b.WriteByte('>')
+	case KindVariant, KindEnum:
+		b.WriteString(t.Kind.String())
+		b.WriteString(" {")
+		for i, c := range t.Cases {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteByte(' ')
+			b.WriteString(c.Name)
+			if c.Type != nil {
+				b.WriteByte('(')
+				c.Type.write(b)
+				b.WriteByte(')')
+			}
+		}
+		b.WriteString(" }")
+	case KindResult:
+		b.WriteString("result")
+		if t.Ok == nil && t.Err == nil {
+			return
+		}
+		b.WriteByte('<')
+		if t.Ok == nil {
+			b.WriteByte('_')
+		} else {
+			t.Ok.write(b)
+		}
+		if t.Err != nil {
+			b.WriteString(", ")
+			t.Err.write(b)
+		}
+		b.WriteByte('>')
+	default:
+		b.WriteString(t.Kind.String())
+	}
+}
+
+// equal returns true if the types are structurally the same.
+func (t *Type) equal(o *Type) bool {
+	if t == o {
+		return true
+	} else if t == nil || o == nil || t.Kind != o.Kind || len(t.Fields) != len(o.Fields) || len(t.Cases) != len(o.Cases) {
+		return false
+	}
+	for i, f := range t.Fields {
+		if f.Name != o.Fields[i].Name || !f.Type.equal(o.Fields[i].Type) {
+			return false
+		}
+	}
+	for i, c := range t.Cases {
+		if c.Name != o.Cases[i].Name || !c.Type.equal(o.Cases[i].Type) {
+			return false
+		}
+	}
+	return t.Elem.equal(o.Elem) && t.Ok.equal(o.Ok) && t.Err.equal(o.Err)
+}
+
+// FuncType is the type of a function of the component model, which has named parameters and at most one result in
+// WIT. Results has more than one type only for functions with named results, from older component binaries.
+type FuncType struct {
+	Params  []Field
+	Results []*Type
+}
+
+// String returns the function type in WIT. Ex. "func(msg: string) -> result<u32>"
+func (f *FuncType) String() string {
+	var b strings.Builder
+	b.WriteString("func(")
+	for i, p := range f.Params {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(p.Name)
+		b.WriteString(": ")
+		p.Type.write(&b)
+	}
+	b.WriteByte(')')
+	switch len(f.Results) {
+	case 0:
+	case 1:
+		b.WriteString(" -> ")
+		f.Results[0].write(&b)
+	default:
+		b.WriteString(" -> (")
+		for i, r := range f.Results {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			r.write(&b)
+		}
+		b.WriteByte(')')
+	}
+	return b.String()
+}
+
+// equal returns true if the function types are structurally the same, including the names of parameters.
+func (f *FuncType) equal(o *FuncType) bool {
+	if len(f.Params) != len(o.Params) || len(f.Results) != len(o.Results) {
+		return false
+	}
+	for i, p := range f.Params {
+		if p.Name != o.Params[i].Name || !p.Type.equal(o.Params[i].Type) {
+			return false
+		}
+	}
+	for i, r := range f.Results {
+		if !r.equal(o.Results[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// paramTuple returns the tuple of the types of the parameters, whose Go value is the parameters.
+func (f *FuncType) paramTuple() *Type {
+	types := make([]*Type, len(f.Params))
+	for i, p := range f.Params {
+		types[i] = p.Type
+	}
+	return Tuple(types...)
+}
+
+// resultTuple returns the tuple of the types of the results, whose Go value is the results.
+func (f *FuncType) resultTuple() *Type {
+	return Tuple(f.Results...)
+}
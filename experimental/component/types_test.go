@@ -0,0 +1,72 @@
+package component
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestType_String(t *testing.T) {
+	tests := []struct {
+		input    *Type
+		expected string
+	}{
+		{input: U32, expected: "u32"},
+		{input: String, expected: "string"},
+		{input: List(U8), expected: "list<u8>"},
+		{input: Option(List(String)), expected: "option<list<string>>"},
+		{input: Tuple(S32, F64), expected: "tuple<s32, f64>"},
+		{input: Record(Field{"x", S32}, Field{"y", S32}), expected: "record { x: s32, y: s32 }"},
+		{input: Variant(Case{"none", nil}, Case{"some", U64}), expected: "variant { none, some(u64) }"},
+		{input: Enum("debug", "info"), expected: "enum { debug, info }"},
+		{input: Result(nil, nil), expected: "result"},
+		{input: Result(U32, nil), expected: "result<u32>"},
+		{input: Result(nil, String), expected: "result<_, string>"},
+		{input: Result(U32, String), expected: "result<u32, string>"},
+		{input: &Type{Kind: 0}, expected: "unknown(0)"},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.expected, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.input.String())
+		})
+	}
+}
+
+func TestType_equal(t *testing.T) {
+	point := Record(Field{"x", S32}, Field{"y", S32})
+	require.True(t, point.equal(Record(Field{"x", S32}, Field{"y", S32})))
+	require.False(t, point.equal(Record(Field{"x", S32}, Field{"z", S32})))
+	require.False(t, point.equal(Record(Field{"x", S32}, Field{"y", S64})))
+	require.False(t, point.equal(Tuple(S32, S32)))
+	require.True(t, Result(U32, nil).equal(Result(U32, nil)))
+	require.False(t, Result(U32, nil).equal(Result(nil, U32)))
+	require.True(t, List(Option(Char)).equal(List(Option(Char))))
+	require.False(t, List(Option(Char)).equal(List(Char)))
+	require.False(t, Enum("a").equal(Variant(Case{"a", nil})))
+}
+
+func TestFuncType_String(t *testing.T) {
+	tests := []struct {
+		input    *FuncType
+		expected string
+	}{
+		{input: &FuncType{}, expected: "func()"},
+		{
+			input:    &FuncType{Params: []Field{{"msg", String}}, Results: []*Type{Result(U32, nil)}},
+			expected: "func(msg: string) -> result<u32>",
+		},
+		{
+			input:    &FuncType{Params: []Field{{"a", U8}, {"b", U8}}, Results: []*Type{U8, Bool}},
+			expected: "func(a: u8, b: u8) -> (u8, bool)",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.expected, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.input.String())
+		})
+	}
+}
@@ -0,0 +1,506 @@
+package component
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Interface is an interface of the WebAssembly Interface Type (WIT) language: named types and the functions which use
+// them. Host functions are defined against one with Imports.ExportInterface.
+type Interface struct {
+	// Name is the name of the interface, ex. "logger"
+	Name string
+
+	// Types are the types defined in the interface by their names.
+	Types map[string]*Type
+
+	// Functions are the types of the functions of the interface by their names.
+	Functions map[string]*FuncType
+}
+
+// ParseInterface parses an interface in WIT, ex.
+//
+//	interface logger {
+//		enum level { debug, info, warn }
+//		record entry { level: level, msg: string }
+//		log: func(e: entry) -> result<u32, string>
+//	}
+//
+// Types may be used before they are defined. Items `use`, `flags` and `resource` aren't supported.
+//
+// See https://github.com/WebAssembly/component-model/blob/main/design/mvp/WIT.md
+func ParseInterface(wit string) (*Interface, error) {
+	tokens, err := lexWIT(wit)
+	if err != nil {
+		return nil, err
+	}
+	p := &witParser{
+		tokens:    tokens,
+		defs:      map[string]*Type{},
+		refs:      map[*Type]string{},
+		resolved:  map[string]*Type{},
+		resolving: map[string]bool{},
+	}
+	return p.parseInterface()
+}
+
+// witToken is a token of WIT: an identifier, keyword or punctuation, ex. "->".
+type witToken struct {
+	text string
+	line int
+}
+
+// lexWIT splits the WIT into tokens, skipping comments and whitespace.
+func lexWIT(wit string) (tokens []witToken, err error) {
+	line := 1
+	for i := 0; i < len(wit); {
+		c := wit[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(wit[i:], "//"):
+			for i < len(wit) && wit[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(wit[i:], "/*"):
+			end := strings.Index(wit[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("wit:%d: unterminated comment", line)
+			}
+			line += strings.Count(wit[i:i+2+end], "\n")
+			i += end + 4
+		case strings.HasPrefix(wit[i:], "->"):
+			tokens = append(tokens, witToken{"->", line})
+			i += 2
+		case strings.IndexByte("{}()<>,:;=.*/@", c) >= 0:
+			tokens = append(tokens, witToken{wit[i : i+1], line})
+			i++
+		case isWITIdentByte(c):
+			start := i
+			for i < len(wit) && isWITIdentByte(wit[i]) {
+				i++
+			}
+			tokens = append(tokens, witToken{wit[start:i], line})
+		default:
+			return nil, fmt.Errorf("wit:%d: unexpected character %q", line, c)
+		}
+	}
+	return
+}
+
+func isWITIdentByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '%'
+}
+
+// witParser parses the tokens of an interface. References to named types are placeholders in refs, which are
+// resolved after all types are defined.
+type witParser struct {
+	tokens []witToken
+	pos    int
+
+	// defs are the unresolved types by their names.
+	defs map[string]*Type
+	// refs are the names of the types referenced by placeholders.
+	refs map[*Type]string
+	// resolved are the resolved types by their names.
+	resolved map[string]*Type
+	// resolving are the names of the types being resolved, to detect cycles.
+	resolving map[string]bool
+}
+
+// peek returns the text of the next token, or "" at the end.
+func (p *witParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos].text
+	}
+	return ""
+}
+
+// errorf returns an error at the line of the next token.
+func (p *witParser) errorf(format string, args ...interface{}) error {
+	line := 0
+	if p.pos < len(p.tokens) {
+		line = p.tokens[p.pos].line
+	} else if len(p.tokens) > 0 {
+		line = p.tokens[len(p.tokens)-1].line
+	}
+	return fmt.Errorf("wit:%d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// expect consumes the next token, which must be text.
+func (p *witParser) expect(text string) error {
+	if p.peek() != text {
+		return p.unexpected(fmt.Sprintf("%q", text))
+	}
+	p.pos++
+	return nil
+}
+
+// accept consumes the next token if it is text, and returns true if it was.
+func (p *witParser) accept(text string) bool {
+	if p.peek() == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *witParser) unexpected(expected string) error {
+	if next := p.peek(); next != "" {
+		return p.errorf("expected %s, but was %q", expected, next)
+	}
+	return p.errorf("expected %s, but was end of input", expected)
+}
+
+// ident consumes the next token, which must be an identifier, and returns it without the '%' escape of keywords.
+func (p *witParser) ident() (string, error) {
+	next := p.peek()
+	if next == "" || !isWITIdentByte(next[0]) {
+		return "", p.unexpected("identifier")
+	}
+	p.pos++
+	return strings.TrimPrefix(next, "%"), nil
+}
+
+func (p *witParser) parseInterface() (*Interface, error) {
+	if err := p.expect("interface"); err != nil {
+		return nil, err
+	}
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	if err = p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	funcs := map[string]*FuncType{}
+	for !p.accept("}") {
+		switch keyword := p.peek(); keyword {
+		case "record", "variant", "enum", "type":
+			p.pos++
+			if err = p.parseTypeDef(keyword); err != nil {
+				return nil, err
+			}
+		case "use", "flags", "resource", "union":
+			return nil, p.errorf("%s is not supported", keyword)
+		default:
+			var fnName string
+			if fnName, err = p.ident(); err != nil {
+				return nil, err
+			}
+			if _, ok := funcs[fnName]; ok {
+				return nil, p.errorf("duplicate function %s", fnName)
+			}
+			if err = p.expect(":"); err != nil {
+				return nil, err
+			}
+			if funcs[fnName], err = p.parseFunc(); err != nil {
+				return nil, err
+			}
+		}
+		p.accept(";")
+	}
+	if p.pos != len(p.tokens) {
+		return nil, p.unexpected("end of input")
+	}
+
+	ret := &Interface{Name: name, Types: make(map[string]*Type, len(p.defs)), Functions: funcs}
+	for n := range p.defs {
+		if ret.Types[n], err = p.resolveName(n); err != nil {
+			return nil, err
+		}
+	}
+	for _, f := range funcs {
+		for i := range f.Params {
+			if f.Params[i].Type, err = p.resolve(f.Params[i].Type); err != nil {
+				return nil, err
+			}
+		}
+		for i := range f.Results {
+			if f.Results[i], err = p.resolve(f.Results[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ret, nil
+}
+
+// parseTypeDef parses the definition of a named type after its keyword.
+func (p *witParser) parseTypeDef(keyword string) error {
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if _, ok := p.defs[name]; ok {
+		return p.errorf("duplicate type %s", name)
+	}
+
+	var t *Type
+	switch keyword {
+	case "type":
+		if err = p.expect("="); err != nil {
+			return err
+		}
+		t, err = p.parseType()
+	case "record":
+		t = &Type{Kind: KindRecord}
+		err = p.parseBody(func() error {
+			n, err := p.ident()
+			if err != nil {
+				return err
+			}
+			if err = p.expect(":"); err != nil {
+				return err
+			}
+			ft, err := p.parseType()
+			t.Fields = append(t.Fields, Field{Name: n, Type: ft})
+			return err
+		})
+	case "variant", "enum":
+		t = &Type{Kind: KindVariant}
+		if keyword == "enum" {
+			t.Kind = KindEnum
+		}
+		err = p.parseBody(func() error {
+			n, err := p.ident()
+			if err != nil {
+				return err
+			}
+			c := Case{Name: n}
+			if t.Kind == KindVariant && p.accept("(") {
+				if c.Type, err = p.parseType(); err != nil {
+					return err
+				}
+				if err = p.expect(")"); err != nil {
+					return err
+				}
+			}
+			t.Cases = append(t.Cases, c)
+			return nil
+		})
+	}
+	if err != nil {
+		return err
+	}
+	p.defs[name] = t
+	return nil
+}
+
+// parseBody parses the comma separated items of a record, variant or enum within braces.
+func (p *witParser) parseBody(item func() error) error {
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for !p.accept("}") {
+		if err := item(); err != nil {
+			return err
+		}
+		if p.peek() != "}" {
+			if err := p.expect(","); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseFunc parses a function type, starting from the keyword "func".
+func (p *witParser) parseFunc() (*FuncType, error) {
+	if err := p.expect("func"); err != nil {
+		return nil, err
+	}
+	ret := &FuncType{}
+	params, err := p.parseNamedList()
+	if err != nil {
+		return nil, err
+	}
+	ret.Params = params
+	if !p.accept("->") {
+		return ret, nil
+	}
+	if p.peek() == "(" {
+		results, err := p.parseNamedList()
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range results {
+			ret.Results = append(ret.Results, r.Type)
+		}
+		return ret, nil
+	}
+	result, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+	ret.Results = []*Type{result}
+	return ret, nil
+}
+
+// parseNamedList parses the parameters or named results of a function, ex. "(a: u32, b: string)".
+func (p *witParser) parseNamedList() (ret []Field, err error) {
+	if err = p.expect("("); err != nil {
+		return
+	}
+	for !p.accept(")") {
+		var f Field
+		if f.Name, err = p.ident(); err != nil {
+			return
+		}
+		if err = p.expect(":"); err != nil {
+			return
+		}
+		if f.Type, err = p.parseType(); err != nil {
+			return
+		}
+		ret = append(ret, f)
+		if p.peek() != ")" {
+			if err = p.expect(","); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+var witPrimitives = map[string]*Type{
+	"bool": Bool, "s8": S8, "u8": U8, "s16": S16, "u16": U16, "s32": S32, "u32": U32, "s64": S64, "u64": U64,
+	"f32": F32, "f64": F64, "float32": F32, "float64": F64, "char": Char, "string": String,
+}
+
+// parseType parses a type, which may be a placeholder of a reference to a named type.
+func (p *witParser) parseType() (*Type, error) {
+	name, err := p.ident()
+	if err != nil {
+		return nil, p.unexpected("type")
+	}
+	if t, ok := witPrimitives[name]; ok {
+		return t, nil
+	}
+	switch name {
+	case "list", "option":
+		if err = p.expect("<"); err != nil {
+			return nil, err
+		}
+		elem, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect(">"); err != nil {
+			return nil, err
+		}
+		if name == "list" {
+			return List(elem), nil
+		}
+		return Option(elem), nil
+	case "tuple":
+		if err = p.expect("<"); err != nil {
+			return nil, err
+		}
+		var types []*Type
+		for !p.accept(">") {
+			t, err := p.parseType()
+			if err != nil {
+				return nil, err
+			}
+			types = append(types, t)
+			if p.peek() != ">" {
+				if err = p.expect(","); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return Tuple(types...), nil
+	case "result":
+		if !p.accept("<") {
+			return Result(nil, nil), nil
+		}
+		var ok, e *Type
+		if !p.accept("_") {
+			if ok, err = p.parseType(); err != nil {
+				return nil, err
+			}
+		}
+		if p.accept(",") {
+			if e, err = p.parseType(); err != nil {
+				return nil, err
+			}
+		}
+		if err = p.expect(">"); err != nil {
+			return nil, err
+		}
+		return Result(ok, e), nil
+	}
+	ref := &Type{}
+	p.refs[ref] = name
+	return ref, nil
+}
+
+// resolveName returns the named type with its references resolved.
+func (p *witParser) resolveName(name string) (*Type, error) {
+	if t, ok := p.resolved[name]; ok {
+		return t, nil
+	}
+	def, ok := p.defs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown type %s", name)
+	} else if p.resolving[name] {
+		return nil, fmt.Errorf("type %s refers to itself", name)
+	}
+	p.resolving[name] = true
+	t, err := p.resolve(def)
+	delete(p.resolving, name)
+	if err != nil {
+		return nil, err
+	}
+	p.resolved[name] = t
+	return t, nil
+}
+
+// resolve returns the type with its references resolved.
+func (p *witParser) resolve(t *Type) (*Type, error) {
+	if t == nil {
+		return nil, nil
+	} else if name, ok := p.refs[t]; ok {
+		return p.resolveName(name)
+	}
+	switch t.Kind {
+	case KindList, KindOption, KindRecord, KindTuple, KindVariant, KindResult:
+	default:
+		return t, nil // primitive or enum
+	}
+
+	ret := &Type{Kind: t.Kind}
+	var err error
+	if ret.Elem, err = p.resolve(t.Elem); err != nil {
+		return nil, err
+	}
+	if ret.Ok, err = p.resolve(t.Ok); err != nil {
+		return nil, err
+	}
+	if ret.Err, err = p.resolve(t.Err); err != nil {
+		return nil, err
+	}
+	if t.Fields != nil {
+		ret.Fields = make([]Field, len(t.Fields))
+		for i, f := range t.Fields {
+			ret.Fields[i].Name = f.Name
+			if ret.Fields[i].Type, err = p.resolve(f.Type); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if t.Cases != nil {
+		ret.Cases = make([]Case, len(t.Cases))
+		for i, c := range t.Cases {
+			ret.Cases[i].Name = c.Name
+			if ret.Cases[i].Type, err = p.resolve(c.Type); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,77 @@
+package component
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestParseInterface(t *testing.T) {
+	iface, err := ParseInterface(`
+// Logs entries of a level.
+interface logger {
+	/* types can be used before they are defined */
+	record entry { level: level, msg: string, tags: list<tag>, }
+	enum level { debug, info, warn }
+	type tag = tuple<string, string>;
+	variant filter { all, at-least(level), matching(option<string>) }
+
+	log: func(e: entry) -> result<u32, string>;
+	set-filter: func(f: filter)
+	count: func() -> (debug: u64, other: u64)
+	flush: func() -> result<_, level>
+	%type: func(a: float32, b: f64) -> result
+}`)
+	require.NoError(t, err)
+
+	level := Enum("debug", "info", "warn")
+	tag := Tuple(String, String)
+	entry := Record(Field{"level", level}, Field{"msg", String}, Field{"tags", List(tag)})
+	filter := Variant(Case{"all", nil}, Case{"at-least", level}, Case{"matching", Option(String)})
+	require.Equal(t, &Interface{
+		Name: "logger",
+		Types: map[string]*Type{
+			"entry":  entry,
+			"level":  level,
+			"tag":    tag,
+			"filter": filter,
+		},
+		Functions: map[string]*FuncType{
+			"log":        {Params: []Field{{"e", entry}}, Results: []*Type{Result(U32, String)}},
+			"set-filter": {Params: []Field{{"f", filter}}},
+			"count":      {Results: []*Type{U64, U64}},
+			"flush":      {Results: []*Type{Result(nil, level)}},
+			"type":       {Params: []Field{{"a", F32}, {"b", F64}}, Results: []*Type{Result(nil, nil)}},
+		},
+	}, iface)
+}
+
+func TestParseInterface_Errors(t *testing.T) {
+	tests := []struct {
+		name, input, expectedErr string
+	}{
+		{name: "empty", input: "", expectedErr: `wit:0: expected "interface", but was end of input`},
+		{name: "world", input: "world w {}", expectedErr: `wit:1: expected "interface", but was "world"`},
+		{name: "unexpected character", input: "interface i { f: func() -> u32! }", expectedErr: "wit:1: unexpected character '!'"},
+		{name: "unterminated comment", input: "interface i {\n/* }", expectedErr: "wit:2: unterminated comment"},
+		{name: "unclosed", input: "interface i {\n", expectedErr: "wit:1: expected identifier, but was end of input"},
+		{name: "trailing", input: "interface i {} }", expectedErr: `wit:1: expected end of input, but was "}"`},
+		{name: "use", input: "interface i {\n  use x.{y}\n}", expectedErr: "wit:2: use is not supported"},
+		{name: "flags", input: "interface i { flags f { a } }", expectedErr: "wit:1: flags is not supported"},
+		{name: "resource", input: "interface i { resource r }", expectedErr: "wit:1: resource is not supported"},
+		{name: "missing type", input: "interface i { f: func(a: ) }", expectedErr: `wit:1: expected type, but was ")"`},
+		{name: "missing comma", input: "interface i { record r { a: u8 b: u8 } }", expectedErr: `wit:1: expected ",", but was "b"`},
+		{name: "duplicate type", input: "interface i { enum a { x } enum a { y } }", expectedErr: "wit:1: duplicate type a"},
+		{name: "duplicate function", input: "interface i { f: func() f: func() }", expectedErr: "wit:1: duplicate function f"},
+		{name: "unknown type", input: "interface i { f: func(a: b) }", expectedErr: "unknown type b"},
+		{name: "cycle", input: "interface i { type a = list<option<a>>; }", expectedErr: "type a refers to itself"},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseInterface(tc.input)
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
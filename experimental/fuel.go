@@ -0,0 +1,49 @@
+package experimental
+
+import (
+	"context"
+
+	internalsys "github.com/tetratelabs/wazero/internal/sys"
+	"github.com/tetratelabs/wazero/internal/wasmruntime"
+)
+
+// ErrOutOfFuel is wrapped by the error returned by api.Function Call when the call ran out of fuel.
+//
+// Ex.
+//	if _, err := fn.Call(ctx); errors.Is(err, experimental.ErrOutOfFuel) {
+//		// The guest did more work than it was allowed to.
+//	}
+//
+// See WithFuel
+var ErrOutOfFuel error = wasmruntime.ErrRuntimeOutOfFuel
+
+// FuelMeter reports the fuel of the context returned by WithFuel.
+type FuelMeter interface {
+	// Remaining is the amount of fuel not yet consumed. This is zero after a call ran out of fuel.
+	Remaining() int64
+
+	// Used is the amount of fuel consumed by calls made with the context.
+	Used() int64
+}
+
+// WithFuel returns a context which limits the work done by api.Function calls made with it to the given amount of
+// fuel. The returned FuelMeter reports how much fuel these calls used.
+//
+// Fuel is a deterministic measure of work: Roughly, each WebAssembly instruction consumes one unit of fuel, and the
+// cost of the same code is the same in the interpreter and the compiler. The cost of a basic block is consumed when
+// entering it, so a call can run out of fuel before executing all of its instructions. When that happens, the call
+// fails with an error wrapping ErrOutOfFuel. Work done inside host functions does not consume fuel.
+//
+// Ex. To limit a call to 10000 units of fuel:
+//	ctx, meter := experimental.WithFuel(context.Background(), 10000)
+//	_, err := fn.Call(ctx)
+//	fmt.Println(meter.Used())
+//
+// Notes:
+//	* Fuel is only consumed when wazero.RuntimeConfig WithFuelMetering is enabled. Otherwise, it is unlimited.
+//	* The fuel is shared by all calls made with the returned context, including those made by host functions.
+//	  Hence, the returned context must not be used for concurrent calls.
+func WithFuel(ctx context.Context, fuel int64) (context.Context, FuelMeter) {
+	f := internalsys.NewFuel(fuel)
+	return context.WithValue(ctx, internalsys.FuelKey{}, f), f
+}
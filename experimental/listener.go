@@ -8,7 +8,8 @@ import (
 
 // FunctionListenerFactoryKey is a context.Context Value key. Its associated value should be a FunctionListenerFactory.
 //
-// Note: This is interpreter-only for now!
+// Note: The compiler only adds the overhead of notifying listeners to modules instantiated with a
+// FunctionListenerFactory that returns a listener for any of their functions.
 //
 // See https://github.com/tetratelabs/wazero/issues/451
 type FunctionListenerFactoryKey struct{}
@@ -30,7 +31,11 @@ type FunctionListener interface {
 	Before(ctx context.Context, paramValues []uint64) context.Context
 
 	// After is invoked after a function is called. ctx is the context of this function call.
-	// The err parameter is nil on success.
+	// The err parameter is nil on success. Otherwise, resultValues is nil.
+	//
+	// Note: When the function ends with a tail call ("return_call" or "return_call_indirect"), After is invoked
+	// before the called function with nil resultValues, as its results are those of the called function. The
+	// called function is then notified as if called by the caller, so tail calls don't grow the call stack.
 	After(ctx context.Context, err error, resultValues []uint64)
 }
 
@@ -38,7 +43,7 @@ type FunctionListener interface {
 // indirect in terms of depth and breadth. The test could show a tree 3 calls deep where the there are a couple calls at
 // each depth under the root. The main thing this can help prevent is accidentally swapping the context internally.
 
-// TODO: Errors aren't handled, and the After hook should accept one along with the result values.
+// TODO: The interpreter doesn't yet pass errors to the After hook, as it doesn't call it when a function panics.
 
 // TODO: The context parameter of the After hook is not the same as the Before hook. This means interceptor patterns
 // are awkward. Ex. something like timing is difficult as it requires propagating a stack. Otherwise, nested calls will
@@ -46,25 +51,7 @@ type FunctionListener interface {
 // position to read from which might be subtle.
 
 // FunctionDefinition includes information about a function available pre-instantiation.
-type FunctionDefinition interface {
-	// ModuleName is the possibly empty name of the module defining this function.
-	ModuleName() string
-
-	// Index is the position in the module's function index namespace, imports first.
-	Index() uint32
-
-	// Name is the module-defined name of the function, which is not necessarily the same as its export name.
-	Name() string
-
-	// ExportNames include all exported names for the given function.
-	ExportNames() []string
-
-	// ParamTypes are the parameters of the function.
-	ParamTypes() []api.ValueType
-
-	// ParamNames are index-correlated with ParamTypes or nil if not available for one or more parameters.
-	ParamNames() []string
-
-	// ResultTypes are the results of the function.
-	ResultTypes() []api.ValueType
-}
+//
+// Note: This is an alias of api.FunctionDefinition, so listeners see the same definitions as wazero.CompiledModule
+// ImportedFunctions and ExportedFunctions.
+type FunctionDefinition = api.FunctionDefinition
@@ -0,0 +1,192 @@
+package profiler
+
+// profile is the subset of a pprof profile used by profiler.
+//
+// Note: Each function has one location, which shares its ID. IDs are positions in functions, beginning at one.
+//
+// See https://github.com/google/pprof/blob/main/proto/profile.proto
+type profile struct {
+	sampleTypes       []valueType
+	samples           []sample
+	functions         []string
+	timeNanos         int64
+	durationNanos     int64
+	periodType        valueType
+	period            int64
+	defaultSampleType string
+}
+
+type valueType struct{ typ, unit string }
+
+type sample struct {
+	// locationIDs are the call stack, leaf first.
+	locationIDs []uint64
+
+	// values are index-correlated with profile.sampleTypes
+	values []int64
+}
+
+// Field numbers of messages in profile.proto
+const (
+	tagProfileSampleType        = 1
+	tagProfileSample            = 2
+	tagProfileLocation          = 4
+	tagProfileFunction          = 5
+	tagProfileStringTable       = 6
+	tagProfileTimeNanos         = 9
+	tagProfileDurationNanos     = 10
+	tagProfilePeriodType        = 11
+	tagProfilePeriod            = 12
+	tagProfileDefaultSampleType = 14
+
+	tagValueTypeType = 1
+	tagValueTypeUnit = 2
+
+	tagSampleLocationID = 1
+	tagSampleValue      = 2
+
+	tagLocationID   = 1
+	tagLocationLine = 4
+
+	tagLineFunctionID = 1
+
+	tagFunctionID         = 1
+	tagFunctionName       = 2
+	tagFunctionSystemName = 3
+)
+
+// encode returns the profile in the protocol buffer format of profile.proto, without compression.
+func (p *profile) encode() []byte {
+	// The string table begins with the empty string, so that zero means unset.
+	strings := []string{""}
+	stringIndex := map[string]int64{"": 0}
+	index := func(s string) int64 {
+		i, ok := stringIndex[s]
+		if !ok {
+			i = int64(len(strings))
+			strings = append(strings, s)
+			stringIndex[s] = i
+		}
+		return i
+	}
+	valueTypeFn := func(vt valueType) func(*protobuf) {
+		typ, unit := index(vt.typ), index(vt.unit)
+		return func(b *protobuf) {
+			b.int64(tagValueTypeType, typ)
+			b.int64(tagValueTypeUnit, unit)
+		}
+	}
+
+	b := &protobuf{}
+	for _, vt := range p.sampleTypes {
+		b.message(tagProfileSampleType, valueTypeFn(vt))
+	}
+	for _, s := range p.samples {
+		b.message(tagProfileSample, func(b *protobuf) {
+			b.uint64s(tagSampleLocationID, s.locationIDs)
+			b.int64s(tagSampleValue, s.values)
+		})
+	}
+	for i := range p.functions {
+		id := uint64(i + 1)
+		b.message(tagProfileLocation, func(b *protobuf) {
+			b.uint64(tagLocationID, id)
+			b.message(tagLocationLine, func(b *protobuf) {
+				b.uint64(tagLineFunctionID, id)
+			})
+		})
+	}
+	for i, name := range p.functions {
+		id, nameIndex := uint64(i+1), index(name)
+		b.message(tagProfileFunction, func(b *protobuf) {
+			b.uint64(tagFunctionID, id)
+			b.int64(tagFunctionName, nameIndex)
+			b.int64(tagFunctionSystemName, nameIndex)
+		})
+	}
+	periodType := valueTypeFn(p.periodType)
+	defaultSampleType := index(p.defaultSampleType)
+
+	// All strings are now indexed, so the table can be written.
+	for _, s := range strings {
+		b.string(tagProfileStringTable, s)
+	}
+	b.int64(tagProfileTimeNanos, p.timeNanos)
+	b.int64(tagProfileDurationNanos, p.durationNanos)
+	b.message(tagProfilePeriodType, periodType)
+	b.int64(tagProfilePeriod, p.period)
+	b.int64(tagProfileDefaultSampleType, defaultSampleType)
+	return b.buf
+}
+
+// protobuf encodes the protocol buffer wire format, with only the field types used in profile.proto.
+//
+// See https://developers.google.com/protocol-buffers/docs/encoding
+type protobuf struct {
+	buf []byte
+}
+
+const (
+	wireTypeVarint = 0
+	wireTypeBytes  = 2
+)
+
+func (b *protobuf) varint(x uint64) {
+	for x >= 0x80 {
+		b.buf = append(b.buf, byte(x)|0x80)
+		x >>= 7
+	}
+	b.buf = append(b.buf, byte(x))
+}
+
+func (b *protobuf) key(tag int, wireType uint64) {
+	b.varint(uint64(tag)<<3 | wireType)
+}
+
+// uint64 encodes a field, unless it is the default value zero.
+func (b *protobuf) uint64(tag int, x uint64) {
+	if x == 0 {
+		return
+	}
+	b.key(tag, wireTypeVarint)
+	b.varint(x)
+}
+
+// int64 encodes a field, unless it is the default value zero.
+func (b *protobuf) int64(tag int, x int64) {
+	b.uint64(tag, uint64(x))
+}
+
+// uint64s encodes a packed repeated field.
+func (b *protobuf) uint64s(tag int, xs []uint64) {
+	b.message(tag, func(b *protobuf) {
+		for _, x := range xs {
+			b.varint(x)
+		}
+	})
+}
+
+// int64s encodes a packed repeated field.
+func (b *protobuf) int64s(tag int, xs []int64) {
+	b.message(tag, func(b *protobuf) {
+		for _, x := range xs {
+			b.varint(uint64(x))
+		}
+	})
+}
+
+// string encodes a field, even if empty, as it is used for repeated fields.
+func (b *protobuf) string(tag int, s string) {
+	b.key(tag, wireTypeBytes)
+	b.varint(uint64(len(s)))
+	b.buf = append(b.buf, s...)
+}
+
+// message encodes a length-delimited field, whose contents are encoded by fn.
+func (b *protobuf) message(tag int, fn func(*protobuf)) {
+	nested := &protobuf{}
+	fn(nested)
+	b.key(tag, wireTypeBytes)
+	b.varint(uint64(len(nested.buf)))
+	b.buf = append(b.buf, nested.buf...)
+}
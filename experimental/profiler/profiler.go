@@ -0,0 +1,222 @@
+// Package profiler records the calls of guest functions, to write as a pprof profile readable by `go tool pprof`.
+//
+// The Profiler is a experimental.FunctionListenerFactory, so it works with any engine, and only adds overhead to modules
+// instantiated with a context.Context including it. Ex.
+//
+//	p := profiler.New()
+//	ctx = context.WithValue(ctx, experimental.FunctionListenerFactoryKey{}, p)
+//	mod, _ := r.InstantiateModuleFromBinary(ctx, wasm)
+//	mod.ExportedFunction("run").Call(ctx)
+//
+//	f, _ := os.Create("wasm.pprof")
+//	defer f.Close()
+//	p.WriteProfile(f)
+//
+// Note: This is an experimental API and may be changed or deleted at any time, so use with caution!
+package profiler
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/wasmdebug"
+)
+
+// Profiler is an experimental.FunctionListenerFactory which records the calls of guest functions, to write as a pprof
+// profile.
+//
+// Each call stack is recorded with the count of calls which returned with it and the time they spent in it, excluding
+// callees. Stacks begin with the function called by the host, and functions are named like they are in stack traces,
+// using the name section when present. Ex. "env.main" or "env.[7]"
+//
+// The profile has the sample and period types of a Go CPU profile written by runtime/pprof: "samples/count", which is
+// the count of calls, and "cpu/nanoseconds". It opens in `go tool pprof` like a Go profile, and can be merged with Go
+// CPU profiles of the host. Ex. `go tool pprof -http=: wasm.pprof cpu.pprof`
+//
+// Note: Times are wall-clock times measured between FunctionListener.Before and After, so include any time the call
+// was blocked, such as in a host function. Calls which don't return, such as on a trap in the interpreter, are not
+// recorded.
+type Profiler interface {
+	experimental.FunctionListenerFactory
+
+	// WriteProfile writes the calls recorded since New or Reset as a gzip-compressed pprof profile.
+	WriteProfile(w io.Writer) error
+
+	// Reset discards the calls recorded so far.
+	Reset()
+}
+
+// New returns a Profiler, which records calls of modules instantiated with it as the
+// experimental.FunctionListenerFactoryKey value.
+func New() Profiler {
+	p := &profiler{}
+	p.Reset()
+	return p
+}
+
+type profiler struct {
+	mux sync.Mutex
+
+	// root is the call stack of the host, whose callees are the functions called by it.
+	root *stack
+
+	// start is when recording began, for the time of the profile.
+	start time.Time
+}
+
+// cpuPeriod is the period of Go CPU profiles, at the default rate of runtime/pprof (100 Hz), so that profiles merge.
+const cpuPeriod = int64(time.Second / 100)
+
+// stack is a call stack, identified by the function at its top and the stack of its caller.
+type stack struct {
+	caller *stack
+
+	// funcName is the function at the top of the stack, or empty for profiler.root
+	funcName string
+
+	callees map[string]*stack
+
+	// calls is the count of calls which returned with this stack.
+	calls int64
+
+	// nanos is the time spent in calls with this stack, excluding callees.
+	nanos int64
+}
+
+// callee returns the stack of a call to the function from this one, adding it if not yet present.
+func (s *stack) callee(funcName string) *stack {
+	c, ok := s.callees[funcName]
+	if !ok {
+		c = &stack{caller: s, funcName: funcName}
+		if s.callees == nil {
+			s.callees = map[string]*stack{}
+		}
+		s.callees[funcName] = c
+	}
+	return c
+}
+
+// Reset implements the same method as documented on Profiler.
+func (p *profiler) Reset() {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	p.root = &stack{}
+	p.start = time.Now()
+}
+
+// NewListener implements the same method as documented on experimental.FunctionListenerFactory.
+func (p *profiler) NewListener(fnd experimental.FunctionDefinition) experimental.FunctionListener {
+	return &listener{p: p, funcName: wasmdebug.FuncName(fnd.ModuleName(), fnd.Name(), fnd.Index())}
+}
+
+// callKey is a context.Context Value key. Its associated value is the *call in progress.
+//
+// Note: This includes the profiler, so that calls of different profilers don't nest.
+type callKey struct{ p *profiler }
+
+// call holds state between listener.Before and listener.After.
+type call struct {
+	caller *call
+	stack  *stack
+	start  time.Time
+
+	// callees is the time spent in calls made by this one, to exclude from it.
+	callees time.Duration
+}
+
+// listener implements experimental.FunctionListener to record calls of a function.
+type listener struct {
+	p        *profiler
+	funcName string
+}
+
+// Before implements the same method as documented on experimental.FunctionListener.
+func (l *listener) Before(ctx context.Context, _ []uint64) context.Context {
+	caller, _ := ctx.Value(callKey{l.p}).(*call)
+
+	l.p.mux.Lock()
+	callerStack := l.p.root
+	if caller != nil {
+		callerStack = caller.stack
+	}
+	s := callerStack.callee(l.funcName)
+	l.p.mux.Unlock()
+
+	return context.WithValue(ctx, callKey{l.p}, &call{caller: caller, stack: s, start: time.Now()})
+}
+
+// After implements the same method as documented on experimental.FunctionListener.
+func (l *listener) After(ctx context.Context, _ error, _ []uint64) {
+	c, ok := ctx.Value(callKey{l.p}).(*call)
+	if !ok {
+		return
+	}
+	elapsed := time.Since(c.start)
+	if c.caller != nil {
+		c.caller.callees += elapsed
+	}
+
+	l.p.mux.Lock()
+	c.stack.calls++
+	c.stack.nanos += int64(elapsed - c.callees)
+	l.p.mux.Unlock()
+}
+
+// WriteProfile implements the same method as documented on Profiler.
+func (p *profiler) WriteProfile(w io.Writer) error {
+	p.mux.Lock()
+	prof := p.profile(time.Now())
+	p.mux.Unlock()
+
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(prof.encode()); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// profile returns the calls recorded so far, in the structure of a pprof profile.
+func (p *profiler) profile(now time.Time) *profile {
+	prof := &profile{
+		sampleTypes:       []valueType{{"samples", "count"}, {"cpu", "nanoseconds"}},
+		defaultSampleType: "cpu",
+		periodType:        valueType{"cpu", "nanoseconds"},
+		period:            cpuPeriod,
+		timeNanos:         p.start.UnixNano(),
+		durationNanos:     now.Sub(p.start).Nanoseconds(),
+	}
+	functionIDs := map[string]uint64{}
+	var visit func(s *stack)
+	visit = func(s *stack) {
+		if s.calls > 0 {
+			var locationIDs []uint64 // leaf first
+			for f := s; f.caller != nil; f = f.caller {
+				id, ok := functionIDs[f.funcName]
+				if !ok {
+					prof.functions = append(prof.functions, f.funcName)
+					id = uint64(len(prof.functions))
+					functionIDs[f.funcName] = id
+				}
+				locationIDs = append(locationIDs, id)
+			}
+			prof.samples = append(prof.samples, sample{locationIDs: locationIDs, values: []int64{s.calls, s.nanos}})
+		}
+
+		// Visit callees in name order, so that the profile is deterministic.
+		funcNames := make([]string, 0, len(s.callees))
+		for funcName := range s.callees {
+			funcNames = append(funcNames, funcName)
+		}
+		sort.Strings(funcNames)
+		for _, funcName := range funcNames {
+			visit(s.callees[funcName])
+		}
+	}
+	visit(p.root)
+	return prof
+}
@@ -0,0 +1,134 @@
+package profiler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/platform"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/watzero"
+)
+
+// testCtx is an arbitrary, non-default context. Non-nil also prevents linter errors.
+var testCtx = context.WithValue(context.Background(), struct{}{}, "arbitrary")
+
+func TestProfiler(t *testing.T) {
+	bin, err := watzero.Wat2Wasm(`(module $prof
+  (func $a)
+  (func $b call $a)
+  (func $run call $a call $b call $a)
+  (export "run" (func $run))
+)`)
+	require.NoError(t, err)
+
+	configs := map[string]wazero.RuntimeConfig{"interpreter": wazero.NewRuntimeConfigInterpreter()}
+	if platform.CompilerSupported() {
+		configs["compiler"] = wazero.NewRuntimeConfigCompiler()
+	}
+
+	for n, c := range configs {
+		config := c
+		t.Run(n, func(t *testing.T) {
+			r := wazero.NewRuntimeWithConfig(config)
+			defer r.Close(testCtx)
+
+			p := New()
+			ctx := context.WithValue(testCtx, experimental.FunctionListenerFactoryKey{}, p)
+			mod, err := r.InstantiateModuleFromBinary(ctx, bin)
+			require.NoError(t, err)
+
+			run := mod.ExportedFunction("run")
+			for i := 0; i < 2; i++ {
+				_, err = run.Call(ctx)
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, map[string]int64{
+				"prof.run":               2,
+				"prof.run;prof.a":        4,
+				"prof.run;prof.b":        2,
+				"prof.run;prof.b;prof.a": 2,
+			}, calls(p.(*profiler).profile(time.Now())))
+
+			p.Reset()
+			require.Equal(t, map[string]int64{}, calls(p.(*profiler).profile(time.Now())))
+		})
+	}
+}
+
+// calls returns the count of calls of each stack in the profile, keyed by function names from the root separated by
+// semicolons.
+func calls(prof *profile) map[string]int64 {
+	ret := map[string]int64{}
+	for _, s := range prof.samples {
+		var names []string
+		for i := len(s.locationIDs) - 1; i >= 0; i-- {
+			names = append(names, prof.functions[s.locationIDs[i]-1])
+		}
+		ret[strings.Join(names, ";")] = s.values[0]
+	}
+	return ret
+}
+
+func TestProfiler_WriteProfile(t *testing.T) {
+	p := New()
+	l := p.NewListener(&funcDef{moduleName: "env", name: "main"})
+	l.After(l.Before(testCtx, nil), nil, nil)
+
+	var buf bytes.Buffer
+	require.NoError(t, p.WriteProfile(&buf))
+
+	zr, err := gzip.NewReader(&buf)
+	require.NoError(t, err)
+	encoded, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	prof := p.(*profiler).profile(time.Now())
+	require.Equal(t, []uint64{1}, prof.samples[0].locationIDs)
+	// The types are those of Go CPU profiles, as pprof only merges profiles with the same sample and period types.
+	require.Equal(t, []valueType{{"samples", "count"}, {"cpu", "nanoseconds"}}, prof.sampleTypes)
+	require.Equal(t, valueType{"cpu", "nanoseconds"}, prof.periodType)
+	require.Equal(t, int64(10000000), prof.period)
+	require.Equal(t, "cpu", prof.defaultSampleType)
+	require.True(t, bytes.Contains(encoded, []byte("env.main")))
+}
+
+func TestProfile_encode(t *testing.T) {
+	prof := &profile{
+		sampleTypes:       []valueType{{"samples", "count"}},
+		samples:           []sample{{locationIDs: []uint64{1}, values: []int64{300}}},
+		functions:         []string{"f"},
+		periodType:        valueType{"samples", "count"},
+		period:            1,
+		defaultSampleType: "samples",
+	}
+	require.Equal(t, []byte{
+		0x0a, 0x04, 0x08, 0x01, 0x10, 0x02, // sample_type {type: 1, unit: 2}
+		0x12, 0x07, 0x0a, 0x01, 0x01, 0x12, 0x02, 0xac, 0x02, // sample {location_id: [1], value: [300]}
+		0x22, 0x06, 0x08, 0x01, 0x22, 0x02, 0x08, 0x01, // location {id: 1, line: {function_id: 1}}
+		0x2a, 0x06, 0x08, 0x01, 0x10, 0x03, 0x18, 0x03, // function {id: 1, name: 3, system_name: 3}
+		0x32, 0x00, // string_table: ""
+		0x32, 0x07, 's', 'a', 'm', 'p', 'l', 'e', 's',
+		0x32, 0x05, 'c', 'o', 'u', 'n', 't',
+		0x32, 0x01, 'f',
+		0x5a, 0x04, 0x08, 0x01, 0x10, 0x02, // period_type {type: 1, unit: 2}
+		0x60, 0x01, // period: 1
+		0x70, 0x01, // default_sample_type: 1
+	}, prof.encode())
+}
+
+// funcDef implements experimental.FunctionDefinition for tests which don't instantiate a module.
+type funcDef struct {
+	experimental.FunctionDefinition
+	moduleName, name string
+}
+
+func (f *funcDef) ModuleName() string { return f.moduleName }
+func (f *funcDef) Name() string       { return f.name }
+func (f *funcDef) Index() uint32      { return 0 }
@@ -449,6 +449,58 @@ const (
 	PMAXUW
 	// PMAXUB is the PMAXUB instruction https://www.felixcloutier.com/x86/pmaxub:pmaxuw
 	PMAXUB
+	// MINPS is the MINPS instruction https://www.felixcloutier.com/x86/minps
+	MINPS
+	// MAXPS is the MAXPS instruction https://www.felixcloutier.com/x86/maxps
+	MAXPS
+	// MINPD is the MINPD instruction https://www.felixcloutier.com/x86/minpd
+	MINPD
+	// MAXPD is the MAXPD instruction https://www.felixcloutier.com/x86/maxpd
+	MAXPD
+	// MULPS is the MULPS instruction https://www.felixcloutier.com/x86/mulps
+	MULPS
+	// MULPD is the MULPD instruction https://www.felixcloutier.com/x86/mulpd
+	MULPD
+	// CVTTPS2DQ is the CVTTPS2DQ instruction https://www.felixcloutier.com/x86/cvttps2dq
+	CVTTPS2DQ
+	// CVTTPD2DQ is the CVTTPD2DQ instruction https://www.felixcloutier.com/x86/cvttpd2dq
+	CVTTPD2DQ
+	// ROUNDPD is the ROUNDPD instruction https://www.felixcloutier.com/x86/roundpd
+	ROUNDPD
+	// SHUFPS is the SHUFPS instruction https://www.felixcloutier.com/x86/shufps
+	SHUFPS
+	// PMULHRSW is the PMULHRSW instruction https://www.felixcloutier.com/x86/pmulhrsw
+	PMULHRSW
+	// PMADDUBSW is the PMADDUBSW instruction https://www.felixcloutier.com/x86/pmaddubsw
+	PMADDUBSW
+	// PMADDWD is the PMADDWD instruction https://www.felixcloutier.com/x86/pmaddwd
+	PMADDWD
+	// LOCKXADDB is the XADD instruction for a single byte, with the LOCK prefix which makes it atomic. https://www.felixcloutier.com/x86/xadd
+	LOCKXADDB
+	// LOCKXADDW is the XADD instruction for a word, with the LOCK prefix which makes it atomic. https://www.felixcloutier.com/x86/xadd
+	LOCKXADDW
+	// LOCKXADDL is the XADD instruction in 32-bit mode, with the LOCK prefix which makes it atomic. https://www.felixcloutier.com/x86/xadd
+	LOCKXADDL
+	// LOCKXADDQ is the XADD instruction in 64-bit mode, with the LOCK prefix which makes it atomic. https://www.felixcloutier.com/x86/xadd
+	LOCKXADDQ
+	// LOCKCMPXCHGB is the CMPXCHG instruction for a single byte, with the LOCK prefix which makes it atomic. https://www.felixcloutier.com/x86/cmpxchg
+	LOCKCMPXCHGB
+	// LOCKCMPXCHGW is the CMPXCHG instruction for a word, with the LOCK prefix which makes it atomic. https://www.felixcloutier.com/x86/cmpxchg
+	LOCKCMPXCHGW
+	// LOCKCMPXCHGL is the CMPXCHG instruction in 32-bit mode, with the LOCK prefix which makes it atomic. https://www.felixcloutier.com/x86/cmpxchg
+	LOCKCMPXCHGL
+	// LOCKCMPXCHGQ is the CMPXCHG instruction in 64-bit mode, with the LOCK prefix which makes it atomic. https://www.felixcloutier.com/x86/cmpxchg
+	LOCKCMPXCHGQ
+	// XCHGB is the XCHG instruction for a single byte, which is atomic with a memory operand. https://www.felixcloutier.com/x86/xchg
+	XCHGB
+	// XCHGW is the XCHG instruction for a word, which is atomic with a memory operand. https://www.felixcloutier.com/x86/xchg
+	XCHGW
+	// XCHGL is the XCHG instruction in 32-bit mode, which is atomic with a memory operand. https://www.felixcloutier.com/x86/xchg
+	XCHGL
+	// XCHGQ is the XCHG instruction in 64-bit mode, which is atomic with a memory operand. https://www.felixcloutier.com/x86/xchg
+	XCHGQ
+	// MFENCE is the MFENCE instruction. https://www.felixcloutier.com/x86/mfence
+	MFENCE
 
 	// instructionEnd is always placed at the bottom of this iota definition to be used in the test.
 	instructionEnd
@@ -863,6 +915,58 @@ func InstructionName(instruction asm.Instruction) string {
 		return "PMAXSW"
 	case PMAXSB:
 		return "PMAXSB"
+	case MINPS:
+		return "MINPS"
+	case MAXPS:
+		return "MAXPS"
+	case MINPD:
+		return "MINPD"
+	case MAXPD:
+		return "MAXPD"
+	case MULPS:
+		return "MULPS"
+	case MULPD:
+		return "MULPD"
+	case CVTTPS2DQ:
+		return "CVTTPS2DQ"
+	case CVTTPD2DQ:
+		return "CVTTPD2DQ"
+	case ROUNDPD:
+		return "ROUNDPD"
+	case SHUFPS:
+		return "SHUFPS"
+	case PMULHRSW:
+		return "PMULHRSW"
+	case PMADDUBSW:
+		return "PMADDUBSW"
+	case PMADDWD:
+		return "PMADDWD"
+	case LOCKXADDB:
+		return "LOCKXADDB"
+	case LOCKXADDW:
+		return "LOCKXADDW"
+	case LOCKXADDL:
+		return "LOCKXADDL"
+	case LOCKXADDQ:
+		return "LOCKXADDQ"
+	case LOCKCMPXCHGB:
+		return "LOCKCMPXCHGB"
+	case LOCKCMPXCHGW:
+		return "LOCKCMPXCHGW"
+	case LOCKCMPXCHGL:
+		return "LOCKCMPXCHGL"
+	case LOCKCMPXCHGQ:
+		return "LOCKCMPXCHGQ"
+	case XCHGB:
+		return "XCHGB"
+	case XCHGW:
+		return "XCHGW"
+	case XCHGL:
+		return "XCHGL"
+	case XCHGQ:
+		return "XCHGQ"
+	case MFENCE:
+		return "MFENCE"
 	}
 	panic(fmt.Errorf("unknown instruction %d", instruction))
 }
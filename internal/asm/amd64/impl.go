@@ -263,6 +263,7 @@ func (a *AssemblerImpl) addNode(node *NodeImpl) {
 		origin.JumpTarget = node
 	}
 	a.SetBranchTargetOnNextNodes = nil
+	a.ResolveOffsetCallbacks(node)
 }
 
 // EncodeNode encodes the given node into writer.
@@ -782,6 +783,9 @@ func (a *AssemblerImpl) encodeNoneToNone(n *NodeImpl) (err error) {
 	case UD2:
 		// https://mudongliang.github.io/x86/html/file_module_x86_id_318.html
 		_, err = a.Buf.Write([]byte{0x0f, 0x0b})
+	case MFENCE:
+		// https://www.felixcloutier.com/x86/mfence
+		_, err = a.Buf.Write([]byte{0x0f, 0xae, 0xf0})
 	default:
 		err = errorEncodingUnsupported(n)
 	}
@@ -1330,6 +1334,32 @@ var registerToRegisterOpcode = map[asm.Instruction]struct {
 	PMAXUW: {mandatoryPrefix: 0x66, opcode: []byte{0x0f, 0x38, 0x3e}, requireSrcFloat: true, requireDstFloat: true},
 	// https://www.felixcloutier.com/x86/pmaxub:pmaxuw
 	PMAXUB: {mandatoryPrefix: 0x66, opcode: []byte{0x0f, 0xde}, requireSrcFloat: true, requireDstFloat: true},
+	// https://www.felixcloutier.com/x86/minps
+	MINPS: {opcode: []byte{0x0f, 0x5d}, requireSrcFloat: true, requireDstFloat: true},
+	// https://www.felixcloutier.com/x86/maxps
+	MAXPS: {opcode: []byte{0x0f, 0x5f}, requireSrcFloat: true, requireDstFloat: true},
+	// https://www.felixcloutier.com/x86/minpd
+	MINPD: {mandatoryPrefix: 0x66, opcode: []byte{0x0f, 0x5d}, requireSrcFloat: true, requireDstFloat: true},
+	// https://www.felixcloutier.com/x86/maxpd
+	MAXPD: {mandatoryPrefix: 0x66, opcode: []byte{0x0f, 0x5f}, requireSrcFloat: true, requireDstFloat: true},
+	// https://www.felixcloutier.com/x86/mulps
+	MULPS: {opcode: []byte{0x0f, 0x59}, requireSrcFloat: true, requireDstFloat: true},
+	// https://www.felixcloutier.com/x86/mulpd
+	MULPD: {mandatoryPrefix: 0x66, opcode: []byte{0x0f, 0x59}, requireSrcFloat: true, requireDstFloat: true},
+	// https://www.felixcloutier.com/x86/cvttps2dq
+	CVTTPS2DQ: {mandatoryPrefix: 0xf3, opcode: []byte{0x0f, 0x5b}, requireSrcFloat: true, requireDstFloat: true},
+	// https://www.felixcloutier.com/x86/cvttpd2dq
+	CVTTPD2DQ: {mandatoryPrefix: 0x66, opcode: []byte{0x0f, 0xe6}, requireSrcFloat: true, requireDstFloat: true},
+	// https://www.felixcloutier.com/x86/roundpd
+	ROUNDPD: {mandatoryPrefix: 0x66, opcode: []byte{0x0f, 0x3a, 0x09}, requireSrcFloat: true, requireDstFloat: true, needArg: true},
+	// https://www.felixcloutier.com/x86/shufps
+	SHUFPS: {opcode: []byte{0x0f, 0xc6}, requireSrcFloat: true, requireDstFloat: true, needArg: true},
+	// https://www.felixcloutier.com/x86/pmulhrsw
+	PMULHRSW: {mandatoryPrefix: 0x66, opcode: []byte{0x0f, 0x38, 0x0b}, requireSrcFloat: true, requireDstFloat: true},
+	// https://www.felixcloutier.com/x86/pmaddubsw
+	PMADDUBSW: {mandatoryPrefix: 0x66, opcode: []byte{0x0f, 0x38, 0x04}, requireSrcFloat: true, requireDstFloat: true},
+	// https://www.felixcloutier.com/x86/pmaddwd
+	PMADDWD: {mandatoryPrefix: 0x66, opcode: []byte{0x0f, 0xf5}, requireSrcFloat: true, requireDstFloat: true},
 }
 
 var RegisterToRegisterShiftOpcode = map[asm.Instruction]struct {
@@ -1493,6 +1523,7 @@ func (a *AssemblerImpl) EncodeRegisterToMemory(n *NodeImpl) (err error) {
 	var mandatoryPrefix byte
 	var isShiftInstruction bool
 	var needArg bool
+	var lockPrefix bool
 	switch n.Instruction {
 	case CMPL:
 		// https://www.felixcloutier.com/x86/cmp
@@ -1607,10 +1638,32 @@ func (a *AssemblerImpl) EncodeRegisterToMemory(n *NodeImpl) (err error) {
 		rexPrefix |= RexPrefixW // REX.W
 		opcode = []byte{0x0f, 0x3a, 0x16}
 		needArg = true
+	case LOCKXADDB, LOCKXADDW, LOCKXADDL, LOCKXADDQ: // https://www.felixcloutier.com/x86/xadd
+		lockPrefix = true
+		opcode = []byte{0x0f, 0xc1}
+	case LOCKCMPXCHGB, LOCKCMPXCHGW, LOCKCMPXCHGL, LOCKCMPXCHGQ: // https://www.felixcloutier.com/x86/cmpxchg
+		lockPrefix = true
+		opcode = []byte{0x0f, 0xb1}
+	case XCHGB, XCHGW, XCHGL, XCHGQ: // https://www.felixcloutier.com/x86/xchg
+		opcode = []byte{0x87}
 	default:
 		return errorEncodingUnsupported(n)
 	}
 
+	// The opcodes of the atomic instructions above are for 32-bit mode, and are adjusted to the other sizes here.
+	switch n.Instruction {
+	case LOCKXADDB, LOCKCMPXCHGB, XCHGB:
+		opcode[len(opcode)-1]-- // The opcodes for a single byte precede the others.
+		// 1 byte register operands need default prefix for the following registers.
+		if n.SrcReg >= RegSP && n.SrcReg <= RegDI {
+			rexPrefix |= RexPrefixDefault
+		}
+	case LOCKXADDW, LOCKCMPXCHGW, XCHGW:
+		mandatoryPrefix = 0x66 // operand-size override prefix.
+	case LOCKXADDQ, LOCKCMPXCHGQ, XCHGQ:
+		rexPrefix |= RexPrefixW
+	}
+
 	if !isShiftInstruction {
 		srcReg3Bits, prefix, err := register3bits(n.SrcReg, registerSpecifierPositionModRMFieldReg)
 		if err != nil {
@@ -1625,6 +1678,11 @@ func (a *AssemblerImpl) EncodeRegisterToMemory(n *NodeImpl) (err error) {
 		}
 	}
 
+	if lockPrefix {
+		// https://www.felixcloutier.com/x86/lock
+		a.Buf.WriteByte(0xf0)
+	}
+
 	if mandatoryPrefix != 0 {
 		// https://wiki.osdev.org/X86-64_Instruction_Encoding#Mandatory_prefix
 		a.Buf.WriteByte(mandatoryPrefix)
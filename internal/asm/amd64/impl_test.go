@@ -373,6 +373,7 @@ func TestAssemblerImpl_encodeNoneToNone(t *testing.T) {
 		{inst: CQO, exp: []byte{0x48, 0x99}},
 		{inst: NOP, exp: nil},
 		{inst: RET, exp: []byte{0xc3}},
+		{inst: MFENCE, exp: []byte{0xf, 0xae, 0xf0}},
 	}
 
 	for _, tt := range tests {
@@ -459,6 +460,126 @@ func TestAssemblerImpl_EncodeRegisterToMemory(t *testing.T) {
 			},
 			exp: []byte{0xf3, 0x41, 0xf, 0x7f, 0x5d, 0x0},
 		},
+		{
+			n: &NodeImpl{
+				Instruction: LOCKXADDB,
+				Types:       OperandTypesRegisterToMemory,
+				SrcReg:      RegSI,
+				DstReg:      RegR13,
+				DstConst:    10,
+			},
+			exp: []byte{0xf0, 0x41, 0xf, 0xc0, 0x75, 0xa},
+		},
+		{
+			n: &NodeImpl{
+				Instruction: LOCKXADDW,
+				Types:       OperandTypesRegisterToMemory,
+				SrcReg:      RegR9,
+				DstReg:      RegAX,
+				DstConst:    0,
+			},
+			exp: []byte{0xf0, 0x66, 0x44, 0xf, 0xc1, 0x8},
+		},
+		{
+			n: &NodeImpl{
+				Instruction: LOCKXADDL,
+				Types:       OperandTypesRegisterToMemory,
+				SrcReg:      RegCX,
+				DstReg:      RegBX,
+				DstConst:    256,
+			},
+			exp: []byte{0xf0, 0xf, 0xc1, 0x8b, 0x0, 0x1, 0x0, 0x0},
+		},
+		{
+			n: &NodeImpl{
+				Instruction: LOCKXADDQ,
+				Types:       OperandTypesRegisterToMemory,
+				SrcReg:      RegR12,
+				DstReg:      RegR13,
+				DstConst:    0,
+			},
+			exp: []byte{0xf0, 0x4d, 0xf, 0xc1, 0x65, 0x0},
+		},
+		{
+			n: &NodeImpl{
+				Instruction: LOCKCMPXCHGB,
+				Types:       OperandTypesRegisterToMemory,
+				SrcReg:      RegDI,
+				DstReg:      RegAX,
+				DstConst:    0,
+			},
+			exp: []byte{0xf0, 0x40, 0xf, 0xb0, 0x38},
+		},
+		{
+			n: &NodeImpl{
+				Instruction: LOCKCMPXCHGW,
+				Types:       OperandTypesRegisterToMemory,
+				SrcReg:      RegCX,
+				DstReg:      RegR10,
+				DstConst:    0,
+			},
+			exp: []byte{0xf0, 0x66, 0x41, 0xf, 0xb1, 0xa},
+		},
+		{
+			n: &NodeImpl{
+				Instruction: LOCKCMPXCHGL,
+				Types:       OperandTypesRegisterToMemory,
+				SrcReg:      RegR11,
+				DstReg:      RegSP,
+				DstConst:    8,
+			},
+			exp: []byte{0xf0, 0x44, 0xf, 0xb1, 0x5c, 0x24, 0x8},
+		},
+		{
+			n: &NodeImpl{
+				Instruction: LOCKCMPXCHGQ,
+				Types:       OperandTypesRegisterToMemory,
+				SrcReg:      RegDX,
+				DstReg:      RegBX,
+				DstConst:    0,
+			},
+			exp: []byte{0xf0, 0x48, 0xf, 0xb1, 0x13},
+		},
+		{
+			n: &NodeImpl{
+				Instruction: XCHGB,
+				Types:       OperandTypesRegisterToMemory,
+				SrcReg:      RegBX,
+				DstReg:      RegR8,
+				DstConst:    0,
+			},
+			exp: []byte{0x41, 0x86, 0x18},
+		},
+		{
+			n: &NodeImpl{
+				Instruction: XCHGW,
+				Types:       OperandTypesRegisterToMemory,
+				SrcReg:      RegAX,
+				DstReg:      RegDX,
+				DstConst:    0,
+			},
+			exp: []byte{0x66, 0x87, 0x2},
+		},
+		{
+			n: &NodeImpl{
+				Instruction: XCHGL,
+				Types:       OperandTypesRegisterToMemory,
+				SrcReg:      RegR15,
+				DstReg:      RegAX,
+				DstConst:    4,
+			},
+			exp: []byte{0x44, 0x87, 0x78, 0x4},
+		},
+		{
+			n: &NodeImpl{
+				Instruction: XCHGQ,
+				Types:       OperandTypesRegisterToMemory,
+				SrcReg:      RegCX,
+				DstReg:      RegR14,
+				DstConst:    0,
+			},
+			exp: []byte{0x49, 0x87, 0xe},
+		},
 	}
 
 	for _, tt := range tests {
@@ -937,6 +1058,138 @@ func TestAssemblerImpl_EncodeRegisterToRegister(t *testing.T) {
 			},
 			exp: []byte{0x66, 0xf, 0x65, 0xca},
 		},
+		{
+			name: "minps xmm10, xmm3",
+			n: &NodeImpl{
+				Instruction: MINPS,
+				Types:       OperandTypesRegisterToRegister,
+				SrcReg:      RegX3,
+				DstReg:      RegX10,
+			},
+			exp: []byte{0x44, 0xf, 0x5d, 0xd3},
+		},
+		{
+			name: "maxps xmm1, xmm2",
+			n: &NodeImpl{
+				Instruction: MAXPS,
+				Types:       OperandTypesRegisterToRegister,
+				SrcReg:      RegX2,
+				DstReg:      RegX1,
+			},
+			exp: []byte{0xf, 0x5f, 0xca},
+		},
+		{
+			name: "minpd xmm1, xmm2",
+			n: &NodeImpl{
+				Instruction: MINPD,
+				Types:       OperandTypesRegisterToRegister,
+				SrcReg:      RegX2,
+				DstReg:      RegX1,
+			},
+			exp: []byte{0x66, 0xf, 0x5d, 0xca},
+		},
+		{
+			name: "maxpd xmm1, xmm12",
+			n: &NodeImpl{
+				Instruction: MAXPD,
+				Types:       OperandTypesRegisterToRegister,
+				SrcReg:      RegX12,
+				DstReg:      RegX1,
+			},
+			exp: []byte{0x66, 0x41, 0xf, 0x5f, 0xcc},
+		},
+		{
+			name: "mulps xmm1, xmm2",
+			n: &NodeImpl{
+				Instruction: MULPS,
+				Types:       OperandTypesRegisterToRegister,
+				SrcReg:      RegX2,
+				DstReg:      RegX1,
+			},
+			exp: []byte{0xf, 0x59, 0xca},
+		},
+		{
+			name: "mulpd xmm1, xmm2",
+			n: &NodeImpl{
+				Instruction: MULPD,
+				Types:       OperandTypesRegisterToRegister,
+				SrcReg:      RegX2,
+				DstReg:      RegX1,
+			},
+			exp: []byte{0x66, 0xf, 0x59, 0xca},
+		},
+		{
+			name: "cvttps2dq xmm1, xmm2",
+			n: &NodeImpl{
+				Instruction: CVTTPS2DQ,
+				Types:       OperandTypesRegisterToRegister,
+				SrcReg:      RegX2,
+				DstReg:      RegX1,
+			},
+			exp: []byte{0xf3, 0xf, 0x5b, 0xca},
+		},
+		{
+			name: "cvttpd2dq xmm1, xmm2",
+			n: &NodeImpl{
+				Instruction: CVTTPD2DQ,
+				Types:       OperandTypesRegisterToRegister,
+				SrcReg:      RegX2,
+				DstReg:      RegX1,
+			},
+			exp: []byte{0x66, 0xf, 0xe6, 0xca},
+		},
+		{
+			name: "roundpd xmm1, xmm2, 3",
+			n: &NodeImpl{
+				Instruction: ROUNDPD,
+				Types:       OperandTypesRegisterToRegister,
+				SrcReg:      RegX2,
+				DstReg:      RegX1,
+				Arg:         3,
+			},
+			exp: []byte{0x66, 0xf, 0x3a, 0x9, 0xca, 0x3},
+		},
+		{
+			name: "shufps xmm1, xmm2, 0x88",
+			n: &NodeImpl{
+				Instruction: SHUFPS,
+				Types:       OperandTypesRegisterToRegister,
+				SrcReg:      RegX2,
+				DstReg:      RegX1,
+				Arg:         0x88,
+			},
+			exp: []byte{0xf, 0xc6, 0xca, 0x88},
+		},
+		{
+			name: "pmulhrsw xmm1, xmm2",
+			n: &NodeImpl{
+				Instruction: PMULHRSW,
+				Types:       OperandTypesRegisterToRegister,
+				SrcReg:      RegX2,
+				DstReg:      RegX1,
+			},
+			exp: []byte{0x66, 0xf, 0x38, 0xb, 0xca},
+		},
+		{
+			name: "pmaddubsw xmm1, xmm2",
+			n: &NodeImpl{
+				Instruction: PMADDUBSW,
+				Types:       OperandTypesRegisterToRegister,
+				SrcReg:      RegX2,
+				DstReg:      RegX1,
+			},
+			exp: []byte{0x66, 0xf, 0x38, 0x4, 0xca},
+		},
+		{
+			name: "pmaddwd xmm1, xmm2",
+			n: &NodeImpl{
+				Instruction: PMADDWD,
+				Types:       OperandTypesRegisterToRegister,
+				SrcReg:      RegX2,
+				DstReg:      RegX1,
+			},
+			exp: []byte{0x66, 0xf, 0xf5, 0xca},
+		},
 	}
 
 	for _, tt := range tests {
@@ -727,6 +727,40 @@ const (
 	TBL1
 	// TBL2 is the TBL instruction whose source is two vectors. https://developer.arm.com/documentation/ddi0596/2020-12/SIMD-FP-Instructions/TBL--Table-vector-Lookup-
 	TBL2
+	// LDARB is the LDARB instruction, which loads a byte with acquire semantics. https://developer.arm.com/documentation/ddi0596/2021-12/Base-Instructions/LDARB--Load-Acquire-Register--Byte-
+	LDARB
+	// LDARH is the LDARH instruction, which loads a halfword with acquire semantics. https://developer.arm.com/documentation/ddi0596/2021-12/Base-Instructions/LDARH--Load-Acquire-Register--Halfword-
+	LDARH
+	// LDARW is the LDAR instruction in 32-bit mode, which loads a word with acquire semantics. https://developer.arm.com/documentation/ddi0596/2021-12/Base-Instructions/LDAR--Load-Acquire-Register-
+	LDARW
+	// LDAR is the LDAR instruction, which loads a doubleword with acquire semantics. https://developer.arm.com/documentation/ddi0596/2021-12/Base-Instructions/LDAR--Load-Acquire-Register-
+	LDAR
+	// LDAXRB is the LDAXRB instruction, which loads a byte exclusively with acquire semantics. https://developer.arm.com/documentation/ddi0596/2021-12/Base-Instructions/LDAXRB--Load-Acquire-Exclusive-Register--Byte-
+	LDAXRB
+	// LDAXRH is the LDAXRH instruction, which loads a halfword exclusively with acquire semantics. https://developer.arm.com/documentation/ddi0596/2021-12/Base-Instructions/LDAXRH--Load-Acquire-Exclusive-Register--Halfword-
+	LDAXRH
+	// LDAXRW is the LDAXR instruction in 32-bit mode, which loads a word exclusively with acquire semantics. https://developer.arm.com/documentation/ddi0596/2021-12/Base-Instructions/LDAXR--Load-Acquire-Exclusive-Register-
+	LDAXRW
+	// LDAXR is the LDAXR instruction, which loads a doubleword exclusively with acquire semantics. https://developer.arm.com/documentation/ddi0596/2021-12/Base-Instructions/LDAXR--Load-Acquire-Exclusive-Register-
+	LDAXR
+	// STLRB is the STLRB instruction, which stores a byte with release semantics. https://developer.arm.com/documentation/ddi0596/2021-12/Base-Instructions/STLRB--Store-Release-Register--Byte-
+	STLRB
+	// STLRH is the STLRH instruction, which stores a halfword with release semantics. https://developer.arm.com/documentation/ddi0596/2021-12/Base-Instructions/STLRH--Store-Release-Register--Halfword-
+	STLRH
+	// STLRW is the STLR instruction in 32-bit mode, which stores a word with release semantics. https://developer.arm.com/documentation/ddi0596/2021-12/Base-Instructions/STLR--Store-Release-Register-
+	STLRW
+	// STLR is the STLR instruction, which stores a doubleword with release semantics. https://developer.arm.com/documentation/ddi0596/2021-12/Base-Instructions/STLR--Store-Release-Register-
+	STLR
+	// STLXRB is the STLXRB instruction, which stores a byte exclusively with release semantics. https://developer.arm.com/documentation/ddi0596/2021-12/Base-Instructions/STLXRB--Store-Release-Exclusive-Register--Byte-
+	STLXRB
+	// STLXRH is the STLXRH instruction, which stores a halfword exclusively with release semantics. https://developer.arm.com/documentation/ddi0596/2021-12/Base-Instructions/STLXRH--Store-Release-Exclusive-Register--Halfword-
+	STLXRH
+	// STLXRW is the STLXR instruction in 32-bit mode, which stores a word exclusively with release semantics. https://developer.arm.com/documentation/ddi0596/2021-12/Base-Instructions/STLXR--Store-Release-Exclusive-Register-
+	STLXRW
+	// STLXR is the STLXR instruction, which stores a doubleword exclusively with release semantics. https://developer.arm.com/documentation/ddi0596/2021-12/Base-Instructions/STLXR--Store-Release-Exclusive-Register-
+	STLXR
+	// DMB is the DMB instruction with the ISH option, which is a full barrier for the inner shareable domain. https://developer.arm.com/documentation/ddi0596/2021-12/Base-Instructions/DMB--Data-Memory-Barrier-
+	DMB
 
 	// instructionEnd is always placed at the bottom of this iota definition to be used in the test.
 	instructionEnd
@@ -1091,6 +1125,40 @@ func InstructionName(i asm.Instruction) string {
 		return "TBL1"
 	case TBL2:
 		return "TBL2"
+	case LDARB:
+		return "LDARB"
+	case LDARH:
+		return "LDARH"
+	case LDARW:
+		return "LDARW"
+	case LDAR:
+		return "LDAR"
+	case LDAXRB:
+		return "LDAXRB"
+	case LDAXRH:
+		return "LDAXRH"
+	case LDAXRW:
+		return "LDAXRW"
+	case LDAXR:
+		return "LDAXR"
+	case STLRB:
+		return "STLRB"
+	case STLRH:
+		return "STLRH"
+	case STLRW:
+		return "STLRW"
+	case STLR:
+		return "STLR"
+	case STLXRB:
+		return "STLXRB"
+	case STLXRH:
+		return "STLXRH"
+	case STLXRW:
+		return "STLXRW"
+	case STLXR:
+		return "STLXR"
+	case DMB:
+		return "DMB"
 	}
 	panic(fmt.Errorf("unknown instruction %d", i))
 }
@@ -274,6 +274,7 @@ func (a *AssemblerImpl) addNode(node *NodeImpl) {
 		origin.JumpTarget = node
 	}
 	a.SetBranchTargetOnNextNodes = nil
+	a.ResolveOffsetCallbacks(node)
 }
 
 // Assemble implements asm.AssemblerBase
@@ -723,7 +724,12 @@ func errorEncodingUnsupported(n *NodeImpl) error {
 // EncodeNoneToNone is exported for inter-op testing with golang-asm.
 // TODO: unexport after golang-asm complete removal.
 func (a *AssemblerImpl) EncodeNoneToNone(n *NodeImpl) (err error) {
-	if n.Instruction != NOP {
+	switch n.Instruction {
+	case NOP:
+	case DMB:
+		// DMB ISH: https://developer.arm.com/documentation/ddi0596/2021-12/Base-Instructions/DMB--Data-Memory-Barrier-
+		a.Buf.Write([]byte{0xbf, 0x3b, 0x03, 0xd5})
+	default:
 		err = errorEncodingUnsupported(n)
 	}
 	return
@@ -1413,6 +1419,22 @@ func (a *AssemblerImpl) EncodeLeftShiftedRegisterToRegister(n *NodeImpl) (err er
 // TODO: unexport after golang-asm complete removal.
 func (a *AssemblerImpl) EncodeTwoRegistersToRegister(n *NodeImpl) (err error) {
 	switch inst := n.Instruction; inst {
+	case STLXRB, STLXRH, STLXRW, STLXR:
+		// The value in the first register is stored at the address in the second one, and the status in the
+		// destination is zero if the store succeeded.
+		valueRegBits, err := intRegisterBits(n.SrcReg)
+		if err != nil {
+			return err
+		}
+		baseRegBits, err := intRegisterBits(n.SrcReg2)
+		if err != nil {
+			return err
+		}
+		statusRegBits, err := intRegisterBits(n.DstReg)
+		if err != nil {
+			return err
+		}
+		a.encodeOrderedLoadOrStore(inst, statusRegBits, baseRegBits, valueRegBits)
 	case AND, ANDW, ORR, ORRW, EOR, EORW:
 		// See "Logical (shifted register)" in
 		// https://developer.arm.com/documentation/ddi0596/2021-12/Index-by-Encoding/Data-Processing----Register?lang=en
@@ -1809,9 +1831,68 @@ var storeOrLoadInstructionTable = map[asm.Instruction]struct {
 	FMOVS: {size: 0b10, v: 0x1, datasize: 4, datasizeLog2: 2, isTargetFloat: true},
 }
 
+// orderedLoadOrStoreInstructionTable holds the fields of the "Load/store exclusive" and "Load-acquire/store-release"
+// instructions, which only take a base register without offset.
+// See https://developer.arm.com/documentation/ddi0596/2021-12/Index-by-Encoding/Loads-and-Stores?lang=en
+var orderedLoadOrStoreInstructionTable = map[asm.Instruction]struct {
+	size, o2, l, o0 byte
+}{
+	LDARB:  {size: 0b00, o2: 1, l: 1, o0: 1},
+	LDARH:  {size: 0b01, o2: 1, l: 1, o0: 1},
+	LDARW:  {size: 0b10, o2: 1, l: 1, o0: 1},
+	LDAR:   {size: 0b11, o2: 1, l: 1, o0: 1},
+	LDAXRB: {size: 0b00, o2: 0, l: 1, o0: 1},
+	LDAXRH: {size: 0b01, o2: 0, l: 1, o0: 1},
+	LDAXRW: {size: 0b10, o2: 0, l: 1, o0: 1},
+	LDAXR:  {size: 0b11, o2: 0, l: 1, o0: 1},
+	STLRB:  {size: 0b00, o2: 1, l: 0, o0: 1},
+	STLRH:  {size: 0b01, o2: 1, l: 0, o0: 1},
+	STLRW:  {size: 0b10, o2: 1, l: 0, o0: 1},
+	STLR:   {size: 0b11, o2: 1, l: 0, o0: 1},
+	STLXRB: {size: 0b00, o2: 0, l: 0, o0: 1},
+	STLXRH: {size: 0b01, o2: 0, l: 0, o0: 1},
+	STLXRW: {size: 0b10, o2: 0, l: 0, o0: 1},
+	STLXR:  {size: 0b11, o2: 0, l: 0, o0: 1},
+}
+
+// encodeOrderedLoadOrStore encodes the instruction in orderedLoadOrStoreInstructionTable, with the status register
+// bits of the exclusive stores, which are all ones otherwise.
+func (a *AssemblerImpl) encodeOrderedLoadOrStore(instruction asm.Instruction, statusRegBits, baseRegBits, targetRegBits byte) {
+	inst := orderedLoadOrStoreInstructionTable[instruction]
+	a.Buf.Write([]byte{
+		(baseRegBits << 5) | targetRegBits,
+		0b111111<<2 | inst.o0<<7 | (baseRegBits >> 3),
+		inst.o2<<7 | inst.l<<6 | statusRegBits,
+		inst.size<<6 | 0b001000,
+	})
+}
+
+// encodeOrderedLoadOrStoreWithMemory encodes the instruction in orderedLoadOrStoreInstructionTable other than the
+// exclusive stores, which only accept a memory operand without offset.
+func (a *AssemblerImpl) encodeOrderedLoadOrStoreWithMemory(n *NodeImpl, targetReg, baseReg, offsetReg asm.Register, offset asm.ConstantValue) error {
+	if offsetReg != asm.NilRegister || offset != 0 {
+		return fmt.Errorf("offset for %s must be zero", InstructionName(n.Instruction))
+	}
+	targetRegBits, err := intRegisterBits(targetReg)
+	if err != nil {
+		return err
+	}
+	baseRegBits, err := intRegisterBits(baseReg)
+	if err != nil {
+		return err
+	}
+	a.encodeOrderedLoadOrStore(n.Instruction, zeroRegisterBits, baseRegBits, targetRegBits)
+	return nil
+}
+
 // Exported for inter-op testing with golang-asm.
 // TODO: unexport after golang-asm complete removal.
 func (a *AssemblerImpl) EncodeRegisterToMemory(n *NodeImpl) (err error) {
+	switch n.Instruction {
+	case STLRB, STLRH, STLRW, STLR:
+		return a.encodeOrderedLoadOrStoreWithMemory(n, n.SrcReg, n.DstReg, n.DstReg2, n.DstConst)
+	}
+
 	inst, ok := storeOrLoadInstructionTable[n.Instruction]
 	if !ok {
 		return errorEncodingUnsupported(n)
@@ -1898,8 +1979,11 @@ func (a *AssemblerImpl) encodeADR(n *NodeImpl) (err error) {
 // Exported for inter-op testing with golang-asm.
 // TODO: unexport after golang-asm complete removal.
 func (a *AssemblerImpl) EncodeMemoryToRegister(n *NodeImpl) (err error) {
-	if n.Instruction == ADR {
+	switch n.Instruction {
+	case ADR:
 		return a.encodeADR(n)
+	case LDARB, LDARH, LDARW, LDAR, LDAXRB, LDAXRH, LDAXRW, LDAXR:
+		return a.encodeOrderedLoadOrStoreWithMemory(n, n.DstReg, n.SrcReg, n.SrcReg2, n.SrcConst)
 	}
 
 	inst, ok := storeOrLoadInstructionTable[n.Instruction]
@@ -1519,3 +1519,50 @@ func TestAssemblerImpl_EncodeStaticConstToVectorRegister(t *testing.T) {
 		})
 	}
 }
+
+func TestAssemblerImpl_EncodeOrderedLoadOrStore(t *testing.T) {
+	// These are not supported by golang-asm, so we test here instead of integration tests.
+	// The expected bytes are those assembled by "go tool asm".
+	tests := []struct {
+		name string
+		n    *NodeImpl
+		exp  []byte
+	}{
+		{name: "ldarb w2, [x1]", n: &NodeImpl{Instruction: LDARB, Types: OperandTypesMemoryToRegister, SrcReg: RegR1, DstReg: RegR2}, exp: []byte{0x22, 0xfc, 0xdf, 0x08}},
+		{name: "ldarh w2, [x1]", n: &NodeImpl{Instruction: LDARH, Types: OperandTypesMemoryToRegister, SrcReg: RegR1, DstReg: RegR2}, exp: []byte{0x22, 0xfc, 0xdf, 0x48}},
+		{name: "ldar w2, [x1]", n: &NodeImpl{Instruction: LDARW, Types: OperandTypesMemoryToRegister, SrcReg: RegR1, DstReg: RegR2}, exp: []byte{0x22, 0xfc, 0xdf, 0x88}},
+		{name: "ldar x2, [x1]", n: &NodeImpl{Instruction: LDAR, Types: OperandTypesMemoryToRegister, SrcReg: RegR1, DstReg: RegR2}, exp: []byte{0x22, 0xfc, 0xdf, 0xc8}},
+		{name: "ldaxrb w4, [x3]", n: &NodeImpl{Instruction: LDAXRB, Types: OperandTypesMemoryToRegister, SrcReg: RegR3, DstReg: RegR4}, exp: []byte{0x64, 0xfc, 0x5f, 0x08}},
+		{name: "ldaxrh w4, [x3]", n: &NodeImpl{Instruction: LDAXRH, Types: OperandTypesMemoryToRegister, SrcReg: RegR3, DstReg: RegR4}, exp: []byte{0x64, 0xfc, 0x5f, 0x48}},
+		{name: "ldaxr w4, [x3]", n: &NodeImpl{Instruction: LDAXRW, Types: OperandTypesMemoryToRegister, SrcReg: RegR3, DstReg: RegR4}, exp: []byte{0x64, 0xfc, 0x5f, 0x88}},
+		{name: "ldaxr x4, [x3]", n: &NodeImpl{Instruction: LDAXR, Types: OperandTypesMemoryToRegister, SrcReg: RegR3, DstReg: RegR4}, exp: []byte{0x64, 0xfc, 0x5f, 0xc8}},
+		{name: "stlrb w5, [x6]", n: &NodeImpl{Instruction: STLRB, Types: OperandTypesRegisterToMemory, SrcReg: RegR5, DstReg: RegR6}, exp: []byte{0xc5, 0xfc, 0x9f, 0x08}},
+		{name: "stlrh w5, [x6]", n: &NodeImpl{Instruction: STLRH, Types: OperandTypesRegisterToMemory, SrcReg: RegR5, DstReg: RegR6}, exp: []byte{0xc5, 0xfc, 0x9f, 0x48}},
+		{name: "stlr w5, [x6]", n: &NodeImpl{Instruction: STLRW, Types: OperandTypesRegisterToMemory, SrcReg: RegR5, DstReg: RegR6}, exp: []byte{0xc5, 0xfc, 0x9f, 0x88}},
+		{name: "stlr x5, [x6]", n: &NodeImpl{Instruction: STLR, Types: OperandTypesRegisterToMemory, SrcReg: RegR5, DstReg: RegR6}, exp: []byte{0xc5, 0xfc, 0x9f, 0xc8}},
+		{name: "stlxrb w9, w7, [x8]", n: &NodeImpl{Instruction: STLXRB, Types: OperandTypesTwoRegistersToRegister, SrcReg: RegR7, SrcReg2: RegR8, DstReg: RegR9}, exp: []byte{0x07, 0xfd, 0x09, 0x08}},
+		{name: "stlxrh w9, w7, [x8]", n: &NodeImpl{Instruction: STLXRH, Types: OperandTypesTwoRegistersToRegister, SrcReg: RegR7, SrcReg2: RegR8, DstReg: RegR9}, exp: []byte{0x07, 0xfd, 0x09, 0x48}},
+		{name: "stlxr w9, w7, [x8]", n: &NodeImpl{Instruction: STLXRW, Types: OperandTypesTwoRegistersToRegister, SrcReg: RegR7, SrcReg2: RegR8, DstReg: RegR9}, exp: []byte{0x07, 0xfd, 0x09, 0x88}},
+		{name: "stlxr w9, x7, [x8]", n: &NodeImpl{Instruction: STLXR, Types: OperandTypesTwoRegistersToRegister, SrcReg: RegR7, SrcReg2: RegR8, DstReg: RegR9}, exp: []byte{0x07, 0xfd, 0x09, 0xc8}},
+		{name: "stlxr w10, x30, [x27]", n: &NodeImpl{Instruction: STLXR, Types: OperandTypesTwoRegistersToRegister, SrcReg: RegR30, SrcReg2: RegR27, DstReg: RegR10}, exp: []byte{0x7e, 0xff, 0x0a, 0xc8}},
+		{name: "dmb ish", n: &NodeImpl{Instruction: DMB, Types: OperandTypesNoneToNone}, exp: []byte{0xbf, 0x3b, 0x03, 0xd5}},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			a := NewAssemblerImpl(RegR10)
+			err := a.EncodeNode(tc.n)
+			require.NoError(t, err)
+
+			actual := a.Buf.Bytes()
+			require.Equal(t, tc.exp, actual, hex.EncodeToString(actual))
+		})
+	}
+
+	t.Run("offset", func(t *testing.T) {
+		a := NewAssemblerImpl(RegR10)
+		err := a.EncodeNode(&NodeImpl{Instruction: LDAR, Types: OperandTypesMemoryToRegister, SrcReg: RegR1, SrcConst: 8, DstReg: RegR2})
+		require.EqualError(t, err, "offset for LDAR must be zero: LDAR [R1 + 0x8], R2")
+	})
+}
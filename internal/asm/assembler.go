@@ -76,6 +76,10 @@ type AssemblerBase interface {
 	// assigned to the given node's jump destination.
 	SetJumpTargetOnNext(nodes ...Node)
 
+	// SetOffsetCallbackOnNext instructs the assembler to invoke the callback with the offset of the next node in the
+	// final binary, once assembled. The callback isn't invoked if no node follows.
+	SetOffsetCallbackOnNext(cb func(NodeOffsetInBinary))
+
 	// BuildJumpTable calculates the offsets between the first instruction `initialInstructions[0]`
 	// and others (e.g. initialInstructions[3]), and wrote the calculated offsets into pre-allocated
 	// `table` slice in little endian.
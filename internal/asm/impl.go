@@ -16,6 +16,10 @@ type BaseAssemblerImpl struct {
 
 	// OnGenerateCallbacks holds the callbacks which are called after generating native code.
 	OnGenerateCallbacks []func(code []byte) error
+
+	// OffsetCallbacksOnNextNode holds the callbacks to invoke with the offset in the binary of the next coming
+	// instruction, once assembled.
+	OffsetCallbacksOnNextNode []func(NodeOffsetInBinary)
 }
 
 // SetJumpTargetOnNext implements AssemblerBase.SetJumpTargetOnNext
@@ -23,6 +27,24 @@ func (a *BaseAssemblerImpl) SetJumpTargetOnNext(nodes ...Node) {
 	a.SetBranchTargetOnNextNodes = append(a.SetBranchTargetOnNextNodes, nodes...)
 }
 
+// SetOffsetCallbackOnNext implements AssemblerBase.SetOffsetCallbackOnNext
+func (a *BaseAssemblerImpl) SetOffsetCallbackOnNext(cb func(NodeOffsetInBinary)) {
+	a.OffsetCallbacksOnNextNode = append(a.OffsetCallbacksOnNextNode, cb)
+}
+
+// ResolveOffsetCallbacks must be called by each architecture when adding a node, to invoke OffsetCallbacksOnNextNode
+// with its offset once assembled.
+func (a *BaseAssemblerImpl) ResolveOffsetCallbacks(node Node) {
+	for _, cb := range a.OffsetCallbacksOnNextNode {
+		cb := cb
+		a.AddOnGenerateCallBack(func([]byte) error {
+			cb(node.OffsetInBinary())
+			return nil
+		})
+	}
+	a.OffsetCallbacksOnNextNode = nil
+}
+
 // AddOnGenerateCallBack implements AssemblerBase.AddOnGenerateCallBack
 func (a *BaseAssemblerImpl) AddOnGenerateCallBack(cb func([]byte) error) {
 	a.OnGenerateCallbacks = append(a.OnGenerateCallbacks, cb)
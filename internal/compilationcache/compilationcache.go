@@ -0,0 +1,23 @@
+// Package compilationcache persists the output of compiling a module, so that later processes can skip compilation.
+package compilationcache
+
+import "crypto/sha256"
+
+// Key is the identity of a cache entry. Ex. a sha256 digest of the module ID and anything that affects the output of
+// compilation, such as the wazero version.
+type Key = [sha256.Size]byte
+
+// Cache stores opaque content by Key. Implementations must be safe for concurrent use.
+//
+// Note: A Cache may authenticate content, like NewFileCache does, but callers must still detect entries that are
+// corrupted or not in the format they expect, and Delete them.
+type Cache interface {
+	// Get returns the content stored with the key, or false if there is no entry.
+	Get(key Key) (content []byte, ok bool, err error)
+
+	// Add stores the content with the key, replacing any existing entry.
+	Add(key Key, content []byte) error
+
+	// Delete removes the entry with the key. It is not an error if there is no entry.
+	Delete(key Key) error
+}
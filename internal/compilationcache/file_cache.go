@@ -0,0 +1,243 @@
+package compilationcache
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// keySize is the size of the key of the HMAC which authenticates entries.
+const keySize = 32
+
+// NewFileCache returns a Cache which stores each entry as a file in the given directory, which is created on demand.
+//
+// Each entry is authenticated with an HMAC-SHA256, whose key is private to the current user and kept outside the
+// directory, in the "wazero" directory of os.UserConfigDir. Entries which don't match it, such as those modified by
+// another user, are deleted and reported missing.
+//
+// Note: The directory and its entries must be owned by the current user, and not writable by others, or Get and Add
+// fail, as entries are executed.
+func NewFileCache(dir string) Cache {
+	return &fileCache{dir: dir, keyPath: defaultKeyPath}
+}
+
+// defaultKeyPath returns the path of the file of the HMAC key used by NewFileCache.
+func defaultKeyPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "wazero", "compilationcache.key"), nil
+}
+
+// fileCache implements Cache with one file per entry, named by the hex encoded Key, and holding the content followed
+// by its HMAC.
+type fileCache struct {
+	dir string
+
+	// keyPath returns the path of the file of the HMAC key, which is read or created on first use.
+	keyPath func() (string, error)
+
+	keyOnce sync.Once
+	key     []byte
+	keyErr  error
+}
+
+// Get implements the same method as documented on Cache.
+func (c *fileCache) Get(key Key) (content []byte, ok bool, err error) {
+	if content, ok, err = c.get(key); err != nil {
+		return nil, false, fmt.Errorf("compilation cache: %w", err)
+	}
+	return
+}
+
+func (c *fileCache) get(key Key) ([]byte, bool, error) {
+	mac, err := c.mac(key)
+	if err != nil {
+		return nil, false, err
+	}
+	if err = checkPrivateDir(c.dir); errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	f, err := os.Open(c.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+
+	// Check the opened file, not the path, so that it can't be replaced in between.
+	if info, err := f.Stat(); err != nil {
+		return nil, false, err
+	} else if err = checkPrivate(c.path(key), info, 0o022); err != nil {
+		return nil, false, err
+	}
+	entry, err := io.ReadAll(f)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(entry) < sha256.Size {
+		return nil, false, c.Delete(key)
+	}
+	content, sum := entry[:len(entry)-sha256.Size], entry[len(entry)-sha256.Size:]
+	mac.Write(content)
+	if !hmac.Equal(sum, mac.Sum(nil)) {
+		// The entry wasn't written with our key, so delete it, so that it can be replaced by a valid one.
+		return nil, false, c.Delete(key)
+	}
+	return content, true, nil
+}
+
+// Add implements the same method as documented on Cache.
+//
+// Note: This writes to a temporary file first, so that concurrent readers, including other processes, never see a
+// partially written entry.
+func (c *fileCache) Add(key Key, content []byte) (err error) {
+	mac, err := c.mac(key)
+	if err != nil {
+		return fmt.Errorf("compilation cache: %w", err)
+	}
+	mac.Write(content)
+
+	if err = os.MkdirAll(c.dir, 0o700); err != nil {
+		return fmt.Errorf("compilation cache: %w", err)
+	}
+	if err = checkPrivateDir(c.dir); err != nil {
+		return fmt.Errorf("compilation cache: %w", err)
+	}
+
+	f, err := os.CreateTemp(c.dir, hex.EncodeToString(key[:])+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("compilation cache: %w", err)
+	}
+	tmp := f.Name()
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmp)
+		}
+	}()
+
+	if _, err = f.Write(mac.Sum(content)); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("compilation cache: %w", err)
+	}
+	if err = f.Close(); err != nil {
+		return fmt.Errorf("compilation cache: %w", err)
+	}
+	if err = os.Rename(tmp, c.path(key)); err != nil {
+		return fmt.Errorf("compilation cache: %w", err)
+	}
+	return nil
+}
+
+// Delete implements the same method as documented on Cache.
+func (c *fileCache) Delete(key Key) error {
+	if err := os.Remove(c.path(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("compilation cache: %w", err)
+	}
+	return nil
+}
+
+// path returns the path of the file holding the entry for the key.
+func (c *fileCache) path(key Key) string {
+	return filepath.Join(c.dir, hex.EncodeToString(key[:]))
+}
+
+// mac returns the HMAC of the entry for the key, which binds the content to the key, so that an entry can't be
+// copied to another one either.
+func (c *fileCache) mac(key Key) (hash.Hash, error) {
+	c.keyOnce.Do(func() {
+		var path string
+		if path, c.keyErr = c.keyPath(); c.keyErr == nil {
+			c.key, c.keyErr = loadOrCreateKey(path)
+		}
+	})
+	if c.keyErr != nil {
+		return nil, c.keyErr
+	}
+	h := hmac.New(sha256.New, c.key)
+	h.Write(key[:])
+	return h, nil
+}
+
+// loadOrCreateKey returns the HMAC key in the file at the path, or creates it with a random key, readable only by the
+// current user.
+func loadOrCreateKey(path string) ([]byte, error) {
+	if key, err := readKey(path); !errors.Is(err, os.ErrNotExist) {
+		return key, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	// Write a temporary file and link it, which fails if another process created the key in between, in which case
+	// that key is used instead.
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp)
+	if _, err = f.Write(key); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if err = f.Close(); err != nil {
+		return nil, err
+	}
+	if err = os.Link(tmp, path); errors.Is(err, os.ErrExist) {
+		return readKey(path)
+	} else if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// readKey reads the HMAC key from the file at the path, which must be only readable by the current user.
+func readKey(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if info, err := f.Stat(); err != nil {
+		return nil, err
+	} else if err = checkPrivate(path, info, 0o077); err != nil {
+		return nil, err
+	}
+	key, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	} else if len(key) != keySize {
+		return nil, fmt.Errorf("%s has %d bytes, but expected %d", path, len(key), keySize)
+	}
+	return key, nil
+}
+
+// checkPrivateDir returns an error unless the directory is owned by the current user and not writable by others.
+func checkPrivateDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	return checkPrivate(dir, info, 0o022)
+}
@@ -0,0 +1,176 @@
+package compilationcache
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+// newTestFileCache returns a fileCache whose HMAC key is in a new temporary directory.
+func newTestFileCache(t *testing.T, dir string) *fileCache {
+	keyPath := filepath.Join(t.TempDir(), "wazero", "compilationcache.key")
+	return &fileCache{dir: dir, keyPath: func() (string, error) { return keyPath, nil }}
+}
+
+func TestFileCache(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache") // doesn't exist, yet
+	c := newTestFileCache(t, dir)
+	key := Key{1, 2, 3}
+
+	t.Run("miss", func(t *testing.T) {
+		content, ok, err := c.Get(key)
+		require.NoError(t, err)
+		require.False(t, ok)
+		require.Nil(t, content)
+	})
+
+	t.Run("add creates dir", func(t *testing.T) {
+		require.NoError(t, c.Add(key, []byte("compiled")))
+
+		content, ok, err := c.Get(key)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, []byte("compiled"), content)
+	})
+
+	t.Run("add replaces", func(t *testing.T) {
+		require.NoError(t, c.Add(key, []byte("recompiled")))
+
+		content, ok, err := c.Get(key)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, []byte("recompiled"), content)
+
+		// No temporary files are left behind.
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(entries))
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, c.Delete(key))
+
+		_, ok, err := c.Get(key)
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		// Deleting a missing entry is not an error.
+		require.NoError(t, c.Delete(key))
+	})
+
+	t.Run("key is private", func(t *testing.T) {
+		path, err := c.keyPath()
+		require.NoError(t, err)
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		require.Equal(t, int64(keySize), info.Size())
+		if runtime.GOOS != "windows" {
+			require.Equal(t, fs.FileMode(0o600), info.Mode().Perm())
+		}
+
+		// Another cache with the same key file can read entries.
+		require.NoError(t, c.Add(key, []byte("compiled")))
+		content, ok, err := (&fileCache{dir: dir, keyPath: c.keyPath}).Get(key)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, []byte("compiled"), content)
+	})
+}
+
+func TestFileCache_Tampered(t *testing.T) {
+	key, other := Key{1}, Key{2}
+
+	tests := []struct {
+		name   string
+		tamper func(t *testing.T, c *fileCache)
+	}{
+		{
+			name: "modified content",
+			tamper: func(t *testing.T, c *fileCache) {
+				entry, err := os.ReadFile(c.path(key))
+				require.NoError(t, err)
+				entry[0] ^= 0xff
+				require.NoError(t, os.WriteFile(c.path(key), entry, 0o600))
+			},
+		},
+		{
+			name: "other key",
+			tamper: func(t *testing.T, c *fileCache) {
+				require.NoError(t, c.Add(other, []byte("other")))
+				require.NoError(t, os.Rename(c.path(other), c.path(key)))
+			},
+		},
+		{
+			name: "other HMAC key",
+			tamper: func(t *testing.T, c *fileCache) {
+				require.NoError(t, newTestFileCache(t, c.dir).Add(key, []byte("attacker")))
+			},
+		},
+		{
+			name: "truncated",
+			tamper: func(t *testing.T, c *fileCache) {
+				require.NoError(t, os.WriteFile(c.path(key), []byte("short"), 0o600))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestFileCache(t, t.TempDir())
+			require.NoError(t, c.Add(key, []byte("compiled")))
+			tc.tamper(t, c)
+
+			content, ok, err := c.Get(key)
+			require.NoError(t, err)
+			require.False(t, ok)
+			require.Nil(t, content)
+
+			// The entry is deleted, so it can be replaced.
+			_, err = os.Stat(c.path(key))
+			require.True(t, errors.Is(err, os.ErrNotExist))
+		})
+	}
+}
+
+func TestFileCache_Insecure(t *testing.T) {
+	if runtime.GOOS != "darwin" && runtime.GOOS != "linux" && runtime.GOOS != "freebsd" {
+		t.Skip("permission bits aren't checked on " + runtime.GOOS)
+	}
+	key := Key{1}
+
+	t.Run("dir writable by others", func(t *testing.T) {
+		c := newTestFileCache(t, t.TempDir())
+		require.NoError(t, os.Chmod(c.dir, 0o777))
+
+		err := c.Add(key, []byte("compiled"))
+		require.Contains(t, err.Error(), "insecure permissions 0777")
+		_, _, err = c.Get(key)
+		require.Contains(t, err.Error(), "insecure permissions 0777")
+	})
+
+	t.Run("entry writable by group", func(t *testing.T) {
+		c := newTestFileCache(t, t.TempDir())
+		require.NoError(t, c.Add(key, []byte("compiled")))
+		require.NoError(t, os.Chmod(c.path(key), 0o620))
+
+		_, _, err := c.Get(key)
+		require.Contains(t, err.Error(), "insecure permissions 0620")
+	})
+
+	t.Run("key readable by others", func(t *testing.T) {
+		c := newTestFileCache(t, t.TempDir())
+		require.NoError(t, c.Add(key, []byte("compiled")))
+		path, err := c.keyPath()
+		require.NoError(t, err)
+		require.NoError(t, os.Chmod(path, 0o644))
+
+		_, _, err = (&fileCache{dir: c.dir, keyPath: c.keyPath}).Get(key)
+		require.Contains(t, err.Error(), "insecure permissions 0644")
+	})
+}
@@ -0,0 +1,22 @@
+//go:build darwin || linux || freebsd
+
+package compilationcache
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// checkPrivate returns an error unless the file is owned by the current user, and has none of the permission bits in
+// the mask, such as 0o022 for writable by the group or others.
+func checkPrivate(path string, info fs.FileInfo, mask fs.FileMode) error {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok && int(st.Uid) != os.Getuid() {
+		return fmt.Errorf("%s is owned by uid %d, not by the current user", path, st.Uid)
+	}
+	if perm := info.Mode().Perm(); perm&mask != 0 {
+		return fmt.Errorf("%s has insecure permissions %#o", path, perm)
+	}
+	return nil
+}
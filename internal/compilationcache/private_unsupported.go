@@ -0,0 +1,10 @@
+//go:build !(darwin || linux || freebsd)
+
+package compilationcache
+
+import "io/fs"
+
+// checkPrivate returns nil as file ownership and permission bits aren't available on this platform.
+func checkPrivate(string, fs.FileInfo, fs.FileMode) error {
+	return nil
+}
@@ -4,6 +4,12 @@ package compiler
 // This must be initialized in init() function in architecture-specific arch_*.go file which is guarded by build tag.
 var newArchContext func() archContext
 
+// staticAddresses are the addresses of Go variables which native code can embed. These must be initialized in init()
+// function in architecture-specific impl_*.go file if the architecture uses any.
+//
+// Note: As these vary with the binary, they are part of the version of a compilation cache entry.
+var staticAddresses []uintptr
+
 // nativecall is used by callEngine.execWasmFunction and the entrypoint to enter the compiled native code.
 // codeSegment is the pointer to the initial instruction of the compiled native code.
 // ce is "*callEngine" as uintptr.
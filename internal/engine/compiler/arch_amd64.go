@@ -23,6 +23,7 @@ func init() {
 
 // newCompiler returns a new compiler interface which can be used to compile the given function instance.
 // Note: ir param can be nil for host functions.
-func newCompiler(ir *wazeroir.CompilationResult) (compiler, error) {
-	return newAmd64Compiler(ir)
+// withListener is true when the function must call builtin functions to notify its experimental.FunctionListener.
+func newCompiler(ir *wazeroir.CompilationResult, withListener bool) (compiler, error) {
+	return newAmd64Compiler(ir, withListener)
 }
@@ -15,6 +15,6 @@ const isSupported = false
 type archContext struct{}
 
 // newCompiler returns an unsupported error.
-func newCompiler(ir *wazeroir.CompilationResult) (compiler, error) {
+func newCompiler(ir *wazeroir.CompilationResult, withListener bool) (compiler, error) {
 	return nil, fmt.Errorf("unsupported GOARCH %s", runtime.GOARCH)
 }
@@ -16,6 +16,13 @@ type compiler interface {
 	// stackPointerCeil is the max stack pointer that the target function would reach.
 	// staticData is codeStaticData for the resulting native code.
 	compile() (code []byte, staticData codeStaticData, stackPointerCeil uint64, err error)
+	// compileInstructionOffset notifies compilers that the following operations are compiled from the Wasm
+	// instruction at the offset in the function body. Traps report this offset, and instructionOffsets maps the
+	// native code of the following operations to it.
+	// See wazeroir.CompilationResult InstructionOffsets
+	compileInstructionOffset(offset uint64)
+	// instructionOffsets returns the offsets notified with compileInstructionOffset. This is complete after compile.
+	instructionOffsets() *instructionOffsetMap
 	// compileHostFunction emits the trampoline code from which native code can jump into the host function.
 	// TODO: maybe we wouldn't need to have trampoline for host functions.
 	compileHostFunction() error
@@ -23,6 +30,18 @@ type compiler interface {
 	// Return true if the compiler decided to skip the entire label.
 	// See wazeroir.OperationLabel
 	compileLabel(o *wazeroir.OperationLabel) (skipThisLabel bool)
+	// compileCheckContextDone adds instructions to decrement exitContext.contextCheckCountdown, and to call the
+	// builtin function builtinFunctionIndexCheckContextDone when it reaches zero.
+	// This is only called on function entries and loop headers when engine.ensureTermination is true.
+	compileCheckContextDone() error
+	// compileConsumeFuel adds instructions to subtract the cost of the basic block from exitContext.fuelRemaining, and
+	// to return to engine with nativeCallStatusCodeOutOfFuel status if it gets negative.
+	// See wazeroir.OperationConsumeFuel
+	compileConsumeFuel(o *wazeroir.OperationConsumeFuel) error
+	// compileCoverBlock adds instructions to increment the counter of the basic block at the given address, which must
+	// outlive the compiled code.
+	// See wazeroir.OperationCoverBlock
+	compileCoverBlock(counter *uint64) error
 	// compileUnreachable adds instructions to return to engine with nativeCallStatusCodeUnreachable status.
 	// See wasm.OpcodeUnreachable
 	compileUnreachable() error
@@ -71,6 +90,17 @@ type compiler interface {
 	//
 	// See wasm.CallIndirect
 	compileCallIndirect(o *wazeroir.OperationCallIndirect) error
+	// compileTailCall adds instructions to replace the call frame of the current function with a call into the
+	// function of the given index, whose parameters must be the only values on the stack.
+	//
+	// Note: This falls back to a call followed by a return when the current function has a listener, which must be
+	// notified of its return.
+	// See wasm.OpcodeReturnCall
+	compileTailCall(o *wazeroir.OperationTailCall) error
+	// compileTailCallIndirect is like compileTailCall for the function at the offset in the table, checked like
+	// compileCallIndirect.
+	// See wasm.OpcodeReturnCallIndirect
+	compileTailCallIndirect(o *wazeroir.OperationTailCallIndirect) error
 	// compileDrop adds instructions to drop values within the given inclusive range from the value stack.
 	// See wazeroir.OperationDrop
 	compileDrop(o *wazeroir.OperationDrop) error
@@ -308,7 +338,7 @@ type compiler interface {
 	compileMemoryGrow() error
 	// compileMemorySize adds instruction to read the current page size of memory instance and push it onto the stack.
 	// See wasm.OpcodeMemorySize
-	compileMemorySize() error
+	compileMemorySize(o *wazeroir.OperationMemorySize) error
 	// compileConstI32 adds instruction to push the given constant i32 value onto the stack.
 	// See wasm.OpcodeI32Const
 	compileConstI32(o *wazeroir.OperationConstI32) error
@@ -486,4 +516,80 @@ type compiler interface {
 	//	wasm.OpcodeVecF32x4LeName, wasm.OpcodeVecF32x4GeName, wasm.OpcodeVecF64x2EqName, wasm.OpcodeVecF64x2NeName, wasm.OpcodeVecF64x2LtName,
 	//	wasm.OpcodeVecF64x2GtName, wasm.OpcodeVecF64x2LeName, wasm.OpcodeVecF64x2GeName
 	compileV128Cmp(*wazeroir.OperationV128Cmp) error
+	// compileV128RelaxedTrunc adds instructions which are equivalent to the relaxed truncations of the relaxed SIMD
+	// proposal, with the result fixed by wazeroir.OperationV128RelaxedTrunc.
+	// See wasm.OpcodeVecI32x4RelaxedTruncF32x4SName wasm.OpcodeVecI32x4RelaxedTruncF32x4UName
+	//	wasm.OpcodeVecI32x4RelaxedTruncF64x2SZeroName wasm.OpcodeVecI32x4RelaxedTruncF64x2UZeroName
+	compileV128RelaxedTrunc(*wazeroir.OperationV128RelaxedTrunc) error
+	// compileV128RelaxedMadd adds instructions which are equivalent to the relaxed multiply-adds of the relaxed SIMD
+	// proposal, with the result fixed by wazeroir.OperationV128RelaxedMadd.
+	// See wasm.OpcodeVecF32x4RelaxedMaddName wasm.OpcodeVecF32x4RelaxedNmaddName wasm.OpcodeVecF64x2RelaxedMaddName
+	//	wasm.OpcodeVecF64x2RelaxedNmaddName
+	compileV128RelaxedMadd(*wazeroir.OperationV128RelaxedMadd) error
+	// compileV128RelaxedMin adds instructions which are equivalent to wasm.OpcodeVecF32x4RelaxedMinName and
+	// wasm.OpcodeVecF64x2RelaxedMinName, with the result fixed by wazeroir.OperationV128RelaxedMin.
+	compileV128RelaxedMin(*wazeroir.OperationV128RelaxedMin) error
+	// compileV128RelaxedMax adds instructions which are equivalent to wasm.OpcodeVecF32x4RelaxedMaxName and
+	// wasm.OpcodeVecF64x2RelaxedMaxName, with the result fixed by wazeroir.OperationV128RelaxedMax.
+	compileV128RelaxedMax(*wazeroir.OperationV128RelaxedMax) error
+	// compileV128RelaxedQ15mulr adds instructions which are equivalent to wasm.OpcodeVecI16x8RelaxedQ15mulrSName,
+	// with the result fixed by wazeroir.OperationV128RelaxedQ15mulr.
+	compileV128RelaxedQ15mulr(*wazeroir.OperationV128RelaxedQ15mulr) error
+	// compileV128RelaxedDot adds instructions which are equivalent to wasm.OpcodeVecI16x8RelaxedDotI8x16I7x16SName,
+	// with the result fixed by wazeroir.OperationV128RelaxedDot.
+	compileV128RelaxedDot(*wazeroir.OperationV128RelaxedDot) error
+	// compileV128RelaxedDotAdd adds instructions which are equivalent to
+	// wasm.OpcodeVecI32x4RelaxedDotI8x16I7x16AddSName, with the result fixed by wazeroir.OperationV128RelaxedDotAdd.
+	compileV128RelaxedDotAdd(*wazeroir.OperationV128RelaxedDotAdd) error
+	// compileAtomicLoad adds instructions which are equivalent to the atomic loads of wasm.FeatureThreads, such as
+	// wasm.OpcodeAtomicI32Load8UName.
+	compileAtomicLoad(o *wazeroir.OperationAtomicLoad) error
+	// compileAtomicStore adds instructions which are equivalent to the atomic stores of wasm.FeatureThreads, such as
+	// wasm.OpcodeAtomicI64Store32Name.
+	compileAtomicStore(o *wazeroir.OperationAtomicStore) error
+	// compileAtomicRMW adds instructions which are equivalent to the atomic read-modify-write instructions of
+	// wasm.FeatureThreads, such as wasm.OpcodeAtomicI32RmwAddName.
+	compileAtomicRMW(o *wazeroir.OperationAtomicRMW) error
+	// compileAtomicCmpxchg adds instructions which are equivalent to the atomic compare and exchange instructions of
+	// wasm.FeatureThreads, such as wasm.OpcodeAtomicI64RmwCmpxchgName.
+	compileAtomicCmpxchg(o *wazeroir.OperationAtomicCmpxchg) error
+	// compileAtomicFence adds instructions which are equivalent to wasm.OpcodeAtomicFenceName.
+	compileAtomicFence() error
+	// compileCallAtomicBuiltinFunction adds instructions to call builtinFunctionIndexAtomic, which executes the atomic
+	// instruction described by the descriptor (see atomicDescriptor) with the given number of operands on the top of
+	// the stack, and pushes its result if hasResult is true. This is used for wazeroir.OperationMemoryWait and
+	// wazeroir.OperationMemoryNotify, which are executed in Go as they may block or wake up waiting calls.
+	compileCallAtomicBuiltinFunction(descriptor uint64, operands int, hasResult bool) error
+	// compileCallMemoryBuiltinFunction adds instructions to call builtinFunctionIndexMemory, which executes the memory
+	// instruction described by the descriptor (see memoryDescriptor) on the memories of the memoryIndexes, with the
+	// static offset and the given number of operands on the top of the stack, and pushes its results of the given
+	// types. See memoryBuiltinInstruction for which instructions are executed in Go.
+	compileCallMemoryBuiltinFunction(descriptor, memoryIndexes, offset uint64, operands int, results ...runtimeValueType) error
+	// compileTry notifies compilers of the beginning of the body of a try block. Values below the try block are
+	// released to the stack memory, where the catch clauses expect them.
+	// See wazeroir.OperationTry
+	compileTry(o *wazeroir.OperationTry) error
+	// compileTryEnd notifies compilers of the end of the body of a try block. This is called even when the end is
+	// unreachable, so that the body is complete.
+	// See wazeroir.OperationTryEnd
+	compileTryEnd(o *wazeroir.OperationTryEnd)
+	// compileCatch notifies compilers of the beginning of a catch clause of the given try block, which catches
+	// exceptions of the tag, or of any tag if all is true. Return true if the compiler decided to skip the entire
+	// catch clause, as the body of its try block was never reached.
+	//
+	// The catch clause is entered by callEngine.throw, with the handle and values of the exception on the stack above
+	// the values below the try block.
+	// See wazeroir.OperationCatch wazeroir.OperationCatchAll
+	compileCatch(tryIndex uint32, tagIndex uint32, all bool) (skipThisCatch bool, err error)
+	// compileThrow adds instructions to call builtinFunctionIndexThrow, which throws an exception of the tag with the
+	// values on the top of the stack.
+	// See wazeroir.OperationThrow
+	compileThrow(o *wazeroir.OperationThrow) error
+	// compileRethrow adds instructions to call builtinFunctionIndexRethrow, which throws again the exception of the
+	// handle on the top of the stack.
+	// See wazeroir.OperationRethrow
+	compileRethrow() error
+	// tryBlocks returns the try blocks notified with compileTry, indexed like wazeroir.CompilationResult TryBlocks.
+	// This is complete after compile.
+	tryBlocks() []*tryBlock
 }
@@ -57,7 +57,7 @@ func TestCompiler_compileMemorySize(t *testing.T) {
 	require.NoError(t, err)
 
 	// Emit memory.size instructions.
-	err = compiler.compileMemorySize()
+	err = compiler.compileMemorySize(&wazeroir.OperationMemorySize{})
 	require.NoError(t, err)
 	// At this point, the size of memory should be pushed onto the stack.
 	require.Equal(t, uint64(1), compiler.runtimeValueLocationStack().sp)
@@ -79,7 +79,7 @@ func TestCompiler_compileLoad(t *testing.T) {
 	loadTargetValue := uint64(0x12_34_56_78_9a_bc_ef_fe)
 	baseOffset := uint32(100)
 	arg := &wazeroir.MemoryArg{Offset: 361}
-	offset := baseOffset + arg.Offset
+	offset := baseOffset + uint32(arg.Offset)
 
 	tests := []struct {
 		name                string
@@ -278,7 +278,7 @@ func TestCompiler_compileStore(t *testing.T) {
 	storeTargetValue := uint64(math.MaxUint64)
 	baseOffset := uint32(100)
 	arg := &wazeroir.MemoryArg{Offset: 361}
-	offset := arg.Offset + baseOffset
+	offset := uint32(arg.Offset) + baseOffset
 
 	tests := []struct {
 		name                string
@@ -444,7 +444,7 @@ func TestCompiler_MemoryOutOfBounds(t *testing.T) {
 					err = compiler.compileConstI32(&wazeroir.OperationConstI32{Value: base})
 					require.NoError(t, err)
 
-					arg := &wazeroir.MemoryArg{Offset: offset}
+					arg := &wazeroir.MemoryArg{Offset: uint64(offset)}
 
 					switch targetSizeInByte {
 					case 1:
@@ -479,3 +479,332 @@ func TestCompiler_MemoryOutOfBounds(t *testing.T) {
 		}
 	}
 }
+
+// TestCompiler_MemoryIndex ensures instructions on memories other than the memory zero access the memory of their
+// index, and are bounded by its size, which isn't the length of the buffer of shared memories.
+func TestCompiler_MemoryIndex(t *testing.T) {
+	const value, base = uint64(0x01_02_03_04_05_06_07_08), uint32(16)
+	memories := []*wasm.Memory{{Min: 1}, {Min: 2, Cap: 2}, {Min: 1, Cap: 2, Max: 2, Shared: true}}
+
+	type testCase struct {
+		name string
+		// compile compiles the instructions on the memory at the index, which leave a value on the stack.
+		compile func(compiler compilerImpl, index wasm.Index) error
+		// exp returns the value on the stack given the memory, and expMemory is the value at base+8 of the memory
+		// after the instructions.
+		exp       func(m *wasm.Memory) uint64
+		expMemory uint64
+	}
+	tests := []testCase{
+		{
+			name: "memory.size",
+			compile: func(compiler compilerImpl, index wasm.Index) error {
+				return compiler.compileMemorySize(&wazeroir.OperationMemorySize{MemoryIndex: index})
+			},
+			exp: func(m *wasm.Memory) uint64 { return uint64(m.Min) },
+		},
+		{
+			name: "store load",
+			compile: func(compiler compilerImpl, index wasm.Index) error {
+				arg := &wazeroir.MemoryArg{MemoryIndex: index, Offset: 8}
+				if err := compiler.compileConstI32(&wazeroir.OperationConstI32{Value: base}); err != nil {
+					return err
+				}
+				if err := compiler.compileConstI64(&wazeroir.OperationConstI64{Value: value}); err != nil {
+					return err
+				}
+				if err := compiler.compileStore(&wazeroir.OperationStore{Type: wazeroir.UnsignedTypeI64, Arg: arg}); err != nil {
+					return err
+				}
+				if err := compiler.compileConstI32(&wazeroir.OperationConstI32{Value: base}); err != nil {
+					return err
+				}
+				return compiler.compileLoad32(&wazeroir.OperationLoad32{Arg: arg})
+			},
+			exp:       func(*wasm.Memory) uint64 { return value & math.MaxUint32 },
+			expMemory: value,
+		},
+		{
+			name: "atomic rmw",
+			compile: func(compiler compilerImpl, index wasm.Index) error {
+				arg := &wazeroir.MemoryArg{MemoryIndex: index, Offset: 8}
+				if err := compiler.compileConstI32(&wazeroir.OperationConstI32{Value: base}); err != nil {
+					return err
+				}
+				if err := compiler.compileConstI64(&wazeroir.OperationConstI64{Value: value}); err != nil {
+					return err
+				}
+				return compiler.compileAtomicRMW(&wazeroir.OperationAtomicRMW{
+					Type: wazeroir.UnsignedInt64, Size: 8, Op: wazeroir.AtomicRMWOpXchg, Arg: arg})
+			},
+			exp:       func(*wasm.Memory) uint64 { return 0 },
+			expMemory: value,
+		},
+	}
+
+	newEnv := func(t *testing.T) (*compilerEnv, []*wasm.MemoryInstance, compilerImpl) {
+		env := newCompilerEnvironment()
+		mems := []*wasm.MemoryInstance{env.module().Memory}
+		for _, m := range memories[1:] {
+			mems = append(mems, wasm.NewMemoryInstance(m))
+		}
+		env.module().Memories = mems
+		compiler := env.requireNewCompiler(t, newCompiler, &wazeroir.CompilationResult{
+			HasMemory: true, Memories: memories, Signature: &wasm.FunctionType{}})
+		return env, mems, compiler
+	}
+
+	for _, index := range []wasm.Index{1, 2} {
+		index := index
+		for _, tc := range tests {
+			tc := tc
+			t.Run(fmt.Sprintf("%s memory %d", tc.name, index), func(t *testing.T) {
+				env, mems, compiler := newEnv(t)
+				require.NoError(t, compiler.compilePreamble())
+				require.NoError(t, tc.compile(compiler, index))
+				require.NoError(t, compiler.compileReturnFunction())
+
+				code, _, _, err := compiler.compile()
+				require.NoError(t, err)
+				env.exec(code)
+
+				require.Equal(t, nativeCallStatusCodeReturned, env.compilerStatus())
+				require.Equal(t, tc.exp(memories[index]), env.stackTopAsUint64())
+				actual, ok := mems[index].ReadUint64Le(testCtx, base+8)
+				require.True(t, ok)
+				require.Equal(t, tc.expMemory, actual)
+
+				// The memory zero is untouched.
+				actual, ok = mems[0].ReadUint64Le(testCtx, base+8)
+				require.True(t, ok)
+				require.Zero(t, actual)
+			})
+		}
+
+		t.Run(fmt.Sprintf("out of bounds memory %d", index), func(t *testing.T) {
+			env, mems, compiler := newEnv(t)
+			require.NoError(t, compiler.compilePreamble())
+
+			// The last 8 bytes are within the memory, but not the 8 bytes after them, even in the buffer of the
+			// shared memory, which is as long as its capacity.
+			size := uint32(mems[index].Size(testCtx))
+			arg := &wazeroir.MemoryArg{MemoryIndex: index, Offset: 4}
+			require.NoError(t, compiler.compileConstI32(&wazeroir.OperationConstI32{Value: size - 8}))
+			require.NoError(t, compiler.compileLoad(&wazeroir.OperationLoad{Type: wazeroir.UnsignedTypeI64, Arg: arg}))
+			require.NoError(t, compiler.compileReturnFunction())
+
+			code, _, _, err := compiler.compile()
+			require.NoError(t, err)
+			env.exec(code)
+
+			require.Equal(t, nativeCallStatusCodeMemoryOutOfBounds, env.compilerStatus())
+		})
+	}
+}
+
+func TestCompiler_Memory64(t *testing.T) {
+	const value = uint64(0x01_02_03_04_05_06_07_08)
+	memories := []*wasm.Memory{{Min: 1, Is64: true}}
+
+	tests := []struct {
+		name            string
+		address, offset uint64
+		expStatus       nativeCallStatusCode
+	}{
+		{name: "in bounds", address: 16, offset: 8, expStatus: nativeCallStatusCodeReturned},
+		{name: "last bytes", address: uint64(wasm.MemoryPageSize) - 16, offset: 8, expStatus: nativeCallStatusCodeReturned},
+		{name: "address over 32 bits", address: 1 << 32, expStatus: nativeCallStatusCodeMemoryOutOfBounds},
+		{name: "offset over 32 bits", offset: 1 << 32, expStatus: nativeCallStatusCodeMemoryOutOfBounds},
+		{name: "offset over max int32", offset: math.MaxInt32 + 1, expStatus: nativeCallStatusCodeMemoryOutOfBounds},
+		{name: "address carry", address: math.MaxUint64 - 4, expStatus: nativeCallStatusCodeMemoryOutOfBounds},
+		{name: "offset carry", address: math.MaxUint64 - 1<<32 + 1, offset: 1 << 32, expStatus: nativeCallStatusCodeMemoryOutOfBounds},
+		{name: "offset overflow", offset: math.MaxUint64 - 4, expStatus: nativeCallStatusCodeMemoryOutOfBounds},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			env := newCompilerEnvironment()
+			compiler := env.requireNewCompiler(t, newCompiler, &wazeroir.CompilationResult{
+				HasMemory: true, Memories: memories, Signature: &wasm.FunctionType{}})
+			require.NoError(t, compiler.compilePreamble())
+
+			// Loads the i64 at the address plus the offset, which is the i64 address of the memory.
+			arg := &wazeroir.MemoryArg{Offset: tc.offset}
+			require.NoError(t, compiler.compileConstI64(&wazeroir.OperationConstI64{Value: tc.address}))
+			require.NoError(t, compiler.compileLoad(&wazeroir.OperationLoad{Type: wazeroir.UnsignedTypeI64, Arg: arg}))
+			require.NoError(t, compiler.compileReturnFunction())
+
+			code, _, _, err := compiler.compile()
+			require.NoError(t, err)
+
+			if tc.expStatus == nativeCallStatusCodeReturned {
+				binary.LittleEndian.PutUint64(env.memory()[tc.address+tc.offset:], value)
+			}
+			env.exec(code)
+
+			require.Equal(t, tc.expStatus, env.compilerStatus())
+			if tc.expStatus == nativeCallStatusCodeReturned {
+				require.Equal(t, value, env.stackTopAsUint64())
+			}
+		})
+	}
+}
+
+func TestCompiler_compileAtomic(t *testing.T) {
+	// For testing. Arbitrary numbers are fine, but the high bits of each byte are set to verify the zero-extension.
+	const initialValue, operand = uint64(0x81_82_83_84_85_86_87_88), uint64(0xf0_e0_d0_c0_b0_a0_90_80)
+	baseOffset := uint32(96)
+	arg := &wazeroir.MemoryArg{Offset: 8}
+	offset := uint64(baseOffset) + arg.Offset
+
+	type testCase struct {
+		name string
+		// operands are pushed after the base offset.
+		operands []uint64
+		// hasResult is true if the instruction pushes the value read.
+		hasResult bool
+		compile   func(compiler compilerImpl, size uint32) error
+		// exec executes the instruction with the wasm.MemoryInstance methods used by the interpreter.
+		exec func(mem *wasm.MemoryInstance, size uint32) (uint64, error)
+	}
+	tests := []testCase{
+		{
+			name:      "load",
+			hasResult: true,
+			compile: func(compiler compilerImpl, size uint32) error {
+				return compiler.compileAtomicLoad(&wazeroir.OperationAtomicLoad{Type: wazeroir.UnsignedInt64, Size: size, Arg: arg})
+			},
+			exec: func(mem *wasm.MemoryInstance, size uint32) (uint64, error) {
+				return mem.AtomicLoad(offset, size)
+			},
+		},
+		{
+			name:     "store",
+			operands: []uint64{operand},
+			compile: func(compiler compilerImpl, size uint32) error {
+				return compiler.compileAtomicStore(&wazeroir.OperationAtomicStore{Type: wazeroir.UnsignedInt64, Size: size, Arg: arg})
+			},
+			exec: func(mem *wasm.MemoryInstance, size uint32) (uint64, error) {
+				return 0, mem.AtomicStore(offset, size, operand)
+			},
+		},
+		{
+			name:      "cmpxchg",
+			operands:  []uint64{initialValue, operand}, // wrapped to the size, so that it is replaced.
+			hasResult: true,
+			compile: func(compiler compilerImpl, size uint32) error {
+				return compiler.compileAtomicCmpxchg(&wazeroir.OperationAtomicCmpxchg{Type: wazeroir.UnsignedInt64, Size: size, Arg: arg})
+			},
+			exec: func(mem *wasm.MemoryInstance, size uint32) (uint64, error) {
+				return mem.AtomicCompareExchange(offset, size, initialValue, operand)
+			},
+		},
+		{
+			name:      "cmpxchg not equal",
+			operands:  []uint64{initialValue + 1, operand},
+			hasResult: true,
+			compile: func(compiler compilerImpl, size uint32) error {
+				return compiler.compileAtomicCmpxchg(&wazeroir.OperationAtomicCmpxchg{Type: wazeroir.UnsignedInt64, Size: size, Arg: arg})
+			},
+			exec: func(mem *wasm.MemoryInstance, size uint32) (uint64, error) {
+				return mem.AtomicCompareExchange(offset, size, initialValue+1, operand)
+			},
+		},
+	}
+	for _, op := range []wazeroir.AtomicRMWOp{
+		wazeroir.AtomicRMWOpAdd, wazeroir.AtomicRMWOpSub, wazeroir.AtomicRMWOpAnd,
+		wazeroir.AtomicRMWOpOr, wazeroir.AtomicRMWOpXor, wazeroir.AtomicRMWOpXchg,
+	} {
+		op := op
+		tests = append(tests, testCase{
+			name:      "rmw." + op.String(),
+			operands:  []uint64{operand},
+			hasResult: true,
+			compile: func(compiler compilerImpl, size uint32) error {
+				return compiler.compileAtomicRMW(&wazeroir.OperationAtomicRMW{Op: op, Type: wazeroir.UnsignedInt64, Size: size, Arg: arg})
+			},
+			exec: func(mem *wasm.MemoryInstance, size uint32) (uint64, error) {
+				return mem.AtomicRMW(offset, size, operand, op.Apply)
+			},
+		})
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		for _, size := range []uint32{1, 2, 4, 8} {
+			size := size
+			t.Run(fmt.Sprintf("%s/size=%d", tc.name, size), func(t *testing.T) {
+				env := newCompilerEnvironment()
+				compiler := env.requireNewCompiler(t, newCompiler, &wazeroir.CompilationResult{HasMemory: true, Signature: &wasm.FunctionType{}})
+
+				err := compiler.compilePreamble()
+				require.NoError(t, err)
+
+				err = compiler.compileConstI32(&wazeroir.OperationConstI32{Value: baseOffset})
+				require.NoError(t, err)
+				for _, v := range tc.operands {
+					err = compiler.compileConstI64(&wazeroir.OperationConstI64{Value: v})
+					require.NoError(t, err)
+				}
+
+				err = tc.compile(compiler, size)
+				require.NoError(t, err)
+
+				err = compiler.compileReturnFunction()
+				require.NoError(t, err)
+
+				// Generate the code under test.
+				code, _, _, err := compiler.compile()
+				require.NoError(t, err)
+
+				// Set the accessed value and its neighbors, and execute the same instruction on a copy of the memory.
+				mem := env.memory()
+				for i := offset - 8; i < offset+16; i += 8 {
+					binary.LittleEndian.PutUint64(mem[i:], initialValue)
+				}
+				expectedMem := &wasm.MemoryInstance{Buffer: append([]byte{}, mem...)}
+				expectedResult, err := tc.exec(expectedMem, size)
+				require.NoError(t, err)
+
+				// Run code.
+				env.exec(code)
+
+				require.Equal(t, nativeCallStatusCodeReturned, env.compilerStatus())
+				if tc.hasResult {
+					require.Equal(t, uint64(1), env.stackPointer())
+					require.Equal(t, expectedResult, env.stackTopAsUint64())
+				} else {
+					require.Equal(t, uint64(0), env.stackPointer())
+				}
+				require.Equal(t, expectedMem.Buffer[offset-8:offset+16], mem[offset-8:offset+16])
+			})
+		}
+	}
+
+	for _, size := range []uint32{2, 4, 8} {
+		size := size
+		t.Run(fmt.Sprintf("unaligned/size=%d", size), func(t *testing.T) {
+			env := newCompilerEnvironment()
+			compiler := env.requireNewCompiler(t, newCompiler, &wazeroir.CompilationResult{HasMemory: true, Signature: &wasm.FunctionType{}})
+
+			err := compiler.compilePreamble()
+			require.NoError(t, err)
+
+			err = compiler.compileConstI32(&wazeroir.OperationConstI32{Value: baseOffset + 1})
+			require.NoError(t, err)
+			err = compiler.compileAtomicLoad(&wazeroir.OperationAtomicLoad{Type: wazeroir.UnsignedInt64, Size: size, Arg: arg})
+			require.NoError(t, err)
+
+			err = compiler.compileReturnFunction()
+			require.NoError(t, err)
+
+			// Generate and run the code under test.
+			code, _, _, err := compiler.compile()
+			require.NoError(t, err)
+			env.exec(code)
+
+			require.Equal(t, nativeCallStatusCodeUnalignedAtomic, env.compilerStatus())
+		})
+	}
+}
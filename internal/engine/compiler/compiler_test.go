@@ -144,7 +144,7 @@ func (j *compilerEnv) exec(codeSegment []byte) {
 }
 
 // newTestCompiler allows us to test a different architecture than the current one.
-type newTestCompiler func(ir *wazeroir.CompilationResult) (compiler, error)
+type newTestCompiler func(ir *wazeroir.CompilationResult, withListener bool) (compiler, error)
 
 func (j *compilerEnv) requireNewCompiler(t *testing.T, fn newTestCompiler, ir *wazeroir.CompilationResult) compilerImpl {
 	requireSupportedOSArch(t)
@@ -155,7 +155,7 @@ func (j *compilerEnv) requireNewCompiler(t *testing.T, fn newTestCompiler, ir *w
 			Signature:    &wasm.FunctionType{},
 		}
 	}
-	c, err := fn(ir)
+	c, err := fn(ir, false)
 
 	require.NoError(t, err)
 
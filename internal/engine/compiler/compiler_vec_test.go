@@ -3274,3 +3274,180 @@ func TestCompiler_compileV128Cmp(t *testing.T) {
 		})
 	}
 }
+
+func TestCompiler_compileV128Relaxed(t *testing.T) {
+	if runtime.GOARCH != "amd64" {
+		// TODO: implement on arm64.
+		t.Skip()
+	}
+
+	nan32, nan64 := float32(math.NaN()), math.NaN()
+	dotX1 := [16]byte{1, 2, 0xff, 3, 0x7f, 0x7f, 0x80, 0x80}
+	dotX2 := [16]byte{3, 4, 5, 6, 0xff, 0xff, 0xff, 0xff}
+
+	tests := []struct {
+		name     string
+		op       wazeroir.Operation
+		operands [][16]byte
+		exp      [16]byte
+	}{
+		{
+			name:     "i32x4.relaxed_trunc_f32x4_s",
+			op:       &wazeroir.OperationV128RelaxedTrunc{OriginShape: wazeroir.ShapeF32x4, Signed: true},
+			operands: [][16]byte{f32x4(1.9, -1.9, nan32, 3e9)},
+			exp:      i32x4(1, 0xffffffff, 0x80000000, 0x80000000),
+		},
+		{
+			name:     "i32x4.relaxed_trunc_f32x4_u",
+			op:       &wazeroir.OperationV128RelaxedTrunc{OriginShape: wazeroir.ShapeF32x4},
+			operands: [][16]byte{f32x4(3e9, -1.5, nan32, 5e9)},
+			exp:      i32x4(3e9, 0, 0, 0xffffffff),
+		},
+		{
+			name:     "i32x4.relaxed_trunc_f32x4_u boundaries",
+			op:       &wazeroir.OperationV128RelaxedTrunc{OriginShape: wazeroir.ShapeF32x4},
+			operands: [][16]byte{f32x4(1.5, 2147483648, math.MaxFloat32, float32(math.Copysign(0, -1)))},
+			exp:      i32x4(1, 0x80000000, 0xffffffff, 0),
+		},
+		{
+			name:     "i32x4.relaxed_trunc_f64x2_s_zero",
+			op:       &wazeroir.OperationV128RelaxedTrunc{OriginShape: wazeroir.ShapeF64x2, Signed: true},
+			operands: [][16]byte{f64x2(-2.5, 3e9)},
+			exp:      i32x4(0xfffffffe, 0x80000000, 0, 0),
+		},
+		{
+			name:     "i32x4.relaxed_trunc_f64x2_u_zero",
+			op:       &wazeroir.OperationV128RelaxedTrunc{OriginShape: wazeroir.ShapeF64x2},
+			operands: [][16]byte{f64x2(4294967295.9, nan64)},
+			exp:      i32x4(0xffffffff, 0, 0, 0),
+		},
+		{
+			name:     "i32x4.relaxed_trunc_f64x2_u_zero large",
+			op:       &wazeroir.OperationV128RelaxedTrunc{OriginShape: wazeroir.ShapeF64x2},
+			operands: [][16]byte{f64x2(1e20, 12345.6)},
+			exp:      i32x4(0xffffffff, 12345, 0, 0),
+		},
+		{
+			name:     "f32x4.relaxed_madd",
+			op:       &wazeroir.OperationV128RelaxedMadd{Shape: wazeroir.ShapeF32x4},
+			operands: [][16]byte{f32x4(1, 2, 3, 1+1.0/(1<<12)), f32x4(2, 2, 2, 1+1.0/(1<<12)), f32x4(0.5, 0.5, 0.5, -(1 + 1.0/(1<<11)))},
+			// The last lane is 2^-24 if fused.
+			exp: f32x4(2.5, 4.5, 6.5, 0),
+		},
+		{
+			name:     "f32x4.relaxed_nmadd",
+			op:       &wazeroir.OperationV128RelaxedMadd{Shape: wazeroir.ShapeF32x4, Negate: true},
+			operands: [][16]byte{f32x4(1, 2, 3, 4), f32x4(2, 2, 2, 2), f32x4(0.5, 0.5, 0.5, 0.5)},
+			exp:      f32x4(-1.5, -3.5, -5.5, -7.5),
+		},
+		{
+			name:     "f64x2.relaxed_madd",
+			op:       &wazeroir.OperationV128RelaxedMadd{Shape: wazeroir.ShapeF64x2},
+			operands: [][16]byte{f64x2(1.5, -2), f64x2(2, 3), f64x2(1, 1)},
+			exp:      f64x2(4, -5),
+		},
+		{
+			name:     "f64x2.relaxed_nmadd",
+			op:       &wazeroir.OperationV128RelaxedMadd{Shape: wazeroir.ShapeF64x2, Negate: true},
+			operands: [][16]byte{f64x2(1.5, -2), f64x2(2, 3), f64x2(1, 1)},
+			exp:      f64x2(-2, 7),
+		},
+		{
+			name:     "f32x4.relaxed_min",
+			op:       &wazeroir.OperationV128RelaxedMin{Shape: wazeroir.ShapeF32x4},
+			operands: [][16]byte{f32x4(1, nan32, 0, 1), f32x4(2, 1, float32(math.Copysign(0, -1)), nan32)},
+			exp:      f32x4(1, 1, float32(math.Copysign(0, -1)), nan32),
+		},
+		{
+			name:     "f32x4.relaxed_max",
+			op:       &wazeroir.OperationV128RelaxedMax{Shape: wazeroir.ShapeF32x4},
+			operands: [][16]byte{f32x4(1, nan32, float32(math.Copysign(0, -1)), 1), f32x4(2, 1, 0, nan32)},
+			exp:      f32x4(2, 1, 0, nan32),
+		},
+		{
+			name:     "f64x2.relaxed_min",
+			op:       &wazeroir.OperationV128RelaxedMin{Shape: wazeroir.ShapeF64x2},
+			operands: [][16]byte{f64x2(nan64, 3), f64x2(5, nan64)},
+			exp:      f64x2(5, nan64),
+		},
+		{
+			name:     "f64x2.relaxed_max",
+			op:       &wazeroir.OperationV128RelaxedMax{Shape: wazeroir.ShapeF64x2},
+			operands: [][16]byte{f64x2(-1, 0), f64x2(-2, math.Copysign(0, -1))},
+			exp:      f64x2(-1, math.Copysign(0, -1)),
+		},
+		{
+			name:     "i16x8.relaxed_q15mulr_s",
+			op:       &wazeroir.OperationV128RelaxedQ15mulr{},
+			operands: [][16]byte{i16x8(0x8000, 0x4000, 0x7fff, 0xffff, 100, 0, 0, 0), i16x8(0x8000, 0x4000, 0x7fff, 1, 200, 0, 0, 0)},
+			exp:      i16x8(0x8000, 0x2000, 0x7ffe, 0, 1, 0, 0, 0),
+		},
+		{
+			name:     "i16x8.relaxed_dot_i8x16_i7x16_s",
+			op:       &wazeroir.OperationV128RelaxedDot{},
+			operands: [][16]byte{dotX1, dotX2},
+			exp:      i16x8(11, 13, 0x7fff, 0x8000, 0, 0, 0, 0),
+		},
+		{
+			name:     "i32x4.relaxed_dot_i8x16_i7x16_add_s",
+			op:       &wazeroir.OperationV128RelaxedDotAdd{},
+			operands: [][16]byte{dotX1, dotX2, i32x4(1, 2, 3, 4)},
+			exp:      i32x4(25, 1, 3, 4),
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			env := newCompilerEnvironment()
+			compiler := env.requireNewCompiler(t, newCompiler,
+				&wazeroir.CompilationResult{HasMemory: true, Signature: &wasm.FunctionType{}})
+
+			err := compiler.compilePreamble()
+			require.NoError(t, err)
+
+			for _, operand := range tc.operands {
+				err = compiler.compileV128Const(&wazeroir.OperationV128Const{
+					Lo: binary.LittleEndian.Uint64(operand[:8]),
+					Hi: binary.LittleEndian.Uint64(operand[8:]),
+				})
+				require.NoError(t, err)
+			}
+
+			switch o := tc.op.(type) {
+			case *wazeroir.OperationV128RelaxedTrunc:
+				err = compiler.compileV128RelaxedTrunc(o)
+			case *wazeroir.OperationV128RelaxedMadd:
+				err = compiler.compileV128RelaxedMadd(o)
+			case *wazeroir.OperationV128RelaxedMin:
+				err = compiler.compileV128RelaxedMin(o)
+			case *wazeroir.OperationV128RelaxedMax:
+				err = compiler.compileV128RelaxedMax(o)
+			case *wazeroir.OperationV128RelaxedQ15mulr:
+				err = compiler.compileV128RelaxedQ15mulr(o)
+			case *wazeroir.OperationV128RelaxedDot:
+				err = compiler.compileV128RelaxedDot(o)
+			case *wazeroir.OperationV128RelaxedDotAdd:
+				err = compiler.compileV128RelaxedDotAdd(o)
+			}
+			require.NoError(t, err)
+
+			require.Equal(t, uint64(2), compiler.runtimeValueLocationStack().sp)
+			require.Equal(t, 1, len(compiler.runtimeValueLocationStack().usedRegisters))
+
+			err = compiler.compileReturnFunction()
+			require.NoError(t, err)
+
+			// Generate and run the code under test.
+			code, _, _, err := compiler.compile()
+			require.NoError(t, err)
+			env.exec(code)
+
+			lo, hi := env.stackTopAsV128()
+			var actual [16]byte
+			binary.LittleEndian.PutUint64(actual[:8], lo)
+			binary.LittleEndian.PutUint64(actual[8:], hi)
+			require.Equal(t, tc.exp, actual)
+		})
+	}
+}
@@ -4,25 +4,41 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"reflect"
 	"runtime"
+	"sort"
 	"sync"
 	"unsafe"
 
 	"github.com/tetratelabs/wazero/internal/buildoptions"
+	"github.com/tetratelabs/wazero/internal/compilationcache"
 	"github.com/tetratelabs/wazero/internal/platform"
+	internalsys "github.com/tetratelabs/wazero/internal/sys"
 	"github.com/tetratelabs/wazero/internal/wasm"
 	"github.com/tetratelabs/wazero/internal/wasmdebug"
 	"github.com/tetratelabs/wazero/internal/wasmruntime"
 	"github.com/tetratelabs/wazero/internal/wazeroir"
+	"github.com/tetratelabs/wazero/sys"
 )
 
 type (
 	// engine is a Compiler implementation of wasm.Engine
 	engine struct {
 		enabledFeatures wasm.Features
-		codes           map[wasm.ModuleID][]*code // guarded by mutex.
-		mux             sync.RWMutex
+		// ensureTermination is true when function entries and loop headers check if the context.Context is done.
+		ensureTermination bool
+		// meterFuel is true when each basic block consumes the fuel of the context.Context.
+		meterFuel bool
+		// coverage is true when each basic block counts how many times it ran.
+		coverage bool
+		codes    map[wasm.ModuleID][]*code // guarded by mutex.
+		// listenerCodes are compiled on the first instantiation with an experimental.FunctionListener, so that codes
+		// don't have the overhead of notifying listeners. guarded by mutex.
+		listenerCodes map[wasm.ModuleID][]*code
+		mux           sync.RWMutex
+		// cache persists codes across processes, or is nil if disabled.
+		cache compilationcache.Cache
 		// setFinalizer defaults to runtime.SetFinalizer, but overridable for tests.
 		setFinalizer func(obj interface{}, finalizer interface{})
 	}
@@ -39,6 +55,16 @@ type (
 		functions []*function
 
 		importedFunctionCount uint32
+
+		// ensureTermination is engine.ensureTermination of the engine this was instantiated from.
+		ensureTermination bool
+
+		// meterFuel is engine.meterFuel of the engine this was instantiated from.
+		meterFuel bool
+
+		// exceptionHandling is true when wasm.FeatureExceptionHandling is enabled on the engine this was instantiated
+		// from. See callEngine.exceptionHandling
+		exceptionHandling bool
 	}
 
 	// callEngine holds context per moduleEngine.Call, and shared across all the
@@ -63,6 +89,39 @@ type (
 		// The currently executed function call frame lives at callFrameStack[callFrameStackPointer-1]
 		// and that is equivalent to  engine.callFrameTop().
 		callFrameStack []callFrame
+
+		// fuel is the fuel of the context.Context of this call, or nil if unlimited. Compiled code consumes
+		// exitContext.fuelRemaining instead, which is synchronized with this around host function calls.
+		fuel *internalsys.Fuel
+
+		// listenerFrames are the functions in the call stack whose FunctionListener.Before was called, but not After.
+		listenerFrames []listenerFrame
+
+		// exceptions are the exceptions caught by catch clauses, indexed by their handles, in the order of their
+		// positions in the value stack.
+		exceptions []caughtException
+
+		// exceptionHandling is true when host functions may throw exceptions Wasm functions catch, so they must be
+		// recovered from. Otherwise, an exception panics through to moduleEngine.Call like any other error.
+		exceptionHandling bool
+	}
+
+	// caughtException is an exception caught by a catch clause, whose handle is on the value stack at the position.
+	caughtException struct {
+		position uint64
+		exc      *sys.Exception
+	}
+
+	// listenerFrame is pushed when calling FunctionListener.Before and popped when calling After.
+	listenerFrame struct {
+		fn *wasm.FunctionInstance
+		// ctx is the context returned by Before, which is passed to After.
+		ctx context.Context
+		// callerCtx is the context passed to Before, which is restored after the function returns.
+		callerCtx context.Context
+		// callFrameStackPointer is the globalContext.callFrameStackPointer when Before was called, so the function
+		// is at callFrameStack[callFrameStackPointer-1].
+		callFrameStackPointer uint64
 	}
 
 	// globalContext holds the data which is constant across multiple function calls.
@@ -118,6 +177,10 @@ type (
 
 		// elementInstancesElement0Address holds the &ModuleInstance.ElementInstances[0] as uintptr.
 		elementInstancesElement0Address uintptr
+
+		// memoriesElement0Address holds the &ModuleInstance.Memories[0] as uintptr. This is only set for modules with
+		// more than one memory, as the memory zero is accessed via memoryElement0Address.
+		memoriesElement0Address uintptr
 	}
 
 	// valueStackContext stores the data to access engine.valueStack.
@@ -151,6 +214,18 @@ type (
 		// Set when statusCode == compilerStatusCallBuiltInFunction}
 		// Indicating the function call index.
 		builtinFunctionCallIndex wasm.Index
+
+		// contextCheckCountdown is decremented by compiled code at function entries and loop headers when
+		// engine.ensureTermination is true. When it reaches zero, compiled code calls the builtin function
+		// builtinFunctionIndexCheckContextDone, which checks if the context.Context is done and resets this.
+		contextCheckCountdown uint64
+
+		// fuelRemaining is decremented by compiled code at the beginning of each basic block when
+		// engine.meterFuel is true. When it gets negative, compiled code exits with nativeCallStatusCodeOutOfFuel.
+		fuelRemaining int64
+
+		// Set when statusCode is a trap, to the offset in the function body of the Wasm instruction which trapped.
+		instructionOffset uint64
 	}
 
 	// callFrame holds the information to which the caller function can return.
@@ -197,12 +272,48 @@ type (
 		// stackPointerCeil is the max of the stack pointer this function can reach. Lazily applied via maybeGrowValueStack.
 		stackPointerCeil uint64
 
+		// instructionOffsets maps codeSegment to the Wasm instructions it is compiled from. For stack traces.
+		instructionOffsets instructionOffsetMap
+
+		// tryBlocks are the try blocks of the function, indexed like wazeroir.CompilationResult TryBlocks.
+		tryBlocks []*tryBlock
+
+		// coverage is counted by codeSegment, which embeds the addresses of its Counts, or nil if engine.coverage is
+		// false. This is shared by the codes of a function with and without listeners.
+		coverage *wasm.FunctionCoverage
+
 		// indexInModule is the index of this function in the module. For logging purpose.
 		indexInModule wasm.Index
 		// sourceModule is the module from which this function is compiled. For logging purpose.
 		sourceModule *wasm.Module
 	}
 
+	// instructionOffsetMap maps native code to the offsets in the function body of the Wasm instructions it is
+	// compiled from.
+	instructionOffsetMap struct {
+		// nativeOffsets are the ascending offsets in the code segment where the code of each instruction begins.
+		nativeOffsets []uint64
+		// instructionOffsets are index-correlated with nativeOffsets.
+		instructionOffsets []uint64
+	}
+
+	// tryBlock is a wazeroir.TryBlock with the offsets in code.codeSegment of its native code. Offsets are zero if its
+	// body is never reached.
+	tryBlock struct {
+		*wazeroir.TryBlock
+		// start and end are the offsets of the beginning of the body and of the code after it.
+		start, end uint64
+		catches    []catchClause
+	}
+
+	// catchClause is a catch clause of a tryBlock, which begins at the handler offset in code.codeSegment.
+	catchClause struct {
+		handler  uint64
+		tagIndex uint32
+		// all is true for wazeroir.OperationCatchAll, which catches exceptions of any tag.
+		all bool
+	}
+
 	// staticData holds the read-only data (i.e. out side of codeSegment which is marked as executable) per function.
 	// This is used to store jump tables for br_table instructions.
 	// The primary index is the logical separation of multiple data, for example data[0] and data[1]
@@ -210,6 +321,14 @@ type (
 	codeStaticData = [][]byte
 )
 
+// functionFromUintptr resurrects the original *function from the given uintptr
+// which comes from either funcref table or OpcodeRefFunc instruction.
+func functionFromUintptr(ptr uintptr) *function {
+	// Wraps ptrs as the double pointer in order to avoid the unsafe access as detected by race detector.
+	var wrapped *uintptr = &ptr
+	return *(**function)(unsafe.Pointer(wrapped))
+}
+
 // createFunction creates a new function which uses the native code compiled.
 func (c *code) createFunction(f *wasm.FunctionInstance) *function {
 	return &function{
@@ -244,14 +363,18 @@ const (
 	callEngineModuleContextTypeIDsElement0AddressOffset          = 88
 	callEngineModuleContextDataInstancesElement0AddressOffset    = 96
 	callEngineModuleContextElementInstancesElement0AddressOffset = 104
+	callEngineModuleContextMemoriesElement0AddressOffset         = 112
 
 	// Offsets for callEngine valueStackContext.
-	callEngineValueStackContextStackPointerOffset     = 112
-	callEngineValueStackContextStackBasePointerOffset = 120
+	callEngineValueStackContextStackPointerOffset     = 120
+	callEngineValueStackContextStackBasePointerOffset = 128
 
 	// Offsets for callEngine exitContext.
-	callEngineExitContextnativeCallStatusCodeOffset       = 128
-	callEngineExitContextBuiltinFunctionCallAddressOffset = 132
+	callEngineExitContextnativeCallStatusCodeOffset       = 136
+	callEngineExitContextBuiltinFunctionCallAddressOffset = 140
+	callEngineExitContextContextCheckCountdownOffset      = 144
+	callEngineExitContextFuelRemainingOffset              = 152
+	callEngineExitContextInstructionOffsetOffset          = 160
 
 	// Offsets for callFrame.
 	callFrameDataSize                      = 32
@@ -274,6 +397,7 @@ const (
 	moduleInstanceTypeIDsOffset          = 152
 	moduleInstanceDataInstancesOffset    = 176
 	moduleInstanceElementInstancesOffset = 200
+	moduleInstanceMemoriesOffset         = 224
 
 	// Offsets for wasm.TableInstance.
 	tableInstanceTableOffset    = 0
@@ -283,8 +407,9 @@ const (
 	functionInstanceTypeIDOffset = 96
 
 	// Offsets for wasm.MemoryInstance.
-	memoryInstanceBufferOffset    = 0
-	memoryInstanceBufferLenOffset = 8
+	memoryInstanceLengthOffset    = 0
+	memoryInstanceBufferOffset    = 8
+	memoryInstanceBufferLenOffset = 16
 
 	// Offsets for wasm.GlobalInstance.
 	globalInstanceValueOffset = 8
@@ -328,6 +453,10 @@ const (
 	nativeCallStatusCodeTypeMismatchOnIndirectCall
 	nativeCallStatusIntegerOverflow
 	nativeCallStatusIntegerDivisionByZero
+	// nativeCallStatusCodeOutOfFuel means the call consumed all of its fuel.
+	nativeCallStatusCodeOutOfFuel
+	// nativeCallStatusCodeUnalignedAtomic means an atomic memory access wasn't aligned to its size.
+	nativeCallStatusCodeUnalignedAtomic
 )
 
 // causePanic causes a panic with the corresponding error to the status code.
@@ -348,10 +477,19 @@ func (s nativeCallStatusCode) causePanic() {
 		err = wasmruntime.ErrRuntimeInvalidTableAccess
 	case nativeCallStatusCodeTypeMismatchOnIndirectCall:
 		err = wasmruntime.ErrRuntimeIndirectCallTypeMismatch
+	case nativeCallStatusCodeOutOfFuel:
+		err = wasmruntime.ErrRuntimeOutOfFuel
+	case nativeCallStatusCodeUnalignedAtomic:
+		err = wasmruntime.ErrRuntimeUnalignedAtomic
 	}
 	panic(err)
 }
 
+// isTrap returns true if the status is one which causePanic turns into an error.
+func (s nativeCallStatusCode) isTrap() bool {
+	return s > nativeCallStatusCodeCallBuiltInFunction
+}
+
 func (s nativeCallStatusCode) String() (ret string) {
 	switch s {
 	case nativeCallStatusCodeReturned:
@@ -374,6 +512,10 @@ func (s nativeCallStatusCode) String() (ret string) {
 		ret = "integer overflow"
 	case nativeCallStatusIntegerDivisionByZero:
 		ret = "integer division by zero"
+	case nativeCallStatusCodeOutOfFuel:
+		ret = "out of fuel"
+	case nativeCallStatusCodeUnalignedAtomic:
+		ret = "unaligned atomic"
 	default:
 		panic("BUG")
 	}
@@ -416,15 +558,39 @@ func (e *engine) DeleteCompiledModule(module *wasm.Module) {
 	e.deleteCodes(module)
 }
 
+// Coverage implements the same method as documented on wasm.Engine.
+func (e *engine) Coverage(module *wasm.Module) (ret []*wasm.FunctionCoverage) {
+	codes, ok := e.getCodes(module)
+	if !ok || !e.coverage || module.IsHostModule() {
+		return
+	}
+	for _, c := range codes {
+		ret = append(ret, c.coverage)
+	}
+	return
+}
+
 // CompileModule implements the same method as documented on wasm.Engine.
 func (e *engine) CompileModule(ctx context.Context, module *wasm.Module) error {
 	if _, ok := e.getCodes(module); ok { // cache hit!
 		return nil
 	}
 
-	funcs := make([]*code, 0, len(module.FunctionSection))
+	// Host functions are cheap to compile, and their module ID varies with the Go function pointers. Coverage counters
+	// are addressed by the compiled code, so it can't be reused by another process.
+	useCache := e.cache != nil && !module.IsHostModule() && !e.coverage
+	if useCache {
+		if funcs, ok, err := e.getCodesFromCache(module); err != nil {
+			return err
+		} else if ok {
+			e.addCodes(module, funcs)
+			return nil
+		}
+	}
 
+	var funcs []*code
 	if module.IsHostModule() {
+		funcs = make([]*code, 0, len(module.FunctionSection))
 		for funcIndex := range module.HostFunctionSection {
 			compiled, err := compileHostFunction(module.TypeSection[module.FunctionSection[funcIndex]])
 			if err != nil {
@@ -440,28 +606,58 @@ func (e *engine) CompileModule(ctx context.Context, module *wasm.Module) error {
 			funcs = append(funcs, compiled)
 		}
 	} else {
-		irs, err := wazeroir.CompileFunctions(ctx, e.enabledFeatures, module)
-		if err != nil {
+		var err error
+		if funcs, err = e.compileWasmFunctions(ctx, module, false); err != nil {
 			return err
 		}
 
-		for funcIndex := range module.FunctionSection {
-			compiled, err := compileWasmFunction(e.enabledFeatures, irs[funcIndex])
-			if err != nil {
-				return fmt.Errorf("function[%d/%d] %w", funcIndex, len(module.FunctionSection)-1, err)
+		if useCache {
+			if err = e.addCodesToCache(module, funcs); err != nil {
+				return err
 			}
+		}
+	}
+	e.addCodes(module, funcs)
+	return nil
+}
 
-			// As this uses mmap, we need to munmap on the compiled machine code when it's GCed.
-			e.setFinalizer(compiled, releaseCode)
+// compileWasmFunctions compiles the functions of the non-host module. When withListener is true, compiled functions
+// call builtin functions to notify their experimental.FunctionListener, if any.
+func (e *engine) compileWasmFunctions(ctx context.Context, module *wasm.Module, withListener bool) ([]*code, error) {
+	irs, err := wazeroir.CompileFunctions(ctx, e.enabledFeatures, e.meterFuel, e.coverage, module)
+	if err != nil {
+		return nil, err
+	}
 
-			compiled.indexInModule = wasm.Index(funcIndex)
-			compiled.sourceModule = module
+	// Codes with listeners count in the coverage of those without, so that the module has one report.
+	var withoutListener []*code
+	if withListener {
+		withoutListener, _ = e.getCodes(module)
+	}
 
-			funcs = append(funcs, compiled)
+	funcs := make([]*code, 0, len(module.FunctionSection))
+	for funcIndex := range module.FunctionSection {
+		var coverage *wasm.FunctionCoverage
+		if withoutListener != nil {
+			coverage = withoutListener[funcIndex].coverage
+		} else if e.coverage {
+			coverage = wasm.NewFunctionCoverage(irs[funcIndex].BlockOffsets)
+		}
+
+		compiled, err := compileWasmFunction(e.enabledFeatures, e.ensureTermination, withListener, coverage, irs[funcIndex])
+		if err != nil {
+			return nil, fmt.Errorf("function[%d/%d] %w", funcIndex, len(module.FunctionSection)-1, err)
 		}
+
+		// As this uses mmap, we need to munmap on the compiled machine code when it's GCed.
+		e.setFinalizer(compiled, releaseCode)
+
+		compiled.indexInModule = wasm.Index(funcIndex)
+		compiled.sourceModule = module
+
+		funcs = append(funcs, compiled)
 	}
-	e.addCodes(module, funcs)
-	return nil
+	return funcs, nil
 }
 
 // NewModuleEngine implements the same method as documented on wasm.Engine.
@@ -471,6 +667,9 @@ func (e *engine) NewModuleEngine(name string, module *wasm.Module, importedFunct
 		name:                  name,
 		functions:             make([]*function, 0, imported+uint32(len(moduleFunctions))),
 		importedFunctionCount: imported,
+		ensureTermination:     e.ensureTermination,
+		meterFuel:             e.meterFuel,
+		exceptionHandling:     e.enabledFeatures.Get(wasm.FeatureExceptionHandling),
 	}
 
 	for _, f := range importedFunctions {
@@ -483,6 +682,14 @@ func (e *engine) NewModuleEngine(name string, module *wasm.Module, importedFunct
 		return nil, fmt.Errorf("source module for %s must be compiled before instantiation", name)
 	}
 
+	// Host functions notify their listeners from Go, so only Wasm functions need different codes.
+	if !module.IsHostModule() && hasFunctionListener(moduleFunctions) {
+		var err error
+		if codes, err = e.getListenerCodes(module); err != nil {
+			return nil, err
+		}
+	}
+
 	for i, c := range codes {
 		f := moduleFunctions[i]
 		function := c.createFunction(f)
@@ -504,10 +711,42 @@ func (e *engine) NewModuleEngine(name string, module *wasm.Module, importedFunct
 	return me, nil
 }
 
+// hasFunctionListener returns true if any of the functions has an experimental.FunctionListener.
+func hasFunctionListener(functions []*wasm.FunctionInstance) bool {
+	for _, f := range functions {
+		if f.FunctionListener != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// getListenerCodes returns the codes of the module which notify experimental.FunctionListener, compiling them if
+// necessary.
+func (e *engine) getListenerCodes(module *wasm.Module) ([]*code, error) {
+	e.mux.RLock()
+	codes, ok := e.listenerCodes[module.ID]
+	e.mux.RUnlock()
+	if ok {
+		return codes, nil
+	}
+
+	codes, err := e.compileWasmFunctions(context.Background(), module, true)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	e.listenerCodes[module.ID] = codes
+	return codes, nil
+}
+
 func (e *engine) deleteCodes(module *wasm.Module) {
 	e.mux.Lock()
 	defer e.mux.Unlock()
 	delete(e.codes, module.ID)
+	delete(e.listenerCodes, module.ID)
 }
 
 func (e *engine) addCodes(module *wasm.Module, fs []*code) {
@@ -542,6 +781,11 @@ func (e *moduleEngine) CreateFuncElementInstance(indexes []*wasm.Index) *wasm.El
 	}
 }
 
+// LookupFunction implements the same method as documented on wasm.ModuleEngine.
+func (e *moduleEngine) LookupFunction(ref wasm.Reference) *wasm.FunctionInstance {
+	return functionFromUintptr(ref).source
+}
+
 // InitializeFuncrefGlobals implements the same method as documented on wasm.InitializeFuncrefGlobals.
 func (e *moduleEngine) InitializeFuncrefGlobals(globals []*wasm.GlobalInstance) {
 	for _, g := range globals {
@@ -591,37 +835,115 @@ func (e *moduleEngine) Call(ctx context.Context, callCtx *wasm.CallContext, f *w
 			// Handle edge-case where the host function is called directly by Go.
 			if ce.globalContext.callFrameStackPointer == 0 {
 				fn := compiled.source
-				builder.AddFrame(fn.DebugName, fn.ParamTypes(), fn.ResultTypes())
+				builder.AddFrame(fn.DebugName, fn.StackFrame(-1))
 			}
 			for i := uint64(0); i < ce.globalContext.callFrameStackPointer; i++ {
-				fn := ce.callFrameStack[ce.globalContext.callFrameStackPointer-1-i].function.source
-				builder.AddFrame(fn.DebugName, fn.ParamTypes(), fn.ResultTypes())
+				frame := &ce.callFrameStack[ce.globalContext.callFrameStackPointer-1-i]
+				fn := frame.function.source
+				builder.AddFrame(fn.DebugName, fn.StackFrame(ce.instructionOffsetOf(frame, i == 0)))
 			}
 			err = builder.FromRecovered(v)
+
+			// Notify the listeners of functions which didn't return, innermost first.
+			for i := len(ce.listenerFrames) - 1; i >= 0; i-- {
+				frame := ce.listenerFrames[i]
+				frame.fn.FunctionListener.After(frame.ctx, err, nil)
+			}
 		}
 	}()
 
 	if f.Kind == wasm.FunctionKindWasm {
+		if e.ensureTermination {
+			// Check the context on the first function entry, so that a call with a context already done fails
+			// immediately.
+			ce.exitContext.contextCheckCountdown = 1
+		}
+		if e.meterFuel {
+			defer ce.initFuel(ctx)()
+		}
 		for _, v := range params {
 			ce.pushValue(v)
 		}
 		ce.execWasmFunction(ctx, callCtx, compiled)
 		results = wasm.PopValues(f.Type.ResultNumInUint64, ce.popValue)
 	} else {
-		results = wasm.CallGoFunc(ctx, callCtx, compiled.source, params)
+		results = ce.callGoFunc(ctx, callCtx, compiled.source, params)
 	}
 	return
 }
 
-func NewEngine(enabledFeatures wasm.Features) wasm.Engine {
-	return newEngine(enabledFeatures)
+// callGoFunc calls the host function, notifying its experimental.FunctionListener, if any.
+func (ce *callEngine) callGoFunc(ctx context.Context, callCtx *wasm.CallContext, f *wasm.FunctionInstance, params []uint64) []uint64 {
+	if f.FunctionListener == nil {
+		return wasm.CallGoFunc(ctx, callCtx, f, params)
+	}
+	results := wasm.CallGoFunc(ce.functionListenerBefore(ctx, f, params), callCtx, f, params)
+	ce.functionListenerAfter(f, results)
+	return results
+}
+
+// callGoModuleFunction calls the api.GoModuleFunction of f with the top of the value stack, where its params are, so
+// that the call doesn't allocate them or its results.
+//
+// Note: The value stack has room for the results, as they are included in the stack pointer ceiling of the caller.
+func (ce *callEngine) callGoModuleFunction(ctx context.Context, callCtx *wasm.CallContext, f *wasm.FunctionInstance) {
+	ce.valueStackContext.stackPointer -= uint64(f.Type.ParamNumInUint64)
+	top := ce.valueStackTopIndex()
+	stack := ce.valueStack[top : top+uint64(f.Type.StackLen())]
+	if f.FunctionListener == nil {
+		f.GoModuleFunc.Call(ctx, callCtx, stack)
+	} else {
+		f.GoModuleFunc.Call(ce.functionListenerBefore(ctx, f, stack[:f.Type.ParamNumInUint64]), callCtx, stack)
+		ce.functionListenerAfter(f, stack[:f.Type.ResultNumInUint64])
+	}
+	ce.valueStackContext.stackPointer += uint64(f.Type.ResultNumInUint64)
+}
+
+// functionListenerBefore calls FunctionListener.Before of the function, and returns the context to call it with.
+func (ce *callEngine) functionListenerBefore(ctx context.Context, f *wasm.FunctionInstance, params []uint64) context.Context {
+	fnCtx := f.FunctionListener.Before(ctx, params)
+	ce.listenerFrames = append(ce.listenerFrames, listenerFrame{fn: f, ctx: fnCtx, callerCtx: ctx,
+		callFrameStackPointer: ce.globalContext.callFrameStackPointer})
+	return fnCtx
+}
+
+// functionListenerAfter calls FunctionListener.After of the function which returned, and returns the context of its
+// caller.
+func (ce *callEngine) functionListenerAfter(f *wasm.FunctionInstance, results []uint64) context.Context {
+	last := len(ce.listenerFrames) - 1
+	frame := ce.listenerFrames[last]
+	ce.listenerFrames = ce.listenerFrames[:last]
+	f.FunctionListener.After(frame.ctx, nil, results)
+	return frame.callerCtx
 }
 
-func newEngine(enabledFeatures wasm.Features) *engine {
+// initFuel initializes exitContext.fuelRemaining with the fuel of the context. The returned function must be called
+// when the call returns to store the remaining fuel back.
+func (ce *callEngine) initFuel(ctx context.Context) (storeFuel func()) {
+	ce.fuel = internalsys.FuelFromContext(ctx)
+	if ce.fuel == nil {
+		ce.exitContext.fuelRemaining = math.MaxInt64 // unlimited
+		return func() {}
+	}
+	ce.exitContext.fuelRemaining = ce.fuel.Remaining()
+	return func() { ce.fuel.SetRemaining(ce.exitContext.fuelRemaining) }
+}
+
+// NewEngine returns a compiler, which persists compiled modules in wasm.EngineConfig Cache unless it is nil.
+func NewEngine(enabledFeatures wasm.Features, config wasm.EngineConfig) wasm.Engine {
+	return newEngine(enabledFeatures, config)
+}
+
+func newEngine(enabledFeatures wasm.Features, config wasm.EngineConfig) *engine {
 	return &engine{
-		enabledFeatures: enabledFeatures,
-		codes:           map[wasm.ModuleID][]*code{},
-		setFinalizer:    runtime.SetFinalizer,
+		enabledFeatures:   enabledFeatures,
+		ensureTermination: config.EnsureTermination,
+		meterFuel:         config.MeterFuel,
+		coverage:          config.Coverage,
+		cache:             config.Cache,
+		codes:             map[wasm.ModuleID][]*code{},
+		listenerCodes:     map[wasm.ModuleID][]*code{},
+		setFinalizer:      runtime.SetFinalizer,
 	}
 }
 
@@ -659,9 +981,10 @@ var (
 
 func (e *moduleEngine) newCallEngine() *callEngine {
 	ce := &callEngine{
-		valueStack:     make([]uint64, initialValueStackSize),
-		callFrameStack: make([]callFrame, initialCallFrameStackSize),
-		archContext:    newArchContext(),
+		valueStack:        make([]uint64, initialValueStackSize),
+		callFrameStack:    make([]callFrame, initialCallFrameStackSize),
+		archContext:       newArchContext(),
+		exceptionHandling: e.exceptionHandling,
 	}
 
 	valueStackHeader := (*reflect.SliceHeader)(unsafe.Pointer(&ce.valueStack))
@@ -705,6 +1028,22 @@ const (
 	builtinFunctionIndexGrowValueStack
 	builtinFunctionIndexGrowCallFrameStack
 	builtinFunctionIndexTableGrow
+	builtinFunctionIndexCheckContextDone
+	// builtinFunctionIndexFunctionListenerBefore is called in the preamble of functions compiled with a listener.
+	builtinFunctionIndexFunctionListenerBefore
+	// builtinFunctionIndexFunctionListenerAfter is called on return of functions compiled with a listener.
+	builtinFunctionIndexFunctionListenerAfter
+	// builtinFunctionIndexFunctionListenerTailCall is called before tail calls of functions compiled with a listener.
+	builtinFunctionIndexFunctionListenerTailCall
+	// builtinFunctionIndexAtomic executes an atomic instruction of the threads proposal. See atomicDescriptor
+	builtinFunctionIndexAtomic
+	// builtinFunctionIndexThrow throws an exception of the exception handling proposal. See callEngine.throw
+	builtinFunctionIndexThrow
+	// builtinFunctionIndexRethrow throws again an exception caught by a catch clause.
+	builtinFunctionIndexRethrow
+	// builtinFunctionIndexMemory executes a memory instruction which isn't compiled to native code.
+	// See memoryBuiltinInstruction
+	builtinFunctionIndexMemory
 	// builtinFunctionIndexBreakPoint is internal (only for wazero developers). Disabled by default.
 	builtinFunctionIndexBreakPoint
 )
@@ -723,7 +1062,8 @@ entry:
 		}
 
 		// Call into the native code.
-		nativecall(frame.returnAddress, uintptr(unsafe.Pointer(ce)), f.moduleInstanceAddress)
+		// The module instance is only used when entering a function or catch clause, so it is the one of the frame.
+		nativecall(frame.returnAddress, uintptr(unsafe.Pointer(ce)), frame.function.moduleInstanceAddress)
 
 		// Check the status code from Compiler code.
 		switch status := ce.exitContext.statusCode; status {
@@ -733,17 +1073,28 @@ entry:
 			calleeHostFunction := ce.callFrameTop().function
 			// Not "callFrameTop" but take the below of peek with "callFrameAt(1)" as the top frame is for host function,
 			// but when making host function calls, we need to pass the memory instance of host function caller.
-			callerFunction := ce.callFrameAt(1).function
-			params := wasm.PopGoFuncParams(calleeHostFunction.source, ce.popValue)
-			results := wasm.CallGoFunc(
-				ctx,
-				// Use the caller's memory, which might be different from the defining module on an imported function.
-				callCtx.WithMemory(callerFunction.source.Module.Memory),
-				calleeHostFunction.source,
-				params,
-			)
-			for _, v := range results {
-				ce.pushValue(v)
+			// There is no such frame when the host function replaced the initial one, by a tail call.
+			callerFunction := calleeHostFunction
+			if ce.globalContext.callFrameStackPointer > 1 {
+				callerFunction = ce.callFrameAt(1).function
+			}
+			if ce.fuel != nil {
+				// The host function may call other functions with the same fuel.
+				ce.fuel.SetRemaining(ce.exitContext.fuelRemaining)
+			}
+			// Use the caller's memory, which might be different from the defining module on an imported function.
+			hostCallCtx := callCtx.WithMemoryOf(callerFunction.source.Module)
+			var exc *sys.Exception
+			if ce.exceptionHandling {
+				exc = ce.callHostFunctionCatching(ctx, hostCallCtx, calleeHostFunction.source)
+			} else {
+				ce.callHostFunction(ctx, hostCallCtx, calleeHostFunction.source)
+			}
+			if ce.fuel != nil {
+				ce.exitContext.fuelRemaining = ce.fuel.Remaining()
+			}
+			if exc != nil {
+				ctx = ce.throw(ctx, exc)
 			}
 			goto entry
 		case nativeCallStatusCodeCallBuiltInFunction:
@@ -759,6 +1110,23 @@ entry:
 			case builtinFunctionIndexTableGrow:
 				caller := ce.callFrameTop().function
 				ce.builtinFunctionTableGrow(ctx, caller.source.Module.Tables)
+			case builtinFunctionIndexCheckContextDone:
+				ce.builtinFunctionCheckContextDone(ctx)
+			case builtinFunctionIndexFunctionListenerBefore:
+				ctx = ce.builtinFunctionFunctionListenerBefore(ctx, ce.callFrameTop().function.source)
+			case builtinFunctionIndexFunctionListenerAfter:
+				ctx = ce.builtinFunctionFunctionListenerAfter(ctx, ce.callFrameTop().function.source)
+			case builtinFunctionIndexFunctionListenerTailCall:
+				ctx = ce.builtinFunctionFunctionListenerTailCall(ctx, ce.callFrameTop().function.source)
+			case builtinFunctionIndexAtomic:
+				d := ce.popValue()
+				ce.builtinFunctionAtomic(ctx, ce.callFrameTop().function.source.Module.Memory, d, uint64(uint32(d)))
+			case builtinFunctionIndexThrow:
+				ctx = ce.builtinFunctionThrow(ctx, ce.callFrameTop().function.source.Module.Tags)
+			case builtinFunctionIndexRethrow:
+				ctx = ce.throw(ctx, ce.exceptions[ce.popValue()].exc)
+			case builtinFunctionIndexMemory:
+				ce.builtinFunctionMemory(ctx, ce.callFrameTop().function.source.Module)
 			}
 			if buildoptions.IsDebugMode {
 				if ce.exitContext.builtinFunctionCallIndex == builtinFunctionIndexBreakPoint {
@@ -772,6 +1140,131 @@ entry:
 	}
 }
 
+// callHostFunction calls the host function with the params on the top of the value stack, replacing them with its
+// results.
+func (ce *callEngine) callHostFunction(ctx context.Context, callCtx *wasm.CallContext, f *wasm.FunctionInstance) {
+	if f.Kind == wasm.FunctionKindGoModuleFunction {
+		ce.callGoModuleFunction(ctx, callCtx, f)
+	} else {
+		params := wasm.PopGoFuncParams(f, ce.popValue)
+		results := ce.callGoFunc(ctx, callCtx, f, params)
+		for _, v := range results {
+			ce.pushValue(v)
+		}
+	}
+}
+
+// callHostFunctionCatching is like callHostFunction, but returns the exception thrown by the host function if any, so
+// that it can be thrown to the Wasm functions catching it. This is only used when callEngine.exceptionHandling, as
+// recovering has a cost on every host call.
+func (ce *callEngine) callHostFunctionCatching(ctx context.Context, callCtx *wasm.CallContext, f *wasm.FunctionInstance) (exc *sys.Exception) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			if exc = wasm.ExceptionFromRecovered(recovered); exc == nil {
+				panic(recovered)
+			}
+		}
+	}()
+	ce.callHostFunction(ctx, callCtx, f)
+	return
+}
+
+// builtinFunctionThrow throws an exception of the tag whose index is on the top of the stack, with the values below
+// it. See callEngine.throw
+func (ce *callEngine) builtinFunctionThrow(ctx context.Context, tags []*wasm.TagInstance) context.Context {
+	tag := tags[ce.popValue()]
+	top := ce.valueStackTopIndex()
+	values := append([]uint64(nil), ce.valueStack[top-uint64(tag.Type.ParamNumInUint64):top]...)
+	return ce.throw(ctx, sys.NewException(tag, values...))
+}
+
+// throw unwinds the call stack to the innermost catch clause of the exception, so that the next native call executes
+// it, and returns the context to execute it with. This panics with the exception if no function catches it.
+//
+// Unwound functions which have a listener are notified with the exception as the error.
+// See wazeroir.TryBlock
+func (ce *callEngine) throw(ctx context.Context, exc *sys.Exception) context.Context {
+	top := ce.globalContext.callFrameStackPointer
+	for sp := top; sp > 0; sp-- {
+		frame := &ce.callFrameStack[sp-1]
+		t, c := frame.catch(exc)
+		if c == nil {
+			continue
+		}
+
+		// The stack base pointer of a frame is saved when it calls the next one.
+		base := ce.valueStackContext.stackBasePointer
+		if sp < top {
+			base = frame.returnStackBasePointer
+		}
+		for i := len(ce.listenerFrames) - 1; i >= 0 && ce.listenerFrames[i].callFrameStackPointer > sp; i-- {
+			l := ce.listenerFrames[i]
+			ce.listenerFrames = ce.listenerFrames[:i]
+			l.fn.FunctionListener.After(l.ctx, exc, nil)
+			ctx = l.callerCtx
+		}
+
+		ce.globalContext.callFrameStackPointer = sp
+		ce.valueStackContext.stackBasePointer = base
+		ce.valueStackContext.stackPointer = uint64(t.StackHeight)
+		ce.pushValue(ce.addException(base+uint64(t.StackHeight), exc))
+		if !c.all {
+			for _, v := range exc.Values() {
+				ce.pushValue(v)
+			}
+		}
+		frame.returnAddress = frame.function.codeInitialAddress + uintptr(c.handler)
+		return ctx
+	}
+	panic(exc)
+}
+
+// addException returns the handle of the exception caught at the position of the value stack. Exceptions caught at or
+// above the position are removed, as their catch clauses were unwound.
+func (ce *callEngine) addException(position uint64, exc *sys.Exception) uint64 {
+	i := len(ce.exceptions)
+	for i > 0 && ce.exceptions[i-1].position >= position {
+		i--
+	}
+	ce.exceptions = append(ce.exceptions[:i], caughtException{position: position, exc: exc})
+	return uint64(i)
+}
+
+// catch returns the catch clause of the function of the frame which catches the exception thrown at its return
+// address, and its try block, or nil if there is none.
+func (f *callFrame) catch(exc *sys.Exception) (*tryBlock, *catchClause) {
+	tryBlocks := f.function.parent.tryBlocks
+	if len(tryBlocks) == 0 {
+		return nil, nil
+	}
+	tags := f.function.source.Module.Tags
+	returnOffset := uint64(f.returnAddress - f.function.codeInitialAddress)
+
+	// Try blocks are in the order of their beginning, so the last one including the return address is the innermost.
+	// The return address is after the instruction which threw, so it may be the end of the body.
+	index := -1
+	for i := len(tryBlocks) - 1; i >= 0; i-- {
+		if t := tryBlocks[i]; t.start < returnOffset && returnOffset <= t.end {
+			index = i
+			break
+		}
+	}
+	for index >= 0 {
+		t := tryBlocks[index]
+		if t.Delegate {
+			index = t.DelegateTarget
+			continue
+		}
+		for i := range t.catches {
+			if c := &t.catches[i]; c.all || tags[c.tagIndex].Matches(exc) {
+				return t, c
+			}
+		}
+		index = t.Parent
+	}
+	return nil, nil
+}
+
 func (ce *callEngine) builtinFunctionGrowValueStack(stackPointerCeil uint64) {
 	// Extends the valueStack's length to currentLen*2+stackPointerCeil.
 	newLen := ce.globalContext.valueStackLen*2 + (stackPointerCeil)
@@ -786,6 +1279,50 @@ func (ce *callEngine) builtinFunctionGrowValueStack(stackPointerCeil uint64) {
 
 var callStackCeiling = uint64(buildoptions.CallStackCeiling)
 
+// contextCheckInterval is the number of function entries and loop iterations between checks of the context.Context
+// when engine.ensureTermination is true. This amortizes the cost of returning to Go.
+const contextCheckInterval = 1 << 14
+
+func (ce *callEngine) builtinFunctionCheckContextDone(ctx context.Context) {
+	ce.exitContext.contextCheckCountdown = contextCheckInterval
+	select {
+	case <-ctx.Done():
+		panic(sys.NewContextDoneError(ctx.Err()))
+	default:
+	}
+}
+
+// builtinFunctionFunctionListenerBefore calls functionListenerBefore with the params at the bottom of the stack of
+// the function, and returns the context to execute it with.
+func (ce *callEngine) builtinFunctionFunctionListenerBefore(ctx context.Context, f *wasm.FunctionInstance) context.Context {
+	if f.FunctionListener == nil { // Only some functions of a module may have listeners.
+		return ctx
+	}
+	base := ce.valueStackContext.stackBasePointer
+	return ce.functionListenerBefore(ctx, f, ce.valueStack[base:base+uint64(f.Type.ParamNumInUint64)])
+}
+
+// builtinFunctionFunctionListenerAfter calls functionListenerAfter with the results on the top of the stack, and
+// returns the context of the caller.
+func (ce *callEngine) builtinFunctionFunctionListenerAfter(ctx context.Context, f *wasm.FunctionInstance) context.Context {
+	if f.FunctionListener == nil {
+		return ctx
+	}
+	top := ce.valueStackTopIndex()
+	return ce.functionListenerAfter(f, ce.valueStack[top-uint64(f.Type.ResultNumInUint64):top])
+}
+
+// builtinFunctionFunctionListenerTailCall calls functionListenerAfter with nil results, as the results of a function
+// making a tail call are those of the called function, and returns the context of the caller to call it with.
+//
+// Note: This ends the call before the called function begins, so that tail calls don't grow ce.listenerFrames.
+func (ce *callEngine) builtinFunctionFunctionListenerTailCall(ctx context.Context, f *wasm.FunctionInstance) context.Context {
+	if f.FunctionListener == nil {
+		return ctx
+	}
+	return ce.functionListenerAfter(f, nil)
+}
+
 func (ce *callEngine) builtinFunctionGrowCallFrameStack() {
 	if callStackCeiling < uint64(len(ce.callFrameStack)+1) {
 		panic(wasmruntime.ErrRuntimeCallStackOverflow)
@@ -806,15 +1343,26 @@ func (ce *callEngine) builtinFunctionGrowCallFrameStack() {
 func (ce *callEngine) builtinFunctionMemoryGrow(ctx context.Context, mem *wasm.MemoryInstance) {
 	newPages := ce.popValue()
 
-	if res, ok := mem.Grow(ctx, uint32(newPages)); !ok {
+	if mem.Is64 && newPages > math.MaxUint32 { // More pages than any 64-bit memory can have.
+		ce.pushValue(math.MaxUint64) // = -1 in signed 64-bit integer.
+	} else if res, ok, err := mem.GrowFromWasm(ctx, uint32(newPages)); err != nil {
+		panic(err)
+	} else if !ok && mem.Is64 {
+		ce.pushValue(math.MaxUint64) // = -1 in signed 64-bit integer.
+	} else if !ok {
 		ce.pushValue(uint64(0xffffffff)) // = -1 in signed 32-bit integer.
 	} else {
 		ce.pushValue(uint64(res))
 	}
 
 	// Update the moduleContext fields as they become stale after the update ^^.
+	ce.updateMemoryContext(mem)
+}
+
+// updateMemoryContext updates the moduleContext fields of the memory, which become stale when it grows.
+func (ce *callEngine) updateMemoryContext(mem *wasm.MemoryInstance) {
 	bufSliceHeader := (*reflect.SliceHeader)(unsafe.Pointer(&mem.Buffer))
-	ce.moduleContext.memorySliceLen = uint64(bufSliceHeader.Len)
+	ce.moduleContext.memorySliceLen = mem.Size64(nil) // not len(mem.Buffer), which is the capacity of shared memories.
 	ce.moduleContext.memoryElement0Address = bufSliceHeader.Data
 }
 
@@ -827,8 +1375,123 @@ func (ce *callEngine) builtinFunctionTableGrow(ctx context.Context, tables []*wa
 	ce.pushValue(uint64(res))
 }
 
+// atomicDescriptor returns the value pushed by compiled code before calling builtinFunctionIndexAtomic, which describes
+// memory.atomic.wait or memory.atomic.notify to execute with the operands below it on the stack.
+//
+// Note: The static offset in the lower 32 bits is only used when accessing the memory zero with 32-bit indices, as
+// builtinFunctionMemory is passed the offset of other memories, which can be over math.MaxUint32 if they have 64-bit
+// indices.
+func atomicDescriptor(kind wazeroir.OperationKind, t wazeroir.UnsignedInt, size uint32, arg *wazeroir.MemoryArg) uint64 {
+	return uint64(uint32(arg.Offset)) | uint64(size)<<32 | uint64(t)<<40 | uint64(kind)<<48
+}
+
+// builtinFunctionAtomic executes memory.atomic.wait or memory.atomic.notify described by the atomicDescriptor d, with
+// the static offset. Other atomic instructions are compiled to native atomic instructions.
+func (ce *callEngine) builtinFunctionAtomic(ctx context.Context, mem *wasm.MemoryInstance, d, offset uint64) {
+	kind, t := wazeroir.OperationKind(d>>48), wazeroir.UnsignedInt(d>>40&0xf)
+	size := uint32(d >> 32 & 0xff)
+
+	var res uint32
+	var err error
+	if kind == wazeroir.OperationKindMemoryWait {
+		timeout, expected := ce.popValue(), ce.popValue()
+		if t == wazeroir.UnsignedInt32 {
+			expected = uint64(uint32(expected))
+		}
+		res, err = mem.AtomicWait(ctx, ce.popMemoryOffset(mem, offset), size, expected, int64(timeout))
+	} else {
+		count := uint32(ce.popValue())
+		res, err = mem.AtomicNotify(ce.popMemoryOffset(mem, offset), count)
+	}
+	if err != nil {
+		panic(err)
+	}
+	ce.pushValue(uint64(res))
+
+	// Another module instance may have grown the shared memory, so update the stale length.
+	ce.updateMemoryContext(mem)
+}
+
+// memoryDescriptor returns the value pushed by compiled code before calling builtinFunctionIndexMemory, which describes
+// the memory instruction to execute with the operands below it on the stack. memory.atomic.wait and
+// memory.atomic.notify are described by atomicDescriptor instead, which has the same layout.
+//
+// The memory indexes are pushed above the descriptor, the accessed memory in the lower 32 bits, and the data index of
+// memory.init or the source memory of memory.copy in the higher 32 bits. The static offset of the instruction is pushed
+// last, as it can be over math.MaxUint32 for memories with 64-bit indices.
+func memoryDescriptor(kind wazeroir.OperationKind) uint64 {
+	return uint64(kind) << 48
+}
+
+// builtinFunctionMemory executes the instruction described by the memoryDescriptor below the memory indexes and the
+// static offset on the top of the stack. Compiled code only supports the memory zero in memory.grow and the bulk memory
+// instructions, and the static offset of memory.atomic.wait and memory.atomic.notify is 32-bit, so the instructions
+// of memoryBuiltinInstruction are executed here.
+func (ce *callEngine) builtinFunctionMemory(ctx context.Context, mod *wasm.ModuleInstance) {
+	offset, indexes, d := ce.popValue(), ce.popValue(), ce.popValue()
+	mem := mod.MemoryAt(wasm.Index(indexes))
+
+	switch wazeroir.OperationKind(d >> 48) {
+	case wazeroir.OperationKindMemoryWait, wazeroir.OperationKindMemoryNotify:
+		ce.builtinFunctionAtomic(ctx, mem, d, offset)
+	case wazeroir.OperationKindMemoryGrow:
+		ce.builtinFunctionMemoryGrow(ctx, mem)
+	case wazeroir.OperationKindMemoryInit:
+		data := mod.DataInstances[indexes>>32]
+		copySize, inDataOffset := uint64(uint32(ce.popValue())), uint64(uint32(ce.popValue()))
+		buf := memoryView(mem, ce.popMemoryOffset(mem, 0), copySize)
+		if inDataOffset+copySize > uint64(len(data)) {
+			panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+		}
+		copy(buf, data[inDataOffset:])
+	case wazeroir.OperationKindMemoryCopy:
+		src := mod.MemoryAt(wasm.Index(indexes >> 32))
+		copySize := ce.popValue()
+		if !mem.Is64 || !src.Is64 { // The size is an i64 only if both memories have 64-bit indices.
+			copySize = uint64(uint32(copySize))
+		}
+		srcBuf := memoryView(src, ce.popMemoryOffset(src, 0), copySize)
+		copy(memoryView(mem, ce.popMemoryOffset(mem, 0), copySize), srcBuf)
+	case wazeroir.OperationKindMemoryFill:
+		fillSize := ce.popMemoryOffset(mem, 0) // The size has the same type as the address.
+		value := byte(ce.popValue())
+		buf := memoryView(mem, ce.popMemoryOffset(mem, 0), fillSize)
+		for i := range buf {
+			buf[i] = value
+		}
+	}
+
+	// The instructions above may update the moduleContext with the accessed memory, so restore the memory zero,
+	// which may also have been grown by another module instance if shared.
+	if mod.Memory != nil {
+		ce.updateMemoryContext(mod.Memory)
+	}
+}
+
+// popMemoryOffset pops the address of a memory instruction and returns it plus the static offset. The address is an
+// i32 unless the memory has 64-bit indices, in which case the sum can overflow, which panics as it is out of range.
+func (ce *callEngine) popMemoryOffset(mem *wasm.MemoryInstance, offset uint64) uint64 {
+	address := ce.popValue()
+	if !mem.Is64 {
+		address = uint64(uint32(address))
+	}
+	if offset += address; offset < address {
+		panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+	}
+	return offset
+}
+
+// memoryView returns the size bytes of the memory at the offset, or panics if they aren't all in range.
+func memoryView(mem *wasm.MemoryInstance, offset, size uint64) []byte {
+	end := offset + size
+	if end < offset || end > mem.Size64(nil) {
+		panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+	}
+	return mem.Buffer[offset:end:end]
+}
+
 func compileHostFunction(sig *wasm.FunctionType) (*code, error) {
-	compiler, err := newCompiler(&wazeroir.CompilationResult{Signature: sig})
+	compiler, err := newCompiler(&wazeroir.CompilationResult{Signature: sig}, false)
 	if err != nil {
 		return nil, err
 	}
@@ -845,35 +1508,87 @@ func compileHostFunction(sig *wasm.FunctionType) (*code, error) {
 	return &code{codeSegment: c}, nil
 }
 
-func compileWasmFunction(_ wasm.Features, ir *wazeroir.CompilationResult) (*code, error) {
-	compiler, err := newCompiler(ir)
+// compileWasmFunction compiles the function. When coverage is non-nil, its Counts are incremented by the basic blocks.
+func compileWasmFunction(_ wasm.Features, ensureTermination, withListener bool, coverage *wasm.FunctionCoverage, ir *wazeroir.CompilationResult) (*code, error) {
+	compiler, err := newCompiler(ir, withListener)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize assembly builder: %w", err)
 	}
 
+	// The preamble is attributed to the first instruction, like the initialization of locals in wazeroir.
+	compiler.compileInstructionOffset(0)
+	instructionOffset := uint64(0)
+
 	if err := compiler.compilePreamble(); err != nil {
 		return nil, fmt.Errorf("failed to emit preamble: %w", err)
 	}
 
+	if ensureTermination {
+		if err := compiler.compileCheckContextDone(); err != nil {
+			return nil, fmt.Errorf("failed to emit context check: %w", err)
+		}
+	}
+
 	var skip bool
-	for _, op := range ir.Operations {
+	for i, op := range ir.Operations {
 		// Compiler determines whether skip the entire label.
 		// For example, if the label doesn't have any caller,
 		// we don't need to generate native code at all as we never reach the region.
-		if op.Kind() == wazeroir.OperationKindLabel {
-			skip = compiler.compileLabel(op.(*wazeroir.OperationLabel))
+		// Likewise, catch clauses are skipped if the body of their try block is never reached.
+		switch o := op.(type) {
+		case *wazeroir.OperationLabel:
+			skip = compiler.compileLabel(o)
+		case *wazeroir.OperationTryEnd:
+			compiler.compileTryEnd(o)
+			continue
+		case *wazeroir.OperationCatch:
+			if skip, err = compiler.compileCatch(o.TryIndex, o.TagIndex, false); err != nil {
+				return nil, fmt.Errorf("operation %s: %w", op.Kind().String(), err)
+			}
+		case *wazeroir.OperationCatchAll:
+			if skip, err = compiler.compileCatch(o.TryIndex, 0, true); err != nil {
+				return nil, fmt.Errorf("operation %s: %w", op.Kind().String(), err)
+			}
 		}
 		if skip {
 			continue
 		}
 
+		if i < len(ir.InstructionOffsets) && ir.InstructionOffsets[i] != instructionOffset {
+			instructionOffset = ir.InstructionOffsets[i]
+			compiler.compileInstructionOffset(instructionOffset)
+		}
+
 		if buildoptions.IsDebugMode {
 			fmt.Printf("compiling op=%s: %s\n", op.Kind(), compiler)
 		}
+		if d, indexes, offset, operands, results, ok := memoryBuiltinInstruction(op, ir.Memories); ok {
+			if err := compiler.compileCallMemoryBuiltinFunction(d, indexes, offset, operands, results...); err != nil {
+				return nil, fmt.Errorf("operation %s: %w", op.Kind().String(), err)
+			}
+			continue
+		}
+
 		var err error
 		switch o := op.(type) {
+		case *wazeroir.OperationConsumeFuel:
+			err = compiler.compileConsumeFuel(o)
+		case *wazeroir.OperationCoverBlock:
+			err = compiler.compileCoverBlock(&coverage.Counts[o.Block])
+		case *wazeroir.OperationCatch, *wazeroir.OperationCatchAll:
+			// Catch ops are already handled ^^.
+		case *wazeroir.OperationTry:
+			err = compiler.compileTry(o)
+		case *wazeroir.OperationThrow:
+			err = compiler.compileThrow(o)
+		case *wazeroir.OperationRethrow:
+			err = compiler.compileRethrow()
 		case *wazeroir.OperationLabel:
 			// Label op is already handled ^^.
+			if _, ok := ir.LoopLabels[o.Label.String()]; ok && ensureTermination {
+				// All backward branches target loop headers, so checking here ensures infinite loops terminate.
+				err = compiler.compileCheckContextDone()
+			}
 		case *wazeroir.OperationUnreachable:
 			err = compiler.compileUnreachable()
 		case *wazeroir.OperationBr:
@@ -886,6 +1601,10 @@ func compileWasmFunction(_ wasm.Features, ir *wazeroir.CompilationResult) (*code
 			err = compiler.compileCall(o)
 		case *wazeroir.OperationCallIndirect:
 			err = compiler.compileCallIndirect(o)
+		case *wazeroir.OperationTailCall:
+			err = compiler.compileTailCall(o)
+		case *wazeroir.OperationTailCallIndirect:
+			err = compiler.compileTailCallIndirect(o)
 		case *wazeroir.OperationDrop:
 			err = compiler.compileDrop(o)
 		case *wazeroir.OperationSelect:
@@ -915,7 +1634,7 @@ func compileWasmFunction(_ wasm.Features, ir *wazeroir.CompilationResult) (*code
 		case *wazeroir.OperationStore32:
 			err = compiler.compileStore32(o)
 		case *wazeroir.OperationMemorySize:
-			err = compiler.compileMemorySize()
+			err = compiler.compileMemorySize(o)
 		case *wazeroir.OperationMemoryGrow:
 			err = compiler.compileMemoryGrow()
 		case *wazeroir.OperationConstI32:
@@ -1094,6 +1813,40 @@ func compileWasmFunction(_ wasm.Features, ir *wazeroir.CompilationResult) (*code
 			err = compiler.compileV128Shl(o)
 		case *wazeroir.OperationV128Cmp:
 			err = compiler.compileV128Cmp(o)
+		case *wazeroir.OperationV128RelaxedTrunc:
+			err = compiler.compileV128RelaxedTrunc(o)
+		case *wazeroir.OperationV128RelaxedMadd:
+			err = compiler.compileV128RelaxedMadd(o)
+		case *wazeroir.OperationV128RelaxedMin:
+			err = compiler.compileV128RelaxedMin(o)
+		case *wazeroir.OperationV128RelaxedMax:
+			err = compiler.compileV128RelaxedMax(o)
+		case *wazeroir.OperationV128RelaxedQ15mulr:
+			err = compiler.compileV128RelaxedQ15mulr(o)
+		case *wazeroir.OperationV128RelaxedDot:
+			err = compiler.compileV128RelaxedDot(o)
+		case *wazeroir.OperationV128RelaxedDotAdd:
+			err = compiler.compileV128RelaxedDotAdd(o)
+		case *wazeroir.OperationAtomicLoad:
+			err = compiler.compileAtomicLoad(o)
+		case *wazeroir.OperationAtomicStore:
+			err = compiler.compileAtomicStore(o)
+		case *wazeroir.OperationAtomicRMW:
+			err = compiler.compileAtomicRMW(o)
+		case *wazeroir.OperationAtomicCmpxchg:
+			err = compiler.compileAtomicCmpxchg(o)
+		case *wazeroir.OperationMemoryWait:
+			size := uint32(4)
+			if o.Type == wazeroir.UnsignedInt64 {
+				size = 8
+			}
+			err = compiler.compileCallAtomicBuiltinFunction(
+				atomicDescriptor(o.Kind(), o.Type, size, o.Arg), 3, true)
+		case *wazeroir.OperationMemoryNotify:
+			err = compiler.compileCallAtomicBuiltinFunction(
+				atomicDescriptor(o.Kind(), 0, 4, o.Arg), 2, true)
+		case *wazeroir.OperationAtomicFence:
+			err = compiler.compileAtomicFence()
 		default:
 			err = errors.New("unsupported")
 		}
@@ -1107,5 +1860,181 @@ func compileWasmFunction(_ wasm.Features, ir *wazeroir.CompilationResult) (*code
 		return nil, fmt.Errorf("failed to compile: %w", err)
 	}
 
-	return &code{codeSegment: c, stackPointerCeil: stackPointerCeil, staticData: staticData}, nil
+	return &code{codeSegment: c, stackPointerCeil: stackPointerCeil, staticData: staticData,
+		instructionOffsets: *compiler.instructionOffsets(), tryBlocks: compiler.tryBlocks(), coverage: coverage}, nil
+}
+
+// memoryBuiltinInstruction returns ok if the operation is executed by builtinFunctionIndexMemory, along with the
+// arguments of compiler.compileCallMemoryBuiltinFunction to execute it. memories are the declarations of the memories
+// of the module. See isBuiltinMemory for which operations are executed there.
+func memoryBuiltinInstruction(op wazeroir.Operation, memories []*wasm.Memory) (d, indexes, offset uint64, operands int, results []runtimeValueType, ok bool) {
+	switch o := op.(type) {
+	case *wazeroir.OperationMemoryWait:
+		if !isBuiltinMemory(o.Arg.MemoryIndex) && !isMemory64(memories, o.Arg.MemoryIndex) {
+			return
+		}
+		size := uint32(4)
+		if o.Type == wazeroir.UnsignedInt64 {
+			size = 8
+		}
+		d = atomicDescriptor(o.Kind(), o.Type, size, o.Arg)
+		return d, uint64(o.Arg.MemoryIndex), o.Arg.Offset, 3, []runtimeValueType{runtimeValueTypeI32}, true
+	case *wazeroir.OperationMemoryNotify:
+		if !isBuiltinMemory(o.Arg.MemoryIndex) && !isMemory64(memories, o.Arg.MemoryIndex) {
+			return
+		}
+		d = atomicDescriptor(o.Kind(), 0, 4, o.Arg)
+		return d, uint64(o.Arg.MemoryIndex), o.Arg.Offset, 2, []runtimeValueType{runtimeValueTypeI32}, true
+	case *wazeroir.OperationMemoryGrow:
+		if !isBuiltinMemory(o.MemoryIndex) {
+			return
+		}
+		d, indexes, results = memoryDescriptor(o.Kind()), uint64(o.MemoryIndex), addressResults(memories, o.MemoryIndex)
+		return d, indexes, 0, 1, results, true
+	case *wazeroir.OperationMemoryInit:
+		if !isBuiltinMemory(o.MemoryIndex) {
+			return
+		}
+		d, indexes = memoryDescriptor(o.Kind()), uint64(o.MemoryIndex)|uint64(o.DataIndex)<<32
+		return d, indexes, 0, 3, nil, true
+	case *wazeroir.OperationMemoryCopy:
+		if !isBuiltinMemory(o.SrcMemoryIndex) && !isBuiltinMemory(o.DstMemoryIndex) {
+			return
+		}
+		d, indexes = memoryDescriptor(o.Kind()), uint64(o.DstMemoryIndex)|uint64(o.SrcMemoryIndex)<<32
+		return d, indexes, 0, 3, nil, true
+	case *wazeroir.OperationMemoryFill:
+		if !isBuiltinMemory(o.MemoryIndex) {
+			return
+		}
+		d, indexes = memoryDescriptor(o.Kind()), uint64(o.MemoryIndex)
+		return d, indexes, 0, 3, nil, true
+	}
+	return
+}
+
+// isBuiltinMemory returns true if memory.grow, the bulk memory instructions, memory.atomic.wait and
+// memory.atomic.notify on the memory at the index are executed by builtinFunctionIndexMemory: memories other than the
+// memory zero. Other instructions are compiled to native instructions, which read the buffer and length of the
+// memories other than the memory zero from their wasm.MemoryInstance via moduleContext.memoriesElement0Address.
+//
+// Note: This is a known limitation. memory.grow, memory.atomic.wait and memory.atomic.notify call into Go for the memory
+// zero as well, but the native code of the bulk memory instructions only supports the memory zero, whose buffer is on
+// the reserved memory register.
+func isBuiltinMemory(index wasm.Index) bool {
+	return index != 0
+}
+
+// isMemory64 returns true if the memory at the index has 64-bit indices, whose addresses are compiled to native 64-bit
+// arithmetic. memory.atomic.wait and memory.atomic.notify on it are executed by builtinFunctionIndexMemory, as their
+// static offset can be over math.MaxUint32, which doesn't fit in the atomicDescriptor.
+func isMemory64(memories []*wasm.Memory, index wasm.Index) bool {
+	return int(index) < len(memories) && memories[index].Is64
+}
+
+// memoryInstanceLengthOffsetOf returns the offset of the length of the wasm.MemoryInstance of the memory, which is
+// memoryInstanceLengthOffset for shared memories, as their buffer is as long as the capacity.
+func memoryInstanceLengthOffsetOf(memory *wasm.Memory) int64 {
+	if memory.Shared {
+		return memoryInstanceLengthOffset
+	}
+	return memoryInstanceBufferLenOffset
+}
+
+// isSharedMemoryZero returns true if the memory zero is shared, in which case another module instance can grow it while
+// a function runs, leaving moduleContext.memorySliceLen stale.
+func isSharedMemoryZero(memories []*wasm.Memory) bool {
+	return len(memories) > 0 && memories[0].Shared
+}
+
+// addressResults returns the result of memory.size or memory.grow, which has the type of the addresses of the memory.
+func addressResults(memories []*wasm.Memory, index wasm.Index) []runtimeValueType {
+	if int(index) < len(memories) && memories[index].Is64 {
+		return []runtimeValueType{runtimeValueTypeI64}
+	}
+	return []runtimeValueType{runtimeValueTypeI32}
+}
+
+func unsignedIntResults(t wazeroir.UnsignedInt) []runtimeValueType {
+	if t == wazeroir.UnsignedInt32 {
+		return []runtimeValueType{runtimeValueTypeI32}
+	}
+	return []runtimeValueType{runtimeValueTypeI64}
+}
+
+// newTryBlocks returns the try blocks of the function, whose offsets are set by compiler.compileTry and others.
+func newTryBlocks(ir *wazeroir.CompilationResult) []*tryBlock {
+	if len(ir.TryBlocks) == 0 {
+		return nil
+	}
+	ret := make([]*tryBlock, len(ir.TryBlocks))
+	for i, t := range ir.TryBlocks {
+		ret[i] = &tryBlock{TryBlock: t}
+	}
+	return ret
+}
+
+// newCatchLocationStack returns the location stack at the beginning of a catch clause of a try block, given the one
+// below it: the handle of the exception is pushed on the stack, followed by its values unless all is true.
+func newCatchLocationStack(ir *wazeroir.CompilationResult, below *runtimeValueLocationStack, tagIndex uint32, all bool) *runtimeValueLocationStack {
+	ret := below.clone()
+	ret.pushRuntimeValueLocationOnStack().valueType = runtimeValueTypeI64
+	if all {
+		return ret
+	}
+	for _, t := range ir.Types[ir.Tags[tagIndex]].Params {
+		loc := ret.pushRuntimeValueLocationOnStack()
+		switch t {
+		case wasm.ValueTypeI32:
+			loc.valueType = runtimeValueTypeI32
+		case wasm.ValueTypeI64, wasm.ValueTypeFuncref, wasm.ValueTypeExternref:
+			loc.valueType = runtimeValueTypeI64
+		case wasm.ValueTypeF32:
+			loc.valueType = runtimeValueTypeF32
+		case wasm.ValueTypeF64:
+			loc.valueType = runtimeValueTypeF64
+		case wasm.ValueTypeV128:
+			loc.valueType = runtimeValueTypeV128Lo
+			hi := ret.pushRuntimeValueLocationOnStack()
+			hi.valueType = runtimeValueTypeV128Hi
+		}
+	}
+	return ret
+}
+
+// add appends the instruction offset of the code beginning at the native offset, which must not be less than the
+// last one added.
+func (m *instructionOffsetMap) add(nativeOffset, instructionOffset uint64) {
+	m.nativeOffsets = append(m.nativeOffsets, nativeOffset)
+	m.instructionOffsets = append(m.instructionOffsets, instructionOffset)
+}
+
+// lookup returns the offset of the Wasm instruction whose code includes the byte before the native offset, or -1 if
+// unknown. The byte before is used as the return address of a call is after the call instruction.
+func (m *instructionOffsetMap) lookup(nativeOffset uint64) int64 {
+	// The index of the first instruction beginning at or after the native offset.
+	i := sort.Search(len(m.nativeOffsets), func(i int) bool { return m.nativeOffsets[i] >= nativeOffset })
+	if i == 0 {
+		return -1
+	}
+	return int64(m.instructionOffsets[i-1])
+}
+
+// instructionOffsetOf returns the offset in the function body of the Wasm instruction in progress in the frame, or
+// -1 if unknown, such as in host functions.
+//
+// * top is true for the innermost frame, which is the one trapped when exitContext.statusCode is a trap. Otherwise,
+//   the frame exited to Go, or called the next frame, at its return address.
+func (ce *callEngine) instructionOffsetOf(frame *callFrame, top bool) int64 {
+	if frame.function.source.Kind != wasm.FunctionKindWasm {
+		return -1
+	} else if top && ce.statusCode.isTrap() {
+		return int64(ce.instructionOffset)
+	}
+	codeSegment := frame.function.parent.codeSegment
+	if frame.returnAddress <= frame.function.codeInitialAddress || // not yet set
+		frame.returnAddress > frame.function.codeInitialAddress+uintptr(len(codeSegment)) {
+		return -1
+	}
+	return frame.function.parent.instructionOffsets.lookup(uint64(frame.returnAddress - frame.function.codeInitialAddress))
 }
@@ -0,0 +1,305 @@
+package compiler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"runtime"
+	"unsafe"
+
+	"github.com/tetratelabs/wazero/internal/compilationcache"
+	"github.com/tetratelabs/wazero/internal/platform"
+	"github.com/tetratelabs/wazero/internal/version"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wazeroir"
+)
+
+// cacheMagic prefixes each cache entry, to avoid reading files that aren't entries.
+const cacheMagic = "WAZERO"
+
+// cacheFormatVersion must be incremented whenever the layout written by serializeCodes changes.
+const cacheFormatVersion byte = 3
+
+// errCorruptedCacheEntry is returned by deserializeCodes when the entry doesn't match its checksum or is truncated.
+//
+// Note: The checksum only detects corruption, such as a partially written file. Tampering is detected by the
+// compilationcache.Cache, which authenticates entries with a key the writer of an entry doesn't have.
+var errCorruptedCacheEntry = errors.New("corrupted compilation cache entry")
+
+// cacheVersion returns what must match for native code compiled by another process to be valid in this one.
+//
+// Besides the wazero version, this includes the CPU features, the settings which change the output of compilation, and
+// the addresses of Go variables embedded in native code, as these change with the binary. Notably, a binary built as a
+// position independent executable places variables at a different address each run, so entries are never re-used.
+//
+// version.DevVersion is that of any revision of the code, so the build ID of the executable is added to it.
+func (e *engine) cacheVersion() string {
+	v := version.GetWazeroVersion()
+	if v == version.DevVersion {
+		v += "+" + version.GetBuildID()
+	}
+	return fmt.Sprintf("%s %s/%s cpu=%s features=%s ensureTermination=%v meterFuel=%v static=%x",
+		v, runtime.GOOS, runtime.GOARCH, platform.CpuFeatures(), e.enabledFeatures, e.ensureTermination, e.meterFuel,
+		staticAddresses)
+}
+
+// cacheKey returns the compilationcache.Key of the codes of the module compiled by this engine.
+func (e *engine) cacheKey(module *wasm.Module) compilationcache.Key {
+	h := sha256.New()
+	h.Write(module.ID[:])
+	h.Write([]byte(e.cacheVersion()))
+	var ret compilationcache.Key
+	copy(ret[:], h.Sum(nil))
+	return ret
+}
+
+// getCodesFromCache returns the codes of the module from engine.cache, or false if there is no valid entry. Invalid
+// entries, such as those from a different wazero version or corrupted on disk, are deleted.
+func (e *engine) getCodesFromCache(module *wasm.Module) (codes []*code, ok bool, err error) {
+	key := e.cacheKey(module)
+	content, ok, err := e.cache.Get(key)
+	if !ok || err != nil {
+		return
+	}
+
+	if codes, err = deserializeCodes(e.cacheVersion(), content); err != nil {
+		// The entry is unusable, so delete it, so that it can be replaced by a valid one.
+		return nil, false, e.cache.Delete(key)
+	}
+
+	for i, c := range codes {
+		// As this uses mmap, we need to munmap on the compiled machine code when it's GCed.
+		e.setFinalizer(c, releaseCode)
+
+		c.indexInModule = wasm.Index(i)
+		c.sourceModule = module
+	}
+	return codes, true, nil
+}
+
+// addCodesToCache adds the codes of the module to engine.cache, unless they can't be relocated.
+func (e *engine) addCodesToCache(module *wasm.Module, codes []*code) error {
+	content, ok := serializeCodes(e.cacheVersion(), codes)
+	if !ok {
+		return nil
+	}
+	return e.cache.Add(e.cacheKey(module), content)
+}
+
+// serializeCodes encodes the codes, so that deserializeCodes can restore them in another process, or returns false
+// if they cannot be relocated.
+//
+// The layout is the following, where integers are little-endian and the checksum is a sha256 of all preceding bytes,
+// which detects corruption, while tampering is detected by the compilationcache.Cache:
+//	magic | formatVersion | len(version) uint32 | version | len(codes) uint32 | code... | checksum
+//
+// Each code is laid out as follows:
+//	stackPointerCeil uint64 | len(codeSegment) uint64 | codeSegment | len(staticData) uint32 | staticData... |
+//	len(instructionOffsets) uint32 | (nativeOffset uint64 | instructionOffset uint64)... | len(tryBlocks) uint32 |
+//	tryBlock...
+//
+// Native code embeds the address of each of its staticData, which is different in each process. So, each staticData
+// is preceded by the offset in the codeSegment to overwrite with its new address:
+//	relocationOffset uint64 | len(data) uint64 | data
+//
+// Each tryBlock is laid out as follows, where Parent and DelegateTarget are int64 and Delegate is 0 or 1:
+//	Parent | StackHeight uint64 | Delegate uint32 | DelegateTarget | start uint64 | end uint64 | len(catches) uint32 |
+//	(handler uint64 | tagIndex uint32 | all uint32)...
+func serializeCodes(version string, codes []*code) ([]byte, bool) {
+	buf := bytes.NewBuffer(nil)
+	buf.WriteString(cacheMagic)
+	buf.WriteByte(cacheFormatVersion)
+	writeUint32(buf, uint32(len(version)))
+	buf.WriteString(version)
+	writeUint32(buf, uint32(len(codes)))
+	for _, c := range codes {
+		writeUint64(buf, c.stackPointerCeil)
+		writeUint64(buf, uint64(len(c.codeSegment)))
+		buf.Write(c.codeSegment)
+		writeUint32(buf, uint32(len(c.staticData)))
+		for _, data := range c.staticData {
+			offset, ok := relocationOffset(c.codeSegment, data)
+			if !ok {
+				return nil, false
+			}
+			writeUint64(buf, offset)
+			writeUint64(buf, uint64(len(data)))
+			buf.Write(data)
+		}
+		offsets := &c.instructionOffsets
+		writeUint32(buf, uint32(len(offsets.nativeOffsets)))
+		for i, nativeOffset := range offsets.nativeOffsets {
+			writeUint64(buf, nativeOffset)
+			writeUint64(buf, offsets.instructionOffsets[i])
+		}
+		writeUint32(buf, uint32(len(c.tryBlocks)))
+		for _, t := range c.tryBlocks {
+			writeUint64(buf, uint64(t.Parent))
+			writeUint64(buf, uint64(t.StackHeight))
+			writeUint32(buf, boolToUint32(t.Delegate))
+			writeUint64(buf, uint64(t.DelegateTarget))
+			writeUint64(buf, t.start)
+			writeUint64(buf, t.end)
+			writeUint32(buf, uint32(len(t.catches)))
+			for _, catch := range t.catches {
+				writeUint64(buf, catch.handler)
+				writeUint32(buf, catch.tagIndex)
+				writeUint32(buf, boolToUint32(catch.all))
+			}
+		}
+	}
+	checksum := sha256.Sum256(buf.Bytes())
+	buf.Write(checksum[:])
+	return buf.Bytes(), true
+}
+
+// relocationOffset returns the offset in the codeSegment where the address of the data is embedded, or false if it
+// isn't embedded exactly once as a 64-bit immediate. Ex. arm64 may encode the address across several instructions.
+func relocationOffset(codeSegment, data []byte) (uint64, bool) {
+	var address [8]byte
+	binary.LittleEndian.PutUint64(address[:], uint64(uintptr(unsafe.Pointer(&data[0]))))
+	offset := bytes.Index(codeSegment, address[:])
+	if offset == -1 || bytes.Index(codeSegment[offset+1:], address[:]) != -1 {
+		return 0, false
+	}
+	return uint64(offset), true
+}
+
+// deserializeCodes decodes the content written by serializeCodes with the same version into executable codes.
+func deserializeCodes(version string, content []byte) (codes []*code, err error) {
+	if len(content) < sha256.Size {
+		return nil, errCorruptedCacheEntry
+	}
+	body, checksum := content[:len(content)-sha256.Size], content[len(content)-sha256.Size:]
+	if actual := sha256.Sum256(body); !bytes.Equal(actual[:], checksum) {
+		return nil, errCorruptedCacheEntry
+	}
+
+	r := &cacheReader{b: body}
+	if magic := r.bytes(uint64(len(cacheMagic))); string(magic) != cacheMagic {
+		return nil, errCorruptedCacheEntry
+	}
+	if formatVersion := r.bytes(1); r.err == nil && formatVersion[0] != cacheFormatVersion {
+		return nil, fmt.Errorf("compilation cache entry has format %d, but expected %d", formatVersion[0], cacheFormatVersion)
+	}
+	if v := string(r.bytes(uint64(r.uint32()))); r.err == nil && v != version {
+		return nil, fmt.Errorf("compilation cache entry has version %q, but expected %q", v, version)
+	}
+
+	defer func() {
+		if err != nil { // release any code already mapped.
+			for _, c := range codes {
+				releaseCode(c)
+			}
+			codes = nil
+		}
+	}()
+
+	codeCount := r.uint32()
+	for i := uint32(0); i < codeCount && r.err == nil; i++ {
+		c := &code{stackPointerCeil: r.uint64()}
+		// Copy as the codeSegment is modified by relocations.
+		codeSegment := append([]byte(nil), r.bytes(r.uint64())...)
+
+		staticDataCount := r.uint32()
+		for j := uint32(0); j < staticDataCount && r.err == nil; j++ {
+			offset := r.uint64()
+			data := append([]byte(nil), r.bytes(r.uint64())...)
+			if r.err != nil {
+				break
+			} else if len(data) == 0 || len(codeSegment) < 8 || offset > uint64(len(codeSegment)-8) {
+				r.err = errCorruptedCacheEntry
+				break
+			}
+			binary.LittleEndian.PutUint64(codeSegment[offset:], uint64(uintptr(unsafe.Pointer(&data[0]))))
+			c.staticData = append(c.staticData, data)
+		}
+		offsetCount := r.uint32()
+		for j := uint32(0); j < offsetCount && r.err == nil; j++ {
+			c.instructionOffsets.add(r.uint64(), r.uint64())
+		}
+		tryBlockCount := r.uint32()
+		for j := uint32(0); j < tryBlockCount && r.err == nil; j++ {
+			t := &tryBlock{TryBlock: &wazeroir.TryBlock{
+				Parent: int(int64(r.uint64())), StackHeight: int(r.uint64()),
+				Delegate: r.uint32() != 0, DelegateTarget: int(int64(r.uint64())),
+			}}
+			t.start, t.end = r.uint64(), r.uint64()
+			catchCount := r.uint32()
+			for k := uint32(0); k < catchCount && r.err == nil; k++ {
+				t.catches = append(t.catches, catchClause{handler: r.uint64(), tagIndex: r.uint32(), all: r.uint32() != 0})
+			}
+			c.tryBlocks = append(c.tryBlocks, t)
+		}
+		if r.err != nil {
+			break
+		}
+
+		if len(codeSegment) == 0 {
+			return codes, errCorruptedCacheEntry
+		}
+		if c.codeSegment, err = platform.MmapCodeSegment(codeSegment); err != nil {
+			return codes, err
+		}
+		codes = append(codes, c)
+	}
+	if r.err == nil && len(r.b) != 0 {
+		r.err = errCorruptedCacheEntry // trailing bytes
+	}
+	return codes, r.err
+}
+
+// cacheReader reads serializeCodes output, failing with errCorruptedCacheEntry if it is truncated.
+type cacheReader struct {
+	b   []byte
+	err error
+}
+
+// bytes returns the next n bytes or nil if there are too few.
+func (r *cacheReader) bytes(n uint64) (ret []byte) {
+	if r.err != nil {
+		return
+	} else if n > uint64(len(r.b)) {
+		r.err = errCorruptedCacheEntry
+		return
+	}
+	ret, r.b = r.b[:n], r.b[n:]
+	return
+}
+
+// uint32 returns the next little-endian uint32 or zero if there are too few bytes.
+func (r *cacheReader) uint32() uint32 {
+	if b := r.bytes(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+// uint64 returns the next little-endian uint64 or zero if there are too few bytes.
+func (r *cacheReader) uint64() uint64 {
+	if b := r.bytes(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+func boolToUint32(b bool) uint32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func writeUint32(buf *bytes.Buffer, v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	buf.Write(b[:])
+}
+
+func writeUint64(buf *bytes.Buffer, v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	buf.Write(b[:])
+}
@@ -0,0 +1,247 @@
+package compiler
+
+import (
+	"crypto/sha256"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/compilationcache"
+	"github.com/tetratelabs/wazero/internal/platform"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/version"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// memoryCache implements compilationcache.Cache in memory for tests.
+type memoryCache map[compilationcache.Key][]byte
+
+// Get implements the same method as documented on compilationcache.Cache.
+func (c memoryCache) Get(key compilationcache.Key) ([]byte, bool, error) {
+	content, ok := c[key]
+	return content, ok, nil
+}
+
+// Add implements the same method as documented on compilationcache.Cache.
+func (c memoryCache) Add(key compilationcache.Key, content []byte) error {
+	c[key] = content
+	return nil
+}
+
+// Delete implements the same method as documented on compilationcache.Cache.
+func (c memoryCache) Delete(key compilationcache.Key) error {
+	delete(c, key)
+	return nil
+}
+
+var (
+	// addModule has a function "add" which returns the sum of its two i32 parameters.
+	addModule = &wasm.Module{
+		ID: sha256.Sum256([]byte("add")),
+		TypeSection: []*wasm.FunctionType{{
+			Params:  []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI32},
+			Results: []wasm.ValueType{wasm.ValueTypeI32}, ParamNumInUint64: 2, ResultNumInUint64: 1,
+		}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1, wasm.OpcodeI32Add, wasm.OpcodeEnd}},
+		},
+		ExportSection: []*wasm.Export{{Name: "add", Type: wasm.ExternTypeFunc, Index: 0}},
+	}
+
+	// brTableModule has a function "br_table" which returns 10, 20 or 30 depending on whether its parameter is 0, 1
+	// or anything else. This requires codeStaticData, which is relocated when restored from the cache.
+	brTableModule = &wasm.Module{
+		ID: sha256.Sum256([]byte("br_table")),
+		TypeSection: []*wasm.FunctionType{{
+			Params:  []wasm.ValueType{wasm.ValueTypeI32},
+			Results: []wasm.ValueType{wasm.ValueTypeI32}, ParamNumInUint64: 1, ResultNumInUint64: 1,
+		}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{
+				wasm.OpcodeBlock, 0x40, wasm.OpcodeBlock, 0x40, wasm.OpcodeBlock, 0x40,
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeBrTable, 2, 0, 1, 2,
+				wasm.OpcodeEnd, wasm.OpcodeI32Const, 10, wasm.OpcodeReturn,
+				wasm.OpcodeEnd, wasm.OpcodeI32Const, 20, wasm.OpcodeReturn,
+				wasm.OpcodeEnd, wasm.OpcodeI32Const, 30, wasm.OpcodeEnd,
+			}},
+		},
+		ExportSection: []*wasm.Export{{Name: "br_table", Type: wasm.ExternTypeFunc, Index: 0}},
+	}
+
+	// tryModule has a function "try" which throws its parameter and returns it plus one when caught. This requires the
+	// try blocks, whose native offsets are restored from the cache.
+	tryModule = &wasm.Module{
+		ID: sha256.Sum256([]byte("try")),
+		TypeSection: []*wasm.FunctionType{
+			{
+				Params:  []wasm.ValueType{wasm.ValueTypeI32},
+				Results: []wasm.ValueType{wasm.ValueTypeI32}, ParamNumInUint64: 1, ResultNumInUint64: 1,
+			},
+			{Params: []wasm.ValueType{wasm.ValueTypeI32}, ParamNumInUint64: 1},
+		},
+		FunctionSection: []wasm.Index{0},
+		TagSection:      []wasm.Index{1},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{
+				wasm.OpcodeTry, 0x7f, wasm.OpcodeLocalGet, 0, wasm.OpcodeThrow, 0,
+				wasm.OpcodeCatch, 0, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Add,
+				wasm.OpcodeEnd, wasm.OpcodeEnd,
+			}},
+		},
+		ExportSection: []*wasm.Export{{Name: "try", Type: wasm.ExternTypeFunc, Index: 0}},
+	}
+)
+
+func TestEngine_CompilationCache(t *testing.T) {
+	tests := []struct {
+		name     string
+		module   *wasm.Module
+		params   [][]uint64
+		expected []uint64
+	}{
+		{
+			name:     "add",
+			module:   addModule,
+			params:   [][]uint64{{1, 2}},
+			expected: []uint64{3},
+		},
+		{
+			name:     "br_table",
+			module:   brTableModule,
+			params:   [][]uint64{{0}, {1}, {2}},
+			expected: []uint64{10, 20, 30},
+		},
+		{
+			name:     "try",
+			module:   tryModule,
+			params:   [][]uint64{{41}},
+			expected: []uint64{42},
+		},
+	}
+	features := wasm.Features20191205 | wasm.FeatureExceptionHandling
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.module == brTableModule && runtime.GOARCH != "amd64" {
+				t.Skip("arm64 encodes the address of codeStaticData across several instructions, so it isn't cached")
+			}
+
+			cache := memoryCache{}
+			e := newEngine(features, wasm.EngineConfig{Cache: cache})
+			require.NoError(t, e.CompileModule(testCtx, tc.module))
+			require.Equal(t, 1, len(cache))
+
+			// Another engine, such as in a new process, restores the codes from the cache.
+			restored := newEngine(features, wasm.EngineConfig{Cache: cache})
+			codes, ok, err := restored.getCodesFromCache(tc.module)
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, 1, len(codes))
+			require.Equal(t, tc.module, codes[0].sourceModule)
+			original, _ := e.getCodes(tc.module)
+			require.Equal(t, original[0].instructionOffsets, codes[0].instructionOffsets)
+			require.Equal(t, original[0].tryBlocks, codes[0].tryBlocks)
+			for _, data := range codes[0].staticData { // Native code embeds the new address of the data.
+				_, ok = relocationOffset(codes[0].codeSegment, data)
+				require.True(t, ok)
+			}
+
+			require.NoError(t, restored.CompileModule(testCtx, tc.module))
+			s, ns := wasm.NewStore(features, restored)
+			mod, err := s.Instantiate(testCtx, ns, tc.module, t.Name(), nil, nil, nil)
+			require.NoError(t, err)
+			defer mod.Close(testCtx)
+
+			fn := mod.ExportedFunction(tc.name)
+			for i, params := range tc.params {
+				results, err := fn.Call(testCtx, params...)
+				require.NoError(t, err)
+				require.Equal(t, tc.expected[i], results[0])
+			}
+		})
+	}
+}
+
+func TestEngine_CompilationCache_Invalid(t *testing.T) {
+	cache := memoryCache{}
+	e := newEngine(wasm.Features20191205, wasm.EngineConfig{Cache: cache})
+	require.NoError(t, e.CompileModule(testCtx, addModule))
+	key := e.cacheKey(addModule)
+	valid := cache[key]
+
+	tests := []struct {
+		name        string
+		content     func() []byte
+		expectedErr string
+	}{
+		{
+			name:        "empty",
+			content:     func() []byte { return nil },
+			expectedErr: "corrupted compilation cache entry",
+		},
+		{
+			name: "corrupted",
+			content: func() []byte {
+				corrupted := append([]byte(nil), valid...)
+				corrupted[len(cacheMagic)+10] ^= 0xff
+				return corrupted
+			},
+			expectedErr: "corrupted compilation cache entry",
+		},
+		{
+			name:        "truncated",
+			content:     func() []byte { return valid[:len(valid)-1] },
+			expectedErr: "corrupted compilation cache entry",
+		},
+		{
+			name: "different version",
+			content: func() []byte {
+				content, ok := serializeCodes("v0.0.1", nil)
+				require.True(t, ok)
+				return content
+			},
+			expectedErr: "compilation cache entry has version \"v0.0.1\", but expected \"" + e.cacheVersion() + "\"",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			content := tc.content()
+			_, err := deserializeCodes(e.cacheVersion(), content)
+			require.EqualError(t, err, tc.expectedErr)
+
+			// The invalid entry is deleted, and replaced by compiling again.
+			cache[key] = content
+			_, ok, err := e.getCodesFromCache(addModule)
+			require.NoError(t, err)
+			require.False(t, ok)
+			_, ok = cache[key]
+			require.False(t, ok)
+		})
+	}
+}
+
+func TestEngine_cacheKey(t *testing.T) {
+	e := newEngine(wasm.Features20191205, wasm.EngineConfig{})
+	key := e.cacheKey(addModule)
+
+	// Different modules have different keys.
+	require.NotEqual(t, key, e.cacheKey(brTableModule))
+
+	// Settings which change the compiled code change the key.
+	require.NotEqual(t, key, newEngine(wasm.Features20220419, wasm.EngineConfig{}).cacheKey(addModule))
+	require.NotEqual(t, key, newEngine(wasm.Features20191205, wasm.EngineConfig{EnsureTermination: true}).cacheKey(addModule))
+	require.NotEqual(t, key, newEngine(wasm.Features20191205, wasm.EngineConfig{MeterFuel: true}).cacheKey(addModule))
+}
+
+func TestEngine_cacheVersion(t *testing.T) {
+	v := newEngine(wasm.Features20191205, wasm.EngineConfig{}).cacheVersion()
+
+	// The tests build wazero itself, so entries are only valid for the same executable.
+	require.True(t, strings.HasPrefix(v, version.DevVersion+"+"+version.GetBuildID()+" "), v)
+	require.Contains(t, v, "cpu="+platform.CpuFeatures()+" ")
+}
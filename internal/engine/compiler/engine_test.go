@@ -40,6 +40,7 @@ func TestCompiler_VerifyOffsetValue(t *testing.T) {
 	require.Equal(t, int(unsafe.Offsetof(ce.typeIDsElement0Address)), callEngineModuleContextTypeIDsElement0AddressOffset)
 	require.Equal(t, int(unsafe.Offsetof(ce.dataInstancesElement0Address)), callEngineModuleContextDataInstancesElement0AddressOffset)
 	require.Equal(t, int(unsafe.Offsetof(ce.elementInstancesElement0Address)), callEngineModuleContextElementInstancesElement0AddressOffset)
+	require.Equal(t, int(unsafe.Offsetof(ce.memoriesElement0Address)), callEngineModuleContextMemoriesElement0AddressOffset)
 
 	// Offsets for callEngine.valueStackContext
 	require.Equal(t, int(unsafe.Offsetof(ce.stackPointer)), callEngineValueStackContextStackPointerOffset)
@@ -48,6 +49,9 @@ func TestCompiler_VerifyOffsetValue(t *testing.T) {
 	// Offsets for callEngine.exitContext.
 	require.Equal(t, int(unsafe.Offsetof(ce.statusCode)), callEngineExitContextnativeCallStatusCodeOffset)
 	require.Equal(t, int(unsafe.Offsetof(ce.builtinFunctionCallIndex)), callEngineExitContextBuiltinFunctionCallAddressOffset)
+	require.Equal(t, int(unsafe.Offsetof(ce.contextCheckCountdown)), callEngineExitContextContextCheckCountdownOffset)
+	require.Equal(t, int(unsafe.Offsetof(ce.fuelRemaining)), callEngineExitContextFuelRemainingOffset)
+	require.Equal(t, int(unsafe.Offsetof(ce.instructionOffset)), callEngineExitContextInstructionOffsetOffset)
 
 	// Size and offsets for callFrame.
 	var frame callFrame
@@ -75,6 +79,7 @@ func TestCompiler_VerifyOffsetValue(t *testing.T) {
 	require.Equal(t, int(unsafe.Offsetof(moduleInstance.TypeIDs)), moduleInstanceTypeIDsOffset)
 	require.Equal(t, int(unsafe.Offsetof(moduleInstance.DataInstances)), moduleInstanceDataInstancesOffset)
 	require.Equal(t, int(unsafe.Offsetof(moduleInstance.ElementInstances)), moduleInstanceElementInstancesOffset)
+	require.Equal(t, int(unsafe.Offsetof(moduleInstance.Memories)), moduleInstanceMemoriesOffset)
 
 	var functionInstance wasm.FunctionInstance
 	require.Equal(t, int(unsafe.Offsetof(functionInstance.TypeID)), functionInstanceTypeIDOffset)
@@ -91,6 +96,10 @@ func TestCompiler_VerifyOffsetValue(t *testing.T) {
 	require.Equal(t, int(unsafe.Offsetof(memoryInstance.Buffer)), memoryInstanceBufferOffset)
 	// "+8" because the slice header is laid out as {Data uintptr, Len int64, Cap int64} on memory.
 	require.Equal(t, int(unsafe.Offsetof(memoryInstance.Buffer)+8), memoryInstanceBufferLenOffset)
+	// The length of shared memories is unexported, so verify it is at the offset via the size of one.
+	sharedMemoryInstance := wasm.NewMemoryInstance(&wasm.Memory{Min: 1, Cap: 2, Max: 2, Shared: true})
+	require.Equal(t, sharedMemoryInstance.Size64(nil),
+		*(*uint64)(unsafe.Pointer(uintptr(unsafe.Pointer(sharedMemoryInstance)) + memoryInstanceLengthOffset)))
 
 	// Offsets for wasm.GlobalInstance
 	var globalInstance wasm.GlobalInstance
@@ -114,7 +123,7 @@ type engineTester struct{}
 
 // NewEngine implements enginetest.EngineTester NewEngine.
 func (e *engineTester) NewEngine(enabledFeatures wasm.Features) wasm.Engine {
-	return newEngine(enabledFeatures)
+	return newEngine(enabledFeatures, wasm.EngineConfig{})
 }
 
 // InitTables implements enginetest.EngineTester InitTables.
@@ -248,6 +257,38 @@ func TestCompiler_CompileModule(t *testing.T) {
 	})
 }
 
+func TestCompiler_Coverage(t *testing.T) {
+	requireSupportedOSArch(t)
+	e := newEngine(wasm.Features20191205, wasm.EngineConfig{})
+	m := &wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection:     []*wasm.Code{{Body: []byte{wasm.OpcodeEnd}}},
+		ID:              wasm.ModuleID{1},
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		require.NoError(t, e.CompileModule(testCtx, m))
+		defer e.DeleteCompiledModule(m)
+		require.Nil(t, e.Coverage(m))
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		e.coverage = true
+		defer func() { e.coverage = false }()
+		require.NoError(t, e.CompileModule(testCtx, m))
+		defer e.DeleteCompiledModule(m)
+
+		coverage := e.Coverage(m)
+		require.Equal(t, []*wasm.FunctionCoverage{{BlockOffsets: []uint64{0}, Counts: []uint64{0}}}, coverage)
+
+		// Codes with listeners count in the same coverage.
+		listenerCodes, err := e.getListenerCodes(m)
+		require.NoError(t, err)
+		require.Same(t, coverage[0], listenerCodes[0].coverage)
+	})
+}
+
 // TestCompiler_Releasecode_Panic tests that an unexpected panic has some identifying information in it.
 func TestCompiler_Releasecode_Panic(t *testing.T) {
 	captured := require.CapturePanic(func() {
@@ -265,7 +306,7 @@ func TestCompiler_Releasecode_Panic(t *testing.T) {
 // See comments on initialValueStackSize and initialCallFrameStackSize.
 func TestCompiler_SliceAllocatedOnHeap(t *testing.T) {
 	enabledFeatures := wasm.Features20191205
-	e := newEngine(enabledFeatures)
+	e := newEngine(enabledFeatures, wasm.EngineConfig{})
 	s, ns := wasm.NewStore(enabledFeatures, e)
 
 	const hostModuleName = "env"
@@ -286,13 +327,13 @@ func TestCompiler_SliceAllocatedOnHeap(t *testing.T) {
 		// Trigger relocation of goroutine stack because at this point we have the majority of
 		// goroutine stack unused after recursive call.
 		runtime.GC()
-	}}, map[string]*wasm.Memory{}, map[string]*wasm.Global{}, enabledFeatures)
+	}}, map[string]*wasm.Memory{}, map[string]*wasm.Table{}, map[string]*wasm.Global{}, enabledFeatures)
 	require.NoError(t, err)
 
 	err = s.Engine.CompileModule(testCtx, hm)
 	require.NoError(t, err)
 
-	_, err = s.Instantiate(testCtx, ns, hm, hostModuleName, nil, nil)
+	_, err = s.Instantiate(testCtx, ns, hm, hostModuleName, nil, nil, nil)
 	require.NoError(t, err)
 
 	const valueStackCorruption = "value_stack_corruption"
@@ -347,7 +388,7 @@ func TestCompiler_SliceAllocatedOnHeap(t *testing.T) {
 	err = s.Engine.CompileModule(testCtx, m)
 	require.NoError(t, err)
 
-	mi, err := s.Instantiate(testCtx, ns, m, t.Name(), nil, nil)
+	mi, err := s.Instantiate(testCtx, ns, m, t.Name(), nil, nil, nil)
 	require.NoError(t, err)
 
 	for _, fnName := range []string{valueStackCorruption, callStackCorruption} {
@@ -363,7 +404,7 @@ func TestCompiler_SliceAllocatedOnHeap(t *testing.T) {
 
 // TODO: move most of this logic to enginetest.go so that there is less drift between interpreter and compiler
 func TestEngine_Cachedcodes(t *testing.T) {
-	e := newEngine(wasm.Features20191205)
+	e := newEngine(wasm.Features20191205, wasm.EngineConfig{})
 	exp := []*code{
 		{codeSegment: []byte{0x0}},
 		{codeSegment: []byte{0x0}},
@@ -383,3 +424,27 @@ func TestEngine_Cachedcodes(t *testing.T) {
 	_, ok = e.getCodes(m)
 	require.False(t, ok)
 }
+
+func TestInstructionOffsetMap_lookup(t *testing.T) {
+	var m instructionOffsetMap
+	m.add(0, 0)
+	m.add(10, 2)
+	m.add(10, 3) // instruction 2 has no native code
+	m.add(20, 5)
+
+	tests := []struct {
+		nativeOffset uint64
+		expected     int64
+	}{
+		{nativeOffset: 0, expected: -1},
+		{nativeOffset: 1, expected: 0},
+		{nativeOffset: 10, expected: 0}, // return address right after the last byte of instruction 0
+		{nativeOffset: 11, expected: 3},
+		{nativeOffset: 20, expected: 3},
+		{nativeOffset: 100, expected: 5},
+	}
+
+	for _, tc := range tests {
+		require.Equal(t, tc.expected, m.lookup(tc.nativeOffset), "native offset %d", tc.nativeOffset)
+	}
+}
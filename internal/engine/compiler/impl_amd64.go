@@ -85,6 +85,28 @@ func init() {
 	float64ForMaximumSigned32bitIntPlusOneAddress = uintptr(unsafe.Pointer(&float64ForMaximumSigned32bitIntPlusOne))
 	float32ForMaximumSigned64bitIntPlusOneAddress = uintptr(unsafe.Pointer(&float32ForMaximumSigned64bitIntPlusOne))
 	float64ForMaximumSigned64bitIntPlusOneAddress = uintptr(unsafe.Pointer(&float64ForMaximumSigned64bitIntPlusOne))
+
+	staticAddresses = []uintptr{
+		zero64BitAddress,
+		minimum32BitSignedIntAddress,
+		maximum32BitSignedIntAddress,
+		maximum32BitUnsignedIntAddress,
+		minimum64BitSignedIntAddress,
+		maximum64BitSignedIntAddress,
+		maximum64BitUnsignedIntAddress,
+		float32SignBitMaskAddress,
+		float32RestBitMaskAddress,
+		float64SignBitMaskAddress,
+		float64RestBitMaskAddress,
+		float32ForMinimumSigned32bitIntegerAddress,
+		float64ForMinimumSigned32bitIntegerAddress,
+		float32ForMinimumSigned64bitIntegerAddress,
+		float64ForMinimumSigned64bitIntegerAddress,
+		float32ForMaximumSigned32bitIntPlusOneAddress,
+		float64ForMaximumSigned32bitIntPlusOneAddress,
+		float32ForMaximumSigned64bitIntPlusOneAddress,
+		float64ForMaximumSigned64bitIntPlusOneAddress,
+	}
 }
 
 var (
@@ -141,15 +163,29 @@ type amd64Compiler struct {
 	// onStackPointerCeilDeterminedCallBack hold a callback which are called when the max stack pointer is determined BEFORE generating native code.
 	onStackPointerCeilDeterminedCallBack func(stackPointerCeil uint64)
 	staticData                           codeStaticData
-}
-
-func newAmd64Compiler(ir *wazeroir.CompilationResult) (compiler, error) {
+	// withListener is true when the preamble and returns call builtin functions to notify the FunctionListener.
+	withListener bool
+	// instructionOffset is the offset in the function body of the Wasm instruction currently compiled.
+	instructionOffset uint64
+	// offsetMap is built during compile. See compiler.instructionOffsets
+	offsetMap instructionOffsetMap
+	// tries is built during compile. See compiler.tryBlocks
+	tries []*tryBlock
+	// tryLocationStacks are index-correlated with tries. Each is the location stack below the try block, or nil if
+	// its body was never reached.
+	tryLocationStacks []*runtimeValueLocationStack
+}
+
+func newAmd64Compiler(ir *wazeroir.CompilationResult, withListener bool) (compiler, error) {
 	c := &amd64Compiler{
-		assembler:     amd64.NewAssemblerImpl(),
-		locationStack: newRuntimeValueLocationStack(),
-		currentLabel:  wazeroir.EntrypointLabel,
-		ir:            ir,
-		labels:        map[string]*amd64LabelInfo{},
+		assembler:         amd64.NewAssemblerImpl(),
+		locationStack:     newRuntimeValueLocationStack(),
+		currentLabel:      wazeroir.EntrypointLabel,
+		ir:                ir,
+		labels:            map[string]*amd64LabelInfo{},
+		withListener:      withListener,
+		tries:             newTryBlocks(ir),
+		tryLocationStacks: make([]*runtimeValueLocationStack, len(ir.TryBlocks)),
 	}
 	return c, nil
 }
@@ -263,6 +299,68 @@ func (c *amd64Compiler) pushFunctionParams() {
 	}
 }
 
+// compileCheckContextDone implements compiler.compileCheckContextDone for the amd64 architecture.
+func (c *amd64Compiler) compileCheckContextDone() error {
+	// Release all the registers, so that the value locations are the same regardless of the builtin function call.
+	c.compileReleaseAllRegistersToStack()
+
+	c.assembler.CompileNoneToMemory(amd64.DECQ, amd64ReservedRegisterForCallEngine, callEngineExitContextContextCheckCountdownOffset)
+
+	// Jump if the countdown hasn't reached zero.
+	jmpIfNotZero := c.assembler.CompileJump(amd64.JNE)
+
+	// Otherwise, we have to make the builtin function call to check the context.
+	if err := c.compileCallBuiltinFunction(builtinFunctionIndexCheckContextDone); err != nil {
+		return err
+	}
+
+	// After the function call, we have to initialize the stack base pointer and memory reserved registers.
+	c.compileReservedStackBasePointerInitialization()
+	c.compileReservedMemoryPointerInitialization()
+
+	c.assembler.SetJumpTargetOnNext(jmpIfNotZero)
+	return nil
+}
+
+// compileConsumeFuel implements compiler.compileConsumeFuel for the amd64 architecture.
+func (c *amd64Compiler) compileConsumeFuel(o *wazeroir.OperationConsumeFuel) error {
+	tmp, err := c.allocateRegister(registerTypeGeneralPurpose)
+	if err != nil {
+		return err
+	}
+
+	c.assembler.CompileMemoryToRegister(amd64.MOVQ, amd64ReservedRegisterForCallEngine, callEngineExitContextFuelRemainingOffset, tmp)
+	// Note: ADDQ is used as SUBQ doesn't support the constant operand. The cost of a basic block fits in 32-bit.
+	c.assembler.CompileConstToRegister(amd64.ADDQ, -int64(o.Cost), tmp)
+	c.assembler.CompileRegisterToMemory(amd64.MOVQ, tmp, amd64ReservedRegisterForCallEngine, callEngineExitContextFuelRemainingOffset)
+
+	// Jump if there is fuel remaining.
+	jmpIfNotNegative := c.assembler.CompileJump(amd64.JPL)
+
+	// Otherwise, exit as the call ran out of fuel.
+	c.compileExitFromNativeCode(nativeCallStatusCodeOutOfFuel)
+
+	c.assembler.SetJumpTargetOnNext(jmpIfNotNegative)
+	c.locationStack.markRegisterUnused(tmp)
+	return nil
+}
+
+// compileCoverBlock implements compiler.compileCoverBlock for the amd64 architecture.
+func (c *amd64Compiler) compileCoverBlock(counter *uint64) error {
+	tmp, err := c.allocateRegister(registerTypeGeneralPurpose)
+	if err != nil {
+		return err
+	}
+
+	// "tmp = counter"
+	c.assembler.CompileConstToRegister(amd64.MOVQ, int64(uintptr(unsafe.Pointer(counter))), tmp)
+	// "*tmp++"
+	c.assembler.CompileNoneToMemory(amd64.INCQ, tmp, 0)
+
+	c.locationStack.markRegisterUnused(tmp)
+	return nil
+}
+
 // compileUnreachable implements compiler.compileUnreachable for the amd64 architecture.
 func (c *amd64Compiler) compileUnreachable() error {
 	c.compileExitFromNativeCode(nativeCallStatusCodeUnreachable)
@@ -680,6 +778,19 @@ func (c *amd64Compiler) assignJumpTarget(labelKey string, jmpInstruction asm.Nod
 	}
 }
 
+// compileInstructionOffset implements compiler.compileInstructionOffset for the amd64 architecture.
+func (c *amd64Compiler) compileInstructionOffset(offset uint64) {
+	c.instructionOffset = offset
+	c.assembler.SetOffsetCallbackOnNext(func(nativeOffset asm.NodeOffsetInBinary) {
+		c.offsetMap.add(nativeOffset, offset)
+	})
+}
+
+// instructionOffsets implements compiler.instructionOffsets for the amd64 architecture.
+func (c *amd64Compiler) instructionOffsets() *instructionOffsetMap {
+	return &c.offsetMap
+}
+
 // compileLabel implements compiler.compileLabel for the amd64 architecture.
 func (c *amd64Compiler) compileLabel(o *wazeroir.OperationLabel) (skipLabel bool) {
 	if buildoptions.IsDebugMode {
@@ -758,27 +869,70 @@ func (c *amd64Compiler) compileCall(o *wazeroir.OperationCall) error {
 
 // compileCallIndirect implements compiler.compileCallIndirect for the amd64 architecture.
 func (c *amd64Compiler) compileCallIndirect(o *wazeroir.OperationCallIndirect) error {
+	targetFunctionAddressRegister, err := c.compileCallIndirectTarget(o.TypeIndex, o.TableIndex)
+	if err != nil {
+		return err
+	}
+
+	targetFunctionType := c.ir.Types[o.TypeIndex]
+	if err = c.compileCallFunctionImpl(0, targetFunctionAddressRegister, targetFunctionType); err != nil {
+		return err
+	}
+
+	// The offset register should be marked as un-used as we consumed in the function call.
+	c.locationStack.markRegisterUnused(targetFunctionAddressRegister)
+
+	// We consumed the function parameters from the stack after call.
+	for i := 0; i < targetFunctionType.ParamNumInUint64; i++ {
+		c.locationStack.pop()
+	}
+
+	// Also, the function results were pushed by the call.
+	for _, t := range targetFunctionType.Results {
+		loc := c.locationStack.pushRuntimeValueLocationOnStack()
+		switch t {
+		case wasm.ValueTypeI32:
+			loc.valueType = runtimeValueTypeI32
+		case wasm.ValueTypeI64, wasm.ValueTypeFuncref, wasm.ValueTypeExternref:
+			loc.valueType = runtimeValueTypeI64
+		case wasm.ValueTypeF32:
+			loc.valueType = runtimeValueTypeF32
+		case wasm.ValueTypeF64:
+			loc.valueType = runtimeValueTypeF64
+		case wasm.ValueTypeV128:
+			loc.valueType = runtimeValueTypeV128Lo
+			hi := c.locationStack.pushRuntimeValueLocationOnStack()
+			hi.valueType = runtimeValueTypeV128Hi
+		}
+	}
+	return nil
+}
+
+// compileCallIndirectTarget adds instructions to pop the offset in the table of the given index, and check the
+// function at it is of the given type, as done by call_indirect. This returns the register holding the address of
+// the function, which is marked used.
+func (c *amd64Compiler) compileCallIndirectTarget(typeIndex, tableIndex uint32) (asm.Register, error) {
 	offset := c.locationStack.pop()
 	if err := c.compileEnsureOnGeneralPurposeRegister(offset); err != nil {
-		return nil
+		return asm.NilRegister, err
 	}
 
 	tmp, err := c.allocateRegister(registerTypeGeneralPurpose)
 	if err != nil {
-		return err
+		return asm.NilRegister, err
 	}
 	c.locationStack.markRegisterUsed(tmp)
 
 	tmp2, err := c.allocateRegister(registerTypeGeneralPurpose)
 	if err != nil {
-		return err
+		return asm.NilRegister, err
 	}
 	c.locationStack.markRegisterUsed(tmp2)
 
 	// Load the address of the target table: tmp = &module.Tables[0]
 	c.assembler.CompileMemoryToRegister(amd64.MOVQ, amd64ReservedRegisterForCallEngine, callEngineModuleContextTablesElement0AddressOffset, tmp)
-	// tmp = &module.Tables[0] + Index*8 = &module.Tables[0] + sizeOf(*TableInstance)*index = module.Tables[o.TableIndex].
-	c.assembler.CompileMemoryToRegister(amd64.MOVQ, tmp, int64(o.TableIndex*8), tmp)
+	// tmp = &module.Tables[0] + Index*8 = &module.Tables[0] + sizeOf(*TableInstance)*index = module.Tables[tableIndex].
+	c.assembler.CompileMemoryToRegister(amd64.MOVQ, tmp, int64(tableIndex*8), tmp)
 
 	// Then, we need to check if the offset doesn't exceed the length of table.
 	c.assembler.CompileMemoryToRegister(amd64.CMPQ, tmp, tableInstanceTableLenOffset, offset.register)
@@ -823,7 +977,7 @@ func (c *amd64Compiler) compileCallIndirect(o *wazeroir.OperationCallIndirect) e
 	c.assembler.CompileMemoryToRegister(amd64.MOVQ,
 		amd64ReservedRegisterForCallEngine, callEngineModuleContextTypeIDsElement0AddressOffset,
 		tmp2)
-	c.assembler.CompileMemoryToRegister(amd64.MOVL, tmp2, int64(o.TypeIndex)*4, tmp2)
+	c.assembler.CompileMemoryToRegister(amd64.MOVL, tmp2, int64(typeIndex)*4, tmp2)
 
 	// Jump if the type matches.
 	c.assembler.CompileMemoryToRegister(amd64.CMPL, tmp, functionInstanceTypeIDOffset, tmp2)
@@ -833,37 +987,48 @@ func (c *amd64Compiler) compileCallIndirect(o *wazeroir.OperationCallIndirect) e
 	c.compileExitFromNativeCode(nativeCallStatusCodeTypeMismatchOnIndirectCall)
 
 	c.assembler.SetJumpTargetOnNext(jumpIfTypeMatch)
-	targetFunctionType := c.ir.Types[o.TypeIndex]
-	if err = c.compileCallFunctionImpl(0, offset.register, targetFunctionType); err != nil {
-		return nil
-	}
 
-	// The offset register should be marked as un-used as we consumed in the function call.
-	c.locationStack.markRegisterUnused(offset.register, tmp, tmp2)
+	// Only the offset register, which now holds the target, remains used.
+	c.locationStack.markRegisterUnused(tmp, tmp2)
+	return offset.register, nil
+}
 
-	// We consumed the function parameters from the stack after call.
-	for i := 0; i < targetFunctionType.ParamNumInUint64; i++ {
-		c.locationStack.pop()
+// compileTailCall implements compiler.compileTailCall for the amd64 architecture.
+func (c *amd64Compiler) compileTailCall(o *wazeroir.OperationTailCall) error {
+	if c.withListener {
+		if err := c.compileCallBuiltinFunction(builtinFunctionIndexFunctionListenerTailCall); err != nil {
+			return err
+		}
+		c.compileReservedStackBasePointerInitialization()
 	}
+	targetType := c.ir.Types[c.ir.Functions[o.FunctionIndex]]
+	return c.compileTailCallFunctionImpl(o.FunctionIndex, asm.NilRegister, targetType)
+}
 
-	// Also, the function results were pushed by the call.
-	for _, t := range targetFunctionType.Results {
-		loc := c.locationStack.pushRuntimeValueLocationOnStack()
-		switch t {
-		case wasm.ValueTypeI32:
-			loc.valueType = runtimeValueTypeI32
-		case wasm.ValueTypeI64, wasm.ValueTypeFuncref, wasm.ValueTypeExternref:
-			loc.valueType = runtimeValueTypeI64
-		case wasm.ValueTypeF32:
-			loc.valueType = runtimeValueTypeF32
-		case wasm.ValueTypeF64:
-			loc.valueType = runtimeValueTypeF64
-		case wasm.ValueTypeV128:
-			loc.valueType = runtimeValueTypeV128Lo
-			hi := c.locationStack.pushRuntimeValueLocationOnStack()
-			hi.valueType = runtimeValueTypeV128Hi
+// compileTailCallIndirect implements compiler.compileTailCallIndirect for the amd64 architecture.
+func (c *amd64Compiler) compileTailCallIndirect(o *wazeroir.OperationTailCallIndirect) error {
+	targetFunctionAddressRegister, err := c.compileCallIndirectTarget(o.TypeIndex, o.TableIndex)
+	if err != nil {
+		return err
+	}
+	if c.withListener {
+		// Keep the target on the stack while calling the builtin function, as it doesn't preserve the registers.
+		c.locationStack.pushRuntimeValueLocationOnRegister(targetFunctionAddressRegister, runtimeValueTypeI64)
+		if err = c.compileCallBuiltinFunction(builtinFunctionIndexFunctionListenerTailCall); err != nil {
+			return err
+		}
+		// After the function call, the stack base pointer register must be initialized to load the target.
+		c.compileReservedStackBasePointerInitialization()
+		target := c.locationStack.pop()
+		if err = c.compileEnsureOnGeneralPurposeRegister(target); err != nil {
+			return err
 		}
+		targetFunctionAddressRegister = target.register
+	}
+	if err = c.compileTailCallFunctionImpl(0, targetFunctionAddressRegister, c.ir.Types[o.TypeIndex]); err != nil {
+		return err
 	}
+	c.locationStack.markRegisterUnused(targetFunctionAddressRegister)
 	return nil
 }
 
@@ -3137,7 +3302,7 @@ func (c *amd64Compiler) compileLoad(o *wazeroir.OperationLoad) error {
 		vt = runtimeValueTypeF64
 	}
 
-	reg, err := c.compileMemoryAccessCeilSetup(o.Arg.Offset, targetSizeInBytes)
+	buffer, reg, err := c.compileMemoryAccessCeilSetup(o.Arg, targetSizeInBytes)
 	if err != nil {
 		return err
 	}
@@ -3147,7 +3312,7 @@ func (c *amd64Compiler) compileLoad(o *wazeroir.OperationLoad) error {
 		// and store the value to the int register.
 		c.assembler.CompileMemoryWithIndexToRegister(movInst,
 			// we access memory as memory.Buffer[ceil-targetSizeInBytes: ceil].
-			amd64ReservedRegisterForMemory, -targetSizeInBytes, reg, 1,
+			buffer, -targetSizeInBytes, reg, 1,
 			reg)
 		c.pushRuntimeValueLocationOnRegister(reg, vt)
 	} else {
@@ -3158,7 +3323,7 @@ func (c *amd64Compiler) compileLoad(o *wazeroir.OperationLoad) error {
 		}
 		c.assembler.CompileMemoryWithIndexToRegister(movInst,
 			// we access memory as memory.Buffer[ceil-targetSizeInBytes: ceil].
-			amd64ReservedRegisterForMemory, -targetSizeInBytes, reg, 1,
+			buffer, -targetSizeInBytes, reg, 1,
 			floatReg)
 		c.pushRuntimeValueLocationOnRegister(floatReg, vt)
 		// We no longer need the int register so mark it unused.
@@ -3170,7 +3335,7 @@ func (c *amd64Compiler) compileLoad(o *wazeroir.OperationLoad) error {
 // compileLoad8 implements compiler.compileLoad8 for the amd64 architecture.
 func (c *amd64Compiler) compileLoad8(o *wazeroir.OperationLoad8) error {
 	const targetSizeInBytes = 1
-	reg, err := c.compileMemoryAccessCeilSetup(o.Arg.Offset, targetSizeInBytes)
+	buffer, reg, err := c.compileMemoryAccessCeilSetup(o.Arg, targetSizeInBytes)
 	if err != nil {
 		return err
 	}
@@ -3196,7 +3361,7 @@ func (c *amd64Compiler) compileLoad8(o *wazeroir.OperationLoad8) error {
 
 	c.assembler.CompileMemoryWithIndexToRegister(inst,
 		// we access memory as memory.Buffer[ceil-targetSizeInBytes: ceil].
-		amd64ReservedRegisterForMemory, -targetSizeInBytes, reg, 1,
+		buffer, -targetSizeInBytes, reg, 1,
 		reg)
 
 	c.pushRuntimeValueLocationOnRegister(reg, vt)
@@ -3206,7 +3371,7 @@ func (c *amd64Compiler) compileLoad8(o *wazeroir.OperationLoad8) error {
 // compileLoad16 implements compiler.compileLoad16 for the amd64 architecture.
 func (c *amd64Compiler) compileLoad16(o *wazeroir.OperationLoad16) error {
 	const targetSizeInBytes = 16 / 8
-	reg, err := c.compileMemoryAccessCeilSetup(o.Arg.Offset, targetSizeInBytes)
+	buffer, reg, err := c.compileMemoryAccessCeilSetup(o.Arg, targetSizeInBytes)
 	if err != nil {
 		return err
 	}
@@ -3232,7 +3397,7 @@ func (c *amd64Compiler) compileLoad16(o *wazeroir.OperationLoad16) error {
 
 	c.assembler.CompileMemoryWithIndexToRegister(inst,
 		// we access memory as memory.Buffer[ceil-targetSizeInBytes: ceil].
-		amd64ReservedRegisterForMemory, -targetSizeInBytes, reg, 1,
+		buffer, -targetSizeInBytes, reg, 1,
 		reg)
 
 	c.pushRuntimeValueLocationOnRegister(reg, vt)
@@ -3242,7 +3407,7 @@ func (c *amd64Compiler) compileLoad16(o *wazeroir.OperationLoad16) error {
 // compileLoad32 implements compiler.compileLoad32 for the amd64 architecture.
 func (c *amd64Compiler) compileLoad32(o *wazeroir.OperationLoad32) error {
 	const targetSizeInBytes = 32 / 8
-	reg, err := c.compileMemoryAccessCeilSetup(o.Arg.Offset, targetSizeInBytes)
+	buffer, reg, err := c.compileMemoryAccessCeilSetup(o.Arg, targetSizeInBytes)
 	if err != nil {
 		return err
 	}
@@ -3256,47 +3421,106 @@ func (c *amd64Compiler) compileLoad32(o *wazeroir.OperationLoad32) error {
 	}
 	c.assembler.CompileMemoryWithIndexToRegister(inst,
 		// We access memory as memory.Buffer[ceil-targetSizeInBytes: ceil].
-		amd64ReservedRegisterForMemory, -targetSizeInBytes, reg, 1,
+		buffer, -targetSizeInBytes, reg, 1,
 		reg)
 	c.pushRuntimeValueLocationOnRegister(reg, runtimeValueTypeI64)
 	return nil
 }
 
-// compileMemoryAccessCeilSetup pops the top value from the stack (called "base"), stores "base + offsetArg + targetSizeInBytes"
+// compileMemoryAccessCeilSetup pops the top value from the stack (called "base"), stores "base + arg.Offset + targetSizeInBytes"
 // into a register, and returns the stored register. We call the result "ceil" because we access the memory
-// as memory.Buffer[ceil-targetSizeInBytes: ceil].
+// as memory.Buffer[ceil-targetSizeInBytes: ceil]. The returned buffer register holds &memory.Buffer[0] of the memory
+// at arg.MemoryIndex, which is amd64ReservedRegisterForMemory for the memory zero. Both registers are marked unused.
 //
 // Note: this also emits the instructions to check the out of bounds memory access.
 // In other words, if the ceil exceeds the memory size, the code exits with nativeCallStatusCodeMemoryOutOfBounds status.
-func (c *amd64Compiler) compileMemoryAccessCeilSetup(offsetArg uint32, targetSizeInBytes int64) (asm.Register, error) {
+func (c *amd64Compiler) compileMemoryAccessCeilSetup(arg *wazeroir.MemoryArg, targetSizeInBytes int64) (buffer, ceil asm.Register, err error) {
 	base := c.locationStack.pop()
-	if err := c.compileEnsureOnGeneralPurposeRegister(base); err != nil {
-		return 0, err
+	if err = c.compileEnsureOnGeneralPurposeRegister(base); err != nil {
+		return
 	}
 
-	result := base.register
-	if offsetConst := int64(offsetArg) + targetSizeInBytes; offsetConst <= math.MaxUint32 {
-		c.assembler.CompileConstToRegister(amd64.ADDQ, offsetConst, result)
-	} else {
+	buffer, ceil = amd64ReservedRegisterForMemory, base.register
+	is64 := isMemory64(c.ir.Memories, arg.MemoryIndex)
+	if offsetConst := arg.Offset + uint64(targetSizeInBytes); offsetConst < arg.Offset || (!is64 && offsetConst > math.MaxUint32) {
 		// If the offset const is too large, we exit with nativeCallStatusCodeMemoryOutOfBounds.
 		c.compileExitFromNativeCode(nativeCallStatusCodeMemoryOutOfBounds)
-		return result, nil
+		c.locationStack.markRegisterUnused(ceil)
+		return
+	} else if offsetConst <= math.MaxInt32 {
+		c.assembler.CompileConstToRegister(amd64.ADDQ, int64(offsetConst), ceil)
+	} else {
+		// The immediate of ADDQ is sign-extended from 32 bits, so larger offset consts are added from a register.
+		var tmp asm.Register
+		if tmp, err = c.allocateRegister(registerTypeGeneralPurpose); err != nil {
+			return
+		}
+		c.assembler.CompileConstToRegister(amd64.MOVQ, int64(offsetConst), tmp)
+		c.assembler.CompileRegisterToRegister(amd64.ADDQ, tmp, ceil)
+	}
+
+	if is64 {
+		c.compileExitOnCarry(nativeCallStatusCodeMemoryOutOfBounds)
+	}
+
+	if arg.MemoryIndex != 0 {
+		buffer, err = c.compileMemoryInstanceBoundsCheck(arg.MemoryIndex, ceil)
+		c.locationStack.markRegisterUnused(ceil)
+		return
 	}
 
 	// Now we compare the value with the memory length which is held by callEngine.
 	c.assembler.CompileMemoryToRegister(amd64.CMPQ,
-		amd64ReservedRegisterForCallEngine, callEngineModuleContextMemorySliceLenOffset, result)
+		amd64ReservedRegisterForCallEngine, callEngineModuleContextMemorySliceLenOffset, ceil)
 
 	// Jump if the value is within the memory length.
 	okJmp := c.assembler.CompileJump(amd64.JCC)
+	reloadedOKJmps := c.compileReloadSharedMemoryLength(ceil)
 
 	// Otherwise, we exit the function with out of bounds status code.
 	c.compileExitFromNativeCode(nativeCallStatusCodeMemoryOutOfBounds)
 
+	c.assembler.SetJumpTargetOnNext(append(reloadedOKJmps, okJmp)...)
+
+	c.locationStack.markRegisterUnused(ceil)
+	return
+}
+
+// compileExitOnCarry exits with the status code if the previous addition carried, which is out of bounds when adding
+// to the i64 addresses of memories with 64-bit indices.
+func (c *amd64Compiler) compileExitOnCarry(status nativeCallStatusCode) {
+	noCarryJmp := c.assembler.CompileJump(amd64.JCC)
+	c.compileExitFromNativeCode(status)
+	c.assembler.SetJumpTargetOnNext(noCarryJmp)
+}
+
+// compileMemoryInstanceBoundsCheck exits with nativeCallStatusCodeMemoryOutOfBounds if the ceil exceeds the length of
+// the memory at the index, which isn't the memory zero, and returns the register unused which holds &memory.Buffer[0].
+//
+// Note: Unlike the memory zero, the length and buffer are read from the wasm.MemoryInstance on each access, so they
+// are never stale, even if the memory is grown by a builtin function call or another module instance.
+func (c *amd64Compiler) compileMemoryInstanceBoundsCheck(index wasm.Index, ceil asm.Register) (asm.Register, error) {
+	buffer, err := c.allocateRegister(registerTypeGeneralPurpose)
+	if err != nil {
+		return 0, err
+	}
+	c.compileLoadMemoryInstance(index, buffer)
+
+	c.assembler.CompileMemoryToRegister(amd64.CMPQ, buffer, memoryInstanceLengthOffsetOf(c.ir.Memories[index]), ceil)
+	okJmp := c.assembler.CompileJump(amd64.JCC)
+	c.compileExitFromNativeCode(nativeCallStatusCodeMemoryOutOfBounds)
 	c.assembler.SetJumpTargetOnNext(okJmp)
 
-	c.locationStack.markRegisterUnused(result)
-	return result, nil
+	c.assembler.CompileMemoryToRegister(amd64.MOVQ, buffer, memoryInstanceBufferOffset, buffer)
+	return buffer, nil
+}
+
+// compileLoadMemoryInstance adds instructions to load the *wasm.MemoryInstance at the index into the register, i.e.
+// "reg = ModuleInstance.Memories[index]".
+func (c *amd64Compiler) compileLoadMemoryInstance(index wasm.Index, reg asm.Register) {
+	c.assembler.CompileMemoryToRegister(amd64.MOVQ,
+		amd64ReservedRegisterForCallEngine, callEngineModuleContextMemoriesElement0AddressOffset, reg)
+	c.assembler.CompileMemoryToRegister(amd64.MOVQ, reg, int64(index)*8, reg)
 }
 
 // compileStore implements compiler.compileStore for the amd64 architecture.
@@ -3311,38 +3535,38 @@ func (c *amd64Compiler) compileStore(o *wazeroir.OperationStore) error {
 		movInst = amd64.MOVQ
 		targetSizeInByte = 64 / 8
 	}
-	return c.compileStoreImpl(o.Arg.Offset, movInst, targetSizeInByte)
+	return c.compileStoreImpl(o.Arg, movInst, targetSizeInByte)
 }
 
 // compileStore8 implements compiler.compileStore8 for the amd64 architecture.
 func (c *amd64Compiler) compileStore8(o *wazeroir.OperationStore8) error {
-	return c.compileStoreImpl(o.Arg.Offset, amd64.MOVB, 1)
+	return c.compileStoreImpl(o.Arg, amd64.MOVB, 1)
 }
 
 // compileStore32 implements compiler.compileStore32 for the amd64 architecture.
 func (c *amd64Compiler) compileStore16(o *wazeroir.OperationStore16) error {
-	return c.compileStoreImpl(o.Arg.Offset, amd64.MOVW, 16/8)
+	return c.compileStoreImpl(o.Arg, amd64.MOVW, 16/8)
 }
 
 // compileStore32 implements compiler.compileStore32 for the amd64 architecture.
 func (c *amd64Compiler) compileStore32(o *wazeroir.OperationStore32) error {
-	return c.compileStoreImpl(o.Arg.Offset, amd64.MOVL, 32/8)
+	return c.compileStoreImpl(o.Arg, amd64.MOVL, 32/8)
 }
 
-func (c *amd64Compiler) compileStoreImpl(offsetConst uint32, inst asm.Instruction, targetSizeInBytes int64) error {
+func (c *amd64Compiler) compileStoreImpl(arg *wazeroir.MemoryArg, inst asm.Instruction, targetSizeInBytes int64) error {
 	val := c.locationStack.pop()
 	if err := c.compileEnsureOnGeneralPurposeRegister(val); err != nil {
 		return err
 	}
 
-	reg, err := c.compileMemoryAccessCeilSetup(offsetConst, targetSizeInBytes)
+	buffer, reg, err := c.compileMemoryAccessCeilSetup(arg, targetSizeInBytes)
 	if err != nil {
-		return nil
+		return err
 	}
 
 	c.assembler.CompileRegisterToMemoryWithIndex(
 		inst, val.register,
-		amd64ReservedRegisterForMemory, -targetSizeInBytes, reg, 1,
+		buffer, -targetSizeInBytes, reg, 1,
 	)
 
 	// We no longer need both the value and base registers.
@@ -3367,16 +3591,24 @@ func (c *amd64Compiler) compileMemoryGrow() error {
 }
 
 // compileMemorySize implements compiler.compileMemorySize for the amd64 architecture.
-func (c *amd64Compiler) compileMemorySize() error {
+func (c *amd64Compiler) compileMemorySize(o *wazeroir.OperationMemorySize) error {
 	c.maybeCompileMoveTopConditionalToFreeGeneralPurposeRegister()
 
 	reg, err := c.allocateRegister(registerTypeGeneralPurpose)
 	if err != nil {
 		return err
 	}
-	loc := c.pushRuntimeValueLocationOnRegister(reg, runtimeValueTypeI32)
+	loc := c.pushRuntimeValueLocationOnRegister(reg, addressResults(c.ir.Memories, o.MemoryIndex)[0])
 
-	c.assembler.CompileMemoryToRegister(amd64.MOVQ, amd64ReservedRegisterForCallEngine, callEngineModuleContextMemorySliceLenOffset, loc.register)
+	if o.MemoryIndex != 0 {
+		c.compileLoadMemoryInstance(o.MemoryIndex, loc.register)
+		c.assembler.CompileMemoryToRegister(amd64.MOVQ,
+			loc.register, memoryInstanceLengthOffsetOf(c.ir.Memories[o.MemoryIndex]), loc.register)
+	} else if isSharedMemoryZero(c.ir.Memories) {
+		c.compileLoadSharedMemoryLength(loc.register)
+	} else {
+		c.assembler.CompileMemoryToRegister(amd64.MOVQ, amd64ReservedRegisterForCallEngine, callEngineModuleContextMemorySliceLenOffset, loc.register)
+	}
 
 	// WebAssembly's memory.size returns the page size (65536) of memory region.
 	// That is equivalent to divide the len of memory slice by 65536 and
@@ -3436,6 +3668,9 @@ func (c *amd64Compiler) compileInitImpl(isTable bool, index, tableIndex uint32)
 	c.assembler.CompileRegisterToRegister(amd64.ADDQ, copySize.register, sourceOffset.register)
 	// destinationOffset += size.
 	c.assembler.CompileRegisterToRegister(amd64.ADDQ, copySize.register, destinationOffset.register)
+	if !isTable && isMemory64(c.ir.Memories, 0) {
+		c.compileExitOnCarry(outOfBoundsErrorStatus)
+	}
 
 	// Check instance bounds and if exceeds the length, exit with out of bounds error.
 	c.assembler.CompileMemoryToRegister(amd64.CMPQ,
@@ -3459,8 +3694,12 @@ func (c *amd64Compiler) compileInitImpl(isTable bool, index, tableIndex uint32)
 	}
 
 	destinationBoundOKJump := c.assembler.CompileJump(amd64.JCC)
+	var reloadedOKJmps []asm.Node
+	if !isTable {
+		reloadedOKJmps = c.compileReloadSharedMemoryLength(destinationOffset.register)
+	}
 	c.compileExitFromNativeCode(outOfBoundsErrorStatus)
-	c.assembler.SetJumpTargetOnNext(destinationBoundOKJump)
+	c.assembler.SetJumpTargetOnNext(append(reloadedOKJmps, destinationBoundOKJump)...)
 
 	// Otherwise, ready to copy the value from source to destination.
 	//
@@ -3586,10 +3825,17 @@ func (c *amd64Compiler) compileCopyImpl(isTable bool, srcTableIndex, dstTableInd
 		return err
 	}
 
+	is64 := !isTable && isMemory64(c.ir.Memories, 0)
 	// sourceOffset += size.
 	c.assembler.CompileRegisterToRegister(amd64.ADDQ, copySize.register, sourceOffset.register)
+	if is64 {
+		c.compileExitOnCarry(outOfBoundsErrorStatus)
+	}
 	// destinationOffset += size.
 	c.assembler.CompileRegisterToRegister(amd64.ADDQ, copySize.register, destinationOffset.register)
+	if is64 {
+		c.compileExitOnCarry(outOfBoundsErrorStatus)
+	}
 
 	// Check source bounds and if exceeds the length, exit with out of bounds error.
 	if isTable {
@@ -3603,8 +3849,12 @@ func (c *amd64Compiler) compileCopyImpl(isTable bool, srcTableIndex, dstTableInd
 			sourceOffset.register)
 	}
 	sourceBoundOKJump := c.assembler.CompileJump(amd64.JCC)
+	var reloadedOKJmps []asm.Node
+	if !isTable {
+		reloadedOKJmps = c.compileReloadSharedMemoryLength(sourceOffset.register)
+	}
 	c.compileExitFromNativeCode(outOfBoundsErrorStatus)
-	c.assembler.SetJumpTargetOnNext(sourceBoundOKJump)
+	c.assembler.SetJumpTargetOnNext(append(reloadedOKJmps, sourceBoundOKJump)...)
 
 	// Check destination bounds and if exceeds the length, exit with out of bounds error.
 	if isTable {
@@ -3618,8 +3868,11 @@ func (c *amd64Compiler) compileCopyImpl(isTable bool, srcTableIndex, dstTableInd
 			destinationOffset.register)
 	}
 	destinationBoundOKJump := c.assembler.CompileJump(amd64.JCC)
+	if !isTable {
+		reloadedOKJmps = c.compileReloadSharedMemoryLength(destinationOffset.register)
+	}
 	c.compileExitFromNativeCode(outOfBoundsErrorStatus)
-	c.assembler.SetJumpTargetOnNext(destinationBoundOKJump)
+	c.assembler.SetJumpTargetOnNext(append(reloadedOKJmps, destinationBoundOKJump)...)
 
 	// Otherwise, ready to copy the value from source to destination.
 	//
@@ -3760,6 +4013,9 @@ func (c *amd64Compiler) compileFillImpl(isTable bool, tableIndex uint32) error {
 
 	// destinationOffset += size.
 	c.assembler.CompileRegisterToRegister(amd64.ADDQ, copySize.register, destinationOffset.register)
+	if !isTable && isMemory64(c.ir.Memories, 0) {
+		c.compileExitOnCarry(nativeCallStatusCodeMemoryOutOfBounds)
+	}
 
 	// Check destination bounds and if exceeds the length, exit with out of bounds error.
 	if isTable {
@@ -3782,12 +4038,14 @@ func (c *amd64Compiler) compileFillImpl(isTable bool, tableIndex uint32) error {
 			destinationOffset.register)
 	}
 	destinationBoundOKJump := c.assembler.CompileJump(amd64.JCC)
+	var reloadedOKJmps []asm.Node
 	if isTable {
 		c.compileExitFromNativeCode(nativeCallStatusCodeInvalidTableAccess)
 	} else {
+		reloadedOKJmps = c.compileReloadSharedMemoryLength(destinationOffset.register)
 		c.compileExitFromNativeCode(nativeCallStatusCodeMemoryOutOfBounds)
 	}
-	c.assembler.SetJumpTargetOnNext(destinationBoundOKJump)
+	c.assembler.SetJumpTargetOnNext(append(reloadedOKJmps, destinationBoundOKJump)...)
 
 	// Otherwise, ready to copy the value from source to destination.
 	//
@@ -4000,6 +4258,390 @@ func (c *amd64Compiler) compileTableGrow(o *wazeroir.OperationTableGrow) error {
 	return nil
 }
 
+// compileCallAtomicBuiltinFunction implements compiler.compileCallAtomicBuiltinFunction for the amd64 architecture.
+func (c *amd64Compiler) compileCallAtomicBuiltinFunction(descriptor uint64, operands int, hasResult bool) error {
+	c.maybeCompileMoveTopConditionalToFreeGeneralPurposeRegister()
+
+	if err := c.compileConstI64(&wazeroir.OperationConstI64{Value: descriptor}); err != nil {
+		return err
+	}
+
+	if err := c.compileCallBuiltinFunction(builtinFunctionIndexAtomic); err != nil {
+		return err
+	}
+
+	// The builtin function consumes the operands and the descriptor.
+	for i := 0; i < operands+1; i++ {
+		c.locationStack.pop()
+	}
+	if hasResult {
+		c.locationStack.pushRuntimeValueLocationOnStack()
+	}
+
+	// After the function call, we have to initialize the stack base pointer and memory reserved registers.
+	c.compileReservedStackBasePointerInitialization()
+	c.compileReservedMemoryPointerInitialization()
+	return nil
+}
+
+// compileAtomicLoad implements compiler.compileAtomicLoad for the amd64 architecture.
+func (c *amd64Compiler) compileAtomicLoad(o *wazeroir.OperationAtomicLoad) error {
+	size := int64(o.Size)
+	buffer, ceil, err := c.compileAtomicMemoryAccessCeilSetup(o.Arg, size)
+	if err != nil {
+		return err
+	}
+
+	// Aligned loads are atomic on amd64, and ordered with the atomic stores which use XCHG.
+	c.assembler.CompileMemoryWithIndexToRegister(atomicLoadInstructionOfSize(size),
+		buffer, -size, ceil, 1,
+		ceil)
+
+	c.locationStack.markRegisterUnused(buffer, ceil)
+	c.pushRuntimeValueLocationOnRegister(ceil, unsignedIntResults(o.Type)[0])
+	return nil
+}
+
+// compileAtomicStore implements compiler.compileAtomicStore for the amd64 architecture.
+func (c *amd64Compiler) compileAtomicStore(o *wazeroir.OperationAtomicStore) error {
+	val := c.locationStack.pop()
+	if err := c.compileEnsureOnGeneralPurposeRegister(val); err != nil {
+		return err
+	}
+
+	size := int64(o.Size)
+	buffer, ceil, err := c.compileAtomicMemoryAccessCeilSetup(o.Arg, size)
+	if err != nil {
+		return err
+	}
+
+	// XCHG is used instead of MOV, as it is a full barrier which makes the store sequentially consistent.
+	c.assembler.CompileRegisterToMemoryWithIndex(atomicInstructionOfSize(amd64.XCHGB, size), val.register,
+		buffer, -size, ceil, 1)
+
+	c.locationStack.releaseRegister(val)
+	c.locationStack.markRegisterUnused(buffer, ceil)
+	return nil
+}
+
+// compileAtomicRMW implements compiler.compileAtomicRMW for the amd64 architecture.
+func (c *amd64Compiler) compileAtomicRMW(o *wazeroir.OperationAtomicRMW) error {
+	switch o.Op {
+	case wazeroir.AtomicRMWOpAnd, wazeroir.AtomicRMWOpOr, wazeroir.AtomicRMWOpXor:
+		return c.compileAtomicRMWLoop(o)
+	}
+
+	val := c.locationStack.pop()
+	if err := c.compileEnsureOnGeneralPurposeRegister(val); err != nil {
+		return err
+	}
+
+	size := int64(o.Size)
+	buffer, ceil, err := c.compileAtomicMemoryAccessCeilSetup(o.Arg, size)
+	if err != nil {
+		return err
+	}
+
+	// The instructions below replace the value register with the old value of the memory.
+	switch o.Op {
+	case wazeroir.AtomicRMWOpAdd:
+		c.assembler.CompileRegisterToMemoryWithIndex(atomicInstructionOfSize(amd64.LOCKXADDB, size), val.register,
+			buffer, -size, ceil, 1)
+	case wazeroir.AtomicRMWOpSub:
+		// Subtracting the value is adding its negation, as the result is wrapped.
+		c.assembler.CompileNoneToRegister(amd64.NEGQ, val.register)
+		c.assembler.CompileRegisterToMemoryWithIndex(atomicInstructionOfSize(amd64.LOCKXADDB, size), val.register,
+			buffer, -size, ceil, 1)
+	case wazeroir.AtomicRMWOpXchg:
+		c.assembler.CompileRegisterToMemoryWithIndex(atomicInstructionOfSize(amd64.XCHGB, size), val.register,
+			buffer, -size, ceil, 1)
+	}
+	c.compileZeroExtendAtomicResult(val.register, size)
+
+	c.locationStack.markRegisterUnused(buffer, ceil)
+	c.locationStack.markRegisterUnused(val.register)
+	c.pushRuntimeValueLocationOnRegister(val.register, unsignedIntResults(o.Type)[0])
+	return nil
+}
+
+// compileAtomicRMWLoop compiles the atomic read-modify-write instructions which don't have an amd64 counterpart
+// returning the old value, as a loop replacing the value with LOCK CMPXCHG until no other write happened in between.
+func (c *amd64Compiler) compileAtomicRMWLoop(o *wazeroir.OperationAtomicRMW) error {
+	const oldValueRegister = amd64.RegAX // CMPXCHG compares with and loads into AX.
+
+	c.maybeCompileMoveTopConditionalToFreeGeneralPurposeRegister()
+
+	// Ensures that the previous value on AX is saved to memory, and that the operands aren't placed on it.
+	c.onValueReleaseRegisterToStack(oldValueRegister)
+	c.locationStack.markRegisterUsed(oldValueRegister)
+
+	val := c.locationStack.pop()
+	if err := c.compileEnsureOnGeneralPurposeRegister(val); err != nil {
+		return err
+	}
+
+	size := int64(o.Size)
+	buffer, ceil, err := c.compileAtomicMemoryAccessCeilSetup(o.Arg, size)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := c.allocateRegister(registerTypeGeneralPurpose)
+	if err != nil {
+		return err
+	}
+
+	var inst asm.Instruction
+	switch o.Op {
+	case wazeroir.AtomicRMWOpAnd:
+		inst = amd64.ANDQ
+	case wazeroir.AtomicRMWOpOr:
+		inst = amd64.ORQ
+	case wazeroir.AtomicRMWOpXor:
+		inst = amd64.XORQ
+	}
+
+	// Load the old value, which is also reloaded by CMPXCHG if it fails.
+	c.assembler.CompileMemoryWithIndexToRegister(atomicLoadInstructionOfSize(size),
+		buffer, -size, ceil, 1,
+		oldValueRegister)
+
+	beginLoop := c.assembler.CompileStandAlone(amd64.NOP)
+	c.assembler.CompileRegisterToRegister(amd64.MOVQ, oldValueRegister, tmp)
+	c.assembler.CompileRegisterToRegister(inst, val.register, tmp)
+	c.assembler.CompileRegisterToMemoryWithIndex(atomicInstructionOfSize(amd64.LOCKCMPXCHGB, size), tmp,
+		buffer, -size, ceil, 1)
+	c.assembler.CompileJump(amd64.JNE).AssignJumpTarget(beginLoop)
+	c.compileZeroExtendAtomicResult(oldValueRegister, size)
+
+	c.locationStack.releaseRegister(val)
+	c.locationStack.markRegisterUnused(buffer, ceil, oldValueRegister)
+	c.pushRuntimeValueLocationOnRegister(oldValueRegister, unsignedIntResults(o.Type)[0])
+	return nil
+}
+
+// compileAtomicCmpxchg implements compiler.compileAtomicCmpxchg for the amd64 architecture.
+func (c *amd64Compiler) compileAtomicCmpxchg(o *wazeroir.OperationAtomicCmpxchg) error {
+	const expectedRegister = amd64.RegAX // CMPXCHG compares with and loads into AX.
+
+	c.maybeCompileMoveTopConditionalToFreeGeneralPurposeRegister()
+
+	// Ensures that the previous value on AX is saved to memory, and that the other operands aren't placed on it.
+	c.onValueReleaseRegisterToStack(expectedRegister)
+	c.locationStack.markRegisterUsed(expectedRegister)
+
+	replacement := c.locationStack.pop()
+	if err := c.compileEnsureOnGeneralPurposeRegister(replacement); err != nil {
+		return err
+	}
+
+	expected := c.locationStack.pop()
+	if err := c.compileEnsureOnGeneralPurposeRegister(expected); err != nil {
+		return err
+	}
+	// Only the lower size bytes are compared by CMPXCHG, so the expected value doesn't have to be wrapped.
+	c.assembler.CompileRegisterToRegister(amd64.MOVQ, expected.register, expectedRegister)
+	c.locationStack.releaseRegister(expected)
+
+	size := int64(o.Size)
+	buffer, ceil, err := c.compileAtomicMemoryAccessCeilSetup(o.Arg, size)
+	if err != nil {
+		return err
+	}
+
+	// AX holds the old value after this, whether it was replaced or not.
+	c.assembler.CompileRegisterToMemoryWithIndex(atomicInstructionOfSize(amd64.LOCKCMPXCHGB, size), replacement.register,
+		buffer, -size, ceil, 1)
+	c.compileZeroExtendAtomicResult(expectedRegister, size)
+
+	c.locationStack.releaseRegister(replacement)
+	c.locationStack.markRegisterUnused(buffer, ceil, expectedRegister)
+	c.pushRuntimeValueLocationOnRegister(expectedRegister, unsignedIntResults(o.Type)[0])
+	return nil
+}
+
+// compileAtomicFence implements compiler.compileAtomicFence for the amd64 architecture.
+func (c *amd64Compiler) compileAtomicFence() error {
+	c.assembler.CompileStandAlone(amd64.MFENCE)
+	return nil
+}
+
+// compileAtomicMemoryAccessCeilSetup is the same as compileMemoryAccessCeilSetup, but also exits with
+// nativeCallStatusCodeUnalignedAtomic if the accessed address isn't a multiple of the size, and the returned registers
+// are marked used.
+func (c *amd64Compiler) compileAtomicMemoryAccessCeilSetup(arg *wazeroir.MemoryArg, size int64) (buffer, ceil asm.Register, err error) {
+	buffer, ceil, err = c.compileMemoryAccessCeilSetup(arg, size)
+	if err != nil {
+		return
+	}
+	c.locationStack.markRegisterUsed(buffer, ceil)
+	if size == 1 {
+		return
+	}
+
+	tmp, err := c.allocateRegister(registerTypeGeneralPurpose)
+	if err != nil {
+		return
+	}
+
+	// The size is a power of two, so the ceil is aligned when the address is.
+	c.assembler.CompileRegisterToRegister(amd64.MOVQ, ceil, tmp)
+	c.assembler.CompileConstToRegister(amd64.ANDQ, size-1, tmp)
+	okJmp := c.assembler.CompileJump(amd64.JEQ)
+	c.compileExitFromNativeCode(nativeCallStatusCodeUnalignedAtomic)
+	c.assembler.SetJumpTargetOnNext(okJmp)
+	return
+}
+
+// compileZeroExtendAtomicResult zero-extends the lower size bytes of the register, which are the result of an atomic
+// instruction.
+func (c *amd64Compiler) compileZeroExtendAtomicResult(reg asm.Register, size int64) {
+	switch size {
+	case 1:
+		c.assembler.CompileRegisterToRegister(amd64.MOVBLZX, reg, reg)
+	case 2:
+		c.assembler.CompileRegisterToRegister(amd64.MOVWLZX, reg, reg)
+	case 4:
+		c.assembler.CompileRegisterToRegister(amd64.MOVL, reg, reg)
+	}
+}
+
+// atomicInstructionOfSize returns the variant of the atomic instruction for a single byte, such as amd64.XCHGB,
+// which accesses the given size in bytes.
+func atomicInstructionOfSize(inst asm.Instruction, size int64) asm.Instruction {
+	switch size {
+	case 2:
+		return inst + 1
+	case 4:
+		return inst + 2
+	case 8:
+		return inst + 3
+	}
+	return inst
+}
+
+// atomicLoadInstructionOfSize returns the instruction zero-extending the given size in bytes loaded from memory.
+func atomicLoadInstructionOfSize(size int64) asm.Instruction {
+	switch size {
+	case 1:
+		return amd64.MOVBQZX
+	case 2:
+		return amd64.MOVWQZX
+	case 4:
+		return amd64.MOVLQZX
+	}
+	return amd64.MOVQ
+}
+
+// compileCallMemoryBuiltinFunction implements compiler.compileCallMemoryBuiltinFunction for the amd64 architecture.
+func (c *amd64Compiler) compileCallMemoryBuiltinFunction(descriptor, memoryIndexes, offset uint64, operands int, results ...runtimeValueType) error {
+	c.maybeCompileMoveTopConditionalToFreeGeneralPurposeRegister()
+
+	for _, v := range []uint64{descriptor, memoryIndexes, offset} {
+		if err := c.compileConstI64(&wazeroir.OperationConstI64{Value: v}); err != nil {
+			return err
+		}
+	}
+
+	if err := c.compileCallBuiltinFunction(builtinFunctionIndexMemory); err != nil {
+		return err
+	}
+
+	// The builtin function consumes the operands, the descriptor, the memory indexes and the offset.
+	for i := 0; i < operands+3; i++ {
+		c.locationStack.pop()
+	}
+	for _, t := range results {
+		c.locationStack.pushRuntimeValueLocationOnStack().valueType = t
+	}
+
+	// After the function call, we have to initialize the stack base pointer and memory reserved registers.
+	c.compileReservedStackBasePointerInitialization()
+	c.compileReservedMemoryPointerInitialization()
+	return nil
+}
+
+// compileTry implements compiler.compileTry for the amd64 architecture.
+func (c *amd64Compiler) compileTry(o *wazeroir.OperationTry) error {
+	c.compileReleaseAllRegistersToStack()
+	below := c.locationStack.clone()
+	below.sp = uint64(c.tries[o.Index].StackHeight)
+	c.tryLocationStacks[o.Index] = below
+
+	t := c.tries[o.Index]
+	c.assembler.SetOffsetCallbackOnNext(func(offset asm.NodeOffsetInBinary) {
+		t.start = uint64(offset)
+	})
+	c.assembler.CompileStandAlone(amd64.NOP)
+	return nil
+}
+
+// compileTryEnd implements compiler.compileTryEnd for the amd64 architecture.
+func (c *amd64Compiler) compileTryEnd(o *wazeroir.OperationTryEnd) {
+	if c.tryLocationStacks[o.Index] == nil {
+		return // The body was never reached.
+	}
+	t := c.tries[o.Index]
+	c.assembler.SetOffsetCallbackOnNext(func(offset asm.NodeOffsetInBinary) {
+		t.end = uint64(offset)
+	})
+	c.assembler.CompileStandAlone(amd64.NOP)
+}
+
+// compileCatch implements compiler.compileCatch for the amd64 architecture.
+func (c *amd64Compiler) compileCatch(tryIndex uint32, tagIndex uint32, all bool) (skipThisCatch bool, err error) {
+	below := c.tryLocationStacks[tryIndex]
+	if below == nil {
+		return true, nil
+	}
+	c.setLocationStack(newCatchLocationStack(c.ir, below, tagIndex, all))
+
+	t := c.tries[tryIndex]
+	i := len(t.catches)
+	t.catches = append(t.catches, catchClause{tagIndex: tagIndex, all: all})
+	c.assembler.SetOffsetCallbackOnNext(func(offset asm.NodeOffsetInBinary) {
+		t.catches[i].handler = uint64(offset)
+	})
+	c.assembler.CompileStandAlone(amd64.NOP)
+
+	// The catch clause is entered by callEngine.throw with nativecall, so initialize the module context and the
+	// reserved registers like the preamble.
+	if err = c.compileModuleContextInitialization(); err != nil {
+		return
+	}
+	c.compileReservedStackBasePointerInitialization()
+	c.compileReservedMemoryPointerInitialization()
+	return
+}
+
+// compileThrow implements compiler.compileThrow for the amd64 architecture.
+func (c *amd64Compiler) compileThrow(o *wazeroir.OperationThrow) error {
+	c.maybeCompileMoveTopConditionalToFreeGeneralPurposeRegister()
+
+	if err := c.compileConstI64(&wazeroir.OperationConstI64{Value: uint64(o.TagIndex)}); err != nil {
+		return err
+	}
+	if err := c.compileCallBuiltinFunction(builtinFunctionIndexThrow); err != nil {
+		return err
+	}
+	// The builtin function never returns here, but the return address must be an instruction.
+	return c.compileUnreachable()
+}
+
+// compileRethrow implements compiler.compileRethrow for the amd64 architecture.
+func (c *amd64Compiler) compileRethrow() error {
+	if err := c.compileCallBuiltinFunction(builtinFunctionIndexRethrow); err != nil {
+		return err
+	}
+	// The builtin function never returns here, but the return address must be an instruction.
+	return c.compileUnreachable()
+}
+
+// tryBlocks implements compiler.tryBlocks for the amd64 architecture.
+func (c *amd64Compiler) tryBlocks() []*tryBlock {
+	return c.tries
+}
+
 // compileTableSize implements compiler.compileTableSize for the amd64 architecture.
 func (c *amd64Compiler) compileTableSize(o *wazeroir.OperationTableSize) error {
 	result, err := c.allocateRegister(registerTypeGeneralPurpose)
@@ -4456,6 +5098,70 @@ func (c *amd64Compiler) compileCallFunctionImpl(index wasm.Index, functionAddres
 	return nil
 }
 
+// compileTailCallFunctionImpl adds instructions to replace the function of the current call frame with the one whose
+// address equals either the index parameter or the value on functionAddressRegister, and jump into it.
+//
+// As the parameters are the only values on the stack, the target function begins at the current stack base pointer.
+// It returns to the caller of the current function, as the return address is in the frame below.
+func (c *amd64Compiler) compileTailCallFunctionImpl(index wasm.Index, functionAddressRegister asm.Register, functype *wasm.FunctionType) error {
+	// Release all the registers as our calling convention requires the caller-save.
+	c.compileReleaseAllRegistersToStack()
+
+	if c.locationStack.sp != uint64(functype.ParamNumInUint64) {
+		return fmt.Errorf("BUG: the parameters must be the only values on the stack for a tail call")
+	}
+
+	// Obtain the temporary registers to be used in the followings.
+	freeRegs, found := c.locationStack.takeFreeRegisters(registerTypeGeneralPurpose, 2)
+	if !found {
+		return fmt.Errorf("could not find enough free registers")
+	}
+	c.locationStack.markRegisterUsed(freeRegs...)
+	callFrameAddressRegister, targetFunctionAddressRegister := freeRegs[0], freeRegs[1]
+
+	// "callFrameAddressRegister = &callFrameStack[callFrameStackPointer]", the top address above the current frame.
+	c.assembler.CompileMemoryToRegister(amd64.MOVQ,
+		amd64ReservedRegisterForCallEngine, callEngineGlobalContextCallFrameStackPointerOffset,
+		callFrameAddressRegister)
+	c.assembler.CompileConstToRegister(amd64.SHLQ, int64(callFrameDataSizeMostSignificantSetBit), callFrameAddressRegister)
+	c.assembler.CompileMemoryToRegister(amd64.ADDQ,
+		amd64ReservedRegisterForCallEngine, callEngineGlobalContextCallFrameStackElement0AddressOffset,
+		callFrameAddressRegister)
+
+	if isNilRegister(functionAddressRegister) {
+		// "targetFunctionAddressRegister = callEngine.functions[index]"
+		c.assembler.CompileMemoryToRegister(amd64.MOVQ, amd64ReservedRegisterForCallEngine,
+			callEngineModuleContextFunctionsElement0AddressOffset, targetFunctionAddressRegister)
+		c.assembler.CompileMemoryToRegister(amd64.MOVQ, targetFunctionAddressRegister, int64(index)*8,
+			targetFunctionAddressRegister)
+	} else {
+		targetFunctionAddressRegister = functionAddressRegister
+	}
+
+	// Replace the function of the current frame, which is BELOW the top address.
+	c.assembler.CompileRegisterToMemory(amd64.MOVQ, targetFunctionAddressRegister,
+		callFrameAddressRegister, -(callFrameDataSize - callFrameFunctionOffset))
+
+	if amd64CallingConventionModuleInstanceAddressRegister == targetFunctionAddressRegister {
+		// See the same case in compileCallFunctionImpl.
+		c.assembler.CompileRegisterToRegister(amd64.MOVQ, targetFunctionAddressRegister, callFrameAddressRegister)
+		targetFunctionAddressRegister = callFrameAddressRegister
+	}
+
+	// Jump into the target function with its *wasm.ModuleInstance, like compileCallFunctionImpl.
+	c.assembler.CompileMemoryToRegister(amd64.MOVQ, targetFunctionAddressRegister, functionModuleInstanceAddressOffset,
+		amd64CallingConventionModuleInstanceAddressRegister)
+	c.assembler.CompileJumpToMemory(amd64.JMP, targetFunctionAddressRegister, functionCodeInitialAddressOffset)
+
+	c.locationStack.markRegisterUnused(freeRegs...)
+
+	// The parameters are consumed by the target function, and the following code is unreachable.
+	for i := 0; i < functype.ParamNumInUint64; i++ {
+		c.locationStack.pop()
+	}
+	return nil
+}
+
 // returnFunction adds instructions to return from the current callframe back to the caller's frame.
 // If this is the current one is the origin, we return to the callEngine.execWasmFunction with the Returned status.
 // Otherwise, we jump into the callers' return address stored in callFrame.returnAddress while setting
@@ -4464,6 +5170,13 @@ func (c *amd64Compiler) compileCallFunctionImpl(index wasm.Index, functionAddres
 // Note: this is the counterpart for callFunction, and see the comments there as well
 // to understand how the function calls are achieved.
 func (c *amd64Compiler) compileReturnFunction() error {
+	if c.withListener {
+		// The results are on the top of the stack, which the builtin function passes to FunctionListener.After.
+		if err := c.compileCallBuiltinFunction(builtinFunctionIndexFunctionListenerAfter); err != nil {
+			return err
+		}
+	}
+
 	// Release all the registers as our calling convention requires the caller-save.
 	c.compileReleaseAllRegistersToStack()
 
@@ -4675,6 +5388,9 @@ func (c *amd64Compiler) compileReleaseRegisterToStack(loc *runtimeValueLocation)
 }
 
 func (c *amd64Compiler) compileExitFromNativeCode(status nativeCallStatusCode) {
+	if status.isTrap() {
+		c.assembler.CompileConstToMemory(amd64.MOVQ, int64(c.instructionOffset), amd64ReservedRegisterForCallEngine, callEngineExitContextInstructionOffsetOffset)
+	}
 	c.assembler.CompileConstToMemory(amd64.MOVB, int64(status), amd64ReservedRegisterForCallEngine, callEngineExitContextnativeCallStatusCodeOffset)
 
 	// Write back the cached SP to the actual eng.stackPointer.
@@ -4701,6 +5417,16 @@ func (c *amd64Compiler) compilePreamble() (err error) {
 
 	// Finally, we initialize the reserved memory register based on the module context.
 	c.compileReservedMemoryPointerInitialization()
+
+	if c.withListener {
+		// The params are at the bottom of the stack, which the builtin function passes to FunctionListener.Before.
+		if err = c.compileCallBuiltinFunction(builtinFunctionIndexFunctionListenerBefore); err != nil {
+			return err
+		}
+		// After return, we re-initialize reserved registers just like above.
+		c.compileReservedStackBasePointerInitialization()
+		c.compileReservedMemoryPointerInitialization()
+	}
 	return
 }
 
@@ -4736,6 +5462,32 @@ func (c *amd64Compiler) compileReservedMemoryPointerInitialization() {
 	}
 }
 
+// compileLoadSharedMemoryLength adds instructions to load the length of the shared memory zero from its
+// wasm.MemoryInstance into moduleContext.memorySliceLen and the register, as another module instance may have grown it.
+func (c *amd64Compiler) compileLoadSharedMemoryLength(reg asm.Register) {
+	c.assembler.CompileMemoryToRegister(amd64.MOVQ,
+		amd64ReservedRegisterForCallEngine, callEngineModuleContextModuleInstanceAddressOffset, reg)
+	c.assembler.CompileMemoryToRegister(amd64.MOVQ, reg, moduleInstanceMemoryOffset, reg)
+	c.assembler.CompileMemoryToRegister(amd64.MOVQ, reg, memoryInstanceLengthOffset, reg)
+	c.assembler.CompileRegisterToMemory(amd64.MOVQ, reg,
+		amd64ReservedRegisterForCallEngine, callEngineModuleContextMemorySliceLenOffset)
+}
+
+// compileReloadSharedMemoryLength is called when the ceil register exceeds moduleContext.memorySliceLen. If the memory
+// zero is shared, this reloads the length, and returns the jump taken if the ceil is within it. Otherwise, the length
+// can't be stale, and this returns nil.
+func (c *amd64Compiler) compileReloadSharedMemoryLength(ceil asm.Register) []asm.Node {
+	if !isSharedMemoryZero(c.ir.Memories) {
+		return nil
+	}
+	// Shared memories don't move when growing, so the memory register is free until it is re-initialized.
+	c.compileLoadSharedMemoryLength(amd64ReservedRegisterForMemory)
+	c.compileReservedMemoryPointerInitialization()
+	c.assembler.CompileMemoryToRegister(amd64.CMPQ,
+		amd64ReservedRegisterForCallEngine, callEngineModuleContextMemorySliceLenOffset, ceil)
+	return []asm.Node{c.assembler.CompileJump(amd64.JCC)}
+}
+
 // compileMaybeGrowValueStack adds instructions to check the necessity to grow the value stack,
 // and if so, make the builtin function call to do so. These instructions are called in the function's
 // preamble.
@@ -4806,6 +5558,7 @@ func (c *amd64Compiler) compileModuleContextInitialization() error {
 	// * callEngine.moduleContext.typeIDsElement0Address
 	// * callEngine.moduleContext.dataInstancesElement0Address
 	// * callEngine.moduleContext.elementInstancesElement0Address
+	// * callEngine.moduleContext.memoriesElement0Address
 
 	// Update globalElement0Address.
 	//
@@ -4851,8 +5604,12 @@ func (c *amd64Compiler) compileModuleContextInitialization() error {
 	if c.ir.HasMemory {
 		c.assembler.CompileMemoryToRegister(amd64.MOVQ, amd64CallingConventionModuleInstanceAddressRegister, moduleInstanceMemoryOffset, tmpRegister)
 
-		// Set length.
-		c.assembler.CompileMemoryToRegister(amd64.MOVQ, tmpRegister, memoryInstanceBufferLenOffset, tmpRegister2)
+		// Set length, which isn't the length of the buffer of shared memories.
+		lengthOffset := int64(memoryInstanceBufferLenOffset)
+		if isSharedMemoryZero(c.ir.Memories) {
+			lengthOffset = memoryInstanceLengthOffset
+		}
+		c.assembler.CompileMemoryToRegister(amd64.MOVQ, tmpRegister, lengthOffset, tmpRegister2)
 		c.assembler.CompileRegisterToMemory(amd64.MOVQ, tmpRegister2,
 			amd64ReservedRegisterForCallEngine, callEngineModuleContextMemorySliceLenOffset)
 
@@ -4862,6 +5619,13 @@ func (c *amd64Compiler) compileModuleContextInitialization() error {
 			amd64ReservedRegisterForCallEngine, callEngineModuleContextMemoryElement0AddressOffset)
 	}
 
+	// Update memoriesElement0Address, which is only used to access the memories other than the memory zero.
+	if len(c.ir.Memories) > 1 {
+		c.assembler.CompileMemoryToRegister(amd64.MOVQ, amd64CallingConventionModuleInstanceAddressRegister, moduleInstanceMemoriesOffset, tmpRegister)
+		c.assembler.CompileRegisterToMemory(amd64.MOVQ, tmpRegister,
+			amd64ReservedRegisterForCallEngine, callEngineModuleContextMemoriesElement0AddressOffset)
+	}
+
 	// Update moduleContext.codesElement0Address
 	{
 		// "tmpRegister = [moduleInstanceAddressRegister + moduleInstanceEngineOffset + interfaceDataOffset] (== *moduleEngine)"
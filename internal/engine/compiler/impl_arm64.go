@@ -37,14 +37,28 @@ type arm64Compiler struct {
 	// codeStaticData holds br_table offset tables.
 	// See codeStaticData and arm64Compiler.compileBrTable.
 	staticData codeStaticData
-}
-
-func newArm64Compiler(ir *wazeroir.CompilationResult) (compiler, error) {
+	// withListener is true when the preamble and returns call builtin functions to notify the FunctionListener.
+	withListener bool
+	// instructionOffset is the offset in the function body of the Wasm instruction currently compiled.
+	instructionOffset uint64
+	// offsetMap is built during compile. See compiler.instructionOffsets
+	offsetMap instructionOffsetMap
+	// tries is built during compile. See compiler.tryBlocks
+	tries []*tryBlock
+	// tryLocationStacks are index-correlated with tries. Each is the location stack below the try block, or nil if
+	// its body was never reached.
+	tryLocationStacks []*runtimeValueLocationStack
+}
+
+func newArm64Compiler(ir *wazeroir.CompilationResult, withListener bool) (compiler, error) {
 	return &arm64Compiler{
-		assembler:     arm64.NewAssemblerImpl(arm64ReservedRegisterForTemporary),
-		locationStack: newRuntimeValueLocationStack(),
-		ir:            ir,
-		labels:        map[string]*arm64LabelInfo{},
+		assembler:         arm64.NewAssemblerImpl(arm64ReservedRegisterForTemporary),
+		locationStack:     newRuntimeValueLocationStack(),
+		ir:                ir,
+		labels:            map[string]*arm64LabelInfo{},
+		withListener:      withListener,
+		tries:             newTryBlocks(ir),
+		tryLocationStacks: make([]*runtimeValueLocationStack, len(ir.TryBlocks)),
 	}, nil
 }
 
@@ -89,11 +103,11 @@ var (
 
 const (
 	// arm64CallEngineArchContextCompilerCallReturnAddressOffset is the offset of archContext.nativeCallReturnAddress in callEngine.
-	arm64CallEngineArchContextCompilerCallReturnAddressOffset = 136
+	arm64CallEngineArchContextCompilerCallReturnAddressOffset = 168
 	// arm64CallEngineArchContextMinimum32BitSignedIntOffset is the offset of archContext.minimum32BitSignedIntAddress in callEngine.
-	arm64CallEngineArchContextMinimum32BitSignedIntOffset = 144
+	arm64CallEngineArchContextMinimum32BitSignedIntOffset = 176
 	// arm64CallEngineArchContextMinimum64BitSignedIntOffset is the offset of archContext.minimum64BitSignedIntAddress in callEngine.
-	arm64CallEngineArchContextMinimum64BitSignedIntOffset = 152
+	arm64CallEngineArchContextMinimum64BitSignedIntOffset = 184
 )
 
 func isZeroRegister(r asm.Register) bool {
@@ -228,6 +242,16 @@ func (c *arm64Compiler) compilePreamble() error {
 	c.compileReservedStackBasePointerRegisterInitialization()
 
 	c.compileReservedMemoryRegisterInitialization()
+
+	if c.withListener {
+		// The params are at the bottom of the stack, which the builtin function passes to FunctionListener.Before.
+		if err := c.compileCallGoFunction(nativeCallStatusCodeCallBuiltInFunction, builtinFunctionIndexFunctionListenerBefore); err != nil {
+			return err
+		}
+		// After return, we re-initialize reserved registers just like above.
+		c.compileReservedStackBasePointerRegisterInitialization()
+		c.compileReservedMemoryRegisterInitialization()
+	}
 	return nil
 }
 
@@ -292,6 +316,13 @@ func (c *arm64Compiler) compileMaybeGrowValueStack() error {
 // If the current frame is the bottom, the code goes back to the Go code with nativeCallStatusCodeReturned status.
 // Otherwise, we branch into the caller's return address.
 func (c *arm64Compiler) compileReturnFunction() error {
+	if c.withListener {
+		// The results are on the top of the stack, which the builtin function passes to FunctionListener.After.
+		if err := c.compileCallGoFunction(nativeCallStatusCodeCallBuiltInFunction, builtinFunctionIndexFunctionListenerAfter); err != nil {
+			return err
+		}
+	}
+
 	// Release all the registers as our calling convention requires the caller-save.
 	if err := c.compileReleaseAllRegistersToStack(); err != nil {
 		return err
@@ -394,6 +425,12 @@ func (c *arm64Compiler) compileExitFromNativeCode(status nativeCallStatusCode) {
 	c.assembler.CompileRegisterToMemory(arm64.MOVD, arm64ReservedRegisterForTemporary, arm64ReservedRegisterForCallEngine,
 		callEngineValueStackContextStackPointerOffset)
 
+	if status.isTrap() {
+		c.assembler.CompileConstToRegister(arm64.MOVD, int64(c.instructionOffset), arm64ReservedRegisterForTemporary)
+		c.assembler.CompileRegisterToMemory(arm64.MOVD, arm64ReservedRegisterForTemporary, arm64ReservedRegisterForCallEngine,
+			callEngineExitContextInstructionOffsetOffset)
+	}
+
 	if status != 0 {
 		c.assembler.CompileConstToRegister(arm64.MOVW, int64(status), arm64ReservedRegisterForTemporary)
 		c.assembler.CompileRegisterToMemory(arm64.MOVWU, arm64ReservedRegisterForTemporary, arm64ReservedRegisterForCallEngine, callEngineExitContextnativeCallStatusCodeOffset)
@@ -435,6 +472,19 @@ func (c *arm64Compiler) setLocationStack(newStack *runtimeValueLocationStack) {
 	c.locationStack = newStack
 }
 
+// compileInstructionOffset implements compiler.compileInstructionOffset for the arm64 architecture.
+func (c *arm64Compiler) compileInstructionOffset(offset uint64) {
+	c.instructionOffset = offset
+	c.assembler.SetOffsetCallbackOnNext(func(nativeOffset asm.NodeOffsetInBinary) {
+		c.offsetMap.add(nativeOffset, offset)
+	})
+}
+
+// instructionOffsets implements compiler.instructionOffsets for the arm64 architecture.
+func (c *arm64Compiler) instructionOffsets() *instructionOffsetMap {
+	return &c.offsetMap
+}
+
 // arm64Compiler implements compiler.arm64Compiler for the arm64 architecture.
 func (c *arm64Compiler) compileLabel(o *wazeroir.OperationLabel) (skipThisLabel bool) {
 	labelKey := o.Label.String()
@@ -465,6 +515,91 @@ func (c *arm64Compiler) compileLabel(o *wazeroir.OperationLabel) (skipThisLabel
 	return false
 }
 
+// compileCheckContextDone implements compiler.compileCheckContextDone for the arm64 architecture.
+func (c *arm64Compiler) compileCheckContextDone() error {
+	// Release all the registers, so that the value locations are the same regardless of the builtin function call.
+	if err := c.compileReleaseAllRegistersToStack(); err != nil {
+		return err
+	}
+
+	// "tmp = ce.contextCheckCountdown - 1"
+	c.assembler.CompileMemoryToRegister(arm64.MOVD,
+		arm64ReservedRegisterForCallEngine, callEngineExitContextContextCheckCountdownOffset,
+		arm64ReservedRegisterForTemporary)
+	c.assembler.CompileConstToRegister(arm64.SUBS, 1, arm64ReservedRegisterForTemporary)
+	// "ce.contextCheckCountdown = tmp"
+	c.assembler.CompileRegisterToMemory(arm64.MOVD,
+		arm64ReservedRegisterForTemporary,
+		arm64ReservedRegisterForCallEngine, callEngineExitContextContextCheckCountdownOffset)
+
+	// Branch if the countdown hasn't reached zero.
+	brIfNotZero := c.assembler.CompileJump(arm64.BNE)
+
+	// Otherwise, we have to make the builtin function call to check the context.
+	if err := c.compileCallGoFunction(nativeCallStatusCodeCallBuiltInFunction, builtinFunctionIndexCheckContextDone); err != nil {
+		return err
+	}
+
+	// After return, we re-initialize reserved registers just like preamble of functions.
+	c.compileReservedStackBasePointerRegisterInitialization()
+	c.compileReservedMemoryRegisterInitialization()
+
+	c.assembler.SetJumpTargetOnNext(brIfNotZero)
+	return nil
+}
+
+// compileConsumeFuel implements compiler.compileConsumeFuel for the arm64 architecture.
+func (c *arm64Compiler) compileConsumeFuel(o *wazeroir.OperationConsumeFuel) error {
+	// Note: We don't use arm64ReservedRegisterForTemporary as the assembler uses it to subtract large constants.
+	tmp, err := c.allocateRegister(registerTypeGeneralPurpose)
+	if err != nil {
+		return err
+	}
+
+	// "tmp = ce.fuelRemaining - cost"
+	c.assembler.CompileMemoryToRegister(arm64.MOVD,
+		arm64ReservedRegisterForCallEngine, callEngineExitContextFuelRemainingOffset,
+		tmp)
+	c.assembler.CompileConstToRegister(arm64.SUBS, int64(o.Cost), tmp)
+	// "ce.fuelRemaining = tmp"
+	c.assembler.CompileRegisterToMemory(arm64.MOVD,
+		tmp,
+		arm64ReservedRegisterForCallEngine, callEngineExitContextFuelRemainingOffset)
+
+	// Branch if there is fuel remaining.
+	brIfNotNegative := c.assembler.CompileJump(arm64.BPL)
+
+	// Otherwise, exit as the call ran out of fuel.
+	c.compileExitFromNativeCode(nativeCallStatusCodeOutOfFuel)
+
+	c.assembler.SetJumpTargetOnNext(brIfNotNegative)
+	c.markRegisterUnused(tmp)
+	return nil
+}
+
+// compileCoverBlock implements compiler.compileCoverBlock for the arm64 architecture.
+func (c *arm64Compiler) compileCoverBlock(counter *uint64) error {
+	addr, err := c.allocateRegister(registerTypeGeneralPurpose)
+	if err != nil {
+		return err
+	}
+	c.markRegisterUsed(addr)
+	tmp, err := c.allocateRegister(registerTypeGeneralPurpose)
+	if err != nil {
+		return err
+	}
+
+	// "addr = counter"
+	c.assembler.CompileConstToRegister(arm64.MOVD, int64(uintptr(unsafe.Pointer(counter))), addr)
+	// "*addr = *addr + 1"
+	c.assembler.CompileMemoryToRegister(arm64.MOVD, addr, 0, tmp)
+	c.assembler.CompileConstToRegister(arm64.ADD, 1, tmp)
+	c.assembler.CompileRegisterToMemory(arm64.MOVD, tmp, addr, 0)
+
+	c.markRegisterUnused(addr, tmp)
+	return nil
+}
+
 // compileUnreachable implements compiler.compileUnreachable for the arm64 architecture.
 func (c *arm64Compiler) compileUnreachable() error {
 	c.compileExitFromNativeCode(nativeCallStatusCodeUnreachable)
@@ -1145,15 +1280,34 @@ func (c *arm64Compiler) compileCalcCallFrameStackTopAddress(callFrameStackPointe
 
 // compileCallIndirect implements compiler.compileCallIndirect for the arm64 architecture.
 func (c *arm64Compiler) compileCallIndirect(o *wazeroir.OperationCallIndirect) error {
+	targetFunctionAddressRegister, err := c.compileCallIndirectTarget(o.TypeIndex, o.TableIndex)
+	if err != nil {
+		return err
+	}
+
+	targetFunctionType := c.ir.Types[o.TypeIndex]
+	if err := c.compileCallImpl(0, targetFunctionAddressRegister, targetFunctionType); err != nil {
+		return err
+	}
+
+	// The offset register should be marked as un-used as we consumed in the function call.
+	c.markRegisterUnused(targetFunctionAddressRegister)
+	return nil
+}
+
+// compileCallIndirectTarget adds instructions to pop the offset in the table of the given index, and check the
+// function at it is of the given type, as done by call_indirect. This returns the register holding the address of
+// the function, which is marked used.
+func (c *arm64Compiler) compileCallIndirectTarget(typeIndex, tableIndex uint32) (asm.Register, error) {
 	offset := c.locationStack.pop()
 	if err := c.compileEnsureOnGeneralPurposeRegister(offset); err != nil {
-		return err
+		return asm.NilRegister, err
 	}
 
 	if isZeroRegister(offset.register) {
 		reg, err := c.allocateRegister(registerTypeGeneralPurpose)
 		if err != nil {
-			return err
+			return asm.NilRegister, err
 		}
 		offset.setRegister(reg)
 		c.markRegisterUsed(reg)
@@ -1164,13 +1318,13 @@ func (c *arm64Compiler) compileCallIndirect(o *wazeroir.OperationCallIndirect) e
 
 	tmp, err := c.allocateRegister(registerTypeGeneralPurpose)
 	if err != nil {
-		return err
+		return asm.NilRegister, err
 	}
 	c.markRegisterUsed(tmp)
 
 	tmp2, err := c.allocateRegister(registerTypeGeneralPurpose)
 	if err != nil {
-		return err
+		return asm.NilRegister, err
 	}
 	c.markRegisterUsed(tmp2)
 
@@ -1182,7 +1336,7 @@ func (c *arm64Compiler) compileCallIndirect(o *wazeroir.OperationCallIndirect) e
 	)
 	// tmp = [tmp + TableIndex*8] = [&Tables[0] + TableIndex*sizeOf(*tableInstance)] = Tables[tableIndex]
 	c.assembler.CompileMemoryToRegister(arm64.MOVD,
-		tmp, int64(o.TableIndex)*8,
+		tmp, int64(tableIndex)*8,
 		tmp,
 	)
 	// tmp2 = [tmp + tableInstanceTableLenOffset] = len(Tables[tableIndex])
@@ -1240,7 +1394,7 @@ func (c *arm64Compiler) compileCallIndirect(o *wazeroir.OperationCallIndirect) e
 	c.assembler.CompileMemoryToRegister(arm64.MOVD,
 		arm64ReservedRegisterForCallEngine, callEngineModuleContextTypeIDsElement0AddressOffset,
 		tmp2)
-	c.assembler.CompileMemoryToRegister(arm64.MOVWU, tmp2, int64(o.TypeIndex)*4, tmp2)
+	c.assembler.CompileMemoryToRegister(arm64.MOVWU, tmp2, int64(typeIndex)*4, tmp2)
 
 	// Compare these two values, and if they equal, we are ready to make function call.
 	c.assembler.CompileTwoRegistersToNone(arm64.CMPW, tmp, tmp2)
@@ -1249,13 +1403,120 @@ func (c *arm64Compiler) compileCallIndirect(o *wazeroir.OperationCallIndirect) e
 
 	c.assembler.SetJumpTargetOnNext(brIfTypeMatched)
 
-	targetFunctionType := c.ir.Types[o.TypeIndex]
-	if err := c.compileCallImpl(0, offset.register, targetFunctionType); err != nil {
+	// Only the offset register, which now holds the target, remains used.
+	c.markRegisterUnused(tmp, tmp2)
+	return offset.register, nil
+}
+
+// compileTailCall implements compiler.compileTailCall for the arm64 architecture.
+func (c *arm64Compiler) compileTailCall(o *wazeroir.OperationTailCall) error {
+	if c.withListener {
+		if err := c.compileCallGoFunction(nativeCallStatusCodeCallBuiltInFunction, builtinFunctionIndexFunctionListenerTailCall); err != nil {
+			return err
+		}
+		c.compileReservedStackBasePointerRegisterInitialization()
+	}
+	tp := c.ir.Types[c.ir.Functions[o.FunctionIndex]]
+	return c.compileTailCallImpl(o.FunctionIndex, asm.NilRegister, tp)
+}
+
+// compileTailCallIndirect implements compiler.compileTailCallIndirect for the arm64 architecture.
+func (c *arm64Compiler) compileTailCallIndirect(o *wazeroir.OperationTailCallIndirect) error {
+	targetFunctionAddressRegister, err := c.compileCallIndirectTarget(o.TypeIndex, o.TableIndex)
+	if err != nil {
+		return err
+	}
+	if c.withListener {
+		// Keep the target on the stack while calling the builtin function, as it doesn't preserve the registers.
+		c.pushRuntimeValueLocationOnRegister(targetFunctionAddressRegister, runtimeValueTypeI64)
+		if err = c.compileCallGoFunction(nativeCallStatusCodeCallBuiltInFunction, builtinFunctionIndexFunctionListenerTailCall); err != nil {
+			return err
+		}
+		// After the function call, the stack base pointer register must be initialized to load the target.
+		c.compileReservedStackBasePointerRegisterInitialization()
+		target := c.locationStack.pop()
+		if err = c.compileEnsureOnGeneralPurposeRegister(target); err != nil {
+			return err
+		}
+		targetFunctionAddressRegister = target.register
+	}
+	if err = c.compileTailCallImpl(0, targetFunctionAddressRegister, c.ir.Types[o.TypeIndex]); err != nil {
 		return err
 	}
+	c.markRegisterUnused(targetFunctionAddressRegister)
+	return nil
+}
 
-	// The offset register should be marked as un-used as we consumed in the function call.
-	c.markRegisterUnused(offset.register, tmp, tmp2)
+// compileTailCallImpl implements compiler.compileTailCall and compiler.compileTailCallIndirect for the arm64
+// architecture, by replacing the function of the current call frame with the target and jumping into it.
+//
+// As the parameters are the only values on the stack, the target function begins at the current stack base pointer.
+// It returns to the caller of the current function, as the return address is in the frame below.
+func (c *arm64Compiler) compileTailCallImpl(index wasm.Index, targetFunctionAddressRegister asm.Register, functype *wasm.FunctionType) error {
+	// Release all the registers as our calling convention requires the caller-save.
+	if err := c.compileReleaseAllRegistersToStack(); err != nil {
+		return err
+	}
+
+	if c.locationStack.sp != uint64(functype.ParamNumInUint64) {
+		return fmt.Errorf("BUG: the parameters must be the only values on the stack for a tail call")
+	}
+
+	freeRegisters, found := c.locationStack.takeFreeRegisters(registerTypeGeneralPurpose, 3)
+	if !found {
+		return fmt.Errorf("BUG: all registers except indexReg should be free at this point")
+	}
+	c.markRegisterUsed(freeRegisters...)
+
+	// Alias for readability.
+	callFrameStackPointerRegister, callFrameStackTopAddressRegister, targetFunctionRegister :=
+		freeRegisters[0], freeRegisters[1], freeRegisters[2]
+
+	// "callFrameStackTopAddressRegister = &ce.callFrameStack[ce.callFrameStackPointer]", above the current frame.
+	c.assembler.CompileMemoryToRegister(arm64.MOVD,
+		arm64ReservedRegisterForCallEngine, callEngineGlobalContextCallFrameStackPointerOffset,
+		callFrameStackPointerRegister)
+	c.compileCalcCallFrameStackTopAddress(callFrameStackPointerRegister, callFrameStackTopAddressRegister)
+
+	if isNilRegister(targetFunctionAddressRegister) {
+		// "targetFunctionRegister = ce.functions[index]"
+		c.assembler.CompileMemoryToRegister(arm64.MOVD,
+			arm64ReservedRegisterForCallEngine, callEngineModuleContextFunctionsElement0AddressOffset,
+			targetFunctionRegister)
+		c.assembler.CompileMemoryToRegister(arm64.MOVD,
+			targetFunctionRegister, int64(index)*8, // * 8 because the size of *function equals 8 bytes.
+			targetFunctionRegister)
+	} else {
+		targetFunctionRegister = targetFunctionAddressRegister
+	}
+
+	// Replace the function of the current frame, which is BELOW the top address.
+	c.assembler.CompileRegisterToMemory(arm64.MOVD,
+		targetFunctionRegister,
+		callFrameStackTopAddressRegister, -(callFrameDataSize - callFrameFunctionOffset))
+
+	if targetFunctionRegister == arm64CallingConventionModuleInstanceAddressRegister {
+		// See the same case in compileCallImpl.
+		c.assembler.CompileRegisterToRegister(arm64.MOVD, targetFunctionRegister, callFrameStackPointerRegister)
+		targetFunctionRegister = callFrameStackPointerRegister
+	}
+
+	// Br into the target function with its moduleInstance address, like compileCallImpl.
+	c.assembler.CompileMemoryToRegister(arm64.MOVD,
+		targetFunctionRegister, functionModuleInstanceAddressOffset,
+		arm64CallingConventionModuleInstanceAddressRegister,
+	)
+	c.assembler.CompileMemoryToRegister(arm64.MOVD,
+		targetFunctionRegister, functionCodeInitialAddressOffset,
+		callFrameStackTopAddressRegister)
+	c.assembler.CompileJumpToMemory(arm64.B, callFrameStackTopAddressRegister)
+
+	c.markRegisterUnused(freeRegisters...)
+
+	// The parameters are consumed by the target function, and the following code is unreachable.
+	for i := 0; i < functype.ParamNumInUint64; i++ {
+		c.locationStack.pop()
+	}
 	return nil
 }
 
@@ -2663,7 +2924,7 @@ func (c *arm64Compiler) compileLoad(o *wazeroir.OperationLoad) error {
 		targetSizeInBytes = 64 / 8
 		vt = runtimeValueTypeF64
 	}
-	return c.compileLoadImpl(o.Arg.Offset, loadInst, targetSizeInBytes, isFloat, vt)
+	return c.compileLoadImpl(o.Arg, loadInst, targetSizeInBytes, isFloat, vt)
 }
 
 // compileLoad8 implements compiler.compileLoad8 for the arm64 architecture.
@@ -2685,7 +2946,7 @@ func (c *arm64Compiler) compileLoad8(o *wazeroir.OperationLoad8) error {
 		loadInst = arm64.MOVBU
 		vt = runtimeValueTypeI64
 	}
-	return c.compileLoadImpl(o.Arg.Offset, loadInst, 1, false, vt)
+	return c.compileLoadImpl(o.Arg, loadInst, 1, false, vt)
 }
 
 // compileLoad16 implements compiler.compileLoad16 for the arm64 architecture.
@@ -2707,7 +2968,7 @@ func (c *arm64Compiler) compileLoad16(o *wazeroir.OperationLoad16) error {
 		loadInst = arm64.MOVHU
 		vt = runtimeValueTypeI64
 	}
-	return c.compileLoadImpl(o.Arg.Offset, loadInst, 16/8, false, vt)
+	return c.compileLoadImpl(o.Arg, loadInst, 16/8, false, vt)
 }
 
 // compileLoad32 implements compiler.compileLoad32 for the arm64 architecture.
@@ -2718,13 +2979,13 @@ func (c *arm64Compiler) compileLoad32(o *wazeroir.OperationLoad32) error {
 	} else {
 		loadInst = arm64.MOVWU
 	}
-	return c.compileLoadImpl(o.Arg.Offset, loadInst, 32/8, false, runtimeValueTypeI64)
+	return c.compileLoadImpl(o.Arg, loadInst, 32/8, false, runtimeValueTypeI64)
 }
 
 // compileLoadImpl implements compileLoadImpl* variants for arm64 architecture.
-func (c *arm64Compiler) compileLoadImpl(offsetArg uint32, loadInst asm.Instruction,
+func (c *arm64Compiler) compileLoadImpl(arg *wazeroir.MemoryArg, loadInst asm.Instruction,
 	targetSizeInBytes int64, isFloat bool, resultRuntimeValueType runtimeValueType) error {
-	offsetReg, err := c.compileMemoryAccessOffsetSetup(offsetArg, targetSizeInBytes)
+	buffer, offsetReg, err := c.compileMemoryAccessOffsetSetup(arg, targetSizeInBytes)
 	if err != nil {
 		return err
 	}
@@ -2737,11 +2998,11 @@ func (c *arm64Compiler) compileLoadImpl(offsetArg uint32, loadInst asm.Instructi
 		}
 	}
 
-	// "resultRegister = [arm64ReservedRegisterForMemory + offsetReg]"
+	// "resultRegister = [buffer + offsetReg]"
 	// In other words, "resultRegister = memory.Buffer[offset: offset+targetSizeInBytes]"
 	c.assembler.CompileMemoryWithRegisterOffsetToRegister(
 		loadInst,
-		arm64ReservedRegisterForMemory, offsetReg,
+		buffer, offsetReg,
 		resultRegister,
 	)
 
@@ -2767,26 +3028,26 @@ func (c *arm64Compiler) compileStore(o *wazeroir.OperationStore) error {
 		movInst = arm64.FMOVD
 		targetSizeInBytes = 64 / 8
 	}
-	return c.compileStoreImpl(o.Arg.Offset, movInst, targetSizeInBytes)
+	return c.compileStoreImpl(o.Arg, movInst, targetSizeInBytes)
 }
 
 // compileStore8 implements compiler.compileStore8 for the arm64 architecture.
 func (c *arm64Compiler) compileStore8(o *wazeroir.OperationStore8) error {
-	return c.compileStoreImpl(o.Arg.Offset, arm64.MOVB, 1)
+	return c.compileStoreImpl(o.Arg, arm64.MOVB, 1)
 }
 
 // compileStore16 implements compiler.compileStore16 for the arm64 architecture.
 func (c *arm64Compiler) compileStore16(o *wazeroir.OperationStore16) error {
-	return c.compileStoreImpl(o.Arg.Offset, arm64.MOVH, 16/8)
+	return c.compileStoreImpl(o.Arg, arm64.MOVH, 16/8)
 }
 
 // compileStore32 implements compiler.compileStore32 for the arm64 architecture.
 func (c *arm64Compiler) compileStore32(o *wazeroir.OperationStore32) error {
-	return c.compileStoreImpl(o.Arg.Offset, arm64.MOVW, 32/8)
+	return c.compileStoreImpl(o.Arg, arm64.MOVW, 32/8)
 }
 
 // compileStoreImpl implements compleStore* variants for arm64 architecture.
-func (c *arm64Compiler) compileStoreImpl(offsetArg uint32, storeInst asm.Instruction, targetSizeInBytes int64) error {
+func (c *arm64Compiler) compileStoreImpl(arg *wazeroir.MemoryArg, storeInst asm.Instruction, targetSizeInBytes int64) error {
 	val, err := c.popValueOnRegister()
 	if err != nil {
 		return err
@@ -2794,32 +3055,33 @@ func (c *arm64Compiler) compileStoreImpl(offsetArg uint32, storeInst asm.Instruc
 	// Mark temporarily used as compileMemoryAccessOffsetSetup might try allocating register.
 	c.markRegisterUsed(val.register)
 
-	offsetReg, err := c.compileMemoryAccessOffsetSetup(offsetArg, targetSizeInBytes)
+	buffer, offsetReg, err := c.compileMemoryAccessOffsetSetup(arg, targetSizeInBytes)
 	if err != nil {
 		return err
 	}
 
-	// "[arm64ReservedRegisterForMemory + offsetReg] = val.register"
+	// "[buffer + offsetReg] = val.register"
 	// In other words, "memory.Buffer[offset: offset+targetSizeInBytes] = val.register"
 	c.assembler.CompileRegisterToMemoryWithRegisterOffset(
 		storeInst, val.register,
-		arm64ReservedRegisterForMemory, offsetReg,
+		buffer, offsetReg,
 	)
 
 	c.markRegisterUnused(val.register)
 	return nil
 }
 
-// compileMemoryAccessOffsetSetup pops the top value from the stack (called "base"), stores "base + offsetArg + targetSizeInBytes"
+// compileMemoryAccessOffsetSetup pops the top value from the stack (called "base"), stores "base + arg.Offset + targetSizeInBytes"
 // into a register, and returns the stored register. We call the result "offset" because we access the memory
-// as memory.Buffer[offset: offset+targetSizeInBytes].
+// as memory.Buffer[offset: offset+targetSizeInBytes]. The returned buffer register holds &memory.Buffer[0] of the
+// memory at arg.MemoryIndex, which is arm64ReservedRegisterForMemory for the memory zero.
 //
 // Note: this also emits the instructions to check the out of bounds memory access.
 // In other words, if the offset+targetSizeInBytes exceeds the memory size, the code exits with nativeCallStatusCodeMemoryOutOfBounds status.
-func (c *arm64Compiler) compileMemoryAccessOffsetSetup(offsetArg uint32, targetSizeInBytes int64) (offsetRegister asm.Register, err error) {
+func (c *arm64Compiler) compileMemoryAccessOffsetSetup(arg *wazeroir.MemoryArg, targetSizeInBytes int64) (buffer, offsetRegister asm.Register, err error) {
 	base, err := c.popValueOnRegister()
 	if err != nil {
-		return 0, err
+		return
 	}
 
 	offsetRegister = base.register
@@ -2831,32 +3093,92 @@ func (c *arm64Compiler) compileMemoryAccessOffsetSetup(offsetArg uint32, targetS
 		c.assembler.CompileRegisterToRegister(arm64.MOVD, arm64.RegRZR, offsetRegister)
 	}
 
-	if offsetConst := int64(offsetArg) + targetSizeInBytes; offsetConst <= math.MaxUint32 {
-		// "offsetRegister = base + offsetArg + targetSizeInBytes"
-		c.assembler.CompileConstToRegister(arm64.ADD, offsetConst, offsetRegister)
-	} else {
+	buffer = arm64ReservedRegisterForMemory
+	is64 := isMemory64(c.ir.Memories, arg.MemoryIndex)
+	if offsetConst := arg.Offset + uint64(targetSizeInBytes); offsetConst < arg.Offset || (!is64 && offsetConst > math.MaxUint32) {
 		// If the offset const is too large, we exit with nativeCallStatusCodeMemoryOutOfBounds.
 		c.compileExitFromNativeCode(nativeCallStatusCodeMemoryOutOfBounds)
 		return
+	} else if is64 {
+		// "offsetRegister = base + arg.Offset + targetSizeInBytes", which is out of bounds if it carries, as the base
+		// of memories with 64-bit indices is an i64.
+		c.assembler.CompileConstToRegister(arm64.ADDS, int64(offsetConst), offsetRegister)
+		c.compileExitOnCarry(nativeCallStatusCodeMemoryOutOfBounds)
+	} else {
+		// "offsetRegister = base + arg.Offset + targetSizeInBytes"
+		c.assembler.CompileConstToRegister(arm64.ADD, int64(offsetConst), offsetRegister)
 	}
 
-	// "arm64ReservedRegisterForTemporary = len(memory.Buffer)"
-	c.assembler.CompileMemoryToRegister(arm64.MOVD,
-		arm64ReservedRegisterForCallEngine, callEngineModuleContextMemorySliceLenOffset,
-		arm64ReservedRegisterForTemporary)
+	var reloadedBoundsOK []asm.Node
+	if arg.MemoryIndex != 0 {
+		// Mark temporarily used as allocating the buffer register might steal it otherwise.
+		c.markRegisterUsed(offsetRegister)
+		buffer, err = c.allocateRegister(registerTypeGeneralPurpose)
+		c.markRegisterUnused(offsetRegister)
+		if err != nil {
+			return
+		}
 
-	// Check if offsetRegister(= base+offsetArg+targetSizeInBytes) > len(memory.Buffer).
+		// The length and buffer of the memories other than the memory zero are read from their wasm.MemoryInstance
+		// on each access, so they are never stale, even if the memory is grown by a builtin function call or another
+		// module instance.
+		c.compileLoadMemoryInstance(arg.MemoryIndex, buffer)
+		// "arm64ReservedRegisterForTemporary = len(memory.Buffer)"
+		c.assembler.CompileMemoryToRegister(arm64.MOVD,
+			buffer, memoryInstanceLengthOffsetOf(c.ir.Memories[arg.MemoryIndex]),
+			arm64ReservedRegisterForTemporary)
+	} else {
+		// "arm64ReservedRegisterForTemporary = len(memory.Buffer)"
+		c.assembler.CompileMemoryToRegister(arm64.MOVD,
+			arm64ReservedRegisterForCallEngine, callEngineModuleContextMemorySliceLenOffset,
+			arm64ReservedRegisterForTemporary)
+	}
+
+	// Check if offsetRegister(= base+arg.Offset+targetSizeInBytes) > len(memory.Buffer).
 	c.assembler.CompileTwoRegistersToNone(arm64.CMP, arm64ReservedRegisterForTemporary, offsetRegister)
 	boundsOK := c.assembler.CompileJump(arm64.BLS)
+	if arg.MemoryIndex == 0 {
+		reloadedBoundsOK = c.compileReloadSharedMemoryLength(offsetRegister)
+	}
 
-	// If offsetRegister(= base+offsetArg+targetSizeInBytes) exceeds the memory length,
+	// If offsetRegister(= base+arg.Offset+targetSizeInBytes) exceeds the memory length,
 	//  we exit the function with nativeCallStatusCodeMemoryOutOfBounds.
 	c.compileExitFromNativeCode(nativeCallStatusCodeMemoryOutOfBounds)
 
 	// Otherwise, we subtract targetSizeInBytes from offsetRegister.
-	c.assembler.SetJumpTargetOnNext(boundsOK)
+	c.assembler.SetJumpTargetOnNext(append(reloadedBoundsOK, boundsOK)...)
 	c.assembler.CompileConstToRegister(arm64.SUB, targetSizeInBytes, offsetRegister)
-	return offsetRegister, nil
+
+	if arg.MemoryIndex != 0 {
+		// "buffer = &memory.Buffer[0]"
+		c.assembler.CompileMemoryToRegister(arm64.MOVD, buffer, memoryInstanceBufferOffset, buffer)
+	}
+	return
+}
+
+// compileExitOnCarry exits with the status code if the previous addition, which sets the flags, carried. That is out
+// of bounds when adding to the i64 addresses of memories with 64-bit indices.
+func (c *arm64Compiler) compileExitOnCarry(status nativeCallStatusCode) {
+	noCarry := c.assembler.CompileJump(arm64.BLO)
+	c.compileExitFromNativeCode(status)
+	c.assembler.SetJumpTargetOnNext(noCarry)
+}
+
+// compileExitOnAdditionCarry exits with the status code if "sum += addend" carried, i.e. the sum is less than the
+// addend. That is out of bounds when adding to the i64 addresses of memories with 64-bit indices.
+func (c *arm64Compiler) compileExitOnAdditionCarry(sum, addend asm.Register, status nativeCallStatusCode) {
+	c.assembler.CompileTwoRegistersToNone(arm64.CMP, addend, sum)
+	noCarry := c.assembler.CompileJump(arm64.BHS)
+	c.compileExitFromNativeCode(status)
+	c.assembler.SetJumpTargetOnNext(noCarry)
+}
+
+// compileLoadMemoryInstance adds instructions to load the *wasm.MemoryInstance at the index into the register, i.e.
+// "reg = ModuleInstance.Memories[index]".
+func (c *arm64Compiler) compileLoadMemoryInstance(index wasm.Index, reg asm.Register) {
+	c.assembler.CompileMemoryToRegister(arm64.MOVD,
+		arm64ReservedRegisterForCallEngine, callEngineModuleContextMemoriesElement0AddressOffset, reg)
+	c.assembler.CompileMemoryToRegister(arm64.MOVD, reg, int64(index)*8, reg)
 }
 
 // compileMemoryGrow implements compileMemoryGrow variants for arm64 architecture.
@@ -2874,7 +3196,7 @@ func (c *arm64Compiler) compileMemoryGrow() error {
 }
 
 // compileMemorySize implements compileMemorySize variants for arm64 architecture.
-func (c *arm64Compiler) compileMemorySize() error {
+func (c *arm64Compiler) compileMemorySize(o *wazeroir.OperationMemorySize) error {
 	c.maybeCompileMoveTopConditionalToFreeGeneralPurposeRegister()
 
 	reg, err := c.allocateRegister(registerTypeGeneralPurpose)
@@ -2883,11 +3205,19 @@ func (c *arm64Compiler) compileMemorySize() error {
 	}
 
 	// "reg = len(memory.Buffer)"
-	c.assembler.CompileMemoryToRegister(
-		arm64.MOVD,
-		arm64ReservedRegisterForCallEngine, callEngineModuleContextMemorySliceLenOffset,
-		reg,
-	)
+	if o.MemoryIndex != 0 {
+		c.compileLoadMemoryInstance(o.MemoryIndex, reg)
+		c.assembler.CompileMemoryToRegister(arm64.MOVD,
+			reg, memoryInstanceLengthOffsetOf(c.ir.Memories[o.MemoryIndex]), reg)
+	} else if isSharedMemoryZero(c.ir.Memories) {
+		c.compileLoadSharedMemoryLength(reg)
+	} else {
+		c.assembler.CompileMemoryToRegister(
+			arm64.MOVD,
+			arm64ReservedRegisterForCallEngine, callEngineModuleContextMemorySliceLenOffset,
+			reg,
+		)
+	}
 
 	// memory.size loads the page size of memory, so we have to divide by the page size.
 	// "reg = reg >> wasm.MemoryPageSizeInBits (== reg / wasm.MemoryPageSize) "
@@ -3116,6 +3446,9 @@ func (c *arm64Compiler) compileInitImpl(isTable bool, index, tableIndex uint32)
 		c.assembler.CompileRegisterToRegister(arm64.ADD, copySize.register, sourceOffset.register)
 		// destinationOffset += size.
 		c.assembler.CompileRegisterToRegister(arm64.ADD, copySize.register, destinationOffset.register)
+		if !isTable && isMemory64(c.ir.Memories, 0) {
+			c.compileExitOnAdditionCarry(destinationOffset.register, copySize.register, outOfBoundsErrorStatus)
+		}
 	}
 
 	instanceAddr, err := c.allocateRegister(registerTypeGeneralPurpose)
@@ -3166,12 +3499,16 @@ func (c *arm64Compiler) compileInitImpl(isTable bool, index, tableIndex uint32)
 
 	c.assembler.CompileTwoRegistersToNone(arm64.CMP, arm64ReservedRegisterForTemporary, destinationOffset.register)
 	destinationBoundsOK := c.assembler.CompileJump(arm64.BLS)
+	var reloadedBoundsOK []asm.Node
+	if !isTable {
+		reloadedBoundsOK = c.compileReloadSharedMemoryLength(destinationOffset.register)
+	}
 
 	// If not, raise out of bounds memory access error.
 	c.compileExitFromNativeCode(outOfBoundsErrorStatus)
 
 	// Otherwise, ready to copy the value from source to destination.
-	c.assembler.SetJumpTargetOnNext(destinationBoundsOK)
+	c.assembler.SetJumpTargetOnNext(append(reloadedBoundsOK, destinationBoundsOK)...)
 
 	if !isZeroRegister(copySize.register) {
 		// If the size equals zero, we can skip the entire instructions beflow.
@@ -3325,6 +3662,10 @@ func (c *arm64Compiler) compileCopyImpl(isTable bool, srcTableIndex, dstTableInd
 		c.assembler.CompileRegisterToRegister(arm64.ADD, copySize.register, sourceOffset.register)
 		// destinationOffset += size.
 		c.assembler.CompileRegisterToRegister(arm64.ADD, copySize.register, destinationOffset.register)
+		if !isTable && isMemory64(c.ir.Memories, 0) {
+			c.compileExitOnAdditionCarry(sourceOffset.register, copySize.register, outOfBoundsErrorStatus)
+			c.compileExitOnAdditionCarry(destinationOffset.register, copySize.register, outOfBoundsErrorStatus)
+		}
 	}
 
 	if isTable {
@@ -3352,11 +3693,15 @@ func (c *arm64Compiler) compileCopyImpl(isTable bool, srcTableIndex, dstTableInd
 	// Check memory len >= sourceOffset.
 	c.assembler.CompileTwoRegistersToNone(arm64.CMP, arm64ReservedRegisterForTemporary, sourceOffset.register)
 	sourceBoundsOK := c.assembler.CompileJump(arm64.BLS)
+	var reloadedBoundsOK []asm.Node
+	if !isTable {
+		reloadedBoundsOK = c.compileReloadSharedMemoryLength(sourceOffset.register)
+	}
 
 	// If not, raise out of bounds memory access error.
 	c.compileExitFromNativeCode(outOfBoundsErrorStatus)
 
-	c.assembler.SetJumpTargetOnNext(sourceBoundsOK)
+	c.assembler.SetJumpTargetOnNext(append(reloadedBoundsOK, sourceBoundsOK)...)
 
 	// Otherwise, check memory len >= destinationOffset.
 	if isTable {
@@ -3378,12 +3723,15 @@ func (c *arm64Compiler) compileCopyImpl(isTable bool, srcTableIndex, dstTableInd
 
 	c.assembler.CompileTwoRegistersToNone(arm64.CMP, arm64ReservedRegisterForTemporary, destinationOffset.register)
 	destinationBoundsOK := c.assembler.CompileJump(arm64.BLS)
+	if !isTable {
+		reloadedBoundsOK = c.compileReloadSharedMemoryLength(destinationOffset.register)
+	}
 
 	// If not, raise out of bounds memory access error.
 	c.compileExitFromNativeCode(outOfBoundsErrorStatus)
 
 	// Otherwise, ready to copy the value from source to destination.
-	c.assembler.SetJumpTargetOnNext(destinationBoundsOK)
+	c.assembler.SetJumpTargetOnNext(append(reloadedBoundsOK, destinationBoundsOK)...)
 
 	var movInst asm.Instruction
 	var movSize int64
@@ -3577,6 +3925,9 @@ func (c *arm64Compiler) compileFillImpl(isTable bool, tableIndex uint32) error {
 
 	// destinationOffset += size.
 	c.assembler.CompileRegisterToRegister(arm64.ADD, fillSize.register, destinationOffset.register)
+	if !isTable && isMemory64(c.ir.Memories, 0) {
+		c.compileExitOnAdditionCarry(destinationOffset.register, fillSize.register, nativeCallStatusCodeMemoryOutOfBounds)
+	}
 
 	if isTable {
 		// arm64ReservedRegisterForTemporary = &tables[0]
@@ -3605,14 +3956,16 @@ func (c *arm64Compiler) compileFillImpl(isTable bool, tableIndex uint32) error {
 	destinationBoundsOK := c.assembler.CompileJump(arm64.BLS)
 
 	// If not, raise the runtime error.
+	var reloadedBoundsOK []asm.Node
 	if isTable {
 		c.compileExitFromNativeCode(nativeCallStatusCodeInvalidTableAccess)
 	} else {
+		reloadedBoundsOK = c.compileReloadSharedMemoryLength(destinationOffset.register)
 		c.compileExitFromNativeCode(nativeCallStatusCodeMemoryOutOfBounds)
 	}
 
 	// Otherwise, ready to copy the value from destination to source.
-	c.assembler.SetJumpTargetOnNext(destinationBoundsOK)
+	c.assembler.SetJumpTargetOnNext(append(reloadedBoundsOK, destinationBoundsOK)...)
 
 	// If the size equals zero, we can skip the entire instructions beflow.
 	c.assembler.CompileTwoRegistersToNone(arm64.CMP, arm64.RegRZR, fillSize.register)
@@ -3885,6 +4238,329 @@ func (c *arm64Compiler) compileTableGrow(o *wazeroir.OperationTableGrow) error {
 	return nil
 }
 
+// compileCallAtomicBuiltinFunction implements compiler.compileCallAtomicBuiltinFunction for the arm64 architecture.
+func (c *arm64Compiler) compileCallAtomicBuiltinFunction(descriptor uint64, operands int, hasResult bool) error {
+	c.maybeCompileMoveTopConditionalToFreeGeneralPurposeRegister()
+
+	if err := c.compileConstI64(&wazeroir.OperationConstI64{Value: descriptor}); err != nil {
+		return err
+	}
+
+	if err := c.compileCallGoFunction(nativeCallStatusCodeCallBuiltInFunction, builtinFunctionIndexAtomic); err != nil {
+		return err
+	}
+
+	// The builtin function consumes the operands and the descriptor.
+	for i := 0; i < operands+1; i++ {
+		c.locationStack.pop()
+	}
+	if hasResult {
+		c.locationStack.pushRuntimeValueLocationOnStack()
+	}
+
+	// After return, we re-initialize reserved registers just like preamble of functions.
+	c.compileReservedStackBasePointerRegisterInitialization()
+	c.compileReservedMemoryRegisterInitialization()
+	return nil
+}
+
+// compileAtomicLoad implements compiler.compileAtomicLoad for the arm64 architecture.
+func (c *arm64Compiler) compileAtomicLoad(o *wazeroir.OperationAtomicLoad) error {
+	size := int64(o.Size)
+	addr, err := c.compileAtomicMemoryAccessAddressSetup(o.Arg, size)
+	if err != nil {
+		return err
+	}
+
+	// "addr = [addr]", which is zero-extended.
+	c.assembler.CompileMemoryToRegister(atomicInstructionOfSize(arm64.LDARB, size), addr, 0, addr)
+
+	c.markRegisterUnused(addr)
+	c.pushRuntimeValueLocationOnRegister(addr, unsignedIntResults(o.Type)[0])
+	return nil
+}
+
+// compileAtomicStore implements compiler.compileAtomicStore for the arm64 architecture.
+func (c *arm64Compiler) compileAtomicStore(o *wazeroir.OperationAtomicStore) error {
+	val, err := c.popValueOnRegister()
+	if err != nil {
+		return err
+	}
+	// Mark temporarily used as compileAtomicMemoryAccessAddressSetup might try allocating register.
+	c.markRegisterUsed(val.register)
+
+	size := int64(o.Size)
+	addr, err := c.compileAtomicMemoryAccessAddressSetup(o.Arg, size)
+	if err != nil {
+		return err
+	}
+
+	// "[addr] = val.register"
+	c.assembler.CompileRegisterToMemory(atomicInstructionOfSize(arm64.STLRB, size), val.register, addr, 0)
+
+	c.markRegisterUnused(val.register, addr)
+	return nil
+}
+
+// compileAtomicRMW implements compiler.compileAtomicRMW for the arm64 architecture.
+func (c *arm64Compiler) compileAtomicRMW(o *wazeroir.OperationAtomicRMW) error {
+	val, err := c.popValueOnRegister()
+	if err != nil {
+		return err
+	}
+	// Mark temporarily used as the registers below are allocated.
+	c.markRegisterUsed(val.register)
+
+	size := int64(o.Size)
+	addr, err := c.compileAtomicMemoryAccessAddressSetup(o.Arg, size)
+	if err != nil {
+		return err
+	}
+
+	old, err := c.allocateRegister(registerTypeGeneralPurpose)
+	if err != nil {
+		return err
+	}
+	c.markRegisterUsed(old)
+
+	newValue := val.register
+	if o.Op != wazeroir.AtomicRMWOpXchg {
+		if newValue, err = c.allocateRegister(registerTypeGeneralPurpose); err != nil {
+			return err
+		}
+	}
+
+	// Replace the value until no other write happened since it was loaded, in which case the status is zero.
+	beginLoop := c.assembler.CompileStandAlone(arm64.NOP)
+	c.assembler.CompileMemoryToRegister(atomicInstructionOfSize(arm64.LDAXRB, size), addr, 0, old)
+	switch o.Op {
+	case wazeroir.AtomicRMWOpAdd:
+		c.assembler.CompileTwoRegistersToRegister(arm64.ADD, val.register, old, newValue)
+	case wazeroir.AtomicRMWOpSub:
+		c.assembler.CompileTwoRegistersToRegister(arm64.SUB, val.register, old, newValue)
+	case wazeroir.AtomicRMWOpAnd:
+		c.assembler.CompileTwoRegistersToRegister(arm64.AND, val.register, old, newValue)
+	case wazeroir.AtomicRMWOpOr:
+		c.assembler.CompileTwoRegistersToRegister(arm64.ORR, val.register, old, newValue)
+	case wazeroir.AtomicRMWOpXor:
+		c.assembler.CompileTwoRegistersToRegister(arm64.EOR, val.register, old, newValue)
+	}
+	c.assembler.CompileTwoRegistersToRegister(atomicInstructionOfSize(arm64.STLXRB, size),
+		newValue, addr, arm64ReservedRegisterForTemporary)
+	c.assembler.CompileTwoRegistersToNone(arm64.CMPW, arm64.RegRZR, arm64ReservedRegisterForTemporary)
+	c.assembler.CompileJump(arm64.BNE).AssignJumpTarget(beginLoop)
+
+	c.markRegisterUnused(val.register, addr, old)
+	c.pushRuntimeValueLocationOnRegister(old, unsignedIntResults(o.Type)[0])
+	return nil
+}
+
+// compileAtomicCmpxchg implements compiler.compileAtomicCmpxchg for the arm64 architecture.
+func (c *arm64Compiler) compileAtomicCmpxchg(o *wazeroir.OperationAtomicCmpxchg) error {
+	replacement, err := c.popValueOnRegister()
+	if err != nil {
+		return err
+	}
+	// Mark temporarily used as the registers below are allocated.
+	c.markRegisterUsed(replacement.register)
+
+	expected, err := c.popValueOnRegister()
+	if err != nil {
+		return err
+	}
+	c.markRegisterUsed(expected.register)
+
+	size := int64(o.Size)
+	addr, err := c.compileAtomicMemoryAccessAddressSetup(o.Arg, size)
+	if err != nil {
+		return err
+	}
+
+	old, err := c.allocateRegister(registerTypeGeneralPurpose)
+	if err != nil {
+		return err
+	}
+	c.markRegisterUsed(old)
+
+	// The old value is zero-extended, so the expected value is wrapped to the size before comparing them.
+	if size < 8 && !isZeroRegister(expected.register) {
+		c.assembler.CompileConstToRegister(arm64.MOVD, 1<<(size*8)-1, arm64ReservedRegisterForTemporary)
+		c.assembler.CompileTwoRegistersToRegister(arm64.AND, arm64ReservedRegisterForTemporary, expected.register, expected.register)
+	}
+
+	// Replace the value until no other write happened since it was loaded, unless it isn't the expected one.
+	beginLoop := c.assembler.CompileStandAlone(arm64.NOP)
+	c.assembler.CompileMemoryToRegister(atomicInstructionOfSize(arm64.LDAXRB, size), addr, 0, old)
+	c.assembler.CompileTwoRegistersToNone(arm64.CMP, expected.register, old)
+	notEqual := c.assembler.CompileJump(arm64.BNE)
+	c.assembler.CompileTwoRegistersToRegister(atomicInstructionOfSize(arm64.STLXRB, size),
+		replacement.register, addr, arm64ReservedRegisterForTemporary)
+	c.assembler.CompileTwoRegistersToNone(arm64.CMPW, arm64.RegRZR, arm64ReservedRegisterForTemporary)
+	c.assembler.CompileJump(arm64.BNE).AssignJumpTarget(beginLoop)
+	c.assembler.SetJumpTargetOnNext(notEqual)
+
+	c.markRegisterUnused(replacement.register, expected.register, addr, old)
+	c.pushRuntimeValueLocationOnRegister(old, unsignedIntResults(o.Type)[0])
+	return nil
+}
+
+// compileAtomicFence implements compiler.compileAtomicFence for the arm64 architecture.
+func (c *arm64Compiler) compileAtomicFence() error {
+	c.assembler.CompileStandAlone(arm64.DMB)
+	return nil
+}
+
+// compileAtomicMemoryAccessAddressSetup is the same as compileMemoryAccessOffsetSetup, but also exits with
+// nativeCallStatusCodeUnalignedAtomic if the offset isn't a multiple of the size, and returns the register holding the
+// address of memory.Buffer[offset] marked used, as the atomic instructions don't take an offset register.
+func (c *arm64Compiler) compileAtomicMemoryAccessAddressSetup(arg *wazeroir.MemoryArg, size int64) (asm.Register, error) {
+	buffer, offsetReg, err := c.compileMemoryAccessOffsetSetup(arg, size)
+	if err != nil {
+		return 0, err
+	}
+	c.markRegisterUsed(offsetReg)
+
+	if size > 1 {
+		// "arm64ReservedRegisterForTemporary = offsetReg & (size-1)", which is zero if the offset is aligned.
+		c.assembler.CompileConstToRegister(arm64.MOVD, size-1, arm64ReservedRegisterForTemporary)
+		c.assembler.CompileTwoRegistersToRegister(arm64.AND, offsetReg, arm64ReservedRegisterForTemporary,
+			arm64ReservedRegisterForTemporary)
+		c.assembler.CompileTwoRegistersToNone(arm64.CMP, arm64.RegRZR, arm64ReservedRegisterForTemporary)
+		alignedOK := c.assembler.CompileJump(arm64.BEQ)
+		c.compileExitFromNativeCode(nativeCallStatusCodeUnalignedAtomic)
+		c.assembler.SetJumpTargetOnNext(alignedOK)
+	}
+
+	// "offsetReg = &memory.Buffer[offsetReg]"
+	c.assembler.CompileRegisterToRegister(arm64.ADD, buffer, offsetReg)
+	return offsetReg, nil
+}
+
+// atomicInstructionOfSize returns the variant of the atomic instruction for a single byte, such as arm64.LDARB, which
+// accesses the given size in bytes.
+func atomicInstructionOfSize(inst asm.Instruction, size int64) asm.Instruction {
+	switch size {
+	case 2:
+		return inst + 1
+	case 4:
+		return inst + 2
+	case 8:
+		return inst + 3
+	}
+	return inst
+}
+
+// compileCallMemoryBuiltinFunction implements compiler.compileCallMemoryBuiltinFunction for the arm64 architecture.
+func (c *arm64Compiler) compileCallMemoryBuiltinFunction(descriptor, memoryIndexes, offset uint64, operands int, results ...runtimeValueType) error {
+	c.maybeCompileMoveTopConditionalToFreeGeneralPurposeRegister()
+
+	for _, v := range []uint64{descriptor, memoryIndexes, offset} {
+		if err := c.compileConstI64(&wazeroir.OperationConstI64{Value: v}); err != nil {
+			return err
+		}
+	}
+
+	if err := c.compileCallGoFunction(nativeCallStatusCodeCallBuiltInFunction, builtinFunctionIndexMemory); err != nil {
+		return err
+	}
+
+	// The builtin function consumes the operands, the descriptor, the memory indexes and the offset.
+	for i := 0; i < operands+3; i++ {
+		c.locationStack.pop()
+	}
+	for _, t := range results {
+		c.locationStack.pushRuntimeValueLocationOnStack().valueType = t
+	}
+
+	// After return, we re-initialize reserved registers just like preamble of functions.
+	c.compileReservedStackBasePointerRegisterInitialization()
+	c.compileReservedMemoryRegisterInitialization()
+	return nil
+}
+
+// compileTry implements compiler.compileTry for the arm64 architecture.
+func (c *arm64Compiler) compileTry(o *wazeroir.OperationTry) error {
+	if err := c.compileReleaseAllRegistersToStack(); err != nil {
+		return err
+	}
+	below := c.locationStack.clone()
+	below.sp = uint64(c.tries[o.Index].StackHeight)
+	c.tryLocationStacks[o.Index] = below
+
+	t := c.tries[o.Index]
+	c.assembler.SetOffsetCallbackOnNext(func(offset asm.NodeOffsetInBinary) {
+		t.start = uint64(offset)
+	})
+	c.assembler.CompileStandAlone(arm64.NOP)
+	return nil
+}
+
+// compileTryEnd implements compiler.compileTryEnd for the arm64 architecture.
+func (c *arm64Compiler) compileTryEnd(o *wazeroir.OperationTryEnd) {
+	if c.tryLocationStacks[o.Index] == nil {
+		return // The body was never reached.
+	}
+	t := c.tries[o.Index]
+	c.assembler.SetOffsetCallbackOnNext(func(offset asm.NodeOffsetInBinary) {
+		t.end = uint64(offset)
+	})
+	c.assembler.CompileStandAlone(arm64.NOP)
+}
+
+// compileCatch implements compiler.compileCatch for the arm64 architecture.
+func (c *arm64Compiler) compileCatch(tryIndex uint32, tagIndex uint32, all bool) (skipThisCatch bool, err error) {
+	below := c.tryLocationStacks[tryIndex]
+	if below == nil {
+		return true, nil
+	}
+	c.setLocationStack(newCatchLocationStack(c.ir, below, tagIndex, all))
+
+	t := c.tries[tryIndex]
+	i := len(t.catches)
+	t.catches = append(t.catches, catchClause{tagIndex: tagIndex, all: all})
+	c.assembler.SetOffsetCallbackOnNext(func(offset asm.NodeOffsetInBinary) {
+		t.catches[i].handler = uint64(offset)
+	})
+	c.assembler.CompileStandAlone(arm64.NOP)
+
+	// The catch clause is entered by callEngine.throw with nativecall, so initialize the module context and the
+	// reserved registers like the preamble.
+	if err = c.compileModuleContextInitialization(); err != nil {
+		return
+	}
+	c.compileReservedStackBasePointerRegisterInitialization()
+	c.compileReservedMemoryRegisterInitialization()
+	return
+}
+
+// compileThrow implements compiler.compileThrow for the arm64 architecture.
+func (c *arm64Compiler) compileThrow(o *wazeroir.OperationThrow) error {
+	c.maybeCompileMoveTopConditionalToFreeGeneralPurposeRegister()
+
+	if err := c.compileConstI64(&wazeroir.OperationConstI64{Value: uint64(o.TagIndex)}); err != nil {
+		return err
+	}
+	if err := c.compileCallGoFunction(nativeCallStatusCodeCallBuiltInFunction, builtinFunctionIndexThrow); err != nil {
+		return err
+	}
+	// The builtin function never returns here, but the return address must be an instruction.
+	return c.compileUnreachable()
+}
+
+// compileRethrow implements compiler.compileRethrow for the arm64 architecture.
+func (c *arm64Compiler) compileRethrow() error {
+	if err := c.compileCallGoFunction(nativeCallStatusCodeCallBuiltInFunction, builtinFunctionIndexRethrow); err != nil {
+		return err
+	}
+	// The builtin function never returns here, but the return address must be an instruction.
+	return c.compileUnreachable()
+}
+
+// tryBlocks implements compiler.tryBlocks for the arm64 architecture.
+func (c *arm64Compiler) tryBlocks() []*tryBlock {
+	return c.tries
+}
+
 // compileTableSize implements compiler.compileTableSize for the arm64 architecture.
 func (c *arm64Compiler) compileTableSize(o *wazeroir.OperationTableSize) error {
 	result, err := c.allocateRegister(registerTypeGeneralPurpose)
@@ -4135,6 +4811,29 @@ func (c *arm64Compiler) compileReservedMemoryRegisterInitialization() {
 	}
 }
 
+// compileLoadSharedMemoryLength adds instructions to load the length of the shared memory zero from its
+// wasm.MemoryInstance into moduleContext.memorySliceLen and the register, as another module instance may have grown it.
+func (c *arm64Compiler) compileLoadSharedMemoryLength(reg asm.Register) {
+	c.assembler.CompileMemoryToRegister(arm64.MOVD,
+		arm64ReservedRegisterForCallEngine, callEngineModuleContextModuleInstanceAddressOffset, reg)
+	c.assembler.CompileMemoryToRegister(arm64.MOVD, reg, moduleInstanceMemoryOffset, reg)
+	c.assembler.CompileMemoryToRegister(arm64.MOVD, reg, memoryInstanceLengthOffset, reg)
+	c.assembler.CompileRegisterToMemory(arm64.MOVD, reg,
+		arm64ReservedRegisterForCallEngine, callEngineModuleContextMemorySliceLenOffset)
+}
+
+// compileReloadSharedMemoryLength is called when the ceil register exceeds moduleContext.memorySliceLen. If the memory
+// zero is shared, this reloads the length, and returns the jump taken if the ceil is within it. Otherwise, the length
+// can't be stale, and this returns nil.
+func (c *arm64Compiler) compileReloadSharedMemoryLength(ceil asm.Register) []asm.Node {
+	if !isSharedMemoryZero(c.ir.Memories) {
+		return nil
+	}
+	c.compileLoadSharedMemoryLength(arm64ReservedRegisterForTemporary)
+	c.assembler.CompileTwoRegistersToNone(arm64.CMP, arm64ReservedRegisterForTemporary, ceil)
+	return []asm.Node{c.assembler.CompileJump(arm64.BLS)}
+}
+
 // compileModuleContextInitialization adds instructions to initialize ce.moduleContext's fields based on
 // ce.moduleContext.ModuleInstanceAddress.
 // This is called in two cases: in function preamble, and on the return from (non-Go) function calls.
@@ -4174,6 +4873,7 @@ func (c *arm64Compiler) compileModuleContextInitialization() error {
 	// * callEngine.moduleContext.typeIDsElement0Address
 	// * callEngine.moduleContext.dataInstancesElement0Address
 	// * callEngine.moduleContext.elementInstancesElement0Address
+	// * callEngine.moduleContext.memoriesElement0Address
 
 	// Update globalElement0Address.
 	//
@@ -4207,12 +4907,17 @@ func (c *arm64Compiler) compileModuleContextInitialization() error {
 			tmpX,
 		)
 
-		// First, we write the memory length into ce.MemorySliceLen.
+		// First, we write the memory length into ce.MemorySliceLen, which isn't the length of the buffer of shared
+		// memories.
 		//
 		// "tmpY = [tmpX + memoryInstanceBufferLenOffset] (== len(memory.Buffer))"
+		lengthOffset := int64(memoryInstanceBufferLenOffset)
+		if isSharedMemoryZero(c.ir.Memories) {
+			lengthOffset = memoryInstanceLengthOffset
+		}
 		c.assembler.CompileMemoryToRegister(
 			arm64.MOVD,
-			tmpX, memoryInstanceBufferLenOffset,
+			tmpX, lengthOffset,
 			tmpY,
 		)
 		// "ce.MemorySliceLen = tmpY".
@@ -4238,6 +4943,22 @@ func (c *arm64Compiler) compileModuleContextInitialization() error {
 		)
 	}
 
+	// Update memoriesElement0Address, which is only used to access the memories other than the memory zero.
+	if len(c.ir.Memories) > 1 {
+		// "tmpX = &moduleInstance.Memories[0]"
+		c.assembler.CompileMemoryToRegister(
+			arm64.MOVD,
+			arm64CallingConventionModuleInstanceAddressRegister, moduleInstanceMemoriesOffset,
+			tmpX,
+		)
+		// "callEngine.moduleContext.memoriesElement0Address = tmpX".
+		c.assembler.CompileRegisterToMemory(
+			arm64.MOVD,
+			tmpX,
+			arm64ReservedRegisterForCallEngine, callEngineModuleContextMemoriesElement0AddressOffset,
+		)
+	}
+
 	// Update tableElement0Address, tableSliceLen and typeIDsElement0Address.
 	//
 	// Note: if there's table instruction in the function, the existence of the table
@@ -117,25 +117,25 @@ func (c *amd64Compiler) compileV128Load(o *wazeroir.OperationV128Load) error {
 
 	switch o.Type {
 	case wazeroir.LoadV128Type128:
-		err = c.compileV128LoadImpl(amd64.MOVDQU, o.Arg.Offset, 16, result)
+		err = c.compileV128LoadImpl(amd64.MOVDQU, o.Arg, 16, result)
 	case wazeroir.LoadV128Type8x8s:
-		err = c.compileV128LoadImpl(amd64.PMOVSXBW, o.Arg.Offset, 8, result)
+		err = c.compileV128LoadImpl(amd64.PMOVSXBW, o.Arg, 8, result)
 	case wazeroir.LoadV128Type8x8u:
-		err = c.compileV128LoadImpl(amd64.PMOVZXBW, o.Arg.Offset, 8, result)
+		err = c.compileV128LoadImpl(amd64.PMOVZXBW, o.Arg, 8, result)
 	case wazeroir.LoadV128Type16x4s:
-		err = c.compileV128LoadImpl(amd64.PMOVSXWD, o.Arg.Offset, 8, result)
+		err = c.compileV128LoadImpl(amd64.PMOVSXWD, o.Arg, 8, result)
 	case wazeroir.LoadV128Type16x4u:
-		err = c.compileV128LoadImpl(amd64.PMOVZXWD, o.Arg.Offset, 8, result)
+		err = c.compileV128LoadImpl(amd64.PMOVZXWD, o.Arg, 8, result)
 	case wazeroir.LoadV128Type32x2s:
-		err = c.compileV128LoadImpl(amd64.PMOVSXDQ, o.Arg.Offset, 8, result)
+		err = c.compileV128LoadImpl(amd64.PMOVSXDQ, o.Arg, 8, result)
 	case wazeroir.LoadV128Type32x2u:
-		err = c.compileV128LoadImpl(amd64.PMOVZXDQ, o.Arg.Offset, 8, result)
+		err = c.compileV128LoadImpl(amd64.PMOVZXDQ, o.Arg, 8, result)
 	case wazeroir.LoadV128Type8Splat:
-		reg, err := c.compileMemoryAccessCeilSetup(o.Arg.Offset, 1)
+		buffer, reg, err := c.compileMemoryAccessCeilSetup(o.Arg, 1)
 		if err != nil {
 			return err
 		}
-		c.assembler.CompileMemoryWithIndexToRegister(amd64.MOVBQZX, amd64ReservedRegisterForMemory, -1,
+		c.assembler.CompileMemoryWithIndexToRegister(amd64.MOVBQZX, buffer, -1,
 			reg, 1, reg)
 		// pinsrb   $0, reg, result
 		// pxor	    tmpVReg, tmpVReg
@@ -149,11 +149,11 @@ func (c *amd64Compiler) compileV128Load(o *wazeroir.OperationV128Load) error {
 		c.assembler.CompileRegisterToRegister(amd64.PXOR, tmpVReg, tmpVReg)
 		c.assembler.CompileRegisterToRegister(amd64.PSHUFB, tmpVReg, result)
 	case wazeroir.LoadV128Type16Splat:
-		reg, err := c.compileMemoryAccessCeilSetup(o.Arg.Offset, 2)
+		buffer, reg, err := c.compileMemoryAccessCeilSetup(o.Arg, 2)
 		if err != nil {
 			return err
 		}
-		c.assembler.CompileMemoryWithIndexToRegister(amd64.MOVWQZX, amd64ReservedRegisterForMemory, -2,
+		c.assembler.CompileMemoryWithIndexToRegister(amd64.MOVWQZX, buffer, -2,
 			reg, 1, reg)
 		// pinsrw $0, reg, result
 		// pinsrw $1, reg, result
@@ -162,31 +162,31 @@ func (c *amd64Compiler) compileV128Load(o *wazeroir.OperationV128Load) error {
 		c.assembler.CompileRegisterToRegisterWithArg(amd64.PINSRW, reg, result, 1)
 		c.assembler.CompileRegisterToRegisterWithArg(amd64.PSHUFD, result, result, 0)
 	case wazeroir.LoadV128Type32Splat:
-		reg, err := c.compileMemoryAccessCeilSetup(o.Arg.Offset, 4)
+		buffer, reg, err := c.compileMemoryAccessCeilSetup(o.Arg, 4)
 		if err != nil {
 			return err
 		}
-		c.assembler.CompileMemoryWithIndexToRegister(amd64.MOVLQZX, amd64ReservedRegisterForMemory, -4,
+		c.assembler.CompileMemoryWithIndexToRegister(amd64.MOVLQZX, buffer, -4,
 			reg, 1, reg)
 		// pinsrd $0, reg, result
 		// pshufd $0, result, result (result = result[0,0,0,0])
 		c.assembler.CompileRegisterToRegisterWithArg(amd64.PINSRD, reg, result, 0)
 		c.assembler.CompileRegisterToRegisterWithArg(amd64.PSHUFD, result, result, 0)
 	case wazeroir.LoadV128Type64Splat:
-		reg, err := c.compileMemoryAccessCeilSetup(o.Arg.Offset, 8)
+		buffer, reg, err := c.compileMemoryAccessCeilSetup(o.Arg, 8)
 		if err != nil {
 			return err
 		}
-		c.assembler.CompileMemoryWithIndexToRegister(amd64.MOVQ, amd64ReservedRegisterForMemory, -8,
+		c.assembler.CompileMemoryWithIndexToRegister(amd64.MOVQ, buffer, -8,
 			reg, 1, reg)
 		// pinsrq $0, reg, result
 		// pinsrq $1, reg, result
 		c.assembler.CompileRegisterToRegisterWithArg(amd64.PINSRQ, reg, result, 0)
 		c.assembler.CompileRegisterToRegisterWithArg(amd64.PINSRQ, reg, result, 1)
 	case wazeroir.LoadV128Type32zero:
-		err = c.compileV128LoadImpl(amd64.MOVL, o.Arg.Offset, 4, result)
+		err = c.compileV128LoadImpl(amd64.MOVL, o.Arg, 4, result)
 	case wazeroir.LoadV128Type64zero:
-		err = c.compileV128LoadImpl(amd64.MOVQ, o.Arg.Offset, 8, result)
+		err = c.compileV128LoadImpl(amd64.MOVQ, o.Arg, 8, result)
 	}
 
 	if err != nil {
@@ -197,12 +197,12 @@ func (c *amd64Compiler) compileV128Load(o *wazeroir.OperationV128Load) error {
 	return nil
 }
 
-func (c *amd64Compiler) compileV128LoadImpl(inst asm.Instruction, offset uint32, targetSizeInBytes int64, dst asm.Register) error {
-	offsetReg, err := c.compileMemoryAccessCeilSetup(offset, targetSizeInBytes)
+func (c *amd64Compiler) compileV128LoadImpl(inst asm.Instruction, arg *wazeroir.MemoryArg, targetSizeInBytes int64, dst asm.Register) error {
+	buffer, offsetReg, err := c.compileMemoryAccessCeilSetup(arg, targetSizeInBytes)
 	if err != nil {
 		return err
 	}
-	c.assembler.CompileMemoryWithIndexToRegister(inst, amd64ReservedRegisterForMemory, -targetSizeInBytes,
+	c.assembler.CompileMemoryWithIndexToRegister(inst, buffer, -targetSizeInBytes,
 		offsetReg, 1, dst)
 	return nil
 }
@@ -227,11 +227,11 @@ func (c *amd64Compiler) compileV128LoadLane(o *wazeroir.OperationV128LoadLane) e
 	}
 
 	targetSizeInBytes := int64(o.LaneSize / 8)
-	offsetReg, err := c.compileMemoryAccessCeilSetup(o.Arg.Offset, targetSizeInBytes)
+	buffer, offsetReg, err := c.compileMemoryAccessCeilSetup(o.Arg, targetSizeInBytes)
 	if err != nil {
 		return err
 	}
-	c.assembler.CompileMemoryWithIndexAndArgToRegister(insertInst, amd64ReservedRegisterForMemory, -targetSizeInBytes,
+	c.assembler.CompileMemoryWithIndexAndArgToRegister(insertInst, buffer, -targetSizeInBytes,
 		offsetReg, 1, targetVector.register, o.LaneIndex)
 
 	c.pushVectorRuntimeValueLocationOnRegister(targetVector.register)
@@ -246,13 +246,13 @@ func (c *amd64Compiler) compileV128Store(o *wazeroir.OperationV128Store) error {
 	}
 
 	const targetSizeInBytes = 16
-	offsetReg, err := c.compileMemoryAccessCeilSetup(o.Arg.Offset, targetSizeInBytes)
+	buffer, offsetReg, err := c.compileMemoryAccessCeilSetup(o.Arg, targetSizeInBytes)
 	if err != nil {
 		return err
 	}
 
 	c.assembler.CompileRegisterToMemoryWithIndex(amd64.MOVDQU, val.register,
-		amd64ReservedRegisterForMemory, -targetSizeInBytes, offsetReg, 1)
+		buffer, -targetSizeInBytes, offsetReg, 1)
 
 	c.locationStack.markRegisterUnused(val.register, offsetReg)
 	return nil
@@ -278,13 +278,13 @@ func (c *amd64Compiler) compileV128StoreLane(o *wazeroir.OperationV128StoreLane)
 	}
 
 	targetSizeInBytes := int64(o.LaneSize / 8)
-	offsetReg, err := c.compileMemoryAccessCeilSetup(o.Arg.Offset, targetSizeInBytes)
+	buffer, offsetReg, err := c.compileMemoryAccessCeilSetup(o.Arg, targetSizeInBytes)
 	if err != nil {
 		return err
 	}
 
 	c.assembler.CompileRegisterToMemoryWithIndexAndArg(storeInst, val.register,
-		amd64ReservedRegisterForMemory, -targetSizeInBytes, offsetReg, 1, o.LaneIndex)
+		buffer, -targetSizeInBytes, offsetReg, 1, o.LaneIndex)
 
 	c.locationStack.markRegisterUnused(val.register, offsetReg)
 	return nil
@@ -1330,3 +1330,221 @@ func (c *amd64Compiler) compileV128Cmp(o *wazeroir.OperationV128Cmp) error {
 	c.pushVectorRuntimeValueLocationOnRegister(result)
 	return nil
 }
+
+var (
+	// relaxedTruncF32x4UConst is the f32x4 splat of 2^31.
+	relaxedTruncF32x4UConst = [16]byte{
+		0x00, 0x00, 0x00, 0x4f, 0x00, 0x00, 0x00, 0x4f,
+		0x00, 0x00, 0x00, 0x4f, 0x00, 0x00, 0x00, 0x4f,
+	}
+	// relaxedTruncF64x2UConsts are the f64x2 splats of math.MaxUint32 and 2^52.
+	relaxedTruncF64x2UConsts = [32]byte{
+		0x00, 0x00, 0xe0, 0xff, 0xff, 0xff, 0xef, 0x41, 0x00, 0x00, 0xe0, 0xff, 0xff, 0xff, 0xef, 0x41,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x30, 0x43, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x30, 0x43,
+	}
+)
+
+// compileV128RelaxedTrunc implements compiler.compileV128RelaxedTrunc for amd64.
+func (c *amd64Compiler) compileV128RelaxedTrunc(o *wazeroir.OperationV128RelaxedTrunc) error {
+	v := c.locationStack.popV128()
+	if err := c.compileEnsureOnGeneralPurposeRegister(v); err != nil {
+		return err
+	}
+	vr := v.register
+
+	if o.Signed {
+		// CVTTPS2DQ and CVTTPD2DQ result in math.MinInt32 on NaN or out of range lanes, and CVTTPD2DQ zeroes the
+		// upper two lanes.
+		if o.OriginShape == wazeroir.ShapeF32x4 {
+			c.assembler.CompileRegisterToRegister(amd64.CVTTPS2DQ, vr, vr)
+		} else {
+			c.assembler.CompileRegisterToRegister(amd64.CVTTPD2DQ, vr, vr)
+		}
+		c.pushVectorRuntimeValueLocationOnRegister(vr)
+		return nil
+	}
+
+	tmp, err := c.allocateRegister(registerTypeVector)
+	if err != nil {
+		return err
+	}
+	c.locationStack.markRegisterUsed(tmp)
+	tmp2, err := c.allocateRegister(registerTypeVector)
+	if err != nil {
+		return err
+	}
+
+	// Clamp NaN and negative lanes to zero. MAXPS and MAXPD result in the source when either is NaN.
+	c.assembler.CompileRegisterToRegister(amd64.PXOR, tmp, tmp)
+	if o.OriginShape == wazeroir.ShapeF32x4 {
+		c.assembler.CompileRegisterToRegister(amd64.MAXPS, tmp, vr)
+
+		// CVTTPS2DQ only converts lanes less than 2^31, so the lanes of at least 2^31 are converted after
+		// subtracting 2^31 into tmp2, which is added to the result of 2^31 (math.MinInt32) of CVTTPS2DQ on them.
+		if err = c.assembler.CompileLoadStaticConstToRegister(amd64.MOVDQU, relaxedTruncF32x4UConst[:], tmp); err != nil {
+			return err
+		}
+		c.assembler.CompileRegisterToRegister(amd64.MOVDQA, vr, tmp2)
+		c.assembler.CompileRegisterToRegister(amd64.SUBPS, tmp, tmp2)
+		// tmp is the mask of lanes at least 2^32, i.e. 2^31 <= tmp2, where tmp2 is converted to 0x7fffffff, so that
+		// they saturate to math.MaxUint32.
+		c.assembler.CompileRegisterToRegisterWithArg(amd64.CMPPS, tmp2, tmp, 2) // Less than or equal.
+		c.assembler.CompileRegisterToRegister(amd64.CVTTPS2DQ, tmp2, tmp2)
+		c.assembler.CompileRegisterToRegister(amd64.PXOR, tmp, tmp2)
+		// The lanes less than 2^31 are negative on tmp2, which are clamped to zero.
+		c.assembler.CompileRegisterToRegister(amd64.PXOR, tmp, tmp)
+		c.assembler.CompileRegisterToRegister(amd64.PMAXSD, tmp, tmp2)
+		c.assembler.CompileRegisterToRegister(amd64.CVTTPS2DQ, vr, vr)
+		c.assembler.CompileRegisterToRegister(amd64.PADDL, tmp2, vr)
+	} else {
+		c.assembler.CompileRegisterToRegister(amd64.MAXPD, tmp, vr)
+		if err = c.assembler.CompileLoadStaticConstToRegister(amd64.MOVDQU, relaxedTruncF64x2UConsts[:16], tmp2); err != nil {
+			return err
+		}
+		c.assembler.CompileRegisterToRegister(amd64.MINPD, tmp2, vr)
+		c.assembler.CompileRegisterToRegisterWithArg(amd64.ROUNDPD, vr, vr, 0x3) // Round toward zero.
+		// Adding 2^52 places the integer of each lane on the lower 32 bits of its mantissa.
+		if err = c.assembler.CompileLoadStaticConstToRegister(amd64.MOVDQU, relaxedTruncF64x2UConsts[16:], tmp2); err != nil {
+			return err
+		}
+		c.assembler.CompileRegisterToRegister(amd64.ADDPD, tmp2, vr)
+		// Take the lower 32 bits of each lane onto the lower two lanes, and zeros of tmp onto the upper two lanes.
+		c.assembler.CompileRegisterToRegisterWithArg(amd64.SHUFPS, tmp, vr, 0b10_00_10_00)
+	}
+
+	c.locationStack.markRegisterUnused(tmp)
+	c.pushVectorRuntimeValueLocationOnRegister(vr)
+	return nil
+}
+
+// compileV128RelaxedMadd implements compiler.compileV128RelaxedMadd for amd64.
+func (c *amd64Compiler) compileV128RelaxedMadd(o *wazeroir.OperationV128RelaxedMadd) error {
+	z := c.locationStack.popV128()
+	if err := c.compileEnsureOnGeneralPurposeRegister(z); err != nil {
+		return err
+	}
+
+	y := c.locationStack.popV128()
+	if err := c.compileEnsureOnGeneralPurposeRegister(y); err != nil {
+		return err
+	}
+
+	x := c.locationStack.popV128()
+	if err := c.compileEnsureOnGeneralPurposeRegister(x); err != nil {
+		return err
+	}
+
+	mul, add, sub := amd64.MULPS, amd64.ADDPS, amd64.SUBPS
+	if o.Shape == wazeroir.ShapeF64x2 {
+		mul, add, sub = amd64.MULPD, amd64.ADDPD, amd64.SUBPD
+	}
+
+	// The product is rounded on x before the addition, as there's no FMA without AVX.
+	c.assembler.CompileRegisterToRegister(mul, y.register, x.register)
+	result := x.register
+	if o.Negate {
+		c.assembler.CompileRegisterToRegister(sub, x.register, z.register)
+		result = z.register
+	} else {
+		c.assembler.CompileRegisterToRegister(add, z.register, x.register)
+	}
+
+	c.locationStack.markRegisterUnused(x.register, y.register, z.register)
+	c.pushVectorRuntimeValueLocationOnRegister(result)
+	return nil
+}
+
+// compileV128RelaxedMin implements compiler.compileV128RelaxedMin for amd64.
+func (c *amd64Compiler) compileV128RelaxedMin(o *wazeroir.OperationV128RelaxedMin) error {
+	if o.Shape == wazeroir.ShapeF32x4 {
+		return c.compileV128BinOpImpl(amd64.MINPS)
+	}
+	return c.compileV128BinOpImpl(amd64.MINPD)
+}
+
+// compileV128RelaxedMax implements compiler.compileV128RelaxedMax for amd64.
+func (c *amd64Compiler) compileV128RelaxedMax(o *wazeroir.OperationV128RelaxedMax) error {
+	if o.Shape == wazeroir.ShapeF32x4 {
+		return c.compileV128BinOpImpl(amd64.MAXPS)
+	}
+	return c.compileV128BinOpImpl(amd64.MAXPD)
+}
+
+// compileV128BinOpImpl adds the instruction inst on the two vectors on the top of the stack, where the result is on the
+// register of the first operand.
+func (c *amd64Compiler) compileV128BinOpImpl(inst asm.Instruction) error {
+	x2 := c.locationStack.popV128()
+	if err := c.compileEnsureOnGeneralPurposeRegister(x2); err != nil {
+		return err
+	}
+
+	x1 := c.locationStack.popV128()
+	if err := c.compileEnsureOnGeneralPurposeRegister(x1); err != nil {
+		return err
+	}
+
+	c.assembler.CompileRegisterToRegister(inst, x2.register, x1.register)
+
+	c.locationStack.markRegisterUnused(x2.register)
+	c.pushVectorRuntimeValueLocationOnRegister(x1.register)
+	return nil
+}
+
+// compileV128RelaxedQ15mulr implements compiler.compileV128RelaxedQ15mulr for amd64.
+func (c *amd64Compiler) compileV128RelaxedQ15mulr(*wazeroir.OperationV128RelaxedQ15mulr) error {
+	return c.compileV128BinOpImpl(amd64.PMULHRSW)
+}
+
+// compileV128RelaxedDot implements compiler.compileV128RelaxedDot for amd64.
+func (c *amd64Compiler) compileV128RelaxedDot(*wazeroir.OperationV128RelaxedDot) error {
+	x2 := c.locationStack.popV128()
+	if err := c.compileEnsureOnGeneralPurposeRegister(x2); err != nil {
+		return err
+	}
+
+	x1 := c.locationStack.popV128()
+	if err := c.compileEnsureOnGeneralPurposeRegister(x1); err != nil {
+		return err
+	}
+
+	// PMADDUBSW treats the destination as unsigned, so the result is on x2.
+	c.assembler.CompileRegisterToRegister(amd64.PMADDUBSW, x1.register, x2.register)
+
+	c.locationStack.markRegisterUnused(x1.register)
+	c.pushVectorRuntimeValueLocationOnRegister(x2.register)
+	return nil
+}
+
+// relaxedDotAddConst is the i16x8 splat of one.
+var relaxedDotAddConst = [16]byte{1, 0, 1, 0, 1, 0, 1, 0, 1, 0, 1, 0, 1, 0, 1, 0}
+
+// compileV128RelaxedDotAdd implements compiler.compileV128RelaxedDotAdd for amd64.
+func (c *amd64Compiler) compileV128RelaxedDotAdd(*wazeroir.OperationV128RelaxedDotAdd) error {
+	x3 := c.locationStack.popV128()
+	if err := c.compileEnsureOnGeneralPurposeRegister(x3); err != nil {
+		return err
+	}
+
+	x2 := c.locationStack.popV128()
+	if err := c.compileEnsureOnGeneralPurposeRegister(x2); err != nil {
+		return err
+	}
+
+	x1 := c.locationStack.popV128()
+	if err := c.compileEnsureOnGeneralPurposeRegister(x1); err != nil {
+		return err
+	}
+
+	// PMADDUBSW treats the destination as unsigned, so the result is on x2.
+	c.assembler.CompileRegisterToRegister(amd64.PMADDUBSW, x1.register, x2.register)
+	// Sum the adjacent i16 lanes into i32 lanes by multiplying them with ones, which reuses x1 for them.
+	if err := c.assembler.CompileLoadStaticConstToRegister(amd64.MOVDQU, relaxedDotAddConst[:], x1.register); err != nil {
+		return err
+	}
+	c.assembler.CompileRegisterToRegister(amd64.PMADDWD, x1.register, x2.register)
+	c.assembler.CompileRegisterToRegister(amd64.PADDL, x3.register, x2.register)
+
+	c.locationStack.markRegisterUnused(x1.register, x3.register)
+	c.pushVectorRuntimeValueLocationOnRegister(x2.register)
+	return nil
+}
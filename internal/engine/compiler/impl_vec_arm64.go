@@ -136,116 +136,116 @@ func (c *arm64Compiler) compileV128Load(o *wazeroir.OperationV128Load) (err erro
 
 	switch o.Type {
 	case wazeroir.LoadV128Type128:
-		offset, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, 16)
+		buffer, offset, err := c.compileMemoryAccessOffsetSetup(o.Arg, 16)
 		if err != nil {
 			return err
 		}
 		c.assembler.CompileMemoryWithRegisterOffsetToVectorRegister(arm64.VMOV,
-			arm64ReservedRegisterForMemory, offset, result, arm64.VectorArrangementQ,
+			buffer, offset, result, arm64.VectorArrangementQ,
 		)
 	case wazeroir.LoadV128Type8x8s:
-		offset, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, 8)
+		buffer, offset, err := c.compileMemoryAccessOffsetSetup(o.Arg, 8)
 		if err != nil {
 			return err
 		}
 		c.assembler.CompileMemoryWithRegisterOffsetToVectorRegister(arm64.VMOV,
-			arm64ReservedRegisterForMemory, offset, result, arm64.VectorArrangementD,
+			buffer, offset, result, arm64.VectorArrangementD,
 		)
 		c.assembler.CompileVectorRegisterToVectorRegister(arm64.SSHLL, result, result,
 			arm64.VectorArrangement8B, arm64.VectorIndexNone, arm64.VectorIndexNone)
 	case wazeroir.LoadV128Type8x8u:
-		offset, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, 8)
+		buffer, offset, err := c.compileMemoryAccessOffsetSetup(o.Arg, 8)
 		if err != nil {
 			return err
 		}
 		c.assembler.CompileMemoryWithRegisterOffsetToVectorRegister(arm64.VMOV,
-			arm64ReservedRegisterForMemory, offset, result, arm64.VectorArrangementD,
+			buffer, offset, result, arm64.VectorArrangementD,
 		)
 		c.assembler.CompileVectorRegisterToVectorRegister(arm64.USHLL, result, result,
 			arm64.VectorArrangement8B, arm64.VectorIndexNone, arm64.VectorIndexNone)
 	case wazeroir.LoadV128Type16x4s:
-		offset, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, 8)
+		buffer, offset, err := c.compileMemoryAccessOffsetSetup(o.Arg, 8)
 		if err != nil {
 			return err
 		}
 		c.assembler.CompileMemoryWithRegisterOffsetToVectorRegister(arm64.VMOV,
-			arm64ReservedRegisterForMemory, offset, result, arm64.VectorArrangementD,
+			buffer, offset, result, arm64.VectorArrangementD,
 		)
 		c.assembler.CompileVectorRegisterToVectorRegister(arm64.SSHLL, result, result,
 			arm64.VectorArrangement4H, arm64.VectorIndexNone, arm64.VectorIndexNone)
 	case wazeroir.LoadV128Type16x4u:
-		offset, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, 8)
+		buffer, offset, err := c.compileMemoryAccessOffsetSetup(o.Arg, 8)
 		if err != nil {
 			return err
 		}
 		c.assembler.CompileMemoryWithRegisterOffsetToVectorRegister(arm64.VMOV,
-			arm64ReservedRegisterForMemory, offset, result, arm64.VectorArrangementD,
+			buffer, offset, result, arm64.VectorArrangementD,
 		)
 		c.assembler.CompileVectorRegisterToVectorRegister(arm64.USHLL, result, result,
 			arm64.VectorArrangement4H, arm64.VectorIndexNone, arm64.VectorIndexNone)
 	case wazeroir.LoadV128Type32x2s:
-		offset, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, 8)
+		buffer, offset, err := c.compileMemoryAccessOffsetSetup(o.Arg, 8)
 		if err != nil {
 			return err
 		}
 		c.assembler.CompileMemoryWithRegisterOffsetToVectorRegister(arm64.VMOV,
-			arm64ReservedRegisterForMemory, offset, result, arm64.VectorArrangementD,
+			buffer, offset, result, arm64.VectorArrangementD,
 		)
 		c.assembler.CompileVectorRegisterToVectorRegister(arm64.SSHLL, result, result,
 			arm64.VectorArrangement2S, arm64.VectorIndexNone, arm64.VectorIndexNone)
 	case wazeroir.LoadV128Type32x2u:
-		offset, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, 8)
+		buffer, offset, err := c.compileMemoryAccessOffsetSetup(o.Arg, 8)
 		if err != nil {
 			return err
 		}
 		c.assembler.CompileMemoryWithRegisterOffsetToVectorRegister(arm64.VMOV,
-			arm64ReservedRegisterForMemory, offset, result, arm64.VectorArrangementD,
+			buffer, offset, result, arm64.VectorArrangementD,
 		)
 		c.assembler.CompileVectorRegisterToVectorRegister(arm64.USHLL, result, result,
 			arm64.VectorArrangement2S, arm64.VectorIndexNone, arm64.VectorIndexNone)
 	case wazeroir.LoadV128Type8Splat:
-		offset, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, 1)
+		buffer, offset, err := c.compileMemoryAccessOffsetSetup(o.Arg, 1)
 		if err != nil {
 			return err
 		}
-		c.assembler.CompileRegisterToRegister(arm64.ADD, arm64ReservedRegisterForMemory, offset)
+		c.assembler.CompileRegisterToRegister(arm64.ADD, buffer, offset)
 		c.assembler.CompileMemoryToVectorRegister(arm64.LD1R, offset, 0, result, arm64.VectorArrangement16B)
 	case wazeroir.LoadV128Type16Splat:
-		offset, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, 2)
+		buffer, offset, err := c.compileMemoryAccessOffsetSetup(o.Arg, 2)
 		if err != nil {
 			return err
 		}
-		c.assembler.CompileRegisterToRegister(arm64.ADD, arm64ReservedRegisterForMemory, offset)
+		c.assembler.CompileRegisterToRegister(arm64.ADD, buffer, offset)
 		c.assembler.CompileMemoryToVectorRegister(arm64.LD1R, offset, 0, result, arm64.VectorArrangement8H)
 	case wazeroir.LoadV128Type32Splat:
-		offset, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, 4)
+		buffer, offset, err := c.compileMemoryAccessOffsetSetup(o.Arg, 4)
 		if err != nil {
 			return err
 		}
-		c.assembler.CompileRegisterToRegister(arm64.ADD, arm64ReservedRegisterForMemory, offset)
+		c.assembler.CompileRegisterToRegister(arm64.ADD, buffer, offset)
 		c.assembler.CompileMemoryToVectorRegister(arm64.LD1R, offset, 0, result, arm64.VectorArrangement4S)
 	case wazeroir.LoadV128Type64Splat:
-		offset, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, 8)
+		buffer, offset, err := c.compileMemoryAccessOffsetSetup(o.Arg, 8)
 		if err != nil {
 			return err
 		}
-		c.assembler.CompileRegisterToRegister(arm64.ADD, arm64ReservedRegisterForMemory, offset)
+		c.assembler.CompileRegisterToRegister(arm64.ADD, buffer, offset)
 		c.assembler.CompileMemoryToVectorRegister(arm64.LD1R, offset, 0, result, arm64.VectorArrangement2D)
 	case wazeroir.LoadV128Type32zero:
-		offset, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, 16)
+		buffer, offset, err := c.compileMemoryAccessOffsetSetup(o.Arg, 16)
 		if err != nil {
 			return err
 		}
 		c.assembler.CompileMemoryWithRegisterOffsetToVectorRegister(arm64.VMOV,
-			arm64ReservedRegisterForMemory, offset, result, arm64.VectorArrangementS,
+			buffer, offset, result, arm64.VectorArrangementS,
 		)
 	case wazeroir.LoadV128Type64zero:
-		offset, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, 16)
+		buffer, offset, err := c.compileMemoryAccessOffsetSetup(o.Arg, 16)
 		if err != nil {
 			return err
 		}
 		c.assembler.CompileMemoryWithRegisterOffsetToVectorRegister(arm64.VMOV,
-			arm64ReservedRegisterForMemory, offset, result, arm64.VectorArrangementD,
+			buffer, offset, result, arm64.VectorArrangementD,
 		)
 	}
 
@@ -261,7 +261,7 @@ func (c *arm64Compiler) compileV128LoadLane(o *wazeroir.OperationV128LoadLane) (
 	}
 
 	targetSizeInBytes := int64(o.LaneSize / 8)
-	source, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, targetSizeInBytes)
+	buffer, source, err := c.compileMemoryAccessOffsetSetup(o.Arg, targetSizeInBytes)
 	if err != nil {
 		return err
 	}
@@ -283,7 +283,7 @@ func (c *arm64Compiler) compileV128LoadLane(o *wazeroir.OperationV128LoadLane) (
 		arr = arm64.VectorArrangementD
 	}
 
-	c.assembler.CompileMemoryWithRegisterOffsetToRegister(loadInst, arm64ReservedRegisterForMemory, source, source)
+	c.assembler.CompileMemoryWithRegisterOffsetToRegister(loadInst, buffer, source, source)
 	c.assembler.CompileRegisterToVectorRegister(arm64.VMOV, source, targetVector.register, arr, arm64.VectorIndex(o.LaneIndex))
 
 	c.pushVectorRuntimeValueLocationOnRegister(targetVector.register)
@@ -299,13 +299,13 @@ func (c *arm64Compiler) compileV128Store(o *wazeroir.OperationV128Store) (err er
 	}
 
 	const targetSizeInBytes = 16
-	offset, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, targetSizeInBytes)
+	buffer, offset, err := c.compileMemoryAccessOffsetSetup(o.Arg, targetSizeInBytes)
 	if err != nil {
 		return err
 	}
 
 	c.assembler.CompileVectorRegisterToMemoryWithRegisterOffset(arm64.VMOV,
-		v.register, arm64ReservedRegisterForMemory, offset, arm64.VectorArrangementQ)
+		v.register, buffer, offset, arm64.VectorArrangementQ)
 
 	c.markRegisterUnused(v.register)
 	return
@@ -336,7 +336,7 @@ func (c *arm64Compiler) compileV128StoreLane(o *wazeroir.OperationV128StoreLane)
 	}
 
 	targetSizeInBytes := int64(o.LaneSize / 8)
-	offset, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, targetSizeInBytes)
+	buffer, offset, err := c.compileMemoryAccessOffsetSetup(o.Arg, targetSizeInBytes)
 	if err != nil {
 		return err
 	}
@@ -345,7 +345,7 @@ func (c *arm64Compiler) compileV128StoreLane(o *wazeroir.OperationV128StoreLane)
 		arm64.VectorIndex(o.LaneIndex))
 
 	c.assembler.CompileRegisterToMemoryWithRegisterOffset(storeInst,
-		arm64ReservedRegisterForTemporary, arm64ReservedRegisterForMemory, offset)
+		arm64ReservedRegisterForTemporary, buffer, offset)
 
 	c.locationStack.markRegisterUnused(v.register)
 	return
@@ -702,3 +702,38 @@ func (c *arm64Compiler) compileV128Shl(o *wazeroir.OperationV128Shl) error {
 func (c *arm64Compiler) compileV128Cmp(o *wazeroir.OperationV128Cmp) error {
 	return fmt.Errorf("TODO: %s is not implemented yet on arm64 compiler", o.Kind())
 }
+
+// compileV128RelaxedTrunc implements compiler.compileV128RelaxedTrunc for arm64.
+func (c *arm64Compiler) compileV128RelaxedTrunc(o *wazeroir.OperationV128RelaxedTrunc) error {
+	return fmt.Errorf("TODO: %s is not implemented yet on arm64 compiler", o.Kind())
+}
+
+// compileV128RelaxedMadd implements compiler.compileV128RelaxedMadd for arm64.
+func (c *arm64Compiler) compileV128RelaxedMadd(o *wazeroir.OperationV128RelaxedMadd) error {
+	return fmt.Errorf("TODO: %s is not implemented yet on arm64 compiler", o.Kind())
+}
+
+// compileV128RelaxedMin implements compiler.compileV128RelaxedMin for arm64.
+func (c *arm64Compiler) compileV128RelaxedMin(o *wazeroir.OperationV128RelaxedMin) error {
+	return fmt.Errorf("TODO: %s is not implemented yet on arm64 compiler", o.Kind())
+}
+
+// compileV128RelaxedMax implements compiler.compileV128RelaxedMax for arm64.
+func (c *arm64Compiler) compileV128RelaxedMax(o *wazeroir.OperationV128RelaxedMax) error {
+	return fmt.Errorf("TODO: %s is not implemented yet on arm64 compiler", o.Kind())
+}
+
+// compileV128RelaxedQ15mulr implements compiler.compileV128RelaxedQ15mulr for arm64.
+func (c *arm64Compiler) compileV128RelaxedQ15mulr(o *wazeroir.OperationV128RelaxedQ15mulr) error {
+	return fmt.Errorf("TODO: %s is not implemented yet on arm64 compiler", o.Kind())
+}
+
+// compileV128RelaxedDot implements compiler.compileV128RelaxedDot for arm64.
+func (c *arm64Compiler) compileV128RelaxedDot(o *wazeroir.OperationV128RelaxedDot) error {
+	return fmt.Errorf("TODO: %s is not implemented yet on arm64 compiler", o.Kind())
+}
+
+// compileV128RelaxedDotAdd implements compiler.compileV128RelaxedDotAdd for arm64.
+func (c *arm64Compiler) compileV128RelaxedDotAdd(o *wazeroir.OperationV128RelaxedDotAdd) error {
+	return fmt.Errorf("TODO: %s is not implemented yet on arm64 compiler", o.Kind())
+}
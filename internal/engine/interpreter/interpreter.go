@@ -9,15 +9,18 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"unsafe"
 
 	"github.com/tetratelabs/wazero/experimental"
 	"github.com/tetratelabs/wazero/internal/buildoptions"
 	"github.com/tetratelabs/wazero/internal/moremath"
+	internalsys "github.com/tetratelabs/wazero/internal/sys"
 	"github.com/tetratelabs/wazero/internal/wasm"
 	"github.com/tetratelabs/wazero/internal/wasmdebug"
 	"github.com/tetratelabs/wazero/internal/wasmruntime"
 	"github.com/tetratelabs/wazero/internal/wazeroir"
+	"github.com/tetratelabs/wazero/sys"
 )
 
 var callStackCeiling = buildoptions.CallStackCeiling
@@ -25,14 +28,24 @@ var callStackCeiling = buildoptions.CallStackCeiling
 // engine is an interpreter implementation of wasm.Engine
 type engine struct {
 	enabledFeatures wasm.Features
-	codes           map[wasm.ModuleID][]*code // guarded by mutex.
-	mux             sync.RWMutex
+	// ensureTermination is true when function entries and loop headers check if the context.Context is done.
+	ensureTermination bool
+	// meterFuel is true when each basic block consumes the fuel of the context.Context.
+	meterFuel bool
+	// coverage is true when each basic block counts how many times it ran.
+	coverage bool
+	codes    map[wasm.ModuleID][]*code // guarded by mutex.
+	mux      sync.RWMutex
 }
 
-func NewEngine(enabledFeatures wasm.Features) wasm.Engine {
+// NewEngine returns an interpreter. As this doesn't compile to native code, wasm.EngineConfig Cache is ignored.
+func NewEngine(enabledFeatures wasm.Features, config wasm.EngineConfig) wasm.Engine {
 	return &engine{
-		enabledFeatures: enabledFeatures,
-		codes:           map[wasm.ModuleID][]*code{},
+		enabledFeatures:   enabledFeatures,
+		ensureTermination: config.EnsureTermination,
+		meterFuel:         config.MeterFuel,
+		coverage:          config.Coverage,
+		codes:             map[wasm.ModuleID][]*code{},
 	}
 }
 
@@ -46,6 +59,18 @@ func (e *engine) DeleteCompiledModule(m *wasm.Module) {
 	e.deleteCodes(m)
 }
 
+// Coverage implements the same method as documented on wasm.Engine.
+func (e *engine) Coverage(module *wasm.Module) (ret []*wasm.FunctionCoverage) {
+	codes, ok := e.getCodes(module)
+	if !ok || !e.coverage || module.IsHostModule() {
+		return
+	}
+	for _, c := range codes {
+		ret = append(ret, c.coverage)
+	}
+	return
+}
+
 func (e *engine) deleteCodes(module *wasm.Module) {
 	e.mux.Lock()
 	defer e.mux.Unlock()
@@ -88,6 +113,19 @@ type callEngine struct {
 
 	// frames are the function call stack.
 	frames []*callFrame
+
+	// fuel is consumed by wazeroir.OperationConsumeFuel, or nil if unlimited.
+	fuel *internalsys.Fuel
+
+	// exceptions are the exceptions caught by catch clauses, indexed by their handles, in the order of their positions
+	// in the stack.
+	exceptions []caughtException
+}
+
+// caughtException is an exception caught by a catch clause, whose handle is on the stack at the position.
+type caughtException struct {
+	position int
+	exc      *sys.Exception
 }
 
 func (me *moduleEngine) newCallEngine() *callEngine {
@@ -111,17 +149,13 @@ func (ce *callEngine) popValue() (v uint64) {
 	return
 }
 
-// peekValues peeks api.ValueType values from the stack and returns them in reverse order.
+// peekValues peeks api.ValueType values from the top of the stack and returns them in the order they were pushed.
 func (ce *callEngine) peekValues(count int) []uint64 {
 	if count == 0 {
 		return nil
 	}
-	stackTopIndex := len(ce.stack) - 1
-	peeked := ce.stack[stackTopIndex-count : stackTopIndex]
-	values := make([]uint64, 0, count)
-	for i := count - 1; i >= 0; i-- {
-		values = append(values, peeked[i])
-	}
+	values := make([]uint64, count)
+	copy(values, ce.stack[len(ce.stack)-count:])
 	return values
 }
 
@@ -150,6 +184,18 @@ func (ce *callEngine) pushFrame(frame *callFrame) {
 	ce.frames = append(ce.frames, frame)
 }
 
+// newFrame returns a frame to call f, reusing the one left above the top of the call stack by a previous call at the
+// same depth if any, so that calls don't allocate their frames.
+func (ce *callEngine) newFrame(f *function) *callFrame {
+	if top := len(ce.frames); top < cap(ce.frames) {
+		if frame := ce.frames[:top+1][top]; frame != nil {
+			*frame = callFrame{f: f}
+			return frame
+		}
+	}
+	return &callFrame{f: f}
+}
+
 func (ce *callEngine) popFrame() (frame *callFrame) {
 	// No need to check stack bound as we can assume that all the operations are valid thanks to validateFunction at
 	// module validation phase and wazeroir translation before compilation.
@@ -164,17 +210,43 @@ type callFrame struct {
 	pc uint64
 	// f is the compiled function used in this function frame.
 	f *function
+	// base is the position in the stack of the first param of the function, only set if it has try blocks.
+	base int
 }
 
 type code struct {
-	body   []*interpreterOp
-	hostFn *reflect.Value
+	body []*interpreterOp
+	// instructionOffsets are index-correlated with body. See wazeroir.CompilationResult InstructionOffsets
+	instructionOffsets []uint64
+	hostFn             *reflect.Value
+	// coverage is counted by wazeroir.OperationKindCoverBlock, or nil if engine.coverage is false.
+	coverage *wasm.FunctionCoverage
+	// tryBlocks are index-correlated with wazeroir.CompilationResult TryBlocks.
+	tryBlocks []*tryBlock
 }
 
 type function struct {
-	source *wasm.FunctionInstance
-	body   []*interpreterOp
-	hostFn *reflect.Value
+	source             *wasm.FunctionInstance
+	body               []*interpreterOp
+	instructionOffsets []uint64
+	hostFn             *reflect.Value
+	tryBlocks          []*tryBlock
+}
+
+// tryBlock is a wazeroir.TryBlock with the positions of its operations in code.body.
+type tryBlock struct {
+	*wazeroir.TryBlock
+	// start and end are the positions of the first operation of the body, and of the first operation after it.
+	start, end uint64
+	catches    []catchClause
+}
+
+// catchClause is a catch clause of a tryBlock, which begins at the position pc in code.body.
+type catchClause struct {
+	pc       uint64
+	tagIndex uint32
+	// all is true for wazeroir.OperationCatchAll, which catches exceptions of any tag.
+	all bool
 }
 
 // functionFromUintptr resurrects the original *function from the given uintptr
@@ -191,9 +263,11 @@ func functionFromUintptr(ptr uintptr) *function {
 
 func (c *code) instantiate(f *wasm.FunctionInstance) *function {
 	return &function{
-		source: f,
-		body:   c.body,
-		hostFn: c.hostFn,
+		source:             f,
+		body:               c.body,
+		instructionOffsets: c.instructionOffsets,
+		hostFn:             c.hostFn,
+		tryBlocks:          c.tryBlocks,
 	}
 }
 
@@ -211,6 +285,8 @@ type interpreterOp struct {
 	b3     bool
 	us     []uint64
 	rs     []*wazeroir.InclusiveRange
+	// memoryIndex is the memory accessed by memory instructions, which is only non-zero with wasm.FeatureMultiMemory.
+	memoryIndex wasm.Index
 }
 
 // CompileModule implements the same method as documented on wasm.Engine.
@@ -228,7 +304,7 @@ func (e *engine) CompileModule(ctx context.Context, module *wasm.Module) error {
 			funcs = append(funcs, &code{hostFn: hf})
 		}
 	} else {
-		irs, err := wazeroir.CompileFunctions(ctx, e.enabledFeatures, module)
+		irs, err := wazeroir.CompileFunctions(ctx, e.enabledFeatures, e.meterFuel, e.coverage, module)
 		if err != nil {
 			return err
 		}
@@ -289,9 +365,20 @@ func (e *engine) NewModuleEngine(name string, module *wasm.Module, importedFunct
 func (e *engine) lowerIR(ir *wazeroir.CompilationResult) (*code, error) {
 	ops := ir.Operations
 	ret := &code{}
+	if e.coverage {
+		ret.coverage = wasm.NewFunctionCoverage(ir.BlockOffsets)
+	}
 	labelAddress := map[string]uint64{}
 	onLabelAddressResolved := map[string][]func(addr uint64){}
-	for _, original := range ops {
+	for _, t := range ir.TryBlocks {
+		ret.tryBlocks = append(ret.tryBlocks, &tryBlock{TryBlock: t})
+	}
+	if e.ensureTermination {
+		// Check if the context is done on function entry.
+		ret.body = append(ret.body, &interpreterOp{kind: wazeroir.OperationKindLabel})
+		ret.instructionOffsets = append(ret.instructionOffsets, 0)
+	}
+	for i, original := range ops {
 		op := &interpreterOp{kind: original.Kind()}
 		switch o := original.(type) {
 		case *wazeroir.OperationUnreachable:
@@ -303,6 +390,11 @@ func (e *engine) lowerIR(ir *wazeroir.CompilationResult) (*code, error) {
 				cb(address)
 			}
 			delete(onLabelAddressResolved, labelKey)
+			if _, ok := ir.LoopLabels[labelKey]; ok && e.ensureTermination {
+				// Keep the label of a loop header as the point to check if the context is done, as that is
+				// the target of all backward branches.
+				break
+			}
 			// We just ignore the label operation
 			// as we translate branch operations to the direct address jmp.
 			continue
@@ -384,6 +476,28 @@ func (e *engine) lowerIR(ir *wazeroir.CompilationResult) (*code, error) {
 			op.us = make([]uint64, 2)
 			op.us[0] = uint64(o.TypeIndex)
 			op.us[1] = uint64(o.TableIndex)
+		case *wazeroir.OperationTailCall:
+			op.us = []uint64{uint64(o.FunctionIndex)}
+		case *wazeroir.OperationTailCallIndirect:
+			op.us = []uint64{uint64(o.TypeIndex), uint64(o.TableIndex)}
+		case *wazeroir.OperationTry:
+			// The try block is only the range of the operations of its body, which are handled by callEngine.catch.
+			ret.tryBlocks[o.Index].start = uint64(len(ret.body))
+			continue
+		case *wazeroir.OperationTryEnd:
+			ret.tryBlocks[o.Index].end = uint64(len(ret.body))
+			continue
+		case *wazeroir.OperationCatch:
+			t := ret.tryBlocks[o.TryIndex]
+			t.catches = append(t.catches, catchClause{pc: uint64(len(ret.body)), tagIndex: o.TagIndex})
+			continue
+		case *wazeroir.OperationCatchAll:
+			t := ret.tryBlocks[o.TryIndex]
+			t.catches = append(t.catches, catchClause{pc: uint64(len(ret.body)), all: true})
+			continue
+		case *wazeroir.OperationThrow:
+			op.us = []uint64{uint64(o.TagIndex)}
+		case *wazeroir.OperationRethrow:
 		case *wazeroir.OperationDrop:
 			op.rs = make([]*wazeroir.InclusiveRange, 1)
 			op.rs[0] = o.Depth
@@ -407,16 +521,19 @@ func (e *engine) lowerIR(ir *wazeroir.CompilationResult) (*code, error) {
 			op.us = make([]uint64, 2)
 			op.us[0] = uint64(o.Arg.Alignment)
 			op.us[1] = uint64(o.Arg.Offset)
+			op.memoryIndex = o.Arg.MemoryIndex
 		case *wazeroir.OperationLoad8:
 			op.b1 = byte(o.Type)
 			op.us = make([]uint64, 2)
 			op.us[0] = uint64(o.Arg.Alignment)
 			op.us[1] = uint64(o.Arg.Offset)
+			op.memoryIndex = o.Arg.MemoryIndex
 		case *wazeroir.OperationLoad16:
 			op.b1 = byte(o.Type)
 			op.us = make([]uint64, 2)
 			op.us[0] = uint64(o.Arg.Alignment)
 			op.us[1] = uint64(o.Arg.Offset)
+			op.memoryIndex = o.Arg.MemoryIndex
 		case *wazeroir.OperationLoad32:
 			if o.Signed {
 				op.b1 = 1
@@ -424,27 +541,34 @@ func (e *engine) lowerIR(ir *wazeroir.CompilationResult) (*code, error) {
 			op.us = make([]uint64, 2)
 			op.us[0] = uint64(o.Arg.Alignment)
 			op.us[1] = uint64(o.Arg.Offset)
+			op.memoryIndex = o.Arg.MemoryIndex
 		case *wazeroir.OperationStore:
 			op.b1 = byte(o.Type)
 			op.us = make([]uint64, 2)
 			op.us[0] = uint64(o.Arg.Alignment)
 			op.us[1] = uint64(o.Arg.Offset)
+			op.memoryIndex = o.Arg.MemoryIndex
 		case *wazeroir.OperationStore8:
 			op.b1 = byte(o.Type)
 			op.us = make([]uint64, 2)
 			op.us[0] = uint64(o.Arg.Alignment)
 			op.us[1] = uint64(o.Arg.Offset)
+			op.memoryIndex = o.Arg.MemoryIndex
 		case *wazeroir.OperationStore16:
 			op.b1 = byte(o.Type)
 			op.us = make([]uint64, 2)
 			op.us[0] = uint64(o.Arg.Alignment)
 			op.us[1] = uint64(o.Arg.Offset)
+			op.memoryIndex = o.Arg.MemoryIndex
 		case *wazeroir.OperationStore32:
 			op.us = make([]uint64, 2)
 			op.us[0] = uint64(o.Arg.Alignment)
 			op.us[1] = uint64(o.Arg.Offset)
+			op.memoryIndex = o.Arg.MemoryIndex
 		case *wazeroir.OperationMemorySize:
+			op.memoryIndex = o.MemoryIndex
 		case *wazeroir.OperationMemoryGrow:
+			op.memoryIndex = o.MemoryIndex
 		case *wazeroir.OperationConstI32:
 			op.us = make([]uint64, 1)
 			op.us[0] = uint64(o.Value)
@@ -549,11 +673,16 @@ func (e *engine) lowerIR(ir *wazeroir.CompilationResult) (*code, error) {
 		case *wazeroir.OperationMemoryInit:
 			op.us = make([]uint64, 1)
 			op.us[0] = uint64(o.DataIndex)
+			op.memoryIndex = o.MemoryIndex
 		case *wazeroir.OperationDataDrop:
 			op.us = make([]uint64, 1)
 			op.us[0] = uint64(o.DataIndex)
 		case *wazeroir.OperationMemoryCopy:
+			op.us = make([]uint64, 1)
+			op.us[0] = uint64(o.SrcMemoryIndex)
+			op.memoryIndex = o.DstMemoryIndex
 		case *wazeroir.OperationMemoryFill:
+			op.memoryIndex = o.MemoryIndex
 		case *wazeroir.OperationTableInit:
 			op.us = make([]uint64, 2)
 			op.us[0] = uint64(o.ElemIndex)
@@ -596,22 +725,26 @@ func (e *engine) lowerIR(ir *wazeroir.CompilationResult) (*code, error) {
 			op.us = make([]uint64, 2)
 			op.us[0] = uint64(o.Arg.Alignment)
 			op.us[1] = uint64(o.Arg.Offset)
+			op.memoryIndex = o.Arg.MemoryIndex
 		case *wazeroir.OperationV128LoadLane:
 			op.b1 = o.LaneSize
 			op.b2 = o.LaneIndex
 			op.us = make([]uint64, 2)
 			op.us[0] = uint64(o.Arg.Alignment)
 			op.us[1] = uint64(o.Arg.Offset)
+			op.memoryIndex = o.Arg.MemoryIndex
 		case *wazeroir.OperationV128Store:
 			op.us = make([]uint64, 2)
 			op.us[0] = uint64(o.Arg.Alignment)
 			op.us[1] = uint64(o.Arg.Offset)
+			op.memoryIndex = o.Arg.MemoryIndex
 		case *wazeroir.OperationV128StoreLane:
 			op.b1 = o.LaneSize
 			op.b2 = o.LaneIndex
 			op.us = make([]uint64, 2)
 			op.us[0] = uint64(o.Arg.Alignment)
 			op.us[1] = uint64(o.Arg.Offset)
+			op.memoryIndex = o.Arg.MemoryIndex
 		case *wazeroir.OperationV128ExtractLane:
 			op.b1 = o.Shape
 			op.b2 = o.LaneIndex
@@ -645,10 +778,58 @@ func (e *engine) lowerIR(ir *wazeroir.CompilationResult) (*code, error) {
 			op.b1 = o.Shape
 		case *wazeroir.OperationV128Cmp:
 			op.b1 = o.Type
+		case *wazeroir.OperationV128RelaxedTrunc:
+			op.b1 = o.OriginShape
+			op.b3 = o.Signed
+		case *wazeroir.OperationV128RelaxedMadd:
+			op.b1 = o.Shape
+			op.b3 = o.Negate
+		case *wazeroir.OperationV128RelaxedMin:
+			op.b1 = o.Shape
+		case *wazeroir.OperationV128RelaxedMax:
+			op.b1 = o.Shape
+		case *wazeroir.OperationV128RelaxedQ15mulr:
+		case *wazeroir.OperationV128RelaxedDot:
+		case *wazeroir.OperationV128RelaxedDotAdd:
+		case *wazeroir.OperationAtomicLoad:
+			op.b1 = byte(o.Type)
+			op.us = []uint64{uint64(o.Size), uint64(o.Arg.Offset)}
+			op.memoryIndex = o.Arg.MemoryIndex
+		case *wazeroir.OperationAtomicStore:
+			op.b1 = byte(o.Type)
+			op.us = []uint64{uint64(o.Size), uint64(o.Arg.Offset)}
+			op.memoryIndex = o.Arg.MemoryIndex
+		case *wazeroir.OperationAtomicRMW:
+			op.b1 = byte(o.Type)
+			op.b2 = byte(o.Op)
+			op.us = []uint64{uint64(o.Size), uint64(o.Arg.Offset)}
+			op.memoryIndex = o.Arg.MemoryIndex
+		case *wazeroir.OperationAtomicCmpxchg:
+			op.b1 = byte(o.Type)
+			op.us = []uint64{uint64(o.Size), uint64(o.Arg.Offset)}
+			op.memoryIndex = o.Arg.MemoryIndex
+		case *wazeroir.OperationMemoryWait:
+			op.b1 = byte(o.Type)
+			size := uint64(4)
+			if o.Type == wazeroir.UnsignedInt64 {
+				size = 8
+			}
+			op.us = []uint64{size, uint64(o.Arg.Offset)}
+			op.memoryIndex = o.Arg.MemoryIndex
+		case *wazeroir.OperationMemoryNotify:
+			op.us = []uint64{4, uint64(o.Arg.Offset)}
+			op.memoryIndex = o.Arg.MemoryIndex
+		case *wazeroir.OperationAtomicFence:
+		case *wazeroir.OperationConsumeFuel:
+			op.us = []uint64{o.Cost}
+		case *wazeroir.OperationCoverBlock:
+			// Share the counter of the block, so that it is incremented in place.
+			op.us = ret.coverage.Counts[o.Block : o.Block+1]
 		default:
 			panic(fmt.Errorf("BUG: unimplemented operation %s", op.kind.String()))
 		}
 		ret.body = append(ret.body, op)
+		ret.instructionOffsets = append(ret.instructionOffsets, ir.InstructionOffsets[i])
 	}
 
 	if len(onLabelAddressResolved) > 0 {
@@ -680,6 +861,11 @@ func (me *moduleEngine) CreateFuncElementInstance(indexes []*wasm.Index) *wasm.E
 	}
 }
 
+// LookupFunction implements the same method as documented on wasm.ModuleEngine.
+func (me *moduleEngine) LookupFunction(ref wasm.Reference) *wasm.FunctionInstance {
+	return functionFromUintptr(ref).source
+}
+
 // InitializeFuncrefGlobals implements the same method as documented on wasm.InitializeFuncrefGlobals.
 func (me *moduleEngine) InitializeFuncrefGlobals(globals []*wasm.GlobalInstance) {
 	for _, g := range globals {
@@ -713,6 +899,9 @@ func (me *moduleEngine) Call(ctx context.Context, m *wasm.CallContext, f *wasm.F
 	}
 
 	ce := me.newCallEngine()
+	if me.parentEngine.meterFuel {
+		ce.fuel = internalsys.FuelFromContext(ctx)
+	}
 	defer func() {
 		// If the module closed during the call, and the call didn't err for another reason, set an ExitError.
 		if err == nil {
@@ -725,26 +914,23 @@ func (me *moduleEngine) Call(ctx context.Context, m *wasm.CallContext, f *wasm.F
 			frameCount := len(ce.frames)
 			for i := 0; i < frameCount; i++ {
 				frame := ce.popFrame()
+				offset := int64(-1)
+				if frame.pc < uint64(len(frame.f.instructionOffsets)) {
+					offset = int64(frame.f.instructionOffsets[frame.pc])
+				}
 				fn := frame.f.source
-				builder.AddFrame(fn.DebugName, fn.ParamTypes(), fn.ResultTypes())
+				builder.AddFrame(fn.DebugName, fn.StackFrame(offset))
 			}
 			err = builder.FromRecovered(v)
 		}
 	}()
 
 	if f.Kind == wasm.FunctionKindWasm {
-		if f.FunctionListener != nil {
-			ctx = f.FunctionListener.Before(ctx, params)
-		}
 		for _, param := range params {
 			ce.pushValue(param)
 		}
 		ce.callNativeFunc(ctx, m, compiled)
 		results = wasm.PopValues(f.Type.ResultNumInUint64, ce.popValue)
-		if f.FunctionListener != nil {
-			// TODO: This doesn't get the error due to use of panic to propagate them.
-			f.FunctionListener.After(ctx, nil, results)
-		}
 	} else {
 		results = ce.callGoFunc(ctx, m, compiled, params)
 	}
@@ -754,13 +940,12 @@ func (me *moduleEngine) Call(ctx context.Context, m *wasm.CallContext, f *wasm.F
 func (ce *callEngine) callGoFunc(ctx context.Context, callCtx *wasm.CallContext, f *function, params []uint64) (results []uint64) {
 	if len(ce.frames) > 0 {
 		// Use the caller's memory, which might be different from the defining module on an imported function.
-		callCtx = callCtx.WithMemory(ce.frames[len(ce.frames)-1].f.source.Module.Memory)
+		callCtx = callCtx.WithMemoryOf(ce.frames[len(ce.frames)-1].f.source.Module)
 	}
 	if f.source.FunctionListener != nil {
 		ctx = f.source.FunctionListener.Before(ctx, params)
 	}
-	frame := &callFrame{f: f}
-	ce.pushFrame(frame)
+	ce.pushFrame(ce.newFrame(f))
 	results = wasm.CallGoFunc(ctx, callCtx, f.source, params)
 	ce.popFrame()
 	if f.source.FunctionListener != nil {
@@ -770,18 +955,129 @@ func (ce *callEngine) callGoFunc(ctx context.Context, callCtx *wasm.CallContext,
 	return
 }
 
+// callNativeFunc calls f, notifying its listener if any.
 func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallContext, f *function) {
-	frame := &callFrame{f: f}
+	// A tail call returns the function replacing f, which is called in this loop so that the stack doesn't grow, even
+	// when the functions have listeners.
+	for f != nil {
+		if fnl := f.source.FunctionListener; fnl != nil {
+			f = ce.execNativeFuncWithListener(ctx, callCtx, f, fnl)
+		} else {
+			f = ce.execNativeFunc(ctx, callCtx, f)
+		}
+	}
+}
+
+// tailCall replaces the frame of the current function with a call of f, whose parameters are the only values on the
+// stack of the current function. This returns f if it should be executed by callNativeFunc in place of the current
+// function, or nil if it was called already.
+func (ce *callEngine) tailCall(ctx context.Context, callCtx *wasm.CallContext, f *function) *function {
+	if f.hostFn != nil {
+		// Call the host function with the memory of the current function, before returning its results.
+		ce.callGoFuncWithStack(ctx, callCtx, f)
+		ce.popFrame()
+		return nil
+	}
+	ce.popFrame()
+	return f
+}
+
+// execNativeFunc executes f until it returns, or makes a tail call, in which case this returns the called function.
+func (ce *callEngine) execNativeFunc(ctx context.Context, callCtx *wasm.CallContext, f *function) *function {
+	frame := ce.newFrame(f)
+	ce.pushFrame(frame)
+	if len(f.tryBlocks) == 0 {
+		return ce.exec(ctx, callCtx, frame)
+	}
+	frame.base = len(ce.stack) - f.source.Type.ParamNumInUint64
+	// Resume the function at the catch clause of each exception it catches.
+	for {
+		if next, caught := ce.execCatching(ctx, callCtx, frame); !caught {
+			return next
+		}
+	}
+}
+
+// execCatching calls exec, recovering from exceptions caught by the function of the frame, in which case this returns
+// true, and the frame is at the catch clause.
+func (ce *callEngine) execCatching(ctx context.Context, callCtx *wasm.CallContext, frame *callFrame) (next *function, caught bool) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			exc := wasm.ExceptionFromRecovered(recovered)
+			if exc == nil || !ce.catch(frame, exc) {
+				panic(recovered)
+			}
+			caught = true
+		}
+	}()
+	return ce.exec(ctx, callCtx, frame), false
+}
+
+// catch returns true if the exception thrown at the pc of the frame is caught by its function. In that case, the stack
+// is unwound to the frame, followed by the handle and values of the exception, and the pc is set to the catch clause.
+//
+// See wazeroir.TryBlock
+func (ce *callEngine) catch(frame *callFrame, exc *sys.Exception) bool {
+	tryBlocks := frame.f.tryBlocks
+	tags := frame.f.source.Module.Tags
+
+	// Try blocks are in the order of their beginning, so the last one including the pc is the innermost.
+	index := -1
+	for i := len(tryBlocks) - 1; i >= 0; i-- {
+		if t := tryBlocks[i]; t.start <= frame.pc && frame.pc < t.end {
+			index = i
+			break
+		}
+	}
+	for index >= 0 {
+		t := tryBlocks[index]
+		if t.Delegate {
+			index = t.DelegateTarget
+			continue
+		}
+		for _, c := range t.catches {
+			if !c.all && !tags[c.tagIndex].Matches(exc) {
+				continue
+			}
+			for ce.frames[len(ce.frames)-1] != frame {
+				ce.popFrame()
+			}
+			position := frame.base + t.StackHeight
+			ce.stack = ce.stack[:position]
+			ce.pushValue(ce.addException(position, exc))
+			if !c.all {
+				ce.stack = append(ce.stack, exc.Values()...)
+			}
+			frame.pc = c.pc
+			return true
+		}
+		index = t.Parent
+	}
+	return false
+}
+
+// addException returns the handle of the exception caught at the position of the stack. Exceptions caught at or above
+// the position are removed, as their catch clauses were unwound.
+func (ce *callEngine) addException(position int, exc *sys.Exception) uint64 {
+	i := len(ce.exceptions)
+	for i > 0 && ce.exceptions[i-1].position >= position {
+		i--
+	}
+	ce.exceptions = append(ce.exceptions[:i], caughtException{position: position, exc: exc})
+	return uint64(i)
+}
+
+// exec executes the function of the frame from its pc until it returns, or makes a tail call, in which case this
+// returns the called function.
+func (ce *callEngine) exec(ctx context.Context, callCtx *wasm.CallContext, frame *callFrame) *function {
+	f := frame.f
 	moduleInst := f.source.Module
-	memoryInst := moduleInst.Memory
 	globals := moduleInst.Globals
 	tables := moduleInst.Tables
 	typeIDs := f.source.Module.TypeIDs
 	functions := f.source.Module.Engine.(*moduleEngine).functions
 	dataInstances := f.source.Module.DataInstances
 	elementInstances := f.source.Module.ElementInstances
-	listener := f.source.FunctionListener
-	ce.pushFrame(frame)
 	bodyLen := uint64(len(frame.f.body))
 	for frame.pc < bodyLen {
 		op := frame.f.body[frame.pc]
@@ -791,6 +1087,18 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 		switch op.kind {
 		case wazeroir.OperationKindUnreachable:
 			panic(wasmruntime.ErrRuntimeUnreachable)
+		case wazeroir.OperationKindLabel:
+			// Labels are only kept when engine.ensureTermination is enabled.
+			checkContextDone(ctx)
+			frame.pc++
+		case wazeroir.OperationKindConsumeFuel:
+			if ce.fuel != nil && !ce.fuel.Consume(op.us[0]) {
+				panic(wasmruntime.ErrRuntimeOutOfFuel)
+			}
+			frame.pc++
+		case wazeroir.OperationKindCoverBlock:
+			atomic.AddUint64(&op.us[0], 1)
+			frame.pc++
 		case wazeroir.OperationKindBr:
 			frame.pc = op.us[0]
 		case wazeroir.OperationKindBrIf:
@@ -814,37 +1122,24 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			f := functions[op.us[0]]
 			if f.hostFn != nil {
 				ce.callGoFuncWithStack(ctx, callCtx, f)
-			} else if listener != nil {
-				ctx = ce.callNativeFuncWithListener(ctx, callCtx, f, listener)
 			} else {
 				ce.callNativeFunc(ctx, callCtx, f)
 			}
 			frame.pc++
 		case wazeroir.OperationKindCallIndirect:
-			offset := ce.popValue()
-			table := tables[op.us[1]]
-			if offset >= uint64(len(table.References)) {
-				panic(wasmruntime.ErrRuntimeInvalidTableAccess)
-			}
-			rawPtr := table.References[offset]
-			if rawPtr == 0 {
-				panic(wasmruntime.ErrRuntimeInvalidTableAccess)
-			}
-
-			tf := functionFromUintptr(rawPtr)
-			if tf.source.TypeID != typeIDs[op.us[0]] {
-				panic(wasmruntime.ErrRuntimeIndirectCallTypeMismatch)
-			}
+			tf := ce.callIndirectTarget(tables[op.us[1]], typeIDs[op.us[0]])
 
 			// Call in.
 			if tf.hostFn != nil {
 				ce.callGoFuncWithStack(ctx, callCtx, tf)
-			} else if listener != nil {
-				ctx = ce.callNativeFuncWithListener(ctx, callCtx, f, listener)
 			} else {
 				ce.callNativeFunc(ctx, callCtx, tf)
 			}
 			frame.pc++
+		case wazeroir.OperationKindTailCall:
+			return ce.tailCall(ctx, callCtx, functions[op.us[0]])
+		case wazeroir.OperationKindTailCallIndirect:
+			return ce.tailCall(ctx, callCtx, ce.callIndirectTarget(tables[op.us[1]], typeIDs[op.us[0]]))
 		case wazeroir.OperationKindDrop:
 			ce.drop(op.rs[0])
 			frame.pc++
@@ -888,27 +1183,18 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			g.Val = ce.popValue()
 			frame.pc++
 		case wazeroir.OperationKindLoad:
-			offset := ce.popMemoryOffset(op)
+			memoryInst := moduleInst.MemoryAt(op.memoryIndex)
+			offset := ce.popMemoryOffset(op, memoryInst)
 			switch wazeroir.UnsignedType(op.b1) {
 			case wazeroir.UnsignedTypeI32, wazeroir.UnsignedTypeF32:
-				if val, ok := memoryInst.ReadUint32Le(ctx, offset); !ok {
-					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-				} else {
-					ce.pushValue(uint64(val))
-				}
+				ce.pushValue(uint64(binary.LittleEndian.Uint32(memoryView(memoryInst, offset, 4))))
 			case wazeroir.UnsignedTypeI64, wazeroir.UnsignedTypeF64:
-				if val, ok := memoryInst.ReadUint64Le(ctx, offset); !ok {
-					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-				} else {
-					ce.pushValue(val)
-				}
+				ce.pushValue(binary.LittleEndian.Uint64(memoryView(memoryInst, offset, 8)))
 			}
 			frame.pc++
 		case wazeroir.OperationKindLoad8:
-			val, ok := memoryInst.ReadByte(ctx, ce.popMemoryOffset(op))
-			if !ok {
-				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-			}
+			memoryInst := moduleInst.MemoryAt(op.memoryIndex)
+			val := memoryView(memoryInst, ce.popMemoryOffset(op, memoryInst), 1)[0]
 
 			switch wazeroir.SignedInt(op.b1) {
 			case wazeroir.SignedInt32, wazeroir.SignedInt64:
@@ -918,10 +1204,8 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			}
 			frame.pc++
 		case wazeroir.OperationKindLoad16:
-			val, ok := memoryInst.ReadUint16Le(ctx, ce.popMemoryOffset(op))
-			if !ok {
-				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-			}
+			memoryInst := moduleInst.MemoryAt(op.memoryIndex)
+			val := binary.LittleEndian.Uint16(memoryView(memoryInst, ce.popMemoryOffset(op, memoryInst), 2))
 
 			switch wazeroir.SignedInt(op.b1) {
 			case wazeroir.SignedInt32, wazeroir.SignedInt64:
@@ -931,10 +1215,8 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			}
 			frame.pc++
 		case wazeroir.OperationKindLoad32:
-			val, ok := memoryInst.ReadUint32Le(ctx, ce.popMemoryOffset(op))
-			if !ok {
-				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-			}
+			memoryInst := moduleInst.MemoryAt(op.memoryIndex)
+			val := binary.LittleEndian.Uint32(memoryView(memoryInst, ce.popMemoryOffset(op, memoryInst), 4))
 
 			if op.b1 == 1 { // Signed
 				ce.pushValue(uint64(int32(val)))
@@ -943,46 +1225,48 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			}
 			frame.pc++
 		case wazeroir.OperationKindStore:
+			memoryInst := moduleInst.MemoryAt(op.memoryIndex)
 			val := ce.popValue()
-			offset := ce.popMemoryOffset(op)
+			offset := ce.popMemoryOffset(op, memoryInst)
 			switch wazeroir.UnsignedType(op.b1) {
 			case wazeroir.UnsignedTypeI32, wazeroir.UnsignedTypeF32:
-				if !memoryInst.WriteUint32Le(ctx, offset, uint32(val)) {
-					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-				}
+				binary.LittleEndian.PutUint32(memoryView(memoryInst, offset, 4), uint32(val))
 			case wazeroir.UnsignedTypeI64, wazeroir.UnsignedTypeF64:
-				if !memoryInst.WriteUint64Le(ctx, offset, val) {
-					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-				}
+				binary.LittleEndian.PutUint64(memoryView(memoryInst, offset, 8), val)
 			}
 			frame.pc++
 		case wazeroir.OperationKindStore8:
+			memoryInst := moduleInst.MemoryAt(op.memoryIndex)
 			val := byte(ce.popValue())
-			offset := ce.popMemoryOffset(op)
-			if !memoryInst.WriteByte(ctx, offset, val) {
-				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-			}
+			offset := ce.popMemoryOffset(op, memoryInst)
+			memoryView(memoryInst, offset, 1)[0] = val
 			frame.pc++
 		case wazeroir.OperationKindStore16:
+			memoryInst := moduleInst.MemoryAt(op.memoryIndex)
 			val := uint16(ce.popValue())
-			offset := ce.popMemoryOffset(op)
-			if !memoryInst.WriteUint16Le(ctx, offset, val) {
-				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-			}
+			offset := ce.popMemoryOffset(op, memoryInst)
+			binary.LittleEndian.PutUint16(memoryView(memoryInst, offset, 2), val)
 			frame.pc++
 		case wazeroir.OperationKindStore32:
+			memoryInst := moduleInst.MemoryAt(op.memoryIndex)
 			val := uint32(ce.popValue())
-			offset := ce.popMemoryOffset(op)
-			if !memoryInst.WriteUint32Le(ctx, offset, val) {
-				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-			}
+			offset := ce.popMemoryOffset(op, memoryInst)
+			binary.LittleEndian.PutUint32(memoryView(memoryInst, offset, 4), val)
 			frame.pc++
 		case wazeroir.OperationKindMemorySize:
+			memoryInst := moduleInst.MemoryAt(op.memoryIndex)
 			ce.pushValue(uint64(memoryInst.PageSize(ctx)))
 			frame.pc++
 		case wazeroir.OperationKindMemoryGrow:
+			memoryInst := moduleInst.MemoryAt(op.memoryIndex)
 			n := ce.popValue()
-			if res, ok := memoryInst.Grow(ctx, uint32(n)); !ok {
+			if memoryInst.Is64 && n > math.MaxUint32 { // More pages than any 64-bit memory can have.
+				ce.pushValue(math.MaxUint64) // = -1 in signed 64-bit integer.
+			} else if res, ok, err := memoryInst.GrowFromWasm(ctx, uint32(n)); err != nil {
+				panic(err)
+			} else if !ok && memoryInst.Is64 {
+				ce.pushValue(math.MaxUint64) // = -1 in signed 64-bit integer.
+			} else if !ok {
 				ce.pushValue(uint64(0xffffffff)) // = -1 in signed 32-bit integer.
 			} else {
 				ce.pushValue(uint64(res))
@@ -1757,42 +2041,38 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			ce.pushValue(uint64(v))
 			frame.pc++
 		case wazeroir.OperationKindMemoryInit:
+			memoryInst := moduleInst.MemoryAt(op.memoryIndex)
 			dataInstance := dataInstances[op.us[0]]
 			copySize := ce.popValue()
 			inDataOffset := ce.popValue()
 			inMemoryOffset := ce.popValue()
-			if inDataOffset+copySize > uint64(len(dataInstance)) ||
-				inMemoryOffset+copySize > uint64(len(memoryInst.Buffer)) {
+			buf := memoryView(memoryInst, inMemoryOffset, copySize)
+			if inDataOffset+copySize > uint64(len(dataInstance)) {
 				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-			} else if copySize != 0 {
-				copy(memoryInst.Buffer[inMemoryOffset:inMemoryOffset+copySize], dataInstance[inDataOffset:])
 			}
+			copy(buf, dataInstance[inDataOffset:])
 			frame.pc++
 		case wazeroir.OperationKindDataDrop:
 			dataInstances[op.us[0]] = nil
 			frame.pc++
 		case wazeroir.OperationKindMemoryCopy:
-			memLen := uint64(len(memoryInst.Buffer))
+			srcMemoryInst := moduleInst.MemoryAt(wasm.Index(op.us[0]))
+			dstMemoryInst := moduleInst.MemoryAt(op.memoryIndex)
 			copySize := ce.popValue()
 			sourceOffset := ce.popValue()
 			destinationOffset := ce.popValue()
-			if sourceOffset+copySize > memLen || destinationOffset+copySize > memLen {
-				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-			} else if copySize != 0 {
-				copy(memoryInst.Buffer[destinationOffset:],
-					memoryInst.Buffer[sourceOffset:sourceOffset+copySize])
-			}
+			// Both ranges are checked before copying, as nothing is copied if either is out of range.
+			src := memoryView(srcMemoryInst, sourceOffset, copySize)
+			copy(memoryView(dstMemoryInst, destinationOffset, copySize), src)
 			frame.pc++
 		case wazeroir.OperationKindMemoryFill:
+			memoryInst := moduleInst.MemoryAt(op.memoryIndex)
 			fillSize := ce.popValue()
 			value := byte(ce.popValue())
 			offset := ce.popValue()
-			if fillSize+offset > uint64(len(memoryInst.Buffer)) {
-				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-			} else if fillSize != 0 {
+			if buf := memoryView(memoryInst, offset, fillSize); len(buf) != 0 {
 				// Uses the copy trick for faster filling buffer.
 				// https://gist.github.com/taylorza/df2f89d5f9ab3ffd06865062a4cf015d
-				buf := memoryInst.Buffer[offset : offset+fillSize]
 				buf[0] = value
 				for i := 1; i < len(buf); i *= 2 {
 					copy(buf[i:], buf[:i])
@@ -1951,24 +2231,16 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			}
 			frame.pc++
 		case wazeroir.OperationKindV128Load:
-			offset := ce.popMemoryOffset(op)
+			memoryInst := moduleInst.MemoryAt(op.memoryIndex)
+			offset := ce.popMemoryOffset(op, memoryInst)
 			switch op.b1 {
 			case wazeroir.LoadV128Type128:
-				lo, ok := memoryInst.ReadUint64Le(ctx, offset)
-				if !ok {
-					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-				}
+				lo := binary.LittleEndian.Uint64(memoryView(memoryInst, offset, 8))
 				ce.pushValue(lo)
-				hi, ok := memoryInst.ReadUint64Le(ctx, offset+8)
-				if !ok {
-					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-				}
+				hi := binary.LittleEndian.Uint64(memoryView(memoryInst, offset+8, 8))
 				ce.pushValue(hi)
 			case wazeroir.LoadV128Type8x8s:
-				data, ok := memoryInst.Read(ctx, offset, 8)
-				if !ok {
-					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-				}
+				data := memoryView(memoryInst, offset, 8)
 				ce.pushValue(
 					uint64(uint16(int8(data[3])))<<48 | uint64(uint16(int8(data[2])))<<32 | uint64(uint16(int8(data[1])))<<16 | uint64(uint16(int8(data[0]))),
 				)
@@ -1976,10 +2248,7 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 					uint64(uint16(int8(data[7])))<<48 | uint64(uint16(int8(data[6])))<<32 | uint64(uint16(int8(data[5])))<<16 | uint64(uint16(int8(data[4]))),
 				)
 			case wazeroir.LoadV128Type8x8u:
-				data, ok := memoryInst.Read(ctx, offset, 8)
-				if !ok {
-					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-				}
+				data := memoryView(memoryInst, offset, 8)
 				ce.pushValue(
 					uint64(data[3])<<48 | uint64(data[2])<<32 | uint64(data[1])<<16 | uint64(data[0]),
 				)
@@ -1987,10 +2256,7 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 					uint64(data[7])<<48 | uint64(data[6])<<32 | uint64(data[5])<<16 | uint64(data[4]),
 				)
 			case wazeroir.LoadV128Type16x4s:
-				data, ok := memoryInst.Read(ctx, offset, 8)
-				if !ok {
-					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-				}
+				data := memoryView(memoryInst, offset, 8)
 				ce.pushValue(
 					uint64(int16(binary.LittleEndian.Uint16(data[2:])))<<32 |
 						uint64(uint32(int16(binary.LittleEndian.Uint16(data)))),
@@ -2000,10 +2266,7 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 						uint64(uint32(int16(binary.LittleEndian.Uint16(data[4:])))),
 				)
 			case wazeroir.LoadV128Type16x4u:
-				data, ok := memoryInst.Read(ctx, offset, 8)
-				if !ok {
-					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-				}
+				data := memoryView(memoryInst, offset, 8)
 				ce.pushValue(
 					uint64(binary.LittleEndian.Uint16(data[2:]))<<32 | uint64(binary.LittleEndian.Uint16(data)),
 				)
@@ -2011,76 +2274,50 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 					uint64(binary.LittleEndian.Uint16(data[6:]))<<32 | uint64(binary.LittleEndian.Uint16(data[4:])),
 				)
 			case wazeroir.LoadV128Type32x2s:
-				data, ok := memoryInst.Read(ctx, offset, 8)
-				if !ok {
-					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-				}
+				data := memoryView(memoryInst, offset, 8)
 				ce.pushValue(uint64(int32(binary.LittleEndian.Uint32(data))))
 				ce.pushValue(uint64(int32(binary.LittleEndian.Uint32(data[4:]))))
 			case wazeroir.LoadV128Type32x2u:
-				data, ok := memoryInst.Read(ctx, offset, 8)
-				if !ok {
-					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-				}
+				data := memoryView(memoryInst, offset, 8)
 				ce.pushValue(uint64(binary.LittleEndian.Uint32(data)))
 				ce.pushValue(uint64(binary.LittleEndian.Uint32(data[4:])))
 			case wazeroir.LoadV128Type8Splat:
-				v, ok := memoryInst.ReadByte(ctx, offset)
-				if !ok {
-					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-				}
+				v := memoryView(memoryInst, offset, 1)[0]
 				v8 := uint64(v)<<56 | uint64(v)<<48 | uint64(v)<<40 | uint64(v)<<32 |
 					uint64(v)<<24 | uint64(v)<<16 | uint64(v)<<8 | uint64(v)
 				ce.pushValue(v8)
 				ce.pushValue(v8)
 			case wazeroir.LoadV128Type16Splat:
-				v, ok := memoryInst.ReadUint16Le(ctx, offset)
-				if !ok {
-					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-				}
+				v := binary.LittleEndian.Uint16(memoryView(memoryInst, offset, 2))
 				v4 := uint64(v)<<48 | uint64(v)<<32 | uint64(v)<<16 | uint64(v)
 				ce.pushValue(v4)
 				ce.pushValue(v4)
 			case wazeroir.LoadV128Type32Splat:
-				v, ok := memoryInst.ReadUint32Le(ctx, offset)
-				if !ok {
-					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-				}
+				v := binary.LittleEndian.Uint32(memoryView(memoryInst, offset, 4))
 				vv := uint64(v)<<32 | uint64(v)
 				ce.pushValue(vv)
 				ce.pushValue(vv)
 			case wazeroir.LoadV128Type64Splat:
-				lo, ok := memoryInst.ReadUint64Le(ctx, offset)
-				if !ok {
-					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-				}
+				lo := binary.LittleEndian.Uint64(memoryView(memoryInst, offset, 8))
 				ce.pushValue(lo)
 				ce.pushValue(lo)
 			case wazeroir.LoadV128Type32zero:
-				lo, ok := memoryInst.ReadUint32Le(ctx, offset)
-				if !ok {
-					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-				}
+				lo := binary.LittleEndian.Uint32(memoryView(memoryInst, offset, 4))
 				ce.pushValue(uint64(lo))
 				ce.pushValue(0)
 			case wazeroir.LoadV128Type64zero:
-				lo, ok := memoryInst.ReadUint64Le(ctx, offset)
-				if !ok {
-					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-				}
+				lo := binary.LittleEndian.Uint64(memoryView(memoryInst, offset, 8))
 				ce.pushValue(lo)
 				ce.pushValue(0)
 			}
 			frame.pc++
 		case wazeroir.OperationKindV128LoadLane:
+			memoryInst := moduleInst.MemoryAt(op.memoryIndex)
 			hi, lo := ce.popValue(), ce.popValue()
-			offset := ce.popMemoryOffset(op)
+			offset := ce.popMemoryOffset(op, memoryInst)
 			switch op.b1 {
 			case 8:
-				b, ok := memoryInst.ReadByte(ctx, offset)
-				if !ok {
-					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-				}
+				b := memoryView(memoryInst, offset, 1)[0]
 				if op.b2 < 8 {
 					s := op.b2 << 3
 					lo = (lo & ^(0xff << s)) | uint64(b)<<s
@@ -2089,10 +2326,7 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 					hi = (hi & ^(0xff << s)) | uint64(b)<<s
 				}
 			case 16:
-				b, ok := memoryInst.ReadUint16Le(ctx, offset)
-				if !ok {
-					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-				}
+				b := binary.LittleEndian.Uint16(memoryView(memoryInst, offset, 2))
 				if op.b2 < 4 {
 					s := op.b2 << 4
 					lo = (lo & ^(0xff_ff << s)) | uint64(b)<<s
@@ -2101,10 +2335,7 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 					hi = (hi & ^(0xff_ff << s)) | uint64(b)<<s
 				}
 			case 32:
-				b, ok := memoryInst.ReadUint32Le(ctx, offset)
-				if !ok {
-					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-				}
+				b := binary.LittleEndian.Uint32(memoryView(memoryInst, offset, 4))
 				if op.b2 < 2 {
 					s := op.b2 << 5
 					lo = (lo & ^(0xff_ff_ff_ff << s)) | uint64(b)<<s
@@ -2113,10 +2344,7 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 					hi = (hi & ^(0xff_ff_ff_ff << s)) | uint64(b)<<s
 				}
 			case 64:
-				b, ok := memoryInst.ReadUint64Le(ctx, offset)
-				if !ok {
-					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-				}
+				b := binary.LittleEndian.Uint64(memoryView(memoryInst, offset, 8))
 				if op.b2 == 0 {
 					lo = b
 				} else {
@@ -2127,48 +2355,42 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			ce.pushValue(hi)
 			frame.pc++
 		case wazeroir.OperationKindV128Store:
+			memoryInst := moduleInst.MemoryAt(op.memoryIndex)
 			hi, lo := ce.popValue(), ce.popValue()
-			offset := ce.popMemoryOffset(op)
-			if ok := memoryInst.WriteUint64Le(ctx, offset, lo); !ok {
-				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-			}
-			if ok := memoryInst.WriteUint64Le(ctx, offset+8, hi); !ok {
-				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-			}
+			offset := ce.popMemoryOffset(op, memoryInst)
+			binary.LittleEndian.PutUint64(memoryView(memoryInst, offset, 8), lo)
+			binary.LittleEndian.PutUint64(memoryView(memoryInst, offset+8, 8), hi)
 			frame.pc++
 		case wazeroir.OperationKindV128StoreLane:
+			memoryInst := moduleInst.MemoryAt(op.memoryIndex)
 			hi, lo := ce.popValue(), ce.popValue()
-			offset := ce.popMemoryOffset(op)
-			var ok bool
+			offset := ce.popMemoryOffset(op, memoryInst)
 			switch op.b1 {
 			case 8:
 				if op.b2 < 8 {
-					ok = memoryInst.WriteByte(ctx, offset, byte(lo>>(op.b2*8)))
+					memoryView(memoryInst, offset, 1)[0] = byte(lo >> (op.b2 * 8))
 				} else {
-					ok = memoryInst.WriteByte(ctx, offset, byte(hi>>((op.b2-8)*8)))
+					memoryView(memoryInst, offset, 1)[0] = byte(hi >> ((op.b2 - 8) * 8))
 				}
 			case 16:
 				if op.b2 < 4 {
-					ok = memoryInst.WriteUint16Le(ctx, offset, uint16(lo>>(op.b2*16)))
+					binary.LittleEndian.PutUint16(memoryView(memoryInst, offset, 2), uint16(lo>>(op.b2*16)))
 				} else {
-					ok = memoryInst.WriteUint16Le(ctx, offset, uint16(hi>>((op.b2-4)*16)))
+					binary.LittleEndian.PutUint16(memoryView(memoryInst, offset, 2), uint16(hi>>((op.b2-4)*16)))
 				}
 			case 32:
 				if op.b2 < 2 {
-					ok = memoryInst.WriteUint32Le(ctx, offset, uint32(lo>>(op.b2*32)))
+					binary.LittleEndian.PutUint32(memoryView(memoryInst, offset, 4), uint32(lo>>(op.b2*32)))
 				} else {
-					ok = memoryInst.WriteUint32Le(ctx, offset, uint32(hi>>((op.b2-2)*32)))
+					binary.LittleEndian.PutUint32(memoryView(memoryInst, offset, 4), uint32(hi>>((op.b2-2)*32)))
 				}
 			case 64:
 				if op.b2 == 0 {
-					ok = memoryInst.WriteUint64Le(ctx, offset, lo)
+					binary.LittleEndian.PutUint64(memoryView(memoryInst, offset, 8), lo)
 				} else {
-					ok = memoryInst.WriteUint64Le(ctx, offset, hi)
+					binary.LittleEndian.PutUint64(memoryView(memoryInst, offset, 8), hi)
 				}
 			}
-			if !ok {
-				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-			}
 			frame.pc++
 		case wazeroir.OperationKindV128ReplaceLane:
 			v := ce.popValue()
@@ -2924,34 +3146,349 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			ce.pushValue(retLo)
 			ce.pushValue(retHi)
 			frame.pc++
+		case wazeroir.OperationKindV128RelaxedTrunc:
+			hi, lo := ce.popValue(), ce.popValue()
+			if op.b1 == wazeroir.ShapeF32x4 {
+				lo = uint64(relaxedTrunc(float64(math.Float32frombits(uint32(lo))), op.b3)) |
+					uint64(relaxedTrunc(float64(math.Float32frombits(uint32(lo>>32))), op.b3))<<32
+				hi = uint64(relaxedTrunc(float64(math.Float32frombits(uint32(hi))), op.b3)) |
+					uint64(relaxedTrunc(float64(math.Float32frombits(uint32(hi>>32))), op.b3))<<32
+			} else {
+				lo = uint64(relaxedTrunc(math.Float64frombits(lo), op.b3)) |
+					uint64(relaxedTrunc(math.Float64frombits(hi), op.b3))<<32
+				hi = 0
+			}
+			ce.pushValue(lo)
+			ce.pushValue(hi)
+			frame.pc++
+		case wazeroir.OperationKindV128RelaxedMadd:
+			cHi, cLo := ce.popValue(), ce.popValue()
+			bHi, bLo := ce.popValue(), ce.popValue()
+			aHi, aLo := ce.popValue(), ce.popValue()
+			var retLo, retHi uint64
+			if op.b1 == wazeroir.ShapeF32x4 {
+				retLo = uint64(relaxedMaddF32(uint32(aLo), uint32(bLo), uint32(cLo), op.b3)) |
+					uint64(relaxedMaddF32(uint32(aLo>>32), uint32(bLo>>32), uint32(cLo>>32), op.b3))<<32
+				retHi = uint64(relaxedMaddF32(uint32(aHi), uint32(bHi), uint32(cHi), op.b3)) |
+					uint64(relaxedMaddF32(uint32(aHi>>32), uint32(bHi>>32), uint32(cHi>>32), op.b3))<<32
+			} else {
+				retLo = relaxedMaddF64(aLo, bLo, cLo, op.b3)
+				retHi = relaxedMaddF64(aHi, bHi, cHi, op.b3)
+			}
+			ce.pushValue(retLo)
+			ce.pushValue(retHi)
+			frame.pc++
+		case wazeroir.OperationKindV128RelaxedMin, wazeroir.OperationKindV128RelaxedMax:
+			bHi, bLo := ce.popValue(), ce.popValue()
+			aHi, aLo := ce.popValue(), ce.popValue()
+			isMin := op.kind == wazeroir.OperationKindV128RelaxedMin
+			var retLo, retHi uint64
+			if op.b1 == wazeroir.ShapeF32x4 {
+				retLo = uint64(relaxedMinMaxF32(uint32(aLo), uint32(bLo), isMin)) |
+					uint64(relaxedMinMaxF32(uint32(aLo>>32), uint32(bLo>>32), isMin))<<32
+				retHi = uint64(relaxedMinMaxF32(uint32(aHi), uint32(bHi), isMin)) |
+					uint64(relaxedMinMaxF32(uint32(aHi>>32), uint32(bHi>>32), isMin))<<32
+			} else {
+				retLo = relaxedMinMaxF64(aLo, bLo, isMin)
+				retHi = relaxedMinMaxF64(aHi, bHi, isMin)
+			}
+			ce.pushValue(retLo)
+			ce.pushValue(retHi)
+			frame.pc++
+		case wazeroir.OperationKindV128RelaxedQ15mulr:
+			bHi, bLo := ce.popValue(), ce.popValue()
+			aHi, aLo := ce.popValue(), ce.popValue()
+			ce.pushValue(relaxedQ15mulr(aLo, bLo))
+			ce.pushValue(relaxedQ15mulr(aHi, bHi))
+			frame.pc++
+		case wazeroir.OperationKindV128RelaxedDot:
+			bHi, bLo := ce.popValue(), ce.popValue()
+			aHi, aLo := ce.popValue(), ce.popValue()
+			ce.pushValue(relaxedDot(aLo, bLo))
+			ce.pushValue(relaxedDot(aHi, bHi))
+			frame.pc++
+		case wazeroir.OperationKindV128RelaxedDotAdd:
+			cHi, cLo := ce.popValue(), ce.popValue()
+			bHi, bLo := ce.popValue(), ce.popValue()
+			aHi, aLo := ce.popValue(), ce.popValue()
+			ce.pushValue(relaxedDotAdd(aLo, bLo, cLo))
+			ce.pushValue(relaxedDotAdd(aHi, bHi, cHi))
+			frame.pc++
+		case wazeroir.OperationKindAtomicLoad:
+			memoryInst := moduleInst.MemoryAt(op.memoryIndex)
+			offset := ce.popMemoryOffset(op, memoryInst)
+			val, err := memoryInst.AtomicLoad(offset, uint32(op.us[0]))
+			if err != nil {
+				panic(err)
+			}
+			ce.pushValue(val)
+			frame.pc++
+		case wazeroir.OperationKindAtomicStore:
+			memoryInst := moduleInst.MemoryAt(op.memoryIndex)
+			val := ce.popValue()
+			offset := ce.popMemoryOffset(op, memoryInst)
+			if err := memoryInst.AtomicStore(offset, uint32(op.us[0]), val); err != nil {
+				panic(err)
+			}
+			frame.pc++
+		case wazeroir.OperationKindAtomicRMW:
+			memoryInst := moduleInst.MemoryAt(op.memoryIndex)
+			val := ce.popValue()
+			offset := ce.popMemoryOffset(op, memoryInst)
+			old, err := memoryInst.AtomicRMW(offset, uint32(op.us[0]), val, wazeroir.AtomicRMWOp(op.b2).Apply)
+			if err != nil {
+				panic(err)
+			}
+			ce.pushValue(old)
+			frame.pc++
+		case wazeroir.OperationKindAtomicCmpxchg:
+			memoryInst := moduleInst.MemoryAt(op.memoryIndex)
+			replacement, expected := ce.popValue(), ce.popValue()
+			offset := ce.popMemoryOffset(op, memoryInst)
+			old, err := memoryInst.AtomicCompareExchange(offset, uint32(op.us[0]), expected, replacement)
+			if err != nil {
+				panic(err)
+			}
+			ce.pushValue(old)
+			frame.pc++
+		case wazeroir.OperationKindMemoryWait:
+			memoryInst := moduleInst.MemoryAt(op.memoryIndex)
+			timeout, expected := int64(ce.popValue()), ce.popValue()
+			if wazeroir.UnsignedInt(op.b1) == wazeroir.UnsignedInt32 {
+				expected = uint64(uint32(expected))
+			}
+			offset := ce.popMemoryOffset(op, memoryInst)
+			res, err := memoryInst.AtomicWait(ctx, offset, uint32(op.us[0]), expected, timeout)
+			if err != nil {
+				panic(err)
+			}
+			ce.pushValue(uint64(res))
+			frame.pc++
+		case wazeroir.OperationKindMemoryNotify:
+			memoryInst := moduleInst.MemoryAt(op.memoryIndex)
+			count := uint32(ce.popValue())
+			offset := ce.popMemoryOffset(op, memoryInst)
+			res, err := memoryInst.AtomicNotify(offset, count)
+			if err != nil {
+				panic(err)
+			}
+			ce.pushValue(uint64(res))
+			frame.pc++
+		case wazeroir.OperationKindAtomicFence:
+			wasm.AtomicFence()
+			frame.pc++
+		case wazeroir.OperationKindThrow:
+			tag := moduleInst.Tags[op.us[0]]
+			values := ce.peekValues(tag.Type.ParamNumInUint64)
+			panic(sys.NewException(tag, values...))
+		case wazeroir.OperationKindRethrow:
+			panic(ce.exceptions[ce.popValue()].exc)
 		}
 	}
 	ce.popFrame()
+	return nil
 }
 
-func (ce *callEngine) callNativeFuncWithListener(ctx context.Context, callCtx *wasm.CallContext, f *function, fnl experimental.FunctionListener) context.Context {
+// callIndirectTarget pops the offset in the table of call_indirect and returns the function at it, which must be of the
+// given type.
+func (ce *callEngine) callIndirectTarget(table *wasm.TableInstance, typeID wasm.FunctionTypeID) *function {
+	offset := ce.popValue()
+	if offset >= uint64(len(table.References)) {
+		panic(wasmruntime.ErrRuntimeInvalidTableAccess)
+	}
+	rawPtr := table.References[offset]
+	if rawPtr == 0 {
+		panic(wasmruntime.ErrRuntimeInvalidTableAccess)
+	}
+
+	tf := functionFromUintptr(rawPtr)
+	if tf.source.TypeID != typeID {
+		panic(wasmruntime.ErrRuntimeIndirectCallTypeMismatch)
+	}
+	return tf
+}
+
+// execNativeFuncWithListener is like execNativeFunc, but notifies the listener of the function. When the function
+// makes a tail call, the listener is notified of its end with nil results before the called function begins, as the
+// results are those of the called function.
+//
+// Note: The context passed to the listener is only used for this call, so it doesn't affect calls made by the caller,
+// including the function called by a tail call.
+func (ce *callEngine) execNativeFuncWithListener(ctx context.Context, callCtx *wasm.CallContext, f *function, fnl experimental.FunctionListener) (next *function) {
 	ctx = fnl.Before(ctx, ce.peekValues(len(f.source.Type.Params)))
-	ce.callNativeFunc(ctx, callCtx, f)
+	defer func() {
+		// Notify the listener of an exception thrown through the function, as the caller may catch it.
+		if recovered := recover(); recovered != nil {
+			if exc := wasm.ExceptionFromRecovered(recovered); exc != nil {
+				fnl.After(ctx, exc, nil)
+			}
+			panic(recovered)
+		}
+	}()
+	if next = ce.execNativeFunc(ctx, callCtx, f); next != nil {
+		fnl.After(ctx, nil, nil)
+		return
+	}
 	// TODO: This doesn't get the error due to use of panic to propagate them.
 	fnl.After(ctx, nil, ce.peekValues(len(f.source.Type.Results)))
-	return ctx
+	return
 }
 
-// popMemoryOffset takes a memory offset off the stack for use in load and store instructions.
-// As the top of stack value is 64-bit, this ensures it is in range before returning it.
-func (ce *callEngine) popMemoryOffset(op *interpreterOp) uint32 {
-	// TODO: Document what 'us' is and why we expect to look at value 1.
-	offset := op.us[1] + ce.popValue()
-	if offset > math.MaxUint32 {
+// popMemoryOffset takes a memory address off the stack for use in load and store instructions, and returns it plus
+// the static offset of the instruction in op.us[1]. The address is an i32, unless the memory is wasm.MemoryInstance
+// Is64, in which case the sum can overflow: that panics, as it is out of range.
+func (ce *callEngine) popMemoryOffset(op *interpreterOp, memoryInst *wasm.MemoryInstance) uint64 {
+	address := ce.popValue()
+	if !memoryInst.Is64 {
+		address = uint64(uint32(address))
+	}
+	offset := op.us[1] + address
+	if offset < address {
 		panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 	}
-	return uint32(offset)
+	return offset
+}
+
+// memoryView returns the byteCount bytes of the memory at the offset, which loads read and stores write through. This
+// panics if any of them are out of range.
+func memoryView(memoryInst *wasm.MemoryInstance, offset, byteCount uint64) []byte {
+	end := offset + byteCount
+	if end < offset || end > memoryInst.Size64(nil) {
+		panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+	}
+	return memoryInst.Buffer[offset:end:end]
+}
+
+// checkContextDone panics with sys.ContextDoneError if the context is done, which unwinds the call.
+func checkContextDone(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+		panic(sys.NewContextDoneError(ctx.Err()))
+	default:
+	}
+}
+
+// relaxedTrunc truncates a lane of wazeroir.OperationV128RelaxedTrunc to i32. f32 lanes are exact as float64.
+func relaxedTrunc(v float64, signed bool) uint32 {
+	if signed {
+		// NaN fails both comparisons.
+		if !(v > math.MinInt32-1 && v < math.MaxInt32+1) {
+			return 0x80000000 // math.MinInt32
+		}
+		return uint32(int32(v))
+	}
+	if v != v || v < 0 {
+		return 0
+	} else if v >= math.MaxUint32+1 {
+		return math.MaxUint32
+	}
+	return uint32(v)
+}
+
+// relaxedMaddF32 returns a lane of wazeroir.OperationV128RelaxedMadd on f32 lanes.
+func relaxedMaddF32(a, b, c uint32, negate bool) uint32 {
+	// The explicit conversion rounds the product, which prevents it from being fused with the addition.
+	p := float32(math.Float32frombits(a) * math.Float32frombits(b))
+	if negate {
+		return math.Float32bits(math.Float32frombits(c) - p)
+	}
+	return math.Float32bits(p + math.Float32frombits(c))
+}
+
+// relaxedMaddF64 returns a lane of wazeroir.OperationV128RelaxedMadd on f64 lanes.
+func relaxedMaddF64(a, b, c uint64, negate bool) uint64 {
+	// The explicit conversion rounds the product, which prevents it from being fused with the addition.
+	p := float64(math.Float64frombits(a) * math.Float64frombits(b))
+	if negate {
+		return math.Float64bits(math.Float64frombits(c) - p)
+	}
+	return math.Float64bits(p + math.Float64frombits(c))
+}
+
+// relaxedMinMaxF32 returns a lane of wazeroir.OperationV128RelaxedMin, or wazeroir.OperationV128RelaxedMax unless
+// isMin, on f32 lanes.
+func relaxedMinMaxF32(a, b uint32, isMin bool) uint32 {
+	af, bf := math.Float32frombits(a), math.Float32frombits(b)
+	if (isMin && af < bf) || (!isMin && af > bf) {
+		return a
+	}
+	return b
+}
+
+// relaxedMinMaxF64 returns a lane of wazeroir.OperationV128RelaxedMin, or wazeroir.OperationV128RelaxedMax unless
+// isMin, on f64 lanes.
+func relaxedMinMaxF64(a, b uint64, isMin bool) uint64 {
+	af, bf := math.Float64frombits(a), math.Float64frombits(b)
+	if (isMin && af < bf) || (!isMin && af > bf) {
+		return a
+	}
+	return b
+}
+
+// relaxedQ15mulr returns wazeroir.OperationV128RelaxedQ15mulr on the four i16 lanes in a half of a vector.
+func relaxedQ15mulr(a, b uint64) (ret uint64) {
+	for i := 0; i < 64; i += 16 {
+		r := (int32(int16(a>>i))*int32(int16(b>>i)) + 0x4000) >> 15
+		ret |= uint64(uint16(r)) << i
+	}
+	return
+}
+
+// relaxedDot returns wazeroir.OperationV128RelaxedDot on the eight i8 lanes in a half of a vector.
+func relaxedDot(a, b uint64) (ret uint64) {
+	for i := 0; i < 64; i += 16 {
+		s := int32(int8(a>>i))*int32(uint8(b>>i)) + int32(int8(a>>(i+8)))*int32(uint8(b>>(i+8)))
+		if s > math.MaxInt16 {
+			s = math.MaxInt16
+		} else if s < math.MinInt16 {
+			s = math.MinInt16
+		}
+		ret |= uint64(uint16(s)) << i
+	}
+	return
+}
+
+// relaxedDotAdd returns wazeroir.OperationV128RelaxedDotAdd on the eight i8 lanes in a half of a vector.
+func relaxedDotAdd(a, b, c uint64) uint64 {
+	d := relaxedDot(a, b)
+	lo := int32(int16(d)) + int32(int16(d>>16)) + int32(c)
+	hi := int32(int16(d>>32)) + int32(int16(d>>48)) + int32(c>>32)
+	return uint64(uint32(lo)) | uint64(uint32(hi))<<32
 }
 
 func (ce *callEngine) callGoFuncWithStack(ctx context.Context, callCtx *wasm.CallContext, f *function) {
+	if f.source.Kind == wasm.FunctionKindGoModuleFunction {
+		ce.callGoModuleFunction(ctx, callCtx, f)
+		return
+	}
 	params := wasm.PopGoFuncParams(f.source, ce.popValue)
 	results := ce.callGoFunc(ctx, callCtx, f, params)
 	for _, v := range results {
 		ce.pushValue(v)
 	}
 }
+
+// callGoModuleFunction calls the api.GoModuleFunction of f with the top of the value stack, where its params are, so
+// that the call doesn't allocate them or its results.
+func (ce *callEngine) callGoModuleFunction(ctx context.Context, callCtx *wasm.CallContext, f *function) {
+	tp := f.source.Type
+	base := len(ce.stack) - tp.ParamNumInUint64
+	for len(ce.stack) < base+tp.StackLen() {
+		ce.stack = append(ce.stack, 0)
+	}
+	stack := ce.stack[base : base+tp.StackLen()]
+
+	// Use the caller's memory, which might be different from the defining module on an imported function.
+	callCtx = callCtx.WithMemoryOf(ce.frames[len(ce.frames)-1].f.source.Module)
+	if f.source.FunctionListener != nil {
+		ctx = f.source.FunctionListener.Before(ctx, stack[:tp.ParamNumInUint64])
+	}
+	ce.pushFrame(ce.newFrame(f))
+	f.source.GoModuleFunc.Call(ctx, callCtx, stack)
+	ce.popFrame()
+	if f.source.FunctionListener != nil {
+		// TODO: This doesn't get the error due to use of panic to propagate them.
+		f.source.FunctionListener.After(ctx, nil, stack[:tp.ResultNumInUint64])
+	}
+	ce.stack = ce.stack[:base+tp.ResultNumInUint64]
+}
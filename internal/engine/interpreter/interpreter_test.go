@@ -32,6 +32,23 @@ func TestInterpreter_CallEngine_PushFrame(t *testing.T) {
 	require.Equal(t, []*callFrame{f1, f2}, ce.frames)
 }
 
+func TestInterpreter_CallEngine_PeekValues(t *testing.T) {
+	ce := callEngine{}
+	require.Nil(t, ce.peekValues(0))
+
+	ce.stack = []uint64{1, 2, 3}
+	// The values are in the order they were pushed, which listeners see as parameters and results.
+	require.Equal(t, []uint64{3}, ce.peekValues(1))
+	require.Equal(t, []uint64{2, 3}, ce.peekValues(2))
+	require.Equal(t, []uint64{1, 2, 3}, ce.peekValues(3))
+
+	// The values are copied, so they aren't overwritten by the stack.
+	peeked := ce.peekValues(3)
+	ce.stack[0] = 4
+	require.Equal(t, []uint64{1, 2, 3}, peeked)
+	require.Equal(t, []uint64{4, 2, 3}, ce.stack)
+}
+
 func TestInterpreter_CallEngine_PushFrame_StackOverflow(t *testing.T) {
 	defer func() { callStackCeiling = buildoptions.CallStackCeiling }()
 
@@ -59,7 +76,7 @@ type engineTester struct{}
 
 // NewEngine implements enginetest.EngineTester NewEngine.
 func (e engineTester) NewEngine(enabledFeatures wasm.Features) wasm.Engine {
-	return NewEngine(enabledFeatures)
+	return NewEngine(enabledFeatures, wasm.EngineConfig{})
 }
 
 // InitTables implements enginetest.EngineTester InitTables.
@@ -483,6 +500,35 @@ func TestInterpreter_Compile(t *testing.T) {
 	})
 }
 
+func TestInterpreter_Coverage(t *testing.T) {
+	m := &wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection:     []*wasm.Code{{Body: []byte{wasm.OpcodeEnd}}},
+		ID:              wasm.ModuleID{1},
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		e := et.NewEngine(wasm.Features20191205)
+		require.NoError(t, e.CompileModule(testCtx, m))
+		require.Nil(t, e.Coverage(m))
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		e := NewEngine(wasm.Features20191205, wasm.EngineConfig{Coverage: true})
+		require.NoError(t, e.CompileModule(testCtx, m))
+		coverage := e.Coverage(m)
+		require.Equal(t, []*wasm.FunctionCoverage{{BlockOffsets: []uint64{0}, Counts: []uint64{0}}}, coverage)
+
+		// The cover block operation increments the count in place.
+		codes, _ := e.(*engine).getCodes(m)
+		f := codes[0].instantiate(&wasm.FunctionInstance{Module: &wasm.ModuleInstance{Engine: &moduleEngine{}}})
+		ce := &callEngine{}
+		ce.callNativeFunc(testCtx, &wasm.CallContext{}, f)
+		require.Equal(t, []uint64{1}, coverage[0].Counts)
+	})
+}
+
 func TestEngine_CachedcodesPerModule(t *testing.T) {
 	e := et.NewEngine(wasm.Features20191205).(*engine)
 	exp := []*code{
@@ -41,18 +41,18 @@ func newExample() *wasm.Module {
 		},
 		FunctionSection: []wasm.Index{wasm.Index(1), wasm.Index(1), wasm.Index(0), wasm.Index(3), wasm.Index(4), wasm.Index(5)},
 		CodeSection: []*wasm.Code{
-			{Body: []byte{wasm.OpcodeCall, 3, wasm.OpcodeEnd}},
-			{Body: []byte{wasm.OpcodeEnd}},
-			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1, wasm.OpcodeI32Add, wasm.OpcodeEnd}},
-			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeI64Extend16S, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeCall, 3, wasm.OpcodeEnd}, BodyOffsetInCodeSection: 3},
+			{Body: []byte{wasm.OpcodeEnd}, BodyOffsetInCodeSection: 8},
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1, wasm.OpcodeI32Add, wasm.OpcodeEnd}, BodyOffsetInCodeSection: 11},
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeI64Extend16S, wasm.OpcodeEnd}, BodyOffsetInCodeSection: 19},
 			{Body: []byte{
 				wasm.OpcodeLocalGet, 0x00,
 				wasm.OpcodeMiscPrefix, wasm.OpcodeMiscI32TruncSatF32S,
 				wasm.OpcodeEnd,
-			}},
-			{Body: []byte{wasm.OpcodeLocalGet, 1, wasm.OpcodeLocalGet, 0, wasm.OpcodeEnd}},
+			}, BodyOffsetInCodeSection: 25},
+			{Body: []byte{wasm.OpcodeLocalGet, 1, wasm.OpcodeLocalGet, 0, wasm.OpcodeEnd}, BodyOffsetInCodeSection: 32},
 		},
-		MemorySection: &wasm.Memory{Min: 1, Cap: 1, Max: three, IsMaxEncoded: true},
+		MemorySection: []*wasm.Memory{{Min: 1, Cap: 1, Max: three, IsMaxEncoded: true}},
 		ExportSection: []*wasm.Export{
 			{Name: "AddInt", Type: wasm.ExternTypeFunc, Index: wasm.Index(4)},
 			{Name: "", Type: wasm.ExternTypeFunc, Index: wasm.Index(3)},
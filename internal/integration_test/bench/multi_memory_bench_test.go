@@ -0,0 +1,90 @@
+package bench
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+// multiMemoryWasm has two memories, and exports the functions "sum0" and "sum1", which take the count of i64 values
+// to load from the memory of the index, and return their sum.
+var multiMemoryWasm = binary.EncodeModule(&wasm.Module{
+	TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{wasm.ValueTypeI32}, Results: []wasm.ValueType{wasm.ValueTypeI64}}},
+	FunctionSection: []wasm.Index{0, 0},
+	MemorySection:   []*wasm.Memory{{Min: 1, Cap: 1, Max: 1}, {Min: 1, Cap: 1, Max: 1}},
+	CodeSection:     []*wasm.Code{sumLoadsCode(0), sumLoadsCode(1)},
+	ExportSection: []*wasm.Export{
+		{Name: "sum0", Type: wasm.ExternTypeFunc, Index: 0},
+		{Name: "sum1", Type: wasm.ExternTypeFunc, Index: 1},
+	},
+})
+
+// sumLoadsCode returns the code of (func (param $n i32) (result i64) (local $acc i64)), which adds the i64 values
+// loaded from the memory of the index at ($n & 0x1ff8) to $acc while decrementing $n to zero.
+func sumLoadsCode(memoryIndex byte) *wasm.Code {
+	memArg := []byte{3, 0} // The alignment and offset.
+	if memoryIndex != 0 {
+		memArg = []byte{3 | wasm.MemoryArgMemoryIndexFlag, memoryIndex, 0}
+	}
+	body := []byte{
+		wasm.OpcodeLoop, 0x40,
+		wasm.OpcodeLocalGet, 1,
+		wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Const, 0xf8, 0x3f, wasm.OpcodeI32And, // 0x1ff8
+		wasm.OpcodeI64Load,
+	}
+	body = append(body, memArg...)
+	body = append(body,
+		wasm.OpcodeI64Add,
+		wasm.OpcodeLocalSet, 1,
+		wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Sub, wasm.OpcodeLocalTee, 0,
+		wasm.OpcodeBrIf, 0,
+		wasm.OpcodeEnd,
+		wasm.OpcodeLocalGet, 1,
+		wasm.OpcodeEnd,
+	)
+	return &wasm.Code{LocalTypes: []wasm.ValueType{wasm.ValueTypeI64}, Body: body}
+}
+
+// BenchmarkMultiMemory compares loads from the memory zero, which compiled code holds in reserved registers, with loads
+// from another memory, which compiled code reads from its wasm.MemoryInstance.
+func BenchmarkMultiMemory(b *testing.B) {
+	b.Run("interpreter", func(b *testing.B) {
+		runMultiMemoryBenches(b, wazero.NewRuntimeConfigInterpreter())
+	})
+	if runtime.GOARCH == "amd64" || runtime.GOARCH == "arm64" {
+		b.Run("compiler", func(b *testing.B) {
+			runMultiMemoryBenches(b, wazero.NewRuntimeConfigCompiler())
+		})
+	}
+}
+
+func runMultiMemoryBenches(b *testing.B, config wazero.RuntimeConfig) {
+	r := wazero.NewRuntimeWithConfig(config.WithFeatureMultiMemory(true))
+	defer r.Close(testCtx)
+
+	m, err := r.InstantiateModuleFromBinary(testCtx, multiMemoryWasm)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, name := range []string{"sum0", "sum1"} {
+		fn := m.ExportedFunction(name)
+		b.Run(name, func(b *testing.B) {
+			runMultiMemoryBench(b, fn)
+		})
+	}
+}
+
+func runMultiMemoryBench(b *testing.B, fn api.Function) {
+	const loads = 1000
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := fn.Call(testCtx, loads); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -3,17 +3,25 @@ package adhoc
 import (
 	"context"
 	_ "embed"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"math"
+	"runtime"
 	"strconv"
 	"testing"
+	"time"
 	"unsafe"
 
 	"github.com/tetratelabs/wazero"
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
 	"github.com/tetratelabs/wazero/internal/platform"
+	"github.com/tetratelabs/wazero/internal/testing/dwarftestdata"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
+	binaryformat "github.com/tetratelabs/wazero/internal/wasm/binary"
+	"github.com/tetratelabs/wazero/internal/wasmruntime"
 	"github.com/tetratelabs/wazero/internal/watzero"
 	"github.com/tetratelabs/wazero/sys"
 )
@@ -45,6 +53,14 @@ var tests = map[string]func(t *testing.T, r wazero.Runtime){
 	"multiple instantiation from same source":           testMultipleInstantiation,
 	"exported function that grows memory":               testMemOps,
 	"import functions with reference type in signature": testReftypeImports,
+	"exported table":                                    testExportedTable,
+	"host table":                                        testHostTable,
+	"snapshot and restore":                              testSnapshotRestore,
+	"stack trace frames":                                testStackTraceFrames,
+	"stack trace source locations":                      testStackTraceSources,
+	"host function returning an error":                  testHostFunctionError,
+	"go module function":                                testGoModuleFunction,
+	"host function retaining its module":                testHostFunctionRetainingModule,
 }
 
 func TestEngineCompiler(t *testing.T) {
@@ -58,6 +74,147 @@ func TestEngineInterpreter(t *testing.T) {
 	runAllTests(t, tests, wazero.NewRuntimeConfigInterpreter())
 }
 
+func TestEngineCompiler_EnsureTermination(t *testing.T) {
+	if !platform.CompilerSupported() {
+		t.Skip()
+	}
+	testEnsureTermination(t, wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigCompiler().WithEnsureTermination(true)))
+}
+
+func TestEngineInterpreter_EnsureTermination(t *testing.T) {
+	testEnsureTermination(t, wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigInterpreter().WithEnsureTermination(true)))
+}
+
+func TestEngineCompiler_FuelMetering(t *testing.T) {
+	if !platform.CompilerSupported() {
+		t.Skip()
+	}
+	testFuelMetering(t, wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigCompiler().WithFuelMetering(true)))
+}
+
+func TestEngineInterpreter_FuelMetering(t *testing.T) {
+	testFuelMetering(t, wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigInterpreter().WithFuelMetering(true)))
+}
+
+func TestEngineCompiler_MemoryLimit(t *testing.T) {
+	if !platform.CompilerSupported() {
+		t.Skip()
+	}
+	testMemoryLimit(t, wazero.NewRuntimeConfigCompiler())
+}
+
+func TestEngineInterpreter_MemoryLimit(t *testing.T) {
+	testMemoryLimit(t, wazero.NewRuntimeConfigInterpreter())
+}
+
+func TestEngineCompiler_Threads(t *testing.T) {
+	if !platform.CompilerSupported() {
+		t.Skip()
+	}
+	testThreads(t, wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigCompiler().WithFeatureThreads(true)))
+}
+
+func TestEngineInterpreter_Threads(t *testing.T) {
+	testThreads(t, wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigInterpreter().WithFeatureThreads(true)))
+}
+
+// TestEngineCompiler_CallsWithoutAllocations isn't in tests, as allocations can't be counted in parallel tests.
+func TestEngineCompiler_CallsWithoutAllocations(t *testing.T) {
+	if !platform.CompilerSupported() {
+		t.Skip()
+	}
+	testCallsWithoutAllocations(t, wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigCompiler()))
+}
+
+func TestEngineInterpreter_CallsWithoutAllocations(t *testing.T) {
+	testCallsWithoutAllocations(t, wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigInterpreter()))
+}
+
+func TestEngineCompiler_TailCall(t *testing.T) {
+	if !platform.CompilerSupported() {
+		t.Skip()
+	}
+	testTailCall(t, wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigCompiler().WithFeatureTailCall(true)))
+}
+
+func TestEngineInterpreter_TailCall(t *testing.T) {
+	testTailCall(t, wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigInterpreter().WithFeatureTailCall(true)))
+}
+
+func TestEngineCompiler_ExceptionHandling(t *testing.T) {
+	if !platform.CompilerSupported() {
+		t.Skip()
+	}
+	testExceptionHandling(t, wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigCompiler().WithFeatureExceptionHandling(true)))
+}
+
+func TestEngineInterpreter_ExceptionHandling(t *testing.T) {
+	testExceptionHandling(t, wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigInterpreter().WithFeatureExceptionHandling(true)))
+}
+
+func TestEngineCompiler_MultiMemory(t *testing.T) {
+	if !platform.CompilerSupported() {
+		t.Skip()
+	}
+	testMultiMemory(t, wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigCompiler().WithWasmCore2().WithFeatureMultiMemory(true)))
+}
+
+func TestEngineInterpreter_MultiMemory(t *testing.T) {
+	testMultiMemory(t, wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigInterpreter().WithWasmCore2().WithFeatureMultiMemory(true)))
+}
+
+func TestEngineCompiler_Memory64(t *testing.T) {
+	if !platform.CompilerSupported() {
+		t.Skip()
+	}
+	testMemory64(t, wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigCompiler().WithWasmCore2().WithFeatureMemory64(true)))
+}
+
+func TestEngineInterpreter_Memory64(t *testing.T) {
+	testMemory64(t, wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigInterpreter().WithWasmCore2().WithFeatureMemory64(true)))
+}
+
+func TestEngineCompiler_ExtendedConst(t *testing.T) {
+	if !platform.CompilerSupported() {
+		t.Skip()
+	}
+	testExtendedConst(t, wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigCompiler().WithFeatureExtendedConst(true)))
+}
+
+func TestEngineInterpreter_ExtendedConst(t *testing.T) {
+	testExtendedConst(t, wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigInterpreter().WithFeatureExtendedConst(true)))
+}
+
+func TestEngineCompiler_RelaxedSIMD(t *testing.T) {
+	if !platform.CompilerSupported() || runtime.GOARCH != "amd64" {
+		// TODO: implement on arm64.
+		t.Skip()
+	}
+	testRelaxedSIMD(t, wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigCompiler().WithWasmCore2().WithFeatureRelaxedSIMD(true)))
+}
+
+func TestEngineInterpreter_RelaxedSIMD(t *testing.T) {
+	testRelaxedSIMD(t, wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigInterpreter().WithWasmCore2().WithFeatureRelaxedSIMD(true)))
+}
+
+func TestEngineCompiler_FunctionListener(t *testing.T) {
+	if !platform.CompilerSupported() {
+		t.Skip()
+	}
+	testFunctionListener(t, wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigCompiler()))
+}
+
+func TestEngineCompiler_FunctionListenerNested(t *testing.T) {
+	if !platform.CompilerSupported() {
+		t.Skip()
+	}
+	testFunctionListenerNested(t, wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigCompiler()))
+}
+
+func TestEngineInterpreter_FunctionListenerNested(t *testing.T) {
+	testFunctionListenerNested(t, wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigInterpreter()))
+}
+
 func runAllTests(t *testing.T, tests map[string]func(t *testing.T, r wazero.Runtime), config wazero.RuntimeConfig) {
 	config = config.WithFeatureReferenceTypes(true)
 	for name, testf := range tests {
@@ -97,49 +254,1087 @@ func testReftypeImports(t *testing.T, r wazero.Runtime) {
 	require.NoError(t, err)
 	defer host.Close(testCtx)
 
-	module, err := r.InstantiateModuleFromBinary(testCtx, reftypeImportsWasm)
+	module, err := r.InstantiateModuleFromBinary(testCtx, reftypeImportsWasm)
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	actual, err := module.ExportedFunction("get_externref_by_host").Call(testCtx)
+	require.NoError(t, err)
+
+	// Verifies that the returned raw uintptr is the same as the one for the host object.
+	require.Equal(t, uintptr(unsafe.Pointer(hostObj)), uintptr(actual[0]))
+}
+
+// tableModule returns a module with a function "dispatch", which calls the function at the given index in its table.
+func tableModule(importTable bool) []byte {
+	// Below set manually until the text compiler supports this:
+	//	(type $i32 (func (result i32)))
+	//	(import "host" "table" (table 2 funcref)) ;; only when importTable, else defined and exported as "table"
+	//	(func $one (type $i32) (i32.const 1))
+	//	(func $two (type $i32) (i32.const 2))
+	//	(func (export "dispatch") (param i32) (result i32) (call_indirect (type $i32) (local.get 0)))
+	//	(elem (i32.const 0) $one)
+	//	(export "two" (func $two))
+	i32 := &wasm.FunctionType{Results: []wasm.ValueType{wasm.ValueTypeI32}}
+	one := wasm.Index(0)
+	m := &wasm.Module{
+		TypeSection:     []*wasm.FunctionType{i32, {Params: []wasm.ValueType{wasm.ValueTypeI32}, Results: []wasm.ValueType{wasm.ValueTypeI32}}},
+		FunctionSection: []wasm.Index{0, 0, 1},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeI32Const, 1, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeI32Const, 2, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeCallIndirect, 0, 0, wasm.OpcodeEnd}},
+		},
+		ElementSection: []*wasm.ElementSegment{{
+			OffsetExpr: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+			Init:       []*wasm.Index{&one},
+			Type:       wasm.RefTypeFuncref,
+		}},
+		ExportSection: []*wasm.Export{
+			{Name: "two", Type: wasm.ExternTypeFunc, Index: 1},
+			{Name: "dispatch", Type: wasm.ExternTypeFunc, Index: 2},
+		},
+	}
+	if importTable {
+		m.ImportSection = []*wasm.Import{{Module: "host", Name: "table", Type: wasm.ExternTypeTable,
+			DescTable: &wasm.Table{Min: 2, Type: wasm.RefTypeFuncref}}}
+	} else {
+		m.TableSection = []*wasm.Table{{Min: 2, Type: wasm.RefTypeFuncref}}
+		m.ExportSection = append(m.ExportSection, &wasm.Export{Name: "table", Type: wasm.ExternTypeTable, Index: 0})
+	}
+	return binaryformat.EncodeModule(m)
+}
+
+func testExportedTable(t *testing.T, r wazero.Runtime) {
+	module, err := r.InstantiateModuleFromBinary(testCtx, tableModule(false))
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	require.Nil(t, module.ExportedTable("dispatch"))
+	table := module.ExportedTable("table")
+	require.Equal(t, api.ValueTypeFuncref, table.Type())
+	require.Equal(t, uint32(2), table.Size(testCtx))
+
+	// The function initialized by the element segment is the same one Wasm calls.
+	fn, ok := table.GetFunction(testCtx, 0)
+	require.True(t, ok)
+	results, err := fn.Call(testCtx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), results[0])
+
+	// Wasm sees functions set by the host.
+	dispatch := module.ExportedFunction("dispatch")
+	require.True(t, table.SetFunction(testCtx, 0, module.ExportedFunction("two")))
+	results, err = dispatch.Call(testCtx, 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), results[0])
+
+	// Null references are a trap in Wasm.
+	require.True(t, table.SetFunction(testCtx, 0, nil))
+	_, err = dispatch.Call(testCtx, 0)
+	require.Error(t, err)
+
+	// Grown elements can be set, too.
+	previousSize, ok := table.Grow(testCtx, 1)
+	require.True(t, ok)
+	require.Equal(t, uint32(2), previousSize)
+	require.True(t, table.SetFunction(testCtx, 2, fn))
+	results, err = dispatch.Call(testCtx, 2)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), results[0])
+
+	// Out of range
+	require.False(t, table.SetFunction(testCtx, 3, fn))
+	_, ok = table.GetFunction(testCtx, 3)
+	require.False(t, ok)
+
+	// Functions of closed modules can't be set.
+	compiled, err := r.CompileModule(testCtx, tableModule(false), wazero.NewCompileConfig())
+	require.NoError(t, err)
+	closed, err := r.InstantiateModule(testCtx, compiled, wazero.NewModuleConfig().WithName("closed"))
+	require.NoError(t, err)
+	two := closed.ExportedFunction("two")
+	require.NoError(t, closed.Close(testCtx))
+	require.False(t, table.SetFunction(testCtx, 2, two))
+	results, err = dispatch.Call(testCtx, 2)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), results[0])
+}
+
+// TestExportedTable_FunctionOfOtherRuntime isn't in tests, as it needs runtimes of both engines.
+func TestExportedTable_FunctionOfOtherRuntime(t *testing.T) {
+	if !platform.CompilerSupported() {
+		t.Skip()
+	}
+
+	compiler, interpreter := wazero.NewRuntimeConfigCompiler(), wazero.NewRuntimeConfigInterpreter()
+	for _, tc := range []struct {
+		name      string
+		table, fn wazero.RuntimeConfig
+	}{
+		{name: "interpreter into compiler", table: compiler, fn: interpreter},
+		{name: "compiler into interpreter", table: interpreter, fn: compiler},
+		{name: "compiler into compiler", table: compiler, fn: compiler},
+		{name: "interpreter into interpreter", table: interpreter, fn: interpreter},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			testSetFunctionOfOtherRuntime(t, wazero.NewRuntimeWithConfig(tc.table), wazero.NewRuntimeWithConfig(tc.fn))
+		})
+	}
+}
+
+// testSetFunctionOfOtherRuntime ensures a table rejects functions of another runtime, as its references are
+// specific to the runtime which defines them.
+func testSetFunctionOfOtherRuntime(t *testing.T, r, other wazero.Runtime) {
+	defer r.Close(testCtx)
+	defer other.Close(testCtx)
+
+	module, err := r.InstantiateModuleFromBinary(testCtx, tableModule(false))
+	require.NoError(t, err)
+	otherModule, err := other.InstantiateModuleFromBinary(testCtx, tableModule(false))
+	require.NoError(t, err)
+
+	table := module.ExportedTable("table")
+	require.False(t, table.SetFunction(testCtx, 0, otherModule.ExportedFunction("two")))
+
+	// The table is unchanged.
+	results, err := module.ExportedFunction("dispatch").Call(testCtx, 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), results[0])
+}
+
+func testHostTable(t *testing.T, r wazero.Runtime) {
+	host, err := r.NewModuleBuilder("host").ExportTableWithMax("table", api.ValueTypeFuncref, 2, 2).Instantiate(testCtx, r)
+	require.NoError(t, err)
+	defer host.Close(testCtx)
+
+	module, err := r.InstantiateModuleFromBinary(testCtx, tableModule(true))
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	// The host sees functions set by Wasm's element segment.
+	table := host.ExportedTable("table")
+	fn, ok := table.GetFunction(testCtx, 0)
+	require.True(t, ok)
+	results, err := fn.Call(testCtx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), results[0])
+
+	// Wasm sees functions set by the host.
+	require.True(t, table.SetFunction(testCtx, 1, module.ExportedFunction("two")))
+	results, err = module.ExportedFunction("dispatch").Call(testCtx, 1)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), results[0])
+
+	// The table can't grow beyond its max.
+	_, ok = table.Grow(testCtx, 1)
+	require.False(t, ok)
+}
+
+func testSnapshotRestore(t *testing.T, r wazero.Runtime) {
+	memoryCompiled, err := r.CompileModule(testCtx, wat2wasm(`(module
+  (func $grow (param $delta i32) (result (;previous_size;) i32) local.get 0 memory.grow)
+  (memory 1 3)
+  (export "grow" (func $grow))
+)`), wazero.NewCompileConfig())
+	require.NoError(t, err)
+	defer memoryCompiled.Close(testCtx)
+
+	tableCompiled, err := r.CompileModule(testCtx, tableModule(false), wazero.NewCompileConfig())
+	require.NoError(t, err)
+	defer tableCompiled.Close(testCtx)
+
+	instantiate := func(compiled wazero.CompiledModule, name string) api.Module {
+		mod, err := r.InstantiateModule(testCtx, compiled, wazero.NewModuleConfig().WithName(name))
+		require.NoError(t, err)
+		return mod
+	}
+
+	t.Run("memory", func(t *testing.T) {
+		mod := instantiate(memoryCompiled, "memory")
+		defer mod.Close(testCtx)
+
+		_, err := mod.ExportedFunction("grow").Call(testCtx, 1)
+		require.NoError(t, err)
+		require.True(t, mod.Memory().WriteUint32Le(testCtx, wasm.MemoryPageSize, 42))
+		snapshot, err := wazero.SnapshotModule(mod)
+		require.NoError(t, err)
+
+		restored := instantiate(memoryCompiled, "restored")
+		defer restored.Close(testCtx)
+		require.NoError(t, wazero.RestoreModule(testCtx, restored, snapshot))
+
+		require.Equal(t, 2*wasm.MemoryPageSize, restored.Memory().Size(testCtx))
+		v, ok := restored.Memory().ReadUint32Le(testCtx, wasm.MemoryPageSize)
+		require.True(t, ok)
+		require.Equal(t, uint32(42), v)
+
+		// Snapshots can only be restored into instances of the same module.
+		other := instantiate(tableCompiled, "other")
+		defer other.Close(testCtx)
+		require.EqualError(t, wazero.RestoreModule(testCtx, other, snapshot), "snapshot is of a different module")
+	})
+
+	t.Run("table", func(t *testing.T) {
+		mod := instantiate(tableCompiled, "table")
+		defer mod.Close(testCtx)
+
+		require.True(t, mod.ExportedTable("table").SetFunction(testCtx, 1, mod.ExportedFunction("two")))
+		snapshot, err := wazero.SnapshotModule(mod)
+		require.NoError(t, err)
+
+		// The restored table references functions of the restored instance.
+		restored := instantiate(tableCompiled, "restored")
+		defer restored.Close(testCtx)
+		require.NoError(t, wazero.RestoreModule(testCtx, restored, snapshot))
+		mod.Close(testCtx)
+
+		results, err := restored.ExportedFunction("dispatch").Call(testCtx, 1)
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), results[0])
+	})
+}
+
+// recordingListenerFactory implements experimental.FunctionListenerFactory to record calls of all functions.
+type recordingListenerFactory struct {
+	t      *testing.T
+	events []string
+	// callers are the names of the functions whose context was passed to Before, or empty for the first call.
+	callers []string
+}
+
+// NewListener implements the same method as documented on experimental.FunctionListenerFactory.
+func (f *recordingListenerFactory) NewListener(fnd experimental.FunctionDefinition) experimental.FunctionListener {
+	return &recordingListener{factory: f, name: fnd.Name()}
+}
+
+// funcNameKey is a context.Context Value key of the name of the function being called.
+type funcNameKey struct{}
+
+// recordingListener implements experimental.FunctionListener to record calls of a function.
+type recordingListener struct {
+	factory *recordingListenerFactory
+	name    string
+}
+
+// Before implements the same method as documented on experimental.FunctionListener.
+func (l *recordingListener) Before(ctx context.Context, params []uint64) context.Context {
+	l.factory.events = append(l.factory.events, fmt.Sprintf("before %s%v", l.name, params))
+	caller, _ := ctx.Value(funcNameKey{}).(string)
+	l.factory.callers = append(l.factory.callers, caller)
+	return context.WithValue(ctx, funcNameKey{}, l.name)
+}
+
+// After implements the same method as documented on experimental.FunctionListener.
+func (l *recordingListener) After(ctx context.Context, err error, results []uint64) {
+	// The context must be the one returned by Before, even after nested calls.
+	require.Equal(l.factory.t, l.name, ctx.Value(funcNameKey{}))
+	event := fmt.Sprintf("after %s%v", l.name, results)
+	if err != nil {
+		event += " with error"
+	}
+	l.factory.events = append(l.factory.events, event)
+}
+
+func testFunctionListener(t *testing.T, r wazero.Runtime) {
+	factory := &recordingListenerFactory{t: t}
+	ctx := context.WithValue(testCtx, experimental.FunctionListenerFactoryKey{}, factory)
+
+	double := func(ctx context.Context, x uint32) uint32 {
+		require.Equal(t, "double", ctx.Value(funcNameKey{})) // host functions are called with the listener's context
+		return x * 2
+	}
+	host, err := r.NewModuleBuilder("host").ExportFunction("double", double).Instantiate(ctx, r)
+	require.NoError(t, err)
+	defer host.Close(testCtx)
+
+	module, err := r.InstantiateModuleFromBinary(ctx, wat2wasm(`(module $test
+  (import "host" "double" (func $double (param i32) (result i32)))
+  (func $add_doubled (param i32 i32) (result i32)
+    local.get 0
+    call $double
+    local.get 1
+    call $double
+    i32.add
+  )
+  (memory 0)
+  (func $trap i32.const 0 i64.const 0 i64.store) ;; out of bounds
+  (func $call_trap call $trap)
+  (export "add_doubled" (func $add_doubled))
+  (export "call_trap" (func $call_trap))
+)`))
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	results, err := module.ExportedFunction("add_doubled").Call(ctx, 1, 2)
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), results[0])
+	require.Equal(t, []string{
+		"before add_doubled[1 2]",
+		"before double[1]",
+		"after double[2]",
+		"before double[2]",
+		"after double[4]",
+		"after add_doubled[6]",
+	}, factory.events)
+
+	// Functions which don't return due to a trap are notified with the error.
+	factory.events = nil
+	_, err = module.ExportedFunction("call_trap").Call(ctx)
+	require.Error(t, err)
+	require.Equal(t, []string{
+		"before call_trap[]",
+		"before trap[]",
+		"after trap[] with error",
+		"after call_trap[] with error",
+	}, factory.events)
+
+	// Host functions called directly are notified, too.
+	factory.events = nil
+	results, err = host.ExportedFunction("double").Call(ctx, 3)
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), results[0])
+	require.Equal(t, []string{"before double[3]", "after double[6]"}, factory.events)
+}
+
+// testFunctionListenerNested ensures each function calling another is notified with its own listener, and that
+// the context returned by the listener of a callee isn't seen by later calls of the caller.
+func testFunctionListenerNested(t *testing.T, r wazero.Runtime) {
+	factory := &recordingListenerFactory{t: t}
+	ctx := context.WithValue(testCtx, experimental.FunctionListenerFactoryKey{}, factory)
+
+	inc := wasm.Index(0)
+	i32 := wasm.ValueTypeI32
+	module, err := r.InstantiateModuleFromBinary(ctx, binaryformat.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}}},
+		FunctionSection: []wasm.Index{0, 0, 0},
+		TableSection:    []*wasm.Table{{Min: 1, Type: wasm.RefTypeFuncref}},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{ // (func $inc (param i32) (result i32)
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Add,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // (func $inc_twice (param i32) (result i32), which calls $inc directly, then via the table.
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeCall, 0,
+				wasm.OpcodeI32Const, 0,
+				wasm.OpcodeCallIndirect, 0, 0,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // (func $inc_four_times (param i32) (result i32)
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeCall, 1,
+				wasm.OpcodeCall, 1,
+				wasm.OpcodeEnd,
+			}},
+		},
+		ElementSection: []*wasm.ElementSegment{{
+			OffsetExpr: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+			Init:       []*wasm.Index{&inc},
+			Type:       wasm.RefTypeFuncref,
+		}},
+		ExportSection: []*wasm.Export{{Name: "inc_four_times", Type: wasm.ExternTypeFunc, Index: 2}},
+		NameSection: &wasm.NameSection{FunctionNames: wasm.NameMap{
+			{Index: 0, Name: "inc"}, {Index: 1, Name: "inc_twice"}, {Index: 2, Name: "inc_four_times"},
+		}},
+	}))
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	results, err := module.ExportedFunction("inc_four_times").Call(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), results[0])
+	require.Equal(t, []string{
+		"before inc_four_times[1]",
+		"before inc_twice[1]",
+		"before inc[1]",
+		"after inc[2]",
+		"before inc[2]",
+		"after inc[3]",
+		"after inc_twice[3]",
+		"before inc_twice[3]",
+		"before inc[3]",
+		"after inc[4]",
+		"before inc[4]",
+		"after inc[5]",
+		"after inc_twice[5]",
+		"after inc_four_times[5]",
+	}, factory.events)
+	require.Equal(t, []string{
+		"",
+		"inc_four_times",
+		"inc_twice",
+		"inc_twice",
+		"inc_four_times",
+		"inc_twice",
+		"inc_twice",
+	}, factory.callers)
+}
+
+func testHugeStack(t *testing.T, r wazero.Runtime) {
+	module, err := r.InstantiateModuleFromBinary(testCtx, hugestackWasm)
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	fn := module.ExportedFunction("main")
+	require.NotNil(t, fn)
+
+	_, err = fn.Call(testCtx)
+	require.NoError(t, err)
+}
+
+func testUnreachable(t *testing.T, r wazero.Runtime) {
+	callUnreachable := func(nil api.Module) {
+		panic("panic in host function")
+	}
+
+	_, err := r.NewModuleBuilder("host").ExportFunction("cause_unreachable", callUnreachable).Instantiate(testCtx, r)
+	require.NoError(t, err)
+
+	module, err := r.InstantiateModuleFromBinary(testCtx, unreachableWasm)
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	_, err = module.ExportedFunction("main").Call(testCtx)
+	exp := `panic in host function (recovered by wazero)
+wasm stack trace:
+	host.cause_unreachable()
+	.two()
+	.one()
+	.main()`
+	require.Equal(t, exp, err.Error())
+}
+
+// testStackTraceFrames ensures errors from calls which panicked include the frames as data, with the offset of the
+// instruction in progress in each function body.
+func testStackTraceFrames(t *testing.T, r wazero.Runtime) {
+	hostErr := errors.New("host error")
+	_, err := r.NewModuleBuilder("host").ExportFunction("panic", func() { panic(hostErr) }).Instantiate(testCtx, r)
+	require.NoError(t, err)
+
+	module, err := r.InstantiateModuleFromBinary(testCtx, wat2wasm(`(module $stack
+  (import "host" "panic" (func $host_panic))
+  (memory 1)
+  (func $trap (param $addr i32) local.get 0 i64.const 0 i64.store)
+  (func $call_trap i32.const 65536 call $trap)
+  (func $call_host i32.const 0 drop call $host_panic)
+  (export "call_trap" (func $call_trap))
+  (export "call_host" (func $call_host))
+)`))
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	tests := []struct {
+		name           string
+		expectedCause  error
+		expectedFrames []sys.StackFrame
+	}{
+		{
+			name: "call_trap",
+			expectedFrames: []sys.StackFrame{
+				// i64.store follows local.get 0 and i64.const 0, which are two bytes each.
+				{ModuleName: "stack", FunctionIndex: 1, FunctionName: "trap", ParamTypes: []api.ValueType{api.ValueTypeI32},
+					ParamNames: []string{"addr"}, Offset: 4},
+				// call follows i32.const 65536, which is four bytes.
+				{ModuleName: "stack", FunctionIndex: 2, FunctionName: "call_trap", Offset: 4},
+			},
+		},
+		{
+			name:          "call_host",
+			expectedCause: hostErr,
+			expectedFrames: []sys.StackFrame{
+				{ModuleName: "host", FunctionIndex: 0, FunctionName: "panic", ParamTypes: []api.ValueType{},
+					ResultTypes: []api.ValueType{}, Offset: -1},
+				{ModuleName: "stack", FunctionIndex: 3, FunctionName: "call_host", Offset: 3},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := module.ExportedFunction(tc.name).Call(testCtx)
+			var stErr *sys.StackTraceError
+			require.True(t, errors.As(err, &stErr))
+			require.Equal(t, tc.expectedFrames, stErr.Frames())
+			if tc.expectedCause != nil {
+				require.ErrorIs(t, err, tc.expectedCause)
+			}
+		})
+	}
+}
+
+// testHostFunctionError ensures a non-nil error returned by a host function unwinds the Wasm stack, and a nil one
+// returns the other results normally.
+func testHostFunctionError(t *testing.T, r wazero.Runtime) {
+	errZero := errors.New("zero")
+	inverse := func(x uint32) (uint32, error) {
+		if x == 0 {
+			return 0, errZero
+		}
+		return math.MaxUint32 / x, nil
+	}
+	_, err := r.NewModuleBuilder("host").ExportFunction("inverse", inverse).Instantiate(testCtx, r)
+	require.NoError(t, err)
+
+	module, err := r.InstantiateModuleFromBinary(testCtx, wat2wasm(`(module $math
+  (import "host" "inverse" (func $inverse (param i32) (result i32)))
+  (func $call_inverse (param i32) (result i32) local.get 0 call $inverse)
+  (export "call_inverse" (func $call_inverse))
+)`))
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	results, err := module.ExportedFunction("call_inverse").Call(testCtx, 3)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{math.MaxUint32 / 3}, results)
+
+	_, err = module.ExportedFunction("call_inverse").Call(testCtx, 0)
+	require.EqualError(t, err, `zero
+wasm stack trace:
+	host.inverse(i32) i32
+	math.call_inverse(i32) i32`)
+	require.ErrorIs(t, err, errZero)
+
+	// The module can be called again, as the error didn't close it.
+	results, err = module.ExportedFunction("call_inverse").Call(testCtx, 1)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{math.MaxUint32}, results)
+}
+
+// testGoModuleFunction ensures an api.GoModuleFunction is called with its params on the stack, with the caller's
+// memory, and that values on the stack below them are preserved.
+func testGoModuleFunction(t *testing.T, r wazero.Runtime) {
+	factory := &recordingListenerFactory{t: t}
+	ctx := context.WithValue(testCtx, experimental.FunctionListenerFactoryKey{}, factory)
+
+	add := api.GoModuleFunc(func(ctx context.Context, _ api.Module, stack []uint64) {
+		require.Equal(t, "add", ctx.Value(funcNameKey{})) // called with the listener's context
+		stack[0] = uint64(uint32(stack[0]) + uint32(stack[1]))
+	})
+	memorySize := api.GoModuleFunc(func(ctx context.Context, mod api.Module, stack []uint64) {
+		stack[0] = uint64(mod.Memory().Size(ctx))
+	})
+	i32 := api.ValueTypeI32
+	host, err := r.NewModuleBuilder("host").
+		ExportGoModuleFunction("add", add, []api.ValueType{i32, i32}, []api.ValueType{i32}).
+		ExportGoModuleFunction("memory_size", memorySize, nil, []api.ValueType{i32}).
+		Instantiate(ctx, r)
+	require.NoError(t, err)
+	defer host.Close(testCtx)
+
+	module, err := r.InstantiateModuleFromBinary(ctx, wat2wasm(`(module $test
+  (import "host" "add" (func $add (param i32 i32) (result i32)))
+  (import "host" "memory_size" (func $memory_size (result i32)))
+  (memory 1)
+  (func $add_memory_size (param i32) (result i32)
+    i32.const 10 ;; below the params of $add
+    local.get 0
+    call $memory_size
+    call $add
+    i32.add
+  )
+  (export "add_memory_size" (func $add_memory_size))
+)`))
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	results, err := module.ExportedFunction("add_memory_size").Call(ctx, 5)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{10 + 5 + 65536}, results)
+	require.Equal(t, []string{
+		"before add_memory_size[5]",
+		"before memory_size[]",
+		"after memory_size[65536]",
+		"before add[5 65536]",
+		"after add[65541]",
+		"after add_memory_size[65551]",
+	}, factory.events)
+
+	// Host functions called directly are called with the stack, too.
+	factory.events = nil
+	results, err = host.ExportedFunction("add").Call(ctx, 1, 2)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{3}, results)
+	require.Equal(t, []string{"before add[1 2]", "after add[3]"}, factory.events)
+}
+
+// testCallsWithoutAllocations ensures calls of Wasm and host functions don't allocate, including when the host function
+// is called with the memory of an imported function, which differs from the memory of the module called.
+func testCallsWithoutAllocations(t *testing.T, r wazero.Runtime) {
+	inc := api.GoModuleFunc(func(ctx context.Context, mod api.Module, stack []uint64) {
+		stack[0] = uint64(uint32(stack[0]) + 1)
+	})
+	i32 := api.ValueTypeI32
+	host, err := r.NewModuleBuilder("host").
+		ExportGoModuleFunction("inc", inc, []api.ValueType{i32}, []api.ValueType{i32}).
+		Instantiate(testCtx, r)
+	require.NoError(t, err)
+	defer host.Close(testCtx)
+
+	lib, err := r.InstantiateModuleFromBinary(testCtx, wat2wasm(`(module $lib
+  (import "host" "inc" (func $inc (param i32) (result i32)))
+  (memory 1)
+  (func $inc_by_memory_size (param i32) (result i32)
+    local.get 0
+    call $inc
+  )
+  (export "inc" (func $inc_by_memory_size))
+)`))
+	require.NoError(t, err)
+	defer lib.Close(testCtx)
+
+	i32Type := wasm.ValueTypeI32
+	module, err := r.InstantiateModuleFromBinary(testCtx, binaryformat.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{i32Type}, Results: []wasm.ValueType{i32Type}}},
+		ImportSection:   []*wasm.Import{{Module: "lib", Name: "inc", Type: wasm.ExternTypeFunc, DescFunc: 0}},
+		FunctionSection: []wasm.Index{0},
+		MemorySection:   []*wasm.Memory{{Min: 2}},
+		CodeSection: []*wasm.Code{
+			{LocalTypes: []wasm.ValueType{i32Type}, Body: []byte{ // (func $loop (param $n i32) (result i32) (local $acc i32)
+				wasm.OpcodeBlock, 0x40,
+				wasm.OpcodeLoop, 0x40,
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Eqz, wasm.OpcodeBrIf, 1,
+				wasm.OpcodeLocalGet, 1, wasm.OpcodeCall, 0, wasm.OpcodeLocalSet, 1,
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Sub, wasm.OpcodeLocalSet, 0,
+				wasm.OpcodeBr, 0,
+				wasm.OpcodeEnd,
+				wasm.OpcodeEnd,
+				wasm.OpcodeLocalGet, 1,
+				wasm.OpcodeEnd,
+			}},
+		},
+		ExportSection: []*wasm.Export{{Name: "loop", Type: wasm.ExternTypeFunc, Index: 1}},
+	}))
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	loop := module.ExportedFunction("loop")
+	results, err := loop.Call(testCtx, 100)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{100}, results)
+
+	// The number of calls doesn't change the allocations, which are those of the api.Function Call.
+	allocs := func(n uint64) float64 {
+		return testing.AllocsPerRun(10, func() {
+			if _, err := loop.Call(testCtx, n); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+	require.Equal(t, allocs(1), allocs(100))
+}
+
+// testHostFunctionRetainingModule ensures the api.Module passed to a host function with the memory of its caller stays
+// valid after it returns, even when the next call of the same host function has another caller memory.
+func testHostFunctionRetainingModule(t *testing.T, r wazero.Runtime) {
+	var retained []api.Module
+	host, err := r.NewModuleBuilder("host").
+		ExportFunction("retain", func(ctx context.Context, mod api.Module) { retained = append(retained, mod) }).
+		Instantiate(testCtx, r)
+	require.NoError(t, err)
+	defer host.Close(testCtx)
+
+	// lib1 and lib2 call the host function with their memory, which differs from the memory of the module called.
+	for _, lib := range []struct {
+		name  string
+		pages int
+	}{{"lib1", 1}, {"lib2", 3}} {
+		mod, err := r.InstantiateModuleFromBinary(testCtx, wat2wasm(fmt.Sprintf(`(module $%s
+  (import "host" "retain" (func $retain))
+  (memory %d)
+  (func $call call $retain)
+  (export "call" (func $call))
+)`, lib.name, lib.pages)))
+		require.NoError(t, err)
+		defer mod.Close(testCtx)
+	}
+
+	mod, err := r.InstantiateModuleFromBinary(testCtx, wat2wasm(`(module
+  (import "lib1" "call" (func $call1))
+  (import "lib2" "call" (func $call2))
+  (memory 2)
+  (func $run call $call1 call $call2)
+  (export "run" (func $run))
+)`))
+	require.NoError(t, err)
+	defer mod.Close(testCtx)
+
+	for i := 0; i < 2; i++ {
+		_, err = mod.ExportedFunction("run").Call(testCtx)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 4, len(retained))
+	for i, pages := range []uint32{1, 3, 1, 3} {
+		require.Equal(t, pages*65536, retained[i].Memory().Size(testCtx), "retained[%d]", i)
+	}
+	// The module of each caller memory is reused, so calls don't allocate it.
+	require.Same(t, retained[0], retained[2])
+	require.Same(t, retained[1], retained[3])
+}
+
+// testThreads ensures module instances importing a shared memory can be called concurrently, and synchronize with
+// atomic instructions.
+func testThreads(t *testing.T, r wazero.Runtime) {
+	host, err := r.NewModuleBuilder("env").ExportSharedMemory("memory", 1, 1).Instantiate(testCtx, r)
+	require.NoError(t, err)
+	defer host.Close(testCtx)
+
+	i32, i64 := wasm.ValueTypeI32, wasm.ValueTypeI64
+	bin := binaryformat.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}},
+			{Params: []wasm.ValueType{i32, i32, i64}, Results: []wasm.ValueType{i32}},
+			{Params: []wasm.ValueType{i32, i32}, Results: []wasm.ValueType{i32}},
+			{Params: []wasm.ValueType{i32, i64, i64}, Results: []wasm.ValueType{i64}},
+		},
+		ImportSection: []*wasm.Import{{
+			Module: "env", Name: "memory", Type: wasm.ExternTypeMemory,
+			DescMem: &wasm.Memory{Min: 1, Max: 1, IsMaxEncoded: true, Shared: true},
+		}},
+		FunctionSection: []wasm.Index{0, 0, 1, 2, 3},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{ // (func $increment (param $addr i32) (result i32)
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeI32Const, 1,
+				wasm.OpcodeAtomicPrefix, wasm.OpcodeAtomicI32RmwAdd, 2, 0,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // (func $load (param $addr i32) (result i32)
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeAtomicPrefix, wasm.OpcodeAtomicI32Load, 2, 0,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // (func $wait (param $addr i32) (param $expected i32) (param $timeout i64) (result i32)
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeLocalGet, 1,
+				wasm.OpcodeLocalGet, 2,
+				wasm.OpcodeAtomicPrefix, wasm.OpcodeAtomicMemoryWait32, 2, 0,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // (func $notify (param $addr i32) (param $count i32) (result i32)
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeLocalGet, 1,
+				wasm.OpcodeAtomicPrefix, wasm.OpcodeAtomicMemoryNotify, 2, 0,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // (func $cmpxchg (param $addr i32) (param $expected i64) (param $replacement i64) (result i64)
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeLocalGet, 1,
+				wasm.OpcodeLocalGet, 2,
+				wasm.OpcodeAtomicPrefix, wasm.OpcodeAtomicI64RmwCmpxchg, 3, 0,
+				wasm.OpcodeEnd,
+			}},
+		},
+		ExportSection: []*wasm.Export{
+			{Name: "increment", Type: wasm.ExternTypeFunc, Index: 0},
+			{Name: "load", Type: wasm.ExternTypeFunc, Index: 1},
+			{Name: "wait", Type: wasm.ExternTypeFunc, Index: 2},
+			{Name: "notify", Type: wasm.ExternTypeFunc, Index: 3},
+			{Name: "cmpxchg", Type: wasm.ExternTypeFunc, Index: 4},
+		},
+	})
+	compiled, err := r.CompileModule(testCtx, bin, wazero.NewCompileConfig())
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	instances := make([]api.Module, 2)
+	for i := range instances {
+		instances[i], err = r.InstantiateModule(testCtx, compiled, wazero.NewModuleConfig().WithName(strconv.Itoa(i)))
+		require.NoError(t, err)
+		defer instances[i].Close(testCtx)
+	}
+
+	t.Run("concurrent increments", func(t *testing.T) {
+		const goroutines, increments = 4, 1000
+		done := make(chan error)
+		for g := 0; g < goroutines; g++ {
+			increment := instances[g%len(instances)].ExportedFunction("increment")
+			go func() {
+				var err error
+				for i := 0; i < increments && err == nil; i++ {
+					_, err = increment.Call(testCtx, 0)
+				}
+				done <- err
+			}()
+		}
+		for g := 0; g < goroutines; g++ {
+			require.NoError(t, <-done)
+		}
+
+		results, err := instances[1].ExportedFunction("load").Call(testCtx, 0)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{goroutines * increments}, results)
+	})
+
+	t.Run("compare exchange", func(t *testing.T) {
+		cmpxchg := instances[0].ExportedFunction("cmpxchg")
+		results, err := cmpxchg.Call(testCtx, 8, 1, 2)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{0}, results) // not replaced
+
+		results, err = cmpxchg.Call(testCtx, 8, 0, 2)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{0}, results)
+
+		v, ok := host.Memory().ReadUint64Le(testCtx, 8)
+		require.True(t, ok)
+		require.Equal(t, uint64(2), v)
+	})
+
+	t.Run("wait and notify", func(t *testing.T) {
+		wait, notify := instances[0].ExportedFunction("wait"), instances[1].ExportedFunction("notify")
+
+		results, err := wait.Call(testCtx, 16, 1, api.EncodeI64(-1))
+		require.NoError(t, err)
+		require.Equal(t, []uint64{1}, results) // not equal
+
+		results, err = wait.Call(testCtx, 16, 0, api.EncodeI64(int64(time.Millisecond)))
+		require.NoError(t, err)
+		require.Equal(t, []uint64{2}, results) // timed out
+
+		waited := make(chan []uint64)
+		go func() {
+			results, err := wait.Call(testCtx, 16, 0, api.EncodeI64(-1))
+			require.NoError(t, err)
+			waited <- results
+		}()
+		// Notify until the waiter is woken, as it may not be waiting yet.
+		for {
+			results, err = notify.Call(testCtx, 16, 1)
+			require.NoError(t, err)
+			if results[0] == 1 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		require.Equal(t, []uint64{0}, <-waited) // ok
+	})
+
+	t.Run("unaligned", func(t *testing.T) {
+		_, err := instances[0].ExportedFunction("increment").Call(testCtx, 2)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "wasm error: unaligned atomic")
+	})
+}
+
+// testStackTraceSources ensures stack traces include source locations when the module has DWARF custom sections.
+func testStackTraceSources(t *testing.T, r wazero.Runtime) {
+	module, err := r.InstantiateModuleFromBinary(testCtx, dwarftestdata.DWARFWasm)
 	require.NoError(t, err)
 	defer module.Close(testCtx)
 
-	actual, err := module.ExportedFunction("get_externref_by_host").Call(testCtx)
+	_, err = module.ExportedFunction("run").Call(testCtx)
+	require.EqualError(t, err, `wasm error: unreachable
+wasm stack trace:
+	dwarf.wasm.middle()
+		/testdata/dwarf.c:4:3 (inlined)
+		/testdata/dwarf.c:9:3
+	dwarf.wasm.run()
+		/testdata/dwarf.c:13:3`)
+
+	var stErr *sys.StackTraceError
+	require.True(t, errors.As(err, &stErr))
+	frames := stErr.Frames()
+	require.Equal(t, 2, len(frames))
+	require.Equal(t, []sys.SourceLocation{
+		{File: "/testdata/dwarf.c", Line: 4, Column: 3, Inlined: true},
+		{File: "/testdata/dwarf.c", Line: 9, Column: 3},
+	}, frames[0].Sources)
+	require.Equal(t, []sys.SourceLocation{{File: "/testdata/dwarf.c", Line: 13, Column: 3}}, frames[1].Sources)
+}
+
+// testEnsureTermination ensures a function running an infinite loop returns when its context is done, and that the
+// module can be called again afterwards.
+func testEnsureTermination(t *testing.T, r wazero.Runtime) {
+	defer r.Close(testCtx)
+
+	// Above set manually until the text compiler supports this:
+	//	(func (export "infinite_loop") (loop (br 0)))
+	//	(func (export "nop"))
+	bin := binaryformat.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{}},
+		FunctionSection: []wasm.Index{0, 0},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeLoop, 0x40, wasm.OpcodeBr, 0, wasm.OpcodeEnd, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeEnd}},
+		},
+		ExportSection: []*wasm.Export{
+			{Name: "infinite_loop", Type: wasm.ExternTypeFunc, Index: 0},
+			{Name: "nop", Type: wasm.ExternTypeFunc, Index: 1},
+		},
+	})
+	module, err := r.InstantiateModuleFromBinary(testCtx, bin)
 	require.NoError(t, err)
+	defer module.Close(testCtx)
 
-	// Verifies that the returned raw uintptr is the same as the one for the host object.
-	require.Equal(t, uintptr(unsafe.Pointer(hostObj)), uintptr(actual[0]))
+	infiniteLoop := module.ExportedFunction("infinite_loop")
+	nop := module.ExportedFunction("nop")
+
+	t.Run("cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(testCtx)
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			cancel()
+		}()
+		_, err := infiniteLoop.Call(ctx)
+		require.True(t, errors.Is(err, context.Canceled))
+
+		var ctxErr *sys.ContextDoneError
+		require.True(t, errors.As(err, &ctxErr))
+		require.Equal(t, `context done: context canceled
+wasm stack trace:
+	.[0]()`, err.Error())
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(testCtx, 50*time.Millisecond)
+		defer cancel()
+		_, err := infiniteLoop.Call(ctx)
+		require.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+
+	t.Run("done before entry", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(testCtx)
+		cancel()
+		_, err := nop.Call(ctx)
+		require.True(t, errors.Is(err, context.Canceled))
+	})
+
+	// The module isn't closed, so it can be called with a context that isn't done.
+	_, err = nop.Call(testCtx)
+	require.NoError(t, err)
 }
 
-func testHugeStack(t *testing.T, r wazero.Runtime) {
-	module, err := r.InstantiateModuleFromBinary(testCtx, hugestackWasm)
+// testFuelMetering ensures calls consume the same fuel regardless of the engine, and trap when they run out of it.
+func testFuelMetering(t *testing.T, r wazero.Runtime) {
+	defer r.Close(testCtx)
+
+	// Above set manually until the text compiler supports this:
+	//	(func (export "infinite_loop") (loop (br 0)))
+	//	(func (export "count_to_ten") (local i32)
+	//	  (loop
+	//	    (local.set 0 (i32.add (local.get 0) (i32.const 1)))
+	//	    (br_if 0 (i32.lt_u (local.get 0) (i32.const 10)))))
+	bin := binaryformat.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{}},
+		FunctionSection: []wasm.Index{0, 0},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeLoop, 0x40, wasm.OpcodeBr, 0, wasm.OpcodeEnd, wasm.OpcodeEnd}},
+			{LocalTypes: []wasm.ValueType{wasm.ValueTypeI32}, Body: []byte{
+				wasm.OpcodeLoop, 0x40,
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Add, wasm.OpcodeLocalSet, 0,
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Const, 10, wasm.OpcodeI32LtU, wasm.OpcodeBrIf, 0,
+				wasm.OpcodeEnd, wasm.OpcodeEnd,
+			}},
+		},
+		ExportSection: []*wasm.Export{
+			{Name: "infinite_loop", Type: wasm.ExternTypeFunc, Index: 0},
+			{Name: "count_to_ten", Type: wasm.ExternTypeFunc, Index: 1},
+		},
+	})
+	module, err := r.InstantiateModuleFromBinary(testCtx, bin)
 	require.NoError(t, err)
 	defer module.Close(testCtx)
 
-	fn := module.ExportedFunction("main")
-	require.NotNil(t, fn)
+	infiniteLoop := module.ExportedFunction("infinite_loop")
+	countToTen := module.ExportedFunction("count_to_ten")
 
-	_, err = fn.Call(testCtx)
-	require.NoError(t, err)
+	t.Run("out of fuel", func(t *testing.T) {
+		ctx, meter := experimental.WithFuel(testCtx, 1000)
+		_, err := infiniteLoop.Call(ctx)
+		require.True(t, errors.Is(err, experimental.ErrOutOfFuel))
+		require.Equal(t, int64(1000), meter.Used())
+		require.Equal(t, int64(0), meter.Remaining())
+	})
+
+	t.Run("deterministic", func(t *testing.T) {
+		ctx, meter := experimental.WithFuel(testCtx, 1000)
+		_, err := countToTen.Call(ctx)
+		require.NoError(t, err)
+		require.Equal(t, int64(94), meter.Used())
+
+		// The fuel is shared by calls made with the same context.
+		_, err = countToTen.Call(ctx)
+		require.NoError(t, err)
+		require.Equal(t, int64(188), meter.Used())
+	})
+
+	t.Run("unlimited without fuel", func(t *testing.T) {
+		_, err := countToTen.Call(testCtx)
+		require.NoError(t, err)
+	})
 }
 
-func testUnreachable(t *testing.T, r wazero.Runtime) {
-	callUnreachable := func(nil api.Module) {
-		panic("panic in host function")
-	}
+// testMemoryLimit ensures the limits of RuntimeConfig and ModuleConfig apply to instantiation and memory.grow.
+func testMemoryLimit(t *testing.T, config wazero.RuntimeConfig) {
+	r := wazero.NewRuntimeWithConfig(config.WithMemoryLimitPages(4))
+	defer r.Close(testCtx)
 
-	_, err := r.NewModuleBuilder("host").ExportFunction("cause_unreachable", callUnreachable).Instantiate(testCtx, r)
+	compiled, err := r.CompileModule(testCtx, wat2wasm(`(module
+  (func $grow (param $delta i32) (result (;previous_size;) i32) local.get 0 memory.grow)
+  (memory 1 10)
+  (export "grow" (func $grow))
+)`), wazero.NewCompileConfig())
 	require.NoError(t, err)
+	defer compiled.Close(testCtx)
 
-	module, err := r.InstantiateModuleFromBinary(testCtx, unreachableWasm)
-	require.NoError(t, err)
-	defer module.Close(testCtx)
+	t.Run("runtime limit", func(t *testing.T) {
+		mod, err := r.InstantiateModule(testCtx, compiled, wazero.NewModuleConfig().WithName(t.Name()))
+		require.NoError(t, err)
+		defer mod.Close(testCtx)
 
-	_, err = module.ExportedFunction("main").Call(testCtx)
-	exp := `panic in host function (recovered by wazero)
+		results, err := mod.ExportedFunction("grow").Call(testCtx, 3)
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), results[0])
+
+		results, err = mod.ExportedFunction("grow").Call(testCtx, 1)
+		require.NoError(t, err)
+		require.Equal(t, uint64(0xffffffff), results[0]) // -1
+		require.Equal(t, uint32(4*65536), mod.Memory().Size(testCtx))
+	})
+
+	t.Run("module limit", func(t *testing.T) {
+		var current, requested, limit uint32
+		mod, err := r.InstantiateModule(testCtx, compiled, wazero.NewModuleConfig().WithName(t.Name()).
+			WithMemoryLimitPages(2).
+			WithMemoryLimitExceeded(func(ctx context.Context, currentPages, requestedPages, limitPages uint32) error {
+				current, requested, limit = currentPages, requestedPages, limitPages
+				return nil
+			}))
+		require.NoError(t, err)
+		defer mod.Close(testCtx)
+
+		results, err := mod.ExportedFunction("grow").Call(testCtx, 2)
+		require.NoError(t, err)
+		require.Equal(t, uint64(0xffffffff), results[0]) // -1
+		require.Equal(t, []uint32{1, 3, 2}, []uint32{current, requested, limit})
+
+		// The host can't grow past the limit either.
+		_, ok := mod.Memory().Grow(testCtx, 2)
+		require.False(t, ok)
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		mod, err := r.InstantiateModule(testCtx, compiled, wazero.NewModuleConfig().WithName("denied").
+			WithMemoryLimitExceeded(func(context.Context, uint32, uint32, uint32) error {
+				return errors.New("plugin exceeded its memory quota")
+			}))
+		require.NoError(t, err)
+		defer mod.Close(testCtx)
+
+		_, err = mod.ExportedFunction("grow").Call(testCtx, 4)
+		require.EqualError(t, err, `plugin exceeded its memory quota (recovered by wazero)
 wasm stack trace:
-	host.cause_unreachable()
-	.two()
-	.one()
-	.main()`
-	require.Equal(t, exp, err.Error())
+	denied.grow(i32) i32`)
+	})
+
+	t.Run("min over limit", func(t *testing.T) {
+		_, err := r.InstantiateModule(testCtx, compiled, wazero.NewModuleConfig().WithName(t.Name()).
+			WithMemoryLimitPages(0))
+		require.EqualError(t, err, "memory min 1 pages (64 Ki) over limit of 0 pages (0 Ki)")
+	})
 }
 
 func testRecursiveEntry(t *testing.T, r wazero.Runtime) {
@@ -573,3 +1768,758 @@ func testMultipleInstantiation(t *testing.T, r wazero.Runtime) {
 		require.Equal(t, uint64(1000), after)
 	}
 }
+
+// testTailCall ensures tail calls replace the frame of the caller, so that recursion deeper than the call stack
+// ceiling succeeds, and that they return the results of the called function, including host functions.
+func testTailCall(t *testing.T, r wazero.Runtime) {
+	host, err := r.NewModuleBuilder("env").ExportFunction("double", func(x uint64) uint64 {
+		return x * 2
+	}).Instantiate(testCtx, r)
+	require.NoError(t, err)
+	defer host.Close(testCtx)
+
+	i64 := wasm.ValueTypeI64
+	sumIndirect := wasm.Index(2)
+	bin := binaryformat.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Params: []wasm.ValueType{i64, i64}, Results: []wasm.ValueType{i64}},
+			{Params: []wasm.ValueType{i64}, Results: []wasm.ValueType{i64}},
+		},
+		ImportSection:   []*wasm.Import{{Module: "env", Name: "double", Type: wasm.ExternTypeFunc, DescFunc: 1}},
+		FunctionSection: []wasm.Index{0, 0, 1},
+		TableSection:    []*wasm.Table{{Min: 1, Type: wasm.RefTypeFuncref}},
+		CodeSection: []*wasm.Code{
+			{LocalTypes: []wasm.ValueType{i64}, Body: []byte{ // (func $sum (param $n i64) (param $acc i64) (result i64)
+				wasm.OpcodeLocalGet, 1, // below the parameters of the tail call, so it must be dropped.
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeI64Eqz,
+				wasm.OpcodeIf, 0x40,
+				wasm.OpcodeLocalGet, 1,
+				wasm.OpcodeReturn,
+				wasm.OpcodeEnd,
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeI64Const, 1, wasm.OpcodeI64Sub, wasm.OpcodeLocalTee, 2,
+				wasm.OpcodeLocalGet, 1, wasm.OpcodeLocalGet, 0, wasm.OpcodeI64Add,
+				wasm.OpcodeReturnCall, 1,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // (func $sum_indirect (param $n i64) (param $acc i64) (result i64), which calls itself via the table.
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeI64Eqz,
+				wasm.OpcodeIf, 0x40,
+				wasm.OpcodeLocalGet, 1,
+				wasm.OpcodeReturn,
+				wasm.OpcodeEnd,
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeI64Const, 1, wasm.OpcodeI64Sub,
+				wasm.OpcodeLocalGet, 1, wasm.OpcodeLocalGet, 0, wasm.OpcodeI64Add,
+				wasm.OpcodeI32Const, 0,
+				wasm.OpcodeReturnCallIndirect, 0, 0,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // (func $double (param i64) (result i64)
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeReturnCall, 0,
+				wasm.OpcodeEnd,
+			}},
+		},
+		ElementSection: []*wasm.ElementSegment{{
+			OffsetExpr: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+			Init:       []*wasm.Index{&sumIndirect},
+			Type:       wasm.RefTypeFuncref,
+		}},
+		ExportSection: []*wasm.Export{
+			{Name: "sum", Type: wasm.ExternTypeFunc, Index: 1},
+			{Name: "sum_indirect", Type: wasm.ExternTypeFunc, Index: 2},
+			{Name: "double", Type: wasm.ExternTypeFunc, Index: 3},
+		},
+		NameSection: &wasm.NameSection{FunctionNames: wasm.NameMap{
+			{Index: 1, Name: "sum"}, {Index: 2, Name: "sum_indirect"}, {Index: 3, Name: "double"},
+		}},
+	})
+	compiled, err := r.CompileModule(testCtx, bin, wazero.NewCompileConfig())
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	module, err := r.InstantiateModule(testCtx, compiled, wazero.NewModuleConfig())
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	// The depth is far above the call stack ceiling, which limits calls which aren't tail calls.
+	const n = 100_000
+	for _, name := range []string{"sum", "sum_indirect"} {
+		results, err := module.ExportedFunction(name).Call(testCtx, n, 0)
+		require.NoError(t, err, name)
+		require.Equal(t, uint64(n*(n+1)/2), results[0], name)
+	}
+
+	results, err := module.ExportedFunction("double").Call(testCtx, 21)
+	require.NoError(t, err)
+	require.Equal(t, uint64(42), results[0])
+
+	t.Run("listener", func(t *testing.T) {
+		factory := &recordingListenerFactory{t: t}
+		ctx := context.WithValue(testCtx, experimental.FunctionListenerFactoryKey{}, factory)
+		module, err := r.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().WithName("listener"))
+		require.NoError(t, err)
+		defer module.Close(testCtx)
+
+		results, err := module.ExportedFunction("sum").Call(ctx, 2, 0)
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), results[0])
+
+		// The listener of a function making a tail call is notified of its end, without results, before the called
+		// function begins as if called by the caller.
+		require.Equal(t, []string{
+			"before sum[2 0]",
+			"after sum[]",
+			"before sum[1 2]",
+			"after sum[]",
+			"before sum[0 3]",
+			"after sum[3]",
+		}, factory.events)
+		require.Equal(t, []string{"", "", ""}, factory.callers)
+
+		// Tail calls don't grow the call stack, even with listeners.
+		for _, name := range []string{"sum", "sum_indirect"} {
+			factory.events, factory.callers = nil, nil
+			results, err = module.ExportedFunction(name).Call(ctx, n, 0)
+			require.NoError(t, err, name)
+			require.Equal(t, uint64(n*(n+1)/2), results[0], name)
+			require.Equal(t, 2*(n+1), len(factory.events), name)
+		}
+	})
+}
+
+// testMultiMemory ensures instructions access the memory of their memory index, where the memory zero is imported
+// and the memory one is defined, and that the host reaches both by their export names.
+func testMultiMemory(t *testing.T, r wazero.Runtime) {
+	host, err := r.NewModuleBuilder("env").ExportMemory("scratch", 1).Instantiate(testCtx, r)
+	require.NoError(t, err)
+	defer host.Close(testCtx)
+
+	i32, i64, f32 := wasm.ValueTypeI32, wasm.ValueTypeI64, wasm.ValueTypeF32
+	const memArg1 = wasm.MemoryArgMemoryIndexFlag // followed by the memory index 1.
+	bin := binaryformat.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Params: []wasm.ValueType{i32, i32, i32}},
+			{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i64}},
+			{Params: []wasm.ValueType{i32, i64}},
+			{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}},
+			{Results: []wasm.ValueType{i32}},
+			{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{f32}},
+			{Params: []wasm.ValueType{i32, i32}},
+		},
+		ImportSection:   []*wasm.Import{{Module: "env", Name: "scratch", Type: wasm.ExternTypeMemory, DescMem: &wasm.Memory{Min: 1}}},
+		FunctionSection: []wasm.Index{0, 0, 1, 2, 3, 4, 5, 6},
+		MemorySection:   []*wasm.Memory{{Min: 1, Cap: 1, Max: 3, IsMaxEncoded: true}},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{ // (func $copy (param $dst i32) (param $src i32) (param $len i32), from the heap to scratch.
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1, wasm.OpcodeLocalGet, 2,
+				wasm.OpcodeMiscPrefix, wasm.OpcodeMiscMemoryCopy, 0, 1,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // (func $fill (param $offset i32) (param $value i32) (param $len i32)
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1, wasm.OpcodeLocalGet, 2,
+				wasm.OpcodeMiscPrefix, wasm.OpcodeMiscMemoryFill, 1,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // (func $load (param $offset i32) (result i64)
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeI64Load, 3 | memArg1, 1, 0,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // (func $store (param $offset i32) (param $v i64)
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1,
+				wasm.OpcodeI64Store, 3 | memArg1, 1, 0,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // (func $grow (param $pages i32) (result i32)
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeMemoryGrow, 1,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // (func $size (result i32)
+				wasm.OpcodeMemorySize, 1,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // (func $load_f32_plus_one (param $offset i32) (result f32)
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeF32Load, 2 | memArg1, 1, 0,
+				wasm.OpcodeF32Const, 0, 0, 0x80, 0x3f, // 1.0
+				wasm.OpcodeF32Add,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // (func $copy_v128 (param $dst i32) (param $src i32), from the heap to scratch.
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1,
+				wasm.OpcodeVecPrefix, wasm.OpcodeVecV128Load, 4 | memArg1, 1, 0,
+				wasm.OpcodeVecPrefix, wasm.OpcodeVecV128Store, 4, 0,
+				wasm.OpcodeEnd,
+			}},
+		},
+		DataSection: []*wasm.DataSegment{
+			{OffsetExpression: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}}, Init: []byte("hello"), MemoryIndex: 1},
+		},
+		ExportSection: []*wasm.Export{
+			{Name: "scratch", Type: wasm.ExternTypeMemory, Index: 0},
+			{Name: "heap", Type: wasm.ExternTypeMemory, Index: 1},
+			{Name: "copy", Type: wasm.ExternTypeFunc, Index: 0},
+			{Name: "fill", Type: wasm.ExternTypeFunc, Index: 1},
+			{Name: "load", Type: wasm.ExternTypeFunc, Index: 2},
+			{Name: "store", Type: wasm.ExternTypeFunc, Index: 3},
+			{Name: "grow", Type: wasm.ExternTypeFunc, Index: 4},
+			{Name: "size", Type: wasm.ExternTypeFunc, Index: 5},
+			{Name: "load_f32_plus_one", Type: wasm.ExternTypeFunc, Index: 6},
+			{Name: "copy_v128", Type: wasm.ExternTypeFunc, Index: 7},
+		},
+	})
+	module, err := r.InstantiateModuleFromBinary(testCtx, bin)
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	scratch, heap := module.ExportedMemory("scratch"), module.ExportedMemory("heap")
+	require.Equal(t, host.Memory(), scratch)
+	require.Equal(t, scratch, module.Memory()) // the memory zero
+	call := func(name string, params ...uint64) []uint64 {
+		results, err := module.ExportedFunction(name).Call(testCtx, params...)
+		require.NoError(t, err, name)
+		return results
+	}
+	read := func(mem api.Memory, offset, byteCount uint32) string {
+		b, ok := mem.Read(testCtx, offset, byteCount)
+		require.True(t, ok)
+		return string(b)
+	}
+
+	// The active data segment was copied into the heap.
+	require.Equal(t, "hello", read(heap, 0, 5))
+	require.Equal(t, "\x00\x00\x00\x00\x00", read(scratch, 0, 5))
+
+	call("copy", 8, 0, 5)
+	require.Equal(t, "hello", read(scratch, 8, 5))
+	require.Equal(t, "\x00\x00\x00\x00\x00", read(heap, 8, 5))
+
+	call("store", 16, 0x0102030405060708)
+	require.Equal(t, []uint64{0x0102030405060708}, call("load", 16))
+	v, ok := scratch.ReadUint64Le(testCtx, 16)
+	require.True(t, ok)
+	require.Zero(t, v)
+
+	require.True(t, heap.WriteFloat32Le(testCtx, 32, 1.5))
+	require.Equal(t, float32(2.5), api.DecodeF32(call("load_f32_plus_one", 32)[0]))
+
+	call("fill", 40, 0xff, 4)
+	require.Equal(t, "\xff\xff\xff\xff", read(heap, 40, 4))
+
+	call("copy_v128", 64, 0)
+	require.Equal(t, read(heap, 0, 16), read(scratch, 64, 16))
+
+	require.Equal(t, []uint64{1}, call("size"))
+	require.Equal(t, []uint64{1}, call("grow", 1))
+	require.Equal(t, []uint64{2}, call("size"))
+	require.Equal(t, uint32(2*65536), heap.Size(testCtx))
+	require.Equal(t, uint32(65536), scratch.Size(testCtx))
+
+	// Accesses are bounded by the size of their memory.
+	call("store", 2*65536-8, 1)
+	_, err = module.ExportedFunction("load").Call(testCtx, 2*65536)
+	require.ErrorIs(t, err, wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+	_, err = module.ExportedFunction("copy").Call(testCtx, 65536-4, 2*65536-8, 8)
+	require.ErrorIs(t, err, wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+}
+
+// testMemory64 ensures memory instructions of a memory with 64-bit indices take i64 addresses, and that addresses and
+// offsets over 32 bits are bounds checked rather than truncated.
+func testMemory64(t *testing.T, r wazero.Runtime) {
+	i32, i64 := wasm.ValueTypeI32, wasm.ValueTypeI64
+	bin := binaryformat.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Params: []wasm.ValueType{i64}, Results: []wasm.ValueType{i64}},
+			{Params: []wasm.ValueType{i64, i64}},
+			{Results: []wasm.ValueType{i64}},
+			{Params: []wasm.ValueType{i64, i32, i64}},
+			{Params: []wasm.ValueType{i64, i64, i64}},
+		},
+		FunctionSection: []wasm.Index{0, 0, 1, 2, 0, 3, 4, 1},
+		MemorySection:   []*wasm.Memory{{Min: 1, Cap: 1, Max: 3, IsMaxEncoded: true, Is64: true}},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{ // (func $load (param $addr i64) (result i64)
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeI64Load, 3, 0,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // (func $load_offset (param $addr i64) (result i64), with an offset of 1<<32.
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeI64Load, 3, 0x80, 0x80, 0x80, 0x80, 0x10,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // (func $store (param $addr i64) (param $v i64)
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1,
+				wasm.OpcodeI64Store, 3, 0,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // (func $size (result i64)
+				wasm.OpcodeMemorySize, 0,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // (func $grow (param $pages i64) (result i64)
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeMemoryGrow, 0,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // (func $fill (param $addr i64) (param $value i32) (param $len i64)
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1, wasm.OpcodeLocalGet, 2,
+				wasm.OpcodeMiscPrefix, wasm.OpcodeMiscMemoryFill, 0,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // (func $copy (param $dst i64) (param $src i64) (param $len i64)
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1, wasm.OpcodeLocalGet, 2,
+				wasm.OpcodeMiscPrefix, wasm.OpcodeMiscMemoryCopy, 0, 0,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // (func $copy_v128 (param $dst i64) (param $src i64)
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1,
+				wasm.OpcodeVecPrefix, wasm.OpcodeVecV128Load, 4, 0,
+				wasm.OpcodeVecPrefix, wasm.OpcodeVecV128Store, 4, 0,
+				wasm.OpcodeEnd,
+			}},
+		},
+		DataSection: []*wasm.DataSegment{
+			{OffsetExpression: &wasm.ConstantExpression{Opcode: wasm.OpcodeI64Const, Data: []byte{8}}, Init: []byte("hello")},
+		},
+		ExportSection: []*wasm.Export{
+			{Name: "load", Type: wasm.ExternTypeFunc, Index: 0},
+			{Name: "load_offset", Type: wasm.ExternTypeFunc, Index: 1},
+			{Name: "store", Type: wasm.ExternTypeFunc, Index: 2},
+			{Name: "size", Type: wasm.ExternTypeFunc, Index: 3},
+			{Name: "grow", Type: wasm.ExternTypeFunc, Index: 4},
+			{Name: "fill", Type: wasm.ExternTypeFunc, Index: 5},
+			{Name: "copy", Type: wasm.ExternTypeFunc, Index: 6},
+			{Name: "copy_v128", Type: wasm.ExternTypeFunc, Index: 7},
+		},
+	})
+	module, err := r.InstantiateModuleFromBinary(testCtx, bin)
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	mem, ok := module.Memory().(api.Memory64)
+	require.True(t, ok)
+	call := func(name string, params ...uint64) []uint64 {
+		results, err := module.ExportedFunction(name).Call(testCtx, params...)
+		require.NoError(t, err, name)
+		return results
+	}
+	read := func(offset, byteCount uint64) string {
+		b, ok := mem.Read64(testCtx, offset, byteCount)
+		require.True(t, ok)
+		return string(b)
+	}
+
+	// The active data segment with an i64 offset was copied into memory.
+	require.Equal(t, "hello", read(8, 5))
+
+	call("store", 16, 0x0102030405060708)
+	require.Equal(t, []uint64{0x0102030405060708}, call("load", 16))
+
+	call("fill", 32, 0xff, 4)
+	require.Equal(t, "\xff\xff\xff\xff", read(32, 4))
+
+	call("copy", 40, 8, 5)
+	require.Equal(t, "hello", read(40, 5))
+
+	call("copy_v128", 64, 8)
+	require.Equal(t, read(8, 16), read(64, 16))
+
+	require.True(t, mem.Write64(testCtx, 48, []byte("world")))
+	require.Equal(t, "world", read(48, 5))
+
+	require.Equal(t, []uint64{1}, call("size"))
+	require.Equal(t, []uint64{1}, call("grow", 1))
+	require.Equal(t, []uint64{2}, call("size"))
+	require.Equal(t, uint64(2*65536), mem.Size64(testCtx))
+	// Growing past the maximum, or by more pages than fit in 32 bits, fails with -1 as an i64.
+	require.Equal(t, []uint64{math.MaxUint64}, call("grow", 2))
+	require.Equal(t, []uint64{math.MaxUint64}, call("grow", 1<<32+1))
+
+	// Addresses and offsets aren't truncated to 32 bits, so these are out of bounds instead of wrapping to zero.
+	for _, tc := range []struct {
+		name   string
+		params []uint64
+	}{
+		{name: "load", params: []uint64{1 << 32}},
+		{name: "load", params: []uint64{math.MaxUint64 - 4}},
+		{name: "load_offset", params: []uint64{0}},
+		{name: "load_offset", params: []uint64{math.MaxUint64 - 1<<32 + 1}},
+		{name: "store", params: []uint64{1<<32 + 16, 1}},
+		{name: "fill", params: []uint64{1 << 32, 0, 1}},
+		{name: "fill", params: []uint64{0, 0, 1<<32 + 1}},
+		{name: "fill", params: []uint64{8, 0, math.MaxUint64}},
+		{name: "copy", params: []uint64{0, 1 << 32, 1}},
+		{name: "copy", params: []uint64{0, math.MaxUint64, 2}},
+		{name: "copy", params: []uint64{math.MaxUint64, 0, 2}},
+		{name: "copy_v128", params: []uint64{1 << 32, 0}},
+	} {
+		_, err = module.ExportedFunction(tc.name).Call(testCtx, tc.params...)
+		require.ErrorIs(t, err, wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess, tc.name)
+	}
+}
+
+// testExceptionHandling ensures exceptions thrown by Wasm and host functions are caught by the innermost matching
+// catch clause in the call stack, and that uncaught ones are returned by api.Function Call.
+func testExceptionHandling(t *testing.T, r wazero.Runtime) {
+	var module api.Module // The host function throws exceptions of the tag exported by the module.
+	host, err := r.NewModuleBuilder("env").ExportFunction("throw", func(x uint32) {
+		panic(sys.NewException(module.ExportedTag("error"), uint64(x)))
+	}).Instantiate(testCtx, r)
+	require.NoError(t, err)
+	defer host.Close(testCtx)
+
+	i32 := wasm.ValueTypeI32
+	blockTypeI32 := byte(0x7f)
+	// catchError returns the value of the exception caught plus the given addend.
+	catchError := func(addend byte) []byte {
+		return []byte{wasm.OpcodeCatch, 0, wasm.OpcodeI32Const, addend, wasm.OpcodeI32Add}
+	}
+	callThrow := []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeCall, 1, wasm.OpcodeI32Const, 0x7f}
+	body := func(b ...[]byte) (ret []byte) {
+		for _, code := range b {
+			ret = append(ret, code...)
+		}
+		return append(ret, wasm.OpcodeEnd)
+	}
+	bin := binaryformat.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Params: []wasm.ValueType{i32}},
+			{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}},
+			{},
+		},
+		ImportSection:   []*wasm.Import{{Module: "env", Name: "throw", Type: wasm.ExternTypeFunc, DescFunc: 0}},
+		FunctionSection: []wasm.Index{0, 1, 1, 1, 1, 1, 1, 0},
+		TagSection:      []wasm.Index{0, 2},
+		CodeSection: []*wasm.Code{
+			{Body: body([]byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeThrow, 0})}, // $throw
+			{Body: body( // $catch
+				[]byte{wasm.OpcodeTry, blockTypeI32}, callThrow, catchError(10), []byte{wasm.OpcodeEnd},
+			)},
+			{Body: body( // $catch_all, after a catch clause of another tag.
+				[]byte{wasm.OpcodeTry, blockTypeI32}, callThrow,
+				[]byte{wasm.OpcodeCatch, 1, wasm.OpcodeI32Const, 1},
+				[]byte{wasm.OpcodeCatchAll, wasm.OpcodeI32Const, 2},
+				[]byte{wasm.OpcodeEnd},
+			)},
+			{Body: body( // $rethrow from a catch_all clause to the enclosing try block.
+				[]byte{wasm.OpcodeTry, blockTypeI32, wasm.OpcodeTry, blockTypeI32}, callThrow,
+				[]byte{wasm.OpcodeCatchAll, wasm.OpcodeRethrow, 0, wasm.OpcodeEnd},
+				catchError(20), []byte{wasm.OpcodeEnd},
+			)},
+			{Body: body( // $delegate to the enclosing try block.
+				[]byte{wasm.OpcodeTry, blockTypeI32, wasm.OpcodeTry, blockTypeI32}, callThrow,
+				[]byte{wasm.OpcodeDelegate, 0},
+				catchError(30), []byte{wasm.OpcodeEnd},
+			)},
+			{Body: body( // $host catches the exception thrown by the host function.
+				[]byte{wasm.OpcodeTry, blockTypeI32, wasm.OpcodeLocalGet, 0, wasm.OpcodeCall, 0, wasm.OpcodeI32Const, 0x7f},
+				catchError(40), []byte{wasm.OpcodeEnd},
+			)},
+			{LocalTypes: []wasm.ValueType{i32}, Body: body( // $local sets a local before throwing in the same function.
+				[]byte{wasm.OpcodeTry, blockTypeI32, wasm.OpcodeI32Const, 50, wasm.OpcodeLocalSet, 1},
+				[]byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeThrow, 0},
+				[]byte{wasm.OpcodeCatch, 0, wasm.OpcodeLocalGet, 1, wasm.OpcodeI32Add, wasm.OpcodeEnd},
+			)},
+			{Body: body(callThrow[:4])}, // $uncaught
+		},
+		ExportSection: []*wasm.Export{
+			{Name: "error", Type: wasm.ExternTypeTag, Index: 0},
+			{Name: "catch", Type: wasm.ExternTypeFunc, Index: 2},
+			{Name: "catch_all", Type: wasm.ExternTypeFunc, Index: 3},
+			{Name: "rethrow", Type: wasm.ExternTypeFunc, Index: 4},
+			{Name: "delegate", Type: wasm.ExternTypeFunc, Index: 5},
+			{Name: "host", Type: wasm.ExternTypeFunc, Index: 6},
+			{Name: "local", Type: wasm.ExternTypeFunc, Index: 7},
+			{Name: "uncaught", Type: wasm.ExternTypeFunc, Index: 8},
+		},
+		NameSection: &wasm.NameSection{FunctionNames: wasm.NameMap{{Index: 1, Name: "throw"}, {Index: 2, Name: "catch"}}},
+	})
+	compiled, err := r.CompileModule(testCtx, bin, wazero.NewCompileConfig())
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	module, err = r.InstantiateModule(testCtx, compiled, wazero.NewModuleConfig())
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	for _, tc := range []struct {
+		name     string
+		expected uint64
+	}{
+		{name: "catch", expected: 10 + 5},
+		{name: "catch_all", expected: 2},
+		{name: "rethrow", expected: 20 + 5},
+		{name: "delegate", expected: 30 + 5},
+		{name: "host", expected: 40 + 5},
+		{name: "local", expected: 50 + 5},
+	} {
+		results, err := module.ExportedFunction(tc.name).Call(testCtx, 5)
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.expected, results[0], tc.name)
+	}
+
+	_, err = module.ExportedFunction("uncaught").Call(testCtx, 5)
+	var exc *sys.Exception
+	require.True(t, errors.As(err, &exc))
+	require.Equal(t, module.ExportedTag("error"), exc.Tag())
+	require.Equal(t, []uint64{5}, exc.Values())
+	require.Contains(t, err.Error(), "wasm exception with tag .error")
+
+	t.Run("listener", func(t *testing.T) {
+		factory := &recordingListenerFactory{t: t}
+		ctx := context.WithValue(testCtx, experimental.FunctionListenerFactoryKey{}, factory)
+		module, err := r.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().WithName("listener"))
+		require.NoError(t, err)
+		defer module.Close(testCtx)
+
+		results, err := module.ExportedFunction("catch").Call(ctx, 5)
+		require.NoError(t, err)
+		require.Equal(t, uint64(15), results[0])
+
+		// The listener of the function which threw is notified, as it didn't return.
+		require.Equal(t, []string{
+			"before catch[5]",
+			"before throw[5]",
+			"after throw[] with error",
+			"after catch[15]",
+		}, factory.events)
+	})
+}
+
+// testExtendedConst ensures arithmetic in constant expressions is evaluated on instantiation, like a data segment offset
+// relative to an imported base address, as emitted by LLVM for position-independent code.
+func testExtendedConst(t *testing.T, r wazero.Runtime) {
+	host, err := r.NewModuleBuilder("env").ExportGlobalI32("memory_base", 1024).Instantiate(testCtx, r)
+	require.NoError(t, err)
+	defer host.Close(testCtx)
+
+	bin := binaryformat.EncodeModule(&wasm.Module{
+		ImportSection: []*wasm.Import{
+			{Module: "env", Name: "memory_base", Type: wasm.ExternTypeGlobal, DescGlobal: &wasm.GlobalType{ValType: wasm.ValueTypeI32}},
+		},
+		MemorySection: []*wasm.Memory{{Min: 1, Cap: 1, Max: 1}},
+		GlobalSection: []*wasm.Global{
+			{ // (global $ptr i32 (i32.add (global.get $memory_base) (i32.const 16)))
+				Type: &wasm.GlobalType{ValType: wasm.ValueTypeI32},
+				Init: &wasm.ConstantExpression{
+					Opcode: wasm.OpcodeI32Add,
+					Data:   []byte{wasm.OpcodeGlobalGet, 0, wasm.OpcodeI32Const, 16},
+				},
+			},
+			{ // (global $answer i64 (i64.sub (i64.mul (i64.const 6) (i64.const 7)) (i64.const 2)))
+				Type: &wasm.GlobalType{ValType: wasm.ValueTypeI64},
+				Init: &wasm.ConstantExpression{
+					Opcode: wasm.OpcodeI64Sub,
+					Data: []byte{
+						wasm.OpcodeI64Const, 6, wasm.OpcodeI64Const, 7, wasm.OpcodeI64Mul,
+						wasm.OpcodeI64Const, 2,
+					},
+				},
+			},
+		},
+		DataSection: []*wasm.DataSegment{
+			{ // (data (i32.add (global.get $memory_base) (i32.const 16)) "hello")
+				OffsetExpression: &wasm.ConstantExpression{
+					Opcode: wasm.OpcodeI32Add,
+					Data:   []byte{wasm.OpcodeGlobalGet, 0, wasm.OpcodeI32Const, 16},
+				},
+				Init: []byte("hello"),
+			},
+		},
+		ExportSection: []*wasm.Export{
+			{Name: "ptr", Type: wasm.ExternTypeGlobal, Index: 1},
+			{Name: "answer", Type: wasm.ExternTypeGlobal, Index: 2},
+		},
+	})
+	module, err := r.InstantiateModuleFromBinary(testCtx, bin)
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	require.Equal(t, uint64(1040), module.ExportedGlobal("ptr").Get(testCtx))
+	require.Equal(t, uint64(40), module.ExportedGlobal("answer").Get(testCtx))
+
+	hello, ok := module.Memory().Read(testCtx, 1040, 5)
+	require.True(t, ok)
+	require.Equal(t, "hello", string(hello))
+}
+
+// testRelaxedSIMD ensures relaxed vector instructions result in what is documented on their wazeroir operations, so
+// that the results are the same regardless of the engine.
+func testRelaxedSIMD(t *testing.T, r wazero.Runtime) {
+	i16x8 := func(lanes ...uint16) (ret [16]byte) {
+		for i, l := range lanes {
+			binary.LittleEndian.PutUint16(ret[i*2:], l)
+		}
+		return
+	}
+	i32x4 := func(lanes ...uint32) (ret [16]byte) {
+		for i, l := range lanes {
+			binary.LittleEndian.PutUint32(ret[i*4:], l)
+		}
+		return
+	}
+	f32x4 := func(lanes ...float32) (ret [16]byte) {
+		for i, l := range lanes {
+			binary.LittleEndian.PutUint32(ret[i*4:], math.Float32bits(l))
+		}
+		return
+	}
+	f64x2 := func(lanes ...float64) (ret [16]byte) {
+		for i, l := range lanes {
+			binary.LittleEndian.PutUint64(ret[i*8:], math.Float64bits(l))
+		}
+		return
+	}
+	nan32, nan64 := float32(math.NaN()), math.NaN()
+	negZero32, negZero64 := float32(math.Copysign(0, -1)), math.Copysign(0, -1)
+	dotA := [16]byte{1, 2, 0xff, 3, 0x7f, 0x7f, 0x80, 0x80}
+	dotB := [16]byte{3, 4, 5, 6, 0xff, 0xff, 0xff, 0xff}
+
+	tests := []struct {
+		opcode   wasm.OpcodeVecRelaxed
+		operands [][16]byte
+		exp      [16]byte
+	}{
+		{
+			opcode: wasm.OpcodeVecI8x16RelaxedSwizzle,
+			operands: [][16]byte{
+				{10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25},
+				{0, 15, 16, 0x80, 0xff, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11},
+			},
+			// Out of range indices result in zero.
+			exp: [16]byte{10, 25, 0, 0, 0, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21},
+		},
+		{
+			opcode:   wasm.OpcodeVecI32x4RelaxedTruncF32x4S,
+			operands: [][16]byte{f32x4(1.9, -1.9, nan32, 3e9)},
+			exp:      i32x4(1, 0xffffffff, 0x80000000, 0x80000000),
+		},
+		{
+			opcode:   wasm.OpcodeVecI32x4RelaxedTruncF32x4U,
+			operands: [][16]byte{f32x4(3e9, -1.5, nan32, 5e9)},
+			exp:      i32x4(3e9, 0, 0, 0xffffffff),
+		},
+		{
+			opcode:   wasm.OpcodeVecI32x4RelaxedTruncF64x2SZero,
+			operands: [][16]byte{f64x2(-2.5, 3e9)},
+			exp:      i32x4(0xfffffffe, 0x80000000, 0, 0),
+		},
+		{
+			opcode:   wasm.OpcodeVecI32x4RelaxedTruncF64x2UZero,
+			operands: [][16]byte{f64x2(1e20, nan64)},
+			exp:      i32x4(0xffffffff, 0, 0, 0),
+		},
+		{
+			opcode:   wasm.OpcodeVecF32x4RelaxedMadd,
+			operands: [][16]byte{f32x4(1, 2, 3, 1+1.0/(1<<12)), f32x4(2, 2, 2, 1+1.0/(1<<12)), f32x4(0.5, 0.5, 0.5, -(1 + 1.0/(1<<11)))},
+			// The product isn't fused with the addition, otherwise the last lane is 2^-24.
+			exp: f32x4(2.5, 4.5, 6.5, 0),
+		},
+		{
+			opcode:   wasm.OpcodeVecF32x4RelaxedNmadd,
+			operands: [][16]byte{f32x4(1, 2, 3, 4), f32x4(2, 2, 2, 2), f32x4(0.5, 0.5, 0.5, 0.5)},
+			exp:      f32x4(-1.5, -3.5, -5.5, -7.5),
+		},
+		{
+			opcode:   wasm.OpcodeVecF64x2RelaxedMadd,
+			operands: [][16]byte{f64x2(1.5, -2), f64x2(2, 3), f64x2(1, 1)},
+			exp:      f64x2(4, -5),
+		},
+		{
+			opcode:   wasm.OpcodeVecF64x2RelaxedNmadd,
+			operands: [][16]byte{f64x2(1.5, -2), f64x2(2, 3), f64x2(1, 1)},
+			exp:      f64x2(-2, 7),
+		},
+		{
+			opcode:   wasm.OpcodeVecI8x16RelaxedLaneselect,
+			operands: [][16]byte{{0x11, 0x11, 0x11}, {0x22, 0x22, 0x22}, {0xff, 0, 0xf0}},
+			exp:      [16]byte{0x11, 0x22, 0x12},
+		},
+		{
+			opcode:   wasm.OpcodeVecI32x4RelaxedLaneselect,
+			operands: [][16]byte{i32x4(0x11111111, 0x11111111, 0x11111111), i32x4(0x22222222, 0x22222222, 0x22222222), i32x4(0xffffffff, 0x0000ffff, 0xf0f0f0f0)},
+			exp:      i32x4(0x11111111, 0x22221111, 0x12121212),
+		},
+		{
+			opcode:   wasm.OpcodeVecF32x4RelaxedMin,
+			operands: [][16]byte{f32x4(1, nan32, 0, 1), f32x4(2, 1, negZero32, nan32)},
+			exp:      f32x4(1, 1, negZero32, nan32),
+		},
+		{
+			opcode:   wasm.OpcodeVecF32x4RelaxedMax,
+			operands: [][16]byte{f32x4(1, nan32, negZero32, 1), f32x4(2, 1, 0, nan32)},
+			exp:      f32x4(2, 1, 0, nan32),
+		},
+		{
+			opcode:   wasm.OpcodeVecF64x2RelaxedMin,
+			operands: [][16]byte{f64x2(nan64, 0), f64x2(5, negZero64)},
+			exp:      f64x2(5, negZero64),
+		},
+		{
+			opcode:   wasm.OpcodeVecF64x2RelaxedMax,
+			operands: [][16]byte{f64x2(-1, 3), f64x2(-2, nan64)},
+			exp:      f64x2(-1, nan64),
+		},
+		{
+			opcode:   wasm.OpcodeVecI16x8RelaxedQ15mulrS,
+			operands: [][16]byte{i16x8(0x8000, 0x4000, 0x7fff, 0xffff, 100), i16x8(0x8000, 0x4000, 0x7fff, 1, 200)},
+			// -32768 * -32768 wraps to -32768.
+			exp: i16x8(0x8000, 0x2000, 0x7ffe, 0, 1),
+		},
+		{
+			opcode:   wasm.OpcodeVecI16x8RelaxedDotI8x16I7x16S,
+			operands: [][16]byte{dotA, dotB},
+			// The second operand is unsigned, and the sums saturate.
+			exp: i16x8(11, 13, 0x7fff, 0x8000),
+		},
+		{
+			opcode:   wasm.OpcodeVecI32x4RelaxedDotI8x16I7x16AddS,
+			operands: [][16]byte{dotA, dotB, i32x4(1, 2, 3, 4)},
+			exp:      i32x4(25, 1, 3, 4),
+		},
+	}
+
+	// Each function loads its operands from the memory after the first 16 bytes, where it stores the result.
+	m := &wasm.Module{
+		TypeSection:   []*wasm.FunctionType{{}},
+		MemorySection: []*wasm.Memory{{Min: 1, Cap: 1, Max: 1}},
+	}
+	for i, tc := range tests {
+		body := []byte{wasm.OpcodeI32Const, 0}
+		for j := range tc.operands {
+			body = append(body, wasm.OpcodeI32Const, 0, wasm.OpcodeVecPrefix, wasm.OpcodeVecV128Load, 4, byte(16*(j+1)))
+		}
+		body = append(body,
+			wasm.OpcodeVecPrefix, 0x80|byte(tc.opcode&0x7f), byte(tc.opcode>>7),
+			wasm.OpcodeVecPrefix, wasm.OpcodeVecV128Store, 4, 0,
+			wasm.OpcodeEnd)
+		m.FunctionSection = append(m.FunctionSection, 0)
+		m.CodeSection = append(m.CodeSection, &wasm.Code{Body: body})
+		m.ExportSection = append(m.ExportSection, &wasm.Export{
+			Name: wasm.RelaxedVectorInstructionName(tc.opcode), Type: wasm.ExternTypeFunc, Index: wasm.Index(i),
+		})
+	}
+	module, err := r.InstantiateModuleFromBinary(testCtx, binaryformat.EncodeModule(m))
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	for _, tt := range tests {
+		tc := tt
+		name := wasm.RelaxedVectorInstructionName(tc.opcode)
+		t.Run(name, func(t *testing.T) {
+			for j, operand := range tc.operands {
+				require.True(t, module.Memory().Write(testCtx, uint32(16*(j+1)), operand[:]))
+			}
+			_, err := module.ExportedFunction(name).Call(testCtx)
+			require.NoError(t, err)
+
+			actual, ok := module.Memory().Read(testCtx, 0, 16)
+			require.True(t, ok)
+			require.Equal(t, tc.exp[:], actual)
+		})
+	}
+}
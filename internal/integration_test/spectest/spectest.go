@@ -345,13 +345,13 @@ func addSpectestModule(t *testing.T, s *wasm.Store, ns *wasm.Namespace) {
 	err = s.Engine.CompileModule(testCtx, mod)
 	require.NoError(t, err)
 
-	_, err = s.Instantiate(testCtx, ns, mod, mod.NameSection.ModuleName, sys.DefaultContext(), nil)
+	_, err = s.Instantiate(testCtx, ns, mod, mod.NameSection.ModuleName, sys.DefaultContext(), nil, nil)
 	require.NoError(t, err)
 }
 
 // maybeSetMemoryCap assigns wasm.Memory Cap to Min, which is what wazero.CompileModule would do.
 func maybeSetMemoryCap(mod *wasm.Module) {
-	if mem := mod.MemorySection; mem != nil {
+	for _, mem := range mod.MemorySection {
 		mem.Cap = mem.Min
 	}
 }
@@ -361,7 +361,7 @@ func maybeSetMemoryCap(mod *wasm.Module) {
 //
 // filter is a callback which is called with the target json file name and should return true if the engine wants to run tests against it, false otherwise.
 // TODO: remove filter after SIMD completion.
-func Run(t *testing.T, testDataFS embed.FS, newEngine func(wasm.Features) wasm.Engine, enabledFeatures wasm.Features, filter func(jsonname string) bool) {
+func Run(t *testing.T, testDataFS embed.FS, newEngine func(enabledFeatures wasm.Features, config wasm.EngineConfig) wasm.Engine, enabledFeatures wasm.Features, filter func(jsonname string) bool) {
 	files, err := testDataFS.ReadDir("testdata")
 	require.NoError(t, err)
 
@@ -390,7 +390,7 @@ func Run(t *testing.T, testDataFS embed.FS, newEngine func(wasm.Features) wasm.E
 		wastName := basename(base.SourceFile)
 
 		t.Run(wastName, func(t *testing.T) {
-			s, ns := wasm.NewStore(enabledFeatures, newEngine(enabledFeatures))
+			s, ns := wasm.NewStore(enabledFeatures, newEngine(enabledFeatures, wasm.EngineConfig{}))
 			addSpectestModule(t, s, ns)
 
 			var lastInstantiatedModuleName string
@@ -416,7 +416,7 @@ func Run(t *testing.T, testDataFS embed.FS, newEngine func(wasm.Features) wasm.E
 						err = s.Engine.CompileModule(testCtx, mod)
 						require.NoError(t, err, msg)
 
-						_, err = s.Instantiate(testCtx, ns, mod, moduleName, nil, nil)
+						_, err = s.Instantiate(testCtx, ns, mod, moduleName, nil, nil, nil)
 						lastInstantiatedModuleName = moduleName
 						require.NoError(t, err)
 					case "register":
@@ -546,7 +546,7 @@ func Run(t *testing.T, testDataFS embed.FS, newEngine func(wasm.Features) wasm.E
 							err = s.Engine.CompileModule(testCtx, mod)
 							require.NoError(t, err, msg)
 
-							_, err = s.Instantiate(testCtx, ns, mod, t.Name(), nil, nil)
+							_, err = s.Instantiate(testCtx, ns, mod, t.Name(), nil, nil, nil)
 							require.NoError(t, err, msg)
 						} else {
 							requireInstantiationError(t, s, ns, buf, msg)
@@ -580,7 +580,7 @@ func requireInstantiationError(t *testing.T, s *wasm.Store, ns *wasm.Namespace,
 		return
 	}
 
-	_, err = s.Instantiate(testCtx, ns, mod, t.Name(), nil, nil)
+	_, err = s.Instantiate(testCtx, ns, mod, t.Name(), nil, nil, nil)
 	require.Error(t, err, msg)
 }
 
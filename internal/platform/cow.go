@@ -0,0 +1,26 @@
+package platform
+
+import "os"
+
+// CopyOnWrite is content, such as that of a memory, which can be mapped privately many times. Mappings share the pages
+// of the content until they write them, so that a mapping can be reset to the content without copying it.
+//
+// Note: This is only supported on Linux. Elsewhere, NewCopyOnWrite returns an error, and callers copy the content
+// instead.
+type CopyOnWrite struct {
+	// f is an unlinked temporary file holding the content, followed by zeros up to size.
+	f    *os.File
+	size int
+}
+
+// Size returns the length of the mappings returned by Map.
+func (c *CopyOnWrite) Size() int {
+	return c.size
+}
+
+// Close releases the content. Existing mappings remain valid until MunmapCopyOnWrite.
+//
+// Note: Like an os.File, the content is also released when the CopyOnWrite is garbage collected.
+func (c *CopyOnWrite) Close() error {
+	return c.f.Close()
+}
@@ -0,0 +1,48 @@
+package platform
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// NewCopyOnWrite returns a CopyOnWrite of the content followed by zeros up to the size, which must not be smaller.
+func NewCopyOnWrite(content []byte, size int) (*CopyOnWrite, error) {
+	f, err := os.CreateTemp("", "wazero-cow-")
+	if err != nil {
+		return nil, err
+	}
+	// Unlink the file, so that it is removed when closed, even if this process exits abnormally.
+	if err = os.Remove(f.Name()); err == nil {
+		if _, err = f.Write(content); err == nil {
+			err = f.Truncate(int64(size)) // Sparse, so the zeros don't take space.
+		}
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &CopyOnWrite{f: f, size: size}, nil
+}
+
+// Map returns a new private mapping of the content, which must be released with MunmapCopyOnWrite.
+func (c *CopyOnWrite) Map() ([]byte, error) {
+	return syscall.Mmap(int(c.f.Fd()), 0, c.size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE)
+}
+
+// Remap discards the writes to the mapping, which must be returned by Map on the same CopyOnWrite, so that it is
+// equal to the content again. The address of the mapping doesn't change.
+func (c *CopyOnWrite) Remap(mapping []byte) error {
+	// MAP_FIXED atomically replaces the pages of the mapping, which syscall.Mmap doesn't allow to choose.
+	_, _, errno := syscall.Syscall6(syscall.SYS_MMAP, uintptr(unsafe.Pointer(&mapping[0])), uintptr(len(mapping)),
+		syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE|syscall.MAP_FIXED, c.f.Fd(), 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// MunmapCopyOnWrite releases a mapping returned by CopyOnWrite.Map.
+func MunmapCopyOnWrite(mapping []byte) error {
+	return syscall.Munmap(mapping)
+}
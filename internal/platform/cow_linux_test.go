@@ -0,0 +1,39 @@
+package platform
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestCopyOnWrite(t *testing.T) {
+	content := []byte("wazero")
+	cow, err := NewCopyOnWrite(content, 8192)
+	require.NoError(t, err)
+	defer cow.Close()
+	require.Equal(t, 8192, cow.Size())
+
+	m1, err := cow.Map()
+	require.NoError(t, err)
+	defer MunmapCopyOnWrite(m1) //nolint
+	m2, err := cow.Map()
+	require.NoError(t, err)
+	defer MunmapCopyOnWrite(m2) //nolint
+
+	// The content is followed by zeros up to the size.
+	require.Equal(t, 8192, len(m1))
+	require.Equal(t, content, m1[:len(content)])
+	require.Equal(t, make([]byte, 8192-len(content)), m1[len(content):])
+
+	// Writes are private to the mapping.
+	m1[0], m1[5000] = 'W', 1
+	require.Equal(t, content, m2[:len(content)])
+	require.Equal(t, byte(0), m2[5000])
+
+	// Remap discards the writes, without moving the mapping.
+	address := &m1[0]
+	require.NoError(t, cow.Remap(m1))
+	require.Same(t, address, &m1[0])
+	require.Equal(t, content, m1[:len(content)])
+	require.Equal(t, byte(0), m1[5000])
+}
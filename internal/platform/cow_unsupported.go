@@ -0,0 +1,27 @@
+//go:build !linux
+
+package platform
+
+import (
+	"fmt"
+	"runtime"
+)
+
+var errCopyOnWriteUnsupported = fmt.Errorf("copy-on-write mappings unsupported on GOOS=%s", runtime.GOOS)
+
+// NewCopyOnWrite returns an error, as copy-on-write mappings are only supported on Linux.
+func NewCopyOnWrite([]byte, int) (*CopyOnWrite, error) {
+	return nil, errCopyOnWriteUnsupported
+}
+
+func (c *CopyOnWrite) Map() ([]byte, error) {
+	return nil, errCopyOnWriteUnsupported
+}
+
+func (c *CopyOnWrite) Remap([]byte) error {
+	return errCopyOnWriteUnsupported
+}
+
+func MunmapCopyOnWrite([]byte) error {
+	return errCopyOnWriteUnsupported
+}
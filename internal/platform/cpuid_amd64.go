@@ -0,0 +1,23 @@
+package platform
+
+import "fmt"
+
+// CpuFeatures returns the feature flags of the CPU, which native code compiled on another machine may not run without.
+// These are the bit sets CPUID reports in ECX and EDX of leaf 1, EBX and ECX of leaf 7, and ECX of leaf 0x80000001.
+func CpuFeatures() string {
+	var ecx1, edx1, ebx7, ecx7, ecxExt uint32
+	maxLeaf, _, _, _ := cpuid(0, 0)
+	if maxLeaf >= 1 {
+		_, _, ecx1, edx1 = cpuid(1, 0)
+	}
+	if maxLeaf >= 7 {
+		_, ebx7, ecx7, _ = cpuid(7, 0)
+	}
+	if maxExtLeaf, _, _, _ := cpuid(0x80000000, 0); maxExtLeaf >= 0x80000001 {
+		_, _, ecxExt, _ = cpuid(0x80000001, 0)
+	}
+	return fmt.Sprintf("%08x.%08x.%08x.%08x.%08x", ecx1, edx1, ebx7, ecx7, ecxExt)
+}
+
+// cpuid executes the CPUID instruction with the leaf in EAX and the subleaf in ECX. See cpuid_amd64.s
+func cpuid(leaf, subleaf uint32) (eax, ebx, ecx, edx uint32)
@@ -0,0 +1,17 @@
+package platform
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestCpuFeatures(t *testing.T) {
+	features := CpuFeatures()
+	require.Equal(t, features, CpuFeatures())
+
+	// SSE2 is in the baseline of amd64, as bit 26 of EDX of leaf 1.
+	_, _, _, edx := cpuid(1, 0)
+	require.NotEqual(t, uint32(0), edx&(1<<26))
+	require.Equal(t, 5*8+4, len(features))
+}
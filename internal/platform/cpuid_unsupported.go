@@ -0,0 +1,8 @@
+//go:build !amd64
+
+package platform
+
+// CpuFeatures returns "", as the features of the CPU aren't detected on this architecture.
+func CpuFeatures() string {
+	return ""
+}
@@ -0,0 +1,16 @@
+//go:build darwin || linux || freebsd
+
+package platform
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// Inode returns the inode number of the file, or zero if fs.FileInfo Sys isn't a syscall.Stat_t.
+func Inode(info fs.FileInfo) uint64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Ino)
+	}
+	return 0
+}
@@ -0,0 +1,30 @@
+package platform
+
+import (
+	"os"
+	"runtime"
+	"testing"
+	"testing/fstest"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestInode(t *testing.T) {
+	t.Run("os", func(t *testing.T) {
+		info, err := os.Stat(t.TempDir())
+		require.NoError(t, err)
+
+		switch runtime.GOOS {
+		case "darwin", "linux", "freebsd":
+			require.NotEqual(t, uint64(0), Inode(info))
+		default:
+			require.Equal(t, uint64(0), Inode(info))
+		}
+	})
+
+	t.Run("fstest.MapFS", func(t *testing.T) {
+		info, err := fstest.MapFS{"a": {}}.Stat("a")
+		require.NoError(t, err)
+		require.Equal(t, uint64(0), Inode(info))
+	})
+}
@@ -0,0 +1,10 @@
+//go:build !(darwin || linux || freebsd)
+
+package platform
+
+import "io/fs"
+
+// Inode returns zero as inode numbers aren't available on this platform.
+func Inode(fs.FileInfo) uint64 {
+	return 0
+}
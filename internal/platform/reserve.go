@@ -0,0 +1,27 @@
+// This uses syscall.Mprotect. Go's SDK only supports this on darwin and linux.
+//go:build darwin || linux
+
+package platform
+
+import "syscall"
+
+// ReserveMemory returns a mapping of size bytes, whose pages are inaccessible and don't use physical memory until
+// committed with CommitMemory. This allows memory to grow within the mapping without moving. The mapping must be
+// released with ReleaseMemory.
+func ReserveMemory(size int) ([]byte, error) {
+	return syscall.Mmap(-1, 0, size, syscall.PROT_NONE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+}
+
+// CommitMemory makes the pages of the region, which must be in a mapping returned by ReserveMemory and begin at a page
+// boundary, readable and writable. Pages committed before are unchanged.
+func CommitMemory(region []byte) error {
+	if len(region) == 0 {
+		return nil
+	}
+	return syscall.Mprotect(region, syscall.PROT_READ|syscall.PROT_WRITE)
+}
+
+// ReleaseMemory releases a mapping returned by ReserveMemory.
+func ReleaseMemory(mapping []byte) error {
+	return syscall.Munmap(mapping)
+}
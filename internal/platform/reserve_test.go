@@ -0,0 +1,36 @@
+package platform
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestReserveMemory(t *testing.T) {
+	switch runtime.GOOS {
+	case "darwin", "linux", "windows":
+	default:
+		t.Skip()
+	}
+
+	// Reserving 1 GiB doesn't allocate it.
+	const page, size = 65536, 1 << 30
+	mapping, err := ReserveMemory(size)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, ReleaseMemory(mapping))
+	}()
+	require.Equal(t, size, len(mapping))
+
+	require.NoError(t, CommitMemory(mapping[:page]))
+	mapping[0], mapping[page-1] = 1, 2
+
+	// Committing more keeps what was written.
+	require.NoError(t, CommitMemory(mapping[page:3*page]))
+	require.NoError(t, CommitMemory(mapping[:2*page]))
+	mapping[3*page-1] = 3
+	require.Equal(t, []byte{1, 2, 3}, []byte{mapping[0], mapping[page-1], mapping[3*page-1]})
+
+	require.NoError(t, CommitMemory(nil))
+}
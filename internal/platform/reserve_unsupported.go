@@ -0,0 +1,23 @@
+//go:build !(darwin || linux || windows)
+
+package platform
+
+import (
+	"fmt"
+	"runtime"
+)
+
+var errReserveUnsupported = fmt.Errorf("reserving memory unsupported on GOOS=%s", runtime.GOOS)
+
+// ReserveMemory returns an error, as reserving memory isn't supported on this platform, so callers allocate it.
+func ReserveMemory(int) ([]byte, error) {
+	return nil, errReserveUnsupported
+}
+
+func CommitMemory([]byte) error {
+	return errReserveUnsupported
+}
+
+func ReleaseMemory([]byte) error {
+	return errReserveUnsupported
+}
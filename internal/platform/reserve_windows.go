@@ -0,0 +1,49 @@
+package platform
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+const (
+	windows_MEM_RESERVE   uintptr = 0x00002000
+	windows_PAGE_NOACCESS uintptr = 0x00000001
+)
+
+// ReserveMemory returns a mapping of size bytes, whose pages are inaccessible and don't use physical memory until
+// committed with CommitMemory. This allows memory to grow within the mapping without moving. The mapping must be
+// released with ReleaseMemory.
+//
+// See https://docs.microsoft.com/en-us/windows/win32/api/memoryapi/nf-memoryapi-virtualalloc
+func ReserveMemory(size int) ([]byte, error) {
+	p, _, err := procVirtualAlloc.Call(0, uintptr(size), windows_MEM_RESERVE, windows_PAGE_NOACCESS)
+	if p == 0 {
+		return nil, fmt.Errorf("VirtualAlloc error: %w", ensureErr(err))
+	}
+
+	var mapping []byte
+	sh := (*reflect.SliceHeader)(unsafe.Pointer(&mapping))
+	sh.Data = p
+	sh.Len = size
+	sh.Cap = size
+	return mapping, nil
+}
+
+// CommitMemory makes the pages of the region, which must be in a mapping returned by ReserveMemory and begin at a page
+// boundary, readable and writable. Pages committed before are unchanged.
+func CommitMemory(region []byte) error {
+	if len(region) == 0 {
+		return nil
+	}
+	address := uintptr(unsafe.Pointer(&region[0]))
+	if p, _, err := procVirtualAlloc.Call(address, uintptr(len(region)), windows_MEM_COMMIT, windows_PAGE_READWRITE); p == 0 {
+		return fmt.Errorf("VirtualAlloc error: %w", ensureErr(err))
+	}
+	return nil
+}
+
+// ReleaseMemory releases a mapping returned by ReserveMemory.
+func ReleaseMemory(mapping []byte) error {
+	return freeMemory(mapping)
+}
@@ -19,6 +19,9 @@ type FileEntry struct {
 	FS   fs.FS
 	// File when nil this is a mount like "." or "/".
 	File fs.File
+
+	// DirEntries are the entries last read by the WASI function "fd_readdir", which are indexed by its cookie.
+	DirEntries []fs.DirEntry
 }
 
 type FSContext struct {
@@ -0,0 +1,58 @@
+package sys
+
+import "context"
+
+// FuelKey is a context.Context Value key. Its associated value should be a *Fuel.
+//
+// See experimental.WithFuel
+type FuelKey struct{}
+
+// Fuel is the budget of work for function calls made with a context.Context, when fuel metering is enabled.
+//
+// Note: This is unguarded, so not goroutine-safe!
+type Fuel struct {
+	budget, remaining int64
+}
+
+// NewFuel returns Fuel with the given budget, which is entirely remaining.
+func NewFuel(budget int64) *Fuel {
+	return &Fuel{budget: budget, remaining: budget}
+}
+
+// FuelFromContext returns the Fuel of the context or nil if there is none, which means the budget is unlimited.
+func FuelFromContext(ctx context.Context) *Fuel {
+	if fuel, ok := ctx.Value(FuelKey{}).(*Fuel); ok {
+		return fuel
+	}
+	return nil
+}
+
+// Consume subtracts the cost from the remaining fuel, and returns false if there was not enough fuel.
+// When false, the remaining fuel is zero.
+func (f *Fuel) Consume(cost uint64) bool {
+	f.remaining -= int64(cost)
+	if f.remaining < 0 {
+		f.remaining = 0
+		return false
+	}
+	return true
+}
+
+// Remaining returns the amount of fuel not yet consumed.
+func (f *Fuel) Remaining() int64 {
+	return f.remaining
+}
+
+// SetRemaining updates the remaining fuel, e.g. after an engine consumed a local copy. Negative values, which mean the
+// fuel ran out, are stored as zero.
+func (f *Fuel) SetRemaining(remaining int64) {
+	if remaining < 0 {
+		remaining = 0
+	}
+	f.remaining = remaining
+}
+
+// Used returns the amount of fuel consumed.
+func (f *Fuel) Used() int64 {
+	return f.budget - f.remaining
+}
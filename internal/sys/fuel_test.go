@@ -0,0 +1,38 @@
+package sys
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestFuelFromContext(t *testing.T) {
+	require.Nil(t, FuelFromContext(testCtx))
+
+	fuel := NewFuel(10)
+	ctx := context.WithValue(testCtx, FuelKey{}, fuel)
+	require.Equal(t, fuel, FuelFromContext(ctx))
+}
+
+func TestFuel(t *testing.T) {
+	fuel := NewFuel(10)
+	require.Equal(t, int64(10), fuel.Remaining())
+	require.Zero(t, fuel.Used())
+
+	require.True(t, fuel.Consume(4))
+	require.Equal(t, int64(6), fuel.Remaining())
+	require.Equal(t, int64(4), fuel.Used())
+
+	fuel.SetRemaining(3)
+	require.Equal(t, int64(3), fuel.Remaining())
+	require.Equal(t, int64(7), fuel.Used())
+
+	// Running out of fuel leaves none remaining.
+	require.False(t, fuel.Consume(4))
+	require.Zero(t, fuel.Remaining())
+	require.Equal(t, int64(10), fuel.Used())
+
+	fuel.SetRemaining(-1)
+	require.Zero(t, fuel.Remaining())
+}
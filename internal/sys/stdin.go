@@ -0,0 +1,106 @@
+package sys
+
+import (
+	"io"
+	"sync"
+)
+
+// stdinChunkSize is the size of each read of the goroutine of stdinReader.
+const stdinChunkSize = 4096
+
+// closedChan is a channel which is always ready, for Stdin which never blocks.
+var closedChan = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+// stdinReader wraps a Stdin which doesn't know how much it has buffered, such as an os.File, so that poll_oneoff can
+// wait until it has data, instead of reporting it ready and blocking the following fd_read.
+//
+// Until it is polled, Read reads the wrapped reader directly. Afterwards, a goroutine reads it, one chunk at a time,
+// and Read returns what it buffered.
+//
+// Note: The goroutine blocks in Read of the wrapped reader until it has data, so it leaks if none comes. This is only
+// started when a module polls stdin, and Go has no portable way to poll an io.Reader otherwise.
+type stdinReader struct {
+	r io.Reader
+
+	mu sync.Mutex
+	// polled is true once the goroutine reading r started.
+	polled bool
+	// buf is the data read by the goroutine, but not yet by Read.
+	buf []byte
+	// err is the error which stopped the goroutine, such as io.EOF, and which Read returns after buf.
+	err error
+	// ready is closed when buf or err are set, and replaced when Read consumes buf.
+	ready chan struct{}
+	// more is sent to by Read when it consumed buf, so that the goroutine reads the next chunk.
+	more chan struct{}
+}
+
+// Read implements io.Reader
+func (s *stdinReader) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	if !s.polled {
+		s.mu.Unlock()
+		return s.r.Read(p)
+	}
+	ready := s.ready
+	s.mu.Unlock()
+
+	<-ready
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.buf) == 0 {
+		return 0, s.err
+	}
+	n := copy(p, s.buf)
+	if s.buf = s.buf[n:]; len(s.buf) == 0 && s.err == nil {
+		s.ready = make(chan struct{})
+		s.more <- struct{}{}
+	}
+	return n, nil
+}
+
+// poll returns a channel which is closed when Read won't block, starting the goroutine reading r if needed.
+func (s *stdinReader) poll() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.polled {
+		s.polled = true
+		s.ready = make(chan struct{})
+		s.more = make(chan struct{}, 1)
+		go s.readLoop()
+	}
+	return s.ready
+}
+
+// buffered returns the count of bytes Read returns without blocking.
+func (s *stdinReader) buffered() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.buf)
+}
+
+// readLoop reads r into buf, one chunk at a time, until it fails.
+func (s *stdinReader) readLoop() {
+	chunk := make([]byte, stdinChunkSize)
+	for {
+		n, err := s.r.Read(chunk)
+		if n == 0 && err == nil {
+			continue
+		}
+
+		s.mu.Lock()
+		s.buf = append(s.buf, chunk[:n]...)
+		s.err = err
+		close(s.ready)
+		s.mu.Unlock()
+
+		if err != nil {
+			return
+		}
+		<-s.more // wait until Read consumed buf
+	}
+}
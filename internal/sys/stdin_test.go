@@ -0,0 +1,81 @@
+package sys
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestContext_StdinReady(t *testing.T) {
+	t.Run("eof", func(t *testing.T) {
+		sysCtx := DefaultContext()
+		requireReady(t, sysCtx.StdinReady())
+		require.Equal(t, 0, sysCtx.StdinBuffered())
+	})
+
+	t.Run("bytes.Buffer", func(t *testing.T) {
+		sysCtx, err := NewContext(0, nil, nil, bytes.NewBufferString("wazero"), nil, nil, nil, nil, 0, nil, 0, nil, nil)
+		require.NoError(t, err)
+		requireReady(t, sysCtx.StdinReady())
+		require.Equal(t, 6, sysCtx.StdinBuffered())
+	})
+
+	t.Run("pipe", func(t *testing.T) {
+		r, w := io.Pipe()
+		defer w.Close()
+		sysCtx, err := NewContext(0, nil, nil, r, nil, nil, nil, nil, 0, nil, 0, nil, nil)
+		require.NoError(t, err)
+
+		// Not polled, yet, so this reads the pipe directly.
+		go w.Write([]byte("a"))
+		buf := make([]byte, 4)
+		n, err := sysCtx.Stdin().Read(buf)
+		require.NoError(t, err)
+		require.Equal(t, "a", string(buf[:n]))
+
+		ready := sysCtx.StdinReady()
+		select {
+		case <-ready:
+			t.Fatal("ready without data")
+		case <-time.After(10 * time.Millisecond):
+		}
+		require.Equal(t, 0, sysCtx.StdinBuffered())
+
+		go w.Write([]byte("wazero"))
+		<-ready
+		require.Equal(t, 6, sysCtx.StdinBuffered())
+
+		// Reads return the buffered data, until it is consumed.
+		n, err = sysCtx.Stdin().Read(buf)
+		require.NoError(t, err)
+		require.Equal(t, "waze", string(buf[:n]))
+		requireReady(t, sysCtx.StdinReady())
+		n, err = sysCtx.Stdin().Read(buf)
+		require.NoError(t, err)
+		require.Equal(t, "ro", string(buf[:n]))
+
+		// Consumed, so no longer ready, until the next data or error.
+		ready = sysCtx.StdinReady()
+		select {
+		case <-ready:
+			t.Fatal("ready without data")
+		case <-time.After(10 * time.Millisecond):
+		}
+		require.NoError(t, w.Close())
+		<-ready
+		n, err = sysCtx.Stdin().Read(buf)
+		require.Equal(t, 0, n)
+		require.Equal(t, io.EOF, err)
+	})
+}
+
+func requireReady(t *testing.T, ready <-chan struct{}) {
+	select {
+	case <-ready:
+	default:
+		t.Fatal("not ready")
+	}
+}
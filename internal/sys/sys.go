@@ -26,6 +26,7 @@ type Context struct {
 	walltimeResolution sys.ClockResolution
 	nanotime           *sys.Nanotime
 	nanotimeResolution sys.ClockResolution
+	nanosleep          *sys.Nanosleep
 	randSource         io.Reader
 
 	fs *FSContext
@@ -71,6 +72,29 @@ func (c *Context) Stdin() io.Reader {
 	return c.stdin
 }
 
+// StdinReady returns a channel which is closed when reading Stdin won't block.
+//
+// Note: Stdin which knows its length, such as bytes.Buffer, never blocks. Otherwise, the first call starts reading
+// Stdin in the background, so that data can be waited for, and then buffered until read.
+func (c *Context) StdinReady() <-chan struct{} {
+	if s, ok := c.stdin.(*stdinReader); ok {
+		return s.poll()
+	}
+	return closedChan
+}
+
+// StdinBuffered returns the count of bytes that reading Stdin returns without blocking, which are only known when
+// StdinReady or when Stdin has a Len method, such as bytes.Buffer.
+func (c *Context) StdinBuffered() int {
+	switch s := c.stdin.(type) {
+	case *stdinReader:
+		return s.buffered()
+	case interface{ Len() int }:
+		return s.Len()
+	}
+	return 0
+}
+
 // Stdout is like exec.Cmd Stdout and defaults to io.Discard.
 // See wazero.ModuleConfig WithStdout
 func (c *Context) Stdout() io.Writer {
@@ -103,6 +127,11 @@ func (c *Context) NanotimeResolution() sys.ClockResolution {
 	return c.nanotimeResolution
 }
 
+// Nanosleep implements sys.Nanosleep.
+func (c *Context) Nanosleep(ctx context.Context, ns int64) {
+	(*(c.nanosleep))(ctx, ns)
+}
+
 // FS returns the file system context.
 func (c *Context) FS() *FSContext {
 	return c.fs
@@ -128,7 +157,7 @@ func (eofReader) Read([]byte) (int, error) {
 // Note: This isn't a constant because Context.openedFiles is currently mutable even when empty.
 // TODO: Make it an error to open or close files when no FS was assigned.
 func DefaultContext() *Context {
-	if sysCtx, err := NewContext(0, nil, nil, nil, nil, nil, nil, nil, 0, nil, 0, nil); err != nil {
+	if sysCtx, err := NewContext(0, nil, nil, nil, nil, nil, nil, nil, 0, nil, 0, nil, nil); err != nil {
 		panic(fmt.Errorf("BUG: DefaultContext should never error: %w", err))
 	} else {
 		return sysCtx
@@ -138,6 +167,7 @@ func DefaultContext() *Context {
 var _ = DefaultContext() // Force panic on bug.
 var wt sys.Walltime = platform.FakeWalltime
 var nt sys.Nanotime = platform.FakeNanotime
+var ns sys.Nanosleep = platform.FakeNanosleep
 
 // NewContext is a factory function which helps avoid needing to know defaults or exporting all fields.
 // Note: max is exposed for testing. max is only used for env/args validation.
@@ -149,6 +179,7 @@ func NewContext(
 	randSource io.Reader,
 	walltime *sys.Walltime, walltimeResolution sys.ClockResolution,
 	nanotime *sys.Nanotime, nanotimeResolution sys.ClockResolution,
+	nanosleep *sys.Nanosleep,
 	openedFiles map[uint32]*FileEntry,
 ) (sysCtx *Context, err error) {
 	sysCtx = &Context{args: args, environ: environ}
@@ -163,8 +194,10 @@ func NewContext(
 
 	if stdin == nil {
 		sysCtx.stdin = eofReader{}
+	} else if _, ok := stdin.(interface{ Len() int }); ok {
+		sysCtx.stdin = stdin // can't block when it has no data
 	} else {
-		sysCtx.stdin = stdin
+		sysCtx.stdin = &stdinReader{r: stdin}
 	}
 
 	if stdout == nil {
@@ -207,6 +240,12 @@ func NewContext(
 		sysCtx.nanotimeResolution = sys.ClockResolution(time.Nanosecond)
 	}
 
+	if nanosleep != nil {
+		sysCtx.nanosleep = nanosleep
+	} else {
+		sysCtx.nanosleep = &ns
+	}
+
 	sysCtx.fs = NewFSContext(openedFiles)
 
 	return
@@ -22,6 +22,7 @@ func TestDefaultSysContext(t *testing.T) {
 		nil,    // randSource
 		nil, 0, // walltime, walltimeResolution
 		nil, 0, // nanotime, nanotimeResolution
+		nil, // nanosleep
 		nil, // openedFiles
 	)
 	require.NoError(t, err)
@@ -37,6 +38,7 @@ func TestDefaultSysContext(t *testing.T) {
 	require.Equal(t, sys.ClockResolution(1_000), sysCtx.WalltimeResolution())
 	require.Equal(t, &nt, sysCtx.nanotime) // To compare functions, we can only compare pointers.
 	require.Equal(t, sys.ClockResolution(1), sysCtx.NanotimeResolution())
+	require.Equal(t, &ns, sysCtx.nanosleep) // To compare functions, we can only compare pointers.
 	require.Equal(t, rand.Reader, sysCtx.RandSource())
 	require.Equal(t, NewFSContext(map[uint32]*FileEntry{}), sysCtx.FS())
 }
@@ -89,6 +91,7 @@ func TestNewContext_Args(t *testing.T) {
 				nil,                              // randSource
 				nil, 0,                           // walltime, walltimeResolution
 				nil, 0, // nanotime, nanotimeResolution
+				nil, // nanosleep
 				nil, // openedFiles
 			)
 			if tc.expectedErr == "" {
@@ -150,6 +153,7 @@ func TestNewContext_Environ(t *testing.T) {
 				nil,                              // randSource
 				nil, 0,                           // walltime, walltimeResolution
 				nil, 0, // nanotime, nanotimeResolution
+				nil, // nanosleep
 				nil, // openedFiles
 			)
 			if tc.expectedErr == "" {
@@ -197,6 +201,7 @@ func TestNewContext_Walltime(t *testing.T) {
 				nil,                    // randSource
 				tc.time, tc.resolution, // walltime, walltimeResolution
 				nil, 0, // nanotime, nanotimeResolution
+				nil, // nanosleep
 				nil, // openedFiles
 			)
 			if tc.expectedErr == "" {
@@ -244,6 +249,7 @@ func TestNewContext_Nanotime(t *testing.T) {
 				nil,    // randSource
 				nil, 0, // nanotime, nanotimeResolution
 				tc.time, tc.resolution, // nanotime, nanotimeResolution
+				nil, // nanosleep
 				nil, // openedFiles
 			)
 			if tc.expectedErr == "" {
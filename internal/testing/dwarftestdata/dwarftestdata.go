@@ -0,0 +1,11 @@
+// Package dwarftestdata includes Wasm binaries with DWARF custom sections, for testing source locations.
+package dwarftestdata
+
+import _ "embed"
+
+// DWARFWasm is built from testdata/dwarf.c, where run calls middle, which traps in the inlined function fail.
+//
+// See testdata/dwarf.ll for how to rebuild it.
+//
+//go:embed testdata/dwarf.wasm
+var DWARFWasm []byte
@@ -0,0 +1,14 @@
+volatile int counter;
+
+static inline __attribute__((always_inline)) void fail(void) {
+  __builtin_trap();
+}
+
+__attribute__((noinline)) void middle(void) {
+  counter += 1;
+  fail();
+}
+
+__attribute__((export_name("run"))) void run(void) {
+  middle();
+}
@@ -0,0 +1,47 @@
+; dwarf.ll is the LLVM IR equivalent of `clang --target=wasm32 -g -O1 -S -emit-llvm dwarf.c`, kept so that
+; dwarf.wasm can be rebuilt without a C toolchain:
+;
+;	llc -O1 -filetype=obj dwarf.ll -o dwarf.o
+;	wasm-ld --no-entry --export=run dwarf.o -o dwarf.wasm
+source_filename = "dwarf.c"
+target datalayout = "e-m:e-p:32:32-i64:64-n32:64-S128"
+target triple = "wasm32-unknown-unknown"
+
+@counter = hidden global i32 0, align 4
+
+define hidden void @middle() #0 !dbg !10 {
+  %1 = load volatile i32, i32* @counter, align 4, !dbg !11
+  %2 = add nsw i32 %1, 1, !dbg !11
+  store volatile i32 %2, i32* @counter, align 4, !dbg !11
+  call void @llvm.trap(), !dbg !12
+  unreachable, !dbg !12
+}
+
+define hidden void @run() #1 !dbg !14 {
+  call void @middle(), !dbg !15
+  ret void, !dbg !16
+}
+
+declare void @llvm.trap() #2
+
+attributes #0 = { noinline nounwind }
+attributes #1 = { nounwind "wasm-export-name"="run" }
+attributes #2 = { cold noreturn nounwind }
+
+!llvm.dbg.cu = !{!0}
+!llvm.module.flags = !{!2, !3}
+
+!0 = distinct !DICompileUnit(language: DW_LANG_C99, file: !1, producer: "dwarf.ll", isOptimized: true, runtimeVersion: 0, emissionKind: FullDebug)
+!1 = !DIFile(filename: "dwarf.c", directory: "/testdata")
+!2 = !{i32 7, !"Dwarf Version", i32 4}
+!3 = !{i32 2, !"Debug Info Version", i32 3}
+!4 = !DISubroutineType(types: !5)
+!5 = !{null}
+!6 = distinct !DISubprogram(name: "fail", scope: !1, file: !1, line: 3, type: !4, scopeLine: 3, flags: DIFlagPrototyped, spFlags: DISPFlagLocalToUnit | DISPFlagDefinition | DISPFlagOptimized, unit: !0)
+!10 = distinct !DISubprogram(name: "middle", scope: !1, file: !1, line: 7, type: !4, scopeLine: 7, flags: DIFlagPrototyped, spFlags: DISPFlagDefinition | DISPFlagOptimized, unit: !0)
+!11 = !DILocation(line: 8, column: 11, scope: !10)
+!12 = !DILocation(line: 4, column: 3, scope: !6, inlinedAt: !13)
+!13 = distinct !DILocation(line: 9, column: 3, scope: !10)
+!14 = distinct !DISubprogram(name: "run", scope: !1, file: !1, line: 12, type: !4, scopeLine: 12, flags: DIFlagPrototyped, spFlags: DISPFlagDefinition | DISPFlagOptimized, unit: !0)
+!15 = !DILocation(line: 13, column: 3, scope: !14)
+!16 = !DILocation(line: 14, column: 1, scope: !14)
@@ -592,7 +592,7 @@ func RunTestModuleEngine_Memory(t *testing.T, et EngineTester) {
 	m := &wasm.Module{
 		TypeSection:     []*wasm.FunctionType{{Params: []api.ValueType{api.ValueTypeI32}, ParamNumInUint64: 1}, {}},
 		FunctionSection: []wasm.Index{0, 1},
-		MemorySection:   &wasm.Memory{Min: 1, Cap: 1, Max: 2},
+		MemorySection:   []*wasm.Memory{{Min: 1, Cap: 1, Max: 2}},
 		DataSection: []*wasm.DataSegment{
 			{
 				OffsetExpression: nil, // passive
@@ -623,7 +623,7 @@ func RunTestModuleEngine_Memory(t *testing.T, et EngineTester) {
 	// Assign memory to the module instance
 	module := &wasm.ModuleInstance{
 		Name:          t.Name(),
-		Memory:        wasm.NewMemoryInstance(m.MemorySection),
+		Memory:        wasm.NewMemoryInstance(m.MemorySection[0]),
 		DataInstances: []wasm.DataInstance{m.DataSection[0].Init},
 	}
 	var memory api.Memory = module.Memory
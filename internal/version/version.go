@@ -0,0 +1,66 @@
+// Package version reports the version of wazero compiled into the current binary.
+package version
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"runtime/debug"
+	"sync"
+)
+
+// wazeroModulePath is the Go module path of wazero.
+const wazeroModulePath = "github.com/tetratelabs/wazero"
+
+// DevVersion is returned by GetWazeroVersion when wazero is not a versioned dependency, such as when building wazero
+// itself.
+const DevVersion = "dev"
+
+// GetWazeroVersion returns the version of wazero in the build info of the current binary, or "dev" if unknown.
+//
+// Note: When this is "dev", artifacts such as compilation caches could be produced by a different revision of the
+// code, so consumers should treat them with care.
+func GetWazeroVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return DevVersion
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == wazeroModulePath {
+			if dep.Replace != nil { // Ex. a local directory, which isn't versioned.
+				return DevVersion
+			}
+			return dep.Version
+		}
+	}
+	return DevVersion
+}
+
+var (
+	buildID     string
+	buildIDOnce sync.Once
+)
+
+// GetBuildID returns the hex sha256 of the current executable, or "" if it can't be read. Unlike GetWazeroVersion, this
+// differs between "dev" builds of different revisions of the code.
+//
+// Note: The executable is only read on the first call, as it can be large.
+func GetBuildID() string {
+	buildIDOnce.Do(func() {
+		path, err := os.Executable()
+		if err != nil {
+			return
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		h := sha256.New()
+		if _, err = io.Copy(h, f); err == nil {
+			buildID = hex.EncodeToString(h.Sum(nil))
+		}
+	})
+	return buildID
+}
@@ -0,0 +1,18 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestGetWazeroVersion(t *testing.T) {
+	// wazero isn't a dependency of its own tests.
+	require.Equal(t, DevVersion, GetWazeroVersion())
+}
+
+func TestGetBuildID(t *testing.T) {
+	id := GetBuildID()
+	require.Equal(t, 64, len(id)) // hex sha256 of the test binary.
+	require.Equal(t, id, GetBuildID())
+}
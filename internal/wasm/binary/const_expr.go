@@ -17,6 +17,7 @@ func decodeConstantExpression(r *bytes.Reader, enabledFeatures wasm.Features) (*
 
 	remainingBeforeData := int64(r.Len())
 	offsetAtData := r.Size() - remainingBeforeData
+	offsetAtOpcode := offsetAtData - 1
 
 	opcode := b
 	switch opcode {
@@ -83,7 +84,10 @@ func decodeConstantExpression(r *bytes.Reader, enabledFeatures wasm.Features) (*
 	}
 
 	if b != wasm.OpcodeEnd {
-		return nil, fmt.Errorf("constant expression has been not terminated")
+		if !enabledFeatures.Get(wasm.FeatureExtendedConst) {
+			return nil, fmt.Errorf("constant expression has been not terminated")
+		}
+		return decodeExtendedConstantExpression(r, b, offsetAtOpcode)
 	}
 
 	data := make([]byte, remainingBeforeData-int64(r.Len())-1)
@@ -94,7 +98,54 @@ func decodeConstantExpression(r *bytes.Reader, enabledFeatures wasm.Features) (*
 	return &wasm.ConstantExpression{Opcode: opcode, Data: data}, nil
 }
 
+// decodeExtendedConstantExpression decodes the remaining instructions of a constant expression with more than one,
+// where b is the opcode of the second instruction and offsetAtOpcode is the position of the first.
+//
+// See https://github.com/WebAssembly/extended-const/blob/main/proposals/extended-const/Overview.md
+func decodeExtendedConstantExpression(r *bytes.Reader, b byte, offsetAtOpcode int64) (*wasm.ConstantExpression, error) {
+	var offsetAtLast int64
+	var err error
+	for b != wasm.OpcodeEnd {
+		offsetAtLast = r.Size() - int64(r.Len()) - 1
+		switch b {
+		case wasm.OpcodeI32Const:
+			_, _, err = leb128.DecodeInt32(r)
+		case wasm.OpcodeI64Const:
+			_, _, err = leb128.DecodeInt64(r)
+		case wasm.OpcodeGlobalGet:
+			_, _, err = leb128.DecodeUint32(r)
+		case wasm.OpcodeI32Add, wasm.OpcodeI32Sub, wasm.OpcodeI32Mul, wasm.OpcodeI64Add, wasm.OpcodeI64Sub, wasm.OpcodeI64Mul:
+		default:
+			return nil, fmt.Errorf("%v for const expression opt code: %#x", ErrInvalidByte, b)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read value: %v", err)
+		}
+		if b, err = r.ReadByte(); err != nil {
+			return nil, fmt.Errorf("look for end opcode: %v", err)
+		}
+	}
+
+	data := make([]byte, offsetAtLast-offsetAtOpcode+1)
+	if _, err = r.ReadAt(data, offsetAtOpcode); err != nil {
+		return nil, fmt.Errorf("error re-buffering ConstantExpression.Data")
+	}
+
+	// The last instruction must be arithmetic for only one value to be left, and becomes the Opcode of the expression.
+	expr := &wasm.ConstantExpression{Opcode: data[len(data)-1], Data: data[:len(data)-1]}
+	if !expr.IsExtended() {
+		return nil, fmt.Errorf("constant expression must end with an arithmetic instruction, but was %s",
+			wasm.InstructionName(expr.Opcode))
+	}
+	return expr, nil
+}
+
 func encodeConstantExpression(expr *wasm.ConstantExpression) (ret []byte) {
+	if expr.IsExtended() { // The arithmetic instruction is last, after those computing its operands.
+		ret = append(ret, expr.Data...)
+		ret = append(ret, expr.Opcode)
+		return append(ret, wasm.OpcodeEnd)
+	}
 	ret = append(ret, expr.Opcode)
 	ret = append(ret, expr.Data...)
 	ret = append(ret, wasm.OpcodeEnd)
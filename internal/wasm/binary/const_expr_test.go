@@ -78,15 +78,51 @@ func TestDecodeConstantExpression(t *testing.T) {
 				},
 			},
 		},
+		{
+			in: []byte{
+				wasm.OpcodeI32Const, 1,
+				wasm.OpcodeGlobalGet, 0,
+				wasm.OpcodeI32Add,
+				wasm.OpcodeEnd,
+			},
+			exp: &wasm.ConstantExpression{
+				Opcode: wasm.OpcodeI32Add,
+				Data:   []byte{wasm.OpcodeI32Const, 1, wasm.OpcodeGlobalGet, 0},
+			},
+		},
+		{
+			in: []byte{
+				wasm.OpcodeI64Const, 2,
+				wasm.OpcodeI64Const, 0x80, 0x1, // 128 in varint encoding.
+				wasm.OpcodeI64Mul,
+				wasm.OpcodeI64Const, 1,
+				wasm.OpcodeI64Sub,
+				wasm.OpcodeEnd,
+			},
+			exp: &wasm.ConstantExpression{
+				Opcode: wasm.OpcodeI64Sub,
+				Data: []byte{
+					wasm.OpcodeI64Const, 2,
+					wasm.OpcodeI64Const, 0x80, 0x1,
+					wasm.OpcodeI64Mul,
+					wasm.OpcodeI64Const, 1,
+				},
+			},
+		},
 	}
 
 	for i, tt := range tests {
 		tc := tt
 		t.Run(strconv.Itoa(i), func(t *testing.T) {
 			actual, err := decodeConstantExpression(bytes.NewReader(tc.in),
-				wasm.FeatureBulkMemoryOperations|wasm.FeatureSIMD)
+				wasm.FeatureBulkMemoryOperations|wasm.FeatureSIMD|wasm.FeatureExtendedConst)
 			require.NoError(t, err)
 			require.Equal(t, tc.exp, actual)
+
+			// Ensure extended expressions encode back to the same instructions, with the arithmetic one last.
+			if actual.IsExtended() {
+				require.Equal(t, tc.in, encodeConstantExpression(actual))
+			}
 		})
 	}
 }
@@ -176,6 +212,44 @@ func TestDecodeConstantExpression_errors(t *testing.T) {
 			expectedErr: "read vector const instruction immediates: needs 16 bytes but was 8 bytes",
 			features:    wasm.FeatureSIMD,
 		},
+		{
+			in: []byte{
+				wasm.OpcodeI32Const, 1,
+				wasm.OpcodeI32Const, 2,
+				wasm.OpcodeI32Add,
+				wasm.OpcodeEnd,
+			},
+			expectedErr: "constant expression has been not terminated",
+			features:    wasm.Features20220419,
+		},
+		{
+			in: []byte{
+				wasm.OpcodeI32Const, 1,
+				wasm.OpcodeI32Const, 2,
+				wasm.OpcodeEnd,
+			},
+			expectedErr: "constant expression must end with an arithmetic instruction, but was i32.const",
+			features:    wasm.FeatureExtendedConst,
+		},
+		{
+			in: []byte{
+				wasm.OpcodeI32Const, 1,
+				wasm.OpcodeI32Const, 2,
+				wasm.OpcodeI32DivS,
+				wasm.OpcodeEnd,
+			},
+			expectedErr: "invalid byte for const expression opt code: 0x6d",
+			features:    wasm.FeatureExtendedConst,
+		},
+		{
+			in: []byte{
+				wasm.OpcodeI32Const, 1,
+				wasm.OpcodeI32Const, 2,
+				wasm.OpcodeI32Add,
+			},
+			expectedErr: "look for end opcode: EOF",
+			features:    wasm.FeatureExtendedConst,
+		},
 	}
 
 	for _, tt := range tests {
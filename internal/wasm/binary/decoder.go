@@ -5,9 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/tetratelabs/wazero/internal/leb128"
 	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasmdebug"
 )
 
 // DecodeModule implements wasm.DecodeModule for the WebAssembly 1.0 (20191205) Binary Format
@@ -31,6 +33,7 @@ func DecodeModule(
 	}
 
 	m := &wasm.Module{}
+	var dwarfSections map[string][]byte
 	for {
 		// TODO: except custom sections, all others are required to be in order, but we aren't checking yet.
 		// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#modules%E2%91%A0%E2%93%AA
@@ -62,10 +65,19 @@ func DecodeModule(
 				break
 			}
 
-			// Now, either decode the NameSection or skip an unsupported one
+			// Now, either decode the NameSection, read a DWARF section or skip an unsupported one
 			limit := sectionSize - nameSize
 			if name == "name" {
 				m.NameSection, err = decodeNameSection(r, uint64(limit))
+			} else if strings.HasPrefix(name, ".debug_") {
+				if dwarfSections == nil {
+					dwarfSections = map[string][]byte{}
+				}
+				section := make([]byte, limit)
+				if _, err = io.ReadFull(r, section); err != nil {
+					return nil, fmt.Errorf("failed to read name[%s]: %w", name, err)
+				}
+				dwarfSections[name] = section
 			} else {
 				// Note: Not Seek because it doesn't err when given an offset past EOF. Rather, it leads to undefined state.
 				if _, err = io.CopyN(io.Discard, r, int64(limit)); err != nil {
@@ -84,7 +96,7 @@ func DecodeModule(
 		case wasm.SectionIDTable:
 			m.TableSection, err = decodeTableSection(r, enabledFeatures)
 		case wasm.SectionIDMemory:
-			m.MemorySection, err = decodeMemorySection(r, memorySizer)
+			m.MemorySection, err = decodeMemorySection(r, memorySizer, enabledFeatures)
 		case wasm.SectionIDGlobal:
 			if m.GlobalSection, err = decodeGlobalSection(r, enabledFeatures); err != nil {
 				return nil, err // avoid re-wrapping the error.
@@ -107,6 +119,11 @@ func DecodeModule(
 				return nil, fmt.Errorf("data count section not supported as %v", err)
 			}
 			m.DataCountSection, err = decodeDataCountSection(r)
+		case wasm.SectionIDTag:
+			if err := enabledFeatures.Require(wasm.FeatureExceptionHandling); err != nil {
+				return nil, fmt.Errorf("tag section not supported as %v", err)
+			}
+			m.TagSection, err = decodeTagSection(r)
 		default:
 			err = ErrInvalidSectionID
 		}
@@ -125,5 +142,10 @@ func DecodeModule(
 	if functionCount != codeCount {
 		return nil, fmt.Errorf("function and code section have inconsistent lengths: %d != %d", functionCount, codeCount)
 	}
+
+	// Invalid debug information is ignored, as it doesn't affect the behavior of the module.
+	if dwarfSections != nil {
+		m.DWARFLines = wasmdebug.NewDWARFLines(dwarfSections)
+	}
 	return m, nil
 }
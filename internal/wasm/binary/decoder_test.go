@@ -3,6 +3,7 @@ package binary
 import (
 	"testing"
 
+	"github.com/tetratelabs/wazero/internal/testing/dwarftestdata"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
 )
@@ -71,7 +72,7 @@ func TestDecodeModule(t *testing.T) {
 			name: "table and memory section",
 			input: &wasm.Module{
 				TableSection:  []*wasm.Table{{Min: 3, Type: wasm.RefTypeFuncref}},
-				MemorySection: &wasm.Memory{Min: 1, Cap: 1, Max: 1, IsMaxEncoded: true},
+				MemorySection: []*wasm.Memory{{Min: 1, Cap: 1, Max: 1, IsMaxEncoded: true}},
 			},
 		},
 		{
@@ -122,12 +123,59 @@ func TestDecodeModule(t *testing.T) {
 		require.NoError(t, e)
 		require.Equal(t, &wasm.Module{NameSection: &wasm.NameSection{ModuleName: "simple"}}, m)
 	})
+	t.Run("reads DWARF sections", func(t *testing.T) {
+		m, e := DecodeModule(dwarftestdata.DWARFWasm, wasm.Features20191205, wasm.MemorySizer)
+		require.NoError(t, e)
+		require.NotNil(t, m.DWARFLines)
+
+		// Offsets of the function bodies, which are after the size and locals of each function.
+		require.Equal(t, 2, len(m.CodeSection))
+		require.Equal(t, uint64(0x3), m.CodeSection[0].BodyOffsetInCodeSection)
+		require.Equal(t, uint64(0x1d), m.CodeSection[1].BodyOffsetInCodeSection)
+	})
+	t.Run("ignores invalid DWARF sections", func(t *testing.T) {
+		input := append(append(Magic, version...),
+			wasm.SectionIDCustom, 0x0e, // 14 bytes in this section
+			0x0b, '.', 'd', 'e', 'b', 'u', 'g', '_', 'i', 'n', 'f', 'o',
+			1, 2,
+			wasm.SectionIDCustom, 0x0e, // 14 bytes in this section
+			0x0b, '.', 'd', 'e', 'b', 'u', 'g', '_', 'l', 'i', 'n', 'e',
+			3, 4)
+		m, e := DecodeModule(input, wasm.Features20191205, wasm.MemorySizer)
+		require.NoError(t, e)
+		require.Equal(t, &wasm.Module{}, m)
+	})
 	t.Run("data count section disabled", func(t *testing.T) {
 		input := append(append(Magic, version...),
 			wasm.SectionIDDataCount, 1, 0)
 		_, e := DecodeModule(input, wasm.Features20191205, wasm.MemorySizer)
 		require.EqualError(t, e, `data count section not supported as feature "bulk-memory-operations" is disabled`)
 	})
+	t.Run("tag section", func(t *testing.T) {
+		input := &wasm.Module{
+			TypeSection: []*wasm.FunctionType{{Params: []wasm.ValueType{i32}, ParamNumInUint64: 1}},
+			ImportSection: []*wasm.Import{
+				{Module: "env", Name: "error", Type: wasm.ExternTypeTag, DescTag: 0},
+			},
+			TagSection:    []wasm.Index{0, 0},
+			ExportSection: []*wasm.Export{{Name: "tag", Type: wasm.ExternTypeTag, Index: 2}},
+		}
+		m, e := DecodeModule(EncodeModule(input), wasm.Features20191205|wasm.FeatureExceptionHandling, wasm.MemorySizer)
+		require.NoError(t, e)
+		require.Equal(t, input, m)
+	})
+	t.Run("tag section disabled", func(t *testing.T) {
+		input := append(append(Magic, version...),
+			wasm.SectionIDTag, 3, 1, 0, 0)
+		_, e := DecodeModule(input, wasm.Features20191205, wasm.MemorySizer)
+		require.EqualError(t, e, `tag section not supported as feature "exception-handling" is disabled`)
+	})
+	t.Run("invalid tag attribute", func(t *testing.T) {
+		input := append(append(Magic, version...),
+			wasm.SectionIDTag, 3, 1, 1, 0)
+		_, e := DecodeModule(input, wasm.Features20191205|wasm.FeatureExceptionHandling, wasm.MemorySizer)
+		require.EqualError(t, e, "section tag: tag[0]: invalid byte: invalid tag attribute: 0x1 != 0x00")
+	})
 }
 
 func TestDecodeModule_Errors(t *testing.T) {
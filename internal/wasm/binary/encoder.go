@@ -30,6 +30,9 @@ func EncodeModule(m *wasm.Module) (bytes []byte) {
 	if m.SectionElementCount(wasm.SectionIDMemory) > 0 {
 		bytes = append(bytes, encodeMemorySection(m.MemorySection)...)
 	}
+	if m.SectionElementCount(wasm.SectionIDTag) > 0 {
+		bytes = append(bytes, encodeTagSection(m.TagSection)...)
+	}
 	if m.SectionElementCount(wasm.SectionIDGlobal) > 0 {
 		bytes = append(bytes, encodeGlobalSection(m.GlobalSection)...)
 	}
@@ -109,7 +109,7 @@ func TestModule_Encode(t *testing.T) {
 			name: "table and memory section",
 			input: &wasm.Module{
 				TableSection:  []*wasm.Table{{Min: 3, Type: wasm.RefTypeFuncref}},
-				MemorySection: &wasm.Memory{Min: 1, Max: 1, IsMaxEncoded: true},
+				MemorySection: []*wasm.Memory{{Min: 1, Max: 1, IsMaxEncoded: true}},
 			},
 			expected: append(append(Magic, version...),
 				wasm.SectionIDTable, 0x04, // 4 bytes in this section
@@ -211,7 +211,7 @@ func TestModule_Encode(t *testing.T) {
 
 func TestModule_Encode_HostFunctionSection_Unsupported(t *testing.T) {
 	// We don't currently have an approach to serialize reflect.Value pointers
-	fn := reflect.ValueOf(func(wasm.Module) {})
+	fn := reflect.ValueOf(func(*wasm.Module) {})
 
 	captured := require.CapturePanic(func() {
 		EncodeModule(&wasm.Module{HostFunctionSection: []*reflect.Value{&fn}})
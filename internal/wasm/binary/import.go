@@ -34,9 +34,13 @@ func decodeImport(
 	case wasm.ExternTypeTable:
 		i.DescTable, err = decodeTable(r, enabledFeatures)
 	case wasm.ExternTypeMemory:
-		i.DescMem, err = decodeMemory(r, memorySizer)
+		i.DescMem, err = decodeMemory(r, memorySizer, enabledFeatures)
 	case wasm.ExternTypeGlobal:
 		i.DescGlobal, err = decodeGlobalType(r)
+	case wasm.ExternTypeTag:
+		if err = enabledFeatures.Require(wasm.FeatureExceptionHandling); err == nil {
+			i.DescTag, err = decodeTagType(r)
+		}
 	default:
 		err = fmt.Errorf("%w: invalid byte for importdesc: %#x", ErrInvalidByte, b)
 	}
@@ -58,13 +62,13 @@ func encodeImport(i *wasm.Import) []byte {
 		data = append(data, leb128.EncodeUint32(i.DescFunc)...)
 	case wasm.ExternTypeTable:
 		data = append(data, wasm.RefTypeFuncref)
-		data = append(data, encodeLimitsType(i.DescTable.Min, i.DescTable.Max)...)
+		data = append(data, encodeLimitsType(i.DescTable.Min, i.DescTable.Max, false, false)...)
 	case wasm.ExternTypeMemory:
 		maxPtr := &i.DescMem.Max
 		if !i.DescMem.IsMaxEncoded {
 			maxPtr = nil
 		}
-		data = append(data, encodeLimitsType(i.DescMem.Min, maxPtr)...)
+		data = append(data, encodeLimitsType(i.DescMem.Min, maxPtr, i.DescMem.Shared, i.DescMem.Is64)...)
 	case wasm.ExternTypeGlobal:
 		g := i.DescGlobal
 		var mutable byte
@@ -72,6 +76,8 @@ func encodeImport(i *wasm.Import) []byte {
 			mutable = 1
 		}
 		data = append(data, g.ValType, mutable)
+	case wasm.ExternTypeTag:
+		data = append(data, encodeTagType(i.DescTag)...)
 	default:
 		panic(fmt.Errorf("invalid externtype: %s", wasm.ExternTypeName(i.Type)))
 	}
@@ -6,7 +6,9 @@ import (
 	"math"
 	"testing"
 
+	"github.com/tetratelabs/wazero/internal/leb128"
 	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
 )
 
 func TestLimitsType(t *testing.T) {
@@ -17,6 +19,8 @@ func TestLimitsType(t *testing.T) {
 		name     string
 		min      uint32
 		max      *uint32
+		shared   bool
+		is64     bool
 		expected []byte
 	}{
 		{
@@ -44,21 +48,100 @@ func TestLimitsType(t *testing.T) {
 			max:      &largest,
 			expected: []byte{0x1, 0xff, 0xff, 0xff, 0xff, 0xf, 0xff, 0xff, 0xff, 0xff, 0xf},
 		},
+		{
+			name:     "shared min 0",
+			shared:   true,
+			expected: []byte{0x2, 0},
+		},
+		{
+			name:     "shared min 0, max largest",
+			max:      &largest,
+			shared:   true,
+			expected: []byte{0x3, 0, 0xff, 0xff, 0xff, 0xff, 0xf},
+		},
+		{
+			name:     "64-bit min 0",
+			is64:     true,
+			expected: []byte{0x4, 0},
+		},
+		{
+			name:     "64-bit min 0, max largest",
+			max:      &largest,
+			is64:     true,
+			expected: []byte{0x5, 0, 0xff, 0xff, 0xff, 0xff, 0xf},
+		},
+		{
+			name:     "shared 64-bit min 0, max 0",
+			max:      &zero,
+			shared:   true,
+			is64:     true,
+			expected: []byte{0x7, 0, 0},
+		},
 	}
 
 	for _, tt := range tests {
 		tc := tt
 
-		b := encodeLimitsType(tc.min, tc.max)
+		b := encodeLimitsType(tc.min, tc.max, tc.shared, tc.is64)
 		t.Run(fmt.Sprintf("encode - %s", tc.name), func(t *testing.T) {
 			require.Equal(t, tc.expected, b)
 		})
 
 		t.Run(fmt.Sprintf("decode - %s", tc.name), func(t *testing.T) {
-			min, max, err := decodeLimitsType(bytes.NewReader(b))
+			min, max, shared, is64, err := decodeLimitsType(bytes.NewReader(b))
 			require.NoError(t, err)
 			require.Equal(t, min, tc.min)
 			require.Equal(t, max, tc.max)
+			require.Equal(t, shared, tc.shared)
+			require.Equal(t, is64, tc.is64)
+		})
+	}
+}
+
+func TestDecodeLimitsType_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       []byte
+		expectedErr string
+	}{
+		{
+			name:        "invalid flag",
+			input:       []byte{0x8, 0},
+			expectedErr: "invalid byte for limits: 0x8 > 0x07",
+		},
+		{
+			name:        "64-bit min over 2^48",
+			input:       append([]byte{0x4}, leb128.EncodeUint64(1<<48+1)...),
+			expectedErr: "read min of limit: 281474976710657 over limit of 281474976710656",
+		},
+		{
+			name:        "64-bit max over 2^48",
+			input:       append([]byte{0x5, 0}, leb128.EncodeUint64(1<<48+1)...),
+			expectedErr: "read max of limit: 281474976710657 over limit of 281474976710656",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, _, _, err := decodeLimitsType(bytes.NewReader(tc.input))
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestDecodeLimitsType_64BitOverUint32(t *testing.T) {
+	for _, v := range []uint64{math.MaxUint32 + 1, 1 << 40, 1 << 48} {
+		input := append([]byte{0x5}, leb128.EncodeUint64(v)...)
+		input = append(input, leb128.EncodeUint64(v)...)
+		t.Run(fmt.Sprint(v), func(t *testing.T) {
+			// Clamped, as page counts are uint32.
+			min, max, _, is64, err := decodeLimitsType(bytes.NewReader(input))
+			require.NoError(t, err)
+			require.True(t, is64)
+			require.Equal(t, wasm.Memory64LimitPages, min)
+			require.Equal(t, wasm.Memory64LimitPages, *max)
 		})
 	}
 }
@@ -2,6 +2,8 @@ package binary
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 
 	"github.com/tetratelabs/wazero/internal/wasm"
 )
@@ -12,14 +14,36 @@ import (
 func decodeMemory(
 	r *bytes.Reader,
 	memorySizer func(minPages uint32, maxPages *uint32) (min, capacity, max uint32),
+	enabledFeatures wasm.Features,
 ) (*wasm.Memory, error) {
-	min, maxP, err := decodeLimitsType(r)
+	min, maxP, shared, is64, err := decodeLimitsType(r)
 	if err != nil {
 		return nil, err
 	}
+	if is64 {
+		if err = enabledFeatures.Require(wasm.FeatureMemory64); err != nil {
+			return nil, fmt.Errorf("memory with 64-bit indices invalid as %v", err)
+		}
+	}
+	if shared {
+		if err = enabledFeatures.Require(wasm.FeatureThreads); err != nil {
+			return nil, fmt.Errorf("shared memory invalid as %v", err)
+		}
+		if maxP == nil {
+			return nil, errors.New("shared memory must have a maximum")
+		}
+	}
 
-	min, capacity, max := memorySizer(min, maxP)
-	mem := &wasm.Memory{Min: min, Cap: capacity, Max: max, IsMaxEncoded: maxP != nil}
+	sizerMaxP := maxP
+	if is64 && maxP == nil { // Default to the limit of 64-bit memories, as memory sizers default to MemoryLimitPages.
+		limit := wasm.Memory64LimitPages
+		sizerMaxP = &limit
+	}
+	min, capacity, max := memorySizer(min, sizerMaxP)
+	if shared {
+		capacity = max // so that growing doesn't move the memory while other calls use it. This is reserved, not allocated.
+	}
+	mem := &wasm.Memory{Min: min, Cap: capacity, Max: max, IsMaxEncoded: maxP != nil, Shared: shared, Is64: is64}
 
 	return mem, mem.Validate()
 }
@@ -32,5 +56,5 @@ func encodeMemory(i *wasm.Memory) []byte {
 	if !i.IsMaxEncoded {
 		maxPtr = nil
 	}
-	return encodeLimitsType(i.Min, maxPtr)
+	return encodeLimitsType(i.Min, maxPtr, i.Shared, i.Is64)
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/tetratelabs/wazero/internal/leb128"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
 )
@@ -48,6 +49,21 @@ func TestMemoryType(t *testing.T) {
 			input:    &wasm.Memory{Min: max, Cap: max, Max: max, IsMaxEncoded: true},
 			expected: []byte{0x1, 0x80, 0x80, 0x4, 0x80, 0x80, 0x4},
 		},
+		{
+			name:     "shared min 1 max 2",
+			input:    &wasm.Memory{Min: 1, Cap: 2, Max: 2, IsMaxEncoded: true, Shared: true},
+			expected: []byte{0x3, 1, 2},
+		},
+		{
+			name:     "64-bit min 1 default max",
+			input:    &wasm.Memory{Min: 1, Cap: 1, Max: wasm.Memory64LimitPages, Is64: true},
+			expected: []byte{0x4, 1},
+		},
+		{
+			name:     "64-bit min 0, max over 32-bit limit",
+			input:    &wasm.Memory{Max: max + 1, IsMaxEncoded: true, Is64: true},
+			expected: []byte{0x5, 0, 0x81, 0x80, 0x4},
+		},
 	}
 
 	for _, tt := range tests {
@@ -59,13 +75,21 @@ func TestMemoryType(t *testing.T) {
 		})
 
 		t.Run(fmt.Sprintf("decode %s", tc.name), func(t *testing.T) {
-			binary, err := decodeMemory(bytes.NewReader(b), wasm.MemorySizer)
+			binary, err := decodeMemory(bytes.NewReader(b), wasm.MemorySizer, wasm.FeatureThreads|wasm.FeatureMemory64)
 			require.NoError(t, err)
 			require.Equal(t, binary, tc.input)
 		})
 	}
 }
 
+func TestDecodeMemoryType_64BitMaxOverLimit(t *testing.T) {
+	// A max of 2^40 pages is valid, but over the pages wazero supports, so it is clamped as if it were missing.
+	input := append([]byte{0x5, 1}, leb128.EncodeUint64(1<<40)...)
+	m, err := decodeMemory(bytes.NewReader(input), wasm.MemorySizer, wasm.FeatureMemory64)
+	require.NoError(t, err)
+	require.Equal(t, &wasm.Memory{Min: 1, Cap: 1, Max: wasm.Memory64LimitPages, IsMaxEncoded: true, Is64: true}, m)
+}
+
 func TestDecodeMemoryType_Errors(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -80,12 +104,17 @@ func TestDecodeMemoryType_Errors(t *testing.T) {
 		{
 			name:        "min > limit",
 			input:       []byte{0x0, 0xff, 0xff, 0xff, 0xff, 0xf},
-			expectedErr: "min 4294967295 pages (3 Ti) over limit of 65536 pages (4 Gi)",
+			expectedErr: "min 4294967295 pages (255 Ti) over limit of 65536 pages (4 Gi)",
 		},
 		{
 			name:        "max > limit",
 			input:       []byte{0x1, 0, 0xff, 0xff, 0xff, 0xff, 0xf},
-			expectedErr: "max 4294967295 pages (3 Ti) over limit of 65536 pages (4 Gi)",
+			expectedErr: "max 4294967295 pages (255 Ti) over limit of 65536 pages (4 Gi)",
+		},
+		{
+			name:        "shared without max",
+			input:       []byte{0x2, 0},
+			expectedErr: "shared memory must have a maximum",
 		},
 	}
 
@@ -93,8 +122,18 @@ func TestDecodeMemoryType_Errors(t *testing.T) {
 		tc := tt
 
 		t.Run(tc.name, func(t *testing.T) {
-			_, err := decodeMemory(bytes.NewReader(tc.input), wasm.MemorySizer)
+			_, err := decodeMemory(bytes.NewReader(tc.input), wasm.MemorySizer, wasm.FeatureThreads)
 			require.EqualError(t, err, tc.expectedErr)
 		})
 	}
+
+	t.Run("shared when threads disabled", func(t *testing.T) {
+		_, err := decodeMemory(bytes.NewReader([]byte{0x3, 0, 1}), wasm.MemorySizer, wasm.Features20191205)
+		require.EqualError(t, err, "shared memory invalid as feature \"threads\" is disabled")
+	})
+
+	t.Run("64-bit when memory64 disabled", func(t *testing.T) {
+		_, err := decodeMemory(bytes.NewReader([]byte{0x4, 0}), wasm.MemorySizer, wasm.Features20191205)
+		require.EqualError(t, err, "memory with 64-bit indices invalid as feature \"memory64\" is disabled")
+	})
 }
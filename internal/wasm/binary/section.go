@@ -83,16 +83,25 @@ func decodeTableSection(r *bytes.Reader, enabledFeatures wasm.Features) ([]*wasm
 func decodeMemorySection(
 	r *bytes.Reader,
 	memorySizer func(minPages uint32, maxPages *uint32) (min, capacity, max uint32),
-) (*wasm.Memory, error) {
+	enabledFeatures wasm.Features,
+) ([]*wasm.Memory, error) {
 	vs, _, err := leb128.DecodeUint32(r)
 	if err != nil {
 		return nil, fmt.Errorf("error reading size")
 	}
 	if vs > 1 {
-		return nil, fmt.Errorf("at most one memory allowed in module, but read %d", vs)
+		if err := enabledFeatures.Require(wasm.FeatureMultiMemory); err != nil {
+			return nil, fmt.Errorf("at most one memory allowed in module as %w", err)
+		}
 	}
 
-	return decodeMemory(r, memorySizer)
+	ret := make([]*wasm.Memory, vs)
+	for i := range ret {
+		if ret[i], err = decodeMemory(r, memorySizer, enabledFeatures); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
 }
 
 func decodeGlobalSection(r *bytes.Reader, enabledFeatures wasm.Features) ([]*wasm.Global, error) {
@@ -157,6 +166,7 @@ func decodeElementSection(r *bytes.Reader, enabledFeatures wasm.Features) ([]*wa
 }
 
 func decodeCodeSection(r *bytes.Reader) ([]*wasm.Code, error) {
+	codeSectionStart := uint64(r.Len())
 	vs, _, err := leb128.DecodeUint32(r)
 	if err != nil {
 		return nil, fmt.Errorf("get size of vector: %w", err)
@@ -164,7 +174,7 @@ func decodeCodeSection(r *bytes.Reader) ([]*wasm.Code, error) {
 
 	result := make([]*wasm.Code, vs)
 	for i := uint32(0); i < vs; i++ {
-		if result[i], err = decodeCode(r); err != nil {
+		if result[i], err = decodeCode(r, codeSectionStart); err != nil {
 			return nil, fmt.Errorf("read %d-th code segment: %v", i, err)
 		}
 	}
@@ -195,6 +205,21 @@ func decodeDataCountSection(r *bytes.Reader) (count *uint32, err error) {
 	return &v, nil
 }
 
+func decodeTagSection(r *bytes.Reader) ([]wasm.Index, error) {
+	vs, _, err := leb128.DecodeUint32(r)
+	if err != nil {
+		return nil, fmt.Errorf("get size of vector: %w", err)
+	}
+
+	result := make([]wasm.Index, vs)
+	for i := uint32(0); i < vs; i++ {
+		if result[i], err = decodeTagType(r); err != nil {
+			return nil, fmt.Errorf("tag[%d]: %w", i, err)
+		}
+	}
+	return result, nil
+}
+
 // encodeSection encodes the sectionID, the size of its contents in bytes, followed by the contents.
 // See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#sections%E2%91%A0
 func encodeSection(sectionID wasm.SectionID, contents []byte) []byte {
@@ -265,16 +290,31 @@ func encodeTableSection(tables []*wasm.Table) []byte {
 	return encodeSection(wasm.SectionIDTable, contents)
 }
 
-// encodeMemorySection encodes a wasm.SectionIDMemory for the module-defined function in WebAssembly 1.0
+// encodeMemorySection encodes a wasm.SectionIDMemory for the module-defined memories in WebAssembly 1.0
 // (20191205) Binary Format.
 //
 // See encodeMemory
 // See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#memory-section%E2%91%A0
-func encodeMemorySection(memory *wasm.Memory) []byte {
-	contents := append([]byte{1}, encodeMemory(memory)...)
+func encodeMemorySection(memories []*wasm.Memory) []byte {
+	contents := leb128.EncodeUint32(uint32(len(memories)))
+	for _, memory := range memories {
+		contents = append(contents, encodeMemory(memory)...)
+	}
 	return encodeSection(wasm.SectionIDMemory, contents)
 }
 
+// encodeTagSection encodes a wasm.SectionIDTag for the type indices of the tags defined in the module.
+//
+// See encodeTagType
+// See https://github.com/WebAssembly/exception-handling/blob/main/proposals/exception-handling/Exceptions.md#tag-section
+func encodeTagSection(typeIndices []wasm.Index) []byte {
+	contents := leb128.EncodeUint32(uint32(len(typeIndices)))
+	for _, index := range typeIndices {
+		contents = append(contents, encodeTagType(index)...)
+	}
+	return encodeSection(wasm.SectionIDTag, contents)
+}
+
 // encodeGlobalSection encodes a wasm.SectionIDGlobal for the given globals in WebAssembly 1.0 (20191205) Binary
 // Format.
 //
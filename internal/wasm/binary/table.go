@@ -2,6 +2,7 @@ package binary
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 
 	"github.com/tetratelabs/wazero/internal/wasm"
@@ -22,10 +23,16 @@ func decodeTable(r *bytes.Reader, enabledFeatures wasm.Features) (*wasm.Table, e
 		}
 	}
 
-	min, max, err := decodeLimitsType(r)
+	min, max, shared, is64, err := decodeLimitsType(r)
 	if err != nil {
 		return nil, fmt.Errorf("read limits: %v", err)
 	}
+	if shared {
+		return nil, errors.New("tables cannot be shared")
+	}
+	if is64 {
+		return nil, errors.New("tables cannot have 64-bit indices")
+	}
 	if min > wasm.MaximumFunctionIndex {
 		return nil, fmt.Errorf("table min must be at most %d", wasm.MaximumFunctionIndex)
 	}
@@ -41,5 +48,5 @@ func decodeTable(r *bytes.Reader, enabledFeatures wasm.Features) (*wasm.Table, e
 //
 // See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#binary-table
 func encodeTable(i *wasm.Table) []byte {
-	return append([]byte{i.Type}, encodeLimitsType(i.Min, i.Max)...)
+	return append([]byte{i.Type}, encodeLimitsType(i.Min, i.Max, false, false)...)
 }
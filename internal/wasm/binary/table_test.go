@@ -90,6 +90,12 @@ func TestDecodeTableType_Errors(t *testing.T) {
 			expectedErr: "table min must be at most 134217728",
 			features:    wasm.FeatureReferenceTypes,
 		},
+		{
+			name:        "shared",
+			input:       []byte{wasm.RefTypeFuncref, 0x3, 0, 1},
+			expectedErr: "tables cannot be shared",
+			features:    wasm.FeatureReferenceTypes | wasm.FeatureThreads,
+		},
 	}
 
 	for _, tt := range tests {
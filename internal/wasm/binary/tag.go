@@ -0,0 +1,36 @@
+package binary
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/tetratelabs/wazero/internal/leb128"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// tagAttributeException is the only attribute of a tag, which classifies exceptions.
+const tagAttributeException = 0x00
+
+// decodeTagType returns the index in wasm.Module TypeSection of the type of a tag.
+//
+// See https://github.com/WebAssembly/exception-handling/blob/main/proposals/exception-handling/Exceptions.md#tag-section
+func decodeTagType(r *bytes.Reader) (wasm.Index, error) {
+	attribute, err := r.ReadByte()
+	if err != nil {
+		return 0, fmt.Errorf("read attribute: %w", err)
+	}
+	if attribute != tagAttributeException {
+		return 0, fmt.Errorf("%w: invalid tag attribute: %#x != 0x00", ErrInvalidByte, attribute)
+	}
+	typeIndex, _, err := leb128.DecodeUint32(r)
+	if err != nil {
+		return 0, fmt.Errorf("read type index: %w", err)
+	}
+	return typeIndex, nil
+}
+
+// encodeTagType returns the tag of the given type index encoded in the Binary Format of the exception handling
+// proposal.
+func encodeTagType(typeIndex wasm.Index) []byte {
+	return append([]byte{tagAttributeException}, leb128.EncodeUint32(typeIndex)...)
+}
@@ -66,6 +66,38 @@ func (m *CallContext) FailIfClosed() error {
 	return nil
 }
 
+// Snapshot returns ModuleInstance.Snapshot, or a sys.ExitError if CloseWithExitCode was called.
+func (m *CallContext) Snapshot() ([]byte, error) {
+	if err := m.FailIfClosed(); err != nil {
+		return nil, err
+	}
+	return m.module.Snapshot()
+}
+
+// Restore calls ModuleInstance.Restore, or returns a sys.ExitError if CloseWithExitCode was called.
+func (m *CallContext) Restore(ctx context.Context, snapshot []byte) error {
+	if err := m.FailIfClosed(); err != nil {
+		return err
+	}
+	return m.module.Restore(ctx, snapshot)
+}
+
+// NewTemplate returns ModuleInstance.NewTemplate, or a sys.ExitError if CloseWithExitCode was called.
+func (m *CallContext) NewTemplate() (*Template, error) {
+	if err := m.FailIfClosed(); err != nil {
+		return nil, err
+	}
+	return m.module.NewTemplate()
+}
+
+// Reset calls ModuleInstance.Reset, or returns a sys.ExitError if CloseWithExitCode was called.
+func (m *CallContext) Reset(ctx context.Context, template *Template) error {
+	if err := m.FailIfClosed(); err != nil {
+		return err
+	}
+	return m.module.Reset(ctx, template)
+}
+
 // Name implements the same method as documented on api.Module
 func (m *CallContext) Name() string {
 	return m.module.Name
@@ -79,6 +111,23 @@ func (m *CallContext) WithMemory(memory *MemoryInstance) *CallContext {
 	return m
 }
 
+// WithMemoryOf is like WithMemory with the memory of the caller module. Engines use this to call host functions with
+// the memory of their caller.
+//
+// The result is cached on the caller, so that calls don't allocate, and is never changed, so that host functions can
+// retain it after they return, like any api.Module.
+func (m *CallContext) WithMemoryOf(caller *ModuleInstance) *CallContext {
+	memory := caller.Memory
+	if memory == nil || memory == m.memory { // only cache if it will change the effective memory
+		return m
+	}
+	if ret, ok := caller.callCtxs.Load(m.module); ok {
+		return ret.(*CallContext)
+	}
+	ret, _ := caller.callCtxs.LoadOrStore(m.module, &CallContext{module: m.module, memory: memory, ns: m.ns, Sys: m.Sys, closed: m.closed})
+	return ret.(*CallContext)
+}
+
 // String implements the same method as documented on api.Module
 func (m *CallContext) String() string {
 	return fmt.Sprintf("Module[%s]", m.Name())
@@ -122,8 +171,10 @@ func (m *CallContext) close(ctx context.Context, exitCode uint32) (c bool, err e
 }
 
 // Memory implements the same method as documented on api.Module.
+//
+// Note: This is the memory of the caller of a host function, when it differs from this module's. See WithMemoryOf
 func (m *CallContext) Memory() api.Memory {
-	return m.module.Memory
+	return m.memory
 }
 
 // ExportedMemory implements the same method as documented on api.Module.
@@ -148,6 +199,15 @@ func (m *CallContext) ExportedFunction(name string) api.Function {
 	}
 }
 
+// ExportedTable implements the same method as documented on api.Module.
+func (m *CallContext) ExportedTable(name string) api.Table {
+	exp, err := m.module.getExport(name, ExternTypeTable)
+	if err != nil {
+		return nil
+	}
+	return &exportedTable{table: exp.Table, module: m.module}
+}
+
 // importedFn implements api.Function and ensures the call context of an imported function is the importing module.
 type importedFn struct {
 	importingModule *CallContext
@@ -193,6 +253,15 @@ func (f *FunctionInstance) Call(ctx context.Context, params ...uint64) (ret []ui
 	return
 }
 
+// ExportedTag implements the same method as documented on api.Module.
+func (m *CallContext) ExportedTag(name string) api.Tag {
+	exp, err := m.module.getExport(name, ExternTypeTag)
+	if err != nil {
+		return nil
+	}
+	return exp.Tag
+}
+
 // ExportedGlobal implements the same method as documented on api.Module.
 func (m *CallContext) ExportedGlobal(name string) api.Global {
 	exp, err := m.module.getExport(name, ExternTypeGlobal)
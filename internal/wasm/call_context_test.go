@@ -59,6 +59,38 @@ func TestCallContext_WithMemory(t *testing.T) {
 	}
 }
 
+func TestCallContext_WithMemoryOf(t *testing.T) {
+	mod := &CallContext{module: &ModuleInstance{}, memory: &MemoryInstance{}, Sys: &sys.Context{}}
+
+	// The same or no memory doesn't cache anything.
+	noMemory := &ModuleInstance{}
+	require.Same(t, mod, mod.WithMemoryOf(noMemory))
+	sameMemory := &ModuleInstance{Memory: mod.memory.(*MemoryInstance)}
+	require.Same(t, mod, mod.WithMemoryOf(sameMemory))
+
+	caller := &ModuleInstance{Memory: &MemoryInstance{}}
+	mod2 := mod.WithMemoryOf(caller)
+	require.NotSame(t, mod, mod2)
+	require.Equal(t, caller.Memory, mod2.memory)
+	require.Same(t, mod.module, mod2.module)
+	require.Same(t, mod.Sys, mod2.Sys)
+
+	// The result is cached, so it is the same for the next call.
+	require.Same(t, mod2, mod.WithMemoryOf(caller))
+
+	// Another caller doesn't change the result of the first.
+	other := &ModuleInstance{Memory: &MemoryInstance{}}
+	mod3 := mod.WithMemoryOf(other)
+	require.Equal(t, other.Memory, mod3.memory)
+	require.Equal(t, caller.Memory, mod2.memory)
+
+	// Another callee has its own result.
+	otherMod := &CallContext{module: &ModuleInstance{}, memory: &MemoryInstance{}, Sys: &sys.Context{}}
+	mod4 := otherMod.WithMemoryOf(caller)
+	require.Same(t, otherMod.module, mod4.module)
+	require.Same(t, mod.module, mod2.module)
+}
+
 func TestCallContext_String(t *testing.T) {
 	s, ns := newStore()
 
@@ -82,7 +114,7 @@ func TestCallContext_String(t *testing.T) {
 
 		t.Run(tc.name, func(t *testing.T) {
 			// Ensure paths that can create the host module can see the name.
-			m, err := s.Instantiate(context.Background(), ns, &Module{}, tc.moduleName, nil, nil)
+			m, err := s.Instantiate(context.Background(), ns, &Module{}, tc.moduleName, nil, nil, nil)
 			defer m.Close(testCtx) //nolint
 
 			require.NoError(t, err)
@@ -121,7 +153,7 @@ func TestCallContext_Close(t *testing.T) {
 		t.Run(fmt.Sprintf("%s calls ns.CloseWithExitCode(module.name))", tc.name), func(t *testing.T) {
 			for _, ctx := range []context.Context{nil, testCtx} { // Ensure it doesn't crash on nil!
 				moduleName := t.Name()
-				m, err := s.Instantiate(ctx, ns, &Module{}, moduleName, nil, nil)
+				m, err := s.Instantiate(ctx, ns, &Module{}, moduleName, nil, nil, nil)
 				require.NoError(t, err)
 
 				// We use side effects to see if Close called ns.CloseWithExitCode (without repeating store_test.go).
@@ -146,7 +178,7 @@ func TestCallContext_Close(t *testing.T) {
 		sysCtx := sys.DefaultContext()
 		sysCtx.FS().OpenFile(&sys.FileEntry{Path: "."})
 
-		m, err := s.Instantiate(context.Background(), ns, &Module{}, t.Name(), sysCtx, nil)
+		m, err := s.Instantiate(context.Background(), ns, &Module{}, t.Name(), sysCtx, nil, nil)
 		require.NoError(t, err)
 
 		// We use side effects to determine if Close in fact called Context.Close (without repeating sys_test.go).
@@ -171,7 +203,7 @@ func TestCallContext_Close(t *testing.T) {
 		sysCtx := sys.DefaultContext()
 		sysCtx.FS().OpenFile(&sys.FileEntry{Path: ".", File: &testFile{errors.New("error closing")}})
 
-		m, err := s.Instantiate(context.Background(), ns, &Module{}, t.Name(), sysCtx, nil)
+		m, err := s.Instantiate(context.Background(), ns, &Module{}, t.Name(), sysCtx, nil, nil)
 		require.NoError(t, err)
 
 		require.EqualError(t, m.Close(testCtx), "error closing")
@@ -0,0 +1,293 @@
+package wasm
+
+import (
+	"sort"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+// compile-time checks to ensure the definitions implement the corresponding api interfaces.
+var (
+	_ api.FunctionDefinition = &FunctionDefinition{}
+	_ api.FunctionDefinition = &FunctionInstance{}
+	_ api.MemoryDefinition   = &MemoryDefinition{}
+	_ api.GlobalDefinition   = &GlobalDefinition{}
+)
+
+// definition includes the fields common to api.FunctionDefinition, api.MemoryDefinition and api.GlobalDefinition.
+type definition struct {
+	moduleName string
+	index      Index
+	// importDesc is nil when this isn't imported
+	importDesc *Import
+	// exportNames is non-nil when this is exported.
+	exportNames []string
+}
+
+// ModuleName implements the same method as documented on api.FunctionDefinition.
+func (d *definition) ModuleName() string {
+	return d.moduleName
+}
+
+// Index implements the same method as documented on api.FunctionDefinition.
+func (d *definition) Index() uint32 {
+	return d.index
+}
+
+// Import implements the same method as documented on api.FunctionDefinition.
+func (d *definition) Import() (moduleName, name string, isImport bool) {
+	if imp := d.importDesc; imp != nil {
+		moduleName, name, isImport = imp.Module, imp.Name, true
+	}
+	return
+}
+
+// ExportNames implements the same method as documented on api.FunctionDefinition.
+func (d *definition) ExportNames() []string {
+	return d.exportNames
+}
+
+// FunctionDefinition implements api.FunctionDefinition
+type FunctionDefinition struct {
+	definition
+	name       string
+	funcType   *FunctionType
+	paramNames []string
+}
+
+// Name implements the same method as documented on api.FunctionDefinition.
+func (f *FunctionDefinition) Name() string {
+	return f.name
+}
+
+// ParamTypes implements the same method as documented on api.FunctionDefinition.
+func (f *FunctionDefinition) ParamTypes() []api.ValueType {
+	return f.funcType.Params
+}
+
+// ParamNames implements the same method as documented on api.FunctionDefinition.
+func (f *FunctionDefinition) ParamNames() []string {
+	return f.paramNames
+}
+
+// ResultTypes implements the same method as documented on api.FunctionDefinition.
+func (f *FunctionDefinition) ResultTypes() []api.ValueType {
+	return f.funcType.Results
+}
+
+// MemoryDefinition implements api.MemoryDefinition
+type MemoryDefinition struct {
+	definition
+	memory *Memory
+}
+
+// Min implements the same method as documented on api.MemoryDefinition.
+func (m *MemoryDefinition) Min() uint32 {
+	return m.memory.Min
+}
+
+// Max implements the same method as documented on api.MemoryDefinition.
+func (m *MemoryDefinition) Max() (uint32, bool) {
+	return m.memory.Max, m.memory.IsMaxEncoded
+}
+
+// GlobalDefinition implements api.GlobalDefinition
+type GlobalDefinition struct {
+	definition
+	globalType *GlobalType
+}
+
+// Type implements the same method as documented on api.GlobalDefinition.
+func (g *GlobalDefinition) Type() api.ValueType {
+	return g.globalType.ValType
+}
+
+// Mutable implements the same method as documented on api.GlobalDefinition.
+func (g *GlobalDefinition) Mutable() bool {
+	return g.globalType.Mutable
+}
+
+// ImportedFunctions returns the definitions of all imported functions, in the order of the function index namespace.
+func (m *Module) ImportedFunctions() (ret []api.FunctionDefinition) {
+	for _, d := range m.functionDefinitions() {
+		if d.importDesc == nil {
+			break // imports are first
+		}
+		ret = append(ret, d)
+	}
+	return
+}
+
+// DefinedFunctions returns the definitions of all functions defined in this module, index-correlated with CodeSection.
+func (m *Module) DefinedFunctions() (ret []api.FunctionDefinition) {
+	for _, d := range m.functionDefinitions() {
+		if d.importDesc == nil { // imports are first
+			ret = append(ret, d)
+		}
+	}
+	return
+}
+
+// ExportedFunctions returns the definitions of all exported functions, keyed by export name.
+func (m *Module) ExportedFunctions() map[string]api.FunctionDefinition {
+	ret := map[string]api.FunctionDefinition{}
+	for _, d := range m.functionDefinitions() {
+		for _, name := range d.exportNames {
+			ret[name] = d
+		}
+	}
+	return ret
+}
+
+// ExportedMemories returns the definitions of all exported memories, keyed by export name.
+func (m *Module) ExportedMemories() map[string]api.MemoryDefinition {
+	ret := map[string]api.MemoryDefinition{}
+	for _, d := range m.memoryDefinitions() {
+		for _, name := range d.exportNames {
+			ret[name] = d
+		}
+	}
+	return ret
+}
+
+// ExportedGlobals returns the definitions of all exported globals, keyed by export name.
+func (m *Module) ExportedGlobals() map[string]api.GlobalDefinition {
+	ret := map[string]api.GlobalDefinition{}
+	for _, d := range m.globalDefinitions() {
+		for _, name := range d.exportNames {
+			ret[name] = d
+		}
+	}
+	return ret
+}
+
+// functionDefinitions returns the definitions of all functions in the function index namespace, imports first.
+//
+// Note: These are built on first use, as a module can have many functions, and are shared by all callers.
+func (m *Module) functionDefinitions() []*FunctionDefinition {
+	m.functionDefinitionsOnce.Do(func() {
+		m.functionDefinitionsCache = m.buildFunctionDefinitions()
+	})
+	return m.functionDefinitionsCache
+}
+
+// buildFunctionDefinitions is functionDefinitions, without caching.
+func (m *Module) buildFunctionDefinitions() (ret []*FunctionDefinition) {
+	var functionNames NameMap
+	var localNames IndirectNameMap
+	if m.NameSection != nil {
+		functionNames = m.NameSection.FunctionNames
+		localNames = m.NameSection.LocalNames
+	}
+
+	ret = make([]*FunctionDefinition, 0, int(m.ImportFuncCount())+len(m.FunctionSection))
+	for _, imp := range m.ImportSection {
+		if imp.Type != ExternTypeFunc {
+			continue
+		}
+		d := &FunctionDefinition{funcType: m.TypeSection[imp.DescFunc]}
+		d.importDesc = imp
+		ret = append(ret, d)
+	}
+	for _, typeIndex := range m.FunctionSection {
+		ret = append(ret, &FunctionDefinition{funcType: m.TypeSection[typeIndex]})
+	}
+
+	exportNames := m.exportNamesByIndex(ExternTypeFunc)
+	for i, d := range ret {
+		d.index = Index(i)
+		d.moduleName = m.moduleName()
+		d.exportNames = exportNames[d.index]
+		d.name = functionNames.name(d.index)
+		if d.importDesc == nil { // imported functions don't have locals
+			d.paramNames = paramNames(localNames.find(d.index), d.index, len(d.funcType.Params))
+		}
+	}
+	return
+}
+
+// memoryDefinitions returns the definitions of all memories in the memory index namespace, imports first.
+func (m *Module) memoryDefinitions() (ret []*MemoryDefinition) {
+	for _, imp := range m.ImportSection {
+		if imp.Type != ExternTypeMemory {
+			continue
+		}
+		d := &MemoryDefinition{memory: imp.DescMem}
+		d.importDesc = imp
+		ret = append(ret, d)
+	}
+	for _, mem := range m.MemorySection {
+		ret = append(ret, &MemoryDefinition{memory: mem})
+	}
+
+	exportNames := m.exportNamesByIndex(ExternTypeMemory)
+	for i, d := range ret {
+		d.index = Index(i)
+		d.moduleName = m.moduleName()
+		d.exportNames = exportNames[d.index]
+	}
+	return
+}
+
+// globalDefinitions returns the definitions of all globals in the global index namespace, imports first.
+func (m *Module) globalDefinitions() (ret []*GlobalDefinition) {
+	for _, imp := range m.ImportSection {
+		if imp.Type != ExternTypeGlobal {
+			continue
+		}
+		d := &GlobalDefinition{globalType: imp.DescGlobal}
+		d.importDesc = imp
+		ret = append(ret, d)
+	}
+	for _, g := range m.GlobalSection {
+		ret = append(ret, &GlobalDefinition{globalType: g.Type})
+	}
+
+	exportNames := m.exportNamesByIndex(ExternTypeGlobal)
+	for i, d := range ret {
+		d.index = Index(i)
+		d.moduleName = m.moduleName()
+		d.exportNames = exportNames[d.index]
+	}
+	return
+}
+
+// moduleName returns the possibly empty name of this module from the name section.
+func (m *Module) moduleName() string {
+	if m.NameSection != nil {
+		return m.NameSection.ModuleName
+	}
+	return ""
+}
+
+// exportNamesByIndex returns the names each definition of the given type is exported with, keyed by its index.
+func (m *Module) exportNamesByIndex(et ExternType) map[Index][]string {
+	ret := map[Index][]string{}
+	for _, e := range m.ExportSection {
+		if e.Type == et {
+			ret[e.Index] = append(ret[e.Index], e.Name)
+		}
+	}
+	return ret
+}
+
+// name returns the name associated with the given index, or empty if there is none.
+//
+// Note: This uses a binary search, as the name section is ordered by index.
+func (n NameMap) name(index Index) string {
+	if i := sort.Search(len(n), func(i int) bool { return n[i].Index >= index }); i < len(n) && n[i].Index == index {
+		return n[i].Name
+	}
+	return ""
+}
+
+// find returns the association of the given index, as an IndirectNameMap of at most one element, so that it can be
+// passed to functions which search one.
+//
+// Note: This uses a binary search, as the name section is ordered by index.
+func (n IndirectNameMap) find(index Index) IndirectNameMap {
+	if i := sort.Search(len(n), func(i int) bool { return n[i].Index >= index }); i < len(n) && n[i].Index == index {
+		return n[i : i+1]
+	}
+	return nil
+}
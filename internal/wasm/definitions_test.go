@@ -0,0 +1,161 @@
+package wasm
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestModule_Definitions(t *testing.T) {
+	i32_i32 := &FunctionType{Params: []ValueType{i32}, Results: []ValueType{i32}}
+	v_v := &FunctionType{}
+	m := &Module{
+		TypeSection: []*FunctionType{v_v, i32_i32},
+		ImportSection: []*Import{
+			{Type: ExternTypeFunc, Module: "env", Name: "log", DescFunc: 1},
+			{Type: ExternTypeGlobal, Module: "env", Name: "offset", DescGlobal: &GlobalType{ValType: i32}},
+			{Type: ExternTypeFunc, Module: "env", Name: "abort", DescFunc: 0},
+		},
+		FunctionSection: []Index{1, 0},
+		CodeSection:     []*Code{{Body: []byte{OpcodeLocalGet, 0, OpcodeEnd}}, {Body: []byte{OpcodeEnd}}},
+		MemorySection:   []*Memory{{Min: 1, Cap: 1, Max: 2, IsMaxEncoded: true}},
+		GlobalSection: []*Global{
+			{Type: &GlobalType{ValType: i64, Mutable: true}, Init: &ConstantExpression{Opcode: OpcodeI64Const, Data: []byte{0}}},
+		},
+		ExportSection: []*Export{
+			{Type: ExternTypeFunc, Name: "handle", Index: 2},
+			{Type: ExternTypeFunc, Name: "handle2", Index: 2},
+			{Type: ExternTypeFunc, Name: "nop", Index: 3},
+			{Type: ExternTypeFunc, Name: "reexport", Index: 0},
+			{Type: ExternTypeMemory, Name: "memory", Index: 0},
+			{Type: ExternTypeGlobal, Name: "offset", Index: 0},
+			{Type: ExternTypeGlobal, Name: "counter", Index: 1},
+		},
+		NameSection: &NameSection{
+			ModuleName: "test",
+			FunctionNames: NameMap{
+				{Index: 0, Name: "log"},
+				{Index: 2, Name: "handle"},
+			},
+			LocalNames: IndirectNameMap{
+				{Index: 2, NameMap: NameMap{{Index: 0, Name: "x"}}},
+			},
+		},
+	}
+
+	t.Run("ImportedFunctions", func(t *testing.T) {
+		imported := m.ImportedFunctions()
+		require.Equal(t, 2, len(imported))
+
+		log := imported[0]
+		require.Equal(t, "test", log.ModuleName())
+		require.Equal(t, uint32(0), log.Index())
+		moduleName, name, isImport := log.Import()
+		require.Equal(t, []interface{}{"env", "log", true}, []interface{}{moduleName, name, isImport})
+		require.Equal(t, "log", log.Name())
+		require.Equal(t, []string{"reexport"}, log.ExportNames())
+		require.Equal(t, []api.ValueType{i32}, log.ParamTypes())
+		require.Nil(t, log.ParamNames())
+		require.Equal(t, []api.ValueType{i32}, log.ResultTypes())
+
+		abort := imported[1]
+		require.Equal(t, uint32(1), abort.Index())
+		moduleName, name, isImport = abort.Import()
+		require.Equal(t, []interface{}{"env", "abort", true}, []interface{}{moduleName, name, isImport})
+		require.Equal(t, "", abort.Name())
+		require.Nil(t, abort.ExportNames())
+	})
+
+	t.Run("ExportedFunctions", func(t *testing.T) {
+		exported := m.ExportedFunctions()
+		require.Equal(t, 4, len(exported))
+
+		handle := exported["handle"]
+		require.Equal(t, handle, exported["handle2"])
+		require.Equal(t, uint32(2), handle.Index())
+		_, _, isImport := handle.Import()
+		require.False(t, isImport)
+		require.Equal(t, "handle", handle.Name())
+		require.Equal(t, []string{"handle", "handle2"}, handle.ExportNames())
+		require.Equal(t, []api.ValueType{i32}, handle.ParamTypes())
+		require.Equal(t, []string{"x"}, handle.ParamNames())
+		require.Equal(t, []api.ValueType{i32}, handle.ResultTypes())
+
+		nop := exported["nop"]
+		require.Equal(t, uint32(3), nop.Index())
+		require.Equal(t, "", nop.Name())
+		require.Equal(t, 0, len(nop.ParamTypes()))
+		require.Equal(t, 0, len(nop.ResultTypes()))
+
+		require.Equal(t, uint32(0), exported["reexport"].Index())
+	})
+
+	t.Run("ExportedMemories", func(t *testing.T) {
+		exported := m.ExportedMemories()
+		require.Equal(t, 1, len(exported))
+
+		memory := exported["memory"]
+		require.Equal(t, uint32(0), memory.Index())
+		require.Equal(t, uint32(1), memory.Min())
+		max, ok := memory.Max()
+		require.True(t, ok)
+		require.Equal(t, uint32(2), max)
+	})
+
+	t.Run("ExportedGlobals", func(t *testing.T) {
+		exported := m.ExportedGlobals()
+		require.Equal(t, 2, len(exported))
+
+		offset := exported["offset"]
+		moduleName, name, isImport := offset.Import()
+		require.Equal(t, []interface{}{"env", "offset", true}, []interface{}{moduleName, name, isImport})
+		require.Equal(t, i32, offset.Type())
+		require.False(t, offset.Mutable())
+
+		counter := exported["counter"]
+		require.Equal(t, uint32(1), counter.Index())
+		_, _, isImport = counter.Import()
+		require.False(t, isImport)
+		require.Equal(t, i64, counter.Type())
+		require.True(t, counter.Mutable())
+	})
+
+	t.Run("cached", func(t *testing.T) {
+		defined := m.DefinedFunctions()
+		require.Equal(t, 2, len(defined))
+		require.Same(t, defined[0], m.DefinedFunctions()[0])
+		require.Same(t, defined[0], m.ExportedFunctions()["handle"])
+		require.Same(t, m.ImportedFunctions()[0], m.ExportedFunctions()["reexport"])
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		empty := &Module{}
+		require.Nil(t, empty.ImportedFunctions())
+		require.Equal(t, 0, len(empty.ExportedFunctions()))
+		require.Equal(t, 0, len(empty.ExportedMemories()))
+		require.Equal(t, 0, len(empty.ExportedGlobals()))
+	})
+}
+
+func TestNameMap_name(t *testing.T) {
+	names := NameMap{{Index: 1, Name: "one"}, {Index: 3, Name: "three"}, {Index: 4, Name: "four"}}
+	for _, tc := range []struct {
+		index    Index
+		expected string
+	}{{0, ""}, {1, "one"}, {2, ""}, {3, "three"}, {4, "four"}, {5, ""}} {
+		require.Equal(t, tc.expected, names.name(tc.index))
+	}
+	require.Equal(t, "", NameMap(nil).name(0))
+}
+
+func TestIndirectNameMap_find(t *testing.T) {
+	one := &NameMapAssoc{Index: 1, NameMap: NameMap{{Index: 0, Name: "x"}}}
+	three := &NameMapAssoc{Index: 3, NameMap: NameMap{{Index: 0, Name: "y"}}}
+	names := IndirectNameMap{one, three}
+	require.Nil(t, names.find(0))
+	require.Equal(t, IndirectNameMap{one}, names.find(1))
+	require.Nil(t, names.find(2))
+	require.Equal(t, IndirectNameMap{three}, names.find(3))
+	require.Nil(t, names.find(4))
+}
@@ -3,8 +3,25 @@ package wasm
 import (
 	"context"
 	"errors"
+
+	"github.com/tetratelabs/wazero/internal/compilationcache"
 )
 
+// EngineConfig configures an Engine, besides the Features it enables. The zero value disables everything optional.
+type EngineConfig struct {
+	// EnsureTermination is true when calls must stop when their context.Context is done.
+	EnsureTermination bool
+
+	// MeterFuel is true when calls consume fuel and stop when it runs out.
+	MeterFuel bool
+
+	// Coverage is true when each basic block counts how many times it ran.
+	Coverage bool
+
+	// Cache persists compiled modules when non-nil. Engines which don't compile to native code ignore it.
+	Cache compilationcache.Cache
+}
+
 // Engine is a Store-scoped mechanism to compile functions declared or imported by a module.
 // This is a top-level type implemented by an interpreter or compiler.
 type Engine interface {
@@ -19,6 +36,13 @@ type Engine interface {
 	// module instances have outstanding calls.
 	DeleteCompiledModule(module *Module)
 
+	// Coverage returns the coverage of the functions declared in the module, index-correlated with
+	// Module.CodeSection, or nil if coverage is disabled or the module wasn't compiled.
+	//
+	// Note: Counts are shared by all instances of the module, and incremented while they run. Increments made by
+	// concurrent calls may be lost in compiled code, so counts are best-effort.
+	Coverage(module *Module) []*FunctionCoverage
+
 	// NewModuleEngine compiles down the function instances in a module, and returns ModuleEngine for the module.
 	//
 	// * name is the name the module was instantiated with used for error handling.
@@ -39,6 +63,22 @@ type Engine interface {
 	) (ModuleEngine, error)
 }
 
+// FunctionCoverage records how many times each basic block of a function ran.
+//
+// See wazeroir.OperationCoverBlock
+type FunctionCoverage struct {
+	// BlockOffsets are the offsets in Code.Body of the first instruction of each basic block. The first block is the
+	// function entry, so its count is the number of calls.
+	BlockOffsets []uint64
+	// Counts are index-correlated with BlockOffsets. Read them with atomic.LoadUint64, as they change while calls run.
+	Counts []uint64
+}
+
+// NewFunctionCoverage returns FunctionCoverage with a zero count for each of the given basic blocks.
+func NewFunctionCoverage(blockOffsets []uint64) *FunctionCoverage {
+	return &FunctionCoverage{BlockOffsets: blockOffsets, Counts: make([]uint64, len(blockOffsets))}
+}
+
 // ModuleEngine implements function calls for a given module.
 type ModuleEngine interface {
 	// Name returns the name of the module this engine was compiled for.
@@ -51,6 +91,10 @@ type ModuleEngine interface {
 	// corresponding to the given `indexes`.
 	CreateFuncElementInstance(indexes []*Index) *ElementInstance
 
+	// LookupFunction returns the function instance the given non-null funcref Reference points to. The Reference must
+	// have been created by this Engine, but can point to a function of any module instantiated with it.
+	LookupFunction(ref Reference) *FunctionInstance
+
 	// InitializeFuncrefGlobals initializes the globals of Funcref type as the opaque pointer values of engine specific compiled functions.
 	InitializeFuncrefGlobals(globals []*GlobalInstance)
 }
@@ -102,6 +102,62 @@ const (
 	//
 	// See https://github.com/WebAssembly/spec/blob/main/proposals/simd/SIMD.md
 	FeatureSIMD
+
+	// FeatureThreads decides if parsing should succeed on the following:
+	//
+	// * Memory.Shared, encoded in the limits of a memory.
+	// * Instructions prefixed by OpcodeAtomicPrefix.
+	//
+	// See https://github.com/WebAssembly/threads/blob/main/proposals/threads/Overview.md
+	FeatureThreads
+
+	// FeatureTailCall decides if parsing should succeed on the following instructions:
+	//
+	// * [ OpcodeReturnCall, OpcodeReturnCallIndirect ]
+	//
+	// See https://github.com/WebAssembly/tail-call/blob/main/proposals/tail-call/Overview.md
+	FeatureTailCall
+
+	// FeatureExceptionHandling decides if parsing should succeed on the following instructions:
+	//
+	// * [ OpcodeTry, OpcodeCatch, OpcodeCatchAll, OpcodeDelegate, OpcodeThrow, OpcodeRethrow ]
+	//
+	// Also, if the parsing should succeed with the tag section (SectionIDTag) and the import and export of tags
+	// (ExternTypeTag).
+	//
+	// See https://github.com/WebAssembly/exception-handling/blob/main/proposals/exception-handling/Exceptions.md
+	FeatureExceptionHandling
+
+	// FeatureMultiMemory decides if parsing should succeed on the following:
+	//
+	// * More than one memory, imported or defined in the module.
+	// * A memory index in the immediates of memory instructions and in active data segments.
+	//
+	// See https://github.com/WebAssembly/multi-memory/blob/main/proposals/multi-memory/Overview.md
+	FeatureMultiMemory
+
+	// FeatureMemory64 decides if parsing should succeed on the following:
+	//
+	// * Memories with 64-bit indices, which are addressed with i64 values instead of i32.
+	// * Offsets of the immediates of memory instructions over math.MaxUint32, accessing such memories.
+	//
+	// See https://github.com/WebAssembly/memory64/blob/main/proposals/memory64/Overview.md
+	FeatureMemory64
+
+	// FeatureExtendedConst decides if parsing should succeed on the following:
+	//
+	// * Constant expressions of more than one instruction, which combine i32.const, i64.const and global.get with
+	//   i32.add, i32.sub, i32.mul, i64.add, i64.sub and i64.mul.
+	//
+	// See https://github.com/WebAssembly/extended-const/blob/main/proposals/extended-const/Overview.md
+	FeatureExtendedConst
+
+	// FeatureRelaxedSIMD decides if parsing should succeed on the following, which also requires FeatureSIMD:
+	//
+	// * Relaxed vector instructions, which are prefixed by OpcodeVecPrefix, such as OpcodeVecF32x4RelaxedMadd.
+	//
+	// See https://github.com/WebAssembly/relaxed-simd/blob/main/proposals/relaxed-simd/Overview.md
+	FeatureRelaxedSIMD
 )
 
 // Set assigns the value for the given feature.
@@ -165,6 +221,27 @@ func featureName(f Features) string {
 	case FeatureSIMD:
 		// match https://github.com/WebAssembly/spec/blob/main/proposals/simd/SIMD.md
 		return "simd"
+	case FeatureThreads:
+		// match https://github.com/WebAssembly/threads/blob/main/proposals/threads/Overview.md
+		return "threads"
+	case FeatureTailCall:
+		// match https://github.com/WebAssembly/tail-call/blob/main/proposals/tail-call/Overview.md
+		return "tail-call"
+	case FeatureExceptionHandling:
+		// match https://github.com/WebAssembly/exception-handling/blob/main/proposals/exception-handling/Exceptions.md
+		return "exception-handling"
+	case FeatureMultiMemory:
+		// match https://github.com/WebAssembly/multi-memory/blob/main/proposals/multi-memory/Overview.md
+		return "multi-memory"
+	case FeatureMemory64:
+		// match https://github.com/WebAssembly/memory64/blob/main/proposals/memory64/Overview.md
+		return "memory64"
+	case FeatureExtendedConst:
+		// match https://github.com/WebAssembly/extended-const/blob/main/proposals/extended-const/Overview.md
+		return "extended-const"
+	case FeatureRelaxedSIMD:
+		// match https://github.com/WebAssembly/relaxed-simd/blob/main/proposals/relaxed-simd/Overview.md
+		return "relaxed-simd"
 	}
 	return ""
 }
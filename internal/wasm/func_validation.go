@@ -20,33 +20,61 @@ const maximumValuesOnStack = 1 << 27
 // * idx is the index in the FunctionSection
 // * functions are the function index namespace, which is prefixed by imports. The value is the TypeSection index.
 // * globals are the global index namespace, which is prefixed by imports.
-// * memory is the potentially imported memory and can be nil.
+// * memories are the memory index namespace, which is prefixed by imports.
 // * table is the potentially imported table and can be nil.
 // * declaredFunctionIndexes is the set of function indexes declared by declarative element segments which can be acceed by OpcodeRefFunc instruction.
 //
 // Returns an error if the instruction sequence is not valid,
 // or potentially it can exceed the maximum number of values on the stack.
 func (m *Module) validateFunction(enabledFeatures Features, idx Index, functions []Index,
-	globals []*GlobalType, memory *Memory, tables []*Table, declaredFunctionIndexes map[Index]struct{}) error {
-	return m.validateFunctionWithMaxStackValues(enabledFeatures, idx, functions, globals, memory, tables, maximumValuesOnStack, declaredFunctionIndexes)
+	globals []*GlobalType, memories []*Memory, tables []*Table, declaredFunctionIndexes map[Index]struct{}) error {
+	return m.validateFunctionWithMaxStackValues(enabledFeatures, idx, functions, globals, memories, tables, maximumValuesOnStack, declaredFunctionIndexes)
 }
 
-func readMemArg(pc uint64, body []byte) (align, offset uint32, read uint64, err error) {
-	align, num, err := leb128.DecodeUint32(bytes.NewReader(body[pc:]))
+// readMemArg reads the "memarg" immediate of the memory instruction with the given name, which must access one of the
+// memories. With FeatureMultiMemory, a memory index follows the alignment when it has MemoryArgMemoryIndexFlag.
+func readMemArg(pc uint64, body []byte, enabledFeatures Features, memories []*Memory, name string) (align uint32, addressType ValueType, read uint64, err error) {
+	r := bytes.NewReader(body[pc:])
+	align, num, err := leb128.DecodeUint32(r)
 	if err != nil {
 		err = fmt.Errorf("read memory align: %v", err)
 		return
 	}
 	read += num
 
-	offset, num, err = leb128.DecodeUint32(bytes.NewReader(body[pc+num:]))
+	var memoryIndex uint32
+	if align&MemoryArgMemoryIndexFlag != 0 && enabledFeatures.Get(FeatureMultiMemory) {
+		align &^= MemoryArgMemoryIndexFlag
+		if memoryIndex, num, err = leb128.DecodeUint32(r); err != nil {
+			err = fmt.Errorf("read memory index: %v", err)
+			return
+		}
+		read += num
+	}
+	if align >= 32 {
+		// Shifting by this overflows when checking the alignment, which is at most 4 for any instruction.
+		err = fmt.Errorf("invalid memory alignment")
+		return
+	}
+	if memoryIndex >= uint32(len(memories)) {
+		err = fmt.Errorf("memory must exist for %s", name)
+		return
+	}
+
+	// The offset is a u64 for memories with 64-bit indices, which are addressed with i64 values.
+	addressType = memories[memoryIndex].AddressType()
+	if addressType == ValueTypeI64 {
+		_, num, err = leb128.DecodeUint64(r)
+	} else {
+		_, num, err = leb128.DecodeUint32(r)
+	}
 	if err != nil {
 		err = fmt.Errorf("read memory offset: %v", err)
 		return
 	}
 
 	read += num
-	return align, offset, read, nil
+	return align, addressType, read, nil
 }
 
 // validateFunctionWithMaxStackValues is like validateFunction, but allows overriding maxStackValues for testing.
@@ -57,7 +85,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 	idx Index,
 	functions []Index,
 	globals []*GlobalType,
-	memory *Memory,
+	memories []*Memory,
 	tables []*Table,
 	maxStackValues int,
 	declaredFunctionIndexes map[Index]struct{},
@@ -71,17 +99,19 @@ func (m *Module) validateFunctionWithMaxStackValues(
 	controlBlockStack := []*controlBlock{{blockType: functionType}}
 	// Create the valueTypeStack to track the state of Wasm value stacks at anypoint of execution.
 	valueTypeStack := &valueTypeStack{}
+	// tags are the types of the tag index namespace, initialized by the first instruction which uses it.
+	var tags []Index
 
 	// Now start walking through all the instructions in the body while tracking
 	// control blocks and value types to check the validity of all instructions.
 	for pc := uint64(0); pc < uint64(len(body)); pc++ {
 		op := body[pc]
 		if OpcodeI32Load <= op && op <= OpcodeI64Store32 {
-			if memory == nil {
+			if len(memories) == 0 {
 				return fmt.Errorf("unknown memory access")
 			}
 			pc++
-			align, _, read, err := readMemArg(pc, body)
+			align, addressType, read, err := readMemArg(pc, body, enabledFeatures, memories, InstructionName(op))
 			if err != nil {
 				return err
 			}
@@ -91,7 +121,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if 1<<align > 32/8 {
 					return fmt.Errorf("invalid memory alignment")
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 				valueTypeStack.push(ValueTypeI32)
@@ -99,7 +129,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if 1<<align > 32/8 {
 					return fmt.Errorf("invalid memory alignment")
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 				valueTypeStack.push(ValueTypeF32)
@@ -110,7 +140,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
 					return err
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 			case OpcodeF32Store:
@@ -120,14 +150,14 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeF32); err != nil {
 					return err
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 			case OpcodeI64Load:
 				if 1<<align > 64/8 {
 					return fmt.Errorf("invalid memory alignment")
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 				valueTypeStack.push(ValueTypeI64)
@@ -135,7 +165,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if 1<<align > 64/8 {
 					return fmt.Errorf("invalid memory alignment")
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 				valueTypeStack.push(ValueTypeF64)
@@ -146,7 +176,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI64); err != nil {
 					return err
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 			case OpcodeF64Store:
@@ -156,14 +186,14 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeF64); err != nil {
 					return err
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 			case OpcodeI32Load8S:
 				if 1<<align > 1 {
 					return fmt.Errorf("invalid memory alignment")
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 				valueTypeStack.push(ValueTypeI32)
@@ -171,7 +201,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if 1<<align > 1 {
 					return fmt.Errorf("invalid memory alignment")
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 				valueTypeStack.push(ValueTypeI32)
@@ -179,7 +209,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if 1<<align > 1 {
 					return fmt.Errorf("invalid memory alignment")
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 				valueTypeStack.push(ValueTypeI64)
@@ -190,7 +220,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
 					return err
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 			case OpcodeI64Store8:
@@ -200,14 +230,14 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI64); err != nil {
 					return err
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 			case OpcodeI32Load16S, OpcodeI32Load16U:
 				if 1<<align > 16/8 {
 					return fmt.Errorf("invalid memory alignment")
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 				valueTypeStack.push(ValueTypeI32)
@@ -215,7 +245,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if 1<<align > 16/8 {
 					return fmt.Errorf("invalid memory alignment")
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 				valueTypeStack.push(ValueTypeI64)
@@ -226,7 +256,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
 					return err
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 			case OpcodeI64Store16:
@@ -236,14 +266,14 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI64); err != nil {
 					return err
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 			case OpcodeI64Load32S, OpcodeI64Load32U:
 				if 1<<align > 32/8 {
 					return fmt.Errorf("invalid memory alignment")
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 				valueTypeStack.push(ValueTypeI64)
@@ -254,12 +284,12 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI64); err != nil {
 					return err
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 			}
 		} else if OpcodeMemorySize <= op && op <= OpcodeMemoryGrow {
-			if memory == nil {
+			if len(memories) == 0 {
 				return fmt.Errorf("unknown memory access")
 			}
 			pc++
@@ -267,17 +297,21 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			if err != nil {
 				return fmt.Errorf("read immediate: %v", err)
 			}
-			if val != 0 || num != 1 {
+			if !enabledFeatures.Get(FeatureMultiMemory) && (val != 0 || num != 1) {
 				return fmt.Errorf("memory instruction reserved bytes not zero with 1 byte")
+			} else if val >= uint32(len(memories)) {
+				return fmt.Errorf("memory must exist for %s", InstructionName(op))
 			}
+			// Pages have the type of the addresses of the memory, which is i64 if it has 64-bit indices.
+			addressType := memories[val].AddressType()
 			switch Opcode(op) {
 			case OpcodeMemoryGrow:
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
-				valueTypeStack.push(ValueTypeI32)
+				valueTypeStack.push(addressType)
 			case OpcodeMemorySize:
-				valueTypeStack.push(ValueTypeI32)
+				valueTypeStack.push(addressType)
 			}
 			pc += num - 1
 		} else if OpcodeI32Const <= op && op <= OpcodeF64Const {
@@ -505,7 +539,12 @@ func (m *Module) validateFunctionWithMaxStackValues(
 
 			// br_table instruction is stack-polymorphic.
 			valueTypeStack.unreachable()
-		} else if op == OpcodeCall {
+		} else if op == OpcodeCall || op == OpcodeReturnCall {
+			if op == OpcodeReturnCall {
+				if err := enabledFeatures.Require(FeatureTailCall); err != nil {
+					return fmt.Errorf("%s invalid as %v", OpcodeReturnCallName, err)
+				}
+			}
 			pc++
 			index, num, err := leb128.DecodeUint32(bytes.NewReader(body[pc:]))
 			if err != nil {
@@ -518,13 +557,26 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			funcType := types[functions[index]]
 			for i := 0; i < len(funcType.Params); i++ {
 				if err := valueTypeStack.popAndVerifyType(funcType.Params[len(funcType.Params)-1-i]); err != nil {
-					return fmt.Errorf("type mismatch on %s operation param type: %v", OpcodeCallName, err)
+					return fmt.Errorf("type mismatch on %s operation param type: %v", InstructionName(op), err)
 				}
 			}
-			for _, exp := range funcType.Results {
-				valueTypeStack.push(exp)
+			if op == OpcodeReturnCall {
+				if err := validateTailCallResults(op, funcType, functionType); err != nil {
+					return err
+				}
+				// return_call instruction is stack-polymorphic.
+				valueTypeStack.unreachable()
+			} else {
+				for _, exp := range funcType.Results {
+					valueTypeStack.push(exp)
+				}
+			}
+		} else if op == OpcodeCallIndirect || op == OpcodeReturnCallIndirect {
+			if op == OpcodeReturnCallIndirect {
+				if err := enabledFeatures.Require(FeatureTailCall); err != nil {
+					return fmt.Errorf("%s invalid as %v", OpcodeReturnCallIndirectName, err)
+				}
 			}
-		} else if op == OpcodeCallIndirect {
 			pc++
 			typeIndex, num, err := leb128.DecodeUint32(bytes.NewReader(body[pc:]))
 			if err != nil {
@@ -564,11 +616,19 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			funcType := types[typeIndex]
 			for i := 0; i < len(funcType.Params); i++ {
 				if err = valueTypeStack.popAndVerifyType(funcType.Params[len(funcType.Params)-1-i]); err != nil {
-					return fmt.Errorf("type mismatch on %s operation input type", OpcodeCallIndirectName)
+					return fmt.Errorf("type mismatch on %s operation input type", InstructionName(op))
 				}
 			}
-			for _, exp := range funcType.Results {
-				valueTypeStack.push(exp)
+			if op == OpcodeReturnCallIndirect {
+				if err := validateTailCallResults(op, funcType, functionType); err != nil {
+					return err
+				}
+				// return_call_indirect instruction is stack-polymorphic.
+				valueTypeStack.unreachable()
+			} else {
+				for _, exp := range funcType.Results {
+					valueTypeStack.push(exp)
+				}
 			}
 		} else if OpcodeI32Eqz <= op && op <= OpcodeI64Extend32S {
 			switch op {
@@ -893,7 +953,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 					}
 					pc += num - 1
 				case OpcodeMiscMemoryInit, OpcodeMiscMemoryCopy, OpcodeMiscMemoryFill:
-					if memory == nil {
+					if len(memories) == 0 {
 						return fmt.Errorf("memory must exist for %s", MiscInstructionName(miscOpcode))
 					}
 					params = []ValueType{ValueTypeI32, ValueTypeI32, ValueTypeI32}
@@ -915,24 +975,39 @@ func (m *Module) validateFunctionWithMaxStackValues(
 						pc += num - 1
 					}
 
-					pc++
-					val, num, err := leb128.DecodeUint32(bytes.NewReader(body[pc:]))
-					if err != nil {
-						return fmt.Errorf("failed to read memory index for %s: %v", MiscInstructionName(miscOpcode), err)
-					}
-					if val != 0 || num != 1 {
-						return fmt.Errorf("%s reserved byte must be zero encoded with 1 byte", MiscInstructionName(miscOpcode))
-					}
+					// memory.copy needs two memory indexes: the destination and the source.
+					indexCount := 1
 					if miscOpcode == OpcodeMiscMemoryCopy {
+						indexCount = 2
+					}
+					var addressTypes [2]ValueType
+					for i := 0; i < indexCount; i++ {
 						pc++
-						// memory.copy needs two memory index which are reserved as zero.
 						val, num, err := leb128.DecodeUint32(bytes.NewReader(body[pc:]))
 						if err != nil {
 							return fmt.Errorf("failed to read memory index for %s: %v", MiscInstructionName(miscOpcode), err)
 						}
-						if val != 0 || num != 1 {
+						if !enabledFeatures.Get(FeatureMultiMemory) && (val != 0 || num != 1) {
 							return fmt.Errorf("%s reserved byte must be zero encoded with 1 byte", MiscInstructionName(miscOpcode))
+						} else if val >= uint32(len(memories)) {
+							return fmt.Errorf("memory must exist for %s", MiscInstructionName(miscOpcode))
+						}
+						addressTypes[i] = memories[val].AddressType()
+						pc += num - 1
+					}
+
+					// Addresses are i64 for memories with 64-bit indices, as are sizes unless copying from or to
+					// another memory with 32-bit indices.
+					switch miscOpcode {
+					case OpcodeMiscMemoryInit:
+						params[0] = addressTypes[0]
+					case OpcodeMiscMemoryCopy:
+						params[0], params[1] = addressTypes[0], addressTypes[1]
+						if addressTypes[0] == ValueTypeI64 && addressTypes[1] == ValueTypeI64 {
+							params[2] = ValueTypeI64
 						}
+					case OpcodeMiscMemoryFill:
+						params[0], params[2] = addressTypes[0], addressTypes[0]
 					}
 
 				case OpcodeMiscTableInit:
@@ -1056,6 +1131,14 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			}
 		} else if op == OpcodeVecPrefix {
 			pc++
+			if relaxedOpcode, ok := DecodeOpcodeVecRelaxed(body[pc:]); ok {
+				// Relaxed vector instructions come with three bytes, as their opcode takes two.
+				pc++
+				if err := validateRelaxedVectorInstruction(enabledFeatures, valueTypeStack, relaxedOpcode); err != nil {
+					return err
+				}
+				continue
+			}
 			// Vector instructions come with two bytes where the first byte is always OpcodeVecPrefix,
 			// and the second byte determines the actual instruction.
 			vecOpcode := body[pc]
@@ -1089,11 +1172,8 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				OpcodeVecV128Load32x2s, OpcodeVecV128Load32x2u, OpcodeVecV128Load8Splat, OpcodeVecV128Load16Splat,
 				OpcodeVecV128Load32Splat, OpcodeVecV128Load64Splat,
 				OpcodeVecV128Load32zero, OpcodeVecV128Load64zero:
-				if memory == nil {
-					return fmt.Errorf("memory must exist for %s", VectorInstructionName(vecOpcode))
-				}
 				pc++
-				align, _, read, err := readMemArg(pc, body)
+				align, addressType, read, err := readMemArg(pc, body, enabledFeatures, memories, VectorInstructionName(vecOpcode))
 				if err != nil {
 					return err
 				}
@@ -1122,16 +1202,13 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if 1<<align > maxAlign {
 					return fmt.Errorf("invalid memory alignment %d for %s", align, VectorInstructionName(vecOpcode))
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return fmt.Errorf("cannot pop the operand for %s: %v", VectorInstructionName(vecOpcode), err)
 				}
 				valueTypeStack.push(ValueTypeV128)
 			case OpcodeVecV128Store:
-				if memory == nil {
-					return fmt.Errorf("memory must exist for %s", VectorInstructionName(vecOpcode))
-				}
 				pc++
-				align, _, read, err := readMemArg(pc, body)
+				align, addressType, read, err := readMemArg(pc, body, enabledFeatures, memories, VectorInstructionName(vecOpcode))
 				if err != nil {
 					return err
 				}
@@ -1142,16 +1219,13 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeV128); err != nil {
 					return fmt.Errorf("cannot pop the operand for %s: %v", OpcodeVecV128StoreName, err)
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return fmt.Errorf("cannot pop the operand for %s: %v", OpcodeVecV128StoreName, err)
 				}
 			case OpcodeVecV128Load8Lane, OpcodeVecV128Load16Lane, OpcodeVecV128Load32Lane, OpcodeVecV128Load64Lane:
-				if memory == nil {
-					return fmt.Errorf("memory must exist for %s", VectorInstructionName(vecOpcode))
-				}
 				attr := vecLoadLanes[vecOpcode]
 				pc++
-				align, _, read, err := readMemArg(pc, body)
+				align, addressType, read, err := readMemArg(pc, body, enabledFeatures, memories, VectorInstructionName(vecOpcode))
 				if err != nil {
 					return err
 				}
@@ -1169,17 +1243,14 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeV128); err != nil {
 					return fmt.Errorf("cannot pop the operand for %s: %v", vectorInstructionName[vecOpcode], err)
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return fmt.Errorf("cannot pop the operand for %s: %v", vectorInstructionName[vecOpcode], err)
 				}
 				valueTypeStack.push(ValueTypeV128)
 			case OpcodeVecV128Store8Lane, OpcodeVecV128Store16Lane, OpcodeVecV128Store32Lane, OpcodeVecV128Store64Lane:
-				if memory == nil {
-					return fmt.Errorf("memory must exist for %s", VectorInstructionName(vecOpcode))
-				}
 				attr := vecStoreLanes[vecOpcode]
 				pc++
-				align, _, read, err := readMemArg(pc, body)
+				align, addressType, read, err := readMemArg(pc, body, enabledFeatures, memories, VectorInstructionName(vecOpcode))
 				if err != nil {
 					return err
 				}
@@ -1197,7 +1268,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeV128); err != nil {
 					return fmt.Errorf("cannot pop the operand for %s: %v", vectorInstructionName[vecOpcode], err)
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return fmt.Errorf("cannot pop the operand for %s: %v", vectorInstructionName[vecOpcode], err)
 				}
 			case OpcodeVecI8x16ExtractLaneS,
@@ -1320,6 +1391,64 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			default:
 				return fmt.Errorf("TODO: SIMD instruction %s will be implemented in #506", vectorInstructionName[vecOpcode])
 			}
+		} else if op == OpcodeAtomicPrefix {
+			pc++
+			// Atomic instructions come with two bytes where the first byte is always OpcodeAtomicPrefix,
+			// and the second byte determines the actual instruction.
+			atomicOpcode := body[pc]
+			name := atomicInstructionNames[atomicOpcode]
+			if name == "" {
+				return fmt.Errorf("invalid atomic instruction 0x%x", atomicOpcode)
+			}
+			if err := enabledFeatures.Require(FeatureThreads); err != nil {
+				return fmt.Errorf("%s invalid as %v", name, err)
+			}
+
+			if atomicOpcode == OpcodeAtomicFence {
+				pc++
+				if pc >= uint64(len(body)) || body[pc] != 0 {
+					return fmt.Errorf("%s must be followed by a zero byte", name)
+				}
+			} else {
+				pc++
+				align, addressType, read, err := readMemArg(pc, body, enabledFeatures, memories, name)
+				if err != nil {
+					return err
+				}
+				pc += read - 1
+
+				// Unlike other memory instructions, the alignment of atomic ones must be their size.
+				size, vt := AtomicOperand(atomicOpcode)
+				if 1<<align != size {
+					return fmt.Errorf("invalid memory alignment %d for %s", align, name)
+				}
+
+				var params, results []ValueType
+				switch {
+				case atomicOpcode == OpcodeAtomicMemoryNotify:
+					params, results = []ValueType{ValueTypeI32, ValueTypeI32}, []ValueType{ValueTypeI32}
+				case atomicOpcode == OpcodeAtomicMemoryWait32 || atomicOpcode == OpcodeAtomicMemoryWait64:
+					params, results = []ValueType{ValueTypeI32, vt, ValueTypeI64}, []ValueType{ValueTypeI32}
+				case atomicOpcode <= OpcodeAtomicI64Load32U:
+					params, results = []ValueType{ValueTypeI32}, []ValueType{vt}
+				case atomicOpcode <= OpcodeAtomicI64Store32:
+					params = []ValueType{ValueTypeI32, vt}
+				case atomicOpcode >= OpcodeAtomicI32RmwCmpxchg:
+					params, results = []ValueType{ValueTypeI32, vt, vt}, []ValueType{vt}
+				default:
+					params, results = []ValueType{ValueTypeI32, vt}, []ValueType{vt}
+				}
+				// The first operand is always the address.
+				params[0] = addressType
+				for i := len(params) - 1; i >= 0; i-- {
+					if err := valueTypeStack.popAndVerifyType(params[i]); err != nil {
+						return fmt.Errorf("cannot pop the operand for %s: %v", name, err)
+					}
+				}
+				for _, r := range results {
+					valueTypeStack.push(r)
+				}
+			}
 		} else if op == OpcodeBlock {
 			bt, num, err := DecodeBlockType(types, bytes.NewReader(body[pc+1:]), enabledFeatures)
 			if err != nil {
@@ -1382,6 +1511,136 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			}
 			valueTypeStack.pushStackLimit(len(bt.Params))
 			pc += num
+		} else if op == OpcodeTry {
+			if err := enabledFeatures.Require(FeatureExceptionHandling); err != nil {
+				return fmt.Errorf("%s invalid as %v", OpcodeTryName, err)
+			}
+			bt, num, err := DecodeBlockType(types, bytes.NewReader(body[pc+1:]), enabledFeatures)
+			if err != nil {
+				return fmt.Errorf("read block: %w", err)
+			}
+			controlBlockStack = append(controlBlockStack, &controlBlock{
+				startAt:        pc,
+				blockType:      bt,
+				blockTypeBytes: num,
+				op:             op,
+			})
+			if err = valueTypeStack.popParams(op, bt.Params, false); err != nil {
+				return err
+			}
+			// Plus we have to push any block params again.
+			for _, p := range bt.Params {
+				valueTypeStack.push(p)
+			}
+			valueTypeStack.pushStackLimit(len(bt.Params))
+			pc += num
+		} else if op == OpcodeCatch || op == OpcodeCatchAll {
+			if err := enabledFeatures.Require(FeatureExceptionHandling); err != nil {
+				return fmt.Errorf("%s invalid as %v", InstructionName(op), err)
+			}
+			bl := controlBlockStack[len(controlBlockStack)-1]
+			if bl.op != OpcodeTry || bl.catchAll {
+				return fmt.Errorf("%s must follow %s or %s", InstructionName(op), OpcodeTryName, OpcodeCatchName)
+			}
+			var params []ValueType
+			if op == OpcodeCatch {
+				pc++
+				index, num, err := leb128.DecodeUint32(bytes.NewReader(body[pc:]))
+				if err != nil {
+					return fmt.Errorf("read tag index: %v", err)
+				}
+				pc += num - 1
+				if tags == nil {
+					tags = m.AllTags()
+				}
+				if index >= uint32(len(tags)) {
+					return fmt.Errorf("invalid tag index %d for %s", index, OpcodeCatchName)
+				}
+				params = types[tags[index]].Params
+			}
+			// Check the type soundness of the instructions *before* entering this clause.
+			if err := valueTypeStack.popResults(OpcodeTry, bl.blockType.Results, true); err != nil {
+				return err
+			}
+			// Before entering instructions inside the clause, we pop all the values pushed by the previous one.
+			valueTypeStack.resetAtStackLimit()
+			// The values of the caught exception are pushed instead of the block params.
+			for _, p := range params {
+				valueTypeStack.push(p)
+			}
+			bl.inCatch = true
+			bl.catchAll = op == OpcodeCatchAll
+		} else if op == OpcodeThrow {
+			if err := enabledFeatures.Require(FeatureExceptionHandling); err != nil {
+				return fmt.Errorf("%s invalid as %v", OpcodeThrowName, err)
+			}
+			pc++
+			index, num, err := leb128.DecodeUint32(bytes.NewReader(body[pc:]))
+			if err != nil {
+				return fmt.Errorf("read tag index: %v", err)
+			}
+			pc += num - 1
+			if tags == nil {
+				tags = m.AllTags()
+			}
+			if index >= uint32(len(tags)) {
+				return fmt.Errorf("invalid tag index %d for %s", index, OpcodeThrowName)
+			}
+			params := types[tags[index]].Params
+			for i := len(params) - 1; i >= 0; i-- {
+				if err = valueTypeStack.popAndVerifyType(params[i]); err != nil {
+					return fmt.Errorf("cannot pop the operand for %s: %v", OpcodeThrowName, err)
+				}
+			}
+			// throw instruction is stack-polymorphic.
+			valueTypeStack.unreachable()
+		} else if op == OpcodeRethrow {
+			if err := enabledFeatures.Require(FeatureExceptionHandling); err != nil {
+				return fmt.Errorf("%s invalid as %v", OpcodeRethrowName, err)
+			}
+			pc++
+			index, num, err := leb128.DecodeUint32(bytes.NewReader(body[pc:]))
+			if err != nil {
+				return fmt.Errorf("read immediate: %v", err)
+			} else if int(index) >= len(controlBlockStack) {
+				return fmt.Errorf("invalid %s operation: index out of range", OpcodeRethrowName)
+			}
+			pc += num - 1
+			if target := controlBlockStack[len(controlBlockStack)-int(index)-1]; target.op != OpcodeTry || !target.inCatch {
+				return fmt.Errorf("invalid %s operation: label %d is not a catch clause", OpcodeRethrowName, index)
+			}
+			// rethrow instruction is stack-polymorphic.
+			valueTypeStack.unreachable()
+		} else if op == OpcodeDelegate {
+			if err := enabledFeatures.Require(FeatureExceptionHandling); err != nil {
+				return fmt.Errorf("%s invalid as %v", OpcodeDelegateName, err)
+			}
+			bl := controlBlockStack[len(controlBlockStack)-1]
+			if bl.op != OpcodeTry || bl.inCatch {
+				return fmt.Errorf("%s must end a %s block without catch clauses", OpcodeDelegateName, OpcodeTryName)
+			}
+			pc++
+			index, num, err := leb128.DecodeUint32(bytes.NewReader(body[pc:]))
+			if err != nil {
+				return fmt.Errorf("read immediate: %v", err)
+			}
+			pc += num - 1
+			bl.endAt = pc
+			controlBlockStack = controlBlockStack[:len(controlBlockStack)-1]
+			// The label is relative to the blocks enclosing the try block.
+			if int(index) >= len(controlBlockStack) {
+				return fmt.Errorf("invalid %s operation: index out of range", OpcodeDelegateName)
+			}
+
+			// Like OpcodeEnd, check the results of the try block.
+			if err := valueTypeStack.requireStackValues(false, OpcodeTryName, bl.blockType.Results, true); err != nil {
+				return err
+			}
+			valueTypeStack.resetAtStackLimit()
+			for _, exp := range bl.blockType.Results {
+				valueTypeStack.push(exp)
+			}
+			valueTypeStack.popStackLimit()
 		} else if op == OpcodeElse {
 			bl := controlBlockStack[len(controlBlockStack)-1]
 			bl.elseAt = pc
@@ -1504,6 +1763,47 @@ func (m *Module) validateFunctionWithMaxStackValues(
 	return nil
 }
 
+// validateTailCallResults returns an error unless the results of the function called by a tail call are those of the
+// function making it, as the callee returns them to the caller's caller.
+func validateTailCallResults(op Opcode, callee, caller *FunctionType) error {
+	if !bytes.Equal(callee.Results, caller.Results) {
+		return fmt.Errorf("type mismatch on %s operation result type: %s != %s", InstructionName(op), callee, caller)
+	}
+	return nil
+}
+
+// validateRelaxedVectorInstruction validates the operands of a relaxed vector instruction, which are all
+// ValueTypeV128 like its result.
+func validateRelaxedVectorInstruction(enabledFeatures Features, valueTypeStack *valueTypeStack, oc OpcodeVecRelaxed) error {
+	name := RelaxedVectorInstructionName(oc)
+	if err := enabledFeatures.Require(FeatureSIMD); err != nil {
+		return fmt.Errorf("%s invalid as %v", name, err)
+	}
+	if err := enabledFeatures.Require(FeatureRelaxedSIMD); err != nil {
+		return fmt.Errorf("%s invalid as %v", name, err)
+	}
+
+	var operands int
+	switch oc {
+	case OpcodeVecI32x4RelaxedTruncF32x4S, OpcodeVecI32x4RelaxedTruncF32x4U,
+		OpcodeVecI32x4RelaxedTruncF64x2SZero, OpcodeVecI32x4RelaxedTruncF64x2UZero:
+		operands = 1
+	case OpcodeVecI8x16RelaxedSwizzle, OpcodeVecF32x4RelaxedMin, OpcodeVecF32x4RelaxedMax,
+		OpcodeVecF64x2RelaxedMin, OpcodeVecF64x2RelaxedMax, OpcodeVecI16x8RelaxedQ15mulrS,
+		OpcodeVecI16x8RelaxedDotI8x16I7x16S:
+		operands = 2
+	default: // madd, nmadd, laneselect and dot with an accumulator.
+		operands = 3
+	}
+	for i := 0; i < operands; i++ {
+		if err := valueTypeStack.popAndVerifyType(ValueTypeV128); err != nil {
+			return fmt.Errorf("cannot pop the operand for %s: %v", name, err)
+		}
+	}
+	valueTypeStack.push(ValueTypeV128)
+	return nil
+}
+
 var vecExtractLanes = [...]struct {
 	laneCeil   byte
 	resultType ValueType
@@ -1788,6 +2088,10 @@ type controlBlock struct {
 	blockTypeBytes         uint64
 	// op is zero when the outermost block
 	op Opcode
+	// inCatch is true when an OpcodeTry block is past its body, in one of its catch clauses.
+	inCatch bool
+	// catchAll is true when an OpcodeTry block is in its OpcodeCatchAll clause, which must be the last one.
+	catchAll bool
 }
 
 // DecodeBlockType decodes the type index from a positive 33-bit signed integer. Negative numbers indicate up to one
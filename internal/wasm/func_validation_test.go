@@ -288,7 +288,7 @@ func TestModule_ValidateFunction_BulkMemoryOperations(t *testing.T) {
 					ElementSection:   []*ElementSegment{{}},
 					DataCountSection: &c,
 				}
-				err := m.validateFunction(FeatureBulkMemoryOperations, 0, []Index{0}, nil, &Memory{}, []*Table{{}, {}}, nil)
+				err := m.validateFunction(FeatureBulkMemoryOperations, 0, []Index{0}, nil, []*Memory{{}}, []*Table{{}, {}}, nil)
 				require.NoError(t, err)
 			})
 		}
@@ -299,7 +299,7 @@ func TestModule_ValidateFunction_BulkMemoryOperations(t *testing.T) {
 			dataSection         []*DataSegment
 			elementSection      []*ElementSegment
 			dataCountSectionNil bool
-			memory              *Memory
+			memories            []*Memory
 			tables              []*Table
 			flag                Features
 			expectedErr         string
@@ -308,7 +308,7 @@ func TestModule_ValidateFunction_BulkMemoryOperations(t *testing.T) {
 			{
 				body:        []byte{OpcodeMiscPrefix, OpcodeMiscMemoryInit},
 				flag:        FeatureBulkMemoryOperations,
-				memory:      nil,
+				memories:    nil,
 				expectedErr: "memory must exist for memory.init",
 			},
 			{
@@ -325,48 +325,48 @@ func TestModule_ValidateFunction_BulkMemoryOperations(t *testing.T) {
 			{
 				body:        []byte{OpcodeMiscPrefix, OpcodeMiscMemoryInit},
 				flag:        FeatureBulkMemoryOperations,
-				memory:      &Memory{},
+				memories:    []*Memory{{}},
 				expectedErr: "failed to read data segment index for memory.init: EOF",
 			},
 			{
 				body:        []byte{OpcodeMiscPrefix, OpcodeMiscMemoryInit, 100 /* data section out of range */},
 				flag:        FeatureBulkMemoryOperations,
-				memory:      &Memory{},
+				memories:    []*Memory{{}},
 				dataSection: []*DataSegment{{}},
 				expectedErr: "index 100 out of range of data section(len=1)",
 			},
 			{
 				body:        []byte{OpcodeMiscPrefix, OpcodeMiscMemoryInit, 0},
 				flag:        FeatureBulkMemoryOperations,
-				memory:      &Memory{},
+				memories:    []*Memory{{}},
 				dataSection: []*DataSegment{{}},
 				expectedErr: "failed to read memory index for memory.init: EOF",
 			},
 			{
 				body:        []byte{OpcodeMiscPrefix, OpcodeMiscMemoryInit, 0, 1},
 				flag:        FeatureBulkMemoryOperations,
-				memory:      &Memory{},
+				memories:    []*Memory{{}},
 				dataSection: []*DataSegment{{}},
 				expectedErr: "memory.init reserved byte must be zero encoded with 1 byte",
 			},
 			{
 				body:        []byte{OpcodeMiscPrefix, OpcodeMiscMemoryInit, 0, 0},
 				flag:        FeatureBulkMemoryOperations,
-				memory:      &Memory{},
+				memories:    []*Memory{{}},
 				dataSection: []*DataSegment{{}},
 				expectedErr: "cannot pop the operand for memory.init: i32 missing",
 			},
 			{
 				body:        []byte{OpcodeI32Const, 0, OpcodeMiscPrefix, OpcodeMiscMemoryInit, 0, 0},
 				flag:        FeatureBulkMemoryOperations,
-				memory:      &Memory{},
+				memories:    []*Memory{{}},
 				dataSection: []*DataSegment{{}},
 				expectedErr: "cannot pop the operand for memory.init: i32 missing",
 			},
 			{
 				body:        []byte{OpcodeI32Const, 0, OpcodeI32Const, 0, OpcodeMiscPrefix, OpcodeMiscMemoryInit, 0, 0},
 				flag:        FeatureBulkMemoryOperations,
-				memory:      &Memory{},
+				memories:    []*Memory{{}},
 				dataSection: []*DataSegment{{}},
 				expectedErr: "cannot pop the operand for memory.init: i32 missing",
 			},
@@ -379,20 +379,20 @@ func TestModule_ValidateFunction_BulkMemoryOperations(t *testing.T) {
 			{
 				body:                []byte{OpcodeMiscPrefix, OpcodeMiscDataDrop},
 				dataCountSectionNil: true,
-				memory:              &Memory{},
+				memories:            []*Memory{{}},
 				flag:                FeatureBulkMemoryOperations,
 				expectedErr:         `data.drop requires data count section`,
 			},
 			{
 				body:        []byte{OpcodeMiscPrefix, OpcodeMiscDataDrop},
 				flag:        FeatureBulkMemoryOperations,
-				memory:      &Memory{},
+				memories:    []*Memory{{}},
 				expectedErr: "failed to read data segment index for data.drop: EOF",
 			},
 			{
 				body:        []byte{OpcodeMiscPrefix, OpcodeMiscDataDrop, 100 /* data section out of range */},
 				flag:        FeatureBulkMemoryOperations,
-				memory:      &Memory{},
+				memories:    []*Memory{{}},
 				dataSection: []*DataSegment{{}},
 				expectedErr: "index 100 out of range of data section(len=1)",
 			},
@@ -400,7 +400,7 @@ func TestModule_ValidateFunction_BulkMemoryOperations(t *testing.T) {
 			{
 				body:        []byte{OpcodeMiscPrefix, OpcodeMiscMemoryCopy},
 				flag:        FeatureBulkMemoryOperations,
-				memory:      nil,
+				memories:    nil,
 				expectedErr: "memory must exist for memory.copy",
 			},
 			{
@@ -411,44 +411,44 @@ func TestModule_ValidateFunction_BulkMemoryOperations(t *testing.T) {
 			{
 				body:        []byte{OpcodeMiscPrefix, OpcodeMiscMemoryCopy},
 				flag:        FeatureBulkMemoryOperations,
-				memory:      &Memory{},
+				memories:    []*Memory{{}},
 				expectedErr: `failed to read memory index for memory.copy: EOF`,
 			},
 			{
 				body:        []byte{OpcodeMiscPrefix, OpcodeMiscMemoryCopy, 0},
 				flag:        FeatureBulkMemoryOperations,
-				memory:      &Memory{},
+				memories:    []*Memory{{}},
 				expectedErr: "failed to read memory index for memory.copy: EOF",
 			},
 			{
 				body:        []byte{OpcodeMiscPrefix, OpcodeMiscMemoryCopy, 0, 1},
 				flag:        FeatureBulkMemoryOperations,
-				memory:      &Memory{},
+				memories:    []*Memory{{}},
 				expectedErr: "memory.copy reserved byte must be zero encoded with 1 byte",
 			},
 			{
 				body:        []byte{OpcodeMiscPrefix, OpcodeMiscMemoryCopy, 0, 0},
 				flag:        FeatureBulkMemoryOperations,
-				memory:      &Memory{},
+				memories:    []*Memory{{}},
 				expectedErr: "cannot pop the operand for memory.copy: i32 missing",
 			},
 			{
 				body:        []byte{OpcodeI32Const, 0, OpcodeMiscPrefix, OpcodeMiscMemoryCopy, 0, 0},
 				flag:        FeatureBulkMemoryOperations,
-				memory:      &Memory{},
+				memories:    []*Memory{{}},
 				expectedErr: "cannot pop the operand for memory.copy: i32 missing",
 			},
 			{
 				body:        []byte{OpcodeI32Const, 0, OpcodeI32Const, 0, OpcodeMiscPrefix, OpcodeMiscMemoryCopy, 0, 0},
 				flag:        FeatureBulkMemoryOperations,
-				memory:      &Memory{},
+				memories:    []*Memory{{}},
 				expectedErr: "cannot pop the operand for memory.copy: i32 missing",
 			},
 			// memory.fill
 			{
 				body:        []byte{OpcodeMiscPrefix, OpcodeMiscMemoryFill},
 				flag:        FeatureBulkMemoryOperations,
-				memory:      nil,
+				memories:    nil,
 				expectedErr: "memory must exist for memory.fill",
 			},
 			{
@@ -459,31 +459,31 @@ func TestModule_ValidateFunction_BulkMemoryOperations(t *testing.T) {
 			{
 				body:        []byte{OpcodeMiscPrefix, OpcodeMiscMemoryFill},
 				flag:        FeatureBulkMemoryOperations,
-				memory:      &Memory{},
+				memories:    []*Memory{{}},
 				expectedErr: `failed to read memory index for memory.fill: EOF`,
 			},
 			{
 				body:        []byte{OpcodeMiscPrefix, OpcodeMiscMemoryFill, 1},
 				flag:        FeatureBulkMemoryOperations,
-				memory:      &Memory{},
+				memories:    []*Memory{{}},
 				expectedErr: `memory.fill reserved byte must be zero encoded with 1 byte`,
 			},
 			{
 				body:        []byte{OpcodeMiscPrefix, OpcodeMiscMemoryFill, 0},
 				flag:        FeatureBulkMemoryOperations,
-				memory:      &Memory{},
+				memories:    []*Memory{{}},
 				expectedErr: "cannot pop the operand for memory.fill: i32 missing",
 			},
 			{
 				body:        []byte{OpcodeI32Const, 0, OpcodeMiscPrefix, OpcodeMiscMemoryFill, 0},
 				flag:        FeatureBulkMemoryOperations,
-				memory:      &Memory{},
+				memories:    []*Memory{{}},
 				expectedErr: "cannot pop the operand for memory.fill: i32 missing",
 			},
 			{
 				body:        []byte{OpcodeI32Const, 0, OpcodeI32Const, 0, OpcodeMiscPrefix, OpcodeMiscMemoryFill, 0},
 				flag:        FeatureBulkMemoryOperations,
-				memory:      &Memory{},
+				memories:    []*Memory{{}},
 				expectedErr: "cannot pop the operand for memory.fill: i32 missing",
 			},
 			// table.init
@@ -652,7 +652,7 @@ func TestModule_ValidateFunction_BulkMemoryOperations(t *testing.T) {
 					c := uint32(0)
 					m.DataCountSection = &c
 				}
-				err := m.validateFunction(tc.flag, 0, []Index{0}, nil, tc.memory, tc.tables, nil)
+				err := m.validateFunction(tc.flag, 0, []Index{0}, nil, tc.memories, tc.tables, nil)
 				require.EqualError(t, err, tc.expectedErr)
 			})
 		}
@@ -2197,7 +2197,7 @@ func TestModule_funcValidation_CallIndirect(t *testing.T) {
 				OpcodeEnd,
 			}}},
 		}
-		err := m.validateFunction(FeatureReferenceTypes, 0, []Index{0}, nil, &Memory{}, []*Table{{Type: RefTypeFuncref}}, nil)
+		err := m.validateFunction(FeatureReferenceTypes, 0, []Index{0}, nil, []*Memory{{}}, []*Table{{Type: RefTypeFuncref}}, nil)
 		require.NoError(t, err)
 	})
 	t.Run("non zero table index", func(t *testing.T) {
@@ -2211,11 +2211,11 @@ func TestModule_funcValidation_CallIndirect(t *testing.T) {
 			}}},
 		}
 		t.Run("disabled", func(t *testing.T) {
-			err := m.validateFunction(Features20191205, 0, []Index{0}, nil, &Memory{}, []*Table{{}, {}}, nil)
+			err := m.validateFunction(Features20191205, 0, []Index{0}, nil, []*Memory{{}}, []*Table{{}, {}}, nil)
 			require.EqualError(t, err, "table index must be zero but was 100: feature \"reference-types\" is disabled")
 		})
 		t.Run("enabled but out of range", func(t *testing.T) {
-			err := m.validateFunction(FeatureReferenceTypes, 0, []Index{0}, nil, &Memory{}, []*Table{{}, {}}, nil)
+			err := m.validateFunction(FeatureReferenceTypes, 0, []Index{0}, nil, []*Memory{{}}, []*Table{{}, {}}, nil)
 			require.EqualError(t, err, "unknown table index: 100")
 		})
 	})
@@ -2229,7 +2229,7 @@ func TestModule_funcValidation_CallIndirect(t *testing.T) {
 				OpcodeEnd,
 			}}},
 		}
-		err := m.validateFunction(FeatureReferenceTypes, 0, []Index{0}, nil, &Memory{}, []*Table{{Type: RefTypeExternref}}, nil)
+		err := m.validateFunction(FeatureReferenceTypes, 0, []Index{0}, nil, []*Memory{{}}, []*Table{{Type: RefTypeExternref}}, nil)
 		require.EqualError(t, err, "table is not funcref type but was externref for call_indirect")
 	})
 }
@@ -3030,7 +3030,7 @@ func TestModule_funcValidation_SIMD(t *testing.T) {
 				FunctionSection: []Index{0},
 				CodeSection:     []*Code{{Body: tc.body}},
 			}
-			err := m.validateFunction(FeatureSIMD, 0, []Index{0}, nil, &Memory{}, nil, nil)
+			err := m.validateFunction(FeatureSIMD, 0, []Index{0}, nil, []*Memory{{}}, nil, nil)
 			require.NoError(t, err)
 		})
 	}
@@ -3185,8 +3185,587 @@ func TestModule_funcValidation_SIMD_error(t *testing.T) {
 				FunctionSection: []Index{0},
 				CodeSection:     []*Code{{Body: tc.body}},
 			}
-			err := m.validateFunction(tc.flag, 0, []Index{0}, nil, &Memory{}, nil, nil)
+			err := m.validateFunction(tc.flag, 0, []Index{0}, nil, []*Memory{{}}, nil, nil)
 			require.EqualError(t, err, tc.expectedErr)
 		})
 	}
 }
+
+func TestModule_funcValidation_Atomic(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        []byte
+		flag        Features
+		memories    []*Memory
+		expectedErr string
+	}{
+		{
+			name: "i32.atomic.rmw8.add_u",
+			body: []byte{
+				OpcodeI32Const, 0, OpcodeI32Const, 1,
+				OpcodeAtomicPrefix, OpcodeAtomicI32Rmw8AddU, 0, 0, // align and offset.
+				OpcodeDrop, OpcodeEnd,
+			},
+			flag:     FeatureThreads,
+			memories: []*Memory{{Shared: true}},
+		},
+		{
+			name: "i64.atomic.rmw.cmpxchg",
+			body: []byte{
+				OpcodeI32Const, 0, OpcodeI64Const, 1, OpcodeI64Const, 2,
+				OpcodeAtomicPrefix, OpcodeAtomicI64RmwCmpxchg, 3, 8,
+				OpcodeDrop, OpcodeEnd,
+			},
+			flag:     FeatureThreads,
+			memories: []*Memory{{Shared: true}},
+		},
+		{
+			name: "memory.atomic.wait32",
+			body: []byte{
+				OpcodeI32Const, 0, OpcodeI32Const, 0, OpcodeI64Const, 0,
+				OpcodeAtomicPrefix, OpcodeAtomicMemoryWait32, 2, 0,
+				OpcodeDrop, OpcodeEnd,
+			},
+			flag:     FeatureThreads,
+			memories: []*Memory{{Shared: true}},
+		},
+		{
+			name:     "atomic.fence",
+			body:     []byte{OpcodeAtomicPrefix, OpcodeAtomicFence, 0, OpcodeEnd},
+			flag:     FeatureThreads,
+			memories: nil,
+		},
+		{
+			name:        "threads disabled",
+			body:        []byte{OpcodeAtomicPrefix, OpcodeAtomicFence, 0, OpcodeEnd},
+			flag:        Features20191205,
+			expectedErr: "atomic.fence invalid as feature \"threads\" is disabled",
+		},
+		{
+			name:        "atomic.fence immediate",
+			body:        []byte{OpcodeAtomicPrefix, OpcodeAtomicFence, 1, OpcodeEnd},
+			flag:        FeatureThreads,
+			expectedErr: "atomic.fence must be followed by a zero byte",
+		},
+		{
+			name:        "invalid atomic instruction",
+			body:        []byte{OpcodeAtomicPrefix, 0x04, OpcodeEnd},
+			flag:        FeatureThreads,
+			expectedErr: "invalid atomic instruction 0x4",
+		},
+		{
+			name:        "no memory",
+			body:        []byte{OpcodeI32Const, 0, OpcodeAtomicPrefix, OpcodeAtomicI32Load, 2, 0, OpcodeDrop, OpcodeEnd},
+			flag:        FeatureThreads,
+			expectedErr: "memory must exist for i32.atomic.load",
+		},
+		{
+			name:        "alignment not natural",
+			body:        []byte{OpcodeI32Const, 0, OpcodeAtomicPrefix, OpcodeAtomicI32Load, 1, 0, OpcodeDrop, OpcodeEnd},
+			flag:        FeatureThreads,
+			memories:    []*Memory{{Shared: true}},
+			expectedErr: "invalid memory alignment 1 for i32.atomic.load",
+		},
+		{
+			name: "operand type",
+			body: []byte{
+				OpcodeI32Const, 0, OpcodeI32Const, 0,
+				OpcodeAtomicPrefix, OpcodeAtomicI64Store, 3, 0,
+				OpcodeEnd,
+			},
+			flag:        FeatureThreads,
+			memories:    []*Memory{{Shared: true}},
+			expectedErr: "cannot pop the operand for i64.atomic.store: type mismatch: expected i64, but was i32",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			m := &Module{
+				TypeSection:     []*FunctionType{v_v},
+				FunctionSection: []Index{0},
+				CodeSection:     []*Code{{Body: tc.body}},
+			}
+			err := m.validateFunction(tc.flag, 0, []Index{0}, nil, tc.memories, nil, nil)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestModule_funcValidation_TailCall(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        []byte
+		flag        Features
+		expectedErr string
+	}{
+		{
+			name: "return_call",
+			body: []byte{OpcodeI32Const, 1, OpcodeReturnCall, 1, OpcodeEnd},
+			flag: FeatureTailCall,
+		},
+		{
+			name: "return_call stack-polymorphic",
+			body: []byte{
+				OpcodeI64Const, 1, // values below the parameters are allowed.
+				OpcodeI32Const, 1, OpcodeReturnCall, 1,
+				OpcodeI32Add, OpcodeEnd,
+			},
+			flag: FeatureTailCall,
+		},
+		{
+			name: "return_call_indirect",
+			body: []byte{OpcodeI32Const, 1, OpcodeI32Const, 0, OpcodeReturnCallIndirect, 1, 0, OpcodeEnd},
+			flag: FeatureTailCall,
+		},
+		{
+			name:        "return_call disabled",
+			body:        []byte{OpcodeI32Const, 1, OpcodeReturnCall, 1, OpcodeEnd},
+			flag:        Features20191205,
+			expectedErr: "return_call invalid as feature \"tail-call\" is disabled",
+		},
+		{
+			name:        "return_call_indirect disabled",
+			body:        []byte{OpcodeI32Const, 1, OpcodeI32Const, 0, OpcodeReturnCallIndirect, 1, 0, OpcodeEnd},
+			flag:        Features20191205,
+			expectedErr: "return_call_indirect invalid as feature \"tail-call\" is disabled",
+		},
+		{
+			name:        "return_call param type",
+			body:        []byte{OpcodeI64Const, 1, OpcodeReturnCall, 1, OpcodeEnd},
+			flag:        FeatureTailCall,
+			expectedErr: "type mismatch on return_call operation param type: type mismatch: expected i32, but was i64",
+		},
+		{
+			name:        "return_call result type",
+			body:        []byte{OpcodeReturnCall, 2, OpcodeEnd},
+			flag:        FeatureTailCall,
+			expectedErr: "type mismatch on return_call operation result type: v_v != v_i32",
+		},
+		{
+			name:        "return_call_indirect result type",
+			body:        []byte{OpcodeI32Const, 0, OpcodeReturnCallIndirect, 2, 0, OpcodeEnd},
+			flag:        FeatureTailCall,
+			expectedErr: "type mismatch on return_call_indirect operation result type: v_v != v_i32",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			m := &Module{
+				TypeSection:     []*FunctionType{v_i32, i32_i32, v_v},
+				FunctionSection: []Index{0, 1, 2},
+				CodeSection:     []*Code{{Body: tc.body}},
+			}
+			tables := []*Table{{Type: RefTypeFuncref}}
+			err := m.validateFunction(tc.flag, 0, []Index{0, 1, 2}, nil, nil, tables, nil)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestModule_funcValidation_ExceptionHandling(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        []byte
+		flag        Features
+		expectedErr string
+	}{
+		{
+			name: "try catch",
+			body: []byte{
+				OpcodeTry, ValueTypeI32, OpcodeI32Const, 1,
+				OpcodeCatch, 0, // pushes the i32 param of the tag.
+				OpcodeEnd, OpcodeEnd,
+			},
+			flag: FeatureExceptionHandling,
+		},
+		{
+			name: "try catch_all",
+			body: []byte{
+				OpcodeTry, ValueTypeI32, OpcodeI32Const, 1,
+				OpcodeCatch, 0,
+				OpcodeCatchAll, OpcodeI32Const, 2,
+				OpcodeEnd, OpcodeEnd,
+			},
+			flag: FeatureExceptionHandling,
+		},
+		{
+			name: "throw stack-polymorphic",
+			body: []byte{OpcodeI32Const, 1, OpcodeThrow, 0, OpcodeEnd},
+			flag: FeatureExceptionHandling,
+		},
+		{
+			name: "rethrow",
+			body: []byte{
+				OpcodeTry, 0x40, OpcodeCatchAll,
+				OpcodeBlock, 0x40, OpcodeRethrow, 1, OpcodeEnd,
+				OpcodeEnd, OpcodeI32Const, 0, OpcodeEnd,
+			},
+			flag: FeatureExceptionHandling,
+		},
+		{
+			name: "delegate",
+			body: []byte{OpcodeTry, ValueTypeI32, OpcodeI32Const, 1, OpcodeDelegate, 0, OpcodeEnd},
+			flag: FeatureExceptionHandling,
+		},
+		{
+			name:        "try disabled",
+			body:        []byte{OpcodeTry, 0x40, OpcodeEnd, OpcodeI32Const, 0, OpcodeEnd},
+			flag:        Features20191205,
+			expectedErr: "try invalid as feature \"exception-handling\" is disabled",
+		},
+		{
+			name:        "throw disabled",
+			body:        []byte{OpcodeI32Const, 1, OpcodeThrow, 0, OpcodeEnd},
+			flag:        Features20191205,
+			expectedErr: "throw invalid as feature \"exception-handling\" is disabled",
+		},
+		{
+			name:        "catch without try",
+			body:        []byte{OpcodeBlock, 0x40, OpcodeCatch, 0, OpcodeEnd, OpcodeI32Const, 0, OpcodeEnd},
+			flag:        FeatureExceptionHandling,
+			expectedErr: "catch must follow try or catch",
+		},
+		{
+			name: "catch after catch_all",
+			body: []byte{
+				OpcodeTry, 0x40, OpcodeCatchAll, OpcodeCatchAll, OpcodeEnd,
+				OpcodeI32Const, 0, OpcodeEnd,
+			},
+			flag:        FeatureExceptionHandling,
+			expectedErr: "catch_all must follow try or catch",
+		},
+		{
+			name:        "catch invalid tag",
+			body:        []byte{OpcodeTry, 0x40, OpcodeCatch, 1, OpcodeEnd, OpcodeI32Const, 0, OpcodeEnd},
+			flag:        FeatureExceptionHandling,
+			expectedErr: "invalid tag index 1 for catch",
+		},
+		{
+			name:        "catch result type",
+			body:        []byte{OpcodeTry, ValueTypeI32, OpcodeI32Const, 1, OpcodeCatchAll, OpcodeEnd, OpcodeEnd},
+			flag:        FeatureExceptionHandling,
+			expectedErr: "not enough results in try block\n\thave ()\n\twant (i32)",
+		},
+		{
+			name:        "throw param type",
+			body:        []byte{OpcodeI64Const, 1, OpcodeThrow, 0, OpcodeEnd},
+			flag:        FeatureExceptionHandling,
+			expectedErr: "cannot pop the operand for throw: type mismatch: expected i32, but was i64",
+		},
+		{
+			name:        "rethrow outside catch",
+			body:        []byte{OpcodeTry, 0x40, OpcodeRethrow, 0, OpcodeEnd, OpcodeI32Const, 0, OpcodeEnd},
+			flag:        FeatureExceptionHandling,
+			expectedErr: "invalid rethrow operation: label 0 is not a catch clause",
+		},
+		{
+			name:        "delegate after catch",
+			body:        []byte{OpcodeTry, 0x40, OpcodeCatchAll, OpcodeDelegate, 0, OpcodeI32Const, 0, OpcodeEnd},
+			flag:        FeatureExceptionHandling,
+			expectedErr: "delegate must end a try block without catch clauses",
+		},
+		{
+			name:        "delegate label",
+			body:        []byte{OpcodeTry, 0x40, OpcodeDelegate, 1, OpcodeI32Const, 0, OpcodeEnd},
+			flag:        FeatureExceptionHandling,
+			expectedErr: "invalid delegate operation: index out of range",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			m := &Module{
+				TypeSection:     []*FunctionType{v_i32, i32_i32, v_v},
+				FunctionSection: []Index{0},
+				TagSection:      []Index{1},
+				CodeSection:     []*Code{{Body: tc.body}},
+			}
+			err := m.validateFunction(tc.flag, 0, []Index{0}, nil, nil, nil, nil)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestModule_funcValidation_MultiMemory(t *testing.T) {
+	const multiMemory = FeatureMultiMemory | FeatureBulkMemoryOperations
+	tests := []struct {
+		name        string
+		body        []byte
+		flag        Features
+		expectedErr string
+	}{
+		{
+			name: "load and store",
+			body: []byte{
+				OpcodeI32Const, 0, OpcodeI32Const, 0,
+				OpcodeI32Load, 2 | MemoryArgMemoryIndexFlag, 1, 0, // align, memory index and offset.
+				OpcodeI32Store, 2 | MemoryArgMemoryIndexFlag, 1, 0,
+				OpcodeEnd,
+			},
+			flag: multiMemory,
+		},
+		{
+			name: "size and grow",
+			body: []byte{OpcodeMemorySize, 1, OpcodeMemoryGrow, 1, OpcodeDrop, OpcodeEnd},
+			flag: multiMemory,
+		},
+		{
+			name: "copy and fill",
+			body: []byte{
+				OpcodeI32Const, 0, OpcodeI32Const, 0, OpcodeI32Const, 0,
+				OpcodeMiscPrefix, OpcodeMiscMemoryCopy, 0, 1,
+				OpcodeI32Const, 0, OpcodeI32Const, 0, OpcodeI32Const, 0,
+				OpcodeMiscPrefix, OpcodeMiscMemoryFill, 1,
+				OpcodeEnd,
+			},
+			flag: multiMemory,
+		},
+		{
+			name:        "load memory not found",
+			body:        []byte{OpcodeI32Const, 0, OpcodeI32Load, 2 | MemoryArgMemoryIndexFlag, 2, 0, OpcodeDrop, OpcodeEnd},
+			flag:        multiMemory,
+			expectedErr: "memory must exist for i32.load",
+		},
+		{
+			name:        "load memory index disabled",
+			body:        []byte{OpcodeI32Const, 0, OpcodeI32Load, 2 | MemoryArgMemoryIndexFlag, 1, 0, OpcodeDrop, OpcodeEnd},
+			flag:        FeatureBulkMemoryOperations,
+			expectedErr: "invalid memory alignment",
+		},
+		{
+			name:        "size memory not found",
+			body:        []byte{OpcodeMemorySize, 2, OpcodeDrop, OpcodeEnd},
+			flag:        multiMemory,
+			expectedErr: "memory must exist for memory.size",
+		},
+		{
+			name:        "size memory index disabled",
+			body:        []byte{OpcodeMemorySize, 1, OpcodeDrop, OpcodeEnd},
+			flag:        FeatureBulkMemoryOperations,
+			expectedErr: "memory instruction reserved bytes not zero with 1 byte",
+		},
+		{
+			name: "copy memory not found",
+			body: []byte{
+				OpcodeI32Const, 0, OpcodeI32Const, 0, OpcodeI32Const, 0,
+				OpcodeMiscPrefix, OpcodeMiscMemoryCopy, 2, 0,
+				OpcodeEnd,
+			},
+			flag:        multiMemory,
+			expectedErr: "memory must exist for memory.copy",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			m := &Module{
+				TypeSection:     []*FunctionType{v_v},
+				FunctionSection: []Index{0},
+				CodeSection:     []*Code{{Body: tc.body}},
+			}
+			err := m.validateFunction(tc.flag, 0, []Index{0}, nil, []*Memory{{}, {}}, nil, nil)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestModule_funcValidation_Memory64(t *testing.T) {
+	const memory64 = FeatureMemory64 | FeatureBulkMemoryOperations | FeatureMultiMemory
+	tests := []struct {
+		name        string
+		body        []byte
+		expectedErr string
+	}{
+		{
+			name: "load and store",
+			body: []byte{
+				OpcodeI64Const, 0, OpcodeI64Const, 0,
+				OpcodeI32Load, 2, 0x80, 0x80, 0x80, 0x80, 0x10, // align and offset of 1<<32.
+				OpcodeI32Store, 2, 0,
+				OpcodeEnd,
+			},
+		},
+		{
+			name: "size and grow",
+			body: []byte{
+				OpcodeMemorySize, 0, OpcodeMemoryGrow, 0,
+				OpcodeI64Eqz, OpcodeDrop,
+				OpcodeEnd,
+			},
+		},
+		{
+			name: "copy and fill",
+			body: []byte{
+				OpcodeI64Const, 0, OpcodeI64Const, 0, OpcodeI64Const, 0,
+				OpcodeMiscPrefix, OpcodeMiscMemoryCopy, 0, 0,
+				OpcodeI64Const, 0, OpcodeI32Const, 0, OpcodeI64Const, 0,
+				OpcodeMiscPrefix, OpcodeMiscMemoryFill, 0,
+				OpcodeEnd,
+			},
+		},
+		{
+			name: "copy to 32-bit memory",
+			body: []byte{
+				OpcodeI32Const, 0, OpcodeI64Const, 0, OpcodeI32Const, 0,
+				OpcodeMiscPrefix, OpcodeMiscMemoryCopy, 1, 0,
+				OpcodeEnd,
+			},
+		},
+		{
+			name:        "load with i32 address",
+			body:        []byte{OpcodeI32Const, 0, OpcodeI32Load, 2, 0, OpcodeDrop, OpcodeEnd},
+			expectedErr: "type mismatch: expected i64, but was i32",
+		},
+		{
+			name: "size is i64",
+			body: []byte{
+				OpcodeMemorySize, 0, OpcodeI32Eqz, OpcodeDrop,
+				OpcodeEnd,
+			},
+			expectedErr: "cannot pop the operand for i32.eqz: type mismatch: expected i32, but was i64",
+		},
+		{
+			name: "fill with i32 size",
+			body: []byte{
+				OpcodeI64Const, 0, OpcodeI32Const, 0, OpcodeI32Const, 0,
+				OpcodeMiscPrefix, OpcodeMiscMemoryFill, 0,
+				OpcodeEnd,
+			},
+			expectedErr: "cannot pop the operand for memory.fill: type mismatch: expected i64, but was i32",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			m := &Module{
+				TypeSection:     []*FunctionType{v_v},
+				FunctionSection: []Index{0},
+				CodeSection:     []*Code{{Body: tc.body}},
+			}
+			err := m.validateFunction(memory64, 0, []Index{0}, nil, []*Memory{{Is64: true}, {}}, nil, nil)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestModule_funcValidation_RelaxedSIMD(t *testing.T) {
+	const relaxedSIMD = FeatureSIMD | FeatureRelaxedSIMD
+	v128Const := []byte{
+		OpcodeVecPrefix, OpcodeVecV128Const,
+		1, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1,
+	}
+	// relaxed returns the body of an instruction with the given count of v128 operands.
+	relaxed := func(oc OpcodeVecRelaxed, operands int) (ret []byte) {
+		for i := 0; i < operands; i++ {
+			ret = append(ret, v128Const...)
+		}
+		// Relaxed opcodes are above 0xff, so their LEB128 encoding is two bytes.
+		return append(ret, OpcodeVecPrefix, byte(oc&0x7f)|0x80, byte(oc>>7), OpcodeDrop, OpcodeEnd)
+	}
+
+	tests := []struct {
+		name        string
+		body        []byte
+		flag        Features
+		expectedErr string
+	}{
+		{name: OpcodeVecI8x16RelaxedSwizzleName, body: relaxed(OpcodeVecI8x16RelaxedSwizzle, 2)},
+		{name: OpcodeVecI32x4RelaxedTruncF32x4SName, body: relaxed(OpcodeVecI32x4RelaxedTruncF32x4S, 1)},
+		{name: OpcodeVecI32x4RelaxedTruncF32x4UName, body: relaxed(OpcodeVecI32x4RelaxedTruncF32x4U, 1)},
+		{name: OpcodeVecI32x4RelaxedTruncF64x2SZeroName, body: relaxed(OpcodeVecI32x4RelaxedTruncF64x2SZero, 1)},
+		{name: OpcodeVecI32x4RelaxedTruncF64x2UZeroName, body: relaxed(OpcodeVecI32x4RelaxedTruncF64x2UZero, 1)},
+		{name: OpcodeVecF32x4RelaxedMaddName, body: relaxed(OpcodeVecF32x4RelaxedMadd, 3)},
+		{name: OpcodeVecF32x4RelaxedNmaddName, body: relaxed(OpcodeVecF32x4RelaxedNmadd, 3)},
+		{name: OpcodeVecF64x2RelaxedMaddName, body: relaxed(OpcodeVecF64x2RelaxedMadd, 3)},
+		{name: OpcodeVecF64x2RelaxedNmaddName, body: relaxed(OpcodeVecF64x2RelaxedNmadd, 3)},
+		{name: OpcodeVecI8x16RelaxedLaneselectName, body: relaxed(OpcodeVecI8x16RelaxedLaneselect, 3)},
+		{name: OpcodeVecI16x8RelaxedLaneselectName, body: relaxed(OpcodeVecI16x8RelaxedLaneselect, 3)},
+		{name: OpcodeVecI32x4RelaxedLaneselectName, body: relaxed(OpcodeVecI32x4RelaxedLaneselect, 3)},
+		{name: OpcodeVecI64x2RelaxedLaneselectName, body: relaxed(OpcodeVecI64x2RelaxedLaneselect, 3)},
+		{name: OpcodeVecF32x4RelaxedMinName, body: relaxed(OpcodeVecF32x4RelaxedMin, 2)},
+		{name: OpcodeVecF32x4RelaxedMaxName, body: relaxed(OpcodeVecF32x4RelaxedMax, 2)},
+		{name: OpcodeVecF64x2RelaxedMinName, body: relaxed(OpcodeVecF64x2RelaxedMin, 2)},
+		{name: OpcodeVecF64x2RelaxedMaxName, body: relaxed(OpcodeVecF64x2RelaxedMax, 2)},
+		{name: OpcodeVecI16x8RelaxedQ15mulrSName, body: relaxed(OpcodeVecI16x8RelaxedQ15mulrS, 2)},
+		{name: OpcodeVecI16x8RelaxedDotI8x16I7x16SName, body: relaxed(OpcodeVecI16x8RelaxedDotI8x16I7x16S, 2)},
+		{name: OpcodeVecI32x4RelaxedDotI8x16I7x16AddSName, body: relaxed(OpcodeVecI32x4RelaxedDotI8x16I7x16AddS, 3)},
+		{
+			name:        "relaxed-simd disabled",
+			body:        relaxed(OpcodeVecF32x4RelaxedMadd, 3),
+			flag:        FeatureSIMD,
+			expectedErr: "f32x4.relaxed_madd invalid as feature \"relaxed-simd\" is disabled",
+		},
+		{
+			name:        "simd disabled",
+			body:        relaxed(OpcodeVecF32x4RelaxedMadd, 0),
+			flag:        FeatureRelaxedSIMD,
+			expectedErr: "f32x4.relaxed_madd invalid as feature \"simd\" is disabled",
+		},
+		{
+			name:        "operand missing",
+			body:        relaxed(OpcodeVecI16x8RelaxedDotI8x16I7x16S, 1),
+			expectedErr: "cannot pop the operand for i16x8.relaxed_dot_i8x16_i7x16_s: v128 missing",
+		},
+		{
+			name: "operand type",
+			body: append([]byte{OpcodeI32Const, 0},
+				relaxed(OpcodeVecI32x4RelaxedTruncF32x4S, 0)...),
+			expectedErr: "cannot pop the operand for i32x4.relaxed_trunc_f32x4_s: type mismatch: expected v128, but was i32",
+		},
+		{
+			name: "opcode above the relaxed instructions",
+			body: append(append([]byte{}, v128Const...),
+				OpcodeVecPrefix, 0x94, 0x02, OpcodeDrop, OpcodeEnd),
+			expectedErr: "TODO: SIMD instruction f64x2.nearest will be implemented in #506",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			m := &Module{
+				TypeSection:     []*FunctionType{v_v},
+				FunctionSection: []Index{0},
+				CodeSection:     []*Code{{Body: tc.body}},
+			}
+			flag := tc.flag
+			if flag == 0 {
+				flag = relaxedSIMD
+			}
+			err := m.validateFunction(flag, 0, []Index{0}, nil, nil, nil, nil)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
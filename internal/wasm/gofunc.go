@@ -2,11 +2,13 @@ package wasm
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"reflect"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/wasmdebug"
 )
 
 // FunctionKind identifies the type of function that can be called.
@@ -26,6 +28,9 @@ const (
 	// FunctionKindGoContextModule is a function implemented in Go, with a signature matching FunctionType, except arg
 	// zero is a context.Context and arg one is an api.Module.
 	FunctionKindGoContextModule
+	// FunctionKindGoModuleFunction is an api.GoModuleFunction, with an explicit FunctionType. It is called with the
+	// stack, without reflection.
+	FunctionKindGoModuleFunction
 )
 
 // Below are reflection code to get the interface type used to parse functions and set values.
@@ -34,6 +39,14 @@ var moduleType = reflect.TypeOf((*api.Module)(nil)).Elem()
 var goContextType = reflect.TypeOf((*context.Context)(nil)).Elem()
 var errorType = reflect.TypeOf((*error)(nil)).Elem()
 
+// HostFunc is a function with an explicit signature, which NewHostModule accepts as a value of nameToGoFunc instead of
+// a Go func.
+type HostFunc struct {
+	ParamTypes  []ValueType
+	ResultTypes []ValueType
+	Code        api.GoModuleFunction
+}
+
 // PopGoFuncParams pops the correct number of parameters off the stack into a parameter slice for use in CallGoFunc
 //
 // For example, if the host function F requires the (x1 uint32, x2 float32) parameters, and
@@ -41,6 +54,9 @@ var errorType = reflect.TypeOf((*error)(nil)).Elem()
 // as uint32 and float32 respectively.
 func PopGoFuncParams(f *FunctionInstance, popParam func() uint64) []uint64 {
 	// First, determine how many values we need to pop
+	if f.Kind == FunctionKindGoModuleFunction {
+		return PopValues(f.Type.ParamNumInUint64, popParam)
+	}
 	paramCount := f.GoFunc.Type().NumIn()
 	switch f.Kind {
 	case FunctionKindGoNoContext:
@@ -72,8 +88,18 @@ func PopValues(count int, popper func() uint64) []uint64 {
 //
 // * callCtx is passed to the host function as a first argument.
 //
+// If the function has a trailing error result which isn't nil, this panics with wasmdebug.GoFuncError, so that the
+// engine unwinds the Wasm stack.
+//
 // Note: ctx must use the caller's memory, which might be different from the defining module on an imported function.
 func CallGoFunc(ctx context.Context, callCtx *CallContext, f *FunctionInstance, params []uint64) []uint64 {
+	if f.Kind == FunctionKindGoModuleFunction {
+		stack := make([]uint64, f.Type.StackLen())
+		copy(stack, params)
+		f.GoModuleFunc.Call(ctx, callCtx, stack)
+		return stack[:f.Type.ResultNumInUint64]
+	}
+
 	tp := f.GoFunc.Type()
 
 	var in []reflect.Value
@@ -119,7 +145,14 @@ func CallGoFunc(ctx context.Context, callCtx *CallContext, f *FunctionInstance,
 	if tp.NumOut() > 0 {
 		results = make([]uint64, 0, tp.NumOut())
 	}
-	for i, ret := range f.GoFunc.Call(in) {
+	rets := f.GoFunc.Call(in)
+	if n := len(rets); n > 0 && rets[n-1].Kind() == reflect.Interface { // only a trailing error is valid
+		if err := rets[n-1]; !err.IsNil() {
+			panic(&wasmdebug.GoFuncError{Err: err.Interface().(error)})
+		}
+		rets = rets[:n-1]
+	}
+	for i, ret := range rets {
 		switch ret.Kind() {
 		case reflect.Float32:
 			results = append(results, uint64(math.Float32bits(float32(ret.Float()))))
@@ -148,6 +181,41 @@ func newModuleVal(m api.Module) reflect.Value {
 	return val
 }
 
+// getHostFuncType returns the explicit function type of the HostFunc or errs if invalid.
+func getHostFuncType(fn *HostFunc, enabledFeatures Features) (ft *FunctionType, err error) {
+	if fn.Code == nil {
+		return nil, errors.New("code is nil")
+	}
+	if len(fn.ResultTypes) > 1 {
+		// Guard >1.0 feature multi-value
+		if err = enabledFeatures.Require(FeatureMultiValue); err != nil {
+			return nil, fmt.Errorf("multiple result types invalid as %v", err)
+		}
+	}
+	for i, t := range fn.ParamTypes {
+		if !isHostValueType(t) {
+			return nil, fmt.Errorf("param[%d] is unsupported: %s", i, ValueTypeName(t))
+		}
+	}
+	for i, t := range fn.ResultTypes {
+		if !isHostValueType(t) {
+			return nil, fmt.Errorf("result[%d] is unsupported: %s", i, ValueTypeName(t))
+		}
+	}
+	ft = &FunctionType{Params: fn.ParamTypes, Results: fn.ResultTypes}
+	ft.CacheNumInUint64()
+	return
+}
+
+// isHostValueType returns true if the type is supported by host functions, as returned by getTypeOf.
+func isHostValueType(t ValueType) bool {
+	switch t {
+	case ValueTypeI32, ValueTypeI64, ValueTypeF32, ValueTypeF64, ValueTypeExternref:
+		return true
+	}
+	return false
+}
+
 // getFunctionType returns the function type corresponding to the function signature or errs if invalid.
 func getFunctionType(fn *reflect.Value, enabledFeatures Features) (fk FunctionKind, ft *FunctionType, err error) {
 	p := fn.Type()
@@ -168,6 +236,9 @@ func getFunctionType(fn *reflect.Value, enabledFeatures Features) (fk FunctionKi
 	}
 
 	rCount := p.NumOut()
+	if rCount > 0 && isError(p.Out(rCount-1)) {
+		rCount-- // A trailing error isn't a Wasm result.
+	}
 
 	if rCount > 1 {
 		// Guard >1.0 feature multi-value
@@ -211,8 +282,8 @@ func getFunctionType(fn *reflect.Value, enabledFeatures Features) (fk FunctionKi
 		}
 
 		// Now, we will definitely err, decide which message is best
-		if rI.Implements(errorType) {
-			err = fmt.Errorf("result[%d] is an error, which is unsupported", i)
+		if isError(rI) {
+			err = fmt.Errorf("result[%d] is an error, which is only supported as the last result", i)
 		} else {
 			err = fmt.Errorf("result[%d] is unsupported: %s", i, rI.Kind())
 		}
@@ -221,6 +292,13 @@ func getFunctionType(fn *reflect.Value, enabledFeatures Features) (fk FunctionKi
 	return
 }
 
+// isError returns true if the type is an interface which implements error, such as error itself.
+//
+// Note: Concrete error types are excluded, as their nil values would be non-nil errors.
+func isError(t reflect.Type) bool {
+	return t.Kind() == reflect.Interface && t.Implements(errorType)
+}
+
 func kind(p reflect.Type) FunctionKind {
 	pCount := p.NumIn()
 	if pCount > 0 && p.In(0).Kind() == reflect.Interface {
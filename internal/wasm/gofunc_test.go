@@ -2,6 +2,7 @@ package wasm
 
 import (
 	"context"
+	"errors"
 	"math"
 	"reflect"
 	"testing"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasmdebug"
 )
 
 // testCtx is an arbitrary, non-default context. Non-nil also prevents linter errors.
@@ -81,6 +83,18 @@ func TestGetFunctionType(t *testing.T) {
 			expectedKind: FunctionKindGoContextModule,
 			expectedType: &FunctionType{Params: []ValueType{i32, i64, f32, f64, externref}, Results: []ValueType{i32}, ParamNumInUint64: 5, ResultNumInUint64: 1},
 		},
+		{
+			name:         "error result",
+			inputFunc:    func() error { return nil },
+			expectedKind: FunctionKindGoNoContext,
+			expectedType: &FunctionType{Params: []ValueType{}, Results: []ValueType{}},
+		},
+		{
+			name:         "i32 and error result",
+			inputFunc:    func(context.Context, uint32) (uint32, error) { return 0, nil },
+			expectedKind: FunctionKindGoContext,
+			expectedType: &FunctionType{Params: []ValueType{i32}, Results: []ValueType{i32}, ParamNumInUint64: 1, ResultNumInUint64: 1},
+		},
 	}
 	for _, tt := range tests {
 		tc := tt
@@ -118,9 +132,19 @@ func TestGetFunctionTypeErrors(t *testing.T) {
 			expectedErr: "result[0] is unsupported: string",
 		},
 		{
-			name:        "error result",
-			input:       func() error { return nil },
-			expectedErr: "result[0] is an error, which is unsupported",
+			name:        "error result not last",
+			input:       func() (error, error) { return nil, nil },
+			expectedErr: "result[0] is an error, which is only supported as the last result",
+		},
+		{
+			name:        "concrete error result",
+			input:       func() *testError { return nil },
+			expectedErr: "result[0] is unsupported: ptr",
+		},
+		{
+			name:        "i32 and error result - multi-value not enabled",
+			input:       func() (uint64, uint32, error) { return 0, 0, nil },
+			expectedErr: "multiple result types invalid as feature \"multi-value\" is disabled",
 		},
 		{
 			name:        "multiple results - multi-value not enabled",
@@ -155,6 +179,61 @@ func TestGetFunctionTypeErrors(t *testing.T) {
 	}
 }
 
+func TestGetHostFuncType(t *testing.T) {
+	code := api.GoModuleFunc(func(context.Context, api.Module, []uint64) {})
+	ft, err := getHostFuncType(&HostFunc{
+		ParamTypes:  []ValueType{ValueTypeI32, ValueTypeF64},
+		ResultTypes: []ValueType{ValueTypeI64, ValueTypeExternref},
+		Code:        code,
+	}, Features20220419)
+	require.NoError(t, err)
+	require.Equal(t, &FunctionType{
+		Params:            []ValueType{ValueTypeI32, ValueTypeF64},
+		Results:           []ValueType{ValueTypeI64, ValueTypeExternref},
+		ParamNumInUint64:  2,
+		ResultNumInUint64: 2,
+	}, ft)
+}
+
+func TestGetHostFuncTypeErrors(t *testing.T) {
+	code := api.GoModuleFunc(func(context.Context, api.Module, []uint64) {})
+	tests := []struct {
+		name        string
+		input       *HostFunc
+		expectedErr string
+	}{
+		{
+			name:        "nil code",
+			input:       &HostFunc{},
+			expectedErr: "code is nil",
+		},
+		{
+			name:        "unsupported param",
+			input:       &HostFunc{ParamTypes: []ValueType{ValueTypeI32, ValueTypeFuncref}, Code: code},
+			expectedErr: "param[1] is unsupported: funcref",
+		},
+		{
+			name:        "unsupported result",
+			input:       &HostFunc{ResultTypes: []ValueType{ValueTypeV128}, Code: code},
+			expectedErr: "result[0] is unsupported: v128",
+		},
+		{
+			name:        "multiple results - multi-value not enabled",
+			input:       &HostFunc{ResultTypes: []ValueType{ValueTypeI64, ValueTypeI32}, Code: code},
+			expectedErr: "multiple result types invalid as feature \"multi-value\" is disabled",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := getHostFuncType(tc.input, Features20191205)
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
+
 // stack simulates the value stack in a way easy to be tested.
 type stack struct {
 	vals []uint64
@@ -401,6 +480,14 @@ func TestCallGoFunc(t *testing.T) {
 			},
 			expectedResults: []uint64{100},
 		},
+		{
+			name: "i32 and nil error result",
+			inputFunc: func(x uint32) (uint32, error) {
+				return x + 1, nil
+			},
+			inputParams:     []uint64{1},
+			expectedResults: []uint64{2},
+		},
 	}
 	for _, tt := range tests {
 		tc := tt
@@ -415,3 +502,73 @@ func TestCallGoFunc(t *testing.T) {
 		})
 	}
 }
+
+func TestCallGoFunc_GoModuleFunction(t *testing.T) {
+	callCtx := &CallContext{}
+	tests := []struct {
+		name                         string
+		params, results              []ValueType
+		inputParams, expectedResults []uint64
+		expectedStackLen             int
+	}{
+		{
+			name:             "more params than results",
+			params:           []ValueType{ValueTypeI32, ValueTypeI32},
+			results:          []ValueType{ValueTypeI32},
+			inputParams:      []uint64{1, 2},
+			expectedResults:  []uint64{1}, // the first of the stack
+			expectedStackLen: 2,
+		},
+		{
+			name:             "more results than params",
+			params:           []ValueType{ValueTypeI32},
+			results:          []ValueType{ValueTypeI32, ValueTypeI32},
+			inputParams:      []uint64{1},
+			expectedResults:  []uint64{1, 3},
+			expectedStackLen: 2,
+		},
+		{
+			name:            "no params or results",
+			expectedResults: []uint64{},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			code := api.GoModuleFunc(func(ctx context.Context, mod api.Module, stack []uint64) {
+				require.Equal(t, testCtx, ctx)
+				require.Equal(t, callCtx, mod)
+				require.Equal(t, tc.expectedStackLen, len(stack))
+				if len(stack) > 0 {
+					stack[len(stack)-1] = 3
+				}
+			})
+			ft, err := getHostFuncType(&HostFunc{ParamTypes: tc.params, ResultTypes: tc.results, Code: code}, Features20220419)
+			require.NoError(t, err)
+
+			f := &FunctionInstance{Kind: FunctionKindGoModuleFunction, Type: ft, GoModuleFunc: code}
+			results := CallGoFunc(testCtx, callCtx, f, tc.inputParams)
+			require.Equal(t, tc.expectedResults, results)
+		})
+	}
+}
+
+type testError struct{}
+
+func (*testError) Error() string { return "test" }
+
+func TestCallGoFunc_Error(t *testing.T) {
+	expectedErr := errors.New("whoops")
+	goFunc := reflect.ValueOf(func(uint32) (uint32, error) {
+		return 0, expectedErr
+	})
+	fk, _, err := getFunctionType(&goFunc, Features20220419)
+	require.NoError(t, err)
+
+	captured := require.CapturePanic(func() {
+		CallGoFunc(testCtx, &CallContext{}, &FunctionInstance{Kind: fk, GoFunc: &goFunc}, []uint64{1})
+	})
+	require.Equal(t, &wasmdebug.GoFuncError{Err: expectedErr}, captured)
+}
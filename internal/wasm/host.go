@@ -6,6 +6,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/experimental"
 	"github.com/tetratelabs/wazero/internal/wasmdebug"
 )
@@ -15,6 +16,7 @@ func NewHostModule(
 	moduleName string,
 	nameToGoFunc map[string]interface{},
 	nameToMemory map[string]*Memory,
+	nameToTable map[string]*Table,
 	nameToGlobal map[string]*Global,
 	enabledFeatures Features,
 ) (m *Module, err error) {
@@ -26,8 +28,9 @@ func NewHostModule(
 
 	funcCount := uint32(len(nameToGoFunc))
 	memoryCount := uint32(len(nameToMemory))
+	tableCount := uint32(len(nameToTable))
 	globalCount := uint32(len(nameToGlobal))
-	exportCount := funcCount + memoryCount + globalCount
+	exportCount := funcCount + memoryCount + tableCount + globalCount
 	if exportCount > 0 {
 		m.ExportSection = make([]*Export, 0, exportCount)
 	}
@@ -37,15 +40,26 @@ func NewHostModule(
 		if _, ok := nameToMemory[name]; ok {
 			return nil, fmt.Errorf("func[%s] exports the same name as a memory", name)
 		}
+		if _, ok := nameToTable[name]; ok {
+			return nil, fmt.Errorf("func[%s] exports the same name as a table", name)
+		}
 		if _, ok := nameToGlobal[name]; ok {
 			return nil, fmt.Errorf("func[%s] exports the same name as a global", name)
 		}
 	}
 	for name := range nameToMemory {
+		if _, ok := nameToTable[name]; ok {
+			return nil, fmt.Errorf("memory[%s] exports the same name as a table", name)
+		}
 		if _, ok := nameToGlobal[name]; ok {
 			return nil, fmt.Errorf("memory[%s] exports the same name as a global", name)
 		}
 	}
+	for name := range nameToTable {
+		if _, ok := nameToGlobal[name]; ok {
+			return nil, fmt.Errorf("table[%s] exports the same name as a global", name)
+		}
+	}
 
 	if funcCount > 0 {
 		if err = addFuncs(m, nameToGoFunc, enabledFeatures); err != nil {
@@ -54,7 +68,13 @@ func NewHostModule(
 	}
 
 	if memoryCount > 0 {
-		if err = addMemory(m, nameToMemory); err != nil {
+		if err = addMemory(m, nameToMemory, enabledFeatures); err != nil {
+			return
+		}
+	}
+
+	if tableCount > 0 {
+		if err = addTables(m, nameToTable, enabledFeatures); err != nil {
 			return
 		}
 	}
@@ -67,8 +87,8 @@ func NewHostModule(
 	}
 
 	// Assins the ModuleID by calculating sha256 on inputs as host modules do not have `wasm` to hash.
-	m.AssignModuleID([]byte(fmt.Sprintf("%s:%v:%v:%v:%v",
-		moduleName, nameToGoFunc, nameToMemory, nameToGlobal, enabledFeatures)))
+	m.AssignModuleID([]byte(fmt.Sprintf("%s:%v:%v:%v:%v:%v",
+		moduleName, nameToGoFunc, nameToMemory, nameToTable, nameToGlobal, enabledFeatures)))
 	return
 }
 
@@ -94,8 +114,16 @@ func addFuncs(m *Module, nameToGoFunc map[string]interface{}, enabledFeatures Fe
 
 	for idx := Index(0); idx < funcCount; idx++ {
 		name := funcNames[idx]
-		fn := reflect.ValueOf(nameToGoFunc[name])
-		_, functionType, err := getFunctionType(&fn, enabledFeatures)
+		var fn reflect.Value
+		var functionType *FunctionType
+		var err error
+		if hf, ok := nameToGoFunc[name].(*HostFunc); ok {
+			fn = reflect.ValueOf(hf.Code)
+			functionType, err = getHostFuncType(hf, enabledFeatures)
+		} else {
+			fn = reflect.ValueOf(nameToGoFunc[name])
+			_, functionType, err = getFunctionType(&fn, enabledFeatures)
+		}
 		if err != nil {
 			return fmt.Errorf("func[%s] %w", name, err)
 		}
@@ -108,30 +136,66 @@ func addFuncs(m *Module, nameToGoFunc map[string]interface{}, enabledFeatures Fe
 	return nil
 }
 
-func addMemory(m *Module, nameToMemory map[string]*Memory) error {
+func addMemory(m *Module, nameToMemory map[string]*Memory, enabledFeatures Features) error {
 	memoryCount := uint32(len(nameToMemory))
 
-	// Only one memory can be defined or imported
+	memoryNames := make([]string, 0, memoryCount)
+	for k := range nameToMemory {
+		memoryNames = append(memoryNames, k)
+	}
+	sort.Strings(memoryNames) // For consistent iteration order
+
+	// Only one memory can be defined or imported before multi-memory
 	if memoryCount > 1 {
-		memoryNames := make([]string, 0, memoryCount)
-		for k := range nameToMemory {
-			memoryNames = append(memoryNames, k)
+		if err := enabledFeatures.Require(FeatureMultiMemory); err != nil {
+			return fmt.Errorf("only one memory is allowed, but configured: %s: %w", strings.Join(memoryNames, ", "), err)
 		}
-		sort.Strings(memoryNames) // For consistent error messages
-		return fmt.Errorf("only one memory is allowed, but configured: %s", strings.Join(memoryNames, ", "))
 	}
 
-	// Find the memory name to export.
-	var name string
-	for k, v := range nameToMemory {
-		name = k
+	m.MemorySection = make([]*Memory, 0, memoryCount)
+	for i, name := range memoryNames {
+		v := nameToMemory[name]
 		if v.Min > v.Max {
 			return fmt.Errorf("memory[%s] min %d pages (%s) > max %d pages (%s)", name, v.Min, PagesToUnitOfBytes(v.Min), v.Max, PagesToUnitOfBytes(v.Max))
 		}
-		m.MemorySection = v
+		m.MemorySection = append(m.MemorySection, v)
+		m.ExportSection = append(m.ExportSection, &Export{Type: ExternTypeMemory, Name: name, Index: Index(i)})
+	}
+	return nil
+}
+
+func addTables(m *Module, nameToTable map[string]*Table, enabledFeatures Features) error {
+	tableCount := len(nameToTable)
+
+	tableNames := make([]string, 0, tableCount)
+	for name := range nameToTable {
+		tableNames = append(tableNames, name)
+	}
+	sort.Strings(tableNames) // For consistent iteration order
+
+	// Only one table can be defined or imported before reference-types
+	if tableCount > 1 {
+		if err := enabledFeatures.Require(FeatureReferenceTypes); err != nil {
+			return fmt.Errorf("only one table is allowed, but configured: %s: %w", strings.Join(tableNames, ", "), err)
+		}
 	}
 
-	m.ExportSection = append(m.ExportSection, &Export{Type: ExternTypeMemory, Name: name, Index: 0})
+	m.TableSection = make([]*Table, 0, tableCount)
+	for i, name := range tableNames {
+		t := nameToTable[name]
+		if t.Type != RefTypeFuncref && t.Type != RefTypeExternref {
+			return fmt.Errorf("table[%s] invalid type: %s", name, ValueTypeName(t.Type))
+		} else if t.Type == RefTypeExternref {
+			if err := enabledFeatures.Require(FeatureReferenceTypes); err != nil {
+				return fmt.Errorf("table[%s] %s: %w", name, RefTypeName(t.Type), err)
+			}
+		}
+		if t.Max != nil && t.Min > *t.Max {
+			return fmt.Errorf("table[%s] min %d > max %d", name, t.Min, *t.Max)
+		}
+		m.TableSection = append(m.TableSection, t)
+		m.ExportSection = append(m.ExportSection, &Export{Type: ExternTypeTable, Name: name, Index: Index(i)})
+	}
 	return nil
 }
 
@@ -174,11 +238,15 @@ func (m *Module) buildHostFunctions(
 	for idx, typeIndex := range m.FunctionSection {
 		fn := m.HostFunctionSection[idx]
 		f := &FunctionInstance{
-			Kind:   kind(fn.Type()),
 			Type:   m.TypeSection[typeIndex],
 			GoFunc: fn,
 			Idx:    Index(idx),
 		}
+		if code, ok := fn.Interface().(api.GoModuleFunction); ok { // from a HostFunc
+			f.Kind, f.GoModuleFunc = FunctionKindGoModuleFunction, code
+		} else {
+			f.Kind = kind(fn.Type())
+		}
 		name := functionNames[f.Idx].Name
 		f.moduleName = moduleName
 		f.DebugName = wasmdebug.FuncName(moduleName, name, f.Idx)
@@ -39,11 +39,13 @@ func TestNewHostModule(t *testing.T) {
 	fnFdWrite := reflect.ValueOf(a.FdWrite)
 	functionSwap := "swap"
 	fnSwap := reflect.ValueOf(swap)
+	max := uint32(10)
 
 	tests := []struct {
 		name, moduleName string
 		nameToGoFunc     map[string]interface{}
 		nameToMemory     map[string]*Memory
+		nameToTable      map[string]*Table
 		nameToGlobal     map[string]*Global
 		expected         *Module
 	}{
@@ -101,10 +103,27 @@ func TestNewHostModule(t *testing.T) {
 			name:         "memory",
 			nameToMemory: map[string]*Memory{"memory": {Min: 1, Max: 2}},
 			expected: &Module{
-				MemorySection: &Memory{Min: 1, Max: 2},
+				MemorySection: []*Memory{{Min: 1, Max: 2}},
 				ExportSection: []*Export{{Name: "memory", Type: ExternTypeMemory, Index: 0}},
 			},
 		},
+		{
+			name: "tables",
+			nameToTable: map[string]*Table{
+				"table2": {Min: 1, Type: RefTypeExternref},
+				"table1": {Min: 2, Max: &max, Type: RefTypeFuncref},
+			},
+			expected: &Module{
+				TableSection: []*Table{
+					{Min: 2, Max: &max, Type: RefTypeFuncref},
+					{Min: 1, Type: RefTypeExternref},
+				},
+				ExportSection: []*Export{
+					{Name: "table1", Type: ExternTypeTable, Index: 0},
+					{Name: "table2", Type: ExternTypeTable, Index: 1},
+				},
+			},
+		},
 		{
 			name: "globals",
 			nameToGlobal: map[string]*Global{
@@ -161,7 +180,7 @@ func TestNewHostModule(t *testing.T) {
 						Init: &ConstantExpression{Opcode: OpcodeI32Const, Data: const1},
 					},
 				},
-				MemorySection: &Memory{Min: 1, Max: 1},
+				MemorySection: []*Memory{{Min: 1, Max: 1}},
 				ExportSection: []*Export{
 					{Name: "args_sizes_get", Type: ExternTypeFunc, Index: 0},
 					{Name: "memory", Type: ExternTypeMemory, Index: 0},
@@ -185,8 +204,9 @@ func TestNewHostModule(t *testing.T) {
 				tc.moduleName,
 				tc.nameToGoFunc,
 				tc.nameToMemory,
+				tc.nameToTable,
 				tc.nameToGlobal,
-				Features20191205|FeatureMultiValue,
+				Features20191205|FeatureMultiValue|FeatureReferenceTypes,
 			)
 			require.NoError(t, e)
 			requireHostModuleEquals(t, tc.expected, m)
@@ -217,10 +237,12 @@ func requireHostModuleEquals(t *testing.T, expected, actual *Module) {
 }
 
 func TestNewHostModule_Errors(t *testing.T) {
+	one := uint32(1)
 	tests := []struct {
 		name, moduleName string
 		nameToGoFunc     map[string]interface{}
 		nameToMemory     map[string]*Memory
+		nameToTable      map[string]*Table
 		nameToGlobal     map[string]*Global
 		expectedErr      string
 	}{
@@ -244,13 +266,39 @@ func TestNewHostModule_Errors(t *testing.T) {
 		{
 			name:         "multiple memories",
 			nameToMemory: map[string]*Memory{"memory": {Min: 1, Max: 1}, "mem": {Min: 2, Max: 2}},
-			expectedErr:  "only one memory is allowed, but configured: mem, memory",
+			expectedErr:  "only one memory is allowed, but configured: mem, memory: feature \"multi-memory\" is disabled",
 		},
 		{
 			name:         "memory max < min",
 			nameToMemory: map[string]*Memory{"memory": {Min: 1, Max: 0}},
 			expectedErr:  "memory[memory] min 1 pages (64 Ki) > max 0 pages (0 Ki)",
 		},
+		{
+			name:        "multiple tables",
+			nameToTable: map[string]*Table{"table": {Type: RefTypeFuncref}, "tab": {Type: RefTypeFuncref}},
+			expectedErr: "only one table is allowed, but configured: tab, table: feature \"reference-types\" is disabled",
+		},
+		{
+			name:        "externref table",
+			nameToTable: map[string]*Table{"table": {Type: RefTypeExternref}},
+			expectedErr: "table[table] externref: feature \"reference-types\" is disabled",
+		},
+		{
+			name:        "table invalid type",
+			nameToTable: map[string]*Table{"table": {Type: ValueTypeI32}},
+			expectedErr: "table[table] invalid type: i32",
+		},
+		{
+			name:        "table max < min",
+			nameToTable: map[string]*Table{"table": {Min: 2, Max: &one, Type: RefTypeFuncref}},
+			expectedErr: "table[table] min 2 > max 1",
+		},
+		{
+			name:         "memory collides on table name",
+			nameToMemory: map[string]*Memory{"t": {Min: 1, Max: 1}},
+			nameToTable:  map[string]*Table{"t": {Type: RefTypeFuncref}},
+			expectedErr:  "memory[t] exports the same name as a table",
+		},
 		{
 			name:         "func collides on global name",
 			nameToGoFunc: map[string]interface{}{"fn": ArgsSizesGet},
@@ -263,7 +311,7 @@ func TestNewHostModule_Errors(t *testing.T) {
 		tc := tt
 
 		t.Run(tc.name, func(t *testing.T) {
-			_, e := NewHostModule(tc.moduleName, tc.nameToGoFunc, tc.nameToMemory, tc.nameToGlobal, Features20191205)
+			_, e := NewHostModule(tc.moduleName, tc.nameToGoFunc, tc.nameToMemory, tc.nameToTable, tc.nameToGlobal, Features20191205)
 			require.EqualError(t, e, tc.expectedErr)
 		})
 	}
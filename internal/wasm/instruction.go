@@ -23,6 +23,18 @@ const (
 	// OpcodeEnd terminates a control instruction OpcodeBlock, OpcodeLoop or OpcodeIf.
 	OpcodeEnd Opcode = 0x0b
 
+	// OpcodeTry begins a block whose exceptions are handled by the OpcodeCatch, OpcodeCatchAll or OpcodeDelegate
+	// which follow it. OpcodeThrow throws an exception of a tag, and OpcodeRethrow the exception caught by an enclosing
+	// catch clause. These are only valid when FeatureExceptionHandling is enabled.
+	//
+	// See https://github.com/WebAssembly/exception-handling/blob/main/proposals/exception-handling/Exceptions.md
+	OpcodeTry      Opcode = 0x06
+	OpcodeCatch    Opcode = 0x07
+	OpcodeThrow    Opcode = 0x08
+	OpcodeRethrow  Opcode = 0x09
+	OpcodeDelegate Opcode = 0x18
+	OpcodeCatchAll Opcode = 0x19
+
 	// OpcodeBr is a stack-polymorphic opcode that performs an unconditional branch. How the stack is modified depends
 	// on whether the "br" is enclosed by a loop, and if FeatureMultiValue is enabled.
 	//
@@ -43,6 +55,11 @@ const (
 	OpcodeCall         Opcode = 0x10
 	OpcodeCallIndirect Opcode = 0x11
 
+	// OpcodeReturnCall and OpcodeReturnCallIndirect are tail calls, which return the results of the function they call.
+	// These are only valid when FeatureTailCall is enabled.
+	OpcodeReturnCall         Opcode = 0x12
+	OpcodeReturnCallIndirect Opcode = 0x13
+
 	// parametric instructions
 
 	OpcodeDrop        Opcode = 0x1a
@@ -281,8 +298,18 @@ const (
 	// OpcodeVecPrefix is the prefix of all vector isntructions introduced in
 	// FeatureSIMD.
 	OpcodeVecPrefix Opcode = 0xfd
+
+	// OpcodeAtomicPrefix is the prefix of all atomic instructions introduced in
+	// FeatureThreads.
+	OpcodeAtomicPrefix Opcode = 0xfe
 )
 
+// MemoryArgMemoryIndexFlag is set in the alignment of the "memarg" immediate of a memory instruction when a memory
+// index follows it, which is only valid with FeatureMultiMemory. Otherwise, the instruction accesses the memory zero.
+//
+// See https://github.com/WebAssembly/multi-memory/blob/main/proposals/multi-memory/Overview.md#binary-format
+const MemoryArgMemoryIndexFlag = 1 << 6
+
 // OpcodeMisc represents opcodes of the miscellaneous operations.
 // Such an operations has multi-byte encoding which is prefixed by OpcodeMiscPrefix.
 type OpcodeMisc = byte
@@ -320,6 +347,90 @@ const (
 	OpcodeMiscTableFill OpcodeMisc = 0x11
 )
 
+// OpcodeAtomic represents an opcode of the atomic instructions, which has multi-byte encoding and is prefixed by
+// OpcodeAtomicPrefix.
+//
+// These opcodes are toggled with FeatureThreads.
+// See https://github.com/WebAssembly/threads/blob/main/proposals/threads/Overview.md
+type OpcodeAtomic = byte
+
+const (
+	OpcodeAtomicMemoryNotify OpcodeAtomic = 0x00
+	OpcodeAtomicMemoryWait32 OpcodeAtomic = 0x01
+	OpcodeAtomicMemoryWait64 OpcodeAtomic = 0x02
+	OpcodeAtomicFence        OpcodeAtomic = 0x03
+
+	OpcodeAtomicI32Load    OpcodeAtomic = 0x10
+	OpcodeAtomicI64Load    OpcodeAtomic = 0x11
+	OpcodeAtomicI32Load8U  OpcodeAtomic = 0x12
+	OpcodeAtomicI32Load16U OpcodeAtomic = 0x13
+	OpcodeAtomicI64Load8U  OpcodeAtomic = 0x14
+	OpcodeAtomicI64Load16U OpcodeAtomic = 0x15
+	OpcodeAtomicI64Load32U OpcodeAtomic = 0x16
+
+	OpcodeAtomicI32Store   OpcodeAtomic = 0x17
+	OpcodeAtomicI64Store   OpcodeAtomic = 0x18
+	OpcodeAtomicI32Store8  OpcodeAtomic = 0x19
+	OpcodeAtomicI32Store16 OpcodeAtomic = 0x1a
+	OpcodeAtomicI64Store8  OpcodeAtomic = 0x1b
+	OpcodeAtomicI64Store16 OpcodeAtomic = 0x1c
+	OpcodeAtomicI64Store32 OpcodeAtomic = 0x1d
+
+	// Atomic read-modify-write operations push the value read, zero-extended to their type.
+
+	OpcodeAtomicI32RmwAdd     OpcodeAtomic = 0x1e
+	OpcodeAtomicI64RmwAdd     OpcodeAtomic = 0x1f
+	OpcodeAtomicI32Rmw8AddU   OpcodeAtomic = 0x20
+	OpcodeAtomicI32Rmw16AddU  OpcodeAtomic = 0x21
+	OpcodeAtomicI64Rmw8AddU   OpcodeAtomic = 0x22
+	OpcodeAtomicI64Rmw16AddU  OpcodeAtomic = 0x23
+	OpcodeAtomicI64Rmw32AddU  OpcodeAtomic = 0x24
+	OpcodeAtomicI32RmwSub     OpcodeAtomic = 0x25
+	OpcodeAtomicI64RmwSub     OpcodeAtomic = 0x26
+	OpcodeAtomicI32Rmw8SubU   OpcodeAtomic = 0x27
+	OpcodeAtomicI32Rmw16SubU  OpcodeAtomic = 0x28
+	OpcodeAtomicI64Rmw8SubU   OpcodeAtomic = 0x29
+	OpcodeAtomicI64Rmw16SubU  OpcodeAtomic = 0x2a
+	OpcodeAtomicI64Rmw32SubU  OpcodeAtomic = 0x2b
+	OpcodeAtomicI32RmwAnd     OpcodeAtomic = 0x2c
+	OpcodeAtomicI64RmwAnd     OpcodeAtomic = 0x2d
+	OpcodeAtomicI32Rmw8AndU   OpcodeAtomic = 0x2e
+	OpcodeAtomicI32Rmw16AndU  OpcodeAtomic = 0x2f
+	OpcodeAtomicI64Rmw8AndU   OpcodeAtomic = 0x30
+	OpcodeAtomicI64Rmw16AndU  OpcodeAtomic = 0x31
+	OpcodeAtomicI64Rmw32AndU  OpcodeAtomic = 0x32
+	OpcodeAtomicI32RmwOr      OpcodeAtomic = 0x33
+	OpcodeAtomicI64RmwOr      OpcodeAtomic = 0x34
+	OpcodeAtomicI32Rmw8OrU    OpcodeAtomic = 0x35
+	OpcodeAtomicI32Rmw16OrU   OpcodeAtomic = 0x36
+	OpcodeAtomicI64Rmw8OrU    OpcodeAtomic = 0x37
+	OpcodeAtomicI64Rmw16OrU   OpcodeAtomic = 0x38
+	OpcodeAtomicI64Rmw32OrU   OpcodeAtomic = 0x39
+	OpcodeAtomicI32RmwXor     OpcodeAtomic = 0x3a
+	OpcodeAtomicI64RmwXor     OpcodeAtomic = 0x3b
+	OpcodeAtomicI32Rmw8XorU   OpcodeAtomic = 0x3c
+	OpcodeAtomicI32Rmw16XorU  OpcodeAtomic = 0x3d
+	OpcodeAtomicI64Rmw8XorU   OpcodeAtomic = 0x3e
+	OpcodeAtomicI64Rmw16XorU  OpcodeAtomic = 0x3f
+	OpcodeAtomicI64Rmw32XorU  OpcodeAtomic = 0x40
+	OpcodeAtomicI32RmwXchg    OpcodeAtomic = 0x41
+	OpcodeAtomicI64RmwXchg    OpcodeAtomic = 0x42
+	OpcodeAtomicI32Rmw8XchgU  OpcodeAtomic = 0x43
+	OpcodeAtomicI32Rmw16XchgU OpcodeAtomic = 0x44
+	OpcodeAtomicI64Rmw8XchgU  OpcodeAtomic = 0x45
+	OpcodeAtomicI64Rmw16XchgU OpcodeAtomic = 0x46
+	OpcodeAtomicI64Rmw32XchgU OpcodeAtomic = 0x47
+	// Atomic compare and exchange replaces the value read when it equals the expected one.
+
+	OpcodeAtomicI32RmwCmpxchg    OpcodeAtomic = 0x48
+	OpcodeAtomicI64RmwCmpxchg    OpcodeAtomic = 0x49
+	OpcodeAtomicI32Rmw8CmpxchgU  OpcodeAtomic = 0x4a
+	OpcodeAtomicI32Rmw16CmpxchgU OpcodeAtomic = 0x4b
+	OpcodeAtomicI64Rmw8CmpxchgU  OpcodeAtomic = 0x4c
+	OpcodeAtomicI64Rmw16CmpxchgU OpcodeAtomic = 0x4d
+	OpcodeAtomicI64Rmw32CmpxchgU OpcodeAtomic = 0x4e
+)
+
 // OpcodeVec represents an opcode of a vector instructions which has
 // multi-byte encoding and is prefixed by OpcodeMiscPrefix.
 //
@@ -623,6 +734,46 @@ const (
 	OpcodeVecF64x2PromoteLowF32x4Zero OpcodeVec = 0x5f
 )
 
+// OpcodeVecRelaxed represents an opcode of a relaxed vector instruction, which is prefixed by OpcodeVecPrefix like
+// OpcodeVec. Unlike OpcodeVec, these opcodes don't fit in a byte, so they are always encoded in two: the lower seven
+// bits with the LEB128 continuation bit, followed by 0x02.
+//
+// These opcodes are toggled with FeatureRelaxedSIMD.
+type OpcodeVecRelaxed = uint32
+
+const (
+	OpcodeVecI8x16RelaxedSwizzle           OpcodeVecRelaxed = 0x100
+	OpcodeVecI32x4RelaxedTruncF32x4S       OpcodeVecRelaxed = 0x101
+	OpcodeVecI32x4RelaxedTruncF32x4U       OpcodeVecRelaxed = 0x102
+	OpcodeVecI32x4RelaxedTruncF64x2SZero   OpcodeVecRelaxed = 0x103
+	OpcodeVecI32x4RelaxedTruncF64x2UZero   OpcodeVecRelaxed = 0x104
+	OpcodeVecF32x4RelaxedMadd              OpcodeVecRelaxed = 0x105
+	OpcodeVecF32x4RelaxedNmadd             OpcodeVecRelaxed = 0x106
+	OpcodeVecF64x2RelaxedMadd              OpcodeVecRelaxed = 0x107
+	OpcodeVecF64x2RelaxedNmadd             OpcodeVecRelaxed = 0x108
+	OpcodeVecI8x16RelaxedLaneselect        OpcodeVecRelaxed = 0x109
+	OpcodeVecI16x8RelaxedLaneselect        OpcodeVecRelaxed = 0x10a
+	OpcodeVecI32x4RelaxedLaneselect        OpcodeVecRelaxed = 0x10b
+	OpcodeVecI64x2RelaxedLaneselect        OpcodeVecRelaxed = 0x10c
+	OpcodeVecF32x4RelaxedMin               OpcodeVecRelaxed = 0x10d
+	OpcodeVecF32x4RelaxedMax               OpcodeVecRelaxed = 0x10e
+	OpcodeVecF64x2RelaxedMin               OpcodeVecRelaxed = 0x10f
+	OpcodeVecF64x2RelaxedMax               OpcodeVecRelaxed = 0x110
+	OpcodeVecI16x8RelaxedQ15mulrS          OpcodeVecRelaxed = 0x111
+	OpcodeVecI16x8RelaxedDotI8x16I7x16S    OpcodeVecRelaxed = 0x112
+	OpcodeVecI32x4RelaxedDotI8x16I7x16AddS OpcodeVecRelaxed = 0x113
+)
+
+// DecodeOpcodeVecRelaxed returns the OpcodeVecRelaxed encoded at the beginning of body, which is the code following
+// OpcodeVecPrefix, or false if body doesn't begin with one. When true, the opcode is two bytes long.
+func DecodeOpcodeVecRelaxed(body []byte) (OpcodeVecRelaxed, bool) {
+	if len(body) < 2 || body[0] < 0x80 || body[1] != 0x02 {
+		return 0, false
+	}
+	oc := OpcodeVecRelaxed(body[0]&0x7f) | 0x100
+	return oc, oc <= OpcodeVecI32x4RelaxedDotI8x16I7x16AddS
+}
+
 const (
 	OpcodeUnreachableName       = "unreachable"
 	OpcodeNopName               = "nop"
@@ -813,8 +964,23 @@ const (
 	OpcodeI64Extend16SName = "i64.extend16_s"
 	OpcodeI64Extend32SName = "i64.extend32_s"
 
-	OpcodeMiscPrefixName = "misc_prefix"
-	OpcodeVecPrefixName  = "vector_prefix"
+	// Below are toggled with FeatureTailCall
+
+	OpcodeReturnCallName         = "return_call"
+	OpcodeReturnCallIndirectName = "return_call_indirect"
+
+	// Below are toggled with FeatureExceptionHandling
+
+	OpcodeTryName      = "try"
+	OpcodeCatchName    = "catch"
+	OpcodeThrowName    = "throw"
+	OpcodeRethrowName  = "rethrow"
+	OpcodeDelegateName = "delegate"
+	OpcodeCatchAllName = "catch_all"
+
+	OpcodeMiscPrefixName   = "misc_prefix"
+	OpcodeVecPrefixName    = "vector_prefix"
+	OpcodeAtomicPrefixName = "atomic_prefix"
 )
 
 var instructionNames = [256]string{
@@ -1007,8 +1173,23 @@ var instructionNames = [256]string{
 	OpcodeI64Extend16S: OpcodeI64Extend16SName,
 	OpcodeI64Extend32S: OpcodeI64Extend32SName,
 
-	OpcodeMiscPrefix: OpcodeMiscPrefixName,
-	OpcodeVecPrefix:  OpcodeVecPrefixName,
+	// Below are toggled with FeatureTailCall
+
+	OpcodeReturnCall:         OpcodeReturnCallName,
+	OpcodeReturnCallIndirect: OpcodeReturnCallIndirectName,
+
+	// Below are toggled with FeatureExceptionHandling
+
+	OpcodeTry:      OpcodeTryName,
+	OpcodeCatch:    OpcodeCatchName,
+	OpcodeThrow:    OpcodeThrowName,
+	OpcodeRethrow:  OpcodeRethrowName,
+	OpcodeDelegate: OpcodeDelegateName,
+	OpcodeCatchAll: OpcodeCatchAllName,
+
+	OpcodeMiscPrefix:   OpcodeMiscPrefixName,
+	OpcodeVecPrefix:    OpcodeVecPrefixName,
+	OpcodeAtomicPrefix: OpcodeAtomicPrefixName,
 }
 
 // InstructionName returns the instruction corresponding to this binary Opcode.
@@ -1548,3 +1729,231 @@ var vectorInstructionName = map[OpcodeVec]string{
 func VectorInstructionName(oc OpcodeVec) (ret string) {
 	return vectorInstructionName[oc]
 }
+
+const (
+	OpcodeVecI8x16RelaxedSwizzleName           = "i8x16.relaxed_swizzle"
+	OpcodeVecI32x4RelaxedTruncF32x4SName       = "i32x4.relaxed_trunc_f32x4_s"
+	OpcodeVecI32x4RelaxedTruncF32x4UName       = "i32x4.relaxed_trunc_f32x4_u"
+	OpcodeVecI32x4RelaxedTruncF64x2SZeroName   = "i32x4.relaxed_trunc_f64x2_s_zero"
+	OpcodeVecI32x4RelaxedTruncF64x2UZeroName   = "i32x4.relaxed_trunc_f64x2_u_zero"
+	OpcodeVecF32x4RelaxedMaddName              = "f32x4.relaxed_madd"
+	OpcodeVecF32x4RelaxedNmaddName             = "f32x4.relaxed_nmadd"
+	OpcodeVecF64x2RelaxedMaddName              = "f64x2.relaxed_madd"
+	OpcodeVecF64x2RelaxedNmaddName             = "f64x2.relaxed_nmadd"
+	OpcodeVecI8x16RelaxedLaneselectName        = "i8x16.relaxed_laneselect"
+	OpcodeVecI16x8RelaxedLaneselectName        = "i16x8.relaxed_laneselect"
+	OpcodeVecI32x4RelaxedLaneselectName        = "i32x4.relaxed_laneselect"
+	OpcodeVecI64x2RelaxedLaneselectName        = "i64x2.relaxed_laneselect"
+	OpcodeVecF32x4RelaxedMinName               = "f32x4.relaxed_min"
+	OpcodeVecF32x4RelaxedMaxName               = "f32x4.relaxed_max"
+	OpcodeVecF64x2RelaxedMinName               = "f64x2.relaxed_min"
+	OpcodeVecF64x2RelaxedMaxName               = "f64x2.relaxed_max"
+	OpcodeVecI16x8RelaxedQ15mulrSName          = "i16x8.relaxed_q15mulr_s"
+	OpcodeVecI16x8RelaxedDotI8x16I7x16SName    = "i16x8.relaxed_dot_i8x16_i7x16_s"
+	OpcodeVecI32x4RelaxedDotI8x16I7x16AddSName = "i32x4.relaxed_dot_i8x16_i7x16_add_s"
+)
+
+var relaxedVectorInstructionNames = [...]string{
+	OpcodeVecI8x16RelaxedSwizzle - 0x100:           OpcodeVecI8x16RelaxedSwizzleName,
+	OpcodeVecI32x4RelaxedTruncF32x4S - 0x100:       OpcodeVecI32x4RelaxedTruncF32x4SName,
+	OpcodeVecI32x4RelaxedTruncF32x4U - 0x100:       OpcodeVecI32x4RelaxedTruncF32x4UName,
+	OpcodeVecI32x4RelaxedTruncF64x2SZero - 0x100:   OpcodeVecI32x4RelaxedTruncF64x2SZeroName,
+	OpcodeVecI32x4RelaxedTruncF64x2UZero - 0x100:   OpcodeVecI32x4RelaxedTruncF64x2UZeroName,
+	OpcodeVecF32x4RelaxedMadd - 0x100:              OpcodeVecF32x4RelaxedMaddName,
+	OpcodeVecF32x4RelaxedNmadd - 0x100:             OpcodeVecF32x4RelaxedNmaddName,
+	OpcodeVecF64x2RelaxedMadd - 0x100:              OpcodeVecF64x2RelaxedMaddName,
+	OpcodeVecF64x2RelaxedNmadd - 0x100:             OpcodeVecF64x2RelaxedNmaddName,
+	OpcodeVecI8x16RelaxedLaneselect - 0x100:        OpcodeVecI8x16RelaxedLaneselectName,
+	OpcodeVecI16x8RelaxedLaneselect - 0x100:        OpcodeVecI16x8RelaxedLaneselectName,
+	OpcodeVecI32x4RelaxedLaneselect - 0x100:        OpcodeVecI32x4RelaxedLaneselectName,
+	OpcodeVecI64x2RelaxedLaneselect - 0x100:        OpcodeVecI64x2RelaxedLaneselectName,
+	OpcodeVecF32x4RelaxedMin - 0x100:               OpcodeVecF32x4RelaxedMinName,
+	OpcodeVecF32x4RelaxedMax - 0x100:               OpcodeVecF32x4RelaxedMaxName,
+	OpcodeVecF64x2RelaxedMin - 0x100:               OpcodeVecF64x2RelaxedMinName,
+	OpcodeVecF64x2RelaxedMax - 0x100:               OpcodeVecF64x2RelaxedMaxName,
+	OpcodeVecI16x8RelaxedQ15mulrS - 0x100:          OpcodeVecI16x8RelaxedQ15mulrSName,
+	OpcodeVecI16x8RelaxedDotI8x16I7x16S - 0x100:    OpcodeVecI16x8RelaxedDotI8x16I7x16SName,
+	OpcodeVecI32x4RelaxedDotI8x16I7x16AddS - 0x100: OpcodeVecI32x4RelaxedDotI8x16I7x16AddSName,
+}
+
+// RelaxedVectorInstructionName returns the instruction name corresponding to the relaxed vector Opcode.
+func RelaxedVectorInstructionName(oc OpcodeVecRelaxed) string {
+	return relaxedVectorInstructionNames[oc-0x100]
+}
+
+const (
+	OpcodeAtomicMemoryNotifyName     = "memory.atomic.notify"
+	OpcodeAtomicMemoryWait32Name     = "memory.atomic.wait32"
+	OpcodeAtomicMemoryWait64Name     = "memory.atomic.wait64"
+	OpcodeAtomicFenceName            = "atomic.fence"
+	OpcodeAtomicI32LoadName          = "i32.atomic.load"
+	OpcodeAtomicI64LoadName          = "i64.atomic.load"
+	OpcodeAtomicI32Load8UName        = "i32.atomic.load8_u"
+	OpcodeAtomicI32Load16UName       = "i32.atomic.load16_u"
+	OpcodeAtomicI64Load8UName        = "i64.atomic.load8_u"
+	OpcodeAtomicI64Load16UName       = "i64.atomic.load16_u"
+	OpcodeAtomicI64Load32UName       = "i64.atomic.load32_u"
+	OpcodeAtomicI32StoreName         = "i32.atomic.store"
+	OpcodeAtomicI64StoreName         = "i64.atomic.store"
+	OpcodeAtomicI32Store8Name        = "i32.atomic.store8"
+	OpcodeAtomicI32Store16Name       = "i32.atomic.store16"
+	OpcodeAtomicI64Store8Name        = "i64.atomic.store8"
+	OpcodeAtomicI64Store16Name       = "i64.atomic.store16"
+	OpcodeAtomicI64Store32Name       = "i64.atomic.store32"
+	OpcodeAtomicI32RmwAddName        = "i32.atomic.rmw.add"
+	OpcodeAtomicI64RmwAddName        = "i64.atomic.rmw.add"
+	OpcodeAtomicI32Rmw8AddUName      = "i32.atomic.rmw8.add_u"
+	OpcodeAtomicI32Rmw16AddUName     = "i32.atomic.rmw16.add_u"
+	OpcodeAtomicI64Rmw8AddUName      = "i64.atomic.rmw8.add_u"
+	OpcodeAtomicI64Rmw16AddUName     = "i64.atomic.rmw16.add_u"
+	OpcodeAtomicI64Rmw32AddUName     = "i64.atomic.rmw32.add_u"
+	OpcodeAtomicI32RmwSubName        = "i32.atomic.rmw.sub"
+	OpcodeAtomicI64RmwSubName        = "i64.atomic.rmw.sub"
+	OpcodeAtomicI32Rmw8SubUName      = "i32.atomic.rmw8.sub_u"
+	OpcodeAtomicI32Rmw16SubUName     = "i32.atomic.rmw16.sub_u"
+	OpcodeAtomicI64Rmw8SubUName      = "i64.atomic.rmw8.sub_u"
+	OpcodeAtomicI64Rmw16SubUName     = "i64.atomic.rmw16.sub_u"
+	OpcodeAtomicI64Rmw32SubUName     = "i64.atomic.rmw32.sub_u"
+	OpcodeAtomicI32RmwAndName        = "i32.atomic.rmw.and"
+	OpcodeAtomicI64RmwAndName        = "i64.atomic.rmw.and"
+	OpcodeAtomicI32Rmw8AndUName      = "i32.atomic.rmw8.and_u"
+	OpcodeAtomicI32Rmw16AndUName     = "i32.atomic.rmw16.and_u"
+	OpcodeAtomicI64Rmw8AndUName      = "i64.atomic.rmw8.and_u"
+	OpcodeAtomicI64Rmw16AndUName     = "i64.atomic.rmw16.and_u"
+	OpcodeAtomicI64Rmw32AndUName     = "i64.atomic.rmw32.and_u"
+	OpcodeAtomicI32RmwOrName         = "i32.atomic.rmw.or"
+	OpcodeAtomicI64RmwOrName         = "i64.atomic.rmw.or"
+	OpcodeAtomicI32Rmw8OrUName       = "i32.atomic.rmw8.or_u"
+	OpcodeAtomicI32Rmw16OrUName      = "i32.atomic.rmw16.or_u"
+	OpcodeAtomicI64Rmw8OrUName       = "i64.atomic.rmw8.or_u"
+	OpcodeAtomicI64Rmw16OrUName      = "i64.atomic.rmw16.or_u"
+	OpcodeAtomicI64Rmw32OrUName      = "i64.atomic.rmw32.or_u"
+	OpcodeAtomicI32RmwXorName        = "i32.atomic.rmw.xor"
+	OpcodeAtomicI64RmwXorName        = "i64.atomic.rmw.xor"
+	OpcodeAtomicI32Rmw8XorUName      = "i32.atomic.rmw8.xor_u"
+	OpcodeAtomicI32Rmw16XorUName     = "i32.atomic.rmw16.xor_u"
+	OpcodeAtomicI64Rmw8XorUName      = "i64.atomic.rmw8.xor_u"
+	OpcodeAtomicI64Rmw16XorUName     = "i64.atomic.rmw16.xor_u"
+	OpcodeAtomicI64Rmw32XorUName     = "i64.atomic.rmw32.xor_u"
+	OpcodeAtomicI32RmwXchgName       = "i32.atomic.rmw.xchg"
+	OpcodeAtomicI64RmwXchgName       = "i64.atomic.rmw.xchg"
+	OpcodeAtomicI32Rmw8XchgUName     = "i32.atomic.rmw8.xchg_u"
+	OpcodeAtomicI32Rmw16XchgUName    = "i32.atomic.rmw16.xchg_u"
+	OpcodeAtomicI64Rmw8XchgUName     = "i64.atomic.rmw8.xchg_u"
+	OpcodeAtomicI64Rmw16XchgUName    = "i64.atomic.rmw16.xchg_u"
+	OpcodeAtomicI64Rmw32XchgUName    = "i64.atomic.rmw32.xchg_u"
+	OpcodeAtomicI32RmwCmpxchgName    = "i32.atomic.rmw.cmpxchg"
+	OpcodeAtomicI64RmwCmpxchgName    = "i64.atomic.rmw.cmpxchg"
+	OpcodeAtomicI32Rmw8CmpxchgUName  = "i32.atomic.rmw8.cmpxchg_u"
+	OpcodeAtomicI32Rmw16CmpxchgUName = "i32.atomic.rmw16.cmpxchg_u"
+	OpcodeAtomicI64Rmw8CmpxchgUName  = "i64.atomic.rmw8.cmpxchg_u"
+	OpcodeAtomicI64Rmw16CmpxchgUName = "i64.atomic.rmw16.cmpxchg_u"
+	OpcodeAtomicI64Rmw32CmpxchgUName = "i64.atomic.rmw32.cmpxchg_u"
+)
+
+var atomicInstructionNames = [256]string{
+	OpcodeAtomicMemoryNotify:     OpcodeAtomicMemoryNotifyName,
+	OpcodeAtomicMemoryWait32:     OpcodeAtomicMemoryWait32Name,
+	OpcodeAtomicMemoryWait64:     OpcodeAtomicMemoryWait64Name,
+	OpcodeAtomicFence:            OpcodeAtomicFenceName,
+	OpcodeAtomicI32Load:          OpcodeAtomicI32LoadName,
+	OpcodeAtomicI64Load:          OpcodeAtomicI64LoadName,
+	OpcodeAtomicI32Load8U:        OpcodeAtomicI32Load8UName,
+	OpcodeAtomicI32Load16U:       OpcodeAtomicI32Load16UName,
+	OpcodeAtomicI64Load8U:        OpcodeAtomicI64Load8UName,
+	OpcodeAtomicI64Load16U:       OpcodeAtomicI64Load16UName,
+	OpcodeAtomicI64Load32U:       OpcodeAtomicI64Load32UName,
+	OpcodeAtomicI32Store:         OpcodeAtomicI32StoreName,
+	OpcodeAtomicI64Store:         OpcodeAtomicI64StoreName,
+	OpcodeAtomicI32Store8:        OpcodeAtomicI32Store8Name,
+	OpcodeAtomicI32Store16:       OpcodeAtomicI32Store16Name,
+	OpcodeAtomicI64Store8:        OpcodeAtomicI64Store8Name,
+	OpcodeAtomicI64Store16:       OpcodeAtomicI64Store16Name,
+	OpcodeAtomicI64Store32:       OpcodeAtomicI64Store32Name,
+	OpcodeAtomicI32RmwAdd:        OpcodeAtomicI32RmwAddName,
+	OpcodeAtomicI64RmwAdd:        OpcodeAtomicI64RmwAddName,
+	OpcodeAtomicI32Rmw8AddU:      OpcodeAtomicI32Rmw8AddUName,
+	OpcodeAtomicI32Rmw16AddU:     OpcodeAtomicI32Rmw16AddUName,
+	OpcodeAtomicI64Rmw8AddU:      OpcodeAtomicI64Rmw8AddUName,
+	OpcodeAtomicI64Rmw16AddU:     OpcodeAtomicI64Rmw16AddUName,
+	OpcodeAtomicI64Rmw32AddU:     OpcodeAtomicI64Rmw32AddUName,
+	OpcodeAtomicI32RmwSub:        OpcodeAtomicI32RmwSubName,
+	OpcodeAtomicI64RmwSub:        OpcodeAtomicI64RmwSubName,
+	OpcodeAtomicI32Rmw8SubU:      OpcodeAtomicI32Rmw8SubUName,
+	OpcodeAtomicI32Rmw16SubU:     OpcodeAtomicI32Rmw16SubUName,
+	OpcodeAtomicI64Rmw8SubU:      OpcodeAtomicI64Rmw8SubUName,
+	OpcodeAtomicI64Rmw16SubU:     OpcodeAtomicI64Rmw16SubUName,
+	OpcodeAtomicI64Rmw32SubU:     OpcodeAtomicI64Rmw32SubUName,
+	OpcodeAtomicI32RmwAnd:        OpcodeAtomicI32RmwAndName,
+	OpcodeAtomicI64RmwAnd:        OpcodeAtomicI64RmwAndName,
+	OpcodeAtomicI32Rmw8AndU:      OpcodeAtomicI32Rmw8AndUName,
+	OpcodeAtomicI32Rmw16AndU:     OpcodeAtomicI32Rmw16AndUName,
+	OpcodeAtomicI64Rmw8AndU:      OpcodeAtomicI64Rmw8AndUName,
+	OpcodeAtomicI64Rmw16AndU:     OpcodeAtomicI64Rmw16AndUName,
+	OpcodeAtomicI64Rmw32AndU:     OpcodeAtomicI64Rmw32AndUName,
+	OpcodeAtomicI32RmwOr:         OpcodeAtomicI32RmwOrName,
+	OpcodeAtomicI64RmwOr:         OpcodeAtomicI64RmwOrName,
+	OpcodeAtomicI32Rmw8OrU:       OpcodeAtomicI32Rmw8OrUName,
+	OpcodeAtomicI32Rmw16OrU:      OpcodeAtomicI32Rmw16OrUName,
+	OpcodeAtomicI64Rmw8OrU:       OpcodeAtomicI64Rmw8OrUName,
+	OpcodeAtomicI64Rmw16OrU:      OpcodeAtomicI64Rmw16OrUName,
+	OpcodeAtomicI64Rmw32OrU:      OpcodeAtomicI64Rmw32OrUName,
+	OpcodeAtomicI32RmwXor:        OpcodeAtomicI32RmwXorName,
+	OpcodeAtomicI64RmwXor:        OpcodeAtomicI64RmwXorName,
+	OpcodeAtomicI32Rmw8XorU:      OpcodeAtomicI32Rmw8XorUName,
+	OpcodeAtomicI32Rmw16XorU:     OpcodeAtomicI32Rmw16XorUName,
+	OpcodeAtomicI64Rmw8XorU:      OpcodeAtomicI64Rmw8XorUName,
+	OpcodeAtomicI64Rmw16XorU:     OpcodeAtomicI64Rmw16XorUName,
+	OpcodeAtomicI64Rmw32XorU:     OpcodeAtomicI64Rmw32XorUName,
+	OpcodeAtomicI32RmwXchg:       OpcodeAtomicI32RmwXchgName,
+	OpcodeAtomicI64RmwXchg:       OpcodeAtomicI64RmwXchgName,
+	OpcodeAtomicI32Rmw8XchgU:     OpcodeAtomicI32Rmw8XchgUName,
+	OpcodeAtomicI32Rmw16XchgU:    OpcodeAtomicI32Rmw16XchgUName,
+	OpcodeAtomicI64Rmw8XchgU:     OpcodeAtomicI64Rmw8XchgUName,
+	OpcodeAtomicI64Rmw16XchgU:    OpcodeAtomicI64Rmw16XchgUName,
+	OpcodeAtomicI64Rmw32XchgU:    OpcodeAtomicI64Rmw32XchgUName,
+	OpcodeAtomicI32RmwCmpxchg:    OpcodeAtomicI32RmwCmpxchgName,
+	OpcodeAtomicI64RmwCmpxchg:    OpcodeAtomicI64RmwCmpxchgName,
+	OpcodeAtomicI32Rmw8CmpxchgU:  OpcodeAtomicI32Rmw8CmpxchgUName,
+	OpcodeAtomicI32Rmw16CmpxchgU: OpcodeAtomicI32Rmw16CmpxchgUName,
+	OpcodeAtomicI64Rmw8CmpxchgU:  OpcodeAtomicI64Rmw8CmpxchgUName,
+	OpcodeAtomicI64Rmw16CmpxchgU: OpcodeAtomicI64Rmw16CmpxchgUName,
+	OpcodeAtomicI64Rmw32CmpxchgU: OpcodeAtomicI64Rmw32CmpxchgUName,
+}
+
+// AtomicInstructionName returns the instruction name corresponding to the atomic Opcode.
+func AtomicInstructionName(oc OpcodeAtomic) string {
+	return atomicInstructionNames[oc]
+}
+
+// AtomicOperand returns the size in bytes of the memory accessed by the atomic Opcode, and the type of the value it
+// loads or stores. Ex. 1 and ValueTypeI64 for OpcodeAtomicI64Rmw8AddU
+//
+// Note: The size is zero for OpcodeAtomicFence, which doesn't access memory.
+func AtomicOperand(oc OpcodeAtomic) (size uint32, vt ValueType) {
+	switch oc {
+	case OpcodeAtomicMemoryNotify, OpcodeAtomicMemoryWait32:
+		return 4, ValueTypeI32
+	case OpcodeAtomicMemoryWait64:
+		return 8, ValueTypeI64
+	case OpcodeAtomicFence:
+		return 0, 0
+	}
+	// Loads, stores and each read-modify-write operation are groups of the same 7 variants, in the same order.
+	switch (oc - OpcodeAtomicI32Load) % 7 {
+	case 0:
+		return 4, ValueTypeI32
+	case 1:
+		return 8, ValueTypeI64
+	case 2:
+		return 1, ValueTypeI32
+	case 3:
+		return 2, ValueTypeI32
+	case 4:
+		return 1, ValueTypeI64
+	case 5:
+		return 2, ValueTypeI64
+	default:
+		return 4, ValueTypeI64
+	}
+}
@@ -5,11 +5,12 @@ import (
 	"encoding/binary"
 	"fmt"
 	"math"
-	"reflect"
+	"runtime"
 	"sync"
-	"unsafe"
+	"sync/atomic"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/platform"
 )
 
 const (
@@ -20,6 +21,10 @@ const (
 	// MemoryLimitPages is maximum number of pages defined (2^16).
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#grow-mem
 	MemoryLimitPages = uint32(65536)
+	// Memory64LimitPages is the maximum number of pages of a memory with 64-bit indices (FeatureMemory64). The proposal
+	// allows up to 2^48 pages, but page counts are uint32 in wazero, so this is lower.
+	// See https://github.com/WebAssembly/memory64/blob/main/proposals/memory64/Overview.md
+	Memory64LimitPages = uint32(math.MaxUint32)
 	// MemoryPageSizeInBits satisfies the relation: "1 << MemoryPageSizeInBits == MemoryPageSize".
 	MemoryPageSizeInBits = 16
 )
@@ -33,8 +38,17 @@ var MemorySizer api.MemorySizer = func(minPages uint32, maxPages *uint32) (min,
 	return minPages, minPages, MemoryLimitPages
 }
 
-// compile-time check to ensure MemoryInstance implements api.Memory
-var _ api.Memory = &MemoryInstance{}
+// MemoryLimit is the host-defined limit of the memory defined by a module instance.
+type MemoryLimit struct {
+	// Pages is the maximum amount of pages the memory can have, regardless of what the module defined.
+	Pages uint32
+
+	// OnExceeded is notified when the memory would grow past Pages, if non-nil.
+	OnExceeded api.MemoryLimitExceeded
+}
+
+// compile-time check to ensure MemoryInstance implements api.Memory64
+var _ api.Memory64 = &MemoryInstance{}
 
 // MemoryInstance represents a memory instance in a store, and implements api.Memory.
 //
@@ -42,36 +56,141 @@ var _ api.Memory = &MemoryInstance{}
 // wasm.Store Memories index zero: `store.Memories[0]`
 // See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#memory-instances%E2%91%A0.
 type MemoryInstance struct {
+	// length is the size in bytes of a Shared memory, which is accessed atomically as it can grow while module
+	// instances read it. This is the first field, so that it is 64-bit aligned on 32-bit platforms.
+	length uint64
+
+	// Buffer is the content of the memory. Its length is the size of the memory, unless it is Shared. See Size64
 	Buffer        []byte
 	Min, Cap, Max uint32
+	// LimitPages is the host-defined limit of pages, which is lower than Max, or nil if there is none.
+	LimitPages *uint32
+	// OnLimitExceeded is notified when growing fails due to LimitPages, if non-nil.
+	OnLimitExceeded api.MemoryLimitExceeded
 	// mux is used to prevent overlapping calls to Grow.
 	mux sync.RWMutex
+
+	// Shared is true when the memory can be imported by module instances called concurrently. Its Buffer has the length
+	// of Cap, and isn't rewritten when growing, so that module instances can read it while another grows the memory.
+	// See Memory.Shared
+	Shared bool
+	// reserved is true when the Buffer of a Shared memory is reserved with platform.ReserveMemory, so that only the
+	// pages up to its size are committed.
+	reserved bool
+	// waitersMux guards waiters, which are the channels of AtomicWait calls in progress, keyed by offset.
+	waitersMux sync.Mutex
+	waiters    map[uint64][]chan struct{}
+
+	// Is64 is true when the memory is addressed with i64 values. See Memory.Is64
+	Is64 bool
+
+	// mapping is the copy-on-write mapping of cow which Buffer is in, unless it grew past it, or nil if the memory was
+	// never reset with a copy-on-write Template. See ModuleInstance.Reset
+	mapping []byte
+	cow     *platform.CopyOnWrite
 }
 
+// sharedMemoryAllocatedLimitPages is the maximum capacity of a Shared memory where its address space can't be
+// reserved, such as on platforms other than Linux, macOS and Windows, as the capacity is then allocated up front.
+//
+// Note: The capacity of a memory is at most the limit set with WithMemoryLimitPages, so that can lower it.
+const sharedMemoryAllocatedLimitPages = uint32(16384) // 1 GiB
+
 // NewMemoryInstance creates a new instance based on the parameters in the SectionIDMemory.
+//
+// Note: This panics when a Shared memory can't be reserved or allocated. See newMemoryInstance
 func NewMemoryInstance(memSec *Memory) *MemoryInstance {
+	m, err := newMemoryInstance(memSec)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// newMemoryInstance implements NewMemoryInstance, except it errs when a Shared memory can't be reserved or allocated.
+//
+// The capacity of a Shared memory is reserved with platform.ReserveMemory, and committed as it grows, so that it
+// doesn't move, nor use physical memory beyond its size. Where that fails, the capacity is allocated, unless it is over
+// sharedMemoryAllocatedLimitPages.
+func newMemoryInstance(memSec *Memory) (*MemoryInstance, error) {
 	min := MemoryPagesToBytesNum(memSec.Min)
 	capacity := MemoryPagesToBytesNum(memSec.Cap)
-	return &MemoryInstance{
-		Buffer: make([]byte, min, capacity),
+	if !memSec.Shared {
+		return &MemoryInstance{
+			Buffer: make([]byte, min, capacity),
+			Min:    memSec.Min,
+			Cap:    memSec.Cap,
+			Max:    memSec.Max,
+			Is64:   memSec.Is64,
+		}, nil
+	}
+
+	m := &MemoryInstance{
+		length: min,
 		Min:    memSec.Min,
 		Cap:    memSec.Cap,
 		Max:    memSec.Max,
+		Shared: true,
+		Is64:   memSec.Is64,
+	}
+	if capacity == 0 {
+		m.Buffer = []byte{}
+		return m, nil
+	}
+
+	var err error
+	if size := int(capacity); uint64(size) == capacity { // false when capacity overflows int on 32-bit platforms.
+		var buf []byte
+		if buf, err = platform.ReserveMemory(size); err == nil {
+			if err = platform.CommitMemory(buf[:min]); err != nil {
+				_ = platform.ReleaseMemory(buf)
+				return nil, err
+			}
+			m.Buffer, m.reserved = buf, true
+			runtime.SetFinalizer(m, releaseMemory)
+			return m, nil
+		}
+	}
+
+	if memSec.Cap > sharedMemoryAllocatedLimitPages {
+		return nil, fmt.Errorf("shared memory capacity %d pages (%s) couldn't be reserved (%v), and is over %d pages (%s), which can be allocated instead",
+			memSec.Cap, PagesToUnitOfBytes(memSec.Cap), err, sharedMemoryAllocatedLimitPages, PagesToUnitOfBytes(sharedMemoryAllocatedLimitPages))
 	}
+	m.Buffer = make([]byte, capacity)
+	return m, nil
+}
+
+// releaseMemory is a runtime.SetFinalizer function that releases the reserved Buffer of a Shared memory.
+func releaseMemory(m *MemoryInstance) {
+	_ = platform.ReleaseMemory(m.Buffer)
 }
 
 // Size implements the same method as documented on api.Memory.
 func (m *MemoryInstance) Size(_ context.Context) uint32 {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
-	return m.size()
+	if size := m.Size64(nil); size < math.MaxUint32 {
+		return uint32(size)
+	}
+	return math.MaxUint32 // saturate, as memories with 64-bit indices can be larger. See Size64
+}
+
+// Size64 implements the same method as documented on api.Memory64.
+func (m *MemoryInstance) Size64(_ context.Context) uint64 {
+	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
+
+	// We don't lock here because size can't become smaller.
+	if m.Shared {
+		return atomic.LoadUint64(&m.length)
+	}
+	return uint64(len(m.Buffer))
 }
 
 // ReadByte implements the same method as documented on api.Memory.
 func (m *MemoryInstance) ReadByte(_ context.Context, offset uint32) (byte, bool) {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
-	if offset >= m.size() {
+	if !m.hasSize(uint64(offset), 1) {
 		return 0, false
 	}
 	return m.Buffer[offset], true
@@ -81,7 +200,7 @@ func (m *MemoryInstance) ReadByte(_ context.Context, offset uint32) (byte, bool)
 func (m *MemoryInstance) ReadUint16Le(_ context.Context, offset uint32) (uint16, bool) {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
-	if !m.hasSize(offset, 2) {
+	if !m.hasSize(uint64(offset), 2) {
 		return 0, false
 	}
 	return binary.LittleEndian.Uint16(m.Buffer[offset : offset+2]), true
@@ -91,14 +210,14 @@ func (m *MemoryInstance) ReadUint16Le(_ context.Context, offset uint32) (uint16,
 func (m *MemoryInstance) ReadUint32Le(_ context.Context, offset uint32) (uint32, bool) {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
-	return m.readUint32Le(offset)
+	return m.readUint32Le(uint64(offset))
 }
 
 // ReadFloat32Le implements the same method as documented on api.Memory.
 func (m *MemoryInstance) ReadFloat32Le(_ context.Context, offset uint32) (float32, bool) {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
-	v, ok := m.readUint32Le(offset)
+	v, ok := m.readUint32Le(uint64(offset))
 	if !ok {
 		return 0, false
 	}
@@ -109,14 +228,14 @@ func (m *MemoryInstance) ReadFloat32Le(_ context.Context, offset uint32) (float3
 func (m *MemoryInstance) ReadUint64Le(_ context.Context, offset uint32) (uint64, bool) {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
-	return m.readUint64Le(offset)
+	return m.readUint64Le(uint64(offset))
 }
 
 // ReadFloat64Le implements the same method as documented on api.Memory.
 func (m *MemoryInstance) ReadFloat64Le(_ context.Context, offset uint32) (float64, bool) {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
-	v, ok := m.readUint64Le(offset)
+	v, ok := m.readUint64Le(uint64(offset))
 	if !ok {
 		return 0, false
 	}
@@ -127,6 +246,13 @@ func (m *MemoryInstance) ReadFloat64Le(_ context.Context, offset uint32) (float6
 func (m *MemoryInstance) Read(_ context.Context, offset, byteCount uint32) ([]byte, bool) {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
+	return m.Read64(nil, uint64(offset), uint64(byteCount))
+}
+
+// Read64 implements the same method as documented on api.Memory64.
+func (m *MemoryInstance) Read64(_ context.Context, offset, byteCount uint64) ([]byte, bool) {
+	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
+
 	if !m.hasSize(offset, byteCount) {
 		return nil, false
 	}
@@ -137,7 +263,7 @@ func (m *MemoryInstance) Read(_ context.Context, offset, byteCount uint32) ([]by
 func (m *MemoryInstance) WriteByte(_ context.Context, offset uint32, v byte) bool {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
-	if offset >= m.size() {
+	if !m.hasSize(uint64(offset), 1) {
 		return false
 	}
 	m.Buffer[offset] = v
@@ -148,7 +274,7 @@ func (m *MemoryInstance) WriteByte(_ context.Context, offset uint32, v byte) boo
 func (m *MemoryInstance) WriteUint16Le(_ context.Context, offset uint32, v uint16) bool {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
-	if !m.hasSize(offset, 2) {
+	if !m.hasSize(uint64(offset), 2) {
 		return false
 	}
 	binary.LittleEndian.PutUint16(m.Buffer[offset:], v)
@@ -158,35 +284,42 @@ func (m *MemoryInstance) WriteUint16Le(_ context.Context, offset uint32, v uint1
 // WriteUint32Le implements the same method as documented on api.Memory.
 func (m *MemoryInstance) WriteUint32Le(_ context.Context, offset, v uint32) bool {
 
-	return m.writeUint32Le(offset, v)
+	return m.writeUint32Le(uint64(offset), v)
 }
 
 // WriteFloat32Le implements the same method as documented on api.Memory.
 func (m *MemoryInstance) WriteFloat32Le(_ context.Context, offset uint32, v float32) bool {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
-	return m.writeUint32Le(offset, math.Float32bits(v))
+	return m.writeUint32Le(uint64(offset), math.Float32bits(v))
 }
 
 // WriteUint64Le implements the same method as documented on api.Memory.
 func (m *MemoryInstance) WriteUint64Le(_ context.Context, offset uint32, v uint64) bool {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
-	return m.writeUint64Le(offset, v)
+	return m.writeUint64Le(uint64(offset), v)
 }
 
 // WriteFloat64Le implements the same method as documented on api.Memory.
 func (m *MemoryInstance) WriteFloat64Le(_ context.Context, offset uint32, v float64) bool {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
-	return m.writeUint64Le(offset, math.Float64bits(v))
+	return m.writeUint64Le(uint64(offset), math.Float64bits(v))
 }
 
 // Write implements the same method as documented on api.Memory.
 func (m *MemoryInstance) Write(_ context.Context, offset uint32, val []byte) bool {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
-	if !m.hasSize(offset, uint32(len(val))) {
+	return m.Write64(nil, uint64(offset), val)
+}
+
+// Write64 implements the same method as documented on api.Memory64.
+func (m *MemoryInstance) Write64(_ context.Context, offset uint64, val []byte) bool {
+	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
+
+	if !m.hasSize(offset, uint64(len(val))) {
 		return false
 	}
 	copy(m.Buffer[offset:], val)
@@ -199,30 +332,75 @@ func MemoryPagesToBytesNum(pages uint32) (bytesNum uint64) {
 }
 
 // Grow implements the same method as documented on api.Memory.
-func (m *MemoryInstance) Grow(_ context.Context, delta uint32) (result uint32, ok bool) {
-	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
+func (m *MemoryInstance) Grow(ctx context.Context, delta uint32) (result uint32, ok bool) {
+	result, ok, _ = m.GrowFromWasm(ctx, delta)
+	return
+}
+
+// GrowFromWasm is like Grow, except it also returns the error of OnLimitExceeded when growing failed due to
+// LimitPages. This is used by the "memory.grow" instruction, which fails with the error if it is non-nil.
+func (m *MemoryInstance) GrowFromWasm(ctx context.Context, delta uint32) (result uint32, ok bool, err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	result, ok, limited := m.grow(delta)
+	if limited {
+		// Notify outside the lock, so that the callback can use this memory.
+		if m.OnLimitExceeded != nil {
+			// Add in uint64, as pages of 64-bit memories can be up to math.MaxUint32, and saturate, so that the requested
+			// pages never appear lower than they are.
+			requested := uint64(result) + uint64(delta)
+			if requested > math.MaxUint32 {
+				requested = math.MaxUint32
+			}
+			err = m.OnLimitExceeded(ctx, result, uint32(requested), *m.LimitPages)
+		}
+		result = 0
+	}
+	return
+}
 
+// grow implements GrowFromWasm except the notification. When limited is true, result is the current pages.
+func (m *MemoryInstance) grow(delta uint32) (result uint32, ok, limited bool) {
 	// We take write-lock here as the following might result in a new slice
 	m.mux.Lock()
 	defer m.mux.Unlock()
 
-	currentPages := memoryBytesNumToPages(uint64(len(m.Buffer)))
+	currentPages := memoryBytesNumToPages(m.Size64(nil))
 	if delta == 0 {
-		return currentPages, true
+		return currentPages, true, false
 	}
 
 	// If exceeds the max of memory size, we push -1 according to the spec.
-	newPages := currentPages + delta
-	if newPages > m.Max {
-		return 0, false
-	} else if newPages > m.Cap { // grow the memory.
+	// Note: uint64 prevents overflow on add, as pages of 64-bit memories can be up to math.MaxUint32.
+	newPages := uint64(currentPages) + uint64(delta)
+	if newPages > uint64(m.Max) {
+		return 0, false, false
+	} else if m.LimitPages != nil && newPages > uint64(*m.LimitPages) { // The host doesn't allow this growth.
+		return currentPages, false, true
+	} else if newPages > uint64(m.Cap) { // grow the memory. Shared memories don't get here, as their Cap is Max.
 		m.Buffer = append(m.Buffer, make([]byte, MemoryPagesToBytesNum(delta))...)
-		m.Cap = newPages
-		return currentPages, true
+		m.Cap = uint32(newPages)
+		return currentPages, true, false
 	} else { // We already have the capacity we need.
-		sp := (*reflect.SliceHeader)(unsafe.Pointer(&m.Buffer))
-		sp.Len = int(MemoryPagesToBytesNum(newPages))
-		return currentPages, true
+		size := MemoryPagesToBytesNum(uint32(newPages))
+		if m.reserved { // Commit the new pages before they can be accessed.
+			if err := platform.CommitMemory(m.Buffer[m.Size64(nil):size]); err != nil {
+				return 0, false, false
+			}
+		}
+		m.setSize(size)
+		return currentPages, true, false
+	}
+}
+
+// setSize sets the size of the memory in bytes, which must be within its capacity. This must be called with mux held.
+func (m *MemoryInstance) setSize(size uint64) {
+	if m.Shared {
+		atomic.StoreUint64(&m.length, size) // Buffer isn't rewritten, as it is read concurrently.
+	} else {
+		m.Buffer = m.Buffer[:size]
 	}
 }
 
@@ -230,14 +408,14 @@ func (m *MemoryInstance) Grow(_ context.Context, delta uint32) (result uint32, o
 func (m *MemoryInstance) PageSize(_ context.Context) (result uint32) {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
-	return memoryBytesNumToPages(uint64(len(m.Buffer)))
+	return memoryBytesNumToPages(m.Size64(nil))
 }
 
 // PagesToUnitOfBytes converts the pages to a human-readable form similar to what's specified. Ex. 1 -> "64Ki"
 //
 // See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#memory-instances%E2%91%A0
 func PagesToUnitOfBytes(pages uint32) string {
-	k := pages * 64
+	k := uint64(pages) * 64
 	if k < 1024 {
 		return fmt.Sprintf("%d Ki", k)
 	}
@@ -259,21 +437,17 @@ func memoryBytesNumToPages(bytesNum uint64) (pages uint32) {
 	return uint32(bytesNum >> MemoryPageSizeInBits)
 }
 
-// size returns the size in bytes of the buffer.
-func (m *MemoryInstance) size() uint32 {
-	return uint32(len(m.Buffer)) // We don't lock here because size can't become smaller.
-}
-
 // hasSize returns true if Len is sufficient for byteCount at the given offset.
 //
 // Note: This is always fine, because memory can grow, but never shrink.
-func (m *MemoryInstance) hasSize(offset uint32, byteCount uint32) bool {
-	return uint64(offset)+uint64(byteCount) <= uint64(len(m.Buffer)) // uint64 prevents overflow on add
+func (m *MemoryInstance) hasSize(offset uint64, byteCount uint64) bool {
+	end := offset + byteCount
+	return end >= offset && end <= m.Size64(nil) // end < offset on overflow
 }
 
 // readUint32Le implements ReadUint32Le without using a context. This is extracted as both ints and floats are stored in
 // memory as uint32le.
-func (m *MemoryInstance) readUint32Le(offset uint32) (uint32, bool) {
+func (m *MemoryInstance) readUint32Le(offset uint64) (uint32, bool) {
 	if !m.hasSize(offset, 4) {
 		return 0, false
 	}
@@ -282,7 +456,7 @@ func (m *MemoryInstance) readUint32Le(offset uint32) (uint32, bool) {
 
 // readUint64Le implements ReadUint64Le without using a context. This is extracted as both ints and floats are stored in
 // memory as uint64le.
-func (m *MemoryInstance) readUint64Le(offset uint32) (uint64, bool) {
+func (m *MemoryInstance) readUint64Le(offset uint64) (uint64, bool) {
 	if !m.hasSize(offset, 8) {
 		return 0, false
 	}
@@ -291,7 +465,7 @@ func (m *MemoryInstance) readUint64Le(offset uint32) (uint64, bool) {
 
 // writeUint32Le implements WriteUint32Le without using a context. This is extracted as both ints and floats are stored
 // in memory as uint32le.
-func (m *MemoryInstance) writeUint32Le(offset uint32, v uint32) bool {
+func (m *MemoryInstance) writeUint32Le(offset uint64, v uint32) bool {
 	if !m.hasSize(offset, 4) {
 		return false
 	}
@@ -301,7 +475,7 @@ func (m *MemoryInstance) writeUint32Le(offset uint32, v uint32) bool {
 
 // writeUint64Le implements WriteUint64Le without using a context. This is extracted as both ints and floats are stored
 // in memory as uint64le.
-func (m *MemoryInstance) writeUint64Le(offset uint32, v uint64) bool {
+func (m *MemoryInstance) writeUint64Le(offset uint64, v uint64) bool {
 	if !m.hasSize(offset, 8) {
 		return false
 	}
@@ -0,0 +1,252 @@
+package wasm
+
+import (
+	"context"
+	"math/bits"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"github.com/tetratelabs/wazero/internal/wasmruntime"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// This file implements the memory accesses of the threads proposal (FeatureThreads), used by both engines.
+//
+// Accesses are atomic with respect to each other when the memory is shared by module instances called concurrently.
+// Values of 1 or 2 bytes are accessed with a compare-and-swap loop on the aligned 4 bytes which include them.
+//
+// See https://github.com/WebAssembly/threads/blob/main/proposals/threads/Overview.md
+
+// hostIsBigEndian is true when values loaded from memory must be byte swapped, as Wasm memory is little-endian.
+var hostIsBigEndian = func() bool {
+	v := uint16(1)
+	return *(*byte)(unsafe.Pointer(&v)) == 0
+}()
+
+func le32(v uint32) uint32 {
+	if hostIsBigEndian {
+		return bits.ReverseBytes32(v)
+	}
+	return v
+}
+
+func le64(v uint64) uint64 {
+	if hostIsBigEndian {
+		return bits.ReverseBytes64(v)
+	}
+	return v
+}
+
+// Results of AtomicWait, as pushed by the "memory.atomic.wait32" and "memory.atomic.wait64" instructions.
+const (
+	AtomicWaitOk       = uint32(0)
+	AtomicWaitNotEqual = uint32(1)
+	AtomicWaitTimedOut = uint32(2)
+)
+
+// checkAtomic returns an error if the access of size bytes at the offset is out of bounds or unaligned.
+func (m *MemoryInstance) checkAtomic(offset uint64, size uint32) error {
+	if !m.hasSize(offset, uint64(size)) {
+		return wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess
+	}
+	if offset%uint64(size) != 0 {
+		return wasmruntime.ErrRuntimeUnalignedAtomic
+	}
+	return nil
+}
+
+// atomicRMW replaces the value of size bytes at the offset with the result of fn, returning the value it replaced.
+// The offset must have been checked with checkAtomic.
+func (m *MemoryInstance) atomicRMW(offset uint64, size uint32, fn func(old uint64) uint64) uint64 {
+	switch size {
+	case 8:
+		p := (*uint64)(unsafe.Pointer(&m.Buffer[offset]))
+		for {
+			raw := atomic.LoadUint64(p)
+			old := le64(raw)
+			if atomic.CompareAndSwapUint64(p, raw, le64(fn(old))) {
+				return old
+			}
+		}
+	case 4:
+		p := (*uint32)(unsafe.Pointer(&m.Buffer[offset]))
+		for {
+			raw := atomic.LoadUint32(p)
+			old := le32(raw)
+			if atomic.CompareAndSwapUint32(p, raw, le32(uint32(fn(uint64(old))))) {
+				return uint64(old)
+			}
+		}
+	default: // 1 or 2
+		// Memory is a multiple of pages, so the aligned word including the value is in bounds.
+		p := (*uint32)(unsafe.Pointer(&m.Buffer[offset&^3]))
+		shift := (offset & 3) * 8
+		mask := uint32(1)<<(size*8) - 1
+		for {
+			raw := atomic.LoadUint32(p)
+			word := le32(raw)
+			old := word >> shift & mask
+			v := uint32(fn(uint64(old))) & mask
+			if atomic.CompareAndSwapUint32(p, raw, le32(word&^(mask<<shift)|v<<shift)) {
+				return uint64(old)
+			}
+		}
+	}
+}
+
+// AtomicLoad returns the value of size bytes at the offset, zero-extended, as read by an atomic load instruction.
+func (m *MemoryInstance) AtomicLoad(offset uint64, size uint32) (uint64, error) {
+	if err := m.checkAtomic(offset, size); err != nil {
+		return 0, err
+	}
+	switch size {
+	case 8:
+		return le64(atomic.LoadUint64((*uint64)(unsafe.Pointer(&m.Buffer[offset])))), nil
+	case 4:
+		return uint64(le32(atomic.LoadUint32((*uint32)(unsafe.Pointer(&m.Buffer[offset]))))), nil
+	default:
+		word := le32(atomic.LoadUint32((*uint32)(unsafe.Pointer(&m.Buffer[offset&^3]))))
+		return uint64(word>>((offset&3)*8)) & (1<<(size*8) - 1), nil
+	}
+}
+
+// AtomicStore writes the low size bytes of the value at the offset, as written by an atomic store instruction.
+func (m *MemoryInstance) AtomicStore(offset uint64, size uint32, v uint64) error {
+	if err := m.checkAtomic(offset, size); err != nil {
+		return err
+	}
+	switch size {
+	case 8:
+		atomic.StoreUint64((*uint64)(unsafe.Pointer(&m.Buffer[offset])), le64(v))
+	case 4:
+		atomic.StoreUint32((*uint32)(unsafe.Pointer(&m.Buffer[offset])), le32(uint32(v)))
+	default:
+		m.atomicRMW(offset, size, func(uint64) uint64 { return v })
+	}
+	return nil
+}
+
+// AtomicRMW replaces the value of size bytes at the offset with fn of it and the operand v, returning the value it
+// replaced, as done by an atomic read-modify-write instruction. Ex. "i32.atomic.rmw.add"
+func (m *MemoryInstance) AtomicRMW(offset uint64, size uint32, v uint64, fn func(old, v uint64) uint64) (uint64, error) {
+	if err := m.checkAtomic(offset, size); err != nil {
+		return 0, err
+	}
+	return m.atomicRMW(offset, size, func(old uint64) uint64 { return fn(old, v) }), nil
+}
+
+// AtomicCompareExchange replaces the value of size bytes at the offset with the replacement, only if it is equal to
+// the expected value wrapped to size, returning the value read, as done by an atomic "cmpxchg" instruction.
+func (m *MemoryInstance) AtomicCompareExchange(offset uint64, size uint32, expected, replacement uint64) (uint64, error) {
+	if err := m.checkAtomic(offset, size); err != nil {
+		return 0, err
+	}
+	if size < 8 {
+		expected &= 1<<(size*8) - 1
+	}
+	return m.atomicRMW(offset, size, func(old uint64) uint64 {
+		if old == expected {
+			return replacement
+		}
+		return old
+	}), nil
+}
+
+// AtomicWait blocks until AtomicNotify is called for the offset, unless the value of size bytes at it isn't equal to
+// the expected value, as done by the "memory.atomic.wait32" and "memory.atomic.wait64" instructions. The result is one
+// of AtomicWaitOk, AtomicWaitNotEqual or AtomicWaitTimedOut.
+//
+// The timeout is in nanoseconds, or negative to wait forever. This returns a sys.ContextDoneError if the context is
+// done while waiting, or wasmruntime.ErrRuntimeExpectedSharedMemory if the memory isn't shared.
+func (m *MemoryInstance) AtomicWait(ctx context.Context, offset uint64, size uint32, expected uint64, timeout int64) (uint32, error) {
+	if err := m.checkAtomic(offset, size); err != nil {
+		return 0, err
+	}
+	if !m.Shared {
+		return 0, wasmruntime.ErrRuntimeExpectedSharedMemory
+	}
+
+	// Compare while holding the lock, so that a notification following a store can't be missed.
+	m.waitersMux.Lock()
+	v, _ := m.AtomicLoad(offset, size)
+	if v != expected {
+		m.waitersMux.Unlock()
+		return AtomicWaitNotEqual, nil
+	}
+	w := make(chan struct{})
+	if m.waiters == nil {
+		m.waiters = map[uint64][]chan struct{}{}
+	}
+	m.waiters[offset] = append(m.waiters[offset], w)
+	m.waitersMux.Unlock()
+
+	var timer <-chan time.Time
+	if timeout >= 0 {
+		t := time.NewTimer(time.Duration(timeout))
+		defer t.Stop()
+		timer = t.C
+	}
+
+	var err error
+	select {
+	case <-w:
+		return AtomicWaitOk, nil
+	case <-timer:
+	case <-ctx.Done():
+		err = sys.NewContextDoneError(ctx.Err())
+	}
+
+	// Stop waiting, unless notified meanwhile.
+	m.waitersMux.Lock()
+	defer m.waitersMux.Unlock()
+	waiters := m.waiters[offset]
+	for i, other := range waiters {
+		if other == w {
+			m.waiters[offset] = append(waiters[:i:i], waiters[i+1:]...)
+			if err != nil {
+				return 0, err
+			}
+			return AtomicWaitTimedOut, nil
+		}
+	}
+	return AtomicWaitOk, nil
+}
+
+// AtomicNotify wakes up to count calls of AtomicWait at the offset, in the order they began waiting, returning how many
+// were woken, as done by the "memory.atomic.notify" instruction. This returns zero if the memory isn't shared.
+func (m *MemoryInstance) AtomicNotify(offset uint64, count uint32) (uint32, error) {
+	if err := m.checkAtomic(offset, 4); err != nil {
+		return 0, err
+	}
+	if !m.Shared {
+		return 0, nil
+	}
+
+	m.waitersMux.Lock()
+	defer m.waitersMux.Unlock()
+	waiters := m.waiters[offset]
+	n := uint32(len(waiters))
+	if count < n {
+		n = count
+	}
+	for _, w := range waiters[:n] {
+		close(w)
+	}
+	if remaining := waiters[n:]; len(remaining) > 0 {
+		m.waiters[offset] = remaining
+	} else {
+		delete(m.waiters, offset)
+	}
+	return n, nil
+}
+
+// fence is the target of the atomic operation of AtomicFence.
+var fence uint32
+
+// AtomicFence orders the memory accesses before it with those after it, as done by the "atomic.fence" instruction.
+//
+// Note: Go atomic operations are sequentially consistent, so performing one is a full barrier.
+func AtomicFence() {
+	atomic.AddUint32(&fence, 0)
+}
@@ -0,0 +1,165 @@
+package wasm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasmruntime"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+func TestMemoryInstance_AtomicLoadStore(t *testing.T) {
+	m := NewMemoryInstance(&Memory{Min: 1, Cap: 1, Max: 1, Shared: true})
+
+	require.NoError(t, m.AtomicStore(8, 8, 0x0102030405060708))
+	require.Equal(t, []byte{8, 7, 6, 5, 4, 3, 2, 1}, m.Buffer[8:16])
+
+	tests := []struct {
+		name          string
+		offset        uint64
+		size          uint32
+		expected      uint64
+		expectedStore []byte
+	}{
+		{name: "8 bytes", offset: 8, size: 8, expected: 0x0102030405060708},
+		{name: "4 bytes", offset: 12, size: 4, expected: 0x01020304},
+		{name: "2 bytes", offset: 10, size: 2, expected: 0x0506},
+		{name: "1 byte", offset: 9, size: 1, expected: 0x07},
+	}
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := m.AtomicLoad(tc.offset, tc.size)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, v)
+		})
+	}
+
+	t.Run("store 1 byte keeps neighbours", func(t *testing.T) {
+		require.NoError(t, m.AtomicStore(13, 1, 0xffff))
+		require.Equal(t, []byte{8, 7, 6, 5, 4, 0xff, 2, 1}, m.Buffer[8:16])
+	})
+
+	t.Run("store 2 bytes keeps neighbours", func(t *testing.T) {
+		require.NoError(t, m.AtomicStore(14, 2, 0xaabbcc))
+		require.Equal(t, []byte{8, 7, 6, 5, 4, 0xff, 0xcc, 0xbb}, m.Buffer[8:16])
+	})
+}
+
+func TestMemoryInstance_Atomic_Errors(t *testing.T) {
+	m := NewMemoryInstance(&Memory{Min: 1, Cap: 1, Max: 1})
+	end := uint64(MemoryPageSize)
+
+	_, err := m.AtomicLoad(end-4, 8)
+	require.Equal(t, wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess, err)
+	_, err = m.AtomicLoad(2, 4)
+	require.Equal(t, wasmruntime.ErrRuntimeUnalignedAtomic, err)
+	require.Equal(t, wasmruntime.ErrRuntimeUnalignedAtomic, m.AtomicStore(1, 2, 0))
+	_, err = m.AtomicRMW(end, 1, 0, func(old, v uint64) uint64 { return v })
+	require.Equal(t, wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess, err)
+	_, err = m.AtomicCompareExchange(4, 8, 0, 0)
+	require.Equal(t, wasmruntime.ErrRuntimeUnalignedAtomic, err)
+
+	t.Run("wait requires shared memory", func(t *testing.T) {
+		_, err = m.AtomicWait(testCtx, 0, 4, 0, 0)
+		require.Equal(t, wasmruntime.ErrRuntimeExpectedSharedMemory, err)
+	})
+
+	t.Run("notify of unshared memory", func(t *testing.T) {
+		n, err := m.AtomicNotify(0, 1)
+		require.NoError(t, err)
+		require.Zero(t, n)
+	})
+}
+
+func TestMemoryInstance_AtomicRMW(t *testing.T) {
+	m := NewMemoryInstance(&Memory{Min: 1, Cap: 1, Max: 1, Shared: true})
+	add := func(old, v uint64) uint64 { return old + v }
+
+	t.Run("wraps to size", func(t *testing.T) {
+		require.NoError(t, m.AtomicStore(0, 4, 0x11223344))
+
+		old, err := m.AtomicRMW(1, 1, 0xff, add)
+		require.NoError(t, err)
+		require.Equal(t, uint64(0x33), old)
+		require.Equal(t, []byte{0x44, 0x32, 0x22, 0x11}, m.Buffer[0:4])
+
+		old, err = m.AtomicRMW(0, 4, 0xf0000000, add)
+		require.NoError(t, err)
+		require.Equal(t, uint64(0x11223244), old)
+		require.Equal(t, []byte{0x44, 0x32, 0x22, 0x01}, m.Buffer[0:4])
+	})
+
+	t.Run("compare exchange", func(t *testing.T) {
+		require.NoError(t, m.AtomicStore(8, 8, 5))
+
+		old, err := m.AtomicCompareExchange(8, 8, 4, 10)
+		require.NoError(t, err)
+		require.Equal(t, uint64(5), old)
+		require.Equal(t, []byte{5, 0, 0, 0, 0, 0, 0, 0}, m.Buffer[8:16])
+
+		// The expected value is wrapped to the size.
+		old, err = m.AtomicCompareExchange(8, 2, 0x10005, 10)
+		require.NoError(t, err)
+		require.Equal(t, uint64(5), old)
+		require.Equal(t, []byte{10, 0, 0, 0, 0, 0, 0, 0}, m.Buffer[8:16])
+	})
+}
+
+func TestMemoryInstance_AtomicWaitNotify(t *testing.T) {
+	m := NewMemoryInstance(&Memory{Min: 1, Cap: 1, Max: 1, Shared: true})
+
+	t.Run("not equal", func(t *testing.T) {
+		res, err := m.AtomicWait(testCtx, 0, 4, 1, -1)
+		require.NoError(t, err)
+		require.Equal(t, AtomicWaitNotEqual, res)
+	})
+
+	t.Run("timed out", func(t *testing.T) {
+		res, err := m.AtomicWait(testCtx, 0, 8, 0, int64(time.Millisecond))
+		require.NoError(t, err)
+		require.Equal(t, AtomicWaitTimedOut, res)
+		require.Zero(t, len(m.waiters[0]))
+	})
+
+	t.Run("context done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(testCtx)
+		cancel()
+		_, err := m.AtomicWait(ctx, 0, 4, 0, -1)
+		var ctxErr *sys.ContextDoneError
+		require.True(t, errors.As(err, &ctxErr))
+	})
+
+	t.Run("notified", func(t *testing.T) {
+		results := make(chan uint32)
+		for i := 0; i < 3; i++ {
+			go func() {
+				res, err := m.AtomicWait(testCtx, 4, 4, 0, -1)
+				require.NoError(t, err)
+				results <- res
+			}()
+		}
+
+		// Notify until all are woken, as they may not all be waiting yet.
+		woken := uint32(0)
+		for woken < 2 {
+			n, err := m.AtomicNotify(4, 2-woken)
+			require.NoError(t, err)
+			woken += n
+			time.Sleep(time.Millisecond)
+		}
+		require.Equal(t, AtomicWaitOk, <-results)
+		require.Equal(t, AtomicWaitOk, <-results)
+
+		for woken < 3 {
+			n, err := m.AtomicNotify(4, 10)
+			require.NoError(t, err)
+			woken += n
+			time.Sleep(time.Millisecond)
+		}
+		require.Equal(t, AtomicWaitOk, <-results)
+	})
+}
@@ -2,9 +2,12 @@ package wasm
 
 import (
 	"context"
+	"errors"
 	"math"
+	"runtime"
 	"testing"
 
+	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 )
 
@@ -106,6 +109,155 @@ func TestMemoryInstance_Grow_Size(t *testing.T) {
 	}
 }
 
+func TestNewMemoryInstance_Shared(t *testing.T) {
+	t.Run("max 4 GiB", func(t *testing.T) {
+		m := NewMemoryInstance(&Memory{Min: 1, Cap: MemoryLimitPages, Max: MemoryLimitPages, Shared: true})
+		require.Equal(t, MemoryPagesToBytesNum(MemoryLimitPages), uint64(len(m.Buffer)))
+		switch runtime.GOOS {
+		case "darwin", "linux", "windows": // Only the pages of the size are allocated.
+			require.True(t, m.reserved)
+		}
+
+		_, ok := m.Grow(testCtx, 2)
+		require.True(t, ok)
+		require.True(t, m.WriteByte(testCtx, uint32(MemoryPagesToBytesNum(3)-1), 1))
+		v, ok := m.ReadByte(testCtx, uint32(MemoryPagesToBytesNum(3)-1))
+		require.True(t, ok)
+		require.Equal(t, byte(1), v)
+		_, ok = m.ReadByte(testCtx, uint32(MemoryPagesToBytesNum(3)))
+		require.False(t, ok)
+	})
+
+	t.Run("max 0", func(t *testing.T) {
+		m := NewMemoryInstance(&Memory{Shared: true})
+		require.Equal(t, 0, len(m.Buffer))
+		_, ok := m.Grow(testCtx, 1)
+		require.False(t, ok)
+	})
+
+	t.Run("over address space", func(t *testing.T) {
+		// 256 TiB can't be reserved, nor allocated.
+		_, err := newMemoryInstance(&Memory{Cap: Memory64LimitPages, Max: Memory64LimitPages, Shared: true, Is64: true})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "shared memory capacity 4294967295 pages (255 Ti) couldn't be reserved")
+	})
+}
+
+// TestMemoryInstance_Grow_Shared ensures a shared memory can be grown while it is accessed by other goroutines, which
+// fails with the race detector if its Buffer is rewritten.
+func TestMemoryInstance_Grow_Shared(t *testing.T) {
+	m := NewMemoryInstance(&Memory{Min: 1, Cap: 10, Max: 10, Shared: true})
+	require.Equal(t, uint32(1), m.PageSize(testCtx))
+	require.Equal(t, MemoryPagesToBytesNum(10), uint64(len(m.Buffer)))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 9; i++ {
+			res, ok := m.Grow(testCtx, 1)
+			require.True(t, ok)
+			require.Equal(t, uint32(i+1), res)
+		}
+	}()
+
+	// The size only grows, and the accessible bytes are those within it.
+	var prev uint64
+	for size := m.Size64(testCtx); size < MemoryPagesToBytesNum(10); size = m.Size64(testCtx) {
+		require.True(t, prev <= size)
+		_, err := m.AtomicRMW(size-8, 8, 1, func(old, v uint64) uint64 { return old + v })
+		require.NoError(t, err)
+		prev = size
+	}
+	<-done
+
+	_, ok := m.Grow(testCtx, 1)
+	require.False(t, ok)
+	require.Equal(t, uint32(10), m.PageSize(testCtx))
+	require.Equal(t, MemoryPagesToBytesNum(10), uint64(len(m.Buffer)))
+}
+
+func TestMemoryInstance_GrowFromWasm_LimitPages(t *testing.T) {
+	limitPages := uint32(2)
+	newMemory := func(onLimitExceeded api.MemoryLimitExceeded) *MemoryInstance {
+		return &MemoryInstance{Max: 10, Buffer: make([]byte, 0), LimitPages: &limitPages, OnLimitExceeded: onLimitExceeded}
+	}
+
+	t.Run("no callback", func(t *testing.T) {
+		m := newMemory(nil)
+
+		res, ok, err := m.GrowFromWasm(testCtx, 2)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, uint32(0), res)
+
+		_, ok, err = m.GrowFromWasm(testCtx, 1)
+		require.NoError(t, err)
+		require.False(t, ok)
+		require.Equal(t, uint32(2), m.PageSize(testCtx))
+	})
+
+	t.Run("callback", func(t *testing.T) {
+		var current, requested, limit uint32
+		m := newMemory(func(ctx context.Context, currentPages, requestedPages, limitPages uint32) error {
+			require.Equal(t, testCtx, ctx)
+			current, requested, limit = currentPages, requestedPages, limitPages
+			return nil
+		})
+
+		_, ok := m.Grow(testCtx, 1)
+		require.True(t, ok)
+
+		_, ok = m.Grow(testCtx, 3)
+		require.False(t, ok)
+		require.Equal(t, uint32(1), current)
+		require.Equal(t, uint32(4), requested)
+		require.Equal(t, uint32(2), limit)
+	})
+
+	t.Run("callback large delta", func(t *testing.T) {
+		var current, requested uint32
+		m := &MemoryInstance{Max: math.MaxUint32, Buffer: make([]byte, 0), LimitPages: &limitPages, Is64: true,
+			OnLimitExceeded: func(ctx context.Context, currentPages, requestedPages, limitPages uint32) error {
+				current, requested = currentPages, requestedPages
+				return nil
+			}}
+
+		_, ok := m.Grow(testCtx, 1)
+		require.True(t, ok)
+
+		// The requested pages don't wrap around to a small count.
+		_, ok = m.Grow(testCtx, math.MaxUint32-1)
+		require.False(t, ok)
+		require.Equal(t, uint32(1), current)
+		require.Equal(t, uint32(math.MaxUint32), requested)
+	})
+
+	t.Run("callback not called over max", func(t *testing.T) {
+		m := newMemory(func(context.Context, uint32, uint32, uint32) error {
+			t.Fatal("unexpected call")
+			return nil
+		})
+
+		_, ok, err := m.GrowFromWasm(testCtx, 11)
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("callback error", func(t *testing.T) {
+		m := newMemory(func(context.Context, uint32, uint32, uint32) error {
+			return errors.New("too much memory")
+		})
+
+		_, ok, err := m.GrowFromWasm(testCtx, 3)
+		require.EqualError(t, err, "too much memory")
+		require.False(t, ok)
+
+		// The host doesn't see the error.
+		_, ok = m.Grow(testCtx, 3)
+		require.False(t, ok)
+	})
+}
+
 func TestMemoryInstance_ReadByte(t *testing.T) {
 	for _, ctx := range []context.Context{nil, testCtx} { // Ensure it doesn't crash on nil!
 		var mem = &MemoryInstance{Buffer: []byte{0, 0, 0, 0, 0, 0, 0, 16}, Min: 1}
@@ -150,7 +302,7 @@ func TestPagesToUnitOfBytes(t *testing.T) {
 		{
 			name:     "max uint32",
 			pages:    math.MaxUint32,
-			expected: "3 Ti",
+			expected: "255 Ti",
 		},
 	}
 
@@ -168,7 +320,7 @@ func TestMemoryInstance_HasSize(t *testing.T) {
 
 	tests := []struct {
 		name        string
-		offset      uint32
+		offset      uint64
 		sizeInBytes uint64
 		expected    bool
 	}{
@@ -180,7 +332,7 @@ func TestMemoryInstance_HasSize(t *testing.T) {
 		},
 		{
 			name:        "maximum valid sizeInBytes",
-			offset:      memory.Size(testCtx) - 8,
+			offset:      memory.Size64(testCtx) - 8,
 			sizeInBytes: 8,
 			expected:    true,
 		},
@@ -192,7 +344,7 @@ func TestMemoryInstance_HasSize(t *testing.T) {
 		},
 		{
 			name:        "offset exceeds the memory size",
-			offset:      memory.Size(testCtx),
+			offset:      memory.Size64(testCtx),
 			sizeInBytes: 1, // arbitrary size
 			expected:    false,
 		},
@@ -202,6 +354,12 @@ func TestMemoryInstance_HasSize(t *testing.T) {
 			sizeInBytes: 4,                  // if there's overflow, offset + sizeInBytes is 3, and it may pass the check
 			expected:    false,
 		},
+		{
+			name:        "offset + sizeInBytes overflows in uint64",
+			offset:      math.MaxUint64 - 1, // invalid too large offset of a memory with 64-bit indices
+			sizeInBytes: 4,
+			expected:    false,
+		},
 		{
 			name:        "address.wast:200",
 			offset:      4294967295,
@@ -214,7 +372,7 @@ func TestMemoryInstance_HasSize(t *testing.T) {
 		tc := tt
 
 		t.Run(tc.name, func(t *testing.T) {
-			require.Equal(t, tc.expected, memory.hasSize(tc.offset, uint32(tc.sizeInBytes)))
+			require.Equal(t, tc.expected, memory.hasSize(tc.offset, tc.sizeInBytes))
 		})
 	}
 }
@@ -495,6 +653,25 @@ func TestMemoryInstance_Read(t *testing.T) {
 	}
 }
 
+func TestMemoryInstance_Read64(t *testing.T) {
+	var mem = &MemoryInstance{Buffer: []byte{0, 0, 0, 0, 16, 0, 0, 0}, Min: 1, Is64: true}
+	require.Equal(t, uint64(8), mem.Size64(testCtx))
+
+	buf, ok := mem.Read64(testCtx, 4, 4)
+	require.True(t, ok)
+	require.Equal(t, []byte{16, 0, 0, 0}, buf)
+
+	_, ok = mem.Read64(testCtx, 5, 4)
+	require.False(t, ok)
+
+	_, ok = mem.Read64(testCtx, math.MaxUint32+4, 4)
+	require.False(t, ok)
+
+	// offset + byteCount overflows uint64
+	_, ok = mem.Read64(testCtx, 4, math.MaxUint64)
+	require.False(t, ok)
+}
+
 func TestMemoryInstance_WriteUint16Le(t *testing.T) {
 	memory := &MemoryInstance{Buffer: make([]byte, 100)}
 
@@ -778,3 +955,13 @@ func TestMemoryInstance_Write(t *testing.T) {
 		require.False(t, ok)
 	}
 }
+
+func TestMemoryInstance_Write64(t *testing.T) {
+	var mem = &MemoryInstance{Buffer: []byte{0, 0, 0, 0, 16, 0, 0, 0}, Min: 1, Is64: true}
+
+	require.True(t, mem.Write64(testCtx, 4, []byte{16, 0, 0, 4}))
+	require.Equal(t, []byte{0, 0, 0, 0, 16, 0, 0, 4}, mem.Buffer)
+
+	require.False(t, mem.Write64(testCtx, 5, []byte{16, 0, 0, 4}))
+	require.False(t, mem.Write64(testCtx, math.MaxUint64, []byte{16}))
+}
@@ -8,12 +8,14 @@ import (
 	"reflect"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/tetratelabs/wazero/api"
 	experimental "github.com/tetratelabs/wazero/experimental"
 	"github.com/tetratelabs/wazero/internal/ieee754"
 	"github.com/tetratelabs/wazero/internal/leb128"
 	"github.com/tetratelabs/wazero/internal/wasmdebug"
+	"github.com/tetratelabs/wazero/sys"
 )
 
 // DecodeModule parses the WebAssembly Binary Format (%.wasm) into a Module. This function returns when the input is
@@ -92,16 +94,17 @@ type Module struct {
 	// MemorySection contains each memory defined in this module.
 	//
 	// Note: The memory Index namespace begins with imported memories and ends with those defined in this module.
-	// For example, if there are two imported memories and one defined in this module, the memory Index 3 is defined in
-	// this module at TableSection[0].
+	// For example, if there are two imported memories and one defined in this module, the memory Index 2 is defined in
+	// this module at MemorySection[0].
 	//
 	// Note: Version 1.0 (20191205) of the WebAssembly spec allows at most one memory definition per module, so the
-	// length of the MemorySection can be zero or one, and can only be one if there is no imported memory.
+	// length of the MemorySection can be zero or one, and can only be one if there is no imported memory. More are
+	// allowed with FeatureMultiMemory.
 	//
 	// Note: In the Binary Format, this is SectionIDMemory.
 	//
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#memory-section%E2%91%A0
-	MemorySection *Memory
+	MemorySection []*Memory
 
 	// GlobalSection contains each global defined in this module.
 	//
@@ -145,15 +148,28 @@ type Module struct {
 	// Note: In the Binary Format, this is SectionIDData.
 	DataSection []*DataSegment
 
+	// TagSection contains the index in TypeSection of the type of each tag defined in this module. The params of the
+	// type are the values of exceptions with the tag, and its results are empty.
+	//
+	// Note: In the Binary Format, this is SectionIDTag, which requires FeatureExceptionHandling.
+	// See https://github.com/WebAssembly/exception-handling/blob/main/proposals/exception-handling/Exceptions.md#tag-section
+	TagSection []Index
+
 	// NameSection is set when the SectionIDCustom "name" was successfully decoded from the binary format.
 	//
 	// Note: This is the only SectionIDCustom defined in the WebAssembly 1.0 (20191205) Binary Format.
-	// Others are skipped as they are not used in wazero.
+	// Others are skipped as they are not used in wazero, except those read into DWARFLines.
 	//
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#name-section%E2%91%A0
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#custom-section%E2%91%A0
 	NameSection *NameSection
 
+	// DWARFLines is set when the SectionIDCustom ".debug_info" and ".debug_line" were successfully decoded from the
+	// binary format, such as when the source was compiled with debug information.
+	//
+	// See https://yurydelendik.github.io/webassembly-dwarf/
+	DWARFLines *wasmdebug.DWARFLines
+
 	// HostFunctionSection is index-correlated with FunctionSection and contains a host function defined in Go.
 	// When present, the CodeSection must be nil.
 	//
@@ -177,6 +193,10 @@ type Module struct {
 
 	// ID is the sha256 value of the source wasm and is used for caching.
 	ID ModuleID
+
+	// functionDefinitionsOnce guards functionDefinitionsCache. See functionDefinitions
+	functionDefinitionsOnce  sync.Once
+	functionDefinitionsCache []*FunctionDefinition
 }
 
 // ModuleID represents sha256 hash value uniquely assigned to Module.
@@ -235,7 +255,7 @@ func (m *Module) Validate(enabledFeatures Features) error {
 		return errors.New("cannot mix functions and host functions in the same module")
 	}
 
-	functions, globals, memory, tables, err := m.AllDeclarations()
+	functions, globals, memories, tables, err := m.AllDeclarations()
 	if err != nil {
 		return err
 	}
@@ -248,16 +268,21 @@ func (m *Module) Validate(enabledFeatures Features) error {
 		return err
 	}
 
-	if err = m.validateMemory(memory, globals, enabledFeatures); err != nil {
+	if err = m.validateMemory(memories, globals, enabledFeatures); err != nil {
 		return err
 	}
 
-	if err = m.validateExports(enabledFeatures, functions, globals, memory, tables); err != nil {
+	tags := m.AllTags()
+	if err = m.validateTags(tags); err != nil {
+		return err
+	}
+
+	if err = m.validateExports(enabledFeatures, functions, globals, memories, tables, tags); err != nil {
 		return err
 	}
 
 	if m.CodeSection != nil {
-		if err = m.validateFunctions(enabledFeatures, functions, globals, memory, tables, MaximumFunctionIndex); err != nil {
+		if err = m.validateFunctions(enabledFeatures, functions, globals, memories, tables, MaximumFunctionIndex); err != nil {
 			return err
 		}
 	} // No need to validate host functions as NewHostModule validates
@@ -304,7 +329,7 @@ func (m *Module) validateGlobals(globals []*GlobalType, numFuncts, maxGlobals ui
 	return nil
 }
 
-func (m *Module) validateFunctions(enabledFeatures Features, functions []Index, globals []*GlobalType, memory *Memory, tables []*Table, maximumFunctionIndex uint32) error {
+func (m *Module) validateFunctions(enabledFeatures Features, functions []Index, globals []*GlobalType, memories []*Memory, tables []*Table, maximumFunctionIndex uint32) error {
 	if uint32(len(functions)) > maximumFunctionIndex {
 		return fmt.Errorf("too many functions in a store")
 	}
@@ -330,7 +355,7 @@ func (m *Module) validateFunctions(enabledFeatures Features, functions []Index,
 			return fmt.Errorf("invalid %s: type section index %d out of range", m.funcDesc(SectionIDFunction, Index(idx)), typeIndex)
 		}
 
-		if err := m.validateFunction(enabledFeatures, Index(idx), functions, globals, memory, tables, declaredFuncIndexes); err != nil {
+		if err := m.validateFunction(enabledFeatures, Index(idx), functions, globals, memories, tables, declaredFuncIndexes); err != nil {
 			return fmt.Errorf("invalid %s: %w", m.funcDesc(SectionIDFunction, Index(idx)), err)
 		}
 	}
@@ -404,20 +429,81 @@ func (m *Module) funcDesc(sectionID SectionID, sectionIndex Index) string {
 	return fmt.Sprintf("%s[%d] export[%s]", sectionIDName, sectionIndex, strings.Join(exportNames, ","))
 }
 
-func (m *Module) validateMemory(memory *Memory, globals []*GlobalType, enabledFeatures Features) error {
-	var activeElementCount int
-	for _, sec := range m.DataSection {
-		if !sec.IsPassive() {
-			activeElementCount++
+// validateExtendedConstExpression returns the type of the value the instructions of an extended constant expression
+// leave on the stack, which must be exactly one.
+func validateExtendedConstExpression(globals []*GlobalType, expr *ConstantExpression) (ValueType, error) {
+	var stack []ValueType
+	r := bytes.NewReader(expr.Data)
+	for r.Len() > 0 {
+		op, _ := r.ReadByte()
+		switch op {
+		case OpcodeI32Const:
+			if _, _, err := leb128.DecodeInt32(r); err != nil {
+				return 0, fmt.Errorf("read i32: %w", err)
+			}
+			stack = append(stack, ValueTypeI32)
+		case OpcodeI64Const:
+			if _, _, err := leb128.DecodeInt64(r); err != nil {
+				return 0, fmt.Errorf("read i64: %w", err)
+			}
+			stack = append(stack, ValueTypeI64)
+		case OpcodeGlobalGet:
+			id, _, err := leb128.DecodeUint32(r)
+			if err != nil {
+				return 0, fmt.Errorf("read index of global: %w", err)
+			}
+			if uint32(len(globals)) <= id {
+				return 0, fmt.Errorf("global index out of range")
+			}
+			stack = append(stack, globals[id].ValType)
+		case OpcodeI32Add, OpcodeI32Sub, OpcodeI32Mul, OpcodeI64Add, OpcodeI64Sub, OpcodeI64Mul:
+			var err error
+			if stack, err = popConstArithmeticOperands(stack, op); err != nil {
+				return 0, err
+			}
+		default:
+			return 0, fmt.Errorf("invalid opcode for const expression: 0x%x", op)
 		}
 	}
-	if activeElementCount > 0 && memory == nil {
-		return fmt.Errorf("unknown memory")
+
+	stack, err := popConstArithmeticOperands(stack, expr.Opcode)
+	if err != nil {
+		return 0, err
+	} else if len(stack) != 1 {
+		return 0, fmt.Errorf("const expression must leave one value, but left %d", len(stack))
+	}
+	return stack[0], nil
+}
+
+// popConstArithmeticOperands pops the two operands of the arithmetic instruction op from the stack, and pushes the type
+// of its result.
+func popConstArithmeticOperands(stack []ValueType, op Opcode) ([]ValueType, error) {
+	t := ValueTypeI64
+	if op <= OpcodeI32Mul {
+		t = ValueTypeI32
+	}
+	if len(stack) < 2 {
+		return nil, fmt.Errorf("not enough operands for %s", InstructionName(op))
+	}
+	if x1, x2 := stack[len(stack)-2], stack[len(stack)-1]; x1 != t || x2 != t {
+		return nil, fmt.Errorf("cannot use %s and %s as the operands of %s", ValueTypeName(x1), ValueTypeName(x2), InstructionName(op))
+	}
+	return append(stack[:len(stack)-2], t), nil
+}
+
+func (m *Module) validateMemory(memories []*Memory, globals []*GlobalType, enabledFeatures Features) error {
+	if len(memories) > 1 {
+		if err := enabledFeatures.Require(FeatureMultiMemory); err != nil {
+			return fmt.Errorf("at most one memory allowed in module as %w", err)
+		}
 	}
 
 	for _, d := range m.DataSection {
 		if !d.IsPassive() {
-			if err := validateConstExpression(globals, 0, d.OffsetExpression, ValueTypeI32); err != nil {
+			if d.MemoryIndex >= uint32(len(memories)) {
+				return fmt.Errorf("unknown memory")
+			}
+			if err := validateConstExpression(globals, 0, d.OffsetExpression, memories[d.MemoryIndex].AddressType()); err != nil {
 				return fmt.Errorf("calculate offset: %w", err)
 			}
 		}
@@ -440,7 +526,20 @@ func (m *Module) validateImports(enabledFeatures Features) error {
 	return nil
 }
 
-func (m *Module) validateExports(enabledFeatures Features, functions []Index, globals []*GlobalType, memory *Memory, tables []*Table) error {
+// validateTags ensures the types of tags, including imported ones, exist and have no results.
+func (m *Module) validateTags(tags []Index) error {
+	for i, typeIndex := range tags {
+		if typeIndex >= uint32(len(m.TypeSection)) {
+			return fmt.Errorf("invalid tag[%d]: type section index %d out of range", i, typeIndex)
+		}
+		if len(m.TypeSection[typeIndex].Results) > 0 {
+			return fmt.Errorf("invalid tag[%d]: type %s must have no results", i, m.TypeSection[typeIndex])
+		}
+	}
+	return nil
+}
+
+func (m *Module) validateExports(enabledFeatures Features, functions []Index, globals []*GlobalType, memories []*Memory, tables []*Table, tags []Index) error {
 	for _, exp := range m.ExportSection {
 		index := exp.Index
 		switch exp.Type {
@@ -459,13 +558,20 @@ func (m *Module) validateExports(enabledFeatures Features, functions []Index, gl
 				return fmt.Errorf("invalid export[%q] global[%d]: %w", exp.Name, index, err)
 			}
 		case ExternTypeMemory:
-			if index > 0 || memory == nil {
+			if index >= uint32(len(memories)) {
 				return fmt.Errorf("memory for export[%q] out of range", exp.Name)
 			}
 		case ExternTypeTable:
 			if index >= uint32(len(tables)) {
 				return fmt.Errorf("table for export[%q] out of range", exp.Name)
 			}
+		case ExternTypeTag:
+			if err := enabledFeatures.Require(FeatureExceptionHandling); err != nil {
+				return fmt.Errorf("invalid export[%q] tag[%d]: %w", exp.Name, index, err)
+			}
+			if index >= uint32(len(tags)) {
+				return fmt.Errorf("unknown tag for export[%q]", exp.Name)
+			}
 		}
 	}
 	return nil
@@ -531,6 +637,10 @@ func validateConstExpression(globals []*GlobalType, numFuncs uint32, expr *Const
 			return fmt.Errorf("%s needs 16 bytes but was %d bytes", OpcodeVecV128ConstName, len(expr.Data))
 		}
 		actualType = ValueTypeV128
+	case OpcodeI32Add, OpcodeI32Sub, OpcodeI32Mul, OpcodeI64Add, OpcodeI64Sub, OpcodeI64Mul:
+		if actualType, err = validateExtendedConstExpression(globals, expr); err != nil {
+			return err
+		}
 	default:
 		return fmt.Errorf("invalid opcode for const expression: 0x%x", expr.Opcode)
 	}
@@ -624,6 +734,19 @@ func (m *Module) buildFunctions(moduleName string, fnlf experimental.FunctionLis
 	return
 }
 
+// sourceLocations returns the DWARF source locations of the instruction at the given offset in the body of a function
+// defined in this module, or nil if unknown.
+func (m *Module) sourceLocations(funcIdx Index, offset uint64) []sys.SourceLocation {
+	if m.DWARFLines == nil {
+		return nil
+	}
+	importCount := m.ImportFuncCount()
+	if funcIdx < importCount || int(funcIdx-importCount) >= len(m.CodeSection) {
+		return nil
+	}
+	return m.DWARFLines.Line(m.CodeSection[funcIdx-importCount].BodyOffsetInCodeSection + offset)
+}
+
 func paramNames(localNames IndirectNameMap, funcIdx uint32, paramLen int) []string {
 	for _, nm := range localNames {
 		// Only build parameter names if we have one for each.
@@ -642,11 +765,37 @@ func paramNames(localNames IndirectNameMap, funcIdx uint32, paramLen int) []stri
 	return nil
 }
 
-func (m *Module) buildMemory() (mem *MemoryInstance) {
-	memSec := m.MemorySection
-	if memSec != nil {
-		mem = NewMemoryInstance(memSec)
+// buildMemories returns the memories defined by this module, or errs if the minimum of one is over the limit, if
+// non-nil.
+func (m *Module) buildMemories(limit *MemoryLimit) (memories []*MemoryInstance, err error) {
+	for _, memSec := range m.MemorySection {
+		var mem *MemoryInstance
+		if mem, err = buildMemory(memSec, limit); err != nil {
+			return nil, err
+		}
+		memories = append(memories, mem)
+	}
+	return
+}
+
+func buildMemory(memSec *Memory, limit *MemoryLimit) (mem *MemoryInstance, err error) {
+	if limit == nil || limit.Pages >= memSec.Max {
+		return newMemoryInstance(memSec)
+	}
+
+	if memSec.Min > limit.Pages {
+		return nil, fmt.Errorf("memory min %d pages (%s) over limit of %d pages (%s)",
+			memSec.Min, PagesToUnitOfBytes(memSec.Min), limit.Pages, PagesToUnitOfBytes(limit.Pages))
+	}
+	limited := *memSec // copy, as the capacity must not be over the limit either.
+	if limited.Cap > limit.Pages {
+		limited.Cap = limit.Pages
+	}
+	if mem, err = newMemoryInstance(&limited); err != nil {
+		return nil, err
 	}
+	limitPages := limit.Pages
+	mem.LimitPages, mem.OnLimitExceeded = &limitPages, limit.OnExceeded
 	return
 }
 
@@ -698,6 +847,15 @@ func (f *FunctionType) CacheNumInUint64() {
 	}
 }
 
+// StackLen returns the length of the stack an api.GoModuleFunction of this type is called with, which is the larger of
+// ParamNumInUint64 and ResultNumInUint64.
+func (f *FunctionType) StackLen() int {
+	if f.ParamNumInUint64 > f.ResultNumInUint64 {
+		return f.ParamNumInUint64
+	}
+	return f.ResultNumInUint64
+}
+
 // EqualsSignature returns true if the function type has the same parameters and results.
 func (t *FunctionType) EqualsSignature(params []ValueType, results []ValueType) bool {
 	return bytes.Equal(t.Params, params) && bytes.Equal(t.Results, results)
@@ -747,6 +905,8 @@ type Import struct {
 	DescMem *Memory
 	// DescGlobal is the inlined GlobalType when Type equals ExternTypeGlobal
 	DescGlobal *GlobalType
+	// DescTag is the index in Module.TypeSection when Type equals ExternTypeTag
+	DescTag Index
 }
 
 // Memory describes the limits of pages (64KB) in a memory.
@@ -754,27 +914,51 @@ type Memory struct {
 	Min, Cap, Max uint32
 	// IsMaxEncoded true if the Max is encoded in the original source (binary or text).
 	IsMaxEncoded bool
+	// Shared is true if the memory can be accessed by concurrent calls, such as via atomic instructions. Shared
+	// memories require FeatureThreads and a maximum. Their capacity is the maximum, so that growing doesn't move them.
+	Shared bool
+	// Is64 is true if the memory is addressed with i64 values instead of i32, which requires FeatureMemory64. Such
+	// memories can have up to Memory64LimitPages instead of MemoryLimitPages.
+	Is64 bool
+}
+
+// AddressType returns the type of the addresses and sizes of the memory: ValueTypeI64 if Is64, otherwise ValueTypeI32.
+func (m *Memory) AddressType() ValueType {
+	if m.Is64 {
+		return ValueTypeI64
+	}
+	return ValueTypeI32
+}
+
+// LimitPages returns the maximum number of pages the memory can define: Memory64LimitPages if Is64, otherwise
+// MemoryLimitPages.
+func (m *Memory) LimitPages() uint32 {
+	if m.Is64 {
+		return Memory64LimitPages
+	}
+	return MemoryLimitPages
 }
 
 // Validate ensures values assigned to Min, Cap and Max are within valid thresholds.
 func (m *Memory) Validate() error {
 	min, capacity, max := m.Min, m.Cap, m.Max
+	limit := m.LimitPages()
 
-	if max > MemoryLimitPages {
+	if max > limit {
 		return fmt.Errorf("max %d pages (%s) over limit of %d pages (%s)",
-			max, PagesToUnitOfBytes(max), MemoryLimitPages, PagesToUnitOfBytes(MemoryLimitPages))
-	} else if min > MemoryLimitPages {
+			max, PagesToUnitOfBytes(max), limit, PagesToUnitOfBytes(limit))
+	} else if min > limit {
 		return fmt.Errorf("min %d pages (%s) over limit of %d pages (%s)",
-			min, PagesToUnitOfBytes(min), MemoryLimitPages, PagesToUnitOfBytes(MemoryLimitPages))
+			min, PagesToUnitOfBytes(min), limit, PagesToUnitOfBytes(limit))
 	} else if min > max {
 		return fmt.Errorf("min %d pages (%s) > max %d pages (%s)",
 			min, PagesToUnitOfBytes(min), max, PagesToUnitOfBytes(max))
 	} else if capacity < min {
 		return fmt.Errorf("capacity %d pages (%s) less than minimum %d pages (%s)",
 			capacity, PagesToUnitOfBytes(capacity), min, PagesToUnitOfBytes(min))
-	} else if capacity > MemoryLimitPages {
+	} else if capacity > limit {
 		return fmt.Errorf("capacity %d pages (%s) over limit of %d pages (%s)",
-			capacity, PagesToUnitOfBytes(capacity), MemoryLimitPages, PagesToUnitOfBytes(MemoryLimitPages))
+			capacity, PagesToUnitOfBytes(capacity), limit, PagesToUnitOfBytes(limit))
 	}
 	return nil
 }
@@ -789,9 +973,26 @@ type Global struct {
 	Init *ConstantExpression
 }
 
+// ConstantExpression is an expression which is evaluated when a module is instantiated, such as the initial value of a
+// global or the offset of an active data segment.
 type ConstantExpression struct {
+	// Opcode is the only instruction of the expression, unless it is an extended constant expression
+	// (FeatureExtendedConst). In that case, this is its last instruction, which is an arithmetic one. See IsExtended
 	Opcode Opcode
-	Data   []byte
+	// Data is the immediates of Opcode, or the instructions computing its operands if Opcode is arithmetic.
+	Data []byte
+}
+
+// IsExtended returns true if the expression has more than one instruction, where Data are the instructions computing
+// the operands of Opcode.
+//
+// See https://github.com/WebAssembly/extended-const/blob/main/proposals/extended-const/Overview.md
+func (c *ConstantExpression) IsExtended() bool {
+	switch c.Opcode {
+	case OpcodeI32Add, OpcodeI32Sub, OpcodeI32Mul, OpcodeI64Add, OpcodeI64Sub, OpcodeI64Mul:
+		return true
+	}
+	return false
 }
 
 // Export is the binary representation of an export indicated by Type
@@ -817,11 +1018,18 @@ type Code struct {
 	// Body is a sequence of expressions ending in OpcodeEnd
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#binary-expr
 	Body []byte
+
+	// BodyOffsetInCodeSection is the offset of Body from the beginning of the code section contents, when decoded from
+	// the binary format. This correlates instructions with addresses in DWARFLines.
+	BodyOffsetInCodeSection uint64
 }
 
 type DataSegment struct {
 	OffsetExpression *ConstantExpression
 	Init             []byte
+
+	// MemoryIndex is the memory an active segment is copied into, which is only non-zero with FeatureMultiMemory.
+	MemoryIndex Index
 }
 
 // IsPassive returns true if this data segment is "passive" in the sense that memory offset and
@@ -895,7 +1103,7 @@ type NameMapAssoc struct {
 }
 
 // AllDeclarations returns all declarations for functions, globals, memories and tables in a module including imported ones.
-func (m *Module) AllDeclarations() (functions []Index, globals []*GlobalType, memory *Memory, tables []*Table, err error) {
+func (m *Module) AllDeclarations() (functions []Index, globals []*GlobalType, memories []*Memory, tables []*Table, err error) {
 	for _, imp := range m.ImportSection {
 		switch imp.Type {
 		case ExternTypeFunc:
@@ -903,7 +1111,7 @@ func (m *Module) AllDeclarations() (functions []Index, globals []*GlobalType, me
 		case ExternTypeGlobal:
 			globals = append(globals, imp.DescGlobal)
 		case ExternTypeMemory:
-			memory = imp.DescMem
+			memories = append(memories, imp.DescMem)
 		case ExternTypeTable:
 			tables = append(tables, imp.DescTable)
 		}
@@ -913,19 +1121,23 @@ func (m *Module) AllDeclarations() (functions []Index, globals []*GlobalType, me
 	for _, g := range m.GlobalSection {
 		globals = append(globals, g.Type)
 	}
-	if m.MemorySection != nil {
-		if memory != nil { // shouldn't be possible due to Validate
-			err = errors.New("at most one table allowed in module")
-			return
-		}
-		memory = m.MemorySection
-	}
+	memories = append(memories, m.MemorySection...)
 	if m.TableSection != nil {
 		tables = append(tables, m.TableSection...)
 	}
 	return
 }
 
+// AllTags returns the index in Module.TypeSection of the type of each tag in the module, imports first.
+func (m *Module) AllTags() (tags []Index) {
+	for _, imp := range m.ImportSection {
+		if imp.Type == ExternTypeTag {
+			tags = append(tags, imp.DescTag)
+		}
+	}
+	return append(tags, m.TagSection...)
+}
+
 // SectionID identifies the sections of a Module in the WebAssembly 1.0 (20191205) Binary Format.
 //
 // Note: these are defined in the wasm package, instead of the binary package, as a key per section is needed regardless
@@ -954,6 +1166,12 @@ const (
 	// See https://www.w3.org/TR/2022/WD-wasm-core-2-20220419/binary/modules.html#data-count-section
 	// See https://www.w3.org/TR/2022/WD-wasm-core-2-20220419/appendix/changes.html#bulk-memory-and-table-instructions
 	SectionIDDataCount
+
+	// SectionIDTag may exist when FeatureExceptionHandling is enabled. In the binary format, it is between the memory
+	// and the global sections.
+	//
+	// See https://github.com/WebAssembly/exception-handling/blob/main/proposals/exception-handling/Exceptions.md#tag-section
+	SectionIDTag
 )
 
 // SectionIDHostFunction is a pseudo-section ID for host functions.
@@ -993,6 +1211,8 @@ func SectionIDName(sectionID SectionID) string {
 		return "host_function"
 	case SectionIDDataCount:
 		return "data_count"
+	case SectionIDTag:
+		return "tag"
 	}
 	return "unknown"
 }
@@ -1007,16 +1227,13 @@ const (
 	ValueTypeF64 = api.ValueTypeF64
 	// TODO: ValueTypeV128 is not exposed in the api pkg yet.
 	ValueTypeV128 = 0x7b
-	// TODO: ValueTypeFuncref is not exposed in the api pkg yet.
-	ValueTypeFuncref   ValueType = 0x70
-	ValueTypeExternref           = api.ValueTypeExternref
+	ValueTypeFuncref   = api.ValueTypeFuncref
+	ValueTypeExternref = api.ValueTypeExternref
 )
 
 // ValueTypeName is an alias of api.ValueTypeName defined to simplify imports.
 func ValueTypeName(t ValueType) string {
-	if t == ValueTypeFuncref {
-		return "funcref"
-	} else if t == ValueTypeV128 {
+	if t == ValueTypeV128 {
 		return "v128"
 	}
 	return api.ValueTypeName(t)
@@ -1038,6 +1255,8 @@ const (
 	ExternTypeMemoryName = api.ExternTypeMemoryName
 	ExternTypeGlobal     = api.ExternTypeGlobal
 	ExternTypeGlobalName = api.ExternTypeGlobalName
+	ExternTypeTag        = api.ExternTypeTag
+	ExternTypeTagName    = api.ExternTypeTagName
 )
 
 // ExternTypeName is an alias of api.ExternTypeName defined to simplify imports.
@@ -88,7 +88,7 @@ func TestMemory_Validate(t *testing.T) {
 		{
 			name:        "cap > maxLimit",
 			mem:         &Memory{Min: 2, Cap: math.MaxUint32, Max: 2},
-			expectedErr: "capacity 4294967295 pages (3 Ti) over limit of 65536 pages (4 Gi)",
+			expectedErr: "capacity 4294967295 pages (255 Ti) over limit of 65536 pages (4 Gi)",
 		},
 		{
 			name:        "max < min",
@@ -98,12 +98,12 @@ func TestMemory_Validate(t *testing.T) {
 		{
 			name:        "min > limit",
 			mem:         &Memory{Min: math.MaxUint32},
-			expectedErr: "min 4294967295 pages (3 Ti) over limit of 65536 pages (4 Gi)",
+			expectedErr: "min 4294967295 pages (255 Ti) over limit of 65536 pages (4 Gi)",
 		},
 		{
 			name:        "max > limit",
 			mem:         &Memory{Max: math.MaxUint32, IsMaxEncoded: true},
-			expectedErr: "max 4294967295 pages (3 Ti) over limit of 65536 pages (4 Gi)",
+			expectedErr: "max 4294967295 pages (255 Ti) over limit of 65536 pages (4 Gi)",
 		},
 	}
 
@@ -126,7 +126,7 @@ func TestModule_allDeclarations(t *testing.T) {
 		module            *Module
 		expectedFunctions []Index
 		expectedGlobals   []*GlobalType
-		expectedMemory    *Memory
+		expectedMemories  []*Memory
 		expectedTables    []*Table
 	}{
 		// Functions.
@@ -174,13 +174,13 @@ func TestModule_allDeclarations(t *testing.T) {
 			module: &Module{
 				ImportSection: []*Import{{Type: ExternTypeMemory, DescMem: &Memory{Min: 1, Max: 10}}},
 			},
-			expectedMemory: &Memory{Min: 1, Max: 10},
+			expectedMemories: []*Memory{{Min: 1, Max: 10}},
 		},
 		{
 			module: &Module{
-				MemorySection: &Memory{Min: 100},
+				MemorySection: []*Memory{{Min: 100}},
 			},
-			expectedMemory: &Memory{Min: 100},
+			expectedMemories: []*Memory{{Min: 100}},
 		},
 		// Tables.
 		{
@@ -200,12 +200,12 @@ func TestModule_allDeclarations(t *testing.T) {
 	for i, tt := range tests {
 		tc := tt
 		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
-			functions, globals, memory, tables, err := tc.module.AllDeclarations()
+			functions, globals, memories, tables, err := tc.module.AllDeclarations()
 			require.NoError(t, err)
 			require.Equal(t, tc.expectedFunctions, functions)
 			require.Equal(t, tc.expectedGlobals, globals)
 			require.Equal(t, tc.expectedTables, tables)
-			require.Equal(t, tc.expectedMemory, memory)
+			require.Equal(t, tc.expectedMemories, memories)
 		})
 	}
 }
@@ -323,6 +323,98 @@ func TestValidateConstExpression(t *testing.T) {
 			}
 		})
 	})
+	t.Run("extended", func(t *testing.T) {
+		globals := []*GlobalType{{ValType: ValueTypeI32}, {ValType: ValueTypeI64}}
+		tests := []struct {
+			name         string
+			expr         *ConstantExpression
+			expectedType ValueType
+			expectedErr  string
+		}{
+			{
+				name: "i32.add",
+				expr: &ConstantExpression{
+					Opcode: OpcodeI32Add,
+					Data:   []byte{OpcodeGlobalGet, 0, OpcodeI32Const, 1},
+				},
+				expectedType: ValueTypeI32,
+			},
+			{
+				name: "i64.sub of i64.mul",
+				expr: &ConstantExpression{
+					Opcode: OpcodeI64Sub,
+					Data:   []byte{OpcodeI64Const, 2, OpcodeGlobalGet, 1, OpcodeI64Mul, OpcodeI64Const, 1},
+				},
+				expectedType: ValueTypeI64,
+			},
+			{
+				name: "type mismatch",
+				expr: &ConstantExpression{
+					Opcode: OpcodeI32Add,
+					Data:   []byte{OpcodeGlobalGet, 1, OpcodeI32Const, 1},
+				},
+				expectedType: ValueTypeI32,
+				expectedErr:  "cannot use i64 and i32 as the operands of i32.add",
+			},
+			{
+				name: "result type mismatch",
+				expr: &ConstantExpression{
+					Opcode: OpcodeI64Add,
+					Data:   []byte{OpcodeI64Const, 1, OpcodeI64Const, 1},
+				},
+				expectedType: ValueTypeI32,
+				expectedErr:  "const expression type mismatch expected i32 but got i64",
+			},
+			{
+				name: "not enough operands",
+				expr: &ConstantExpression{
+					Opcode: OpcodeI32Mul,
+					Data:   []byte{OpcodeI32Const, 1},
+				},
+				expectedType: ValueTypeI32,
+				expectedErr:  "not enough operands for i32.mul",
+			},
+			{
+				name: "more than one value left",
+				expr: &ConstantExpression{
+					Opcode: OpcodeI32Mul,
+					Data:   []byte{OpcodeI32Const, 1, OpcodeI32Const, 1, OpcodeI32Const, 1},
+				},
+				expectedType: ValueTypeI32,
+				expectedErr:  "const expression must leave one value, but left 2",
+			},
+			{
+				name: "global index out of range",
+				expr: &ConstantExpression{
+					Opcode: OpcodeI32Add,
+					Data:   []byte{OpcodeGlobalGet, 2, OpcodeI32Const, 1},
+				},
+				expectedType: ValueTypeI32,
+				expectedErr:  "global index out of range",
+			},
+			{
+				name: "invalid opcode",
+				expr: &ConstantExpression{
+					Opcode: OpcodeI32Add,
+					Data:   []byte{OpcodeI32Const, 1, OpcodeI32DivS},
+				},
+				expectedType: ValueTypeI32,
+				expectedErr:  "invalid opcode for const expression: 0x6d",
+			},
+		}
+
+		for _, tt := range tests {
+			tc := tt
+			t.Run(tc.name, func(t *testing.T) {
+				err := validateConstExpression(globals, 0, tc.expr, tc.expectedType)
+				if tc.expectedErr != "" {
+					require.EqualError(t, err, tc.expectedErr)
+				} else {
+					require.NoError(t, err)
+				}
+			})
+		}
+	})
 }
 
 func TestModule_Validate_Errors(t *testing.T) {
@@ -562,7 +654,7 @@ func TestModule_validateMemory(t *testing.T) {
 				Opcode: OpcodeUnreachable, // Invalid!
 			},
 		}}}
-		err := m.validateMemory(&Memory{}, nil, Features20191205)
+		err := m.validateMemory([]*Memory{{}}, nil, Features20191205)
 		require.EqualError(t, err, "calculate offset: invalid opcode for const expression: 0x0")
 	})
 	t.Run("ok", func(t *testing.T) {
@@ -573,7 +665,7 @@ func TestModule_validateMemory(t *testing.T) {
 				Data:   leb128.EncodeInt32(1),
 			},
 		}}}
-		err := m.validateMemory(&Memory{}, nil, Features20191205)
+		err := m.validateMemory([]*Memory{{}}, nil, Features20191205)
 		require.NoError(t, err)
 	})
 }
@@ -648,8 +740,9 @@ func TestModule_validateExports(t *testing.T) {
 		exportSection   []*Export
 		functions       []Index
 		globals         []*GlobalType
-		memory          *Memory
+		memories        []*Memory
 		tables          []*Table
+		tags            []Index
 		expectedErr     string
 	}{
 		{name: "empty export section", exportSection: []*Export{}},
@@ -715,7 +808,7 @@ func TestModule_validateExports(t *testing.T) {
 			name:            "memory",
 			enabledFeatures: Features20191205,
 			exportSection:   []*Export{{Type: ExternTypeMemory, Index: 0}},
-			memory:          &Memory{},
+			memories:        []*Memory{{}},
 		},
 		{
 			name:            "memory out of range",
@@ -724,13 +817,33 @@ func TestModule_validateExports(t *testing.T) {
 			tables:          []*Table{},
 			expectedErr:     `memory for export["e"] out of range`,
 		},
+		{
+			name:            "tag",
+			enabledFeatures: FeatureExceptionHandling,
+			exportSection:   []*Export{{Type: ExternTypeTag, Index: 0}},
+			tags:            []Index{0},
+		},
+		{
+			name:            "tag disabled",
+			enabledFeatures: Features20220419,
+			exportSection:   []*Export{{Type: ExternTypeTag, Index: 0, Name: "e"}},
+			tags:            []Index{0},
+			expectedErr:     `invalid export["e"] tag[0]: feature "exception-handling" is disabled`,
+		},
+		{
+			name:            "tag out of range",
+			enabledFeatures: FeatureExceptionHandling,
+			exportSection:   []*Export{{Type: ExternTypeTag, Index: 1, Name: "e"}},
+			tags:            []Index{0},
+			expectedErr:     `unknown tag for export["e"]`,
+		},
 	}
 
 	for _, tt := range tests {
 		tc := tt
 		t.Run(tc.name, func(t *testing.T) {
 			m := Module{ExportSection: tc.exportSection}
-			err := m.validateExports(tc.enabledFeatures, tc.functions, tc.globals, tc.memory, tc.tables)
+			err := m.validateExports(tc.enabledFeatures, tc.functions, tc.globals, tc.memories, tc.tables, tc.tags)
 			if tc.expectedErr != "" {
 				require.EqualError(t, err, tc.expectedErr)
 			} else {
@@ -773,7 +886,7 @@ func TestModule_buildGlobals(t *testing.T) {
 }
 
 func TestModule_buildFunctions(t *testing.T) {
-	nopCode := &Code{nil, []byte{OpcodeEnd}}
+	nopCode := &Code{Body: []byte{OpcodeEnd}}
 	m := Module{
 		TypeSection:   []*FunctionType{{}},
 		ImportSection: []*Import{{Type: ExternTypeFunc}},
@@ -800,16 +913,49 @@ func TestModule_buildFunctions(t *testing.T) {
 func TestModule_buildMemoryInstance(t *testing.T) {
 	t.Run("nil", func(t *testing.T) {
 		m := Module{}
-		mem := m.buildMemory()
-		require.Nil(t, mem)
+		mems, err := m.buildMemories(nil)
+		require.NoError(t, err)
+		require.Nil(t, mems)
 	})
 	t.Run("non-nil", func(t *testing.T) {
 		min := uint32(1)
 		max := uint32(10)
-		m := Module{MemorySection: &Memory{Min: min, Cap: min, Max: max}}
-		mem := m.buildMemory()
-		require.Equal(t, min, mem.Min)
-		require.Equal(t, max, mem.Max)
+		m := Module{MemorySection: []*Memory{{Min: min, Cap: min, Max: max}}}
+		mems, err := m.buildMemories(nil)
+		require.NoError(t, err)
+		require.Equal(t, min, mems[0].Min)
+		require.Equal(t, max, mems[0].Max)
+		require.Nil(t, mems[0].LimitPages)
+	})
+	t.Run("multiple", func(t *testing.T) {
+		m := Module{MemorySection: []*Memory{{Min: 1, Cap: 1, Max: 10}, {Min: 2, Cap: 2, Max: 2}}}
+		mems, err := m.buildMemories(nil)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(mems))
+		require.Equal(t, uint32(1), mems[0].PageSize(testCtx))
+		require.Equal(t, uint32(2), mems[1].PageSize(testCtx))
+	})
+	t.Run("limit over max", func(t *testing.T) {
+		m := Module{MemorySection: []*Memory{{Min: 1, Cap: 1, Max: 10}}}
+		mems, err := m.buildMemories(&MemoryLimit{Pages: 10})
+		require.NoError(t, err)
+		require.Nil(t, mems[0].LimitPages)
+	})
+	t.Run("limit under max", func(t *testing.T) {
+		m := Module{MemorySection: []*Memory{{Min: 1, Cap: 8, Max: 10}}}
+		mems, err := m.buildMemories(&MemoryLimit{Pages: 4})
+		require.NoError(t, err)
+		mem := mems[0]
+		require.Equal(t, uint32(10), mem.Max)
+		require.Equal(t, uint32(4), *mem.LimitPages)
+		require.Equal(t, uint32(4), mem.Cap) // capacity isn't over the limit
+		require.Equal(t, 4*int(MemoryPageSize), cap(mem.Buffer))
+		require.Equal(t, uint32(8), m.MemorySection[0].Cap) // the module wasn't modified
+	})
+	t.Run("min over limit", func(t *testing.T) {
+		m := Module{MemorySection: []*Memory{{Min: 1, Cap: 1, Max: 10}, {Min: 2, Cap: 2, Max: 10}}}
+		_, err := m.buildMemories(&MemoryLimit{Pages: 1})
+		require.EqualError(t, err, "memory min 2 pages (128 Ki) over limit of 1 pages (64 Ki)")
 	})
 }
 
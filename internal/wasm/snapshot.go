@@ -0,0 +1,434 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// snapshotMagic prefixes each snapshot, to avoid reading data that isn't one.
+const snapshotMagic = "WZSNAP"
+
+// snapshotFormatVersion must be incremented whenever the layout written by Snapshot changes.
+const snapshotFormatVersion byte = 2
+
+// errCorruptedSnapshot is returned by Restore when the snapshot doesn't match its checksum or is truncated.
+var errCorruptedSnapshot = errors.New("corrupted snapshot")
+
+// snapshot is the decoded state written by ModuleInstance.Snapshot.
+type snapshot struct {
+	moduleID ModuleID
+	// memories are the contents of memories defined by the module, in index order.
+	memories [][]byte
+	// globals are the values of mutable globals defined by the module, in index order. Each is two uint64s, where the
+	// second is ValHi. Function references are encoded with funcrefToIndex.
+	globals [][2]uint64
+	// tables are the elements of tables defined by the module, in index order. Function references are encoded with
+	// funcrefToIndex.
+	tables [][]uint64
+	// droppedData and droppedElements are true for each segment dropped by "data.drop" or "elem.drop".
+	droppedData, droppedElements []bool
+}
+
+// Snapshot returns the state of this module which can change after instantiation, so that Restore can apply it to
+// another instance of the same module. This includes the memories, mutable globals and tables defined by the module, and
+// which data and element segments were dropped.
+//
+// The result is portable across processes: function references are encoded by their index in the function index
+// namespace of this module. This returns an error if a table or global includes a function not in that namespace or a
+// non-null externref, as they aren't portable.
+//
+// The layout is the following, where integers are little-endian and the checksum is a sha256 of all preceding bytes:
+//	magic | formatVersion | moduleID | len(memories) uint32 | (len(memory) uint64 | memory)... |
+//	len(globals) uint32 | (val uint64 | valHi uint64)... | len(tables) uint32 | (len(table) uint32 | ref uint64...)... |
+//	len(data) uint32 | dropped byte... | len(elements) uint32 | dropped byte... | checksum
+//
+// Note: This must not be called concurrently with function calls in this module.
+func (m *ModuleInstance) Snapshot() ([]byte, error) {
+	s, err := m.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return s.encode(), nil
+}
+
+// snapshot implements Snapshot except the encoding.
+func (m *ModuleInstance) snapshot() (*snapshot, error) {
+	s := &snapshot{moduleID: m.Source.ID}
+
+	for _, mem := range m.definedMemories() {
+		mem.mux.RLock()
+		s.memories = append(s.memories, append([]byte{}, mem.Buffer[:mem.Size64(nil)]...))
+		mem.mux.RUnlock()
+	}
+
+	indexes := make(map[*FunctionInstance]uint64, len(m.Functions))
+	for i, f := range m.Functions {
+		indexes[f] = uint64(i) + 1 // zero is null
+	}
+	funcrefToIndex := func(ref Reference) (uint64, error) {
+		if ref == 0 {
+			return 0, nil
+		}
+		if index, ok := indexes[m.Engine.LookupFunction(ref)]; ok {
+			return index, nil
+		}
+		return 0, errors.New("function from another module")
+	}
+
+	for i, g := range m.definedGlobals() {
+		val := g.Val
+		switch g.Type.ValType {
+		case ValueTypeFuncref:
+			var err error
+			if val, err = funcrefToIndex(Reference(val)); err != nil {
+				return nil, fmt.Errorf("mutable global[%d]: %w", i, err)
+			}
+		case ValueTypeExternref:
+			if val != 0 {
+				return nil, fmt.Errorf("mutable global[%d]: non-null externref", i)
+			}
+		}
+		s.globals = append(s.globals, [2]uint64{val, g.ValHi})
+	}
+
+	for i, t := range m.definedTables() {
+		t.mux.RLock()
+		refs := make([]uint64, len(t.References))
+		var err error
+		for j, ref := range t.References {
+			if t.Type == RefTypeFuncref {
+				if refs[j], err = funcrefToIndex(ref); err != nil {
+					break
+				}
+			} else if ref != 0 {
+				err = errors.New("non-null externref")
+				break
+			}
+		}
+		t.mux.RUnlock()
+		if err != nil {
+			return nil, fmt.Errorf("table[%d]: %w", i, err)
+		}
+		s.tables = append(s.tables, refs)
+	}
+
+	for _, d := range m.DataInstances {
+		s.droppedData = append(s.droppedData, len(d) == 0)
+	}
+	for _, e := range m.ElementInstances {
+		s.droppedElements = append(s.droppedElements, len(e.References) == 0)
+	}
+	return s, nil
+}
+
+// Restore applies the state returned by Snapshot of an instance of the same module. This returns an error, without
+// changing any state, if the snapshot is corrupted, from another module or doesn't fit this instance. Ex. the memory
+// can't grow to the size of the snapshot due to a memory limit.
+//
+// Note: This must not be called concurrently with function calls in this module.
+func (m *ModuleInstance) Restore(ctx context.Context, b []byte) error {
+	s, err := decodeSnapshot(b)
+	if err != nil {
+		return err
+	}
+	if s.moduleID != m.Source.ID {
+		return errors.New("snapshot is of a different module")
+	}
+
+	memories, globals, tables := m.definedMemories(), m.definedGlobals(), m.definedTables()
+	// The snapshot is of the same module, so mismatched counts mean the snapshot was crafted.
+	if len(s.memories) != len(memories) || len(s.globals) != len(globals) ||
+		len(s.tables) != len(tables) || len(s.droppedData) != len(m.DataInstances) ||
+		len(s.droppedElements) != len(m.ElementInstances) {
+		return errCorruptedSnapshot
+	}
+
+	// Validate before changing any state.
+	functionCount := uint64(len(m.Functions))
+	for i, g := range globals {
+		if g.Type.ValType == ValueTypeFuncref && s.globals[i][0] > functionCount {
+			return fmt.Errorf("mutable global[%d]: %w", i, errCorruptedSnapshot)
+		}
+	}
+	for i, t := range tables {
+		if len(s.tables[i]) < len(t.References) {
+			return fmt.Errorf("table[%d]: snapshot has %d elements, but the table has %d", i, len(s.tables[i]), len(t.References))
+		} else if t.Max != nil && uint64(len(s.tables[i])) > uint64(*t.Max) {
+			return fmt.Errorf("table[%d]: snapshot has %d elements, over the max of %d", i, len(s.tables[i]), *t.Max)
+		}
+		for _, ref := range s.tables[i] {
+			if ref > functionCount {
+				return fmt.Errorf("table[%d]: %w", i, errCorruptedSnapshot)
+			}
+		}
+	}
+	for i, mem := range memories {
+		if err = validateMemorySize(i, mem, uint64(len(s.memories[i]))); err != nil {
+			return err
+		}
+	}
+
+	// Only change state after all validation passed.
+	for i, mem := range memories {
+		if err = restoreMemorySize(ctx, i, mem, uint64(len(s.memories[i]))); err != nil {
+			return err // only possible when the memory is grown concurrently, which is documented as unsupported.
+		}
+		copy(mem.Buffer, s.memories[i])
+	}
+
+	m.restoreGlobalsAndTables(ctx, s)
+
+	for i, dropped := range s.droppedData {
+		if dropped {
+			m.DataInstances[i] = nil
+		}
+	}
+	for i, dropped := range s.droppedElements {
+		if dropped {
+			m.ElementInstances[i].References = nil
+		}
+	}
+	return nil
+}
+
+// restoreGlobalsAndTables applies the globals and tables of the snapshot, growing tables smaller than it.
+func (m *ModuleInstance) restoreGlobalsAndTables(ctx context.Context, s *snapshot) {
+	indexToFuncref := func(index uint64) Reference {
+		if index == 0 {
+			return 0
+		}
+		idx := Index(index - 1)
+		return m.Engine.CreateFuncElementInstance([]*Index{&idx}).References[0]
+	}
+
+	for i, g := range m.definedGlobals() {
+		g.Val, g.ValHi = s.globals[i][0], s.globals[i][1]
+		if g.Type.ValType == ValueTypeFuncref {
+			g.Val = uint64(indexToFuncref(g.Val))
+		}
+	}
+
+	for i, t := range m.definedTables() {
+		if delta := uint32(len(s.tables[i]) - len(t.References)); delta > 0 {
+			t.Grow(ctx, delta, 0) // validated by the caller
+		}
+		t.mux.Lock()
+		for j, ref := range s.tables[i] {
+			if t.Type == RefTypeFuncref {
+				t.References[j] = indexToFuncref(ref)
+			} else {
+				t.References[j] = 0
+			}
+		}
+		t.mux.Unlock()
+	}
+}
+
+// validateMemorySize returns an error if the i-th defined memory can't grow to the size in bytes, without growing it.
+func validateMemorySize(i int, mem *MemoryInstance, size uint64) error {
+	current, pageSize := mem.Size64(nil), uint64(MemoryPageSize)
+	if size%pageSize != 0 {
+		return fmt.Errorf("%s: %w", memoryDesc(i), errCorruptedSnapshot)
+	} else if size < current {
+		return fmt.Errorf("%s: snapshot has %d pages, but the memory has %d", memoryDesc(i), size/pageSize, current/pageSize)
+	}
+	if pages := size / pageSize; pages > uint64(mem.Max) || (mem.LimitPages != nil && pages > uint64(*mem.LimitPages)) {
+		return fmt.Errorf("%s: couldn't grow to the %d pages of the snapshot", memoryDesc(i), pages)
+	}
+	return nil
+}
+
+// restoreMemorySize grows the i-th defined memory to the size in bytes, which must be validated by validateMemorySize.
+func restoreMemorySize(ctx context.Context, i int, mem *MemoryInstance, size uint64) error {
+	current := mem.Size64(ctx)
+	if size == current {
+		return nil
+	}
+	if _, ok := mem.Grow(ctx, uint32((size-current)/uint64(MemoryPageSize))); !ok {
+		return fmt.Errorf("%s: couldn't grow to the %d pages of the snapshot", memoryDesc(i), size/uint64(MemoryPageSize))
+	}
+	return nil
+}
+
+// memoryDesc describes the i-th defined memory in errors, which is only numbered when it isn't the first.
+func memoryDesc(i int) string {
+	if i == 0 {
+		return "memory"
+	}
+	return fmt.Sprintf("memory[%d]", i)
+}
+
+// definedMemories returns the memories defined by the module, as opposed to imported.
+func (m *ModuleInstance) definedMemories() []*MemoryInstance {
+	if len(m.Memories) == 0 {
+		return nil
+	}
+	return m.Memories[m.Source.ImportMemoryCount():]
+}
+
+// definedGlobals returns the mutable globals defined by the module, as opposed to imported.
+func (m *ModuleInstance) definedGlobals() (ret []*GlobalInstance) {
+	for _, g := range m.Globals[m.Source.ImportGlobalCount():] {
+		if g.Type.Mutable {
+			ret = append(ret, g)
+		}
+	}
+	return
+}
+
+// definedTables returns the tables defined by the module, as opposed to imported.
+func (m *ModuleInstance) definedTables() []*TableInstance {
+	return m.Tables[m.Source.ImportTableCount():]
+}
+
+// encode returns the layout documented on ModuleInstance.Snapshot.
+func (s *snapshot) encode() []byte {
+	buf := bytes.NewBuffer(nil)
+	buf.WriteString(snapshotMagic)
+	buf.WriteByte(snapshotFormatVersion)
+	buf.Write(s.moduleID[:])
+
+	writeSnapshotUint32(buf, uint32(len(s.memories)))
+	for _, mem := range s.memories {
+		writeSnapshotUint64(buf, uint64(len(mem)))
+		buf.Write(mem)
+	}
+
+	writeSnapshotUint32(buf, uint32(len(s.globals)))
+	for _, g := range s.globals {
+		writeSnapshotUint64(buf, g[0])
+		writeSnapshotUint64(buf, g[1])
+	}
+
+	writeSnapshotUint32(buf, uint32(len(s.tables)))
+	for _, t := range s.tables {
+		writeSnapshotUint32(buf, uint32(len(t)))
+		for _, ref := range t {
+			writeSnapshotUint64(buf, ref)
+		}
+	}
+
+	for _, dropped := range [][]bool{s.droppedData, s.droppedElements} {
+		writeSnapshotUint32(buf, uint32(len(dropped)))
+		for _, d := range dropped {
+			if d {
+				buf.WriteByte(1)
+			} else {
+				buf.WriteByte(0)
+			}
+		}
+	}
+
+	checksum := sha256.Sum256(buf.Bytes())
+	buf.Write(checksum[:])
+	return buf.Bytes()
+}
+
+// decodeSnapshot decodes the layout documented on ModuleInstance.Snapshot.
+func decodeSnapshot(b []byte) (*snapshot, error) {
+	if len(b) < sha256.Size {
+		return nil, errCorruptedSnapshot
+	}
+	body, checksum := b[:len(b)-sha256.Size], b[len(b)-sha256.Size:]
+	if actual := sha256.Sum256(body); !bytes.Equal(actual[:], checksum) {
+		return nil, errCorruptedSnapshot
+	}
+
+	r := &snapshotReader{b: body}
+	if magic := r.bytes(uint64(len(snapshotMagic))); string(magic) != snapshotMagic {
+		return nil, errCorruptedSnapshot
+	}
+	if formatVersion := r.bytes(1); r.err == nil && formatVersion[0] != snapshotFormatVersion {
+		return nil, fmt.Errorf("snapshot has format %d, but expected %d", formatVersion[0], snapshotFormatVersion)
+	}
+
+	s := &snapshot{}
+	copy(s.moduleID[:], r.bytes(uint64(len(s.moduleID))))
+
+	for i, count := uint32(0), r.uint32(); i < count && r.err == nil; i++ {
+		s.memories = append(s.memories, append([]byte{}, r.bytes(r.uint64())...))
+	}
+
+	for i, count := uint32(0), r.uint32(); i < count && r.err == nil; i++ {
+		s.globals = append(s.globals, [2]uint64{r.uint64(), r.uint64()})
+	}
+
+	for i, count := uint32(0), r.uint32(); i < count && r.err == nil; i++ {
+		size := r.uint32()
+		if uint64(size)*8 > uint64(len(r.b)) { // avoid allocating for a size that can't be valid
+			return nil, errCorruptedSnapshot
+		}
+		refs := make([]uint64, size)
+		for j := range refs {
+			refs[j] = r.uint64()
+		}
+		s.tables = append(s.tables, refs)
+	}
+
+	for _, dropped := range []*[]bool{&s.droppedData, &s.droppedElements} {
+		for i, count := uint32(0), r.uint32(); i < count && r.err == nil; i++ {
+			if d := r.bytes(1); d != nil {
+				*dropped = append(*dropped, d[0] == 1)
+			}
+		}
+	}
+
+	if r.err == nil && len(r.b) != 0 {
+		r.err = errCorruptedSnapshot // trailing bytes
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return s, nil
+}
+
+// snapshotReader reads the layout documented on ModuleInstance.Snapshot, failing with errCorruptedSnapshot if it is
+// truncated.
+type snapshotReader struct {
+	b   []byte
+	err error
+}
+
+// bytes returns the next n bytes or nil if there are too few.
+func (r *snapshotReader) bytes(n uint64) (ret []byte) {
+	if r.err != nil {
+		return
+	} else if n > uint64(len(r.b)) {
+		r.err = errCorruptedSnapshot
+		return
+	}
+	ret, r.b = r.b[:n], r.b[n:]
+	return
+}
+
+// uint32 returns the next little-endian uint32 or zero if there are too few bytes.
+func (r *snapshotReader) uint32() uint32 {
+	if b := r.bytes(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+// uint64 returns the next little-endian uint64 or zero if there are too few bytes.
+func (r *snapshotReader) uint64() uint64 {
+	if b := r.bytes(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+func writeSnapshotUint32(buf *bytes.Buffer, v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	buf.Write(b[:])
+}
+
+func writeSnapshotUint64(buf *bytes.Buffer, v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	buf.Write(b[:])
+}
@@ -0,0 +1,160 @@
+package wasm
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+// newSnapshotModuleInstance returns a fresh instance of a module with a memory, an immutable and a mutable global, an
+// externref table and two passive data and element segments.
+func newSnapshotModuleInstance(source *Module) *ModuleInstance {
+	mem := NewMemoryInstance(&Memory{Min: 1, Cap: 1, Max: 3})
+	return &ModuleInstance{
+		Source:   source,
+		Memory:   mem,
+		Memories: []*MemoryInstance{mem},
+		Globals: []*GlobalInstance{
+			{Type: &GlobalType{ValType: ValueTypeI32}, Val: 1},
+			{Type: &GlobalType{ValType: ValueTypeV128, Mutable: true}},
+		},
+		Tables:           []*TableInstance{{References: make([]Reference, 1), Min: 1, Max: uint32Ptr(4), Type: RefTypeExternref}},
+		DataInstances:    []DataInstance{{1}, {2}},
+		ElementInstances: []ElementInstance{{References: []Reference{0}}, {References: []Reference{0}}},
+		Engine:           &mockModuleEngine{},
+	}
+}
+
+func TestModuleInstance_Snapshot(t *testing.T) {
+	source := &Module{ID: ModuleID{1}, MemorySection: []*Memory{{Min: 1, Cap: 1, Max: 3}}}
+	m := newSnapshotModuleInstance(source)
+
+	// Change each type of state
+	_, ok := m.Memory.Grow(testCtx, 1)
+	require.True(t, ok)
+	m.Memory.Buffer[MemoryPageSize] = 42
+	m.Globals[1].Val, m.Globals[1].ValHi = 2, 3
+	m.Tables[0].Grow(testCtx, 2, 0)
+	m.DataInstances[1] = nil
+	m.ElementInstances[0].References = nil
+
+	snapshot, err := m.Snapshot()
+	require.NoError(t, err)
+
+	restored := newSnapshotModuleInstance(source)
+	require.NoError(t, restored.Restore(testCtx, snapshot))
+
+	require.Equal(t, m.Memory.Buffer, restored.Memory.Buffer)
+	require.Equal(t, uint64(1), restored.Globals[0].Val) // immutable
+	require.Equal(t, [2]uint64{2, 3}, [2]uint64{restored.Globals[1].Val, restored.Globals[1].ValHi})
+	require.Equal(t, 3, len(restored.Tables[0].References))
+	require.Equal(t, []DataInstance{{1}, nil}, restored.DataInstances)
+	require.Nil(t, restored.ElementInstances[0].References)
+	require.Equal(t, []Reference{0}, restored.ElementInstances[1].References)
+}
+
+func TestModuleInstance_Snapshot_Errors(t *testing.T) {
+	source := &Module{ID: ModuleID{1}, MemorySection: []*Memory{{Min: 1, Cap: 1, Max: 3}}}
+	m := newSnapshotModuleInstance(source)
+
+	m.Tables[0].References[0] = 1
+	_, err := m.Snapshot()
+	require.EqualError(t, err, "table[0]: non-null externref")
+}
+
+func TestModuleInstance_Restore_Errors(t *testing.T) {
+	source := &Module{ID: ModuleID{1}, MemorySection: []*Memory{{Min: 1, Cap: 1, Max: 3}}}
+	snapshot, err := newSnapshotModuleInstance(source).Snapshot()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		snapshot    []byte
+		instance    func() *ModuleInstance
+		expectedErr string
+	}{
+		{
+			name:        "empty",
+			snapshot:    []byte{},
+			instance:    func() *ModuleInstance { return newSnapshotModuleInstance(source) },
+			expectedErr: "corrupted snapshot",
+		},
+		{
+			name: "checksum mismatch",
+			snapshot: func() []byte {
+				corrupted := append([]byte{}, snapshot...)
+				corrupted[len(snapshotMagic)+1] ^= 0xff
+				return corrupted
+			}(),
+			instance:    func() *ModuleInstance { return newSnapshotModuleInstance(source) },
+			expectedErr: "corrupted snapshot",
+		},
+		{
+			name:     "different module",
+			snapshot: snapshot,
+			instance: func() *ModuleInstance {
+				return newSnapshotModuleInstance(&Module{ID: ModuleID{2}, MemorySection: source.MemorySection})
+			},
+			expectedErr: "snapshot is of a different module",
+		},
+		{
+			name:     "memory larger than snapshot",
+			snapshot: snapshot,
+			instance: func() *ModuleInstance {
+				m := newSnapshotModuleInstance(source)
+				m.Memory.Grow(testCtx, 1)
+				return m
+			},
+			expectedErr: "memory: snapshot has 1 pages, but the memory has 2",
+		},
+		{
+			name:     "table larger than snapshot",
+			snapshot: snapshot,
+			instance: func() *ModuleInstance {
+				m := newSnapshotModuleInstance(source)
+				m.Tables[0].Grow(testCtx, 1, 0)
+				return m
+			},
+			expectedErr: "table[0]: snapshot has 1 elements, but the table has 2",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			m := tc.instance()
+			m.Globals[1].Val = 5
+			err := m.Restore(testCtx, tc.snapshot)
+			require.EqualError(t, err, tc.expectedErr)
+			require.Equal(t, uint64(5), m.Globals[1].Val) // unchanged
+		})
+	}
+}
+
+func TestModuleInstance_Restore_MemoryLimitUnchanged(t *testing.T) {
+	source := &Module{ID: ModuleID{1}, MemorySection: []*Memory{{Min: 1, Cap: 1, Max: 3}, {Min: 1, Cap: 1, Max: 3}}}
+	newInstance := func() *ModuleInstance {
+		m := newSnapshotModuleInstance(source)
+		m.Memories = append(m.Memories, NewMemoryInstance(source.MemorySection[1]))
+		return m
+	}
+
+	m := newInstance()
+	for _, mem := range m.Memories {
+		_, ok := mem.Grow(testCtx, 1)
+		require.True(t, ok)
+	}
+	snapshot, err := m.Snapshot()
+	require.NoError(t, err)
+
+	// The second memory can't grow to the size of the snapshot, which must fail before the first memory grows.
+	restored := newInstance()
+	limit := uint32(1)
+	restored.Memories[1].LimitPages = &limit
+	err = restored.Restore(testCtx, snapshot)
+	require.EqualError(t, err, "memory[1]: couldn't grow to the 2 pages of the snapshot")
+	for _, mem := range restored.Memories {
+		require.Equal(t, uint32(1), mem.PageSize(testCtx))
+	}
+}
@@ -13,7 +13,8 @@ import (
 	experimentalapi "github.com/tetratelabs/wazero/experimental"
 	"github.com/tetratelabs/wazero/internal/ieee754"
 	"github.com/tetratelabs/wazero/internal/leb128"
-	"github.com/tetratelabs/wazero/internal/sys"
+	internalsys "github.com/tetratelabs/wazero/internal/sys"
+	"github.com/tetratelabs/wazero/sys"
 )
 
 type (
@@ -35,6 +36,10 @@ type (
 		// Engine is a global context for a Store which is in responsible for compilation and execution of Wasm modules.
 		Engine Engine
 
+		// MemoryLimitPages limits the pages of any memory defined by a module instantiated in this store.
+		// This defaults to Memory64LimitPages, which doesn't limit any memory.
+		MemoryLimitPages uint32
+
 		// typeIDs maps each FunctionType.String() to a unique FunctionTypeID. This is used at runtime to
 		// do type-checks on indirect function calls.
 		typeIDs map[string]FunctionTypeID
@@ -60,7 +65,7 @@ type (
 		Exports   map[string]*ExportInstance
 		Functions []*FunctionInstance
 		Globals   []*GlobalInstance
-		// Memory is set when Module.MemorySection had a memory, regardless of whether it was exported.
+		// Memory is the first memory in the memory index namespace, regardless of whether it was exported.
 		Memory *MemoryInstance
 		Tables []*TableInstance
 		Types  []*FunctionType
@@ -84,6 +89,28 @@ type (
 		// ElementInstances holds the element instance, and each holds the references to either functions
 		// or external objects (unimplemented).
 		ElementInstances []ElementInstance
+
+		// Memories are the memories in the memory index namespace, imports first, so the first is also Memory. There
+		// are more than one only with FeatureMultiMemory.
+		Memories []*MemoryInstance
+
+		// Source is the module this was instantiated from.
+		Source *Module
+
+		// Tags are the tags of the exception handling proposal, imports first.
+		//
+		// Note: This is after the fields whose offsets are used by the compiler engine.
+		Tags []*TagInstance
+
+		// s is the store this was instantiated in, whose Engine created the Engine of this module.
+		s *Store
+
+		// callCtxs caches the CallContext of each *ModuleInstance whose host functions are called by this module, with
+		// its Memory. See CallContext.WithMemoryOf
+		//
+		// Note: This is on the caller, not the callee, so that entries are released with the caller, which is usually
+		// more short-lived than the host module.
+		callCtxs sync.Map
 	}
 
 	// DataInstance holds bytes corresponding to the data segment in a module.
@@ -102,6 +129,7 @@ type (
 		Global   *GlobalInstance
 		Memory   *MemoryInstance
 		Table    *TableInstance
+		Tag      *TagInstance
 	}
 
 	// FunctionInstance represents a function instance in a Store.
@@ -154,6 +182,11 @@ type (
 
 		// FunctionListener holds a listener to notify when this function is called.
 		FunctionListener experimentalapi.FunctionListener
+
+		// GoModuleFunc is set when Kind == FunctionKindGoModuleFunction, and called with the stack instead of GoFunc.
+		//
+		// Note: This is last, so that it doesn't change the offsets of fields read by compiled code, such as TypeID.
+		GoModuleFunc api.GoModuleFunction
 	}
 
 	// GlobalInstance represents a global instance in a store.
@@ -173,31 +206,56 @@ type (
 	FunctionTypeID uint32
 )
 
-// Index implements the same method as documented on experimental.FunctionDefinition.
+// Index implements the same method as documented on api.FunctionDefinition.
 func (f *FunctionInstance) Index() uint32 {
 	return f.Idx
 }
 
-// Name implements the same method as documented on experimental.FunctionDefinition.
+// Name implements the same method as documented on api.FunctionDefinition.
 func (f *FunctionInstance) Name() string {
 	return f.name
 }
 
-// ModuleName implements the same method as documented on experimental.FunctionDefinition.
+// ModuleName implements the same method as documented on api.FunctionDefinition.
 func (f *FunctionInstance) ModuleName() string {
 	return f.moduleName
 }
 
-// ExportNames implements the same method as documented on experimental.FunctionDefinition.
+// ExportNames implements the same method as documented on api.FunctionDefinition.
 func (f *FunctionInstance) ExportNames() []string {
 	return f.exportNames
 }
 
-// ParamNames implements the same method as documented on experimental.FunctionDefinition.
+// ParamNames implements the same method as documented on api.FunctionDefinition.
 func (f *FunctionInstance) ParamNames() []string {
 	return f.paramNames
 }
 
+// Import implements the same method as documented on api.FunctionDefinition.
+//
+// Note: This is always false, as a FunctionInstance is defined by Module, even when another module imports it.
+func (f *FunctionInstance) Import() (moduleName, name string, isImport bool) {
+	return
+}
+
+// StackFrame returns the frame of a call to this function for a sys.StackTraceError, where offset is the position in
+// Body of the instruction in progress, or -1 if unknown.
+func (f *FunctionInstance) StackFrame(offset int64) sys.StackFrame {
+	frame := sys.StackFrame{
+		ModuleName:    f.moduleName,
+		FunctionIndex: f.Idx,
+		FunctionName:  f.name,
+		ParamTypes:    f.ParamTypes(),
+		ParamNames:    f.paramNames,
+		ResultTypes:   f.ResultTypes(),
+		Offset:        offset,
+	}
+	if offset >= 0 && f.Kind == FunctionKindWasm && f.Module != nil && f.Module.Source != nil {
+		frame.Sources = f.Module.Source.sourceLocations(f.Idx, uint64(offset))
+	}
+	return frame
+}
+
 // The wazero specific limitations described at RATIONALE.md.
 const (
 	maximumFunctionTypes = 1 << 27
@@ -205,8 +263,8 @@ const (
 
 // addSections adds section elements to the ModuleInstance
 func (m *ModuleInstance) addSections(module *Module, importedFunctions, functions []*FunctionInstance,
-	importedGlobals, globals []*GlobalInstance, tables []*TableInstance, memory, importedMemory *MemoryInstance,
-	types []*FunctionType, typeIDs []FunctionTypeID) {
+	importedGlobals, globals []*GlobalInstance, tables []*TableInstance, importedMemories, memories []*MemoryInstance,
+	importedTags, tags []*TagInstance, types []*FunctionType, typeIDs []FunctionTypeID) {
 
 	m.Types = types
 	m.TypeIDs = typeIDs
@@ -224,10 +282,13 @@ func (m *ModuleInstance) addSections(module *Module, importedFunctions, function
 
 	m.Tables = tables
 
-	if importedMemory != nil {
-		m.Memory = importedMemory
-	} else {
-		m.Memory = memory
+	m.Tags = append(m.Tags, importedTags...)
+	m.Tags = append(m.Tags, tags...)
+
+	m.Memories = append(m.Memories, importedMemories...)
+	m.Memories = append(m.Memories, memories...)
+	if len(m.Memories) > 0 {
+		m.Memory = m.Memories[0]
 	}
 
 	m.buildExports(module.ExportSection)
@@ -262,9 +323,11 @@ func (m *ModuleInstance) buildExports(exports []*Export) {
 		case ExternTypeGlobal:
 			ei = &ExportInstance{Type: exp.Type, Global: m.Globals[index]}
 		case ExternTypeMemory:
-			ei = &ExportInstance{Type: exp.Type, Memory: m.Memory}
+			ei = &ExportInstance{Type: exp.Type, Memory: m.MemoryAt(index)}
 		case ExternTypeTable:
 			ei = &ExportInstance{Type: exp.Type, Table: m.Tables[index]}
+		case ExternTypeTag:
+			ei = &ExportInstance{Type: exp.Type, Tag: m.Tags[index]}
 		}
 
 		// We already validated the duplicates during module validation phase.
@@ -275,9 +338,8 @@ func (m *ModuleInstance) buildExports(exports []*Export) {
 func (m *ModuleInstance) validateData(data []*DataSegment) (err error) {
 	for i, d := range data {
 		if !d.IsPassive() {
-			offset := int(executeConstExpression(m.Globals, d.OffsetExpression).(int32))
-			ceil := offset + len(d.Init)
-			if offset < 0 || ceil > len(m.Memory.Buffer) {
+			offset := dataOffset(m.Globals, d)
+			if offset < 0 || offset > int64(m.MemoryAt(d.MemoryIndex).Size64(nil))-int64(len(d.Init)) {
 				return fmt.Errorf("%s[%d] out of bounds memory access", SectionIDName(SectionIDElement), i)
 			}
 		}
@@ -288,16 +350,36 @@ func (m *ModuleInstance) validateData(data []*DataSegment) (err error) {
 func (m *ModuleInstance) applyData(data []*DataSegment) error {
 	for i, d := range data {
 		if !d.IsPassive() {
-			offset := executeConstExpression(m.Globals, d.OffsetExpression).(int32)
-			if offset < 0 || int(offset)+len(d.Init) > len(m.Memory.Buffer) {
+			offset := dataOffset(m.Globals, d)
+			mem := m.MemoryAt(d.MemoryIndex)
+			if offset < 0 || offset > int64(mem.Size64(nil))-int64(len(d.Init)) {
 				return fmt.Errorf("%s[%d] out of bounds memory access", SectionIDName(SectionIDElement), i)
 			}
-			copy(m.Memory.Buffer[offset:], d.Init)
+			copy(mem.Buffer[offset:], d.Init)
 		}
 	}
 	return nil
 }
 
+// dataOffset returns the offset of the active data segment, which is an i64 for a memory with 64-bit indices.
+func dataOffset(globals []*GlobalInstance, d *DataSegment) int64 {
+	switch offset := executeConstExpression(globals, d.OffsetExpression).(type) {
+	case int32:
+		return int64(offset)
+	default:
+		return offset.(int64)
+	}
+}
+
+// MemoryAt returns the memory at the index in the memory index namespace, which must be valid. The index zero is
+// Memory, to avoid an indirection for modules with only one memory.
+func (m *ModuleInstance) MemoryAt(index Index) *MemoryInstance {
+	if index == 0 {
+		return m.Memory
+	}
+	return m.Memories[index]
+}
+
 // GetExport returns an export of the given name and type or errs if not exported or the wrong type.
 func (m *ModuleInstance) getExport(name string, et ExternType) (*ExportInstance, error) {
 	exp, ok := m.Exports[name]
@@ -315,6 +397,7 @@ func NewStore(enabledFeatures Features, engine Engine) (*Store, *Namespace) {
 	return &Store{
 		EnabledFeatures:  enabledFeatures,
 		Engine:           engine,
+		MemoryLimitPages: Memory64LimitPages,
 		namespaces:       []*Namespace{ns},
 		typeIDs:          map[string]FunctionTypeID{},
 		functionMaxTypes: maximumFunctionTypes,
@@ -345,8 +428,9 @@ func (s *Store) Instantiate(
 	ns *Namespace,
 	module *Module,
 	name string,
-	sys *sys.Context,
+	sys *internalsys.Context,
 	functionListenerFactory experimentalapi.FunctionListenerFactory,
+	memoryLimit *MemoryLimit,
 ) (*CallContext, error) {
 	if ctx == nil {
 		ctx = context.Background()
@@ -370,7 +454,7 @@ func (s *Store) Instantiate(
 	}
 
 	// Instantiate the module and add it to the namespace so that other modules can import it.
-	if callCtx, err := s.instantiate(ctx, ns, module, name, sys, functionListenerFactory, importedModules); err != nil {
+	if callCtx, err := s.instantiate(ctx, ns, module, name, sys, functionListenerFactory, s.memoryLimit(memoryLimit), importedModules); err != nil {
 		ns.deleteModule(name)
 		return nil, err
 	} else {
@@ -381,13 +465,28 @@ func (s *Store) Instantiate(
 	}
 }
 
+// memoryLimit returns the lower of MemoryLimitPages and the given limit of a module instance, which may be nil.
+func (s *Store) memoryLimit(limit *MemoryLimit) *MemoryLimit {
+	if limit == nil {
+		if s.MemoryLimitPages >= Memory64LimitPages {
+			return nil // no need to limit
+		}
+		return &MemoryLimit{Pages: s.MemoryLimitPages}
+	}
+	if s.MemoryLimitPages < limit.Pages {
+		return &MemoryLimit{Pages: s.MemoryLimitPages, OnExceeded: limit.OnExceeded}
+	}
+	return limit
+}
+
 func (s *Store) instantiate(
 	ctx context.Context,
 	ns *Namespace,
 	module *Module,
 	name string,
-	sys *sys.Context,
+	sys *internalsys.Context,
 	functionListenerFactory experimentalapi.FunctionListenerFactory,
+	memoryLimit *MemoryLimit,
 	modules map[string]*ModuleInstance,
 ) (*CallContext, error) {
 	typeIDs, err := s.getFunctionTypeIDs(module.TypeSection)
@@ -395,7 +494,7 @@ func (s *Store) instantiate(
 		return nil, err
 	}
 
-	importedFunctions, importedGlobals, importedTables, importedMemory, err := resolveImports(module, modules)
+	importedFunctions, importedGlobals, importedTables, importedMemories, importedTags, err := resolveImports(module, modules)
 	if err != nil {
 		return nil, err
 	}
@@ -406,7 +505,11 @@ func (s *Store) instantiate(
 	if err != nil {
 		return nil, err
 	}
-	globals, memory := module.buildGlobals(importedGlobals), module.buildMemory()
+	globals := module.buildGlobals(importedGlobals)
+	memories, err := module.buildMemories(memoryLimit)
+	if err != nil {
+		return nil, err
+	}
 
 	// If there are no module-defined functions, assume this is a host module.
 	var functions []*FunctionInstance
@@ -420,8 +523,9 @@ func (s *Store) instantiate(
 	}
 
 	// Now we have all instances from imports and local ones, so ready to create a new ModuleInstance.
-	m := &ModuleInstance{Name: name}
-	m.addSections(module, importedFunctions, functions, importedGlobals, globals, tables, importedMemory, memory, module.TypeSection, typeIDs)
+	m := &ModuleInstance{Name: name, Source: module, s: s}
+	m.addSections(module, importedFunctions, functions, importedGlobals, globals, tables, importedMemories, memories,
+		importedTags, module.buildTags(name), module.TypeSection, typeIDs)
 
 	// As of reference types proposal, data segment validation must happen after instantiation,
 	// and the side effect must persist even if there's out of bounds error after instantiation.
@@ -469,7 +573,8 @@ func resolveImports(module *Module, modules map[string]*ModuleInstance) (
 	importedFunctions []*FunctionInstance,
 	importedGlobals []*GlobalInstance,
 	importedTables []*TableInstance,
-	importedMemory *MemoryInstance,
+	importedMemories []*MemoryInstance,
+	importedTags []*TagInstance,
 	err error,
 ) {
 	for idx, i := range module.ImportSection {
@@ -529,9 +634,9 @@ func resolveImports(module *Module, modules map[string]*ModuleInstance) (
 			importedTables = append(importedTables, importedTable)
 		case ExternTypeMemory:
 			expected := i.DescMem
-			importedMemory = imported.Memory
+			importedMemory := imported.Memory
 
-			if expected.Min > memoryBytesNumToPages(uint64(len(importedMemory.Buffer))) {
+			if expected.Min > importedMemory.PageSize(nil) {
 				err = errorMinSizeMismatch(i, idx, expected.Min, importedMemory.Min)
 				return
 			}
@@ -540,6 +645,13 @@ func resolveImports(module *Module, modules map[string]*ModuleInstance) (
 				err = errorMaxSizeMismatch(i, idx, expected.Max, importedMemory.Max)
 				return
 			}
+
+			if expected.Shared != importedMemory.Shared {
+				err = errorInvalidImport(i, idx, fmt.Errorf("shared mismatch: %t != %t",
+					expected.Shared, importedMemory.Shared))
+				return
+			}
+			importedMemories = append(importedMemories, importedMemory)
 		case ExternTypeGlobal:
 			expected := i.DescGlobal
 			importedGlobal := imported.Global
@@ -556,6 +668,16 @@ func resolveImports(module *Module, modules map[string]*ModuleInstance) (
 				return
 			}
 			importedGlobals = append(importedGlobals, importedGlobal)
+		case ExternTypeTag:
+			expected := module.TypeSection[i.DescTag]
+			importedTag := imported.Tag
+
+			actual := importedTag.Type
+			if !expected.EqualsSignature(actual.Params, actual.Results) {
+				err = errorInvalidImport(i, idx, fmt.Errorf("tag type mismatch: %s != %s", expected, actual))
+				return
+			}
+			importedTags = append(importedTags, importedTag)
 		}
 	}
 	return
@@ -623,10 +745,59 @@ func executeConstExpression(importedGlobals []*GlobalInstance, expr *ConstantExp
 		v, _, _ = leb128.DecodeInt32(r)
 	case OpcodeVecV128Const:
 		v = [2]uint64{binary.LittleEndian.Uint64(expr.Data[0:8]), binary.LittleEndian.Uint64(expr.Data[8:16])}
+	case OpcodeI32Add, OpcodeI32Sub, OpcodeI32Mul:
+		v = int32(executeExtendedConstExpression(importedGlobals, expr))
+	case OpcodeI64Add, OpcodeI64Sub, OpcodeI64Mul:
+		v = int64(executeExtendedConstExpression(importedGlobals, expr))
 	}
 	return
 }
 
+// executeExtendedConstExpression returns the value an extended constant expression leaves on the stack. i32 values are
+// in the lower 32 bits.
+func executeExtendedConstExpression(importedGlobals []*GlobalInstance, expr *ConstantExpression) uint64 {
+	var stack []uint64
+	r := bytes.NewReader(expr.Data)
+	for r.Len() > 0 {
+		op, _ := r.ReadByte()
+		switch op {
+		case OpcodeI32Const:
+			v, _, _ := leb128.DecodeInt32(r)
+			stack = append(stack, uint64(uint32(v)))
+		case OpcodeI64Const:
+			v, _, _ := leb128.DecodeInt64(r)
+			stack = append(stack, uint64(v))
+		case OpcodeGlobalGet:
+			id, _, _ := leb128.DecodeUint32(r)
+			stack = append(stack, importedGlobals[id].Val)
+		default:
+			stack = executeConstArithmetic(stack, op)
+		}
+	}
+	return executeConstArithmetic(stack, expr.Opcode)[0]
+}
+
+// executeConstArithmetic replaces the two operands of the arithmetic instruction op on the stack with its result.
+func executeConstArithmetic(stack []uint64, op Opcode) []uint64 {
+	x1, x2 := stack[len(stack)-2], stack[len(stack)-1]
+	var v uint64
+	switch op {
+	case OpcodeI32Add:
+		v = uint64(uint32(x1) + uint32(x2))
+	case OpcodeI32Sub:
+		v = uint64(uint32(x1) - uint32(x2))
+	case OpcodeI32Mul:
+		v = uint64(uint32(x1) * uint32(x2))
+	case OpcodeI64Add:
+		v = x1 + x2
+	case OpcodeI64Sub:
+		v = x1 - x2
+	case OpcodeI64Mul:
+		v = x1 * x2
+	}
+	return append(stack[:len(stack)-2], v)
+}
+
 // GlobalInstanceNullFuncRefValue is the temporary value for ValueTypeFuncref globals which are initialized via ref.null.
 const GlobalInstanceNullFuncRefValue int64 = -1
 
@@ -29,23 +29,23 @@ func TestModuleInstance_Memory(t *testing.T) {
 		},
 		{
 			name:  "memory not exported",
-			input: &Module{MemorySection: &Memory{Min: 1, Cap: 1}},
+			input: &Module{MemorySection: []*Memory{{Min: 1, Cap: 1}}},
 		},
 		{
 			name:  "memory not exported, one page",
-			input: &Module{MemorySection: &Memory{Min: 1, Cap: 1}},
+			input: &Module{MemorySection: []*Memory{{Min: 1, Cap: 1}}},
 		},
 		{
 			name: "memory exported, different name",
 			input: &Module{
-				MemorySection: &Memory{Min: 1, Cap: 1},
+				MemorySection: []*Memory{{Min: 1, Cap: 1}},
 				ExportSection: []*Export{{Type: ExternTypeMemory, Name: "momory", Index: 0}},
 			},
 		},
 		{
 			name: "memory exported, but zero length",
 			input: &Module{
-				MemorySection: &Memory{},
+				MemorySection: []*Memory{{}},
 				ExportSection: []*Export{{Type: ExternTypeMemory, Name: "memory", Index: 0}},
 			},
 			expected: true,
@@ -53,7 +53,7 @@ func TestModuleInstance_Memory(t *testing.T) {
 		{
 			name: "memory exported, one page",
 			input: &Module{
-				MemorySection: &Memory{Min: 1, Cap: 1},
+				MemorySection: []*Memory{{Min: 1, Cap: 1}},
 				ExportSection: []*Export{{Type: ExternTypeMemory, Name: "memory", Index: 0}},
 			},
 			expected:    true,
@@ -62,7 +62,7 @@ func TestModuleInstance_Memory(t *testing.T) {
 		{
 			name: "memory exported, two pages",
 			input: &Module{
-				MemorySection: &Memory{Min: 2, Cap: 2},
+				MemorySection: []*Memory{{Min: 2, Cap: 2}},
 				ExportSection: []*Export{{Type: ExternTypeMemory, Name: "memory", Index: 0}},
 			},
 			expected:    true,
@@ -76,7 +76,7 @@ func TestModuleInstance_Memory(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			s, ns := newStore()
 
-			instance, err := s.Instantiate(testCtx, ns, tc.input, "test", nil, nil)
+			instance, err := s.Instantiate(testCtx, ns, tc.input, "test", nil, nil, nil)
 			require.NoError(t, err)
 
 			mem := instance.ExportedMemory("memory")
@@ -95,13 +95,14 @@ func TestStore_Instantiate(t *testing.T) {
 		"",
 		map[string]interface{}{"fn": func(api.Module) {}},
 		map[string]*Memory{},
+		map[string]*Table{},
 		map[string]*Global{},
 		Features20191205,
 	)
 	require.NoError(t, err)
 
 	sysCtx := sys.DefaultContext()
-	mod, err := s.Instantiate(testCtx, ns, m, "", sysCtx, nil)
+	mod, err := s.Instantiate(testCtx, ns, m, "", sysCtx, nil, nil)
 	require.NoError(t, err)
 	defer mod.Close(testCtx)
 
@@ -141,16 +142,16 @@ func TestStore_CloseWithExitCode(t *testing.T) {
 				FunctionSection: []uint32{0},
 				CodeSection:     []*Code{{Body: []byte{OpcodeEnd}}},
 				ExportSection:   []*Export{{Type: ExternTypeFunc, Index: 0, Name: "fn"}},
-			}, importedModuleName, nil, nil)
+			}, importedModuleName, nil, nil, nil)
 			require.NoError(t, err)
 
 			m2, err := s.Instantiate(testCtx, ns, &Module{
 				TypeSection:   []*FunctionType{{}},
 				ImportSection: []*Import{{Type: ExternTypeFunc, Module: importedModuleName, Name: "fn", DescFunc: 0}},
-				MemorySection: &Memory{Min: 1, Cap: 1},
+				MemorySection: []*Memory{{Min: 1, Cap: 1}},
 				GlobalSection: []*Global{{Type: &GlobalType{}, Init: &ConstantExpression{Opcode: OpcodeI32Const, Data: const1}}},
 				TableSection:  []*Table{{Min: 10}},
-			}, importingModuleName, nil, nil)
+			}, importingModuleName, nil, nil, nil)
 			require.NoError(t, err)
 
 			if tc.testClosed {
@@ -178,13 +179,14 @@ func TestStore_hammer(t *testing.T) {
 		importedModuleName,
 		map[string]interface{}{"fn": func(api.Module) {}},
 		map[string]*Memory{},
+		map[string]*Table{},
 		map[string]*Global{},
 		Features20191205,
 	)
 	require.NoError(t, err)
 
 	s, ns := newStore()
-	imported, err := s.Instantiate(testCtx, ns, m, importedModuleName, nil, nil)
+	imported, err := s.Instantiate(testCtx, ns, m, importedModuleName, nil, nil, nil)
 	require.NoError(t, err)
 
 	_, ok := ns.modules[imported.Name()]
@@ -194,7 +196,7 @@ func TestStore_hammer(t *testing.T) {
 		TypeSection:     []*FunctionType{{}},
 		FunctionSection: []uint32{0},
 		CodeSection:     []*Code{{Body: []byte{OpcodeEnd}}},
-		MemorySection:   &Memory{Min: 1, Cap: 1},
+		MemorySection:   []*Memory{{Min: 1, Cap: 1}},
 		GlobalSection:   []*Global{{Type: &GlobalType{}, Init: &ConstantExpression{Opcode: OpcodeI32Const, Data: const1}}},
 		TableSection:    []*Table{{Min: 10}},
 		ImportSection: []*Import{
@@ -211,7 +213,7 @@ func TestStore_hammer(t *testing.T) {
 		N = 100
 	}
 	hammer.NewHammer(t, P, N).Run(func(name string) {
-		mod, instantiateErr := s.Instantiate(testCtx, ns, importingModule, name, sys.DefaultContext(), nil)
+		mod, instantiateErr := s.Instantiate(testCtx, ns, importingModule, name, sys.DefaultContext(), nil, nil)
 		require.NoError(t, instantiateErr)
 		require.NoError(t, mod.Close(testCtx))
 	}, nil)
@@ -234,6 +236,7 @@ func TestStore_Instantiate_Errors(t *testing.T) {
 		importedModuleName,
 		map[string]interface{}{"fn": func(api.Module) {}},
 		map[string]*Memory{},
+		map[string]*Table{},
 		map[string]*Global{},
 		Features20191205,
 	)
@@ -241,17 +244,17 @@ func TestStore_Instantiate_Errors(t *testing.T) {
 
 	t.Run("Fails if module name already in use", func(t *testing.T) {
 		s, ns := newStore()
-		_, err = s.Instantiate(testCtx, ns, m, importedModuleName, nil, nil)
+		_, err = s.Instantiate(testCtx, ns, m, importedModuleName, nil, nil, nil)
 		require.NoError(t, err)
 
 		// Trying to register it again should fail
-		_, err = s.Instantiate(testCtx, ns, m, importedModuleName, nil, nil)
+		_, err = s.Instantiate(testCtx, ns, m, importedModuleName, nil, nil, nil)
 		require.EqualError(t, err, "module[imported] has already been instantiated")
 	})
 
 	t.Run("fail resolve import", func(t *testing.T) {
 		s, ns := newStore()
-		_, err = s.Instantiate(testCtx, ns, m, importedModuleName, nil, nil)
+		_, err = s.Instantiate(testCtx, ns, m, importedModuleName, nil, nil, nil)
 		require.NoError(t, err)
 
 		hm := ns.modules[importedModuleName]
@@ -265,14 +268,14 @@ func TestStore_Instantiate_Errors(t *testing.T) {
 				// But the second one tries to import uninitialized-module ->
 				{Type: ExternTypeFunc, Module: "non-exist", Name: "fn", DescFunc: 0},
 			},
-		}, importingModuleName, nil, nil)
+		}, importingModuleName, nil, nil, nil)
 		require.EqualError(t, err, "module[non-exist] not instantiated")
 	})
 
 	t.Run("compilation failed", func(t *testing.T) {
 		s, ns := newStore()
 
-		_, err = s.Instantiate(testCtx, ns, m, importedModuleName, nil, nil)
+		_, err = s.Instantiate(testCtx, ns, m, importedModuleName, nil, nil, nil)
 		require.NoError(t, err)
 
 		hm := ns.modules[importedModuleName]
@@ -291,7 +294,7 @@ func TestStore_Instantiate_Errors(t *testing.T) {
 			ImportSection: []*Import{
 				{Type: ExternTypeFunc, Module: importedModuleName, Name: "fn", DescFunc: 0},
 			},
-		}, importingModuleName, nil, nil)
+		}, importingModuleName, nil, nil, nil)
 		require.EqualError(t, err, "compilation failed: some compilation error")
 	})
 
@@ -300,7 +303,7 @@ func TestStore_Instantiate_Errors(t *testing.T) {
 		engine := s.Engine.(*mockEngine)
 		engine.callFailIndex = 1
 
-		_, err = s.Instantiate(testCtx, ns, m, importedModuleName, nil, nil)
+		_, err = s.Instantiate(testCtx, ns, m, importedModuleName, nil, nil, nil)
 		require.NoError(t, err)
 
 		hm := ns.modules[importedModuleName]
@@ -315,7 +318,7 @@ func TestStore_Instantiate_Errors(t *testing.T) {
 			ImportSection: []*Import{
 				{Type: ExternTypeFunc, Module: importedModuleName, Name: "fn", DescFunc: 0},
 			},
-		}, importingModuleName, nil, nil)
+		}, importingModuleName, nil, nil, nil)
 		require.EqualError(t, err, "start function[1] failed: call failed")
 	})
 }
@@ -325,6 +328,7 @@ func TestCallContext_ExportedFunction(t *testing.T) {
 		"host",
 		map[string]interface{}{"host_fn": func(api.Module) {}},
 		map[string]*Memory{},
+		map[string]*Table{},
 		map[string]*Global{},
 		Features20191205,
 	)
@@ -333,7 +337,7 @@ func TestCallContext_ExportedFunction(t *testing.T) {
 	s, ns := newStore()
 
 	// Add the host module
-	imported, err := s.Instantiate(testCtx, ns, host, host.NameSection.ModuleName, nil, nil)
+	imported, err := s.Instantiate(testCtx, ns, host, host.NameSection.ModuleName, nil, nil, nil)
 	require.NoError(t, err)
 	defer imported.Close(testCtx)
 
@@ -341,9 +345,9 @@ func TestCallContext_ExportedFunction(t *testing.T) {
 		importing, err := s.Instantiate(testCtx, ns, &Module{
 			TypeSection:   []*FunctionType{{}},
 			ImportSection: []*Import{{Type: ExternTypeFunc, Module: "host", Name: "host_fn", DescFunc: 0}},
-			MemorySection: &Memory{Min: 1, Cap: 1},
+			MemorySection: []*Memory{{Min: 1, Cap: 1}},
 			ExportSection: []*Export{{Type: ExternTypeFunc, Name: "host.fn", Index: 0}},
-		}, "test", nil, nil)
+		}, "test", nil, nil, nil)
 		require.NoError(t, err)
 		defer importing.Close(testCtx)
 
@@ -378,6 +382,9 @@ func (e *mockEngine) CompiledModuleCount() uint32 { return 0 }
 // DeleteCompiledModule implements the same method as documented on wasm.Engine.
 func (e *mockEngine) DeleteCompiledModule(*Module) {}
 
+// Coverage implements the same method as documented on wasm.Engine.
+func (e *mockEngine) Coverage(*Module) []*FunctionCoverage { return nil }
+
 // NewModuleEngine implements the same method as documented on wasm.Engine.
 func (e *mockEngine) NewModuleEngine(_ string, _ *Module, _, _ []*FunctionInstance, _ []*TableInstance, _ []TableInitEntry) (ModuleEngine, error) {
 	if e.shouldCompileFail {
@@ -387,7 +394,12 @@ func (e *mockEngine) NewModuleEngine(_ string, _ *Module, _, _ []*FunctionInstan
 }
 
 // CreateFuncElementInstance implements the same method as documented on wasm.ModuleEngine.
-func (me *mockModuleEngine) CreateFuncElementInstance([]*Index) *ElementInstance {
+func (me *mockModuleEngine) CreateFuncElementInstance(indexes []*Index) *ElementInstance {
+	return &ElementInstance{References: make([]Reference, len(indexes)), Type: RefTypeFuncref}
+}
+
+// LookupFunction implements the same method as documented on wasm.ModuleEngine.
+func (e *mockModuleEngine) LookupFunction(Reference) *FunctionInstance {
 	return nil
 }
 
@@ -412,6 +424,67 @@ func (e *mockModuleEngine) Call(ctx context.Context, callCtx *CallContext, f *Fu
 func (e *mockModuleEngine) Close(_ context.Context) {
 }
 
+func TestStore_memoryLimit(t *testing.T) {
+	tests := []struct {
+		name            string
+		storeLimitPages uint32
+		limit, expected *MemoryLimit
+	}{
+		{
+			name:            "unlimited",
+			storeLimitPages: Memory64LimitPages,
+		},
+		{
+			name:            "store limit of 32-bit memories",
+			storeLimitPages: MemoryLimitPages,
+			expected:        &MemoryLimit{Pages: MemoryLimitPages},
+		},
+		{
+			name:            "store limit",
+			storeLimitPages: 10,
+			expected:        &MemoryLimit{Pages: 10},
+		},
+		{
+			name:            "module limit",
+			storeLimitPages: MemoryLimitPages,
+			limit:           &MemoryLimit{Pages: 10},
+			expected:        &MemoryLimit{Pages: 10},
+		},
+		{
+			name:            "store limit lower",
+			storeLimitPages: 5,
+			limit:           &MemoryLimit{Pages: 10},
+			expected:        &MemoryLimit{Pages: 5},
+		},
+		{
+			name:            "module limit lower",
+			storeLimitPages: 10,
+			limit:           &MemoryLimit{Pages: 5},
+			expected:        &MemoryLimit{Pages: 5},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			s, _ := newStore()
+			s.MemoryLimitPages = tc.storeLimitPages
+			require.Equal(t, tc.expected, s.memoryLimit(tc.limit))
+		})
+	}
+}
+
+func TestStore_Instantiate_MemoryLimit(t *testing.T) {
+	s, ns := newStore()
+	s.MemoryLimitPages = 1
+
+	_, err := s.Instantiate(testCtx, ns, &Module{MemorySection: []*Memory{{Min: 2, Cap: 2, Max: 10}}}, "test", nil, nil, nil)
+	require.EqualError(t, err, "memory min 2 pages (128 Ki) over limit of 1 pages (64 Ki)")
+
+	// The module name isn't taken on error.
+	require.Nil(t, ns.Module("test"))
+}
+
 func TestStore_getFunctionTypeID(t *testing.T) {
 	t.Run("too many functions", func(t *testing.T) {
 		s, _ := newStore()
@@ -592,6 +665,55 @@ func TestExecuteConstExpression(t *testing.T) {
 		require.Equal(t, uint64(0x1), vector[0])
 		require.Equal(t, uint64(0x2), vector[1])
 	})
+
+	t.Run("extended", func(t *testing.T) {
+		// The i32 global is sign-extended, as when it is initialized by a negative i32.const.
+		globals := []*GlobalInstance{
+			{Val: uint64(math.MaxUint64 - 1), Type: &GlobalType{ValType: ValueTypeI32}},
+			{Val: 10, Type: &GlobalType{ValType: ValueTypeI64}},
+		}
+		tests := []struct {
+			name string
+			expr *ConstantExpression
+			exp  interface{}
+		}{
+			{
+				name: "i32.add",
+				expr: &ConstantExpression{Opcode: OpcodeI32Add, Data: []byte{OpcodeGlobalGet, 0, OpcodeI32Const, 3}},
+				exp:  int32(1),
+			},
+			{
+				name: "i32.sub wraps",
+				expr: &ConstantExpression{Opcode: OpcodeI32Sub, Data: []byte{OpcodeI32Const, 0, OpcodeI32Const, 1}},
+				exp:  int32(-1),
+			},
+			{
+				name: "i32.mul",
+				expr: &ConstantExpression{Opcode: OpcodeI32Mul, Data: []byte{OpcodeGlobalGet, 0, OpcodeI32Const, 3}},
+				exp:  int32(-6),
+			},
+			{
+				name: "i64.sub of i64.mul",
+				expr: &ConstantExpression{
+					Opcode: OpcodeI64Sub,
+					Data:   []byte{OpcodeI64Const, 2, OpcodeGlobalGet, 1, OpcodeI64Mul, OpcodeI64Const, 1},
+				},
+				exp: int64(19),
+			},
+			{
+				name: "i64.add",
+				expr: &ConstantExpression{Opcode: OpcodeI64Add, Data: []byte{OpcodeI64Const, 0x7f, OpcodeGlobalGet, 1}},
+				exp:  int64(9),
+			},
+		}
+
+		for _, tt := range tests {
+			tc := tt
+			t.Run(tc.name, func(t *testing.T) {
+				require.Equal(t, tc.exp, executeConstExpression(globals, tc.expr))
+			})
+		}
+	})
 }
 
 func Test_resolveImports(t *testing.T) {
@@ -600,14 +722,14 @@ func Test_resolveImports(t *testing.T) {
 
 	t.Run("module not instantiated", func(t *testing.T) {
 		modules := map[string]*ModuleInstance{}
-		_, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: "unknown", Name: "unknown"}}}, modules)
+		_, _, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: "unknown", Name: "unknown"}}}, modules)
 		require.EqualError(t, err, "module[unknown] not instantiated")
 	})
 	t.Run("export instance not found", func(t *testing.T) {
 		modules := map[string]*ModuleInstance{
 			moduleName: {Exports: map[string]*ExportInstance{}, Name: moduleName},
 		}
-		_, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: "unknown"}}}, modules)
+		_, _, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: "unknown"}}}, modules)
 		require.EqualError(t, err, "\"unknown\" is not exported in module \"test\"")
 	})
 	t.Run("func", func(t *testing.T) {
@@ -630,7 +752,7 @@ func Test_resolveImports(t *testing.T) {
 					{Module: moduleName, Name: "", Type: ExternTypeFunc, DescFunc: 1},
 				},
 			}
-			functions, _, _, _, err := resolveImports(m, modules)
+			functions, _, _, _, _, err := resolveImports(m, modules)
 			require.NoError(t, err)
 			require.True(t, functionsContain(functions, f), "expected to find %v in %v", f, functions)
 			require.True(t, functionsContain(functions, g), "expected to find %v in %v", g, functions)
@@ -639,7 +761,7 @@ func Test_resolveImports(t *testing.T) {
 			modules := map[string]*ModuleInstance{
 				moduleName: {Exports: map[string]*ExportInstance{name: {}}, Name: moduleName},
 			}
-			_, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeFunc, DescFunc: 100}}}, modules)
+			_, _, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeFunc, DescFunc: 100}}}, modules)
 			require.EqualError(t, err, "import[0] func[test.target]: function type out of range")
 		})
 		t.Run("signature mismatch", func(t *testing.T) {
@@ -652,7 +774,7 @@ func Test_resolveImports(t *testing.T) {
 				TypeSection:   []*FunctionType{{Results: []ValueType{ValueTypeF32}}},
 				ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeFunc, DescFunc: 0}},
 			}
-			_, _, _, _, err := resolveImports(m, modules)
+			_, _, _, _, _, err := resolveImports(m, modules)
 			require.EqualError(t, err, "import[0] func[test.target]: signature mismatch: v_f32 != v_v")
 		})
 	})
@@ -662,7 +784,7 @@ func Test_resolveImports(t *testing.T) {
 			modules := map[string]*ModuleInstance{
 				moduleName: {Exports: map[string]*ExportInstance{name: {Type: ExternTypeGlobal, Global: g}}, Name: moduleName},
 			}
-			_, globals, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeGlobal, DescGlobal: g.Type}}}, modules)
+			_, globals, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeGlobal, DescGlobal: g.Type}}}, modules)
 			require.NoError(t, err)
 			require.True(t, globalsContain(globals, g), "expected to find %v in %v", g, globals)
 		})
@@ -673,7 +795,7 @@ func Test_resolveImports(t *testing.T) {
 					Global: &GlobalInstance{Type: &GlobalType{Mutable: false}},
 				}}, Name: moduleName},
 			}
-			_, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeGlobal, DescGlobal: &GlobalType{Mutable: true}}}}, modules)
+			_, _, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeGlobal, DescGlobal: &GlobalType{Mutable: true}}}}, modules)
 			require.EqualError(t, err, "import[0] global[test.target]: mutability mismatch: true != false")
 		})
 		t.Run("type mismatch", func(t *testing.T) {
@@ -683,7 +805,7 @@ func Test_resolveImports(t *testing.T) {
 					Global: &GlobalInstance{Type: &GlobalType{ValType: ValueTypeI32}},
 				}}, Name: moduleName},
 			}
-			_, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeGlobal, DescGlobal: &GlobalType{ValType: ValueTypeF64}}}}, modules)
+			_, _, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeGlobal, DescGlobal: &GlobalType{ValType: ValueTypeF64}}}}, modules)
 			require.EqualError(t, err, "import[0] global[test.target]: value type mismatch: f64 != i32")
 		})
 	})
@@ -697,9 +819,9 @@ func Test_resolveImports(t *testing.T) {
 					Memory: memoryInst,
 				}}, Name: moduleName},
 			}
-			_, _, _, memory, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeMemory, DescMem: &Memory{Max: max}}}}, modules)
+			_, _, _, memories, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeMemory, DescMem: &Memory{Max: max}}}}, modules)
 			require.NoError(t, err)
-			require.Equal(t, memory, memoryInst)
+			require.Equal(t, []*MemoryInstance{memoryInst}, memories)
 		})
 		t.Run("minimum size mismatch", func(t *testing.T) {
 			importMemoryType := &Memory{Min: 2, Cap: 2}
@@ -709,7 +831,7 @@ func Test_resolveImports(t *testing.T) {
 					Memory: &MemoryInstance{Min: importMemoryType.Min - 1, Cap: 2},
 				}}, Name: moduleName},
 			}
-			_, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeMemory, DescMem: importMemoryType}}}, modules)
+			_, _, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeMemory, DescMem: importMemoryType}}}, modules)
 			require.EqualError(t, err, "import[0] memory[test.target]: minimum size mismatch: 2 > 1")
 		})
 		t.Run("maximum size mismatch", func(t *testing.T) {
@@ -721,9 +843,21 @@ func Test_resolveImports(t *testing.T) {
 					Memory: &MemoryInstance{Max: MemoryLimitPages},
 				}}, Name: moduleName},
 			}
-			_, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeMemory, DescMem: importMemoryType}}}, modules)
+			_, _, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeMemory, DescMem: importMemoryType}}}, modules)
 			require.EqualError(t, err, "import[0] memory[test.target]: maximum size mismatch: 10 < 65536")
 		})
+		t.Run("shared mismatch", func(t *testing.T) {
+			max := uint32(10)
+			importMemoryType := &Memory{Max: max, Shared: true}
+			modules := map[string]*ModuleInstance{
+				moduleName: {Exports: map[string]*ExportInstance{name: {
+					Type:   ExternTypeMemory,
+					Memory: &MemoryInstance{Max: max},
+				}}, Name: moduleName},
+			}
+			_, _, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeMemory, DescMem: importMemoryType}}}, modules)
+			require.EqualError(t, err, "import[0] memory[test.target]: shared mismatch: true != false")
+		})
 	})
 }
 
@@ -7,6 +7,7 @@ import (
 	"math"
 	"sync"
 
+	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/leb128"
 )
 
@@ -123,9 +124,12 @@ type Reference = uintptr
 //
 // Note: The global imported at globalIdx may have an offset value that is out-of-bounds for the corresponding table.
 type validatedActiveElementSegment struct {
-	// opcode is OpcodeGlobalGet or OpcodeI32Const
+	// opcode is OpcodeGlobalGet, OpcodeI32Const or the arithmetic opcode of expr.
 	opcode Opcode
 
+	// expr is the offset if it is an extended constant expression (FeatureExtendedConst), evaluated on instantiation.
+	expr *ConstantExpression
+
 	// arg is the only argument to opcode, which when applied results in the offset to add to init indices.
 	//  * OpcodeGlobalGet: position in the global index namespace of an imported Global ValueTypeI32 holding the offset.
 	//  * OpcodeI32Const: a constant ValueTypeI32 offset.
@@ -220,6 +224,17 @@ func (m *Module) validateTable(enabledFeatures Features, tables []*Table, maximu
 				}
 
 				ret = append(ret, &validatedActiveElementSegment{opcode: oc, arg: offset, init: elem.Init, tableIndex: elem.TableIndex})
+			} else if elem.OffsetExpr.IsExtended() {
+				// The offset may depend on imported globals, so it can only be known on instantiation.
+				if err := validateConstExpression(m.importedGlobalTypes(), 0, elem.OffsetExpr, ValueTypeI32); err != nil {
+					return nil, fmt.Errorf("%s[%d] has an invalid const expression: %w", SectionIDName(SectionIDElement), idx, err)
+				}
+
+				if initCount == 0 {
+					continue // Per https://github.com/WebAssembly/spec/issues/1427 init can be no-op, but validate anyway!
+				}
+
+				ret = append(ret, &validatedActiveElementSegment{opcode: oc, expr: elem.OffsetExpr, init: elem.Init, tableIndex: elem.TableIndex})
 			} else {
 				return nil, fmt.Errorf("%s[%d] has an invalid const expression: %s", SectionIDName(SectionIDElement), idx, InstructionName(oc))
 			}
@@ -259,6 +274,8 @@ func (m *Module) buildTables(importedTables []*TableInstance, importedGlobals []
 		if elem.opcode == OpcodeGlobalGet {
 			global := importedGlobals[elem.arg]
 			offset = uint32(global.Val)
+		} else if elem.expr != nil {
+			offset = uint32(executeConstExpression(importedGlobals, elem.expr).(int32))
 		} else {
 			offset = elem.arg // constant
 		}
@@ -291,6 +308,16 @@ func checkSegmentBounds(min uint32, requireMin uint64, idx Index) error { // uin
 	return nil
 }
 
+// importedGlobalTypes returns the types of the imported globals, which are the only ones constant expressions can read.
+func (m *Module) importedGlobalTypes() (globals []*GlobalType) {
+	for _, im := range m.ImportSection {
+		if im.Type == ExternTypeGlobal {
+			globals = append(globals, im.DescGlobal)
+		}
+	}
+	return
+}
+
 func (m *Module) verifyImportGlobalI32(sectionID SectionID, sectionIdx Index, idx uint32) error {
 	ig := uint32(math.MaxUint32) // +1 == 0
 	for i, im := range m.ImportSection {
@@ -336,3 +363,110 @@ func (t *TableInstance) Grow(_ context.Context, delta uint32, initialRef Referen
 	}
 	return
 }
+
+// compile-time check to ensure exportedTable implements api.Table
+var _ api.Table = &exportedTable{}
+
+// exportedTable implements api.Table, converting function references with the ModuleEngine of the exporting module.
+type exportedTable struct {
+	table  *TableInstance
+	module *ModuleInstance
+}
+
+// Type implements the same method as documented on api.Table.
+func (t *exportedTable) Type() api.ValueType {
+	return t.table.Type
+}
+
+// Size implements the same method as documented on api.Table.
+func (t *exportedTable) Size(_ context.Context) uint32 {
+	t.table.mux.RLock()
+	defer t.table.mux.RUnlock()
+	return uint32(len(t.table.References))
+}
+
+// Grow implements the same method as documented on api.Table.
+func (t *exportedTable) Grow(ctx context.Context, delta uint32) (previousSize uint32, ok bool) {
+	if previousSize = t.table.Grow(ctx, delta, 0); previousSize == 0xffffffff {
+		return 0, false
+	}
+	return previousSize, true
+}
+
+// Get implements the same method as documented on api.Table.
+func (t *exportedTable) Get(_ context.Context, offset uint32) (uint64, bool) {
+	if t.table.Type != RefTypeExternref {
+		return 0, false
+	}
+	ref, ok := t.get(offset)
+	return uint64(ref), ok
+}
+
+// Set implements the same method as documented on api.Table.
+func (t *exportedTable) Set(_ context.Context, offset uint32, externref uint64) bool {
+	if t.table.Type != RefTypeExternref {
+		return false
+	}
+	return t.set(offset, Reference(externref))
+}
+
+// GetFunction implements the same method as documented on api.Table.
+func (t *exportedTable) GetFunction(_ context.Context, offset uint32) (api.Function, bool) {
+	if t.table.Type != RefTypeFuncref {
+		return nil, false
+	}
+	ref, ok := t.get(offset)
+	if !ok || ref == 0 {
+		return nil, ok
+	}
+	return t.module.Engine.LookupFunction(ref), true
+}
+
+// SetFunction implements the same method as documented on api.Table.
+func (t *exportedTable) SetFunction(_ context.Context, offset uint32, fn api.Function) bool {
+	if t.table.Type != RefTypeFuncref {
+		return false
+	}
+
+	var ref Reference // null unless fn is non-nil
+	if fn != nil {
+		var f *FunctionInstance
+		switch fn := fn.(type) {
+		case *FunctionInstance:
+			f = fn
+		case *importedFn:
+			f = fn.importedFn
+		default: // not from this runtime
+			return false
+		}
+		// References are engine-specific pointers, and their type IDs are only unique in a store, so a function of
+		// another store, possibly with another engine, could be called with the wrong type or code.
+		if f.Module == nil || f.Module.s != t.module.s || f.Module.CallCtx == nil || f.Module.CallCtx.FailIfClosed() != nil {
+			return false
+		}
+		idx := f.Idx
+		ref = f.Module.Engine.CreateFuncElementInstance([]*Index{&idx}).References[0]
+	}
+	return t.set(offset, ref)
+}
+
+// get returns the reference at the offset or false if it is out of range.
+func (t *exportedTable) get(offset uint32) (Reference, bool) {
+	t.table.mux.RLock()
+	defer t.table.mux.RUnlock()
+	if offset >= uint32(len(t.table.References)) {
+		return 0, false
+	}
+	return t.table.References[offset], true
+}
+
+// set writes the reference at the offset or returns false if it is out of range.
+func (t *exportedTable) set(offset uint32, ref Reference) bool {
+	t.table.mux.RLock() // read-lock as this doesn't replace the slice, similar to table.set in Wasm.
+	defer t.table.mux.RUnlock()
+	if offset >= uint32(len(t.table.References)) {
+		return false
+	}
+	t.table.References[offset] = ref
+	return true
+}
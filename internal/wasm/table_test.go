@@ -4,6 +4,7 @@ import (
 	"math"
 	"testing"
 
+	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/leb128"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 )
@@ -25,7 +26,7 @@ func Test_resolveImports_table(t *testing.T) {
 				Table: tableInst,
 			}}, Name: moduleName},
 		}
-		_, _, tables, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeTable, DescTable: &Table{Max: &max}}}}, modules)
+		_, _, tables, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeTable, DescTable: &Table{Max: &max}}}}, modules)
 		require.NoError(t, err)
 		require.Equal(t, 1, len(tables))
 		require.Equal(t, tables[0], tableInst)
@@ -38,7 +39,7 @@ func Test_resolveImports_table(t *testing.T) {
 				Table: &TableInstance{Min: importTableType.Min - 1},
 			}}, Name: moduleName},
 		}
-		_, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeTable, DescTable: importTableType}}}, modules)
+		_, _, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeTable, DescTable: importTableType}}}, modules)
 		require.EqualError(t, err, "import[0] table[test.target]: minimum size mismatch: 2 > 1")
 	})
 	t.Run("maximum size mismatch", func(t *testing.T) {
@@ -50,7 +51,7 @@ func Test_resolveImports_table(t *testing.T) {
 				Table: &TableInstance{Min: importTableType.Min - 1},
 			}}, Name: moduleName},
 		}
-		_, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeTable, DescTable: importTableType}}}, modules)
+		_, _, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeTable, DescTable: importTableType}}}, modules)
 		require.EqualError(t, err, "import[0] table[test.target]: maximum size mismatch: 10, but actual has no max")
 	})
 }
@@ -216,6 +217,28 @@ func TestModule_validateTable(t *testing.T) {
 				{opcode: OpcodeGlobalGet, arg: 0, init: []*Index{uint32Ptr(0)}},
 			},
 		},
+		{
+			name: "extended const derived element offset and one index",
+			input: &Module{
+				TypeSection: []*FunctionType{{}},
+				ImportSection: []*Import{
+					{Type: ExternTypeGlobal, DescGlobal: &GlobalType{ValType: ValueTypeI32}},
+				},
+				TableSection:    []*Table{{Min: 1}},
+				FunctionSection: []Index{0},
+				CodeSection:     []*Code{codeEnd},
+				ElementSection: []*ElementSegment{
+					{
+						OffsetExpr: &ConstantExpression{Opcode: OpcodeI32Add, Data: []byte{OpcodeGlobalGet, 0x0, OpcodeI32Const, 0x1}},
+						Init:       []*Index{uint32Ptr(0)},
+						Type:       RefTypeFuncref,
+					},
+				},
+			},
+			expected: []*validatedActiveElementSegment{
+				{opcode: OpcodeI32Add, expr: &ConstantExpression{Opcode: OpcodeI32Add, Data: []byte{OpcodeGlobalGet, 0x0, OpcodeI32Const, 0x1}}, init: []*Index{uint32Ptr(0)}},
+			},
+		},
 		{
 			name: "imported global derived element offset and one index - imported table",
 			input: &Module{
@@ -529,6 +552,24 @@ func TestModule_validateTable_Errors(t *testing.T) {
 			},
 			expectedErr: "element[0] (global.get 0): import[0].global.ValType != i32",
 		},
+		{
+			name: "extended const derived element offset - wrong ValType",
+			input: &Module{
+				TypeSection: []*FunctionType{{}},
+				ImportSection: []*Import{
+					{Type: ExternTypeGlobal, DescGlobal: &GlobalType{ValType: ValueTypeI64}},
+				},
+				TableSection:    []*Table{{}},
+				FunctionSection: []Index{0},
+				CodeSection:     []*Code{codeEnd},
+				ElementSection: []*ElementSegment{
+					{OffsetExpr: &ConstantExpression{Opcode: OpcodeI32Add, Data: []byte{OpcodeGlobalGet, 0x0, OpcodeI32Const, 0x1}}, Init: []*Index{uint32Ptr(0)},
+						Type: RefTypeFuncref,
+					},
+				},
+			},
+			expectedErr: "element[0] has an invalid const expression: cannot use i64 and i32 as the operands of i32.add",
+		},
 		{
 			name: "imported global derived element offset - decode error",
 			input: &Module{
@@ -737,6 +778,24 @@ func TestModule_buildTables(t *testing.T) {
 			expectedTables:  []*TableInstance{{References: make([]Reference, 2), Min: 2}},
 			expectedInit:    []TableInitEntry{{TableIndex: 0, Offset: 1, FunctionIndexes: []*Index{uint32Ptr(0)}}},
 		},
+		{
+			name: "extended const derived element offset and one index",
+			module: &Module{
+				TypeSection: []*FunctionType{{}},
+				ImportSection: []*Import{
+					{Type: ExternTypeGlobal, DescGlobal: &GlobalType{ValType: ValueTypeI32}},
+				},
+				TableSection:    []*Table{{Min: 3}},
+				FunctionSection: []Index{0},
+				CodeSection:     []*Code{codeEnd},
+				validatedActiveElementSegments: []*validatedActiveElementSegment{
+					{opcode: OpcodeI32Add, expr: &ConstantExpression{Opcode: OpcodeI32Add, Data: []byte{OpcodeGlobalGet, 0x0, OpcodeI32Const, 0x1}}, init: []*Index{uint32Ptr(0)}},
+				},
+			},
+			importedGlobals: []*GlobalInstance{{Type: &GlobalType{ValType: ValueTypeI32}, Val: 1}},
+			expectedTables:  []*TableInstance{{References: make([]Reference, 3), Min: 3}},
+			expectedInit:    []TableInitEntry{{TableIndex: 0, Offset: 2, FunctionIndexes: []*Index{uint32Ptr(0)}}},
+		},
 		{
 			name: "imported global derived element offset and one index - imported table",
 			module: &Module{
@@ -1005,3 +1064,60 @@ func TestTableInstance_Grow(t *testing.T) {
 		})
 	}
 }
+
+func TestExportedTable_Externref(t *testing.T) {
+	table := &exportedTable{table: &TableInstance{References: make([]Reference, 2), Max: uint32Ptr(3), Type: RefTypeExternref}}
+
+	require.Equal(t, api.ValueTypeExternref, table.Type())
+	require.Equal(t, uint32(2), table.Size(testCtx))
+
+	require.True(t, table.Set(testCtx, 1, 0xdeadbeef))
+	ref, ok := table.Get(testCtx, 1)
+	require.True(t, ok)
+	require.Equal(t, uint64(0xdeadbeef), ref)
+
+	// Out of range
+	require.False(t, table.Set(testCtx, 2, 1))
+	_, ok = table.Get(testCtx, 2)
+	require.False(t, ok)
+
+	// Grow initializes to null
+	previousSize, ok := table.Grow(testCtx, 1)
+	require.True(t, ok)
+	require.Equal(t, uint32(2), previousSize)
+	ref, ok = table.Get(testCtx, 2)
+	require.True(t, ok)
+	require.Zero(t, ref)
+
+	// Can't grow beyond max
+	_, ok = table.Grow(testCtx, 1)
+	require.False(t, ok)
+	require.Equal(t, uint32(3), table.Size(testCtx))
+
+	// Functions are only valid in a funcref table
+	_, ok = table.GetFunction(testCtx, 0)
+	require.False(t, ok)
+	require.False(t, table.SetFunction(testCtx, 0, nil))
+}
+
+func TestExportedTable_Funcref(t *testing.T) {
+	table := &exportedTable{table: &TableInstance{References: make([]Reference, 1), Type: RefTypeFuncref}}
+
+	require.Equal(t, api.ValueTypeFuncref, table.Type())
+
+	// Null references are nil functions
+	fn, ok := table.GetFunction(testCtx, 0)
+	require.True(t, ok)
+	require.Nil(t, fn)
+	require.True(t, table.SetFunction(testCtx, 0, nil))
+
+	// Out of range
+	_, ok = table.GetFunction(testCtx, 1)
+	require.False(t, ok)
+	require.False(t, table.SetFunction(testCtx, 1, nil))
+
+	// Externrefs are only valid in an externref table
+	_, ok = table.Get(testCtx, 0)
+	require.False(t, ok)
+	require.False(t, table.Set(testCtx, 0, 1))
+}
@@ -0,0 +1,88 @@
+package wasm
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// TagInstance is a tag of the exception handling proposal (FeatureExceptionHandling) in a Store, which classifies
+// exceptions. Tags are compared by identity, so tags of the same type defined by different module instances are
+// distinct.
+//
+// See https://github.com/WebAssembly/exception-handling/blob/main/proposals/exception-handling/Exceptions.md#tags
+type TagInstance struct {
+	// Type is the type whose params are the values of exceptions with this tag. Its results are empty.
+	Type *FunctionType
+
+	// name is the name in errors. Ex. "env.error" when exported as "error", or "env.tag[0]"
+	name string
+}
+
+// String implements the same method as documented on api.Tag.
+func (t *TagInstance) String() string {
+	return t.name
+}
+
+// ParamTypes implements the same method as documented on api.Tag.
+func (t *TagInstance) ParamTypes() []ValueType {
+	return t.Type.Params
+}
+
+// Matches returns true if the exception has this tag.
+//
+// This panics if the values of the exception don't match the params of this tag, as host functions can throw
+// exceptions with any values.
+func (t *TagInstance) Matches(exc *sys.Exception) bool {
+	if exc.Tag() != t {
+		return false
+	}
+	if n := len(exc.Values()); n != t.Type.ParamNumInUint64 {
+		panic(fmt.Errorf("invalid exception with tag %s: %d values != %d params", t, n, t.Type.ParamNumInUint64))
+	}
+	return true
+}
+
+// buildTags returns the tags defined in the module, named after their first export, if any.
+func (m *Module) buildTags(moduleName string) []*TagInstance {
+	if len(m.TagSection) == 0 {
+		return nil
+	}
+	importCount := m.ImportTagCount()
+	names := map[Index]string{}
+	for _, exp := range m.ExportSection {
+		if _, ok := names[exp.Index]; !ok && exp.Type == ExternTypeTag && exp.Index >= importCount {
+			names[exp.Index] = moduleName + "." + exp.Name
+		}
+	}
+
+	tags := make([]*TagInstance, len(m.TagSection))
+	for i, typeIndex := range m.TagSection {
+		index := importCount + Index(i)
+		name, ok := names[index]
+		if !ok {
+			name = fmt.Sprintf("%s.tag[%d]", moduleName, index)
+		}
+		tags[i] = &TagInstance{Type: m.TypeSection[typeIndex], name: name}
+	}
+	return tags
+}
+
+// ExceptionFromRecovered returns the exception a host function threw, by panicking with it or by returning it as its
+// trailing error, or nil if the host function panicked for another reason.
+//
+// Note: An exception is also found when wrapped, such as when the host function returned the error of an
+// api.Function call which didn't catch it.
+func ExceptionFromRecovered(recovered interface{}) *sys.Exception {
+	switch v := recovered.(type) {
+	case *sys.Exception:
+		return v
+	case error:
+		var exc *sys.Exception
+		if errors.As(v, &exc) {
+			return exc
+		}
+	}
+	return nil
+}
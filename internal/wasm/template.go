@@ -0,0 +1,138 @@
+package wasm
+
+import (
+	"context"
+	"errors"
+	"runtime"
+
+	"github.com/tetratelabs/wazero/internal/platform"
+)
+
+// Template is the state of a module instance, which Reset applies to other instances of the same module.
+//
+// Unlike the result of Snapshot, this isn't encoded, as it is only used in the same process.
+type Template struct {
+	s *snapshot
+
+	// cows are the copy-on-write contents of s.memories, so that Reset remaps memories instead of copying them. An
+	// element is nil where this is unsupported, such as on platforms other than Linux and for shared memories.
+	cows []*platform.CopyOnWrite
+}
+
+// NewTemplate returns the current state of this instance, usually captured right after instantiation. This returns an
+// error in the same cases as Snapshot.
+//
+// Note: This must not be called concurrently with function calls in this module.
+func (m *ModuleInstance) NewTemplate() (*Template, error) {
+	s, err := m.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	t := &Template{s: s, cows: make([]*platform.CopyOnWrite, len(s.memories))}
+	for i, mem := range m.definedMemories() {
+		// The content is mapped up to the capacity of the memory, so that growing within it stays in the mapping.
+		if size := cap(mem.Buffer); !mem.Shared && size > 0 {
+			t.cows[i], _ = platform.NewCopyOnWrite(s.memories[i], size) // nil on error, so memories are copied.
+		}
+	}
+	return t, nil
+}
+
+// Reset returns this instance to the state of the template, taken from an instance of the same module.
+//
+// Unlike Restore, memories and tables shrink when they are larger than the template, and dropped data and element
+// segments are re-initialized when they weren't dropped in the template. On Linux, memories are reset by remapping the
+// content of the template privately, so this costs as much as the pages written since the last reset. Otherwise, or if
+// that fails, memories are reset by copying the template, so this costs as much as the size of the template's memories.
+//
+// Note: Views returned by api.Memory Read are invalid after this, as the memory may be remapped.
+//
+// Note: This must not be called concurrently with function calls in this module.
+func (m *ModuleInstance) Reset(ctx context.Context, t *Template) error {
+	s := t.s
+	if s.moduleID != m.Source.ID {
+		return errors.New("template is of a different module")
+	}
+
+	for i, mem := range m.definedMemories() {
+		if size := uint64(len(s.memories[i])); size < mem.Size64(ctx) {
+			mem.shrink(size)
+		} else if err := restoreMemorySize(ctx, i, mem, size); err != nil {
+			return err
+		}
+		if cow := t.cows[i]; cow == nil || !mem.resetCopyOnWrite(cow) {
+			copy(mem.Buffer, s.memories[i])
+		}
+	}
+
+	for i, table := range m.definedTables() {
+		table.mux.Lock()
+		if size := len(s.tables[i]); size < len(table.References) {
+			table.References = table.References[:size]
+		}
+		table.mux.Unlock()
+	}
+	m.restoreGlobalsAndTables(ctx, s)
+
+	for i, dropped := range s.droppedData {
+		if dropped {
+			m.DataInstances[i] = nil
+		} else {
+			m.DataInstances[i] = m.Source.DataSection[i].Init
+		}
+	}
+	for i, dropped := range s.droppedElements {
+		if elm := m.Source.ElementSection[i]; dropped {
+			m.ElementInstances[i].References = nil
+		} else if elm.Type == RefTypeFuncref && elm.Mode == ElementModePassive { // as built during instantiation
+			m.ElementInstances[i] = *m.Engine.CreateFuncElementInstance(elm.Init)
+		}
+	}
+	return nil
+}
+
+// resetCopyOnWrite resets the memory to the content of cow, by remapping the mapping of it, or mapping it on the first
+// call with cow. This returns false if this failed, in which case the memory is unchanged. The length doesn't change.
+func (m *MemoryInstance) resetCopyOnWrite(cow *platform.CopyOnWrite) bool {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	size := len(m.Buffer)
+	if size > cow.Size() {
+		return false
+	}
+	if m.cow != cow {
+		mapping, err := cow.Map()
+		if err != nil {
+			return false
+		}
+		if m.mapping == nil {
+			runtime.SetFinalizer(m, munmapMemory)
+		} else {
+			_ = platform.MunmapCopyOnWrite(m.mapping)
+		}
+		m.mapping, m.cow = mapping, cow
+	} else if err := cow.Remap(m.mapping); err != nil {
+		return false
+	}
+	m.Buffer = m.mapping[:size]
+	m.Cap = memoryBytesNumToPages(uint64(len(m.mapping)))
+	return true
+}
+
+// munmapMemory is a runtime.SetFinalizer function that releases the copy-on-write mapping of the memory.
+func munmapMemory(m *MemoryInstance) {
+	_ = platform.MunmapCopyOnWrite(m.mapping)
+}
+
+// shrink reduces the memory to the size in bytes, zeroing the bytes beyond it as growing within the capacity doesn't.
+func (m *MemoryInstance) shrink(size uint64) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	tail := m.Buffer[size:m.Size64(nil)]
+	for i := range tail {
+		tail[i] = 0
+	}
+	m.setSize(size)
+}
@@ -0,0 +1,107 @@
+package wasm
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestModuleInstance_Reset(t *testing.T) {
+	source := &Module{
+		ID:             ModuleID{1},
+		MemorySection:  []*Memory{{Min: 1, Cap: 1, Max: 3}},
+		DataSection:    []*DataSegment{{Init: []byte{1}}, {Init: []byte{2}}},
+		ElementSection: []*ElementSegment{{Mode: ElementModePassive, Type: RefTypeFuncref}, {Mode: ElementModePassive, Type: RefTypeFuncref}},
+	}
+	m := newSnapshotModuleInstance(source)
+	m.DataInstances[0] = nil // dropped before the template
+	m.Memory.Buffer[0] = 1
+	template, err := m.NewTemplate()
+	require.NoError(t, err)
+
+	// Change each type of state, growing the memory and table beyond the template.
+	_, ok := m.Memory.Grow(testCtx, 1)
+	require.True(t, ok)
+	m.Memory.Buffer[0], m.Memory.Buffer[MemoryPageSize] = 2, 2
+	m.Globals[1].Val, m.Globals[1].ValHi = 2, 3
+	m.Tables[0].Grow(testCtx, 2, 0)
+	m.DataInstances[1] = nil
+	m.ElementInstances[0].References = nil
+
+	require.NoError(t, m.Reset(testCtx, template))
+
+	require.Equal(t, byte(1), m.Memory.Buffer[0])
+	require.Equal(t, uint32(1), m.Memory.PageSize(testCtx))
+	require.Equal(t, [2]uint64{0, 0}, [2]uint64{m.Globals[1].Val, m.Globals[1].ValHi})
+	require.Equal(t, 1, len(m.Tables[0].References))
+	require.Equal(t, []DataInstance{nil, {2}}, m.DataInstances)
+	require.Equal(t, []Reference{}, m.ElementInstances[0].References)
+
+	// Growing the memory again must not expose the bytes written before the reset.
+	_, ok = m.Memory.Grow(testCtx, 1)
+	require.True(t, ok)
+	require.Equal(t, byte(0), m.Memory.Buffer[MemoryPageSize])
+
+	t.Run("copy-on-write", func(t *testing.T) {
+		if runtime.GOOS != "linux" {
+			t.Skip("copy-on-write mappings are only supported on Linux")
+		}
+		m := newSnapshotModuleInstance(source)
+		m.Memory = NewMemoryInstance(&Memory{Min: 1, Cap: 2, Max: 3})
+		m.Memories = []*MemoryInstance{m.Memory}
+		m.Memory.Buffer[0] = 1
+		template, err := m.NewTemplate()
+		require.NoError(t, err)
+		require.NotNil(t, template.cows[0])
+
+		// The first reset maps the template, up to the capacity of the memory.
+		require.NoError(t, m.Reset(testCtx, template))
+		require.Equal(t, int(2*MemoryPageSize), len(m.Memory.mapping))
+		address := &m.Memory.Buffer[0]
+
+		// Growing within the capacity stays in the mapping.
+		m.Memory.Buffer[0] = 2
+		_, ok := m.Memory.Grow(testCtx, 1)
+		require.True(t, ok)
+		m.Memory.Buffer[MemoryPageSize] = 2
+		require.Same(t, address, &m.Memory.Buffer[0])
+
+		// Later resets remap the template in place, discarding the writes.
+		require.NoError(t, m.Reset(testCtx, template))
+		require.Same(t, address, &m.Memory.Buffer[0])
+		require.Equal(t, byte(1), m.Memory.Buffer[0])
+		require.Equal(t, uint32(1), m.Memory.PageSize(testCtx))
+		_, ok = m.Memory.Grow(testCtx, 1)
+		require.True(t, ok)
+		require.Equal(t, byte(0), m.Memory.Buffer[MemoryPageSize])
+
+		// Growing past the mapping moves the memory, until the next reset.
+		_, ok = m.Memory.Grow(testCtx, 1)
+		require.True(t, ok)
+		m.Memory.Buffer[0] = 2
+		require.NoError(t, m.Reset(testCtx, template))
+		require.Same(t, address, &m.Memory.Buffer[0])
+		require.Equal(t, byte(1), m.Memory.Buffer[0])
+	})
+
+	t.Run("copy", func(t *testing.T) {
+		m := newSnapshotModuleInstance(source)
+		m.Memory = NewMemoryInstance(&Memory{Min: 1, Cap: 2, Max: 3})
+		m.Memories = []*MemoryInstance{m.Memory}
+		m.Memory.Buffer[0] = 1
+		template, err := m.NewTemplate()
+		require.NoError(t, err)
+		template.cows[0] = nil // as if unsupported
+
+		m.Memory.Buffer[0] = 2
+		require.NoError(t, m.Reset(testCtx, template))
+		require.Nil(t, m.Memory.mapping)
+		require.Equal(t, byte(1), m.Memory.Buffer[0])
+	})
+
+	t.Run("different module", func(t *testing.T) {
+		other := newSnapshotModuleInstance(&Module{ID: ModuleID{2}, MemorySection: source.MemorySection})
+		require.EqualError(t, other.Reset(testCtx, template), "template is of a different module")
+	})
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/buildoptions"
 	"github.com/tetratelabs/wazero/internal/wasmruntime"
+	"github.com/tetratelabs/wazero/sys"
 )
 
 // FuncName returns the naming convention of "moduleName.funcName".
@@ -87,27 +88,46 @@ func signature(funcName string, paramTypes []api.ValueType, resultTypes []api.Va
 
 // ErrorBuilder helps build consistent errors, particularly adding a WASM stack trace.
 //
-// AddFrame should be called beginning at the frame that panicked until no more frames exist. Once done, call Format.
+// AddFrame should be called beginning at the frame that panicked until no more frames exist. Once done, call
+// FromRecovered.
 type ErrorBuilder interface {
 	// AddFrame adds the next frame.
 	//
 	// * funcName should be from FuncName
-	// * paramTypes should be from wasm.FunctionType
-	// * resultTypes should be from wasm.FunctionType
+	// * frame is returned as data by sys.StackTraceError Frames
 	//
-	// Note: paramTypes and resultTypes are present because signature misunderstanding, mismatch or overflow are common.
-	AddFrame(funcName string, paramTypes, resultTypes []api.ValueType)
+	// Note: The param and result types of the frame are formatted because signature misunderstanding, mismatch or
+	// overflow are common. Any frame.Sources are formatted on the following lines.
+	AddFrame(funcName string, frame sys.StackFrame)
 
-	// FromRecovered returns an error with the wasm stack trace appended to it.
+	// FromRecovered returns a sys.StackTraceError with the wasm stack trace appended to its message.
 	FromRecovered(recovered interface{}) error
 }
 
+// GoFuncError is panicked with the non-nil error returned by a host function with a trailing error result, to unwind
+// the Wasm stack. FromRecovered returns a sys.StackTraceError wrapping Err.
+type GoFuncError struct {
+	Err error
+}
+
+// Error implements error
+func (e *GoFuncError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns Err, which allows use via errors.As, such as to find an exception thrown by the host function.
+func (e *GoFuncError) Unwrap() error {
+	return e.Err
+}
+
 func NewErrorBuilder() ErrorBuilder {
 	return &stackTrace{}
 }
 
 type stackTrace struct {
-	frames []string
+	frames []sys.StackFrame
+	// lines are the formatted frames.
+	lines []string
 }
 
 func (s *stackTrace) FromRecovered(recovered interface{}) error {
@@ -115,31 +135,72 @@ func (s *stackTrace) FromRecovered(recovered interface{}) error {
 		debug.PrintStack()
 	}
 
-	stack := strings.Join(s.frames, "\n\t")
+	stack := strings.Join(s.lines, "\n\t")
 
 	// If the error was internal, don't mention it was recovered.
 	if wasmErr, ok := recovered.(*wasmruntime.Error); ok {
-		return fmt.Errorf("wasm error: %w\nwasm stack trace:\n\t%s", wasmErr, stack)
+		return s.newError(fmt.Sprintf("wasm error: %s\nwasm stack trace:\n\t%s", wasmErr, stack), wasmErr)
+	}
+
+	// If a host function returned an error, it wasn't recovered from a panic, so return it as is with the stack trace.
+	if goFuncErr, ok := recovered.(*GoFuncError); ok {
+		return s.newError(fmt.Sprintf("%s\nwasm stack trace:\n\t%s", goFuncErr.Err, stack), goFuncErr.Err)
+	}
+
+	// If an exception wasn't caught, it isn't an error of wazero or a host function.
+	if exc, ok := recovered.(*sys.Exception); ok {
+		return s.newError(fmt.Sprintf("%s\nwasm stack trace:\n\t%s", exc, stack), exc)
+	}
+
+	// If the context was done, the stack trace shows where the Wasm code was interrupted, but it wasn't recovered.
+	if ctxErr, ok := recovered.(*sys.ContextDoneError); ok {
+		return s.newError(fmt.Sprintf("%s\nwasm stack trace:\n\t%s", ctxErr, stack), ctxErr)
 	}
 
 	// If we have a runtime.Error, something severe happened which should include the stack trace. This could be
 	// a nil pointer from wazero or a user-defined function from ModuleBuilder.
 	if runtimeErr, ok := recovered.(runtime.Error); ok {
 		// TODO: consider adding debug.Stack(), but last time we attempted, some tests became unstable.
-		return fmt.Errorf("%w (recovered by wazero)\nwasm stack trace:\n\t%s", runtimeErr, stack)
+		return s.newError(fmt.Sprintf("%s (recovered by wazero)\nwasm stack trace:\n\t%s", runtimeErr, stack), runtimeErr)
 	}
 
 	// At this point we expect the error was from a function defined by ModuleBuilder that intentionally called panic.
 	if runtimeErr, ok := recovered.(error); ok { // Ex. panic(errors.New("whoops"))
-		return fmt.Errorf("%w (recovered by wazero)\nwasm stack trace:\n\t%s", runtimeErr, stack)
+		return s.newError(fmt.Sprintf("%s (recovered by wazero)\nwasm stack trace:\n\t%s", runtimeErr, stack), runtimeErr)
 	} else { // Ex. panic("whoops")
-		return fmt.Errorf("%v (recovered by wazero)\nwasm stack trace:\n\t%s", recovered, stack)
+		return s.newError(fmt.Sprintf("%v (recovered by wazero)\nwasm stack trace:\n\t%s", recovered, stack), nil)
+	}
+}
+
+func (s *stackTrace) newError(message string, cause error) error {
+	return sys.NewStackTraceError(message, cause, s.frames)
+}
+
+// AddFrame implements ErrorBuilder.AddFrame
+func (s *stackTrace) AddFrame(funcName string, frame sys.StackFrame) {
+	s.frames = append(s.frames, frame)
+	line := signature(funcName, frame.ParamTypes, frame.ResultTypes)
+	// Indent any source locations under the signature, similar to a Go stack trace.
+	for _, src := range frame.Sources {
+		line += "\n\t\t" + sourceLocation(src)
 	}
+	s.lines = append(s.lines, line)
 }
 
-// AddFrame implements ErrorBuilder.Format
-func (s *stackTrace) AddFrame(funcName string, paramTypes, resultTypes []api.ValueType) {
-	// Format as best as we can, considering we don't yet have source and line numbers,
-	// TODO: include DWARF symbols. See #58
-	s.frames = append(s.frames, signature(funcName, paramTypes, resultTypes))
+// sourceLocation returns a formatted source location similar to how it is in a Go stack trace. Ex. "main.c:4:3"
+func sourceLocation(src sys.SourceLocation) string {
+	var ret strings.Builder
+	ret.WriteString(src.File)
+	if src.Line != 0 {
+		ret.WriteByte(':')
+		ret.WriteString(strconv.FormatUint(src.Line, 10))
+		if src.Column != 0 {
+			ret.WriteByte(':')
+			ret.WriteString(strconv.FormatUint(src.Column, 10))
+		}
+	}
+	if src.Inlined {
+		ret.WriteString(" (inlined)")
+	}
+	return ret.String()
 }
@@ -1,6 +1,7 @@
 package wasmdebug
 
 import (
+	"context"
 	"errors"
 	"runtime"
 	"testing"
@@ -8,6 +9,7 @@ import (
 	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasmruntime"
+	"github.com/tetratelabs/wazero/sys"
 )
 
 func TestFuncName(t *testing.T) {
@@ -67,8 +69,18 @@ func TestAddSignature(t *testing.T) {
 func TestErrorBuilder(t *testing.T) {
 	argErr := errors.New("invalid argument")
 	rteErr := testRuntimeErr("index out of bounds")
+	ctxErr := sys.NewContextDoneError(context.Canceled)
 	i32 := api.ValueTypeI32
-	i32i32i32i32 := []api.ValueType{i32, i32, i32, i32}
+	fdWrite := sys.StackFrame{
+		ModuleName:    "wasi_snapshot_preview1",
+		FunctionIndex: 1,
+		FunctionName:  "fd_write",
+		ParamTypes:    []api.ValueType{i32, i32, i32, i32},
+		ParamNames:    []string{"fd", "iovs", "iovs_len", "result.size"},
+		ResultTypes:   []api.ValueType{i32},
+		Offset:        -1,
+	}
+	xy := sys.StackFrame{ModuleName: "x", FunctionName: "y", Offset: 3}
 
 	tests := []struct {
 		name         string
@@ -79,7 +91,7 @@ func TestErrorBuilder(t *testing.T) {
 		{
 			name: "one",
 			build: func(builder ErrorBuilder) error {
-				builder.AddFrame("x.y", nil, nil)
+				builder.AddFrame("x.y", xy)
 				return builder.FromRecovered(argErr)
 			},
 			expectedErr: `invalid argument (recovered by wazero)
@@ -90,11 +102,24 @@ wasm stack trace:
 		{
 			name: "two",
 			build: func(builder ErrorBuilder) error {
-				builder.AddFrame("wasi_snapshot_preview1.fd_write", i32i32i32i32, []api.ValueType{i32})
-				builder.AddFrame("x.y", nil, nil)
+				builder.AddFrame("wasi_snapshot_preview1.fd_write", fdWrite)
+				builder.AddFrame("x.y", xy)
 				return builder.FromRecovered(argErr)
 			},
 			expectedErr: `invalid argument (recovered by wazero)
+wasm stack trace:
+	wasi_snapshot_preview1.fd_write(i32,i32,i32,i32) i32
+	x.y()`,
+			expectUnwrap: argErr,
+		},
+		{
+			name: "GoFuncError",
+			build: func(builder ErrorBuilder) error {
+				builder.AddFrame("wasi_snapshot_preview1.fd_write", fdWrite)
+				builder.AddFrame("x.y", xy)
+				return builder.FromRecovered(&GoFuncError{Err: argErr})
+			},
+			expectedErr: `invalid argument
 wasm stack trace:
 	wasi_snapshot_preview1.fd_write(i32,i32,i32,i32) i32
 	x.y()`,
@@ -103,8 +128,8 @@ wasm stack trace:
 		{
 			name: "runtime.Error",
 			build: func(builder ErrorBuilder) error {
-				builder.AddFrame("wasi_snapshot_preview1.fd_write", i32i32i32i32, []api.ValueType{i32})
-				builder.AddFrame("x.y", nil, nil)
+				builder.AddFrame("wasi_snapshot_preview1.fd_write", fdWrite)
+				builder.AddFrame("x.y", xy)
 				return builder.FromRecovered(rteErr)
 			},
 			expectedErr: `index out of bounds (recovered by wazero)
@@ -116,8 +141,8 @@ wasm stack trace:
 		{
 			name: "wasmruntime.Error",
 			build: func(builder ErrorBuilder) error {
-				builder.AddFrame("wasi_snapshot_preview1.fd_write", i32i32i32i32, []api.ValueType{i32})
-				builder.AddFrame("x.y", nil, nil)
+				builder.AddFrame("wasi_snapshot_preview1.fd_write", fdWrite)
+				builder.AddFrame("x.y", xy)
 				return builder.FromRecovered(wasmruntime.ErrRuntimeCallStackOverflow)
 			},
 			expectedErr: `wasm error: callstack overflow
@@ -126,6 +151,27 @@ wasm stack trace:
 	x.y()`,
 			expectUnwrap: wasmruntime.ErrRuntimeCallStackOverflow,
 		},
+		{
+			name: "sys.ContextDoneError",
+			build: func(builder ErrorBuilder) error {
+				builder.AddFrame("x.y", xy)
+				return builder.FromRecovered(ctxErr)
+			},
+			expectedErr: `context done: context canceled
+wasm stack trace:
+	x.y()`,
+			expectUnwrap: ctxErr,
+		},
+		{
+			name: "not an error",
+			build: func(builder ErrorBuilder) error {
+				builder.AddFrame("x.y", xy)
+				return builder.FromRecovered("whoops")
+			},
+			expectedErr: `whoops (recovered by wazero)
+wasm stack trace:
+	x.y()`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -134,10 +180,50 @@ wasm stack trace:
 			withStackTrace := tc.build(NewErrorBuilder())
 			require.Equal(t, tc.expectUnwrap, errors.Unwrap(withStackTrace))
 			require.EqualError(t, withStackTrace, tc.expectedErr)
+
+			var stErr *sys.StackTraceError
+			require.True(t, errors.As(withStackTrace, &stErr))
+			frames := stErr.Frames()
+			require.Equal(t, xy, frames[len(frames)-1])
+			if len(frames) == 2 {
+				require.Equal(t, fdWrite, frames[0])
+			}
 		})
 	}
 }
 
+func TestErrorBuilder_Sources(t *testing.T) {
+	middle := sys.StackFrame{
+		FunctionName: "middle",
+		Offset:       0x15,
+		Sources: []sys.SourceLocation{
+			{File: "/testdata/dwarf.c", Line: 4, Column: 3, Inlined: true},
+			{File: "/testdata/dwarf.c", Line: 9, Column: 3},
+		},
+	}
+	run := sys.StackFrame{
+		FunctionName: "run",
+		Offset:       0,
+		Sources:      []sys.SourceLocation{{File: "/testdata/dwarf.c", Line: 13}},
+	}
+
+	builder := NewErrorBuilder()
+	builder.AddFrame(".middle", middle)
+	builder.AddFrame(".run", run)
+	withStackTrace := builder.FromRecovered(wasmruntime.ErrRuntimeUnreachable)
+	require.EqualError(t, withStackTrace, `wasm error: unreachable
+wasm stack trace:
+	.middle()
+		/testdata/dwarf.c:4:3 (inlined)
+		/testdata/dwarf.c:9:3
+	.run()
+		/testdata/dwarf.c:13`)
+
+	var stErr *sys.StackTraceError
+	require.True(t, errors.As(withStackTrace, &stErr))
+	require.Equal(t, []sys.StackFrame{middle, run}, stErr.Frames())
+}
+
 // compile-time check to ensure testRuntimeErr implements runtime.Error.
 var _ runtime.Error = testRuntimeErr("")
 
@@ -0,0 +1,183 @@
+package wasmdebug
+
+import (
+	"debug/dwarf"
+
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// DWARFLines looks up the source locations of instructions, using the DWARF custom sections of a module, such as
+// ".debug_info" and ".debug_line", produced by compilers when building with debug information (Ex. `-g`).
+//
+// Note: Addresses in Wasm DWARF are offsets from the beginning of the code section contents.
+// See https://yurydelendik.github.io/webassembly-dwarf/#pc
+type DWARFLines struct {
+	d *dwarf.Data
+}
+
+// NewDWARFLines returns DWARFLines for the given DWARF custom sections, keyed by name (Ex. ".debug_info"), or nil if
+// they don't include valid debug information.
+func NewDWARFLines(sections map[string][]byte) *DWARFLines {
+	info, line := sections[".debug_info"], sections[".debug_line"]
+	if info == nil || line == nil {
+		return nil
+	}
+	d, err := dwarf.New(sections[".debug_abbrev"], sections[".debug_aranges"], sections[".debug_frame"], info, line,
+		sections[".debug_pubnames"], sections[".debug_ranges"], sections[".debug_str"])
+	if err != nil {
+		return nil
+	}
+	// Add sections introduced in DWARF 5, which are only present when the compiler targets it.
+	for _, name := range []string{".debug_addr", ".debug_line_str", ".debug_rnglists", ".debug_str_offsets"} {
+		if s, ok := sections[name]; ok {
+			if err = d.AddSection(name, s); err != nil {
+				return nil
+			}
+		}
+	}
+	return &DWARFLines{d: d}
+}
+
+// Line returns the source locations of the instruction at the given offset in the code section, or nil if unknown.
+//
+// When the instruction was inlined, the first location is in the innermost inlined function, followed by each call
+// site. See sys.StackFrame Sources
+func (l *DWARFLines) Line(instructionOffset uint64) (ret []sys.SourceLocation) {
+	if l == nil {
+		return
+	}
+
+	// Find the compilation unit which includes the instruction, positioning r at its first child.
+	r := l.d.Reader()
+	cu, err := r.SeekPC(instructionOffset)
+	if err != nil {
+		return
+	}
+	lr, err := l.d.LineReader(cu)
+	if err != nil || lr == nil {
+		return
+	}
+	var le dwarf.LineEntry
+	if err = lr.SeekPC(instructionOffset, &le); err != nil {
+		return
+	}
+	ret = append(ret, sys.SourceLocation{File: fileName(le.File), Line: uint64(le.Line), Column: uint64(le.Column)})
+
+	// Each inlined call site is a location in the function it was inlined into, so add them innermost first.
+	calls := l.inlinedCalls(r, instructionOffset)
+	files := lr.Files()
+	for i := len(calls) - 1; i >= 0; i-- {
+		ret[len(ret)-1].Inlined = true
+		ret = append(ret, callSite(calls[i], files))
+	}
+	return
+}
+
+// Rows calls fn with the offset in the code section and the source location of each row of the line tables, skipping
+// rows without a line, such as the end of each sequence of instructions.
+//
+// Note: The location is the innermost one, so in the inlined function when the instruction was inlined.
+func (l *DWARFLines) Rows(fn func(instructionOffset uint64, loc sys.SourceLocation)) {
+	if l == nil {
+		return
+	}
+
+	r := l.d.Reader()
+	for {
+		cu, err := r.Next()
+		if err != nil || cu == nil {
+			return
+		}
+		if cu.Children {
+			r.SkipChildren()
+		}
+		if cu.Tag != dwarf.TagCompileUnit {
+			continue
+		}
+
+		lr, err := l.d.LineReader(cu)
+		if err != nil || lr == nil {
+			continue
+		}
+		var le dwarf.LineEntry
+		for lr.Next(&le) == nil {
+			if le.EndSequence || le.Line == 0 {
+				continue
+			}
+			fn(le.Address, sys.SourceLocation{File: fileName(le.File), Line: uint64(le.Line), Column: uint64(le.Column)})
+		}
+	}
+}
+
+// inlinedCalls returns the DW_TAG_inlined_subroutine entries which include the instruction, outermost first.
+//
+// Note: r must be positioned at the first child of a compilation unit.
+func (l *DWARFLines) inlinedCalls(r *dwarf.Reader, instructionOffset uint64) (calls []*dwarf.Entry) {
+	depth := 0
+	for {
+		e, err := r.Next()
+		if err != nil || e == nil {
+			return
+		}
+
+		if e.Tag == 0 { // End of the children of the current entry.
+			if depth--; depth < 0 {
+				return // End of the compilation unit.
+			}
+			continue
+		}
+
+		switch e.Tag {
+		case dwarf.TagSubprogram, dwarf.TagInlinedSubroutine, dwarf.TagLexDwarfBlock:
+			// Only descend into scopes which include the instruction.
+			if !l.includes(e, instructionOffset) {
+				if e.Children {
+					r.SkipChildren()
+				}
+				continue
+			}
+			if e.Tag == dwarf.TagInlinedSubroutine {
+				calls = append(calls, e)
+			}
+		}
+
+		if e.Children {
+			depth++
+		}
+	}
+}
+
+// includes returns true if the address ranges of the entry include the instruction.
+func (l *DWARFLines) includes(e *dwarf.Entry, instructionOffset uint64) bool {
+	ranges, err := l.d.Ranges(e)
+	if err != nil {
+		return false
+	}
+	for _, pcs := range ranges {
+		if pcs[0] <= instructionOffset && instructionOffset < pcs[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// callSite returns the location of the call to an inlined function, given the file table of its compilation unit.
+func callSite(e *dwarf.Entry, files []*dwarf.LineFile) (loc sys.SourceLocation) {
+	if i, ok := e.Val(dwarf.AttrCallFile).(int64); ok && i >= 0 && i < int64(len(files)) {
+		loc.File = fileName(files[i])
+	}
+	if line, ok := e.Val(dwarf.AttrCallLine).(int64); ok {
+		loc.Line = uint64(line)
+	}
+	if column, ok := e.Val(dwarf.AttrCallColumn).(int64); ok {
+		loc.Column = uint64(column)
+	}
+	return
+}
+
+func fileName(f *dwarf.LineFile) string {
+	if f == nil {
+		return ""
+	}
+	return f.Name
+}
@@ -0,0 +1,102 @@
+package wasmdebug_test
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/dwarftestdata"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+	"github.com/tetratelabs/wazero/internal/wasmdebug"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+func TestDWARFLines_Line(t *testing.T) {
+	// This test is in wasmdebug_test as decoding DWARFWasm uses binary, which imports wasmdebug.
+	m, err := binary.DecodeModule(dwarftestdata.DWARFWasm, wasm.Features20191205, wasm.MemorySizer)
+	require.NoError(t, err)
+	require.NotNil(t, m.DWARFLines)
+
+	tests := []struct {
+		name              string
+		instructionOffset uint64
+		expected          []sys.SourceLocation
+	}{
+		{
+			name:              "before code",
+			instructionOffset: 0x1,
+		},
+		{
+			name:              "middle: counter += 1",
+			instructionOffset: 0x7, // i32.load
+			expected:          []sys.SourceLocation{{File: "/testdata/dwarf.c", Line: 8, Column: 11}},
+		},
+		{
+			name:              "middle: fail inlined",
+			instructionOffset: 0x18, // unreachable
+			expected: []sys.SourceLocation{
+				{File: "/testdata/dwarf.c", Line: 4, Column: 3, Inlined: true},
+				{File: "/testdata/dwarf.c", Line: 9, Column: 3},
+			},
+		},
+		{
+			name:              "run: middle()",
+			instructionOffset: 0x1d, // call
+			expected:          []sys.SourceLocation{{File: "/testdata/dwarf.c", Line: 13, Column: 3}},
+		},
+		{
+			name:              "after code",
+			instructionOffset: 0x24,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, m.DWARFLines.Line(tc.instructionOffset))
+		})
+	}
+}
+
+func TestDWARFLines_Line_Nil(t *testing.T) {
+	var lines *wasmdebug.DWARFLines
+	require.Nil(t, lines.Line(0x18))
+}
+
+func TestDWARFLines_Rows(t *testing.T) {
+	m, err := binary.DecodeModule(dwarftestdata.DWARFWasm, wasm.Features20191205, wasm.MemorySizer)
+	require.NoError(t, err)
+
+	type row struct {
+		instructionOffset uint64
+		loc               sys.SourceLocation
+	}
+	var rows []row
+	m.DWARFLines.Rows(func(instructionOffset uint64, loc sys.SourceLocation) {
+		rows = append(rows, row{instructionOffset, loc})
+	})
+	require.Equal(t, []row{
+		{0x2, sys.SourceLocation{File: "/testdata/dwarf.c", Line: 7}},
+		{0x3, sys.SourceLocation{File: "/testdata/dwarf.c", Line: 8, Column: 11}},
+		{0x18, sys.SourceLocation{File: "/testdata/dwarf.c", Line: 4, Column: 3}},
+		{0x1c, sys.SourceLocation{File: "/testdata/dwarf.c", Line: 12}},
+		{0x1d, sys.SourceLocation{File: "/testdata/dwarf.c", Line: 13, Column: 3}},
+		{0x23, sys.SourceLocation{File: "/testdata/dwarf.c", Line: 14, Column: 1}},
+	}, rows)
+}
+
+func TestDWARFLines_Rows_Nil(t *testing.T) {
+	var lines *wasmdebug.DWARFLines
+	lines.Rows(func(uint64, sys.SourceLocation) {
+		t.Fatal("unexpected row")
+	})
+}
+
+func TestNewDWARFLines(t *testing.T) {
+	t.Run("missing .debug_line", func(t *testing.T) {
+		require.Nil(t, wasmdebug.NewDWARFLines(map[string][]byte{".debug_info": {1, 2}}))
+	})
+	t.Run("invalid", func(t *testing.T) {
+		require.Nil(t, wasmdebug.NewDWARFLines(map[string][]byte{".debug_info": {1, 2}, ".debug_line": {3, 4}}))
+	})
+}
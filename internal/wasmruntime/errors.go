@@ -27,6 +27,14 @@ var (
 	ErrRuntimeInvalidTableAccess = New("invalid table access")
 	// ErrRuntimeIndirectCallTypeMismatch indicates that the type check failed during call_indirect.
 	ErrRuntimeIndirectCallTypeMismatch = New("indirect call type mismatch")
+	// ErrRuntimeOutOfFuel indicates that the program consumed all the fuel of the call, when fuel metering is enabled.
+	ErrRuntimeOutOfFuel = New("out of fuel")
+	// ErrRuntimeUnalignedAtomic indicates that the program tried an atomic memory access at an address which isn't a
+	// multiple of its size.
+	ErrRuntimeUnalignedAtomic = New("unaligned atomic")
+	// ErrRuntimeExpectedSharedMemory indicates that the program tried to wait on a memory which isn't shared, which
+	// would never be notified.
+	ErrRuntimeExpectedSharedMemory = New("expected shared memory")
 )
 
 // Error is returned by a wasm.Engine during the execution of Wasm functions, and they indicate that the Wasm runtime
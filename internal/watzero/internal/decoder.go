@@ -21,9 +21,11 @@ const (
 	positionImport
 	positionImportFunc
 	positionMemory
+	positionGlobal
 	positionExport
 	positionExportFunc
 	positionExportMemory
+	positionExportGlobal
 	positionStart
 )
 
@@ -92,6 +94,15 @@ type moduleParser struct {
 	// memoryParser parses the MemorySection for a given module-defined memory.
 	memoryParser *memoryParser
 
+	// globalNamespace represents the global index namespace, which begins with any wasm.ExternTypeGlobal in the
+	// wasm.SectionIDImport followed by the wasm.SectionIDGlobal.
+	//
+	// Module-defined globals can declare symbolic IDs, such as "$g", which are resolved here (without the '$' prefix).
+	globalNamespace *indexNamespace
+
+	// globalParser parses the GlobalSection for a given module-defined global.
+	globalParser *globalParser
+
 	// unresolvedExports holds any exports whose type index wasn't resolvable when parsed.
 	unresolvedExports map[wasm.Index]*wasm.Export
 
@@ -138,6 +149,9 @@ func DecodeModule(
 	if err = p.resolveFunctionIndices(module); err != nil {
 		return nil, err
 	}
+	if err = p.resolveGlobalIndices(); err != nil {
+		return nil, err
+	}
 
 	// Don't set the name section unless we parsed a name!
 	if names.ModuleName == "" && names.FunctionNames == nil && names.LocalNames == nil {
@@ -159,11 +173,13 @@ func newModuleParser(
 		typeNamespace:   newIndexNamespace(module.SectionElementCount),
 		funcNamespace:   newIndexNamespace(module.SectionElementCount),
 		memoryNamespace: newIndexNamespace(module.SectionElementCount),
+		globalNamespace: newIndexNamespace(module.SectionElementCount),
 	}
 	p.typeParser = newTypeParser(enabledFeatures, p.typeNamespace, p.onTypeEnd)
 	p.typeUseParser = newTypeUseParser(enabledFeatures, module, p.typeNamespace)
 	p.funcParser = newFuncParser(enabledFeatures, p.typeUseParser, p.funcNamespace, p.endFunc)
 	p.memoryParser = newMemoryParser(memorySizer, p.memoryNamespace, p.endMemory)
+	p.globalParser = newGlobalParser(enabledFeatures, p.globalNamespace, p.endGlobal)
 	return &p
 }
 
@@ -211,7 +227,8 @@ func (p *moduleParser) beginModuleField(tok tokenType, tokenBytes []byte, _, _ u
 			p.pos = positionMemory
 			return p.memoryParser.begin, nil
 		case wasm.ExternTypeGlobalName:
-			return nil, fmt.Errorf("TODO: %s", tokenBytes)
+			p.pos = positionGlobal
+			return p.globalParser.begin, nil
 		case "export":
 			p.pos = positionExport
 			return p.parseExportName, nil
@@ -463,7 +480,14 @@ func (p *moduleParser) endFunc(typeIdx wasm.Index, code *wasm.Code, name string,
 // endMemory adds the limits for the current memory, and increments memoryNamespace as it is shared across imported and
 // module-defined memories. Finally, this returns parseModule to prepare for the next field.
 func (p *moduleParser) endMemory(mem *wasm.Memory) tokenParser {
-	p.module.MemorySection = mem
+	p.module.MemorySection = append(p.module.MemorySection, mem)
+	p.pos = positionModule
+	return p.parseModule
+}
+
+// endGlobal adds the current global, and returns parseModule to prepare for the next field.
+func (p *moduleParser) endGlobal(g *wasm.Global) tokenParser {
+	p.module.GlobalSection = append(p.module.GlobalSection, g)
 	p.pos = positionModule
 	return p.parseModule
 }
@@ -525,7 +549,10 @@ func (p *moduleParser) beginExportDesc(tok tokenType, tokenBytes []byte, _, _ ui
 	case wasm.ExternTypeMemoryName:
 		p.pos = positionExportMemory
 		return p.parseExportDesc, nil
-	case wasm.ExternTypeTableName, wasm.ExternTypeGlobalName:
+	case wasm.ExternTypeGlobalName:
+		p.pos = positionExportGlobal
+		return p.parseExportDesc, nil
+	case wasm.ExternTypeTableName:
 		return nil, fmt.Errorf("TODO: %s", tokenBytes)
 	default:
 		return nil, unexpectedFieldName(tokenBytes)
@@ -543,6 +570,9 @@ func (p *moduleParser) parseExportDesc(tok tokenType, tokenBytes []byte, line, c
 	case positionExportMemory:
 		e.Type = wasm.ExternTypeMemory
 		namespace = p.memoryNamespace
+	case positionExportGlobal:
+		e.Type = wasm.ExternTypeGlobal
+		namespace = p.globalNamespace
 	default:
 		panic(fmt.Errorf("BUG: unhandled parsing state on parseExportDesc: %v", p.pos))
 	}
@@ -660,6 +690,23 @@ func (p *moduleParser) resolveFunctionIndices(module *wasm.Module) error {
 	return nil
 }
 
+// resolveGlobalIndices ensures any indices point are numeric or returns a FormatError if they cannot be bound.
+func (p *moduleParser) resolveGlobalIndices() error {
+	for _, unresolved := range p.globalNamespace.unresolvedIndices {
+		target, err := p.globalNamespace.resolve(unresolved)
+		if err != nil {
+			return err
+		}
+		switch unresolved.section {
+		case wasm.SectionIDExport:
+			p.unresolvedExports[unresolved.idx].Index = target
+		default:
+			panic(unhandledSection(unresolved.section))
+		}
+	}
+	return nil
+}
+
 // resolveTypeUses adds any missing inlined types, resolving any type indexes in the FunctionSection or ImportSection.
 // This errs if any type index is unresolved, out of range or mismatches an inlined type use signature.
 func (p *moduleParser) resolveTypeUses(module *wasm.Module) error {
@@ -765,12 +812,20 @@ func (p *moduleParser) errorContext() string {
 		return fmt.Sprintf("module.%s[%d]%s", wasm.ExternTypeFuncName, idx, p.typeUseParser.errorContext())
 	case positionMemory:
 		return fmt.Sprintf("module.%s[0]", wasm.ExternTypeMemoryName)
-	case positionExport, positionExportFunc: // TODO: table, memory or global
+	case positionGlobal:
+		idx := p.module.SectionElementCount(wasm.SectionIDGlobal)
+		return fmt.Sprintf("module.%s[%d]", wasm.ExternTypeGlobalName, idx)
+	case positionExport, positionExportFunc, positionExportMemory, positionExportGlobal: // TODO: table
 		idx := p.module.SectionElementCount(wasm.SectionIDExport)
-		if p.pos == positionExport {
-			return fmt.Sprintf("module.export[%d]", idx)
+		switch p.pos {
+		case positionExportFunc:
+			return fmt.Sprintf("module.export[%d].%s", idx, wasm.ExternTypeFuncName)
+		case positionExportMemory:
+			return fmt.Sprintf("module.export[%d].%s", idx, wasm.ExternTypeMemoryName)
+		case positionExportGlobal:
+			return fmt.Sprintf("module.export[%d].%s", idx, wasm.ExternTypeGlobalName)
 		}
-		return fmt.Sprintf("module.export[%d].%s", idx, wasm.ExternTypeFuncName)
+		return fmt.Sprintf("module.export[%d]", idx)
 	case positionStart:
 		return "module.start"
 	default: // parserPosition is an enum, we expect to have handled all cases above. panic if we didn't
@@ -1226,14 +1226,14 @@ func TestDecodeModule(t *testing.T) {
 			name:  "memory",
 			input: "(module (memory 1))",
 			expected: &wasm.Module{
-				MemorySection: &wasm.Memory{Min: 1, Cap: 1, Max: wasm.MemoryLimitPages},
+				MemorySection: []*wasm.Memory{{Min: 1, Cap: 1, Max: wasm.MemoryLimitPages}},
 			},
 		},
 		{
 			name:  "memory ID",
 			input: "(module (memory $mem 1))",
 			expected: &wasm.Module{
-				MemorySection: &wasm.Memory{Min: 1, Cap: 1, Max: wasm.MemoryLimitPages},
+				MemorySection: []*wasm.Memory{{Min: 1, Cap: 1, Max: wasm.MemoryLimitPages}},
 			},
 		},
 		{
@@ -1420,7 +1420,7 @@ func TestDecodeModule(t *testing.T) {
 	(export "foo" (memory 0))
 )`,
 			expected: &wasm.Module{
-				MemorySection: &wasm.Memory{Min: 0, Max: wasm.MemoryLimitPages},
+				MemorySection: []*wasm.Memory{{Min: 0, Max: wasm.MemoryLimitPages}},
 				ExportSection: []*wasm.Export{
 					{Name: "foo", Type: wasm.ExternTypeMemory, Index: 0},
 				},
@@ -1433,12 +1433,49 @@ func TestDecodeModule(t *testing.T) {
 	(memory 0)
 )`,
 			expected: &wasm.Module{
-				MemorySection: &wasm.Memory{Min: 0, Max: wasm.MemoryLimitPages},
+				MemorySection: []*wasm.Memory{{Min: 0, Max: wasm.MemoryLimitPages}},
 				ExportSection: []*wasm.Export{
 					{Name: "foo", Type: wasm.ExternTypeMemory, Index: 0},
 				},
 			},
 		},
+		{
+			name: "global",
+			input: `(module
+	(global $pi f32 (f32.const 3))
+	(global (mut i64) i64.const 1)
+)`,
+			expected: &wasm.Module{
+				GlobalSection: []*wasm.Global{
+					{
+						Type: &wasm.GlobalType{ValType: wasm.ValueTypeF32},
+						Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeF32Const, Data: []byte{0, 0, 0x40, 0x40}},
+					},
+					{
+						Type: &wasm.GlobalType{ValType: wasm.ValueTypeI64, Mutable: true},
+						Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeI64Const, Data: []byte{1}},
+					},
+				},
+			},
+		},
+		{
+			name: "export global - ID",
+			input: `(module
+	(export "pi" (global $pi))
+	(global $pi f32 (f32.const 3))
+)`,
+			expected: &wasm.Module{
+				GlobalSection: []*wasm.Global{
+					{
+						Type: &wasm.GlobalType{ValType: wasm.ValueTypeF32},
+						Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeF32Const, Data: []byte{0, 0, 0x40, 0x40}},
+					},
+				},
+				ExportSection: []*wasm.Export{
+					{Name: "pi", Type: wasm.ExternTypeGlobal, Index: 0},
+				},
+			},
+		},
 		{
 			name: "export empty and non-empty name",
 			input: `(module
@@ -1465,7 +1502,7 @@ func TestDecodeModule(t *testing.T) {
     (export "memory" (memory $mem))
 )`,
 			expected: &wasm.Module{
-				MemorySection: &wasm.Memory{Min: 1, Cap: 1, Max: wasm.MemoryLimitPages},
+				MemorySection: []*wasm.Memory{{Min: 1, Cap: 1, Max: wasm.MemoryLimitPages}},
 				ExportSection: []*wasm.Export{
 					{Name: "memory", Type: wasm.ExternTypeMemory, Index: 0},
 				},
@@ -2137,6 +2174,16 @@ func TestParseModule_Errors(t *testing.T) {
 			input:       "(module (start $main))",
 			expectedErr: "1:16: unknown ID $main in module.start",
 		},
+		{
+			name:        "global extended-const disabled",
+			input:       "(module (global i32 (i32.add (i32.const 1) (i32.const 2))))",
+			expectedErr: "1:22: i32.add invalid as feature \"extended-const\" is disabled in module.global[0]",
+		},
+		{
+			name:        "global missing init",
+			input:       "(module (global i32))",
+			expectedErr: "1:20: missing init in module.global[0]",
+		},
 	}
 
 	for _, tt := range tests {
@@ -2162,8 +2209,11 @@ func TestModuleParser_ErrorContext(t *testing.T) {
 		{input: "module import func", pos: positionImportFunc, expected: "module.import[0].func"},
 		{input: "module func", pos: positionFunc, expected: "module.func[0]"},
 		{input: "module memory", pos: positionMemory, expected: "module.memory[0]"},
+		{input: "module global", pos: positionGlobal, expected: "module.global[0]"},
 		{input: "module export", pos: positionExport, expected: "module.export[0]"},
 		{input: "module export func", pos: positionExportFunc, expected: "module.export[0].func"},
+		{input: "module export memory", pos: positionExportMemory, expected: "module.export[0].memory"},
+		{input: "module export global", pos: positionExportGlobal, expected: "module.export[0].global"},
 		{input: "start", pos: positionStart, expected: "module.start"},
 	}
 
@@ -0,0 +1,326 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/leb128"
+	"github.com/tetratelabs/wazero/internal/u64"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+func newGlobalParser(enabledFeatures wasm.Features, globalNamespace *indexNamespace, onGlobal onGlobal) *globalParser {
+	return &globalParser{enabledFeatures: enabledFeatures, globalNamespace: globalNamespace, onGlobal: onGlobal}
+}
+
+type onGlobal func(*wasm.Global) tokenParser
+
+// globalParser parses a wasm.Global from and dispatches to onGlobal.
+//
+// Ex. `(module (global $g (mut i32) (i32.const 1)))`
+//        starts here --^                         ^
+//                        onGlobal resumes here --+
+//
+// The initial value is a constant expression, whose instructions can be flat or folded. More than one instruction
+// requires wasm.FeatureExtendedConst.
+//
+// Ex. These are the same `(global i32 (i32.add (global.get 0) (i32.const 1)))`
+//                    and `(global i32 global.get 0 i32.const 1 i32.add)`
+//
+// Note: globalParser is reusable. The caller resets via begin.
+// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#globals%E2%91%A5
+type globalParser struct {
+	// enabledFeatures should be set to moduleParser.enabledFeatures
+	enabledFeatures wasm.Features
+
+	globalNamespace *indexNamespace
+
+	// onGlobal is invoked on end
+	onGlobal onGlobal
+
+	// currentGlobal is reset on begin and read onGlobal
+	currentGlobal *wasm.Global
+
+	// currentBody holds the instructions of the initial value encoded in WebAssembly 1.0 (20191205) binary format.
+	currentBody []byte
+
+	// currentInstruction holds the instruction being parsed, until its immediates are read.
+	currentInstruction []byte
+
+	// currentFolded is true if currentInstruction begins a folded instruction, ex. `(i32.add`
+	currentFolded bool
+
+	// pendingInstructions are folded instructions whose operands are being parsed. Each is added to currentBody after
+	// the operands, on its ')'.
+	pendingInstructions [][]byte
+
+	// instructionCount is the count of instructions in currentBody.
+	instructionCount int
+
+	// lastInstructionOffset is the offset in currentBody of the last instruction.
+	lastInstructionOffset int
+}
+
+// begin should be called after reaching the wasm.ExternTypeGlobalName keyword in a module field. Parsing
+// continues until onGlobal or error.
+//
+// This stage records the ID of the current global, if present, and resumes with beginType.
+//
+// Ex. A global ID is present `(global $g i32 (i32.const 1))`
+//                  records g --^  ^
+//    beginType resumes here ------+
+//
+// Ex. No global ID `(global i32 (i32.const 1))`
+//    calls beginType --^
+func (p *globalParser) begin(tok tokenType, tokenBytes []byte, line, col uint32) (tokenParser, error) {
+	p.currentGlobal = &wasm.Global{Type: &wasm.GlobalType{}}
+	p.currentBody, p.currentInstruction, p.pendingInstructions = nil, nil, nil
+	p.instructionCount, p.lastInstructionOffset = 0, 0
+	if tok == tokenID { // Ex. $g
+		if _, err := p.globalNamespace.setID(tokenBytes); err != nil {
+			return nil, err
+		}
+		return p.beginType, nil
+	}
+	return p.beginType(tok, tokenBytes, line, col)
+}
+
+// beginType looks for the value type of the global, which is in a "mut" field if it is mutable.
+func (p *globalParser) beginType(tok tokenType, tokenBytes []byte, _, _ uint32) (tokenParser, error) {
+	switch tok {
+	case tokenID: // Ex.(global $g $g
+		return nil, fmt.Errorf("redundant ID %s", tokenBytes)
+	case tokenKeyword: // Ex. i32
+		return p.parseValueType(tokenBytes, p.parseExpression)
+	case tokenLParen: // Ex. (mut
+		return p.beginMutable, nil
+	case tokenRParen:
+		return nil, errors.New("missing type")
+	default:
+		return nil, unexpectedToken(tok, tokenBytes)
+	}
+}
+
+// beginMutable parses the value type of a global in a field `(mut i32)` and resumes with parseMutableEnd.
+func (p *globalParser) beginMutable(tok tokenType, tokenBytes []byte, _, _ uint32) (tokenParser, error) {
+	if tok != tokenKeyword {
+		return nil, expectedField(tok)
+	}
+	if string(tokenBytes) != "mut" {
+		return nil, unexpectedFieldName(tokenBytes)
+	}
+	p.currentGlobal.Type.Mutable = true
+	return p.parseMutableType, nil
+}
+
+func (p *globalParser) parseMutableType(tok tokenType, tokenBytes []byte, _, _ uint32) (tokenParser, error) {
+	if tok != tokenKeyword {
+		return nil, unexpectedToken(tok, tokenBytes)
+	}
+	return p.parseValueType(tokenBytes, p.parseMutableEnd)
+}
+
+func (p *globalParser) parseMutableEnd(tok tokenType, tokenBytes []byte, _, _ uint32) (tokenParser, error) {
+	if tok != tokenRParen {
+		return nil, unexpectedToken(tok, tokenBytes)
+	}
+	return p.parseExpression, nil
+}
+
+func (p *globalParser) parseValueType(tokenBytes []byte, next tokenParser) (tokenParser, error) {
+	vt, err := parseValueType(tokenBytes)
+	if err != nil {
+		return nil, err
+	}
+	p.currentGlobal.Type.ValType = vt
+	return next, nil
+}
+
+// parseExpression parses the instructions of the initial value, until the ')' ending the global calls end.
+func (p *globalParser) parseExpression(tok tokenType, tokenBytes []byte, _, _ uint32) (tokenParser, error) {
+	switch tok {
+	case tokenKeyword: // Ex. i32.const
+		p.currentFolded = false
+		return p.beginInstruction(tokenBytes)
+	case tokenLParen: // Ex. (i32.const
+		return p.beginFoldedInstruction, nil
+	case tokenRParen:
+		if n := len(p.pendingInstructions); n > 0 { // end of a folded instruction, after its operands
+			p.addInstruction(p.pendingInstructions[n-1])
+			p.pendingInstructions = p.pendingInstructions[:n-1]
+			return p.parseExpression, nil
+		}
+		return p.end()
+	default:
+		return nil, unexpectedToken(tok, tokenBytes)
+	}
+}
+
+func (p *globalParser) beginFoldedInstruction(tok tokenType, tokenBytes []byte, _, _ uint32) (tokenParser, error) {
+	if tok != tokenKeyword {
+		return nil, unexpectedToken(tok, tokenBytes)
+	}
+	p.currentFolded = true
+	return p.beginInstruction(tokenBytes)
+}
+
+// beginInstruction parses the token into an opcode of a constant instruction and dispatches accordingly.
+func (p *globalParser) beginInstruction(tokenBytes []byte) (tokenParser, error) {
+	var opCode wasm.Opcode
+	var next tokenParser
+	switch string(tokenBytes) {
+	case wasm.OpcodeI32ConstName:
+		opCode, next = wasm.OpcodeI32Const, p.parseI32
+	case wasm.OpcodeI64ConstName:
+		opCode, next = wasm.OpcodeI64Const, p.parseI64
+	case wasm.OpcodeF32ConstName:
+		opCode, next = wasm.OpcodeF32Const, p.parseF32
+	case wasm.OpcodeF64ConstName:
+		opCode, next = wasm.OpcodeF64Const, p.parseF64
+	case wasm.OpcodeGlobalGetName:
+		opCode, next = wasm.OpcodeGlobalGet, p.parseGlobalIndex
+	case wasm.OpcodeI32AddName:
+		opCode = wasm.OpcodeI32Add
+	case wasm.OpcodeI32SubName:
+		opCode = wasm.OpcodeI32Sub
+	case wasm.OpcodeI32MulName:
+		opCode = wasm.OpcodeI32Mul
+	case wasm.OpcodeI64AddName:
+		opCode = wasm.OpcodeI64Add
+	case wasm.OpcodeI64SubName:
+		opCode = wasm.OpcodeI64Sub
+	case wasm.OpcodeI64MulName:
+		opCode = wasm.OpcodeI64Mul
+	default:
+		return nil, fmt.Errorf("unsupported instruction in constant expression: %s", tokenBytes)
+	}
+
+	p.currentInstruction = []byte{opCode}
+	if next != nil {
+		return next, nil
+	}
+
+	// Guard >1.0 feature extended-const
+	if err := p.enabledFeatures.Require(wasm.FeatureExtendedConst); err != nil {
+		return nil, fmt.Errorf("%s invalid as %v", tokenBytes, err)
+	}
+	return p.endInstruction()
+}
+
+// endInstruction adds the current instruction to currentBody, unless it is folded and its operands are next.
+func (p *globalParser) endInstruction() (tokenParser, error) {
+	if p.currentFolded {
+		p.pendingInstructions = append(p.pendingInstructions, p.currentInstruction)
+	} else {
+		p.addInstruction(p.currentInstruction)
+	}
+	p.currentInstruction = nil
+	return p.parseExpression, nil
+}
+
+func (p *globalParser) addInstruction(instruction []byte) {
+	p.lastInstructionOffset = len(p.currentBody)
+	p.currentBody = append(p.currentBody, instruction...)
+	p.instructionCount++
+}
+
+// parseI32 parses a wasm.ValueTypeI32 and appends it to the currentInstruction.
+func (p *globalParser) parseI32(tok tokenType, tokenBytes []byte, _, _ uint32) (tokenParser, error) {
+	if tok != tokenUN {
+		return nil, unexpectedToken(tok, tokenBytes)
+	}
+	if i, overflow := decodeUint32(tokenBytes); overflow { // TODO: negative and hex
+		return nil, fmt.Errorf("i32 outside range of uint32: %s", tokenBytes)
+	} else { // See /RATIONALE.md we can't tell the signed interpretation of a constant, so default to signed.
+		p.currentInstruction = append(p.currentInstruction, leb128.EncodeInt32(int32(i))...)
+	}
+	return p.endInstruction()
+}
+
+// parseI64 parses a wasm.ValueTypeI64 and appends it to the currentInstruction.
+func (p *globalParser) parseI64(tok tokenType, tokenBytes []byte, _, _ uint32) (tokenParser, error) {
+	if tok != tokenUN {
+		return nil, unexpectedToken(tok, tokenBytes)
+	}
+	if i, overflow := decodeUint64(tokenBytes); overflow { // TODO: negative and hex
+		return nil, fmt.Errorf("i64 outside range of uint64: %s", tokenBytes)
+	} else { // See /RATIONALE.md we can't tell the signed interpretation of a constant, so default to signed.
+		p.currentInstruction = append(p.currentInstruction, leb128.EncodeInt64(int64(i))...)
+	}
+	return p.endInstruction()
+}
+
+// parseF32 parses a wasm.ValueTypeF32 and appends it to the currentInstruction.
+func (p *globalParser) parseF32(tok tokenType, tokenBytes []byte, _, _ uint32) (tokenParser, error) {
+	if tok != tokenUN {
+		return nil, unexpectedToken(tok, tokenBytes)
+	}
+	if i, overflow := decodeUint32(tokenBytes); overflow { // TODO: negative hex nan inf and actual float!
+		return nil, fmt.Errorf("f32 outside range of uint32: %s", tokenBytes)
+	} else {
+		p.currentInstruction = append(p.currentInstruction, u64.LeBytes(api.EncodeF32(float32(i)))[:4]...)
+	}
+	return p.endInstruction()
+}
+
+// parseF64 parses a wasm.ValueTypeF64 and appends it to the currentInstruction.
+func (p *globalParser) parseF64(tok tokenType, tokenBytes []byte, _, _ uint32) (tokenParser, error) {
+	if tok != tokenUN {
+		return nil, unexpectedToken(tok, tokenBytes)
+	}
+	if i, overflow := decodeUint64(tokenBytes); overflow { // TODO: negative hex nan inf and actual float!
+		return nil, fmt.Errorf("f64 outside range of uint64: %s", tokenBytes)
+	} else {
+		p.currentInstruction = append(p.currentInstruction, u64.LeBytes(api.EncodeF64(float64(i)))...)
+	}
+	return p.endInstruction()
+}
+
+// parseGlobalIndex parses an index in the global namespace and appends it to the currentInstruction. Unlike function
+// indices, a symbolic ID must be defined before, as constant expressions can only read preceding globals.
+func (p *globalParser) parseGlobalIndex(tok tokenType, tokenBytes []byte, _, _ uint32) (tokenParser, error) {
+	var idx wasm.Index
+	switch tok {
+	case tokenUN: // Ex. 1
+		i, overflow := decodeUint32(tokenBytes)
+		if overflow {
+			return nil, fmt.Errorf("index outside range of uint32: %s", tokenBytes)
+		}
+		idx = i
+	case tokenID: // Ex. $g
+		i, err := p.globalNamespace.requireIndex(string(stripDollar(tokenBytes)))
+		if err != nil {
+			return nil, err
+		}
+		idx = i
+	case tokenRParen:
+		return nil, errors.New("missing index")
+	default:
+		return nil, unexpectedToken(tok, tokenBytes)
+	}
+	p.currentInstruction = append(p.currentInstruction, leb128.EncodeUint32(idx)...)
+	return p.endInstruction()
+}
+
+// end increments the global namespace and calls onGlobal with the current global.
+func (p *globalParser) end() (tokenParser, error) {
+	switch {
+	case p.instructionCount == 0:
+		return nil, errors.New("missing init")
+	case p.instructionCount == 1:
+		p.currentGlobal.Init = &wasm.ConstantExpression{Opcode: p.currentBody[0], Data: p.currentBody[1:]}
+	default: // The last instruction is arithmetic, and the others compute its operands. See wasm.ConstantExpression
+		init := &wasm.ConstantExpression{
+			Opcode: p.currentBody[p.lastInstructionOffset],
+			Data:   p.currentBody[:p.lastInstructionOffset],
+		}
+		if !init.IsExtended() {
+			return nil, fmt.Errorf("constant expression must end with an arithmetic instruction, but was %s",
+				wasm.InstructionName(init.Opcode))
+		}
+		p.currentGlobal.Init = init
+	}
+	p.globalNamespace.count++
+	return p.onGlobal(p.currentGlobal), nil
+}
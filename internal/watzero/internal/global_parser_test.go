@@ -0,0 +1,214 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+func TestGlobalParser(t *testing.T) {
+	i32, i64 := &wasm.GlobalType{ValType: wasm.ValueTypeI32}, &wasm.GlobalType{ValType: wasm.ValueTypeI64}
+	tests := []struct {
+		name       string
+		input      string
+		expected   *wasm.Global
+		expectedID string
+	}{
+		{
+			name:  "i32.const",
+			input: "(global i32 i32.const 1)",
+			expected: &wasm.Global{
+				Type: i32, Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{1}},
+			},
+		},
+		{
+			name:  "i32.const folded",
+			input: "(global i32 (i32.const 1))",
+			expected: &wasm.Global{
+				Type: i32, Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{1}},
+			},
+		},
+		{
+			name:  "mutable ID",
+			input: "(global $g (mut i64) (i64.const 2))",
+			expected: &wasm.Global{
+				Type: &wasm.GlobalType{ValType: wasm.ValueTypeI64, Mutable: true},
+				Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeI64Const, Data: []byte{2}},
+			},
+			expectedID: "g",
+		},
+		{
+			name:  "f64.const",
+			input: "(global f64 (f64.const 2))",
+			expected: &wasm.Global{
+				Type: &wasm.GlobalType{ValType: wasm.ValueTypeF64},
+				Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeF64Const, Data: []byte{0, 0, 0, 0, 0, 0, 0, 0x40}},
+			},
+		},
+		{
+			name:  "global.get",
+			input: "(global i32 (global.get 0))",
+			expected: &wasm.Global{
+				Type: i32, Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeGlobalGet, Data: []byte{0}},
+			},
+		},
+		{
+			name:  "extended flat",
+			input: "(global i32 global.get 0 i32.const 16 i32.add)",
+			expected: &wasm.Global{
+				Type: i32, Init: &wasm.ConstantExpression{
+					Opcode: wasm.OpcodeI32Add,
+					Data:   []byte{wasm.OpcodeGlobalGet, 0, wasm.OpcodeI32Const, 16},
+				},
+			},
+		},
+		{
+			name:  "extended folded",
+			input: "(global i32 (i32.add (global.get 0) (i32.const 16)))",
+			expected: &wasm.Global{
+				Type: i32, Init: &wasm.ConstantExpression{
+					Opcode: wasm.OpcodeI32Add,
+					Data:   []byte{wasm.OpcodeGlobalGet, 0, wasm.OpcodeI32Const, 16},
+				},
+			},
+		},
+		{
+			name:  "extended nested",
+			input: "(global i64 (i64.sub (i64.mul (i64.const 2) (i64.const 3)) (i64.const 1)))",
+			expected: &wasm.Global{
+				Type: i64, Init: &wasm.ConstantExpression{
+					Opcode: wasm.OpcodeI64Sub,
+					Data: []byte{
+						wasm.OpcodeI64Const, 2, wasm.OpcodeI64Const, 3, wasm.OpcodeI64Mul,
+						wasm.OpcodeI64Const, 1,
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			globalNamespace := newIndexNamespace(func(sectionID wasm.SectionID) uint32 {
+				require.Equal(t, wasm.SectionIDGlobal, sectionID)
+				return 0
+			})
+			parsed, tp, err := parseGlobal(wasm.FeatureExtendedConst, globalNamespace, tc.input)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, parsed)
+			require.Equal(t, uint32(1), tp.globalNamespace.count)
+			if tc.expectedID == "" {
+				require.Zero(t, len(tp.globalNamespace.idToIdx), "expected no indices")
+			} else {
+				// Since the parser was initially empty, the expected index of the parsed global is 0
+				require.Equal(t, map[string]wasm.Index{tc.expectedID: wasm.Index(0)}, tp.globalNamespace.idToIdx)
+			}
+		})
+	}
+
+	t.Run("global.get ID", func(t *testing.T) {
+		globalNamespace := newIndexNamespace(func(sectionID wasm.SectionID) uint32 {
+			require.Equal(t, wasm.SectionIDGlobal, sectionID)
+			return 0
+		})
+		_, err := globalNamespace.setID([]byte("$base"))
+		require.NoError(t, err)
+		globalNamespace.count++
+
+		parsed, _, err := parseGlobal(wasm.FeatureExtendedConst, globalNamespace, "(global i32 (i32.mul (global.get $base) (i32.const 2)))")
+		require.NoError(t, err)
+		require.Equal(t, &wasm.ConstantExpression{
+			Opcode: wasm.OpcodeI32Mul,
+			Data:   []byte{wasm.OpcodeGlobalGet, 0, wasm.OpcodeI32Const, 2},
+		}, parsed.Init)
+	})
+}
+
+func TestGlobalParser_Errors(t *testing.T) {
+	tests := []struct {
+		name, input, expectedErr string
+		enabledFeatures          wasm.Features
+	}{
+		{
+			name:        "missing type",
+			input:       "(global)",
+			expectedErr: "missing type",
+		},
+		{
+			name:        "redundant ID",
+			input:       "(global $g $h i32 (i32.const 1))",
+			expectedErr: "redundant ID $h",
+		},
+		{
+			name:        "unknown type",
+			input:       "(global i33 (i32.const 1))",
+			expectedErr: "unknown type: i33",
+		},
+		{
+			name:        "not mut",
+			input:       "(global (const i32) (i32.const 1))",
+			expectedErr: "unexpected field: const",
+		},
+		{
+			name:        "missing init",
+			input:       "(global i32)",
+			expectedErr: "missing init",
+		},
+		{
+			name:        "unsupported instruction",
+			input:       "(global i32 (i32.div_s (i32.const 1) (i32.const 2)))",
+			expectedErr: "unsupported instruction in constant expression: i32.div_s",
+		},
+		{
+			name:        "global.get unknown ID",
+			input:       "(global i32 (global.get $g))",
+			expectedErr: "unknown ID $g",
+		},
+		{
+			name:        "global.get missing index",
+			input:       "(global i32 (global.get))",
+			expectedErr: "missing index",
+		},
+		{
+			name:            "extended-const disabled",
+			input:           "(global i32 (i32.add (i32.const 1) (i32.const 2)))",
+			enabledFeatures: wasm.Features20220419,
+			expectedErr:     "i32.add invalid as feature \"extended-const\" is disabled",
+		},
+		{
+			name:            "not ending with arithmetic",
+			input:           "(global i32 (i32.const 1) (i32.const 2))",
+			enabledFeatures: wasm.FeatureExtendedConst,
+			expectedErr:     "constant expression must end with an arithmetic instruction, but was i32.const",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			globalNamespace := newIndexNamespace(func(sectionID wasm.SectionID) uint32 {
+				require.Equal(t, wasm.SectionIDGlobal, sectionID)
+				return 0
+			})
+			parsed, _, err := parseGlobal(tc.enabledFeatures, globalNamespace, tc.input)
+			require.EqualError(t, err, tc.expectedErr)
+			require.Nil(t, parsed)
+		})
+	}
+}
+
+func parseGlobal(enabledFeatures wasm.Features, globalNamespace *indexNamespace, input string) (*wasm.Global, *globalParser, error) {
+	var parsed *wasm.Global
+	var setFunc onGlobal = func(g *wasm.Global) tokenParser {
+		parsed = g
+		return parseErr
+	}
+	tp := newGlobalParser(enabledFeatures, globalNamespace, setFunc)
+	// globalParser starts after the '(global', so we need to eat it first!
+	_, _, err := lex(skipTokens(2, tp.begin), []byte(input))
+	return parsed, tp, err
+}
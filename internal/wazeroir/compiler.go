@@ -23,6 +23,10 @@ const (
 	controlFrameKindLoop
 	controlFrameKindIfWithElse
 	controlFrameKindIfWithoutElse
+	// controlFrameKindTry is a try block in its body, before any catch clause.
+	controlFrameKindTry
+	// controlFrameKindCatch is a try block in one of its catch clauses.
+	controlFrameKindCatch
 )
 
 type (
@@ -33,6 +37,9 @@ type (
 		originalStackLenWithoutParam int
 		blockType                    *wasm.FunctionType
 		kind                         controlFrameKind
+		// tryIndex is the index in CompilationResult.TryBlocks when the kind is controlFrameKindTry or
+		// controlFrameKindCatch.
+		tryIndex uint32
 	}
 	controlFrames struct{ frames []*controlFrame }
 )
@@ -57,7 +64,9 @@ func (c *controlFrame) asBranchTarget() *BranchTarget {
 		// Note nil target is translated as return.
 		return &BranchTarget{Label: nil}
 	case controlFrameKindIfWithElse,
-		controlFrameKindIfWithoutElse:
+		controlFrameKindIfWithoutElse,
+		controlFrameKindTry,
+		controlFrameKindCatch:
 		return &BranchTarget{Label: &Label{FrameID: c.frameID, Kind: LabelKindContinuation}}
 	}
 	panic(fmt.Sprintf("unreachable: a bug in wazeroir implementation: %v", c.kind))
@@ -87,6 +96,19 @@ func (c *controlFrames) top() *controlFrame {
 	return c.frames[len(c.frames)-1]
 }
 
+// tryIndex returns the index in CompilationResult.TryBlocks of the innermost try block in its body at or outward from
+// the n-th frame, or -1 if there is none, in which case exceptions thrown there are thrown to the caller.
+//
+// Note: Exceptions thrown in a catch clause aren't handled by the same try block, so only bodies are considered.
+func (c *controlFrames) tryIndex(n int) int {
+	for ; n < len(c.frames); n++ {
+		if frame := c.get(n); frame.kind == controlFrameKindTry {
+			return int(frame.tryIndex)
+		}
+	}
+	return -1
+}
+
 func (c *controlFrames) empty() bool {
 	return len(c.frames) == 0
 }
@@ -128,7 +150,13 @@ func (c *compiler) calcLocalIndexToStackHeight() {
 }
 
 type compiler struct {
-	enabledFeatures  wasm.Features
+	enabledFeatures wasm.Features
+	// meterFuel is true when each basic block begins with OperationConsumeFuel.
+	meterFuel bool
+	// consumeFuel is OperationConsumeFuel of the current basic block, incremented on each emitted operation.
+	consumeFuel *OperationConsumeFuel
+	// coverage is true when each basic block begins with OperationCoverBlock.
+	coverage         bool
 	stack            []UnsignedType
 	currentID        uint32
 	controlFrames    *controlFrames
@@ -136,8 +164,10 @@ type compiler struct {
 		on    bool
 		depth int
 	}
-	pc     uint64
-	result CompilationResult
+	pc uint64
+	// instructionOffset is the value of pc at the beginning of the current instruction.
+	instructionOffset uint64
+	result            CompilationResult
 
 	// body holds the code for the function's body where Wasm instructions are stored.
 	body []byte
@@ -155,6 +185,8 @@ type compiler struct {
 	funcs []uint32
 	// globals holds the global types for all declard globas in the module where the targe function exists.
 	globals []*wasm.GlobalType
+	// memories holds the types of all declared memories in the module where the target function exists.
+	memories []*wasm.Memory
 }
 
 // For debugging only.
@@ -178,6 +210,15 @@ func (c *compiler) resetUnreachable() {
 type CompilationResult struct {
 	// Operations holds wazeroir operations compiled from Wasm instructions in a Wasm function.
 	Operations []Operation
+	// InstructionOffsets are index-correlated with Operations, and hold the offset in the function body of the Wasm
+	// instruction each operation is compiled from. Operations preceding the first instruction, such as initializing
+	// locals, have offset zero.
+	//
+	// Note: This allows engines to report the instruction executing in each frame of a stack trace.
+	InstructionOffsets []uint64
+	// BlockOffsets are the offsets in the function body of the first Wasm instruction of each basic block, indexed by
+	// OperationCoverBlock.Block, or nil if coverage is disabled. The first basic block is the function entry.
+	BlockOffsets []uint64
 	// LabelCallers maps Label.String() to the number of callers to that label.
 	// Here "callers" means that the call-sites which jumps to the label with br, br_if or br_table
 	// instructions.
@@ -191,6 +232,10 @@ type CompilationResult struct {
 	//
 	// This example the label corresponding to `(block i32.const 1111)` is never be reached at runtime because `br 0` exits the function before we reach there
 	LabelCallers map[string]uint32
+	// LoopLabels is the set of Label.String() of the labels placed at loop headers, or nil if there are no loops.
+	//
+	// Note: This allows engines to find backward branch targets, e.g. to periodically check for termination.
+	LoopLabels map[string]struct{}
 
 	// Signature is the function type of the compilation target function.
 	Signature *wasm.FunctionType
@@ -202,6 +247,11 @@ type CompilationResult struct {
 	Types []*wasm.FunctionType
 	// TableTypes holds all the reference types of all tables declared in the module.
 	TableTypes []wasm.ValueType
+	// Memories holds all the declarations of memories in the module from which this function is compiled, including
+	// imported ones.
+	//
+	// Note: This allows engines to know which memories are addressed with i64 values. See wasm.Memory Is64
+	Memories []*wasm.Memory
 	// HasMemory is true if the module from which this function is compiled has memory declaration.
 	HasMemory bool
 	// HasTable is true if the module from which this function is compiled has table declaration.
@@ -210,17 +260,45 @@ type CompilationResult struct {
 	NeedsAccessToDataInstances bool
 	// NeedsAccessToDataInstances is true if the function needs access to element instances via table.init or elem.drop instructions.
 	NeedsAccessToElementInstances bool
+	// Tags holds the type indexes of all the tags in the module, including imported ones.
+	Tags []wasm.Index
+	// TryBlocks are the try blocks of the function, indexed by OperationTry.Index, or nil if there are none.
+	TryBlocks []*TryBlock
+}
+
+// TryBlock is a try block of the exception handling proposal (wasm.FeatureExceptionHandling), whose body is between
+// OperationTry and OperationTryEnd.
+//
+// Engines handle an exception thrown in a body by the innermost try block which includes it: if it delegates, the
+// exception is handled by DelegateTarget instead. Otherwise, it jumps to the first OperationCatch of the try block
+// whose tag matches the exception, or its OperationCatchAll. If none does, the exception is handled by the Parent.
+type TryBlock struct {
+	// Parent is the index of the innermost try block whose body includes this one, or -1 if there is none.
+	Parent int
+	// StackHeight is the height of the stack below the try block in uint64, including locals, which is where the
+	// handle and values of a caught exception are pushed.
+	StackHeight int
+	// Delegate is true when the try block ends with wasm.OpcodeDelegateName, so has no catch clauses.
+	Delegate bool
+	// DelegateTarget is the index of the try block which handles the exceptions delegated by this one, or -1 to throw
+	// them to the caller.
+	DelegateTarget int
 }
 
-func CompileFunctions(_ context.Context, enabledFeatures wasm.Features, module *wasm.Module) ([]*CompilationResult, error) {
+// CompileFunctions lowers all the functions defined in the module into wazeroir operations.
+//
+// When meterFuel is true, each basic block begins with OperationConsumeFuel, which costs the number of operations in it.
+// When coverage is true, each basic block begins with OperationCoverBlock, and CompilationResult.BlockOffsets is set.
+func CompileFunctions(_ context.Context, enabledFeatures wasm.Features, meterFuel, coverage bool, module *wasm.Module) ([]*CompilationResult, error) {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
-	functions, globals, mem, tables, err := module.AllDeclarations()
+	functions, globals, memories, tables, err := module.AllDeclarations()
 	if err != nil {
 		return nil, err
 	}
 
-	hasMemory, hasTable := mem != nil, len(tables) > 0
+	hasMemory, hasTable := len(memories) > 0, len(tables) > 0
+	tags := module.AllTags()
 
 	tableTypes := make([]wasm.ValueType, len(tables))
 	for i := range tableTypes {
@@ -232,7 +310,7 @@ func CompileFunctions(_ context.Context, enabledFeatures wasm.Features, module *
 		typeID := module.FunctionSection[funcIndex]
 		sig := module.TypeSection[typeID]
 		code := module.CodeSection[funcIndex]
-		r, err := compile(enabledFeatures, sig, code.Body, code.LocalTypes, module.TypeSection, functions, globals)
+		r, err := compile(enabledFeatures, meterFuel, coverage, sig, code.Body, code.LocalTypes, module.TypeSection, functions, globals, memories, tags)
 		if err != nil {
 			return nil, fmt.Errorf("failed to lower func[%d/%d] to wazeroir: %w", funcIndex, len(functions)-1, err)
 		}
@@ -243,6 +321,7 @@ func CompileFunctions(_ context.Context, enabledFeatures wasm.Features, module *
 		r.HasTable = hasTable
 		r.Signature = sig
 		r.TableTypes = tableTypes
+		r.Memories = memories
 		ret = append(ret, r)
 	}
 	return ret, nil
@@ -252,26 +331,40 @@ func CompileFunctions(_ context.Context, enabledFeatures wasm.Features, module *
 // so that the resulting operations can be consumed by the interpreter
 // or the Compiler compilation engine.
 func compile(enabledFeatures wasm.Features,
+	meterFuel, coverage bool,
 	sig *wasm.FunctionType,
 	body []byte,
 	localTypes []wasm.ValueType,
 	types []*wasm.FunctionType,
 	functions []uint32, globals []*wasm.GlobalType,
+	memories []*wasm.Memory,
+	tags []wasm.Index,
 ) (*CompilationResult, error) {
 	c := compiler{
 		enabledFeatures: enabledFeatures,
+		meterFuel:       meterFuel,
+		coverage:        coverage,
 		controlFrames:   &controlFrames{},
-		result:          CompilationResult{LabelCallers: map[string]uint32{}},
+		result:          CompilationResult{LabelCallers: map[string]uint32{}, Tags: tags},
 		body:            body,
 		localTypes:      localTypes,
 		sig:             sig,
 		globals:         globals,
+		memories:        memories,
 		funcs:           functions,
 		types:           types,
 	}
 
 	c.calcLocalIndexToStackHeight()
 
+	if meterFuel {
+		// The function body is the first basic block.
+		c.emitConsumeFuel()
+	}
+	if coverage {
+		c.emitCoverBlock()
+	}
+
 	// Push function arguments.
 	for _, t := range sig.Params {
 		c.stackPush(wasmValueTypeToUnsignedType(t)...)
@@ -297,6 +390,13 @@ func compile(enabledFeatures wasm.Features,
 			return nil, fmt.Errorf("handling instruction: %w", err)
 		}
 	}
+
+	if meterFuel {
+		c.removeFreeBasicBlockFuel()
+	}
+	if coverage {
+		c.numberCoverBlocks()
+	}
 	return &c.result, nil
 }
 
@@ -304,6 +404,7 @@ func compile(enabledFeatures wasm.Features,
 // and emit the results into c.results.
 func (c *compiler) handleInstruction() error {
 	op := c.body[c.pc]
+	c.instructionOffset = c.pc
 	if buildoptions.IsDebugMode {
 		fmt.Printf("handling %s, unreachable_state(on=%v,depth=%d)\n",
 			wasm.InstructionName(op),
@@ -379,6 +480,10 @@ operatorSwitch:
 		// Prep labels for inside and the continuation of this loop.
 		loopLabel := &Label{FrameID: frame.frameID, Kind: LabelKindHeader}
 		c.result.LabelCallers[loopLabel.String()]++
+		if c.result.LoopLabels == nil {
+			c.result.LoopLabels = map[string]struct{}{}
+		}
+		c.result.LoopLabels[loopLabel.String()] = struct{}{}
 
 		// Emit the branch operation to enter inside the loop.
 		c.emit(
@@ -429,6 +534,163 @@ operatorSwitch:
 				Label: thenLabel,
 			},
 		)
+	case wasm.OpcodeTry:
+		bt, num, err := wasm.DecodeBlockType(c.types, bytes.NewReader(c.body[c.pc+1:]), c.enabledFeatures)
+		if err != nil {
+			return fmt.Errorf("reading block type for try instruction: %w", err)
+		}
+		c.pc += num
+
+		if c.unreachableState.on {
+			// If it is currently in unreachable,
+			// just remove the entire block.
+			c.unreachableState.depth++
+			break operatorSwitch
+		}
+
+		// Create a new frame -- entering try.
+		frame := &controlFrame{
+			frameID:                      c.nextID(),
+			originalStackLenWithoutParam: len(c.stack) - len(bt.Params),
+			kind:                         controlFrameKindTry,
+			blockType:                    bt,
+			tryIndex:                     uint32(len(c.result.TryBlocks)),
+		}
+		c.result.TryBlocks = append(c.result.TryBlocks, &TryBlock{
+			Parent:         c.controlFrames.tryIndex(0),
+			StackHeight:    c.stackLenInUint64(frame.originalStackLenWithoutParam),
+			DelegateTarget: -1,
+		})
+		c.controlFrames.push(frame)
+		c.emit(
+			&OperationTry{Index: frame.tryIndex},
+		)
+	case wasm.OpcodeCatch, wasm.OpcodeCatchAll:
+		var tagIndex uint32
+		if op == wasm.OpcodeCatch {
+			v, n, err := leb128.DecodeUint32(bytes.NewReader(c.body[c.pc+1:]))
+			if err != nil {
+				return fmt.Errorf("read the tag for catch: %w", err)
+			}
+			c.pc += n
+			tagIndex = v
+		}
+
+		if c.unreachableState.on && c.unreachableState.depth > 0 {
+			// If it is currently in unreachable, and the nested try,
+			// just remove the entire catch clause.
+			break operatorSwitch
+		}
+
+		frame := c.controlFrames.top()
+		continuationLabel := &Label{FrameID: frame.frameID, Kind: LabelKindContinuation}
+		// The body or the previous catch clause branches into the continuation, unless its end is unreachable.
+		exit := []Operation{
+			&OperationDrop{Depth: c.getFrameDropRange(frame, true)},
+			&OperationBr{Target: continuationLabel.asBranchTarget()},
+		}
+		if !c.unreachableState.on {
+			c.result.LabelCallers[continuationLabel.String()]++
+		}
+		if frame.kind == controlFrameKindTry {
+			// End the body, regardless of whether it is reachable.
+			wasUnreachable := c.unreachableState.on
+			c.resetUnreachable()
+			c.emit(&OperationTryEnd{Index: frame.tryIndex})
+			c.unreachableState.on = wasUnreachable
+			frame.kind = controlFrameKindCatch
+		}
+		c.emit(exit...)
+		// Catch clauses are only reachable by exceptions.
+		c.resetUnreachable()
+
+		// The catch clause begins with the handle and values of the exception, instead of the block params.
+		c.stack = c.stack[:frame.originalStackLenWithoutParam]
+		c.stackPush(UnsignedTypeI64)
+		if op == wasm.OpcodeCatch {
+			for _, t := range c.types[c.result.Tags[tagIndex]].Params {
+				c.stackPush(wasmValueTypeToUnsignedType(t)...)
+			}
+			c.emit(
+				&OperationCatch{TryIndex: frame.tryIndex, TagIndex: tagIndex},
+			)
+		} else {
+			c.emit(
+				&OperationCatchAll{TryIndex: frame.tryIndex},
+			)
+		}
+	case wasm.OpcodeThrow:
+		tagIndex, n, err := leb128.DecodeUint32(bytes.NewReader(c.body[c.pc+1:]))
+		if err != nil {
+			return fmt.Errorf("read the tag for throw: %w", err)
+		}
+		c.pc += n
+		c.emit(
+			&OperationThrow{TagIndex: tagIndex},
+		)
+		// Throw operation is stack-polymorphic, and mark the state as unreachable.
+		c.markUnreachable()
+	case wasm.OpcodeRethrow:
+		targetIndex, n, err := leb128.DecodeUint32(bytes.NewReader(c.body[c.pc+1:]))
+		if err != nil {
+			return fmt.Errorf("read the target for rethrow: %w", err)
+		}
+		c.pc += n
+
+		if c.unreachableState.on {
+			break operatorSwitch
+		}
+
+		// The handle of the exception is the first value of the catch clause.
+		targetFrame := c.controlFrames.get(int(targetIndex))
+		height := c.result.TryBlocks[targetFrame.tryIndex].StackHeight
+		c.emit(
+			&OperationPick{Depth: c.stackLenInUint64(len(c.stack)) - 1 - height},
+			&OperationRethrow{},
+		)
+		// Rethrow operation is stack-polymorphic, and mark the state as unreachable.
+		c.markUnreachable()
+	case wasm.OpcodeDelegate:
+		targetIndex, n, err := leb128.DecodeUint32(bytes.NewReader(c.body[c.pc+1:]))
+		if err != nil {
+			return fmt.Errorf("read the target for delegate: %w", err)
+		}
+		c.pc += n
+
+		if c.unreachableState.on && c.unreachableState.depth > 0 {
+			c.unreachableState.depth--
+			break operatorSwitch
+		}
+
+		// Delegate ends the try block like OpcodeEnd, except that it has no catch clauses.
+		frame := c.controlFrames.pop()
+		tryBlock := c.result.TryBlocks[frame.tryIndex]
+		tryBlock.Delegate = true
+		// The target is relative to the frames enclosing the try block.
+		tryBlock.DelegateTarget = c.controlFrames.tryIndex(int(targetIndex))
+
+		continuationLabel := &Label{FrameID: frame.frameID, Kind: LabelKindContinuation}
+		exit := []Operation{
+			&OperationDrop{Depth: c.getFrameDropRange(frame, true)},
+			&OperationBr{Target: continuationLabel.asBranchTarget()},
+		}
+		if !c.unreachableState.on {
+			c.result.LabelCallers[continuationLabel.String()]++
+		}
+		wasUnreachable := c.unreachableState.on
+		c.resetUnreachable()
+		c.emit(&OperationTryEnd{Index: frame.tryIndex})
+		if !wasUnreachable {
+			c.emit(exit...)
+		}
+
+		c.stack = c.stack[:frame.originalStackLenWithoutParam]
+		for _, t := range frame.blockType.Results {
+			c.stackPush(wasmValueTypeToUnsignedType(t)...)
+		}
+		c.emit(
+			&OperationLabel{Label: continuationLabel},
+		)
 	case wasm.OpcodeElse:
 		frame := c.controlFrames.top()
 		if c.unreachableState.on && c.unreachableState.depth > 0 {
@@ -505,6 +767,11 @@ operatorSwitch:
 			}
 
 			continuationLabel := &Label{FrameID: frame.frameID, Kind: LabelKindContinuation}
+			if frame.kind == controlFrameKindTry {
+				c.emit(
+					&OperationTryEnd{Index: frame.tryIndex},
+				)
+			}
 			if frame.kind == controlFrameKindIfWithoutElse {
 				// Emit the else label.
 				elseLabel := &Label{Kind: LabelKindElse, FrameID: frame.frameID}
@@ -563,10 +830,21 @@ operatorSwitch:
 				&OperationLabel{Label: continuationLabel},
 			)
 		case controlFrameKindBlockWithContinuationLabel,
-			controlFrameKindIfWithElse:
+			controlFrameKindIfWithElse,
+			controlFrameKindCatch:
+			continuationLabel := &Label{Kind: LabelKindContinuation, FrameID: frame.frameID}
+			c.result.LabelCallers[continuationLabel.String()]++
+			c.emit(
+				dropOp,
+				&OperationBr{Target: continuationLabel.asBranchTarget()},
+				&OperationLabel{Label: continuationLabel},
+			)
+		case controlFrameKindTry:
+			// This try block has no catch clauses, so its exceptions are handled by the enclosing ones.
 			continuationLabel := &Label{Kind: LabelKindContinuation, FrameID: frame.frameID}
 			c.result.LabelCallers[continuationLabel.String()]++
 			c.emit(
+				&OperationTryEnd{Index: frame.tryIndex},
 				dropOp,
 				&OperationBr{Target: continuationLabel.asBranchTarget()},
 				&OperationLabel{Label: continuationLabel},
@@ -706,6 +984,37 @@ operatorSwitch:
 		c.emit(
 			&OperationCallIndirect{TypeIndex: *index, TableIndex: tableIndex},
 		)
+	case wasm.OpcodeReturnCall:
+		if index == nil {
+			return fmt.Errorf("index does not exist for tail call")
+		}
+		params := c.types[c.funcs[*index]].ParamNumInUint64
+		// Cleanup the stack below the parameters, so that the called function replaces the current one.
+		c.emit(
+			&OperationDrop{Depth: c.getTailCallDropRange(params)},
+			&OperationTailCall{FunctionIndex: *index},
+		)
+
+		// Tail call operation is stack-polymorphic, like return.
+		c.markUnreachable()
+	case wasm.OpcodeReturnCallIndirect:
+		if index == nil {
+			return fmt.Errorf("index does not exist for indirect tail call")
+		}
+		tableIndex, n, err := leb128.DecodeUint32(bytes.NewReader(c.body[c.pc+1:]))
+		if err != nil {
+			return fmt.Errorf("read table index for return_call_indirect: %w", err)
+		}
+		c.pc += n
+		// +1 for the offset in the table, which is above the parameters.
+		params := c.types[*index].ParamNumInUint64 + 1
+		c.emit(
+			&OperationDrop{Depth: c.getTailCallDropRange(params)},
+			&OperationTailCallIndirect{TypeIndex: *index, TableIndex: tableIndex},
+		)
+
+		// Tail call operation is stack-polymorphic, like return.
+		c.markUnreachable()
 	case wasm.OpcodeDrop:
 		c.emit(
 			&OperationDrop{Depth: &InclusiveRange{Start: 0, End: 0}},
@@ -982,14 +1291,20 @@ operatorSwitch:
 			&OperationStore32{Arg: imm},
 		)
 	case wasm.OpcodeMemorySize:
-		c.pc++ // Skip the reserved one byte.
+		memoryIndex, err := c.readMemoryIndex()
+		if err != nil {
+			return fmt.Errorf("reading memory index for memory.size: %w", err)
+		}
 		c.emit(
-			&OperationMemorySize{},
+			&OperationMemorySize{MemoryIndex: memoryIndex},
 		)
 	case wasm.OpcodeMemoryGrow:
-		c.pc++ // Skip the reserved one byte.
+		memoryIndex, err := c.readMemoryIndex()
+		if err != nil {
+			return fmt.Errorf("reading memory index for memory.grow: %w", err)
+		}
 		c.emit(
-			&OperationMemoryGrow{},
+			&OperationMemoryGrow{MemoryIndex: memoryIndex},
 		)
 	case wasm.OpcodeI32Const:
 		val, num, err := leb128.DecodeInt32(bytes.NewReader(c.body[c.pc+1:]))
@@ -1613,9 +1928,13 @@ operatorSwitch:
 			if err != nil {
 				return fmt.Errorf("reading i32.const value: %v", err)
 			}
-			c.pc += num + 1 // +1 to skip the memory index which is fixed to zero.
+			c.pc += num
+			memoryIndex, err := c.readMemoryIndex()
+			if err != nil {
+				return fmt.Errorf("reading memory index for %s: %w", wasm.OpcodeMemoryInitName, err)
+			}
 			c.emit(
-				&OperationMemoryInit{DataIndex: dataIndex},
+				&OperationMemoryInit{DataIndex: dataIndex, MemoryIndex: memoryIndex},
 			)
 			c.result.NeedsAccessToDataInstances = true
 		case wasm.OpcodeMiscDataDrop:
@@ -1629,14 +1948,24 @@ operatorSwitch:
 			)
 			c.result.NeedsAccessToDataInstances = true
 		case wasm.OpcodeMiscMemoryCopy:
-			c.pc += 2 // +2 to skip two memory indexes which are fixed to zero.
+			dst, err := c.readMemoryIndex()
+			if err != nil {
+				return fmt.Errorf("reading destination memory index for %s: %w", wasm.OpcodeMemoryCopyName, err)
+			}
+			src, err := c.readMemoryIndex()
+			if err != nil {
+				return fmt.Errorf("reading source memory index for %s: %w", wasm.OpcodeMemoryCopyName, err)
+			}
 			c.emit(
-				&OperationMemoryCopy{},
+				&OperationMemoryCopy{SrcMemoryIndex: src, DstMemoryIndex: dst},
 			)
 		case wasm.OpcodeMiscMemoryFill:
-			c.pc += 1 // +1 to skip the memory index which is fixed to zero.
+			memoryIndex, err := c.readMemoryIndex()
+			if err != nil {
+				return fmt.Errorf("reading memory index for %s: %w", wasm.OpcodeMemoryFillName, err)
+			}
 			c.emit(
-				&OperationMemoryFill{},
+				&OperationMemoryFill{MemoryIndex: memoryIndex},
 			)
 		case wasm.OpcodeMiscTableInit:
 			elemIndex, num, err := leb128.DecodeUint32(bytes.NewReader(c.body[c.pc+1:]))
@@ -1720,6 +2049,13 @@ operatorSwitch:
 		}
 	case wasm.OpcodeVecPrefix:
 		c.pc++
+		if relaxedOp, ok := wasm.DecodeOpcodeVecRelaxed(c.body[c.pc:]); ok {
+			c.pc++ // The opcode of relaxed vector instructions takes two bytes.
+			c.emit(
+				relaxedVectorOperation(relaxedOp),
+			)
+			break
+		}
 		switch vecOp := c.body[c.pc]; vecOp {
 		case wasm.OpcodeVecV128Const:
 			c.pc++
@@ -2375,6 +2711,55 @@ operatorSwitch:
 		default:
 			return fmt.Errorf("unsupported vector instruction in wazeroir: %s", wasm.VectorInstructionName(vecOp))
 		}
+	case wasm.OpcodeAtomicPrefix:
+		c.pc++
+		atomicOp := c.body[c.pc]
+		if atomicOp == wasm.OpcodeAtomicFence {
+			c.pc++ // Skip the reserved zero byte.
+			c.emit(
+				&OperationAtomicFence{},
+			)
+			break
+		}
+
+		arg, err := c.readMemoryArg(wasm.AtomicInstructionName(atomicOp))
+		if err != nil {
+			return err
+		}
+		size, vt := wasm.AtomicOperand(atomicOp)
+		t := UnsignedInt32
+		if vt == wasm.ValueTypeI64 {
+			t = UnsignedInt64
+		}
+		switch {
+		case atomicOp == wasm.OpcodeAtomicMemoryNotify:
+			c.emit(
+				&OperationMemoryNotify{Arg: arg},
+			)
+		case atomicOp == wasm.OpcodeAtomicMemoryWait32 || atomicOp == wasm.OpcodeAtomicMemoryWait64:
+			c.emit(
+				&OperationMemoryWait{Type: t, Arg: arg},
+			)
+		case atomicOp <= wasm.OpcodeAtomicI64Load32U:
+			c.emit(
+				&OperationAtomicLoad{Type: t, Size: size, Arg: arg},
+			)
+		case atomicOp <= wasm.OpcodeAtomicI64Store32:
+			c.emit(
+				&OperationAtomicStore{Type: t, Size: size, Arg: arg},
+			)
+		case atomicOp < wasm.OpcodeAtomicI32RmwCmpxchg:
+			// Read-modify-write operations are groups of 7 variants in the order of AtomicRMWOp.
+			c.emit(
+				&OperationAtomicRMW{Op: AtomicRMWOp((atomicOp - wasm.OpcodeAtomicI32RmwAdd) / 7), Type: t, Size: size, Arg: arg},
+			)
+		case atomicOp <= wasm.OpcodeAtomicI64Rmw32CmpxchgU:
+			c.emit(
+				&OperationAtomicCmpxchg{Type: t, Size: size, Arg: arg},
+			)
+		default:
+			return fmt.Errorf("unsupported atomic instruction in wazeroir: %s", wasm.AtomicInstructionName(atomicOp))
+		}
 	default:
 		return fmt.Errorf("unsupported instruction in wazeroir: 0x%x", op)
 	}
@@ -2384,6 +2769,50 @@ operatorSwitch:
 	return nil
 }
 
+// relaxedVectorOperation returns the operation of the relaxed vector instruction, which fixes its result. The result
+// chosen for some is that of another vector instruction, so they are compiled to the same operation.
+func relaxedVectorOperation(op wasm.OpcodeVecRelaxed) Operation {
+	switch op {
+	case wasm.OpcodeVecI8x16RelaxedSwizzle:
+		// Indices out of range select zero, like wasm.OpcodeVecI8x16SwizzleName.
+		return &OperationV128Swizzle{}
+	case wasm.OpcodeVecI32x4RelaxedTruncF32x4S:
+		return &OperationV128RelaxedTrunc{OriginShape: ShapeF32x4, Signed: true}
+	case wasm.OpcodeVecI32x4RelaxedTruncF32x4U:
+		return &OperationV128RelaxedTrunc{OriginShape: ShapeF32x4}
+	case wasm.OpcodeVecI32x4RelaxedTruncF64x2SZero:
+		return &OperationV128RelaxedTrunc{OriginShape: ShapeF64x2, Signed: true}
+	case wasm.OpcodeVecI32x4RelaxedTruncF64x2UZero:
+		return &OperationV128RelaxedTrunc{OriginShape: ShapeF64x2}
+	case wasm.OpcodeVecF32x4RelaxedMadd:
+		return &OperationV128RelaxedMadd{Shape: ShapeF32x4}
+	case wasm.OpcodeVecF32x4RelaxedNmadd:
+		return &OperationV128RelaxedMadd{Shape: ShapeF32x4, Negate: true}
+	case wasm.OpcodeVecF64x2RelaxedMadd:
+		return &OperationV128RelaxedMadd{Shape: ShapeF64x2}
+	case wasm.OpcodeVecF64x2RelaxedNmadd:
+		return &OperationV128RelaxedMadd{Shape: ShapeF64x2, Negate: true}
+	case wasm.OpcodeVecI8x16RelaxedLaneselect, wasm.OpcodeVecI16x8RelaxedLaneselect,
+		wasm.OpcodeVecI32x4RelaxedLaneselect, wasm.OpcodeVecI64x2RelaxedLaneselect:
+		// Each bit of the mask selects, like wasm.OpcodeVecV128BitselectName, whether or not the lane is all ones.
+		return &OperationV128Bitselect{}
+	case wasm.OpcodeVecF32x4RelaxedMin:
+		return &OperationV128RelaxedMin{Shape: ShapeF32x4}
+	case wasm.OpcodeVecF32x4RelaxedMax:
+		return &OperationV128RelaxedMax{Shape: ShapeF32x4}
+	case wasm.OpcodeVecF64x2RelaxedMin:
+		return &OperationV128RelaxedMin{Shape: ShapeF64x2}
+	case wasm.OpcodeVecF64x2RelaxedMax:
+		return &OperationV128RelaxedMax{Shape: ShapeF64x2}
+	case wasm.OpcodeVecI16x8RelaxedQ15mulrS:
+		return &OperationV128RelaxedQ15mulr{}
+	case wasm.OpcodeVecI16x8RelaxedDotI8x16I7x16S:
+		return &OperationV128RelaxedDot{}
+	default: // wasm.OpcodeVecI32x4RelaxedDotI8x16I7x16AddS
+		return &OperationV128RelaxedDotAdd{}
+	}
+}
+
 func (c *compiler) nextID() (id uint32) {
 	id = c.currentID + 1
 	c.currentID++
@@ -2399,6 +2828,8 @@ func (c *compiler) applyToStack(opcode wasm.Opcode) (*uint32, error) {
 		// and it DOES affect the signature of opcode.
 		wasm.OpcodeCall,
 		wasm.OpcodeCallIndirect,
+		wasm.OpcodeReturnCall,
+		wasm.OpcodeReturnCallIndirect,
 		wasm.OpcodeLocalGet,
 		wasm.OpcodeLocalSet,
 		wasm.OpcodeLocalTee,
@@ -2428,6 +2859,7 @@ func (c *compiler) applyToStack(opcode wasm.Opcode) (*uint32, error) {
 	if err != nil {
 		return nil, err
 	}
+	s = c.memory64Signature(opcode, s)
 
 	// Manipulate the stack according to the signature.
 	// Note that the following algorithm assumes that
@@ -2491,12 +2923,97 @@ func (c *compiler) emit(ops ...Operation) {
 				}
 			}
 			c.result.Operations = append(c.result.Operations, op)
+			c.result.InstructionOffsets = append(c.result.InstructionOffsets, c.instructionOffset)
 			if buildoptions.IsDebugMode {
 				fmt.Printf("emitting ")
 				formatOperation(os.Stdout, op)
 			}
+			if c.meterFuel {
+				if beginsBasicBlock(op) {
+					c.emitConsumeFuel()
+				} else {
+					c.consumeFuel.Cost++
+				}
+			}
+			if c.coverage {
+				if beginsBasicBlock(op) {
+					c.emitCoverBlock()
+				}
+			}
+		}
+	}
+}
+
+// beginsBasicBlock returns true if the operation is where the code jumps to: labels are the only branch targets, and
+// catch clauses are where exceptions jump to.
+func beginsBasicBlock(op Operation) bool {
+	switch op.(type) {
+	case *OperationLabel, *OperationCatch, *OperationCatchAll:
+		return true
+	}
+	return false
+}
+
+// emitConsumeFuel begins a new basic block with OperationConsumeFuel, whose cost is incremented by the following emit.
+func (c *compiler) emitConsumeFuel() {
+	c.consumeFuel = &OperationConsumeFuel{}
+	c.result.Operations = append(c.result.Operations, c.consumeFuel)
+	c.result.InstructionOffsets = append(c.result.InstructionOffsets, c.instructionOffset)
+}
+
+// removeFreeBasicBlockFuel removes OperationConsumeFuel of basic blocks without any operation, e.g. when a label
+// immediately follows another one.
+func (c *compiler) removeFreeBasicBlockFuel() {
+	ops, offsets := c.result.Operations[:0], c.result.InstructionOffsets[:0]
+	for i, op := range c.result.Operations {
+		if o, ok := op.(*OperationConsumeFuel); ok && o.Cost == 0 {
+			continue
+		}
+		ops = append(ops, op)
+		offsets = append(offsets, c.result.InstructionOffsets[i])
+	}
+	c.result.Operations, c.result.InstructionOffsets = ops, offsets
+}
+
+// emitCoverBlock begins a new basic block with OperationCoverBlock, which is numbered by numberCoverBlocks.
+func (c *compiler) emitCoverBlock() {
+	c.result.Operations = append(c.result.Operations, &OperationCoverBlock{})
+	c.result.InstructionOffsets = append(c.result.InstructionOffsets, c.instructionOffset)
+}
+
+// numberCoverBlocks removes OperationCoverBlock of basic blocks without any operation, e.g. when a label immediately
+// follows another one, and numbers the others in order, setting CompilationResult.BlockOffsets.
+//
+// Note: The function entry is kept even if empty, so that its count is the number of calls.
+func (c *compiler) numberCoverBlocks() {
+	ops, offsets := c.result.Operations[:0], c.result.InstructionOffsets[:0]
+	for i, op := range c.result.Operations {
+		if o, ok := op.(*OperationCoverBlock); ok {
+			// Find the first operation of the block, skipping any fuel consumption.
+			next := i + 1
+			for ; next < len(c.result.Operations); next++ {
+				if _, ok = c.result.Operations[next].(*OperationConsumeFuel); !ok {
+					break
+				}
+			}
+
+			var empty bool
+			if next == len(c.result.Operations) {
+				empty, next = true, i
+			} else if _, ok = c.result.Operations[next].(*OperationLabel); ok {
+				empty = true
+			}
+
+			if empty && len(c.result.BlockOffsets) > 0 {
+				continue
+			}
+			o.Block = uint32(len(c.result.BlockOffsets))
+			c.result.BlockOffsets = append(c.result.BlockOffsets, c.result.InstructionOffsets[next])
 		}
+		ops = append(ops, op)
+		offsets = append(offsets, c.result.InstructionOffsets[i])
 	}
+	c.result.Operations, c.result.InstructionOffsets = ops, offsets
 }
 
 // Emit const expression with default values of the given type.
@@ -2568,6 +3085,15 @@ func (c *compiler) getFrameDropRange(frame *controlFrame, isEnd bool) *Inclusive
 	return nil
 }
 
+// getTailCallDropRange returns the range of all the values below the top ones of the given size in uint64, which are
+// the operands of a tail call, including locals. This must be called after the operands are popped from c.stack.
+func (c *compiler) getTailCallDropRange(operandsInUint64 int) *InclusiveRange {
+	if below := c.stackLenInUint64(len(c.stack)); below > 0 {
+		return &InclusiveRange{Start: operandsInUint64, End: operandsInUint64 + below - 1}
+	}
+	return nil
+}
+
 func (c *compiler) stackLenInUint64(ceil int) (ret int) {
 	for i := 0; i < ceil; i++ {
 		if c.stack[i] == UnsignedTypeV128 {
@@ -2586,10 +3112,30 @@ func (c *compiler) readMemoryArg(tag string) (*MemoryArg, error) {
 		return nil, fmt.Errorf("reading alignment for %s: %w", tag, err)
 	}
 	c.pc += num
-	offset, num, err := leb128.DecodeUint32(r)
+	var memoryIndex uint32
+	if alignment&wasm.MemoryArgMemoryIndexFlag != 0 { // Only valid with wasm.FeatureMultiMemory.
+		alignment &^= wasm.MemoryArgMemoryIndexFlag
+		if memoryIndex, num, err = leb128.DecodeUint32(r); err != nil {
+			return nil, fmt.Errorf("reading memory index for %s: %w", tag, err)
+		}
+		c.pc += num
+	}
+	// The offset is a u64 for memories with 64-bit indices, and validated to fit a u32 otherwise.
+	offset, num, err := leb128.DecodeUint64(r)
 	if err != nil {
 		return nil, fmt.Errorf("reading offset for %s: %w", tag, err)
 	}
 	c.pc += num
-	return &MemoryArg{Offset: offset, Alignment: alignment}, nil
+	return &MemoryArg{Offset: offset, Alignment: alignment, MemoryIndex: memoryIndex}, nil
+}
+
+// readMemoryIndex reads the memory index immediate of memory.size, memory.grow and bulk memory instructions, which is
+// a reserved zero byte unless wasm.FeatureMultiMemory is enabled.
+func (c *compiler) readMemoryIndex() (uint32, error) {
+	memoryIndex, num, err := leb128.DecodeUint32(bytes.NewReader(c.body[c.pc+1:]))
+	if err != nil {
+		return 0, err
+	}
+	c.pc += num
+	return memoryIndex, nil
 }
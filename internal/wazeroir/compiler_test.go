@@ -49,11 +49,12 @@ func TestCompile(t *testing.T) {
 				Operations: []Operation{ // begin with params: []
 					&OperationBr{Target: &BranchTarget{}}, // return!
 				},
-				LabelCallers: map[string]uint32{},
-				Functions:    []uint32{0},
-				Types:        []*wasm.FunctionType{{}},
-				Signature:    &wasm.FunctionType{},
-				TableTypes:   []wasm.RefType{},
+				InstructionOffsets: []uint64{0},
+				LabelCallers:       map[string]uint32{},
+				Functions:          []uint32{0},
+				Types:              []*wasm.FunctionType{{}},
+				Signature:          &wasm.FunctionType{},
+				TableTypes:         []wasm.RefType{},
 			},
 		},
 		{
@@ -67,7 +68,8 @@ func TestCompile(t *testing.T) {
 					&OperationDrop{Depth: &InclusiveRange{Start: 1, End: 1}}, // [$x]
 					&OperationBr{Target: &BranchTarget{}},                    // return!
 				},
-				LabelCallers: map[string]uint32{},
+				InstructionOffsets: []uint64{0, 2, 2},
+				LabelCallers:       map[string]uint32{},
 				Types: []*wasm.FunctionType{
 					{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32},
 						ParamNumInUint64:  1,
@@ -94,7 +96,8 @@ func TestCompile(t *testing.T) {
 					&OperationDrop{Depth: &InclusiveRange{Start: 1, End: 1}}, // [$old_size]
 					&OperationBr{Target: &BranchTarget{}},                    // return!
 				},
-				LabelCallers: map[string]uint32{},
+				InstructionOffsets: []uint64{0, 2, 4, 4},
+				LabelCallers:       map[string]uint32{},
 				Types: []*wasm.FunctionType{{
 					Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32},
 					ParamNumInUint64:  1,
@@ -120,7 +123,7 @@ func TestCompile(t *testing.T) {
 				enabledFeatures = wasm.Features20220419
 			}
 
-			res, err := CompileFunctions(ctx, enabledFeatures, tc.module)
+			res, err := CompileFunctions(ctx, enabledFeatures, false, false, tc.module)
 			require.NoError(t, err)
 			require.Equal(t, tc.expected, res[0])
 		})
@@ -162,6 +165,7 @@ func TestCompile_Block(t *testing.T) {
 					},
 					&OperationBr{Target: &BranchTarget{}}, // return!
 				},
+				InstructionOffsets: []uint64{2, 6, 7},
 				// Note: i32.add comes after br 0 so is unreachable. Compilation succeeds when it feels like it
 				// shouldn't because the br instruction is stack-polymorphic. In other words, (br 0) substitutes for the
 				// two i32 parameters to add.
@@ -171,6 +175,48 @@ func TestCompile_Block(t *testing.T) {
 				Signature:    v_v,
 				TableTypes:   []wasm.RefType{},
 			},
+		}, {
+			name: "loop",
+			module: &wasm.Module{
+				TypeSection:     []*wasm.FunctionType{v_v},
+				FunctionSection: []wasm.Index{0},
+				CodeSection: []*wasm.Code{{Body: []byte{
+					wasm.OpcodeLoop, 0x40,
+					wasm.OpcodeBr, 0,
+					wasm.OpcodeEnd,
+					wasm.OpcodeEnd,
+				}}},
+			},
+			// Above set manually until the text compiler supports this:
+			// (func (loop (br 0)))
+			expected: &CompilationResult{
+				Operations: []Operation{ // begin with params: []
+					&OperationBr{
+						Target: &BranchTarget{
+							Label: &Label{FrameID: 2, Kind: LabelKindHeader}, // arbitrary FrameID
+						},
+					},
+					&OperationLabel{
+						Label: &Label{FrameID: 2, Kind: LabelKindHeader}, // arbitrary FrameID
+					},
+					&OperationBr{
+						Target: &BranchTarget{
+							Label: &Label{FrameID: 2, Kind: LabelKindHeader}, // arbitrary FrameID
+						},
+					},
+					&OperationLabel{
+						Label: &Label{FrameID: 2, Kind: LabelKindContinuation}, // arbitrary FrameID
+					},
+					&OperationBr{Target: &BranchTarget{}}, // return!
+				},
+				InstructionOffsets: []uint64{0, 0, 2, 4, 5},
+				LabelCallers:       map[string]uint32{".L2": 2},
+				LoopLabels:         map[string]struct{}{".L2": {}},
+				Functions:          []uint32{0},
+				Types:              []*wasm.FunctionType{v_v},
+				Signature:          v_v,
+				TableTypes:         []wasm.RefType{},
+			},
 		},
 	}
 
@@ -208,7 +254,7 @@ func TestCompile_BulkMemoryOperations(t *testing.T) {
 	module := &wasm.Module{
 		TypeSection:     []*wasm.FunctionType{v_v},
 		FunctionSection: []wasm.Index{0},
-		MemorySection:   &wasm.Memory{Min: 1},
+		MemorySection:   []*wasm.Memory{{Min: 1}},
 		DataSection: []*wasm.DataSegment{
 			{
 				OffsetExpression: &wasm.ConstantExpression{
@@ -237,10 +283,12 @@ func TestCompile_BulkMemoryOperations(t *testing.T) {
 			&OperationConstI32{16},                // [16]
 			&OperationConstI32{0},                 // [16, 0]
 			&OperationConstI32{7},                 // [16, 0, 7]
-			&OperationMemoryInit{1},               // []
+			&OperationMemoryInit{DataIndex: 1},    // []
 			&OperationDataDrop{1},                 // []
 			&OperationBr{Target: &BranchTarget{}}, // return!
 		},
+		InstructionOffsets:         []uint64{0, 2, 4, 6, 10, 13},
+		Memories:                   module.MemorySection,
 		HasMemory:                  true,
 		NeedsAccessToDataInstances: true,
 		LabelCallers:               map[string]uint32{},
@@ -250,7 +298,7 @@ func TestCompile_BulkMemoryOperations(t *testing.T) {
 		TableTypes:                 []wasm.RefType{},
 	}
 
-	res, err := CompileFunctions(ctx, wasm.FeatureBulkMemoryOperations, module)
+	res, err := CompileFunctions(ctx, wasm.FeatureBulkMemoryOperations, false, false, module)
 	require.NoError(t, err)
 	require.Equal(t, expected, res[0])
 }
@@ -287,11 +335,12 @@ func TestCompile_MultiValue(t *testing.T) {
 					&OperationDrop{Depth: &InclusiveRange{Start: 2, End: 3}}, // [$y, $x]
 					&OperationBr{Target: &BranchTarget{}},                    // return!
 				},
-				LabelCallers: map[string]uint32{},
-				Signature:    i32i32_i32i32,
-				Functions:    []wasm.Index{0},
-				Types:        []*wasm.FunctionType{i32i32_i32i32},
-				TableTypes:   []wasm.RefType{},
+				InstructionOffsets: []uint64{0, 2, 4, 4},
+				LabelCallers:       map[string]uint32{},
+				Signature:          i32i32_i32i32,
+				Functions:          []wasm.Index{0},
+				Types:              []*wasm.FunctionType{i32i32_i32i32},
+				TableTypes:         []wasm.RefType{},
 			},
 		},
 		{
@@ -330,6 +379,7 @@ func TestCompile_MultiValue(t *testing.T) {
 					},
 					&OperationBr{Target: &BranchTarget{}}, // return!
 				},
+				InstructionOffsets: []uint64{2, 11, 20, 32, 33},
 				// Note: f64.add comes after br 0 so is unreachable. This is why neither the add, nor its other operand
 				// are in the above compilation result.
 				LabelCallers: map[string]uint32{".L2_cont": 1}, // arbitrary label
@@ -351,11 +401,12 @@ func TestCompile_MultiValue(t *testing.T) {
 					&OperationConstI64{Value: 356},        // [306, 356]
 					&OperationBr{Target: &BranchTarget{}}, // return!
 				},
-				LabelCallers: map[string]uint32{},
-				Signature:    _i32i64,
-				Functions:    []wasm.Index{0},
-				Types:        []*wasm.FunctionType{_i32i64},
-				TableTypes:   []wasm.RefType{},
+				InstructionOffsets: []uint64{0, 3, 6},
+				LabelCallers:       map[string]uint32{},
+				Signature:          _i32i64,
+				Functions:          []wasm.Index{0},
+				Types:              []*wasm.FunctionType{_i32i64},
+				TableTypes:         []wasm.RefType{},
 			},
 		},
 		{
@@ -400,6 +451,7 @@ func TestCompile_MultiValue(t *testing.T) {
 					&OperationDrop{Depth: &InclusiveRange{Start: 1, End: 1}}, // .L2 = [3], .L2_else = [-1]
 					&OperationBr{Target: &BranchTarget{}},
 				},
+				InstructionOffsets: []uint64{0, 2, 4, 4, 6, 8, 9, 9, 10, 12, 13, 13, 14, 14},
 				LabelCallers: map[string]uint32{
 					".L2":      1,
 					".L2_cont": 2,
@@ -457,6 +509,7 @@ func TestCompile_MultiValue(t *testing.T) {
 					&OperationDrop{Depth: &InclusiveRange{Start: 1, End: 1}}, // .L2 = [3], .L2_else = [-1]
 					&OperationBr{Target: &BranchTarget{}},
 				},
+				InstructionOffsets: []uint64{0, 2, 4, 6, 6, 8, 9, 9, 10, 11, 11, 12, 12},
 				LabelCallers: map[string]uint32{
 					".L2":      1,
 					".L2_cont": 2,
@@ -514,6 +567,7 @@ func TestCompile_MultiValue(t *testing.T) {
 					&OperationDrop{Depth: &InclusiveRange{Start: 1, End: 1}}, // .L2 = [3], .L2_else = [-1]
 					&OperationBr{Target: &BranchTarget{}},
 				},
+				InstructionOffsets: []uint64{0, 2, 4, 6, 6, 8, 9, 11, 12, 13, 15, 16, 16},
 				LabelCallers: map[string]uint32{
 					".L2":      1,
 					".L2_cont": 2,
@@ -535,7 +589,7 @@ func TestCompile_MultiValue(t *testing.T) {
 			if enabledFeatures == 0 {
 				enabledFeatures = wasm.Features20220419
 			}
-			res, err := CompileFunctions(ctx, enabledFeatures, tc.module)
+			res, err := CompileFunctions(ctx, enabledFeatures, false, false, tc.module)
 			require.NoError(t, err)
 			require.Equal(t, tc.expected, res[0])
 		})
@@ -559,14 +613,15 @@ func TestCompile_NonTrappingFloatToIntConversion(t *testing.T) {
 			&OperationDrop{Depth: &InclusiveRange{Start: 1, End: 1}}, // [i32.trunc_sat_f32_s($0)]
 			&OperationBr{Target: &BranchTarget{}},                    // return!
 		},
-		LabelCallers: map[string]uint32{},
-		Signature:    f32_i32,
-		Functions:    []wasm.Index{0},
-		Types:        []*wasm.FunctionType{f32_i32},
-		TableTypes:   []wasm.RefType{},
+		InstructionOffsets: []uint64{0, 2, 4, 4},
+		LabelCallers:       map[string]uint32{},
+		Signature:          f32_i32,
+		Functions:          []wasm.Index{0},
+		Types:              []*wasm.FunctionType{f32_i32},
+		TableTypes:         []wasm.RefType{},
 	}
 
-	res, err := CompileFunctions(ctx, wasm.FeatureNonTrappingFloatToIntConversion, module)
+	res, err := CompileFunctions(ctx, wasm.FeatureNonTrappingFloatToIntConversion, false, false, module)
 	require.NoError(t, err)
 	require.Equal(t, expected, res[0])
 }
@@ -584,23 +639,249 @@ func TestCompile_SignExtensionOps(t *testing.T) {
 			&OperationDrop{Depth: &InclusiveRange{Start: 1, End: 1}}, // [i32.extend8_s($0)]
 			&OperationBr{Target: &BranchTarget{}},                    // return!
 		},
-		LabelCallers: map[string]uint32{},
-		Signature:    i32_i32,
-		Functions:    []wasm.Index{0},
-		Types:        []*wasm.FunctionType{i32_i32},
-		TableTypes:   []wasm.RefType{},
+		InstructionOffsets: []uint64{0, 2, 3, 3},
+		LabelCallers:       map[string]uint32{},
+		Signature:          i32_i32,
+		Functions:          []wasm.Index{0},
+		Types:              []*wasm.FunctionType{i32_i32},
+		TableTypes:         []wasm.RefType{},
 	}
 
-	res, err := CompileFunctions(ctx, wasm.FeatureSignExtensionOps, module)
+	res, err := CompileFunctions(ctx, wasm.FeatureSignExtensionOps, false, false, module)
 	require.NoError(t, err)
 	require.Equal(t, expected, res[0])
 }
 
+func TestCompile_Atomic(t *testing.T) {
+	module := &wasm.Module{
+		TypeSection:     []*wasm.FunctionType{v_v},
+		FunctionSection: []wasm.Index{0},
+		MemorySection:   []*wasm.Memory{{Min: 1, Cap: 1, Max: 1, Shared: true}},
+		CodeSection: []*wasm.Code{{Body: []byte{
+			wasm.OpcodeI32Const, 0,
+			wasm.OpcodeI32Const, 1,
+			wasm.OpcodeAtomicPrefix, wasm.OpcodeAtomicI32Rmw8AddU, 0, 4, // align and offset.
+			wasm.OpcodeDrop,
+			wasm.OpcodeAtomicPrefix, wasm.OpcodeAtomicFence, 0,
+			wasm.OpcodeI32Const, 0,
+			wasm.OpcodeI32Const, 0,
+			wasm.OpcodeI64Const, 0,
+			wasm.OpcodeAtomicPrefix, wasm.OpcodeAtomicMemoryWait32, 2, 0,
+			wasm.OpcodeDrop,
+			wasm.OpcodeEnd,
+		}}},
+	}
+
+	expected := &CompilationResult{
+		Operations: []Operation{ // begin with params: []
+			&OperationConstI32{Value: 0}, // [0]
+			&OperationConstI32{Value: 1}, // [0, 1]
+			&OperationAtomicRMW{Op: AtomicRMWOpAdd, Type: UnsignedInt32, Size: 1, Arg: &MemoryArg{Offset: 4}}, // [old]
+			&OperationDrop{Depth: &InclusiveRange{}},                                                          // []
+			&OperationAtomicFence{},                                                                           // []
+			&OperationConstI32{Value: 0},                                                                      // [0]
+			&OperationConstI32{Value: 0},                                                                      // [0, 0]
+			&OperationConstI64{Value: 0},                                                                      // [0, 0, 0]
+			&OperationMemoryWait{Type: UnsignedInt32, Arg: &MemoryArg{Alignment: 2}},                          // [result]
+			&OperationDrop{Depth: &InclusiveRange{}},                                                          // []
+			&OperationBr{Target: &BranchTarget{}},                                                             // return!
+		},
+		InstructionOffsets: []uint64{0, 2, 4, 8, 9, 12, 14, 16, 18, 22, 23},
+		Memories:           module.MemorySection,
+		HasMemory:          true,
+		LabelCallers:       map[string]uint32{},
+		Signature:          v_v,
+		Functions:          []wasm.Index{0},
+		Types:              []*wasm.FunctionType{v_v},
+		TableTypes:         []wasm.RefType{},
+	}
+
+	requireCompilationResult(t, wasm.FeatureThreads, expected, module)
+}
+
+func TestCompile_TailCall(t *testing.T) {
+	i64_i64_i64 := &wasm.FunctionType{
+		Params:  []wasm.ValueType{wasm.ValueTypeI64, wasm.ValueTypeI64},
+		Results: []wasm.ValueType{wasm.ValueTypeI64},
+	}
+	i64_i64_i64.CacheNumInUint64()
+
+	t.Run("return_call", func(t *testing.T) {
+		module := &wasm.Module{
+			TypeSection:     []*wasm.FunctionType{i64_i64_i64},
+			FunctionSection: []wasm.Index{0},
+			CodeSection: []*wasm.Code{{Body: []byte{
+				wasm.OpcodeLocalGet, 1,
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeI64Const, 1,
+				wasm.OpcodeI64Sub,
+				wasm.OpcodeLocalGet, 1,
+				wasm.OpcodeReturnCall, 0,
+				wasm.OpcodeEnd,
+			}}},
+		}
+
+		expected := &CompilationResult{
+			Operations: []Operation{ // begin with params: [$x, $y]
+				&OperationPick{Depth: 0},                                 // [$x, $y, $y]
+				&OperationPick{Depth: 2},                                 // [$x, $y, $y, $x]
+				&OperationConstI64{Value: 1},                             // [$x, $y, $y, $x, 1]
+				&OperationSub{Type: UnsignedTypeI64},                     // [$x, $y, $y, $x-1]
+				&OperationPick{Depth: 2},                                 // [$x, $y, $y, $x-1, $y]
+				&OperationDrop{Depth: &InclusiveRange{Start: 2, End: 4}}, // [$x-1, $y]
+				&OperationTailCall{FunctionIndex: 0},                     // replaced by the call.
+			},
+			InstructionOffsets: []uint64{0, 2, 4, 6, 7, 9, 9},
+			LabelCallers:       map[string]uint32{},
+			Signature:          i64_i64_i64,
+			Functions:          []wasm.Index{0},
+			Types:              []*wasm.FunctionType{i64_i64_i64},
+			TableTypes:         []wasm.RefType{},
+		}
+
+		requireCompilationResult(t, wasm.FeatureTailCall, expected, module)
+	})
+
+	t.Run("return_call_indirect", func(t *testing.T) {
+		module := &wasm.Module{
+			TypeSection:     []*wasm.FunctionType{i64_i64_i64},
+			FunctionSection: []wasm.Index{0},
+			TableSection:    []*wasm.Table{{Min: 1, Type: wasm.RefTypeFuncref}},
+			CodeSection: []*wasm.Code{{Body: []byte{
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeLocalGet, 1,
+				wasm.OpcodeI32Const, 0,
+				wasm.OpcodeReturnCallIndirect, 0, 0,
+				wasm.OpcodeEnd,
+			}}},
+		}
+
+		expected := &CompilationResult{
+			Operations: []Operation{ // begin with params: [$x, $y]
+				&OperationPick{Depth: 1},                                 // [$x, $y, $x]
+				&OperationPick{Depth: 1},                                 // [$x, $y, $x, $y]
+				&OperationConstI32{Value: 0},                             // [$x, $y, $x, $y, 0]
+				&OperationDrop{Depth: &InclusiveRange{Start: 3, End: 4}}, // [$x, $y, 0]
+				&OperationTailCallIndirect{TypeIndex: 0, TableIndex: 0},  // replaced by the call.
+			},
+			InstructionOffsets: []uint64{0, 2, 4, 6, 6},
+			LabelCallers:       map[string]uint32{},
+			Signature:          i64_i64_i64,
+			Functions:          []wasm.Index{0},
+			Types:              []*wasm.FunctionType{i64_i64_i64},
+			TableTypes:         []wasm.RefType{wasm.RefTypeFuncref},
+			HasTable:           true,
+		}
+
+		requireCompilationResult(t, wasm.FeatureTailCall, expected, module)
+	})
+}
+
+func TestCompile_ExceptionHandling(t *testing.T) {
+	i32_v := &wasm.FunctionType{Params: []wasm.ValueType{i32}, ParamNumInUint64: 1}
+	v_i32 := &wasm.FunctionType{Results: []wasm.ValueType{i32}, ResultNumInUint64: 1}
+	types := []*wasm.FunctionType{v_i32, i32_v, v_v}
+
+	t.Run("catch", func(t *testing.T) {
+		module := &wasm.Module{
+			TypeSection:     types,
+			FunctionSection: []wasm.Index{0},
+			TagSection:      []wasm.Index{1},
+			CodeSection: []*wasm.Code{{Body: []byte{
+				wasm.OpcodeTry, wasm.ValueTypeI32,
+				wasm.OpcodeI32Const, 1,
+				wasm.OpcodeThrow, 0,
+				wasm.OpcodeCatch, 0,
+				wasm.OpcodeCatchAll,
+				wasm.OpcodeRethrow, 0,
+				wasm.OpcodeEnd,
+				wasm.OpcodeEnd,
+			}}},
+		}
+
+		expected := &CompilationResult{
+			Operations: []Operation{ // begin with params: []
+				&OperationTry{Index: 0},                                  // []
+				&OperationConstI32{Value: 1},                             // [1]
+				&OperationThrow{TagIndex: 0},                             // unreachable
+				&OperationTryEnd{Index: 0},                               //
+				&OperationCatch{TryIndex: 0, TagIndex: 0},                // [$handle, $v]
+				&OperationDrop{Depth: &InclusiveRange{Start: 1, End: 1}}, // [$v]
+				&OperationBr{Target: &BranchTarget{Label: &Label{FrameID: 2, Kind: LabelKindContinuation}}},
+				&OperationCatchAll{TryIndex: 0}, // [$handle]
+				&OperationPick{Depth: 0},        // [$handle, $handle]
+				&OperationRethrow{},             // unreachable
+				&OperationLabel{Label: &Label{FrameID: 2, Kind: LabelKindContinuation}}, // [$v]
+				&OperationBr{Target: &BranchTarget{}},                                   // return!
+			},
+			InstructionOffsets: []uint64{0, 2, 4, 6, 6, 8, 8, 8, 9, 9, 11, 12},
+			LabelCallers:       map[string]uint32{".L2_cont": 1},
+			Signature:          v_i32,
+			Functions:          []wasm.Index{0},
+			Types:              types,
+			TableTypes:         []wasm.RefType{},
+			Tags:               []wasm.Index{1},
+			TryBlocks:          []*TryBlock{{Parent: -1, DelegateTarget: -1}},
+		}
+
+		requireCompilationResult(t, wasm.FeatureExceptionHandling, expected, module)
+	})
+
+	t.Run("delegate", func(t *testing.T) {
+		module := &wasm.Module{
+			TypeSection:     types,
+			FunctionSection: []wasm.Index{2},
+			TagSection:      []wasm.Index{1},
+			CodeSection: []*wasm.Code{{Body: []byte{
+				wasm.OpcodeTry, 0x40,
+				wasm.OpcodeTry, 0x40,
+				wasm.OpcodeI32Const, 1,
+				wasm.OpcodeThrow, 0,
+				wasm.OpcodeDelegate, 0, // to the outer try block.
+				wasm.OpcodeCatchAll,
+				wasm.OpcodeEnd,
+				wasm.OpcodeEnd,
+			}}},
+		}
+
+		expected := &CompilationResult{
+			Operations: []Operation{ // begin with params: []
+				&OperationTry{Index: 0},      // []
+				&OperationTry{Index: 1},      // []
+				&OperationConstI32{Value: 1}, // [1]
+				&OperationThrow{TagIndex: 0}, // unreachable
+				&OperationTryEnd{Index: 1},
+				&OperationLabel{Label: &Label{FrameID: 3, Kind: LabelKindContinuation}}, // []
+				&OperationTryEnd{Index: 0},
+				&OperationBr{Target: &BranchTarget{Label: &Label{FrameID: 2, Kind: LabelKindContinuation}}},
+				&OperationCatchAll{TryIndex: 0},                          // [$handle]
+				&OperationDrop{Depth: &InclusiveRange{Start: 0, End: 0}}, // []
+				&OperationBr{Target: &BranchTarget{Label: &Label{FrameID: 2, Kind: LabelKindContinuation}}},
+				&OperationLabel{Label: &Label{FrameID: 2, Kind: LabelKindContinuation}}, // []
+				&OperationBr{Target: &BranchTarget{}},                                   // return!
+			},
+			InstructionOffsets: []uint64{0, 2, 4, 6, 8, 8, 10, 10, 10, 11, 11, 11, 12},
+			LabelCallers:       map[string]uint32{".L2_cont": 2},
+			Signature:          v_v,
+			Functions:          []wasm.Index{2},
+			Types:              types,
+			TableTypes:         []wasm.RefType{},
+			Tags:               []wasm.Index{1},
+			TryBlocks: []*TryBlock{
+				{Parent: -1, DelegateTarget: -1},
+				{Parent: 0, Delegate: true, DelegateTarget: 0},
+			},
+		}
+
+		requireCompilationResult(t, wasm.FeatureExceptionHandling, expected, module)
+	})
+}
+
 func requireCompilationResult(t *testing.T, enabledFeatures wasm.Features, expected *CompilationResult, module *wasm.Module) {
 	if enabledFeatures == 0 {
 		enabledFeatures = wasm.Features20220419
 	}
-	res, err := CompileFunctions(ctx, enabledFeatures, module)
+	res, err := CompileFunctions(ctx, enabledFeatures, false, false, module)
 	require.NoError(t, err)
 	require.Equal(t, expected, res[0])
 }
@@ -613,6 +894,105 @@ func requireModuleText(t *testing.T, wat string) *wasm.Module {
 	return m
 }
 
+func TestCompile_MeterFuel(t *testing.T) {
+	module := &wasm.Module{
+		TypeSection:     []*wasm.FunctionType{v_v},
+		FunctionSection: []wasm.Index{0},
+		CodeSection: []*wasm.Code{{Body: []byte{
+			wasm.OpcodeI32Const, 1,
+			wasm.OpcodeDrop,
+			wasm.OpcodeLoop, 0x40,
+			wasm.OpcodeBr, 0,
+			wasm.OpcodeEnd,
+			wasm.OpcodeEnd,
+		}}},
+	}
+	// Above set manually until the text compiler supports this:
+	// (func (drop (i32.const 1)) (loop (br 0)))
+
+	expected := []Operation{ // begin with params: []
+		&OperationConsumeFuel{Cost: 3},
+		&OperationConstI32{Value: 1},                             // [1]
+		&OperationDrop{Depth: &InclusiveRange{Start: 0, End: 0}}, // []
+		&OperationBr{
+			Target: &BranchTarget{
+				Label: &Label{FrameID: 2, Kind: LabelKindHeader}, // arbitrary FrameID
+			},
+		},
+		&OperationLabel{
+			Label: &Label{FrameID: 2, Kind: LabelKindHeader}, // arbitrary FrameID
+		},
+		&OperationConsumeFuel{Cost: 1},
+		&OperationBr{
+			Target: &BranchTarget{
+				Label: &Label{FrameID: 2, Kind: LabelKindHeader}, // arbitrary FrameID
+			},
+		},
+		&OperationLabel{
+			Label: &Label{FrameID: 2, Kind: LabelKindContinuation}, // arbitrary FrameID
+		},
+		&OperationConsumeFuel{Cost: 1},
+		&OperationBr{Target: &BranchTarget{}}, // return!
+	}
+
+	res, err := CompileFunctions(ctx, wasm.Features20191205, true, false, module)
+	require.NoError(t, err)
+	require.Equal(t, expected, res[0].Operations)
+	require.Equal(t, []uint64{0, 0, 2, 3, 3, 3, 5, 7, 7, 8}, res[0].InstructionOffsets)
+}
+
+func TestCompile_Coverage(t *testing.T) {
+	module := &wasm.Module{
+		TypeSection:     []*wasm.FunctionType{v_v},
+		FunctionSection: []wasm.Index{0},
+		CodeSection: []*wasm.Code{{Body: []byte{
+			wasm.OpcodeI32Const, 1,
+			wasm.OpcodeDrop,
+			wasm.OpcodeLoop, 0x40,
+			wasm.OpcodeBr, 0,
+			wasm.OpcodeEnd,
+			wasm.OpcodeEnd,
+		}}},
+	}
+	// Above set manually until the text compiler supports this:
+	// (func (drop (i32.const 1)) (loop (br 0)))
+
+	// Fuel metering is also enabled, to show that covering a basic block doesn't cost fuel.
+	expected := []Operation{ // begin with params: []
+		&OperationConsumeFuel{Cost: 3},
+		&OperationCoverBlock{Block: 0},
+		&OperationConstI32{Value: 1},                             // [1]
+		&OperationDrop{Depth: &InclusiveRange{Start: 0, End: 0}}, // []
+		&OperationBr{
+			Target: &BranchTarget{
+				Label: &Label{FrameID: 2, Kind: LabelKindHeader}, // arbitrary FrameID
+			},
+		},
+		&OperationLabel{
+			Label: &Label{FrameID: 2, Kind: LabelKindHeader}, // arbitrary FrameID
+		},
+		&OperationConsumeFuel{Cost: 1},
+		&OperationCoverBlock{Block: 1},
+		&OperationBr{
+			Target: &BranchTarget{
+				Label: &Label{FrameID: 2, Kind: LabelKindHeader}, // arbitrary FrameID
+			},
+		},
+		&OperationLabel{
+			Label: &Label{FrameID: 2, Kind: LabelKindContinuation}, // arbitrary FrameID
+		},
+		&OperationConsumeFuel{Cost: 1},
+		&OperationCoverBlock{Block: 2},
+		&OperationBr{Target: &BranchTarget{}}, // return!
+	}
+
+	res, err := CompileFunctions(ctx, wasm.Features20191205, true, true, module)
+	require.NoError(t, err)
+	require.Equal(t, expected, res[0].Operations)
+	require.Equal(t, []uint64{0, 0, 0, 2, 3, 3, 3, 3, 5, 7, 7, 7, 8}, res[0].InstructionOffsets)
+	require.Equal(t, []uint64{0, 5, 8}, res[0].BlockOffsets)
+}
+
 func TestCompile_CallIndirectNonZeroTableIndex(t *testing.T) {
 	module := &wasm.Module{
 		TypeSection:     []*wasm.FunctionType{v_v, v_v, v_v},
@@ -637,17 +1017,18 @@ func TestCompile_CallIndirectNonZeroTableIndex(t *testing.T) {
 			&OperationCallIndirect{TypeIndex: 2, TableIndex: 5},
 			&OperationBr{Target: &BranchTarget{}}, // return!
 		},
-		HasTable:     true,
-		LabelCallers: map[string]uint32{},
-		Signature:    v_v,
-		Functions:    []wasm.Index{0},
+		InstructionOffsets: []uint64{0, 2, 5},
+		HasTable:           true,
+		LabelCallers:       map[string]uint32{},
+		Signature:          v_v,
+		Functions:          []wasm.Index{0},
 		TableTypes: []wasm.RefType{
 			wasm.RefTypeExternref, wasm.RefTypeFuncref, wasm.RefTypeFuncref, wasm.RefTypeFuncref, wasm.RefTypeFuncref, wasm.RefTypeFuncref,
 		},
 		Types: []*wasm.FunctionType{v_v, v_v, v_v},
 	}
 
-	res, err := CompileFunctions(ctx, wasm.FeatureBulkMemoryOperations, module)
+	res, err := CompileFunctions(ctx, wasm.FeatureBulkMemoryOperations, false, false, module)
 	require.NoError(t, err)
 	require.Equal(t, expected, res[0])
 }
@@ -737,7 +1118,7 @@ func TestCompile_Refs(t *testing.T) {
 				FunctionSection: []wasm.Index{0},
 				CodeSection:     []*wasm.Code{{Body: tc.body}},
 			}
-			res, err := CompileFunctions(ctx, wasm.Features20220419, module)
+			res, err := CompileFunctions(ctx, wasm.Features20220419, false, false, module)
 			require.NoError(t, err)
 			require.Equal(t, tc.expected, res[0].Operations)
 		})
@@ -806,7 +1187,7 @@ func TestCompile_TableGetOrSet(t *testing.T) {
 				CodeSection:     []*wasm.Code{{Body: tc.body}},
 				TableSection:    []*wasm.Table{{}},
 			}
-			res, err := CompileFunctions(ctx, wasm.Features20220419, module)
+			res, err := CompileFunctions(ctx, wasm.Features20220419, false, false, module)
 			require.NoError(t, err)
 			require.Equal(t, tc.expected, res[0].Operations)
 		})
@@ -875,7 +1256,7 @@ func TestCompile_TableGrowFillSize(t *testing.T) {
 				CodeSection:     []*wasm.Code{{Body: tc.body}},
 				TableSection:    []*wasm.Table{{}},
 			}
-			res, err := CompileFunctions(ctx, wasm.Features20220419, module)
+			res, err := CompileFunctions(ctx, wasm.Features20220419, false, false, module)
 			require.NoError(t, err)
 			require.Equal(t, tc.expected, res[0].Operations)
 			require.True(t, res[0].HasTable)
@@ -1093,7 +1474,7 @@ func TestCompile_Locals(t *testing.T) {
 	for _, tt := range tests {
 		tc := tt
 		t.Run(tc.name, func(t *testing.T) {
-			res, err := CompileFunctions(ctx, wasm.Features20220419, tc.mod)
+			res, err := CompileFunctions(ctx, wasm.Features20220419, false, false, tc.mod)
 			require.NoError(t, err)
 			msg := fmt.Sprintf("\nhave:\n\t%s\nwant:\n\t%s", Format(res[0].Operations), Format(tc.expected))
 			require.Equal(t, tc.expected, res[0].Operations, msg)
@@ -1907,10 +2288,10 @@ func TestCompile_Vec(t *testing.T) {
 			module := &wasm.Module{
 				TypeSection:     []*wasm.FunctionType{{}},
 				FunctionSection: []wasm.Index{0},
-				MemorySection:   &wasm.Memory{},
+				MemorySection:   []*wasm.Memory{{}},
 				CodeSection:     []*wasm.Code{{Body: tc.body}},
 			}
-			res, err := CompileFunctions(ctx, wasm.Features20220419, module)
+			res, err := CompileFunctions(ctx, wasm.Features20220419, false, false, module)
 			require.NoError(t, err)
 
 			var actual Operation
@@ -1928,3 +2309,141 @@ func TestCompile_Vec(t *testing.T) {
 		})
 	}
 }
+
+func TestCompile_RelaxedVec(t *testing.T) {
+	v128Const := []byte{
+		wasm.OpcodeVecPrefix, wasm.OpcodeVecV128Const,
+		1, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1,
+	}
+	// relaxed returns the body of an instruction with the given count of v128 operands, whose result is dropped.
+	relaxed := func(oc wasm.OpcodeVecRelaxed, operands int) (ret []byte) {
+		for i := 0; i < operands; i++ {
+			ret = append(ret, v128Const...)
+		}
+		return append(ret, wasm.OpcodeVecPrefix, byte(oc&0x7f)|0x80, byte(oc>>7), wasm.OpcodeDrop, wasm.OpcodeEnd)
+	}
+
+	tests := []struct {
+		name     string
+		body     []byte
+		expected Operation
+	}{
+		{
+			name:     wasm.OpcodeVecI8x16RelaxedSwizzleName,
+			body:     relaxed(wasm.OpcodeVecI8x16RelaxedSwizzle, 2),
+			expected: &OperationV128Swizzle{},
+		},
+		{
+			name:     wasm.OpcodeVecI32x4RelaxedTruncF32x4SName,
+			body:     relaxed(wasm.OpcodeVecI32x4RelaxedTruncF32x4S, 1),
+			expected: &OperationV128RelaxedTrunc{OriginShape: ShapeF32x4, Signed: true},
+		},
+		{
+			name:     wasm.OpcodeVecI32x4RelaxedTruncF32x4UName,
+			body:     relaxed(wasm.OpcodeVecI32x4RelaxedTruncF32x4U, 1),
+			expected: &OperationV128RelaxedTrunc{OriginShape: ShapeF32x4},
+		},
+		{
+			name:     wasm.OpcodeVecI32x4RelaxedTruncF64x2SZeroName,
+			body:     relaxed(wasm.OpcodeVecI32x4RelaxedTruncF64x2SZero, 1),
+			expected: &OperationV128RelaxedTrunc{OriginShape: ShapeF64x2, Signed: true},
+		},
+		{
+			name:     wasm.OpcodeVecI32x4RelaxedTruncF64x2UZeroName,
+			body:     relaxed(wasm.OpcodeVecI32x4RelaxedTruncF64x2UZero, 1),
+			expected: &OperationV128RelaxedTrunc{OriginShape: ShapeF64x2},
+		},
+		{
+			name:     wasm.OpcodeVecF32x4RelaxedMaddName,
+			body:     relaxed(wasm.OpcodeVecF32x4RelaxedMadd, 3),
+			expected: &OperationV128RelaxedMadd{Shape: ShapeF32x4},
+		},
+		{
+			name:     wasm.OpcodeVecF32x4RelaxedNmaddName,
+			body:     relaxed(wasm.OpcodeVecF32x4RelaxedNmadd, 3),
+			expected: &OperationV128RelaxedMadd{Shape: ShapeF32x4, Negate: true},
+		},
+		{
+			name:     wasm.OpcodeVecF64x2RelaxedMaddName,
+			body:     relaxed(wasm.OpcodeVecF64x2RelaxedMadd, 3),
+			expected: &OperationV128RelaxedMadd{Shape: ShapeF64x2},
+		},
+		{
+			name:     wasm.OpcodeVecF64x2RelaxedNmaddName,
+			body:     relaxed(wasm.OpcodeVecF64x2RelaxedNmadd, 3),
+			expected: &OperationV128RelaxedMadd{Shape: ShapeF64x2, Negate: true},
+		},
+		{
+			name:     wasm.OpcodeVecI8x16RelaxedLaneselectName,
+			body:     relaxed(wasm.OpcodeVecI8x16RelaxedLaneselect, 3),
+			expected: &OperationV128Bitselect{},
+		},
+		{
+			name:     wasm.OpcodeVecI16x8RelaxedLaneselectName,
+			body:     relaxed(wasm.OpcodeVecI16x8RelaxedLaneselect, 3),
+			expected: &OperationV128Bitselect{},
+		},
+		{
+			name:     wasm.OpcodeVecI32x4RelaxedLaneselectName,
+			body:     relaxed(wasm.OpcodeVecI32x4RelaxedLaneselect, 3),
+			expected: &OperationV128Bitselect{},
+		},
+		{
+			name:     wasm.OpcodeVecI64x2RelaxedLaneselectName,
+			body:     relaxed(wasm.OpcodeVecI64x2RelaxedLaneselect, 3),
+			expected: &OperationV128Bitselect{},
+		},
+		{
+			name:     wasm.OpcodeVecF32x4RelaxedMinName,
+			body:     relaxed(wasm.OpcodeVecF32x4RelaxedMin, 2),
+			expected: &OperationV128RelaxedMin{Shape: ShapeF32x4},
+		},
+		{
+			name:     wasm.OpcodeVecF32x4RelaxedMaxName,
+			body:     relaxed(wasm.OpcodeVecF32x4RelaxedMax, 2),
+			expected: &OperationV128RelaxedMax{Shape: ShapeF32x4},
+		},
+		{
+			name:     wasm.OpcodeVecF64x2RelaxedMinName,
+			body:     relaxed(wasm.OpcodeVecF64x2RelaxedMin, 2),
+			expected: &OperationV128RelaxedMin{Shape: ShapeF64x2},
+		},
+		{
+			name:     wasm.OpcodeVecF64x2RelaxedMaxName,
+			body:     relaxed(wasm.OpcodeVecF64x2RelaxedMax, 2),
+			expected: &OperationV128RelaxedMax{Shape: ShapeF64x2},
+		},
+		{
+			name:     wasm.OpcodeVecI16x8RelaxedQ15mulrSName,
+			body:     relaxed(wasm.OpcodeVecI16x8RelaxedQ15mulrS, 2),
+			expected: &OperationV128RelaxedQ15mulr{},
+		},
+		{
+			name:     wasm.OpcodeVecI16x8RelaxedDotI8x16I7x16SName,
+			body:     relaxed(wasm.OpcodeVecI16x8RelaxedDotI8x16I7x16S, 2),
+			expected: &OperationV128RelaxedDot{},
+		},
+		{
+			name:     wasm.OpcodeVecI32x4RelaxedDotI8x16I7x16AddSName,
+			body:     relaxed(wasm.OpcodeVecI32x4RelaxedDotI8x16I7x16AddS, 3),
+			expected: &OperationV128RelaxedDotAdd{},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			module := &wasm.Module{
+				TypeSection:     []*wasm.FunctionType{v_v},
+				FunctionSection: []wasm.Index{0},
+				CodeSection:     []*wasm.Code{{Body: tc.body}},
+			}
+			res, err := CompileFunctions(ctx, wasm.Features20220419|wasm.FeatureRelaxedSIMD, false, false, module)
+			require.NoError(t, err)
+
+			// The result is dropped, so the operations end with [... target, drop, br(to return)].
+			require.Equal(t, tc.expected, res[0].Operations[len(res[0].Operations)-3])
+		})
+	}
+}
@@ -43,6 +43,24 @@ func formatOperation(w io.StringWriter, b Operation) {
 		str = fmt.Sprintf("call %d", o.FunctionIndex)
 	case *OperationCallIndirect:
 		str = fmt.Sprintf("call_indirect: type=%d, table=%d", o.TypeIndex, o.TableIndex)
+	case *OperationTailCall:
+		str = fmt.Sprintf("return_call %d", o.FunctionIndex)
+	case *OperationTailCallIndirect:
+		str = fmt.Sprintf("return_call_indirect: type=%d, table=%d", o.TypeIndex, o.TableIndex)
+	case *OperationTry:
+		str = fmt.Sprintf("try %d", o.Index)
+	case *OperationTryEnd:
+		str = fmt.Sprintf("try_end %d", o.Index)
+	case *OperationCatch:
+		isLabel = true
+		str = fmt.Sprintf("catch %d: try=%d", o.TagIndex, o.TryIndex)
+	case *OperationCatchAll:
+		isLabel = true
+		str = fmt.Sprintf("catch_all: try=%d", o.TryIndex)
+	case *OperationThrow:
+		str = fmt.Sprintf("throw %d", o.TagIndex)
+	case *OperationRethrow:
+		str = "rethrow"
 	case *OperationDrop:
 		str = fmt.Sprintf("drop %d..%d", o.Depth.Start, o.Depth.End)
 	case *OperationSelect:
@@ -185,6 +203,10 @@ func formatOperation(w io.StringWriter, b Operation) {
 		str = fmt.Sprintf("v128.const [%#x, %#x]", o.Lo, o.Hi)
 	case *OperationV128Add:
 		str = fmt.Sprintf("v128.add (shape=%s)", shapeName(o.Shape))
+	case *OperationConsumeFuel:
+		str = fmt.Sprintf("consume_fuel %d", o.Cost)
+	case *OperationCoverBlock:
+		str = fmt.Sprintf("cover_block %d", o.Block)
 	default:
 		panic("unreachable: a bug in wazeroir implementation")
 	}
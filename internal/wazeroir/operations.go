@@ -348,6 +348,54 @@ func (o OperationKind) String() (ret string) {
 		ret = "SignExtend64From16"
 	case OperationKindSignExtend64From32:
 		ret = "SignExtend64From32"
+	case OperationKindAtomicLoad:
+		ret = "AtomicLoad"
+	case OperationKindAtomicStore:
+		ret = "AtomicStore"
+	case OperationKindAtomicRMW:
+		ret = "AtomicRMW"
+	case OperationKindAtomicCmpxchg:
+		ret = "AtomicCmpxchg"
+	case OperationKindMemoryWait:
+		ret = "MemoryWait"
+	case OperationKindMemoryNotify:
+		ret = "MemoryNotify"
+	case OperationKindAtomicFence:
+		ret = "AtomicFence"
+	case OperationKindTailCall:
+		ret = "TailCall"
+	case OperationKindTailCallIndirect:
+		ret = "TailCallIndirect"
+	case OperationKindTry:
+		ret = "Try"
+	case OperationKindTryEnd:
+		ret = "TryEnd"
+	case OperationKindCatch:
+		ret = "Catch"
+	case OperationKindCatchAll:
+		ret = "CatchAll"
+	case OperationKindThrow:
+		ret = "Throw"
+	case OperationKindRethrow:
+		ret = "Rethrow"
+	case OperationKindV128RelaxedTrunc:
+		ret = "V128RelaxedTrunc"
+	case OperationKindV128RelaxedMadd:
+		ret = "V128RelaxedMadd"
+	case OperationKindV128RelaxedMin:
+		ret = "V128RelaxedMin"
+	case OperationKindV128RelaxedMax:
+		ret = "V128RelaxedMax"
+	case OperationKindV128RelaxedQ15mulr:
+		ret = "V128RelaxedQ15mulr"
+	case OperationKindV128RelaxedDot:
+		ret = "V128RelaxedDot"
+	case OperationKindV128RelaxedDotAdd:
+		ret = "V128RelaxedDotAdd"
+	case OperationKindConsumeFuel:
+		ret = "ConsumeFuel"
+	case OperationKindCoverBlock:
+		ret = "CoverBlock"
 	default:
 		panic(fmt.Errorf("unknown operation %d", o))
 	}
@@ -470,6 +518,47 @@ const (
 	OperationKindV128Shr
 	OperationKindV128Cmp
 
+	// Atomic instructions of the threads proposal.
+
+	OperationKindAtomicLoad
+	OperationKindAtomicStore
+	OperationKindAtomicRMW
+	OperationKindAtomicCmpxchg
+	OperationKindMemoryWait
+	OperationKindMemoryNotify
+	OperationKindAtomicFence
+
+	// Tail calls of the tail call proposal.
+
+	OperationKindTailCall
+	OperationKindTailCallIndirect
+
+	// Exceptions of the exception handling proposal.
+
+	OperationKindTry
+	OperationKindTryEnd
+	OperationKindCatch
+	OperationKindCatchAll
+	OperationKindThrow
+	OperationKindRethrow
+
+	// Relaxed vector instructions of the relaxed SIMD proposal. The others are compiled to the vector operations
+	// matching the result chosen for them.
+
+	OperationKindV128RelaxedTrunc
+	OperationKindV128RelaxedMadd
+	OperationKindV128RelaxedMin
+	OperationKindV128RelaxedMax
+	OperationKindV128RelaxedQ15mulr
+	OperationKindV128RelaxedDot
+	OperationKindV128RelaxedDotAdd
+
+	// OperationKindConsumeFuel is only emitted when fuel metering is enabled.
+	OperationKindConsumeFuel
+
+	// OperationKindCoverBlock is only emitted when coverage is enabled.
+	OperationKindCoverBlock
+
 	// operationKindEnd is always placed at the bottom of this iota definition to be used in the test.
 	operationKindEnd
 )
@@ -663,7 +752,14 @@ type MemoryArg struct {
 
 	// Offset is the address offset added to the instruction's dynamic address operand, yielding a 33-bit effective
 	// address that is the zero-based index at which the memory is accessed. Default to zero.
-	Offset uint32
+	//
+	// Note: This is only over math.MaxUint32 for memories with 64-bit indices (wasm.FeatureMemory64), whose effective
+	// address is 65-bit.
+	Offset uint64
+
+	// MemoryIndex is the index of the accessed memory in the memory index namespace, which is only non-zero with
+	// wasm.FeatureMultiMemory.
+	MemoryIndex uint32
 }
 
 type OperationLoad struct {
@@ -740,14 +836,17 @@ func (o *OperationStore32) Kind() OperationKind {
 	return OperationKindStore32
 }
 
-type OperationMemorySize struct{}
+type OperationMemorySize struct{ MemoryIndex uint32 }
 
 // Kind implements Operation.Kind.
 func (o *OperationMemorySize) Kind() OperationKind {
 	return OperationKindMemorySize
 }
 
-type OperationMemoryGrow struct{ Alignment uint64 }
+type OperationMemoryGrow struct {
+	Alignment   uint64
+	MemoryIndex uint32
+}
 
 // Kind implements Operation.Kind.
 func (o *OperationMemoryGrow) Kind() OperationKind {
@@ -1123,6 +1222,8 @@ type OperationMemoryInit struct {
 	// DataIndex is the index of the data instance in ModuleInstance.DataInstances
 	// by which this operation instantiates a part of the memory.
 	DataIndex uint32
+	// MemoryIndex is the index of the memory which this operation initializes.
+	MemoryIndex uint32
 }
 
 // Kind implements Operation.Kind.
@@ -1141,14 +1242,16 @@ func (o *OperationDataDrop) Kind() OperationKind {
 	return OperationKindDataDrop
 }
 
-type OperationMemoryCopy struct{}
+type OperationMemoryCopy struct {
+	SrcMemoryIndex, DstMemoryIndex uint32
+}
 
 // Kind implements Operation.Kind.
 func (o *OperationMemoryCopy) Kind() OperationKind {
 	return OperationKindMemoryCopy
 }
 
-type OperationMemoryFill struct{}
+type OperationMemoryFill struct{ MemoryIndex uint32 }
 
 // Kind implements Operation.Kind.
 func (o *OperationMemoryFill) Kind() OperationKind {
@@ -1651,3 +1754,393 @@ const (
 func (o *OperationV128Cmp) Kind() OperationKind {
 	return OperationKindV128Cmp
 }
+
+// OperationAtomicLoad implements Operation.
+//
+// This corresponds to the atomic loads of wasm.FeatureThreads, such as wasm.OpcodeAtomicI32Load8UName, which
+// zero-extend the Size bytes read to the Type.
+type OperationAtomicLoad struct {
+	Type UnsignedInt
+	// Size is the number of bytes accessed: 1, 2, 4 or 8.
+	Size uint32
+	Arg  *MemoryArg
+}
+
+// Kind implements Operation.Kind.
+func (o *OperationAtomicLoad) Kind() OperationKind {
+	return OperationKindAtomicLoad
+}
+
+// OperationAtomicStore implements Operation.
+//
+// This corresponds to the atomic stores of wasm.FeatureThreads, such as wasm.OpcodeAtomicI64Store32Name, which
+// wrap the value to Size bytes.
+type OperationAtomicStore struct {
+	Type UnsignedInt
+	// Size is the number of bytes accessed: 1, 2, 4 or 8.
+	Size uint32
+	Arg  *MemoryArg
+}
+
+// Kind implements Operation.Kind.
+func (o *OperationAtomicStore) Kind() OperationKind {
+	return OperationKindAtomicStore
+}
+
+// AtomicRMWOp is the operation of OperationAtomicRMW.
+type AtomicRMWOp byte
+
+const (
+	AtomicRMWOpAdd AtomicRMWOp = iota
+	AtomicRMWOpSub
+	AtomicRMWOpAnd
+	AtomicRMWOpOr
+	AtomicRMWOpXor
+	// AtomicRMWOpXchg replaces the value read with the operand.
+	AtomicRMWOpXchg
+)
+
+func (o AtomicRMWOp) String() (ret string) {
+	switch o {
+	case AtomicRMWOpAdd:
+		ret = "add"
+	case AtomicRMWOpSub:
+		ret = "sub"
+	case AtomicRMWOpAnd:
+		ret = "and"
+	case AtomicRMWOpOr:
+		ret = "or"
+	case AtomicRMWOpXor:
+		ret = "xor"
+	case AtomicRMWOpXchg:
+		ret = "xchg"
+	}
+	return
+}
+
+// Apply returns the value to write in place of the value read (old), given the operand v. Results wider than the
+// memory accessed are wrapped by it.
+func (o AtomicRMWOp) Apply(old, v uint64) uint64 {
+	switch o {
+	case AtomicRMWOpAdd:
+		return old + v
+	case AtomicRMWOpSub:
+		return old - v
+	case AtomicRMWOpAnd:
+		return old & v
+	case AtomicRMWOpOr:
+		return old | v
+	case AtomicRMWOpXor:
+		return old ^ v
+	default: // AtomicRMWOpXchg
+		return v
+	}
+}
+
+// OperationAtomicRMW implements Operation.
+//
+// This corresponds to the atomic read-modify-write instructions of wasm.FeatureThreads, such as
+// wasm.OpcodeAtomicI32RmwAddName, which push the value read, zero-extended to the Type.
+type OperationAtomicRMW struct {
+	Op   AtomicRMWOp
+	Type UnsignedInt
+	// Size is the number of bytes accessed: 1, 2, 4 or 8.
+	Size uint32
+	Arg  *MemoryArg
+}
+
+// Kind implements Operation.Kind.
+func (o *OperationAtomicRMW) Kind() OperationKind {
+	return OperationKindAtomicRMW
+}
+
+// OperationAtomicCmpxchg implements Operation.
+//
+// This corresponds to the atomic compare and exchange instructions of wasm.FeatureThreads, such as
+// wasm.OpcodeAtomicI64RmwCmpxchgName, which push the value read, zero-extended to the Type.
+type OperationAtomicCmpxchg struct {
+	Type UnsignedInt
+	// Size is the number of bytes accessed: 1, 2, 4 or 8.
+	Size uint32
+	Arg  *MemoryArg
+}
+
+// Kind implements Operation.Kind.
+func (o *OperationAtomicCmpxchg) Kind() OperationKind {
+	return OperationKindAtomicCmpxchg
+}
+
+// OperationMemoryWait implements Operation.
+//
+// This corresponds to wasm.OpcodeAtomicMemoryWait32Name and wasm.OpcodeAtomicMemoryWait64Name, depending on the Type
+// of the expected value.
+type OperationMemoryWait struct {
+	Type UnsignedInt
+	Arg  *MemoryArg
+}
+
+// Kind implements Operation.Kind.
+func (o *OperationMemoryWait) Kind() OperationKind {
+	return OperationKindMemoryWait
+}
+
+// OperationMemoryNotify implements Operation.
+//
+// This corresponds to wasm.OpcodeAtomicMemoryNotifyName.
+type OperationMemoryNotify struct {
+	Arg *MemoryArg
+}
+
+// Kind implements Operation.Kind.
+func (o *OperationMemoryNotify) Kind() OperationKind {
+	return OperationKindMemoryNotify
+}
+
+// OperationAtomicFence implements Operation.
+//
+// This corresponds to wasm.OpcodeAtomicFenceName.
+type OperationAtomicFence struct{}
+
+// Kind implements Operation.Kind.
+func (o *OperationAtomicFence) Kind() OperationKind {
+	return OperationKindAtomicFence
+}
+
+// OperationTailCall implements Operation.
+//
+// This corresponds to wasm.OpcodeReturnCallName, and is preceded by an OperationDrop of all the values below the
+// parameters, so that engines can reuse the frame of the current function for the called one.
+type OperationTailCall struct {
+	FunctionIndex uint32
+}
+
+// Kind implements Operation.Kind.
+func (o *OperationTailCall) Kind() OperationKind {
+	return OperationKindTailCall
+}
+
+// OperationTailCallIndirect implements Operation.
+//
+// This corresponds to wasm.OpcodeReturnCallIndirectName, and is preceded by an OperationDrop of all the values below
+// the parameters and the table offset, like OperationTailCall.
+type OperationTailCallIndirect struct {
+	TypeIndex, TableIndex uint32
+}
+
+// Kind implements Operation.Kind.
+func (o *OperationTailCallIndirect) Kind() OperationKind {
+	return OperationKindTailCallIndirect
+}
+
+// OperationTry implements Operation.
+//
+// This corresponds to wasm.OpcodeTryName, and begins the body of the try block, which ends at OperationTryEnd of the
+// same Index. Exceptions thrown by the operations in between, including those in calls, are handled as described by
+// the TryBlock of the Index.
+type OperationTry struct {
+	// Index is the index of the try block in CompilationResult.TryBlocks.
+	Index uint32
+}
+
+// Kind implements Operation.Kind.
+func (o *OperationTry) Kind() OperationKind {
+	return OperationKindTry
+}
+
+// OperationTryEnd implements Operation.
+//
+// This ends the body of the try block begun by OperationTry of the same Index. It is placed before the first catch
+// clause of the try block, its wasm.OpcodeDelegateName, or its wasm.OpcodeEndName.
+type OperationTryEnd struct {
+	Index uint32
+}
+
+// Kind implements Operation.Kind.
+func (o *OperationTryEnd) Kind() OperationKind {
+	return OperationKindTryEnd
+}
+
+// OperationCatch implements Operation.
+//
+// This corresponds to wasm.OpcodeCatchName, and is where exceptions of the TagIndex thrown in the body of the try
+// block are caught. Only exceptions jump here: the code before always branches away, or is unreachable.
+//
+// Engines begin the catch clause with the stack of the try block at TryBlock.StackHeight, followed by the handle of
+// the exception as an i64, then its values. The handle is only used by OperationRethrow.
+type OperationCatch struct {
+	// TryIndex is the index of the try block in CompilationResult.TryBlocks.
+	TryIndex uint32
+	// TagIndex is the index of the tag in the module, whose type is in CompilationResult.Tags.
+	TagIndex uint32
+}
+
+// Kind implements Operation.Kind.
+func (o *OperationCatch) Kind() OperationKind {
+	return OperationKindCatch
+}
+
+// OperationCatchAll implements Operation.
+//
+// This corresponds to wasm.OpcodeCatchAllName, and is like OperationCatch for exceptions of any tag, except that
+// only the handle of the exception is pushed.
+type OperationCatchAll struct {
+	TryIndex uint32
+}
+
+// Kind implements Operation.Kind.
+func (o *OperationCatchAll) Kind() OperationKind {
+	return OperationKindCatchAll
+}
+
+// OperationThrow implements Operation.
+//
+// This corresponds to wasm.OpcodeThrowName, and pops the values of an exception of the TagIndex, in the order of its
+// type params, to throw it.
+type OperationThrow struct {
+	TagIndex uint32
+}
+
+// Kind implements Operation.Kind.
+func (o *OperationThrow) Kind() OperationKind {
+	return OperationKindThrow
+}
+
+// OperationRethrow implements Operation.
+//
+// This corresponds to wasm.OpcodeRethrowName, and pops the handle of a caught exception to throw it again. The handle
+// is pushed by the preceding OperationPick.
+//
+// See OperationCatch
+type OperationRethrow struct{}
+
+// Kind implements Operation.Kind.
+func (o *OperationRethrow) Kind() OperationKind {
+	return OperationKindRethrow
+}
+
+// OperationV128RelaxedTrunc implements Operation.
+//
+// This corresponds to wasm.OpcodeVecI32x4RelaxedTruncF32x4SName and the other relaxed truncations, which pop a vector
+// of OriginShape and push the i32x4 of its truncated lanes. For ShapeF64x2, the upper two lanes are zero.
+//
+// Lanes which are NaN or out of range are math.MinInt32 when Signed, like the x86 CVTTPS2DQ instruction. Otherwise,
+// they saturate like wasm.OpcodeVecI32x4TruncSatF32x4UName: NaN and negative lanes are zero, and those too large
+// are math.MaxUint32.
+type OperationV128RelaxedTrunc struct {
+	// OriginShape is ShapeF32x4 or ShapeF64x2.
+	OriginShape Shape
+	Signed      bool
+}
+
+// Kind implements Operation.Kind.
+func (o *OperationV128RelaxedTrunc) Kind() OperationKind {
+	return OperationKindV128RelaxedTrunc
+}
+
+// OperationV128RelaxedMadd implements Operation.
+//
+// This corresponds to wasm.OpcodeVecF32x4RelaxedMaddName and the other relaxed multiply-adds, which pop c, b and a
+// to push a*b+c, or c-a*b when Negate. The product is rounded before the addition, so the result is never fused.
+type OperationV128RelaxedMadd struct {
+	// Shape is ShapeF32x4 or ShapeF64x2.
+	Shape  Shape
+	Negate bool
+}
+
+// Kind implements Operation.Kind.
+func (o *OperationV128RelaxedMadd) Kind() OperationKind {
+	return OperationKindV128RelaxedMadd
+}
+
+// OperationV128RelaxedMin implements Operation.
+//
+// This corresponds to wasm.OpcodeVecF32x4RelaxedMinName and wasm.OpcodeVecF64x2RelaxedMinName, which pop b and a.
+// Each lane of the result is a when a < b, and b otherwise, like the x86 MINPS instruction. So, it is b when either
+// is NaN or both are zeros.
+type OperationV128RelaxedMin struct {
+	// Shape is ShapeF32x4 or ShapeF64x2.
+	Shape Shape
+}
+
+// Kind implements Operation.Kind.
+func (o *OperationV128RelaxedMin) Kind() OperationKind {
+	return OperationKindV128RelaxedMin
+}
+
+// OperationV128RelaxedMax implements Operation.
+//
+// This corresponds to wasm.OpcodeVecF32x4RelaxedMaxName and wasm.OpcodeVecF64x2RelaxedMaxName, which pop b and a.
+// Each lane of the result is a when a > b, and b otherwise, like the x86 MAXPS instruction. So, it is b when either
+// is NaN or both are zeros.
+type OperationV128RelaxedMax struct {
+	// Shape is ShapeF32x4 or ShapeF64x2.
+	Shape Shape
+}
+
+// Kind implements Operation.Kind.
+func (o *OperationV128RelaxedMax) Kind() OperationKind {
+	return OperationKindV128RelaxedMax
+}
+
+// OperationV128RelaxedQ15mulr implements Operation.
+//
+// This corresponds to wasm.OpcodeVecI16x8RelaxedQ15mulrSName, which pops b and a. Each i16 lane of the result is
+// (a*b + 0x4000) >> 15. The only product which overflows is -32768 * -32768, and it wraps to -32768 like the x86
+// PMULHRSW instruction, instead of saturating.
+type OperationV128RelaxedQ15mulr struct{}
+
+// Kind implements Operation.Kind.
+func (o *OperationV128RelaxedQ15mulr) Kind() OperationKind {
+	return OperationKindV128RelaxedQ15mulr
+}
+
+// OperationV128RelaxedDot implements Operation.
+//
+// This corresponds to wasm.OpcodeVecI16x8RelaxedDotI8x16I7x16SName, which pops b and a. Each i16 lane of the result
+// is the sum of the products of two adjacent i8 lanes, saturated to i16, like the x86 PMADDUBSW instruction. Lanes of
+// a are signed while lanes of b are unsigned, which is the same for the 7-bit values b is meant to have.
+type OperationV128RelaxedDot struct{}
+
+// Kind implements Operation.Kind.
+func (o *OperationV128RelaxedDot) Kind() OperationKind {
+	return OperationKindV128RelaxedDot
+}
+
+// OperationV128RelaxedDotAdd implements Operation.
+//
+// This corresponds to wasm.OpcodeVecI32x4RelaxedDotI8x16I7x16AddSName, which pops c, b and a. Each i32 lane of the
+// result is the sum of two adjacent i16 lanes of OperationV128RelaxedDot on a and b, added to the lane of c.
+type OperationV128RelaxedDotAdd struct{}
+
+// Kind implements Operation.Kind.
+func (o *OperationV128RelaxedDotAdd) Kind() OperationKind {
+	return OperationKindV128RelaxedDotAdd
+}
+
+// OperationConsumeFuel implements Operation.
+//
+// This is placed at the beginning of each basic block when fuel metering is enabled, so that engines subtract the cost
+// of the block before executing it.
+type OperationConsumeFuel struct {
+	// Cost is the number of the other operations in this basic block.
+	Cost uint64
+}
+
+// Kind implements Operation.Kind.
+func (o *OperationConsumeFuel) Kind() OperationKind {
+	return OperationKindConsumeFuel
+}
+
+// OperationCoverBlock implements Operation.
+//
+// This is placed at the beginning of each basic block when coverage is enabled, so that engines count how many times
+// the block ran.
+type OperationCoverBlock struct {
+	// Block is the index of this basic block in CompilationResult.BlockOffsets.
+	Block uint32
+}
+
+// Kind implements Operation.Kind.
+func (o *OperationCoverBlock) Kind() OperationKind {
+	return OperationKindCoverBlock
+}
@@ -1,8 +1,10 @@
 package wazeroir
 
 import (
+	"bytes"
 	"fmt"
 
+	"github.com/tetratelabs/wazero/internal/leb128"
 	"github.com/tetratelabs/wazero/internal/wasm"
 )
 
@@ -150,6 +152,26 @@ var (
 	signature_I32I64I32_None = &signature{
 		in: []UnsignedType{UnsignedTypeI32, UnsignedTypeI64, UnsignedTypeI32},
 	}
+	signature_I32I64_I64 = &signature{
+		in:  []UnsignedType{UnsignedTypeI32, UnsignedTypeI64},
+		out: []UnsignedType{UnsignedTypeI64},
+	}
+	signature_I32I32I32_I32 = &signature{
+		in:  []UnsignedType{UnsignedTypeI32, UnsignedTypeI32, UnsignedTypeI32},
+		out: []UnsignedType{UnsignedTypeI32},
+	}
+	signature_I32I64I64_I64 = &signature{
+		in:  []UnsignedType{UnsignedTypeI32, UnsignedTypeI64, UnsignedTypeI64},
+		out: []UnsignedType{UnsignedTypeI64},
+	}
+	signature_I32I32I64_I32 = &signature{
+		in:  []UnsignedType{UnsignedTypeI32, UnsignedTypeI32, UnsignedTypeI64},
+		out: []UnsignedType{UnsignedTypeI32},
+	}
+	signature_I32I64I64_I32 = &signature{
+		in:  []UnsignedType{UnsignedTypeI32, UnsignedTypeI64, UnsignedTypeI64},
+		out: []UnsignedType{UnsignedTypeI32},
+	}
 	signature_UnknownUnknownI32_Unknown = &signature{
 		in:  []UnsignedType{UnsignedTypeUnknown, UnsignedTypeUnknown, UnsignedTypeI32},
 		out: []UnsignedType{UnsignedTypeUnknown},
@@ -232,6 +254,12 @@ func (c *compiler) wasmOpcodeSignature(op wasm.Opcode, index uint32) (*signature
 	switch op {
 	case wasm.OpcodeUnreachable, wasm.OpcodeNop, wasm.OpcodeBlock, wasm.OpcodeLoop:
 		return signature_None_None, nil
+	case wasm.OpcodeTry, wasm.OpcodeCatch, wasm.OpcodeCatchAll, wasm.OpcodeDelegate:
+		// The stack is manipulated when lowering these, as they reset it like wasm.OpcodeElse and wasm.OpcodeEnd.
+		return signature_None_None, nil
+	case wasm.OpcodeThrow, wasm.OpcodeRethrow:
+		// The following code is unreachable, so the operands don't need to be popped.
+		return signature_None_None, nil
 	case wasm.OpcodeIf:
 		return signature_I32_None, nil
 	case wasm.OpcodeElse, wasm.OpcodeEnd, wasm.OpcodeBr:
@@ -246,6 +274,16 @@ func (c *compiler) wasmOpcodeSignature(op wasm.Opcode, index uint32) (*signature
 		ret := funcTypeToSignature(c.types[index])
 		ret.in = append(ret.in, UnsignedTypeI32)
 		return ret, nil
+	case wasm.OpcodeReturnCall:
+		// The results are returned to the caller, so they aren't pushed as the following code is unreachable.
+		ret := funcTypeToSignature(c.types[c.funcs[index]])
+		ret.out = nil
+		return ret, nil
+	case wasm.OpcodeReturnCallIndirect:
+		ret := funcTypeToSignature(c.types[index])
+		ret.in = append(ret.in, UnsignedTypeI32)
+		ret.out = nil
+		return ret, nil
 	case wasm.OpcodeDrop:
 		return signature_Unknown_None, nil
 	case wasm.OpcodeSelect, wasm.OpcodeTypedSelect:
@@ -471,6 +509,9 @@ func (c *compiler) wasmOpcodeSignature(op wasm.Opcode, index uint32) (*signature
 			return nil, fmt.Errorf("unsupported misc instruction in wazeroir: 0x%x", op)
 		}
 	case wasm.OpcodeVecPrefix:
+		if relaxedOp, ok := wasm.DecodeOpcodeVecRelaxed(c.body[c.pc+1:]); ok {
+			return relaxedVectorSignature(relaxedOp), nil
+		}
 		switch vecOp := c.body[c.pc+1]; vecOp {
 		case wasm.OpcodeVecV128Const:
 			return signature_None_V128, nil
@@ -550,11 +591,62 @@ func (c *compiler) wasmOpcodeSignature(op wasm.Opcode, index uint32) (*signature
 		default:
 			return nil, fmt.Errorf("unsupported vector instruction in wazeroir: %s", wasm.VectorInstructionName(vecOp))
 		}
+	case wasm.OpcodeAtomicPrefix:
+		atomicOp := c.body[c.pc+1]
+		_, vt := wasm.AtomicOperand(atomicOp)
+		switch {
+		case atomicOp == wasm.OpcodeAtomicMemoryNotify:
+			return signature_I32I32_I32, nil
+		case atomicOp == wasm.OpcodeAtomicMemoryWait32:
+			return signature_I32I32I64_I32, nil
+		case atomicOp == wasm.OpcodeAtomicMemoryWait64:
+			return signature_I32I64I64_I32, nil
+		case atomicOp == wasm.OpcodeAtomicFence:
+			return signature_None_None, nil
+		case atomicOp <= wasm.OpcodeAtomicI64Load32U:
+			if vt == wasm.ValueTypeI32 {
+				return signature_I32_I32, nil
+			}
+			return signature_I32_I64, nil
+		case atomicOp <= wasm.OpcodeAtomicI64Store32:
+			if vt == wasm.ValueTypeI32 {
+				return signature_I32I32_None, nil
+			}
+			return signature_I32I64_None, nil
+		case atomicOp < wasm.OpcodeAtomicI32RmwCmpxchg:
+			if vt == wasm.ValueTypeI32 {
+				return signature_I32I32_I32, nil
+			}
+			return signature_I32I64_I64, nil
+		case atomicOp <= wasm.OpcodeAtomicI64Rmw32CmpxchgU:
+			if vt == wasm.ValueTypeI32 {
+				return signature_I32I32I32_I32, nil
+			}
+			return signature_I32I64I64_I64, nil
+		default:
+			return nil, fmt.Errorf("unsupported atomic instruction in wazeroir: %s", wasm.AtomicInstructionName(atomicOp))
+		}
 	default:
 		return nil, fmt.Errorf("unsupported instruction in wazeroir: 0x%x", op)
 	}
 }
 
+// relaxedVectorSignature returns the signature of the relaxed vector instruction, whose operands and result are all
+// vectors.
+func relaxedVectorSignature(op wasm.OpcodeVecRelaxed) *signature {
+	switch op {
+	case wasm.OpcodeVecI32x4RelaxedTruncF32x4S, wasm.OpcodeVecI32x4RelaxedTruncF32x4U,
+		wasm.OpcodeVecI32x4RelaxedTruncF64x2SZero, wasm.OpcodeVecI32x4RelaxedTruncF64x2UZero:
+		return signature_V128_V128
+	case wasm.OpcodeVecI8x16RelaxedSwizzle, wasm.OpcodeVecF32x4RelaxedMin, wasm.OpcodeVecF32x4RelaxedMax,
+		wasm.OpcodeVecF64x2RelaxedMin, wasm.OpcodeVecF64x2RelaxedMax, wasm.OpcodeVecI16x8RelaxedQ15mulrS,
+		wasm.OpcodeVecI16x8RelaxedDotI8x16I7x16S:
+		return signature_V128V128_V128
+	default: // madd, nmadd, laneselect and dot with an accumulator.
+		return signature_V128V128V128_V32
+	}
+}
+
 func funcTypeToSignature(tps *wasm.FunctionType) *signature {
 	ret := &signature{}
 	for _, vt := range tps.Params {
@@ -583,3 +675,121 @@ func wasmValueTypeToUnsignedType(vt wasm.ValueType) []UnsignedType {
 	}
 	panic("unreachable")
 }
+
+// memory64Signature returns the signature s of the memory instruction op at the current position, with the types of
+// its addresses and sizes replaced by UnsignedTypeI64 if it accesses a memory with 64-bit indices. Otherwise, this
+// returns s.
+//
+// See https://github.com/WebAssembly/memory64/blob/main/proposals/memory64/Overview.md
+func (c *compiler) memory64Signature(op wasm.Opcode, s *signature) *signature {
+	if !c.hasMemory64() {
+		return s
+	}
+	var in, out []int // the indexes of the addresses and sizes in s.in and s.out
+	var memoryIndex uint32
+	switch {
+	case op >= wasm.OpcodeI32Load && op <= wasm.OpcodeI64Store32:
+		memoryIndex, in = c.peekMemoryArgIndex(c.pc+1), []int{0}
+	case op == wasm.OpcodeMemorySize:
+		memoryIndex, out = c.peekIndex(c.pc+1), []int{0}
+	case op == wasm.OpcodeMemoryGrow:
+		memoryIndex, in, out = c.peekIndex(c.pc+1), []int{0}, []int{0}
+	case op == wasm.OpcodeMiscPrefix:
+		switch c.body[c.pc+1] {
+		case wasm.OpcodeMiscMemoryInit:
+			_, n := c.peekIndexN(c.pc + 2) // data index
+			memoryIndex, in = c.peekIndex(c.pc+2+n), []int{0}
+		case wasm.OpcodeMiscMemoryCopy:
+			dst, n := c.peekIndexN(c.pc + 2)
+			src := c.peekIndex(c.pc + 2 + n)
+			dst64, src64 := c.isMemory64(dst), c.isMemory64(src)
+			if !dst64 && !src64 {
+				return s
+			}
+			ret := &signature{in: append([]UnsignedType{}, s.in...)}
+			if dst64 {
+				ret.in[0] = UnsignedTypeI64
+			}
+			if src64 {
+				ret.in[1] = UnsignedTypeI64
+			}
+			if dst64 && src64 {
+				ret.in[2] = UnsignedTypeI64
+			}
+			return ret
+		case wasm.OpcodeMiscMemoryFill:
+			memoryIndex, in = c.peekIndex(c.pc+2), []int{0, 2}
+		default:
+			return s
+		}
+	case op == wasm.OpcodeVecPrefix:
+		switch c.body[c.pc+1] {
+		case wasm.OpcodeVecV128Load, wasm.OpcodeVecV128Load8x8s, wasm.OpcodeVecV128Load8x8u,
+			wasm.OpcodeVecV128Load16x4s, wasm.OpcodeVecV128Load16x4u, wasm.OpcodeVecV128Load32x2s,
+			wasm.OpcodeVecV128Load32x2u, wasm.OpcodeVecV128Load8Splat, wasm.OpcodeVecV128Load16Splat,
+			wasm.OpcodeVecV128Load32Splat, wasm.OpcodeVecV128Load64Splat, wasm.OpcodeVecV128Load32zero,
+			wasm.OpcodeVecV128Load64zero, wasm.OpcodeVecV128Load8Lane, wasm.OpcodeVecV128Load16Lane,
+			wasm.OpcodeVecV128Load32Lane, wasm.OpcodeVecV128Load64Lane, wasm.OpcodeVecV128Store,
+			wasm.OpcodeVecV128Store8Lane, wasm.OpcodeVecV128Store16Lane, wasm.OpcodeVecV128Store32Lane,
+			wasm.OpcodeVecV128Store64Lane:
+			memoryIndex, in = c.peekMemoryArgIndex(c.pc+2), []int{0}
+		default:
+			return s
+		}
+	case op == wasm.OpcodeAtomicPrefix:
+		if c.body[c.pc+1] == wasm.OpcodeAtomicFence {
+			return s
+		}
+		memoryIndex, in = c.peekMemoryArgIndex(c.pc+2), []int{0}
+	default:
+		return s
+	}
+	if !c.isMemory64(memoryIndex) {
+		return s
+	}
+	ret := &signature{in: append([]UnsignedType{}, s.in...), out: append([]UnsignedType{}, s.out...)}
+	for _, i := range in {
+		ret.in[i] = UnsignedTypeI64
+	}
+	for _, i := range out {
+		ret.out[i] = UnsignedTypeI64
+	}
+	return ret
+}
+
+// hasMemory64 returns true if any memory of the module has 64-bit indices.
+func (c *compiler) hasMemory64() bool {
+	for _, m := range c.memories {
+		if m.Is64 {
+			return true
+		}
+	}
+	return false
+}
+
+// isMemory64 returns true if the memory at the index has 64-bit indices.
+func (c *compiler) isMemory64(index uint32) bool {
+	return int(index) < len(c.memories) && c.memories[index].Is64
+}
+
+// peekMemoryArgIndex returns the memory index of the "memarg" at the position in the body, without reading it.
+func (c *compiler) peekMemoryArgIndex(pos uint64) uint32 {
+	alignment, n := c.peekIndexN(pos)
+	if alignment&wasm.MemoryArgMemoryIndexFlag == 0 {
+		return 0
+	}
+	return c.peekIndex(pos + n)
+}
+
+// peekIndex returns the u32 at the position in the body, without reading it.
+func (c *compiler) peekIndex(pos uint64) uint32 {
+	v, _ := c.peekIndexN(pos)
+	return v
+}
+
+// peekIndexN is like peekIndex, except it also returns the count of bytes it is encoded in. Errors are ignored, as
+// they are reported when the immediate is read.
+func (c *compiler) peekIndexN(pos uint64) (uint32, uint64) {
+	v, n, _ := leb128.DecodeUint32(bytes.NewReader(c.body[pos:]))
+	return v, n
+}
@@ -95,7 +95,7 @@ func (ns *namespace) InstantiateModule(
 	}
 
 	// Instantiate the module in the appropriate namespace.
-	mod, err = ns.store.Instantiate(ctx, ns.ns, code.module, name, sysCtx, functionListenerFactory)
+	mod, err = ns.store.Instantiate(ctx, ns.ns, code.module, name, sysCtx, functionListenerFactory, &config.memoryLimit)
 	if err != nil {
 		// If there was an error, don't leak the compiled module.
 		if code.closeWithModule {
@@ -0,0 +1,195 @@
+package wazero
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// ModulePool hands out instances of a CompiledModule, which are reset to their state after instantiation when put
+// back. This avoids the cost of InstantiateModule per request, such as copying data segments and running start
+// functions.
+//
+// Ex. To handle each request with a fresh instance:
+//	pool, _ := wazero.NewModulePool(ctx, r, compiled, wazero.NewModuleConfig().WithName("handler"))
+//	defer pool.Close(ctx)
+//
+//	mod, _ := pool.Get(ctx)
+//	defer pool.Put(ctx, mod)
+//	_, err := mod.ExportedFunction("handle").Call(ctx)
+//
+// Notes:
+//	* Instances are named after ModuleConfig.WithName suffixed with a sequence, such as "handler#1", as names must be
+//	  unique in a Namespace.
+//	* Only state defined by the module is reset: the memory, mutable globals, tables and data and element segments.
+//	  State of imported modules, and ModuleConfig resources such as open files, persist across uses.
+//	* On Linux, resetting the memory maps it copy-on-write from the memory after instantiation, so it costs as much as
+//	  the pages written by the last use. Elsewhere, it copies the memory, so it costs as much as its size.
+//	* Views returned by api.Memory Read must not be used after Put, as resetting may remap the memory.
+type ModulePool interface {
+	// Get returns an idle instance, or instantiates a new one if there are none. The instance must be returned with
+	// Put, or closed.
+	Get(ctx context.Context) (api.Module, error)
+
+	// Put resets an instance returned by Get, and makes it available to a later call to Get. Instances which were
+	// closed, such as by a WASI proc_exit, are discarded.
+	//
+	// Note: This must not be called concurrently with function calls in the instance.
+	Put(ctx context.Context, mod api.Module) error
+
+	// Closer closes idle instances. Instances put afterwards are closed instead of reset.
+	api.Closer
+}
+
+// NewModulePool instantiates the compiled module in the namespace, such as a Runtime, and returns a ModulePool which
+// resets instances to the state of this first instance.
+//
+// An error is returned in the same cases as Namespace.InstantiateModule, or if the module's tables include functions
+// of another module.
+func NewModulePool(ctx context.Context, ns Namespace, compiled CompiledModule, config ModuleConfig) (ModulePool, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	code, ok := compiled.(*compiledModule)
+	if !ok {
+		return nil, fmt.Errorf("unsupported wazero.CompiledModule implementation: %T", compiled)
+	}
+	mConfig, ok := config.(*moduleConfig)
+	if !ok {
+		return nil, fmt.Errorf("unsupported wazero.ModuleConfig implementation: %T", config)
+	}
+
+	name := mConfig.name
+	if name == "" && code.module.NameSection != nil {
+		name = code.module.NameSection.ModuleName
+	}
+
+	p := &modulePool{ns: ns, compiled: compiled, config: mConfig, name: name, inUse: map[*wasm.CallContext]struct{}{}}
+	mod, err := p.instantiate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if p.template, err = mod.NewTemplate(); err != nil {
+		_ = mod.Close(ctx) // don't overwrite the error
+		return nil, err
+	}
+	p.idle = append(p.idle, mod)
+	return p, nil
+}
+
+// modulePool implements ModulePool
+type modulePool struct {
+	ns       Namespace
+	compiled CompiledModule
+	config   *moduleConfig
+	name     string
+	template *wasm.Template
+
+	// mux guards the fields below.
+	mux sync.Mutex
+	// sequence is the suffix of the name of the last instance.
+	sequence uint64
+	idle     []*wasm.CallContext
+	// inUse are the instances returned by Get and not yet put, to reject others in Put.
+	inUse  map[*wasm.CallContext]struct{}
+	closed bool
+}
+
+// Get implements ModulePool.Get
+func (p *modulePool) Get(ctx context.Context) (api.Module, error) {
+	p.mux.Lock()
+	if p.closed {
+		p.mux.Unlock()
+		return nil, errors.New("module pool closed")
+	}
+	if last := len(p.idle) - 1; last >= 0 {
+		mod := p.idle[last]
+		p.idle = p.idle[:last]
+		p.inUse[mod] = struct{}{}
+		p.mux.Unlock()
+		return mod, nil
+	}
+	p.mux.Unlock()
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	mod, err := p.instantiate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.mux.Lock()
+	p.inUse[mod] = struct{}{}
+	p.mux.Unlock()
+	return mod, nil
+}
+
+// Put implements ModulePool.Put
+func (p *modulePool) Put(ctx context.Context, mod api.Module) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	callCtx, ok := mod.(*wasm.CallContext)
+	p.mux.Lock()
+	if ok {
+		_, ok = p.inUse[callCtx]
+		delete(p.inUse, callCtx)
+	}
+	closed := p.closed
+	p.mux.Unlock()
+	if !ok {
+		return fmt.Errorf("%s isn't in use from this pool", mod)
+	}
+
+	if callCtx.FailIfClosed() != nil { // discard
+		return nil
+	} else if closed {
+		return callCtx.Close(ctx)
+	}
+
+	if err := callCtx.Reset(ctx, p.template); err != nil {
+		_ = callCtx.Close(ctx) // don't overwrite the error
+		return err
+	}
+
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	if p.closed { // closed while resetting
+		return callCtx.Close(ctx)
+	}
+	p.idle = append(p.idle, callCtx)
+	return nil
+}
+
+// Close implements api.Closer embedded in ModulePool.
+func (p *modulePool) Close(ctx context.Context) (err error) {
+	p.mux.Lock()
+	idle := p.idle
+	p.idle, p.closed = nil, true
+	p.mux.Unlock()
+
+	for _, mod := range idle {
+		if e := mod.Close(ctx); e != nil && err == nil {
+			err = e
+		}
+	}
+	return
+}
+
+// instantiate instantiates a new instance under a unique name.
+func (p *modulePool) instantiate(ctx context.Context) (*wasm.CallContext, error) {
+	p.mux.Lock()
+	p.sequence++
+	name := fmt.Sprintf("%s#%d", p.name, p.sequence)
+	p.mux.Unlock()
+
+	mod, err := p.ns.InstantiateModule(ctx, p.compiled, p.config.WithName(name))
+	if err != nil {
+		return nil, err
+	}
+	return mod.(*wasm.CallContext), nil
+}
@@ -0,0 +1,77 @@
+package wazero
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/watzero"
+)
+
+func TestModulePool(t *testing.T) {
+	r := NewRuntime()
+	defer r.Close(testCtx)
+
+	bin, err := watzero.Wat2Wasm(`(module
+  (func $grow (param $delta i32) (result (;previous_size;) i32) local.get 0 memory.grow)
+  (memory 1 3)
+  (export "grow" (func $grow))
+)`)
+	require.NoError(t, err)
+	compiled, err := r.CompileModule(testCtx, bin, NewCompileConfig())
+	require.NoError(t, err)
+
+	pool, err := NewModulePool(testCtx, r, compiled, NewModuleConfig().WithName("pool"))
+	require.NoError(t, err)
+	defer pool.Close(testCtx)
+
+	mod, err := pool.Get(testCtx)
+	require.NoError(t, err)
+	require.Equal(t, "pool#1", mod.Name())
+
+	// Change the state of the instance.
+	_, err = mod.ExportedFunction("grow").Call(testCtx, 1)
+	require.NoError(t, err)
+	require.True(t, mod.Memory().WriteByte(testCtx, 0, 1))
+
+	// Another instance is created while the first is in use.
+	other, err := pool.Get(testCtx)
+	require.NoError(t, err)
+	require.Equal(t, "pool#2", other.Name())
+	require.NoError(t, pool.Put(testCtx, other))
+
+	// The instance is reset when put back.
+	require.NoError(t, pool.Put(testCtx, mod))
+	require.EqualError(t, pool.Put(testCtx, mod), "Module[pool#1] isn't in use from this pool")
+	mod, err = pool.Get(testCtx)
+	require.NoError(t, err)
+	require.Equal(t, "pool#1", mod.Name())
+	require.Equal(t, uint32(65536), mod.Memory().Size(testCtx))
+	b, ok := mod.Memory().ReadByte(testCtx, 0)
+	require.True(t, ok)
+	require.Zero(t, b)
+
+	// Closed instances are discarded.
+	require.NoError(t, mod.Close(testCtx))
+	require.NoError(t, pool.Put(testCtx, mod))
+
+	t.Run("not from this pool", func(t *testing.T) {
+		mod, err := r.InstantiateModule(testCtx, compiled, NewModuleConfig().WithName("other"))
+		require.NoError(t, err)
+		defer mod.Close(testCtx)
+		require.EqualError(t, pool.Put(testCtx, mod), "Module[other] isn't in use from this pool")
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		mod, err := pool.Get(testCtx)
+		require.NoError(t, err)
+
+		require.NoError(t, pool.Close(testCtx))
+		require.Nil(t, r.Module("pool#1")) // idle instances are closed
+		_, err = pool.Get(testCtx)
+		require.EqualError(t, err, "module pool closed")
+
+		// Instances in use are closed when put back.
+		require.NoError(t, pool.Put(testCtx, mod))
+		require.Nil(t, r.Module(mod.Name()))
+	})
+}
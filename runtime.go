@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/compilationcache"
 	"github.com/tetratelabs/wazero/internal/wasm"
 	binaryformat "github.com/tetratelabs/wazero/internal/wasm/binary"
 )
@@ -129,7 +130,17 @@ func NewRuntimeWithConfig(rConfig RuntimeConfig) Runtime {
 	if !ok {
 		panic(fmt.Errorf("unsupported wazero.RuntimeConfig implementation: %#v", rConfig))
 	}
-	store, ns := wasm.NewStore(config.enabledFeatures, config.newEngine(config.enabledFeatures))
+	var cache compilationcache.Cache
+	if config.compilationCacheDir != "" {
+		cache = compilationcache.NewFileCache(config.compilationCacheDir)
+	}
+	store, ns := wasm.NewStore(config.enabledFeatures, config.newEngine(config.enabledFeatures, wasm.EngineConfig{
+		EnsureTermination: config.ensureTermination,
+		MeterFuel:         config.meterFuel,
+		Coverage:          config.coverage,
+		Cache:             cache,
+	}))
+	store.MemoryLimitPages = config.memoryLimitPages
 	return &runtime{
 		store:           store,
 		ns:              &namespace{store: store, ns: ns},
@@ -2,13 +2,18 @@ package wazero
 
 import (
 	"context"
+	"crypto/sha256"
 	_ "embed"
+	"encoding/binary"
 	"errors"
 	"math"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/leb128"
+	"github.com/tetratelabs/wazero/internal/platform"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
 	binaryformat "github.com/tetratelabs/wazero/internal/wasm/binary"
@@ -73,7 +78,7 @@ func TestRuntime_CompileModule(t *testing.T) {
 	}
 
 	t.Run("WithMemorySizer", func(t *testing.T) {
-		testWasm := binaryformat.EncodeModule(&wasm.Module{MemorySection: &wasm.Memory{Min: 1}})
+		testWasm := binaryformat.EncodeModule(&wasm.Module{MemorySection: []*wasm.Memory{{Min: 1}}})
 
 		m, err := r.CompileModule(testCtx, testWasm, NewCompileConfig().
 			WithMemorySizer(func(minPages uint32, maxPages *uint32) (min, capacity, max uint32) {
@@ -82,11 +87,11 @@ func TestRuntime_CompileModule(t *testing.T) {
 		require.NoError(t, err)
 		code := m.(*compiledModule)
 
-		require.Equal(t, &wasm.Memory{
+		require.Equal(t, []*wasm.Memory{{
 			Min: 1,
 			Cap: 2,
 			Max: 3,
-		}, code.module.MemorySection)
+		}}, code.module.MemorySection)
 	})
 
 	t.Run("WithImportReplacements", func(t *testing.T) {
@@ -138,6 +143,129 @@ func TestRuntime_CompileModule(t *testing.T) {
 			},
 		}, code.module.ImportSection)
 	})
+
+	t.Run("Definitions", func(t *testing.T) {
+		testBin, err := watzero.Wat2Wasm(`(module $plugin
+  (import "env" "log" (func $log (param i32 i32)))
+  (func $handle (param $ptr i32) (param $len i32) (result i32) local.get 0)
+  (memory 1)
+  (export "handle" (func $handle))
+  (export "memory" (memory 0))
+)`)
+		require.NoError(t, err)
+
+		m, err := r.CompileModule(testCtx, testBin, NewCompileConfig())
+		require.NoError(t, err)
+
+		imported := m.ImportedFunctions()
+		require.Equal(t, 1, len(imported))
+		moduleName, name, isImport := imported[0].Import()
+		require.Equal(t, []interface{}{"env", "log", true}, []interface{}{moduleName, name, isImport})
+		require.Equal(t, []api.ValueType{api.ValueTypeI32, api.ValueTypeI32}, imported[0].ParamTypes())
+
+		handle := m.ExportedFunctions()["handle"]
+		require.Equal(t, "plugin", handle.ModuleName())
+		require.Equal(t, "handle", handle.Name())
+		require.Equal(t, []api.ValueType{api.ValueTypeI32, api.ValueTypeI32}, handle.ParamTypes())
+		require.Equal(t, []string{"ptr", "len"}, handle.ParamNames())
+		require.Equal(t, []api.ValueType{api.ValueTypeI32}, handle.ResultTypes())
+
+		memory := m.ExportedMemories()["memory"]
+		require.Equal(t, uint32(1), memory.Min())
+		_, ok := memory.Max()
+		require.False(t, ok)
+
+		require.Equal(t, 0, len(m.ExportedGlobals()))
+	})
+}
+
+func TestRuntime_CompileModule_CompilationCache(t *testing.T) {
+	if !platform.CompilerSupported() {
+		t.Skip()
+	}
+
+	setUserConfigDir(t)
+
+	dir := t.TempDir()
+	bin, err := watzero.Wat2Wasm(`(module
+  (func $add (param i32 i32) (result i32) local.get 0 local.get 1 i32.add)
+  (export "add" (func $add))
+)`)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ { // the second runtime uses the code the first added to the cache.
+		r := NewRuntimeWithConfig(NewRuntimeConfigCompiler().WithCompilationCache(dir))
+		mod, err := r.InstantiateModuleFromBinary(testCtx, bin)
+		require.NoError(t, err)
+
+		results, err := mod.ExportedFunction("add").Call(testCtx, 1, 2)
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), results[0])
+		require.NoError(t, r.Close(testCtx))
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(entries))
+	}
+}
+
+// TestRuntime_CompileModule_CompilationCache_Tampered ensures an entry whose native code was modified is recompiled,
+// even if its checksum was updated to match, as it isn't authenticated by the HMAC key.
+func TestRuntime_CompileModule_CompilationCache_Tampered(t *testing.T) {
+	if !platform.CompilerSupported() {
+		t.Skip()
+	}
+	setUserConfigDir(t)
+
+	dir := t.TempDir()
+	bin, err := watzero.Wat2Wasm(`(module
+  (func $add (param i32 i32) (result i32) local.get 0 local.get 1 i32.add)
+  (export "add" (func $add))
+)`)
+	require.NoError(t, err)
+
+	compile := func() {
+		r := NewRuntimeWithConfig(NewRuntimeConfigCompiler().WithCompilationCache(dir))
+		defer r.Close(testCtx)
+		mod, err := r.InstantiateModuleFromBinary(testCtx, bin)
+		require.NoError(t, err)
+
+		results, err := mod.ExportedFunction("add").Call(testCtx, 1, 2)
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), results[0])
+	}
+	compile()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(entries))
+	path := filepath.Join(dir, entries[0].Name())
+	original, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	// The entry is the serialized codes, followed by their sha256 and then the HMAC. Modify the native code of the
+	// first function, which follows the header and its stackPointerCeil and len(codeSegment), and fix the sha256.
+	body := append([]byte{}, original[:len(original)-2*sha256.Size]...)
+	versionLen := int(binary.LittleEndian.Uint32(body[7:]))
+	body[6+1+4+versionLen+4+8+8] ^= 0xff
+	checksum := sha256.Sum256(body)
+	tampered := append(append(body, checksum[:]...), original[len(original)-sha256.Size:]...)
+	require.NoError(t, os.WriteFile(path, tampered, 0o600))
+
+	compile() // rejects the tampered entry and compiles again.
+
+	recompiled, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, original, recompiled)
+}
+
+// setUserConfigDir points os.UserConfigDir to a temporary directory, so that tests don't create the key of the
+// compilation cache in the home directory.
+func setUserConfigDir(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir) // linux and freebsd
+	t.Setenv("HOME", dir)            // darwin
+	t.Setenv("AppData", dir)         // windows
 }
 
 func TestRuntime_CompileModule_Errors(t *testing.T) {
@@ -162,7 +290,7 @@ func TestRuntime_CompileModule_Errors(t *testing.T) {
 				return 3, 1, 3
 			}),
 			wasm: binaryformat.EncodeModule(&wasm.Module{
-				MemorySection: &wasm.Memory{Min: 3},
+				MemorySection: []*wasm.Memory{{Min: 3}},
 			}),
 			expectedErr: "section memory: capacity 1 pages (64 Ki) less than minimum 3 pages (192 Ki)",
 		},
@@ -172,7 +300,7 @@ func TestRuntime_CompileModule_Errors(t *testing.T) {
 				return 3, 2, 3
 			}),
 			wasm: binaryformat.EncodeModule(&wasm.Module{
-				MemorySection: &wasm.Memory{},
+				MemorySection: []*wasm.Memory{{}},
 				ExportSection: []*wasm.Export{
 					{Name: "memory", Type: api.ExternTypeMemory},
 				},
@@ -181,7 +309,7 @@ func TestRuntime_CompileModule_Errors(t *testing.T) {
 		},
 		{
 			name:        "memory has too many pages",
-			wasm:        binaryformat.EncodeModule(&wasm.Module{MemorySection: &wasm.Memory{Min: 2, Cap: 2, Max: 70000, IsMaxEncoded: true}}),
+			wasm:        binaryformat.EncodeModule(&wasm.Module{MemorySection: []*wasm.Memory{{Min: 2, Cap: 2, Max: 70000, IsMaxEncoded: true}}}),
 			expectedErr: "section memory: max 70000 pages (4 Gi) over limit of 65536 pages (4 Gi)",
 		},
 	}
@@ -426,7 +554,7 @@ func TestRuntime_InstantiateModuleFromBinary_DoesntEnforce_Start(t *testing.T) {
 	defer r.Close(testCtx)
 
 	binary := binaryformat.EncodeModule(&wasm.Module{
-		MemorySection: &wasm.Memory{Min: 1},
+		MemorySection: []*wasm.Memory{{Min: 1}},
 		ExportSection: []*wasm.Export{{Name: "memory", Type: wasm.ExternTypeMemory, Index: 0}},
 	})
 
@@ -644,7 +772,7 @@ func TestRuntime_CloseWithExitCode(t *testing.T) {
 func TestRuntime_Close_ClosesCompiledModules(t *testing.T) {
 	engine := &mockEngine{name: "mock", cachedModules: map[*wasm.Module]struct{}{}}
 	conf := *engineLessConfig
-	conf.newEngine = func(wasm.Features) wasm.Engine {
+	conf.newEngine = func(wasm.Features, wasm.EngineConfig) wasm.Engine {
 		return engine
 	}
 	r := NewRuntimeWithConfig(&conf)
@@ -695,6 +823,11 @@ func (e *mockEngine) DeleteCompiledModule(module *wasm.Module) {
 	delete(e.cachedModules, module)
 }
 
+// Coverage implements the same method as documented on wasm.Engine.
+func (e *mockEngine) Coverage(*wasm.Module) []*wasm.FunctionCoverage {
+	return nil
+}
+
 // NewModuleEngine implements the same method as documented on wasm.Engine.
 func (e *mockEngine) NewModuleEngine(_ string, _ *wasm.Module, _, _ []*wasm.FunctionInstance, _ []*wasm.TableInstance, _ []wasm.TableInitEntry) (wasm.ModuleEngine, error) {
 	return nil, nil
@@ -0,0 +1,52 @@
+package wazero
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// SnapshotModule returns the state of the module which can change after instantiation, so that RestoreModule can
+// resume it in another instance of the same CompiledModule, possibly in another process.
+//
+// The snapshot includes the memory, mutable globals and tables defined by the module, and which data and element
+// segments were dropped. State the module imports belongs to the module exporting it, so it must be snapshot
+// separately. State of host modules, such as open files, remains the responsibility of the embedder.
+//
+// Ex. To checkpoint a module between calls and resume it later:
+//	snapshot, err := wazero.SnapshotModule(mod)
+//	// ... later, possibly in another process
+//	mod, err := r.InstantiateModule(ctx, compiled, wazero.NewModuleConfig())
+//	err = wazero.RestoreModule(ctx, mod, snapshot)
+//
+// Notes:
+//	* This must not be called concurrently with function calls in the module.
+//	* This fails if a table or global references a function of another module, or a non-null externref, as these
+//	  can't be restored in another process.
+func SnapshotModule(mod api.Module) ([]byte, error) {
+	callCtx, ok := mod.(*wasm.CallContext)
+	if !ok {
+		return nil, fmt.Errorf("unsupported api.Module implementation: %T", mod)
+	}
+	return callCtx.Snapshot()
+}
+
+// RestoreModule applies the snapshot returned by SnapshotModule to the module, which must be instantiated from the
+// same CompiledModule. For example, the memory grows to the size it was in the snapshot.
+//
+// This returns an error, without changing the module, if the snapshot is corrupted, of another module or doesn't
+// fit. Ex. the memory can't grow to the size of the snapshot due to ModuleConfig.WithMemoryLimitPages.
+//
+// Note: This must not be called concurrently with function calls in the module.
+func RestoreModule(ctx context.Context, mod api.Module, snapshot []byte) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	callCtx, ok := mod.(*wasm.CallContext)
+	if !ok {
+		return fmt.Errorf("unsupported api.Module implementation: %T", mod)
+	}
+	return callCtx.Restore(ctx, snapshot)
+}
@@ -19,3 +19,11 @@ type Walltime func(context.Context) (sec int64, nsec int32)
 // Note: There are no constraints on the value return except that it
 // increments. For example, -1 is a valid if the next value is >= 0.
 type Nanotime func(context.Context) int64
+
+// Nanosleep puts the current goroutine to sleep for at least ns nanoseconds,
+// or until the context is done. This is used to implement functions such as
+// "poll_oneoff" in "wasi_snapshot_preview1".
+//
+// Note: Implementations should return early when the context is done, so that
+// a blocked guest can be canceled.
+type Nanosleep func(ctx context.Context, ns int64)
@@ -3,6 +3,8 @@ package sys
 
 import (
 	"fmt"
+
+	"github.com/tetratelabs/wazero/api"
 )
 
 // ExitError is returned to a caller of api.Function still running when api.Module CloseWithExitCode was invoked.
@@ -52,3 +54,172 @@ func (e *ExitError) Is(err error) bool {
 	}
 	return false
 }
+
+// ContextDoneError is returned to a caller of api.Function when its context.Context was done (cancelled or past its
+// deadline) while Wasm code was running. This is only possible when wazero.RuntimeConfig WithEnsureTermination is
+// enabled.
+//
+// Here's an example of how to detect a call was stopped by its context:
+//	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+//	defer cancel()
+//	if _, err := main.Call(ctx); err != nil {
+//		if errors.Is(err, context.DeadlineExceeded) {
+//			// The guest ran longer than a second.
+//		}
+//	--snip--
+//
+// Note: Unlike ExitError, the module is not closed, so it can be called again.
+type ContextDoneError struct {
+	err error
+}
+
+func NewContextDoneError(err error) *ContextDoneError {
+	return &ContextDoneError{err: err}
+}
+
+// Error implements the error interface.
+func (e *ContextDoneError) Error() string {
+	return fmt.Sprintf("context done: %v", e.err)
+}
+
+// Unwrap returns the context.Context Err, which allows use via errors.Is. Ex. errors.Is(err, context.Canceled)
+func (e *ContextDoneError) Unwrap() error {
+	return e.err
+}
+
+// StackTraceError is returned to a caller of api.Function when the call panicked, such as a trap in Wasm code or a
+// panic in a host function, or when a host function returned a non-nil trailing error. The message includes the Wasm stack trace, which Frames returns as data.
+//
+// Here's an example of how to group crash reports by the function that trapped:
+//	if _, err := main.Call(ctx); err != nil {
+//		var stErr *sys.StackTraceError
+//		if errors.As(err, &stErr) && len(stErr.Frames()) > 0 {
+//			top := stErr.Frames()[0]
+//			report(top.ModuleName, top.FunctionIndex, top.Offset)
+//		}
+//	--snip--
+//
+// Note: The cause, such as a trap or the error a host function panicked with or returned, is available via errors.Is and
+// errors.As. Ex. errors.Is(err, context.Canceled) or errors.As(err, &exitErr)
+type StackTraceError struct {
+	message string
+	cause   error
+	frames  []StackFrame
+}
+
+// NewStackTraceError returns an error with the given message, which should include the stack trace of the frames.
+//
+// * cause is the error which panicked, or nil if the panic was with a value that isn't an error. Ex. panic("whoops")
+// * frames should begin with the frame that panicked.
+func NewStackTraceError(message string, cause error, frames []StackFrame) *StackTraceError {
+	return &StackTraceError{message: message, cause: cause, frames: frames}
+}
+
+// Frames returns the function calls which were in progress, innermost first.
+func (e *StackTraceError) Frames() []StackFrame {
+	return e.frames
+}
+
+// Error implements the error interface.
+func (e *StackTraceError) Error() string {
+	return e.message
+}
+
+// Unwrap returns the cause, or nil if the call panicked with a value that isn't an error.
+func (e *StackTraceError) Unwrap() error {
+	return e.cause
+}
+
+// StackFrame is a function call in progress, in the stack trace of a StackTraceError.
+type StackFrame struct {
+	// ModuleName is the possibly empty name of the module defining the function.
+	ModuleName string
+
+	// FunctionIndex is the position in the module's function index namespace, imports first.
+	FunctionIndex uint32
+
+	// FunctionName is the module-defined name of the function, such as from the name section, or empty if it has
+	// none.
+	FunctionName string
+
+	// ParamTypes are the possibly empty sequence of value types accepted by the function.
+	ParamTypes []api.ValueType
+
+	// ParamNames are index-correlated with ParamTypes or nil if not available for one or more parameters.
+	ParamNames []string
+
+	// ResultTypes are the possibly empty sequence of value types returned by the function.
+	ResultTypes []api.ValueType
+
+	// Offset is the position in the function body of the Wasm instruction in progress, such as the one which trapped
+	// or the call to the next frame. This is -1 when unknown, such as in host functions.
+	//
+	// Note: The function body begins after the declaration of locals, so this is not a position in the module.
+	Offset int64
+
+	// Sources are the source code locations of the instruction at Offset, read from DWARF custom sections such as
+	// ".debug_line". This is nil when the module has no debug information for the instruction.
+	//
+	// When the compiler inlined calls, the first location is in the innermost inlined function, followed by each call
+	// site, ending in the source of this function.
+	Sources []SourceLocation
+}
+
+// SourceLocation is a position in source code which was compiled to Wasm, read from DWARF debug information.
+type SourceLocation struct {
+	// File is the path of the source file, as recorded by the compiler.
+	File string
+
+	// Line is the 1-based line number in File, or zero if unknown.
+	Line uint64
+
+	// Column is the 1-based column number in Line, or zero if unknown.
+	Column uint64
+
+	// Inlined is true when this location is in a function inlined into the next location in StackFrame.Sources.
+	Inlined bool
+}
+
+// Exception is a WebAssembly exception of the exception handling proposal, which is thrown by the "throw" instruction
+// and caught by the "catch" clauses of its tag, or by "catch_all".
+//
+// A host function throws an exception into its caller by panicking with it, or by returning it as its trailing
+// error. An exception which isn't caught by any Wasm function is returned to the caller of api.Function, wrapped in
+// a StackTraceError.
+//
+// Here's an example of how to inspect an uncaught exception:
+//	if _, err := main.Call(ctx); err != nil {
+//		var exc *sys.Exception
+//		if errors.As(err, &exc) && exc.Tag() == module.ExportedTag("error") {
+//			errno := api.DecodeI32(exc.Values()[0])
+//		}
+//	--snip--
+//
+// See wazero.RuntimeConfig WithFeatureExceptionHandling
+type Exception struct {
+	tag    api.Tag
+	values []uint64
+}
+
+// NewException returns an exception of the tag, with values encoded according to its api.Tag ParamTypes.
+//
+// Note: The tag must be from a module instantiated by the same wazero.Runtime, such as the result of
+// api.Module ExportedTag.
+func NewException(tag api.Tag, values ...uint64) *Exception {
+	return &Exception{tag: tag, values: values}
+}
+
+// Tag returns the tag which classifies this exception.
+func (e *Exception) Tag() api.Tag {
+	return e.tag
+}
+
+// Values returns the values of this exception, encoded according to the api.Tag ParamTypes.
+func (e *Exception) Values() []uint64 {
+	return e.values
+}
+
+// Error implements the error interface.
+func (e *Exception) Error() string {
+	return fmt.Sprintf("wasm exception with tag %s", e.tag)
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 )
 
@@ -61,3 +62,44 @@ func TestIs(t *testing.T) {
 		})
 	}
 }
+
+func TestStackTraceError(t *testing.T) {
+	exitErr := NewExitError("some module", 2)
+	frames := []StackFrame{{ModuleName: "some module", FunctionName: "main", Offset: 3}}
+	err := NewStackTraceError("module \"some module\" closed with exit_code(2)\nwasm stack trace:\n\tsome module.main()",
+		exitErr, frames)
+
+	require.EqualError(t, err, "module \"some module\" closed with exit_code(2)\nwasm stack trace:\n\tsome module.main()")
+	require.Equal(t, frames, err.Frames())
+	require.True(t, errors.Is(err, NewExitError("some module", 2)))
+
+	var target *ExitError
+	require.True(t, errors.As(err, &target))
+	require.Equal(t, exitErr, target)
+
+	// A panic with a value that isn't an error has no cause.
+	require.Nil(t, NewStackTraceError("whoops (recovered by wazero)", nil, frames).Unwrap())
+}
+
+type tag string
+
+func (t tag) String() string {
+	return string(t)
+}
+
+func (t tag) ParamTypes() []api.ValueType {
+	return []api.ValueType{api.ValueTypeI32}
+}
+
+func TestException(t *testing.T) {
+	exc := NewException(tag("env.error"), 42)
+	require.EqualError(t, exc, "wasm exception with tag env.error")
+	require.Equal(t, tag("env.error"), exc.Tag())
+	require.Equal(t, []uint64{42}, exc.Values())
+
+	// An uncaught exception is returned wrapped in a StackTraceError.
+	err := NewStackTraceError("wasm exception with tag env.error\nwasm stack trace:\n\tmodule.main()", exc, nil)
+	var target *Exception
+	require.True(t, errors.As(err, &target))
+	require.Equal(t, exc, target)
+}
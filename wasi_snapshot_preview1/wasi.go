@@ -16,15 +16,18 @@ package wasi_snapshot_preview1
 
 import (
 	"context"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
+	"math"
 	"path"
 	"time"
 
 	"github.com/tetratelabs/wazero"
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/platform"
 	"github.com/tetratelabs/wazero/internal/sys"
 	"github.com/tetratelabs/wazero/internal/wasm"
 )
@@ -377,7 +380,7 @@ const (
 	importPathUnlinkFile = `(import "wasi_snapshot_preview1" "path_unlink_file"
     (func $wasi.path_unlink_file (param $fd i32) (param $path i32) (param $path_len i32) (result (;errno;) i32)))`
 
-	// functionPollOneoff unlinks a file.
+	// functionPollOneoff concurrently polls for the occurrence of a set of events.
 	// See https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-poll_oneoffin-constpointersubscription-out-pointerevent-nsubscriptions-size---errno-size
 	functionPollOneoff = "poll_oneoff"
 
@@ -1043,9 +1046,158 @@ func (a *wasi) FdRead(ctx context.Context, mod api.Module, fd, iovs, iovsCount,
 	return ErrnoSuccess
 }
 
-// FdReaddir is the WASI function named functionFdReaddir
+// FdReaddir is the WASI function named functionFdReaddir which reads directory entries from a directory.
+//
+// * fd - an opened file descriptor of a directory to read entries from
+// * buf - the offset in `mod.Memory` to write the dirent entries
+// * bufLen - the maximum count of bytes to write to `buf`
+// * cookie - the location in the directory to start reading from, which is zero or the d_next of a previous entry
+// * resultBufused - the offset in `mod.Memory` to write the count of bytes written to `buf`
+//
+// The wasi_snapshot_preview1.Errno returned is wasi_snapshot_preview1.ErrnoSuccess except the following error conditions:
+// * wasi_snapshot_preview1.ErrnoBadf - if `fd` is invalid
+// * wasi_snapshot_preview1.ErrnoNotdir - if `fd` is not a directory
+// * wasi_snapshot_preview1.ErrnoInval - if `cookie` is past the last entry of the directory
+// * wasi_snapshot_preview1.ErrnoFault - if `buf` or `resultBufused` contain an invalid offset due to the memory constraint
+// * wasi_snapshot_preview1.ErrnoIo - if the directory couldn't be read
+//
+// dirent byte layout is a 24-byte header, followed by the name of the entry, which isn't NUL terminated
+// * d_next 8 bytes, to indicate the cookie of the next entry
+// * d_ino 8 bytes, to indicate the inode number of the entry, or zero if not available
+// * d_namlen 4 bytes, to indicate the length of the name in bytes
+// * d_type 1 byte, to indicate the file type of the entry
+// * 3 pad bytes
+//
+// For example, with a directory corresponding with `fd` that only contains the regular file (=4) "ab", without an
+// inode number, and parameters buf=1 bufLen=26 cookie=0, this function writes the below to `mod.Memory`:
+//
+//                uint64le                uint64le              uint32le       padding
+//              +--------------------+  +--------------------+  +--------+     +-----+
+//              |                    |  |                    |  |        |     |     |
+//    []byte{?, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 4, 0, 0, 0, 'a', 'b', ?}
+//     buf --^  ^-- d_next              ^-- d_ino               ^- d_namlen ^           ^-- name
+//                                                                          |
+//                                                                          +-- d_type
+//
+// Notes
+//
+//	* The entries are read with fs.ReadDirFile when `cookie` is zero, and kept for subsequent calls, so that cookies
+//	  are stable until the directory is read again from zero.
+//	* As many entries as fit are written to `buf`, the last of which is truncated when it doesn't fit. When
+//	  `resultBufused` is `bufLen`, there may be more entries, so the caller should read again from the d_next of the
+//	  last complete entry, with a larger buffer if it wasn't able to read any complete entry.
+//	* The "." and ".." entries aren't included as fs.FS doesn't return them.
+//	* importFdReaddir shows this signature in the WebAssembly 1.0 (20191205) Text Format.
+//	* This is similar to `getdirentries` in BSD.
+//
+// See https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-dirent-struct
+// See https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#fd_readdir
+// See https://man.openbsd.org/OpenBSD-5.4/getdirentries.2
 func (a *wasi) FdReaddir(ctx context.Context, mod api.Module, fd, buf, bufLen uint32, cookie uint64, resultBufused uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	_, fsc := sysFSCtx(ctx, mod)
+
+	entry, ok := fsc.OpenedFile(fd)
+	if !ok || entry.FS == nil {
+		return ErrnoBadf
+	}
+
+	if cookie == 0 || entry.DirEntries == nil {
+		entries, errno := readDirEntries(entry)
+		if errno != ErrnoSuccess {
+			return errno
+		}
+		entry.DirEntries = entries
+	}
+	if cookie > uint64(len(entry.DirEntries)) {
+		return ErrnoInval
+	}
+
+	out, ok := mod.Memory().Read(ctx, buf, bufLen)
+	if !ok {
+		return ErrnoFault
+	}
+
+	var bufused uint32
+	for i := cookie; i < uint64(len(entry.DirEntries)) && bufused < bufLen; i++ {
+		bufused += writeDirent(out[bufused:], entry.DirEntries[i], i+1)
+	}
+
+	if !mod.Memory().WriteUint32Le(ctx, resultBufused, bufused) {
+		return ErrnoFault
+	}
+	return ErrnoSuccess
+}
+
+// readDirEntries reads all entries of the directory from a new fs.ReadDirFile, so that it reads from the beginning.
+func readDirEntries(entry *sys.FileEntry) ([]fs.DirEntry, Errno) {
+	name := entry.Path
+	if entry.File == nil { // a mount like "." or "/" is the root of its fs.FS
+		name = "."
+	} else if stat, err := entry.File.Stat(); err != nil {
+		return nil, ErrnoIo
+	} else if !stat.IsDir() {
+		return nil, ErrnoNotdir
+	}
+
+	f, err := entry.FS.Open(name)
+	if err != nil {
+		return nil, ErrnoIo
+	}
+	defer f.Close()
+
+	dir, ok := f.(fs.ReadDirFile)
+	if !ok {
+		return nil, ErrnoNotdir
+	}
+	entries, err := dir.ReadDir(-1)
+	if err != nil {
+		return nil, ErrnoIo
+	} else if entries == nil {
+		entries = []fs.DirEntry{} // non-nil, so that an empty directory isn't read again
+	}
+	return entries, ErrnoSuccess
+}
+
+// writeDirent writes the dirent of the entry to buf, truncating it to the length of buf, and returns the count of
+// bytes written.
+//
+// See https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-dirent-struct
+func writeDirent(buf []byte, entry fs.DirEntry, next uint64) uint32 {
+	var ino uint64
+	if info, err := entry.Info(); err == nil {
+		ino = platform.Inode(info)
+	}
+	name := entry.Name()
+
+	dirent := make([]byte, direntLen+len(name))
+	binary.LittleEndian.PutUint64(dirent, next)
+	binary.LittleEndian.PutUint64(dirent[8:], ino)
+	binary.LittleEndian.PutUint32(dirent[16:], uint32(len(name)))
+	dirent[20] = fileType(entry.Type())
+	copy(dirent[direntLen:], name)
+	return uint32(copy(buf, dirent))
+}
+
+// fileType returns the WASI filetype of the type bits of fs.FileMode.
+//
+// See https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-filetype-enumu8
+func fileType(mode fs.FileMode) byte {
+	switch mode & fs.ModeType {
+	case 0:
+		return fileTypeRegularFile
+	case fs.ModeDir:
+		return fileTypeDirectory
+	case fs.ModeSymlink:
+		return fileTypeSymbolicLink
+	case fs.ModeDevice | fs.ModeCharDevice:
+		return fileTypeCharacterDevice
+	case fs.ModeDevice:
+		return fileTypeBlockDevice
+	case fs.ModeSocket:
+		return fileTypeSocketStream
+	default: // such as fs.ModeNamedPipe, which doesn't have a WASI filetype
+		return fileTypeUnknown
+	}
 }
 
 // FdRenumber is the WASI function named functionFdRenumber
@@ -1339,9 +1491,260 @@ func (a *wasi) PathUnlinkFile(ctx context.Context, mod api.Module, fd, path, pat
 	return ErrnoNosys // stubbed for GrainLang per #271
 }
 
-// PollOneoff is the WASI function named functionPollOneoff
+// PollOneoff is the WASI function named functionPollOneoff that concurrently polls for the occurrence of a set of
+// events.
+//
+// * in - the offset in `mod.Memory` to read `nsubscriptions` subscriptions of 48 bytes each
+// * out - the offset in `mod.Memory` to write the resulting events of 32 bytes each
+// * nsubscriptions - the count of subscriptions, which must be at least one
+// * resultNevents - the offset in `mod.Memory` to write the count of events written to `out`
+//
+// The wasi_snapshot_preview1.Errno returned is wasi_snapshot_preview1.ErrnoSuccess except the following error conditions:
+// * wasi_snapshot_preview1.ErrnoInval - if `nsubscriptions` is zero or a subscription has an invalid event type
+// * wasi_snapshot_preview1.ErrnoFault - if `in`, `out` or `resultNevents` contain an invalid offset due to the memory
+//   constraint
+// * wasi_snapshot_preview1.ErrnoIntr - if the context.Context of the call is done while sleeping
+//
+// Errors of each subscription, such as an invalid clock ID or file descriptor, are written to the error of its event.
+//
+// Notes
+//
+//	* Clock subscriptions use the sys.Walltime and sys.Nanotime of the module when absolute, and sleep with its
+//	  sys.Nanosleep. These default to fakes, so see wazero.ModuleConfig WithNanosleep and WithSysNanosleep.
+//	* When there are only clock subscriptions, this sleeps until the soonest, and returns the events of those expired.
+//	* The fd_read and fd_write subscriptions of stdout, stderr and open files are always ready, so this returns them
+//	  without sleeping, with clock subscriptions which already expired. The nbytes of fd_read are the bytes until the
+//	  end of a file.
+//	* The fd_read subscription of stdin is ready when it has data or fails, such as with io.EOF. Until then, this
+//	  sleeps until the soonest clock subscription expires, or until stdin is ready. Stdin which has a Len method, such
+//	  as bytes.Buffer, is always ready, with nbytes of its Len. Otherwise, such as os.File, stdin is read in the
+//	  background once polled, and nbytes are the bytes buffered.
+//	* importPollOneoff shows this signature in the WebAssembly 1.0 (20191205) Text Format.
+//	* This is similar to `poll` in POSIX.
+//
+// See https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#poll_oneoff
+// See https://linux.die.net/man/3/poll
 func (a *wasi) PollOneoff(ctx context.Context, mod api.Module, in, out, nsubscriptions, resultNevents uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	if nsubscriptions == 0 {
+		return ErrnoInval
+	}
+
+	mem := mod.Memory()
+	// Ensure capacity prior to the loop, to reduce error handling.
+	if uint64(nsubscriptions)*subscriptionLen > math.MaxUint32 {
+		return ErrnoFault
+	}
+	inBuf, ok := mem.Read(ctx, in, nsubscriptions*subscriptionLen)
+	if !ok {
+		return ErrnoFault
+	}
+	outBuf, ok := mem.Read(ctx, out, nsubscriptions*eventLen)
+	if !ok {
+		return ErrnoFault
+	}
+
+	sysCtx, fsCtx := sysFSCtx(ctx, mod)
+
+	// timeouts are the nanoseconds until each clock subscription expires, or -1 for other subscriptions.
+	timeouts := make([]int64, nsubscriptions)
+	// stdinSubs are the indexes of fd_read subscriptions of stdin which aren't ready, yet, and stdinReady is closed
+	// when they are.
+	var stdinSubs []uint32
+	var stdinReady <-chan struct{}
+	var nevents uint32
+	for i := range timeouts {
+		timeouts[i] = -1
+		sub := inBuf[uint32(i)*subscriptionLen:]
+		eventType := sub[8]
+		var errno Errno
+		var nbytes uint64
+		switch eventType {
+		case eventTypeClock:
+			if timeouts[i], errno = clockTimeout(ctx, sysCtx, sub[16:]); errno == ErrnoSuccess {
+				continue // written after sleeping
+			}
+			timeouts[i] = -1
+		case eventTypeFdRead, eventTypeFdWrite:
+			fd := binary.LittleEndian.Uint32(sub[16:])
+			if fd == fdStdin && eventType == eventTypeFdRead {
+				if stdinReady = sysCtx.StdinReady(); !isReady(stdinReady) {
+					stdinSubs = append(stdinSubs, uint32(i))
+					continue // written if ready after sleeping
+				}
+			}
+			nbytes, errno = pollFd(sysCtx, fsCtx, fd, eventType)
+		default:
+			return ErrnoInval
+		}
+		writeEvent(outBuf[nevents*eventLen:], sub, eventType, errno, nbytes)
+		nevents++
+	}
+
+	// When no events are ready, sleep until the soonest clock subscription expires, or stdin is ready.
+	var elapsed int64
+	if nevents == 0 {
+		elapsed = -1 // no clock subscriptions
+		for _, t := range timeouts {
+			if t >= 0 && (elapsed < 0 || t < elapsed) {
+				elapsed = t
+			}
+		}
+		if len(stdinSubs) == 0 {
+			sysCtx.Nanosleep(ctx, elapsed)
+		} else if waitStdin(ctx, sysCtx, stdinReady, elapsed) {
+			elapsed = 0 // only clock subscriptions which already expired
+			for _, i := range stdinSubs {
+				sub := inBuf[i*subscriptionLen:]
+				writeEvent(outBuf[nevents*eventLen:], sub, eventTypeFdRead, ErrnoSuccess, uint64(sysCtx.StdinBuffered()))
+				nevents++
+			}
+		}
+		if ctx.Err() != nil {
+			return ErrnoIntr
+		}
+	}
+	for i, t := range timeouts {
+		if t >= 0 && t <= elapsed {
+			writeEvent(outBuf[nevents*eventLen:], inBuf[uint32(i)*subscriptionLen:], eventTypeClock, ErrnoSuccess, 0)
+			nevents++
+		}
+	}
+
+	if !mem.WriteUint32Le(ctx, resultNevents, nevents) {
+		return ErrnoFault
+	}
+	return ErrnoSuccess
+}
+
+// clockTimeout returns the nanoseconds until the subscription_clock expires, which are zero when it already did.
+//
+// See https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-subscription_clock-struct
+func clockTimeout(ctx context.Context, sysCtx *sys.Context, subClock []byte) (int64, Errno) {
+	id := binary.LittleEndian.Uint32(subClock)
+	timeout := binary.LittleEndian.Uint64(subClock[8:])
+	// subClock[16:24] is the precision, which is ignored like ClockTimeGet.
+	flags := binary.LittleEndian.Uint16(subClock[24:])
+
+	var now uint64
+	switch id {
+	case clockIDRealtime:
+		if flags&subclockflagsSubscriptionClockAbstime != 0 {
+			sec, nsec := sysCtx.Walltime(ctx)
+			now = (uint64(sec) * uint64(time.Second.Nanoseconds())) + uint64(nsec)
+		}
+	case clockIDMonotonic:
+		if flags&subclockflagsSubscriptionClockAbstime != 0 {
+			now = uint64(sysCtx.Nanotime(ctx))
+		}
+	default:
+		return 0, ErrnoInval
+	}
+
+	if timeout <= now {
+		return 0, ErrnoSuccess
+	} else if timeout -= now; timeout > math.MaxInt64 {
+		return math.MaxInt64, ErrnoSuccess
+	}
+	return int64(timeout), ErrnoSuccess
+}
+
+// waitStdin waits until stdin is ready, or until the timeout elapsed according to sys.Nanosleep, unless negative, or
+// until the context is done. This returns true if stdin is ready.
+func waitStdin(ctx context.Context, sysCtx *sys.Context, ready <-chan struct{}, timeout int64) bool {
+	if timeout < 0 {
+		select {
+		case <-ready:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	sleepCtx, cancel := context.WithCancel(ctx)
+	defer cancel() // stops sleeping if stdin is ready first
+	slept := make(chan struct{})
+	go func() {
+		sysCtx.Nanosleep(sleepCtx, timeout)
+		close(slept)
+	}()
+	select {
+	case <-ready:
+		return true
+	case <-slept:
+	case <-ctx.Done():
+	}
+	return isReady(ready)
+}
+
+// isReady returns true if the channel is closed.
+func isReady(ready <-chan struct{}) bool {
+	select {
+	case <-ready:
+		return true
+	default:
+		return false
+	}
+}
+
+// pollFd returns the readiness of a fd_read or fd_write subscription, and the bytes available to read.
+func pollFd(sysCtx *sys.Context, fsCtx *sys.FSContext, fd uint32, eventType byte) (uint64, Errno) {
+	switch fd {
+	case fdStdin:
+		if eventType != eventTypeFdRead {
+			return 0, ErrnoBadf
+		}
+		return uint64(sysCtx.StdinBuffered()), ErrnoSuccess
+	case fdStdout, fdStderr:
+		if eventType != eventTypeFdWrite {
+			return 0, ErrnoBadf
+		}
+		return 0, ErrnoSuccess
+	}
+
+	f, ok := fsCtx.OpenedFile(fd)
+	if !ok || f.File == nil {
+		return 0, ErrnoBadf
+	}
+	if eventType == eventTypeFdWrite {
+		// fs.FS doesn't declare io.Writer, but implementations such as os.File implement it.
+		if _, ok = f.File.(io.Writer); !ok {
+			return 0, ErrnoBadf
+		}
+		return 0, ErrnoSuccess
+	}
+
+	stat, err := f.File.Stat()
+	if err != nil {
+		return 0, ErrnoIo
+	} else if stat.IsDir() {
+		return 0, ErrnoBadf
+	}
+	remaining := stat.Size()
+	if seeker, ok := f.File.(io.Seeker); ok {
+		if offset, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			remaining -= offset
+		}
+	}
+	if remaining < 0 {
+		remaining = 0
+	}
+	return uint64(remaining), ErrnoSuccess
+}
+
+// writeEvent writes the event of the subscription to buf.
+//
+// See https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-event-struct
+func writeEvent(buf, sub []byte, eventType byte, errno Errno, nbytes uint64) {
+	copy(buf, sub[:8]) // userdata
+	binary.LittleEndian.PutUint16(buf[8:], uint16(errno))
+	buf[10] = eventType
+	for i := 11; i < 16; i++ {
+		buf[i] = 0 // padding
+	}
+	binary.LittleEndian.PutUint64(buf[16:], nbytes)
+	for i := 24; i < eventLen; i++ {
+		buf[i] = 0 // flags and padding
+	}
 }
 
 // ProcExit is the WASI function that terminates the execution of the module with an exit code.
@@ -1419,6 +1822,39 @@ const (
 	fdStderr = 2
 )
 
+// https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-filetype-enumu8
+const (
+	fileTypeUnknown         = 0
+	fileTypeBlockDevice     = 1
+	fileTypeCharacterDevice = 2
+	fileTypeDirectory       = 3
+	fileTypeRegularFile     = 4
+	fileTypeSocketDgram     = 5
+	fileTypeSocketStream    = 6
+	fileTypeSymbolicLink    = 7
+)
+
+// direntLen is the length of the dirent header, which precedes its name.
+// See https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-dirent-struct
+const direntLen = 24
+
+// https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-eventtype-enumu8
+const (
+	eventTypeClock   = 0
+	eventTypeFdRead  = 1
+	eventTypeFdWrite = 2
+)
+
+// https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-subclockflags-flagsu16
+const subclockflagsSubscriptionClockAbstime = 1
+
+// https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-subscription-struct
+// https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-event-struct
+const (
+	subscriptionLen = 48
+	eventLen        = 32
+)
+
 // https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-clockid-enumu32
 const (
 	clockIDRealtime  = 0
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	_ "embed"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
@@ -15,6 +16,7 @@ import (
 	"testing"
 	"testing/fstest"
 	"testing/iotest"
+	"time"
 
 	"github.com/tetratelabs/wazero"
 	"github.com/tetratelabs/wazero/api"
@@ -1277,22 +1279,201 @@ func TestSnapshotPreview1_FdRead_Errors(t *testing.T) {
 	}
 }
 
-// TestSnapshotPreview1_FdReaddir only tests it is stubbed for GrainLang per #271
 func TestSnapshotPreview1_FdReaddir(t *testing.T) {
-	mod, fn := instantiateModule(testCtx, t, functionFdReaddir, importFdReaddir, nil)
-	defer mod.Close(testCtx)
+	testFS := fstest.MapFS{
+		"dir/ab":  {Data: []byte("wazero")},
+		"dir/sub": {Mode: fs.ModeDir},
+		"file":    {Data: []byte("wazero")},
+	}
+	dir, err := testFS.Open("dir")
+	require.NoError(t, err)
 
-	t.Run("wasi.FdReaddir", func(t *testing.T) {
-		errno := a.FdReaddir(testCtx, mod, 0, 0, 0, 0, 0)
-		require.Equal(t, ErrnoNosys, errno, ErrnoName(errno))
+	fdRoot, fdDir := uint32(3), uint32(4)
+	sysCtx, err := newSysContext(nil, nil, map[uint32]*internalsys.FileEntry{
+		fdRoot: {Path: ".", FS: testFS},
+		fdDir:  {Path: "dir", FS: testFS, File: dir},
 	})
+	require.NoError(t, err)
 
-	t.Run(functionFdReaddir, func(t *testing.T) {
-		results, err := fn.Call(testCtx, 0, 0, 0, 0, 0)
-		require.NoError(t, err)
-		errno := Errno(results[0]) // results[0] is the errno
-		require.Equal(t, ErrnoNosys, errno, ErrnoName(errno))
+	mod, fn := instantiateModule(testCtx, t, functionFdReaddir, importFdReaddir, sysCtx)
+	defer mod.Close(testCtx)
+
+	ab := dirent(1, 0, fileTypeRegularFile, "ab")
+	sub := dirent(2, 0, fileTypeDirectory, "sub")
+
+	buf, resultBufused := uint32(0), uint32(256) // arbitrary, non-overlapping offsets
+	tests := []struct {
+		name            string
+		fd, bufLen      uint32
+		cookie          uint64
+		expectedDirents []byte
+	}{
+		{
+			name:            "all entries",
+			fd:              fdDir,
+			bufLen:          128,
+			expectedDirents: append(append([]byte{}, ab...), sub...),
+		},
+		{
+			name:            "from cookie",
+			fd:              fdDir,
+			bufLen:          128,
+			cookie:          1,
+			expectedDirents: sub,
+		},
+		{
+			name:            "from last cookie",
+			fd:              fdDir,
+			bufLen:          128,
+			cookie:          2,
+			expectedDirents: []byte{},
+		},
+		{
+			name:            "truncates the last entry",
+			fd:              fdDir,
+			bufLen:          uint32(len(ab)) + 4,
+			expectedDirents: append(append([]byte{}, ab...), sub[:4]...),
+		},
+		{
+			name:            "buffer smaller than an entry",
+			fd:              fdDir,
+			bufLen:          direntLen - 1,
+			expectedDirents: ab[:direntLen-1],
+		},
+		{
+			name:            "mount",
+			fd:              fdRoot,
+			bufLen:          128,
+			expectedDirents: append(dirent(1, 0, fileTypeDirectory, "dir"), dirent(2, 0, fileTypeRegularFile, "file")...),
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			maskMemory(t, testCtx, mod, int(resultBufused)+4)
+
+			results, err := fn.Call(testCtx, uint64(tc.fd), uint64(buf), uint64(tc.bufLen), tc.cookie, uint64(resultBufused))
+			require.NoError(t, err)
+			errno := Errno(results[0]) // results[0] is the errno
+			require.Equal(t, ErrnoSuccess, errno, ErrnoName(errno))
+
+			bufused, ok := mod.Memory().ReadUint32Le(testCtx, resultBufused)
+			require.True(t, ok)
+			actual, ok := mod.Memory().Read(testCtx, buf, bufused)
+			require.True(t, ok)
+			require.Equal(t, tc.expectedDirents, actual)
+
+			// Ensure nothing was written past bufused.
+			next, ok := mod.Memory().ReadByte(testCtx, buf+bufused)
+			require.True(t, ok)
+			require.Equal(t, byte('?'), next)
+		})
+	}
+}
+
+func TestSnapshotPreview1_FdReaddir_StableCookies(t *testing.T) {
+	testFS := fstest.MapFS{"b": {}, "c": {}}
+	sysCtx, err := newSysContext(nil, nil, map[uint32]*internalsys.FileEntry{3: {Path: ".", FS: testFS}})
+	require.NoError(t, err)
+
+	mod, _ := instantiateModule(testCtx, t, functionFdReaddir, importFdReaddir, sysCtx)
+	defer mod.Close(testCtx)
+
+	readdir := func(cookie uint64) []byte {
+		buf, bufLen, resultBufused := uint32(0), uint32(128), uint32(256) // arbitrary, non-overlapping offsets
+		errno := a.FdReaddir(testCtx, mod, 3, buf, bufLen, cookie, resultBufused)
+		require.Equal(t, ErrnoSuccess, errno, ErrnoName(errno))
+		bufused, ok := mod.Memory().ReadUint32Le(testCtx, resultBufused)
+		require.True(t, ok)
+		dirents, ok := mod.Memory().Read(testCtx, buf, bufused)
+		require.True(t, ok)
+		return append([]byte{}, dirents...)
+	}
+
+	require.Equal(t, append(dirent(1, 0, fileTypeRegularFile, "b"), dirent(2, 0, fileTypeRegularFile, "c")...), readdir(0))
+
+	// Adding an entry that sorts first doesn't change the cookies until the directory is read again from zero.
+	testFS["a"] = &fstest.MapFile{}
+	require.Equal(t, dirent(2, 0, fileTypeRegularFile, "c"), readdir(1))
+	require.Equal(t, append(append(dirent(1, 0, fileTypeRegularFile, "a"),
+		dirent(2, 0, fileTypeRegularFile, "b")...),
+		dirent(3, 0, fileTypeRegularFile, "c")...), readdir(0))
+}
+
+func TestSnapshotPreview1_FdReaddir_Errors(t *testing.T) {
+	testFS := fstest.MapFS{"dir/a": {}, "file": {Data: []byte("wazero")}}
+	file, err := testFS.Open("file")
+	require.NoError(t, err)
+
+	fdRoot, fdFile := uint32(3), uint32(4)
+	sysCtx, err := newSysContext(nil, nil, map[uint32]*internalsys.FileEntry{
+		fdRoot: {Path: ".", FS: testFS},
+		fdFile: {Path: "file", FS: testFS, File: file},
 	})
+	require.NoError(t, err)
+
+	mod, _ := instantiateModule(testCtx, t, functionFdReaddir, importFdReaddir, sysCtx)
+	defer mod.Close(testCtx)
+	memorySize := mod.Memory().Size(testCtx)
+
+	tests := []struct {
+		name                           string
+		fd, buf, bufLen, resultBufused uint32
+		cookie                         uint64
+		expectedErrno                  Errno
+	}{
+		{
+			name:          "invalid fd",
+			fd:            42, // arbitrary invalid fd
+			expectedErrno: ErrnoBadf,
+		},
+		{
+			name:          "not a directory",
+			fd:            fdFile,
+			expectedErrno: ErrnoNotdir,
+		},
+		{
+			name:          "cookie past the last entry",
+			fd:            fdRoot,
+			cookie:        3,
+			expectedErrno: ErrnoInval,
+		},
+		{
+			name:          "buf out of memory",
+			fd:            fdRoot,
+			buf:           memorySize - 10,
+			bufLen:        11,
+			expectedErrno: ErrnoFault,
+		},
+		{
+			name:          "resultBufused out of memory",
+			fd:            fdRoot,
+			bufLen:        128,
+			resultBufused: memorySize - 3,
+			expectedErrno: ErrnoFault,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			errno := a.FdReaddir(testCtx, mod, tc.fd, tc.buf, tc.bufLen, tc.cookie, tc.resultBufused)
+			require.Equal(t, tc.expectedErrno, errno, ErrnoName(errno))
+		})
+	}
+}
+
+// dirent returns a dirent with its name in the little-endian layout of WASI.
+func dirent(next, ino uint64, fileType byte, name string) []byte {
+	d := make([]byte, direntLen, direntLen+len(name))
+	binary.LittleEndian.PutUint64(d, next)
+	binary.LittleEndian.PutUint64(d[8:], ino)
+	binary.LittleEndian.PutUint32(d[16:], uint32(len(name)))
+	d[20] = fileType
+	return append(d, name...)
 }
 
 // TestSnapshotPreview1_FdRenumber only tests it is stubbed for GrainLang per #271
@@ -2001,24 +2182,362 @@ func TestSnapshotPreview1_PathUnlinkFile(t *testing.T) {
 	})
 }
 
-// TestSnapshotPreview1_PollOneoff only tests it is stubbed for GrainLang per #271
 func TestSnapshotPreview1_PollOneoff(t *testing.T) {
-	mod, fn := instantiateModule(testCtx, t, functionPollOneoff, importPollOneoff, nil)
+	var slept []int64
+	nanosleep := sys.Nanosleep(func(_ context.Context, ns int64) { slept = append(slept, ns) })
+	sysCtx, err := internalsys.NewContext(
+		math.MaxUint32,
+		nil,
+		nil,
+		bytes.NewBufferString("wazero"),
+		nil,
+		nil,
+		deterministicRandomSource(),
+		nil, 0,
+		nil, 0,
+		&nanosleep,
+		nil,
+	)
+	require.NoError(t, err)
+
+	mod, fn := instantiateModule(testCtx, t, functionPollOneoff, importPollOneoff, sysCtx)
 	defer mod.Close(testCtx)
 
-	t.Run("wasi.PollOneoff", func(t *testing.T) {
-		errno := a.PollOneoff(testCtx, mod, 0, 0, 0, 0)
-		require.Equal(t, ErrnoNosys, errno, ErrnoName(errno))
+	in, out, resultNevents := uint32(0), uint32(256), uint32(512) // arbitrary, non-overlapping offsets
+
+	tests := []struct {
+		name           string
+		subscriptions  [][]byte
+		expectedSlept  []int64
+		expectedEvents [][]byte
+	}{
+		{
+			name: "relative clocks sleep until the soonest",
+			subscriptions: [][]byte{
+				clockSubscription(1, clockIDMonotonic, 20, 0),
+				clockSubscription(2, clockIDRealtime, 10, 0),
+			},
+			expectedSlept:  []int64{10},
+			expectedEvents: [][]byte{event(2, ErrnoSuccess, eventTypeClock, 0)},
+		},
+		{
+			name: "absolute clock in the past",
+			subscriptions: [][]byte{
+				clockSubscription(1, clockIDMonotonic, uint64(platform.FakeEpochNanos-1), subclockflagsSubscriptionClockAbstime),
+			},
+			expectedSlept:  []int64{0},
+			expectedEvents: [][]byte{event(1, ErrnoSuccess, eventTypeClock, 0)},
+		},
+		{
+			name: "absolute clock in the future",
+			subscriptions: [][]byte{
+				clockSubscription(1, clockIDRealtime, uint64(platform.FakeEpochNanos+5), subclockflagsSubscriptionClockAbstime),
+			},
+			expectedSlept:  []int64{5},
+			expectedEvents: [][]byte{event(1, ErrnoSuccess, eventTypeClock, 0)},
+		},
+		{
+			name: "relative clock overflows",
+			subscriptions: [][]byte{
+				clockSubscription(1, clockIDMonotonic, math.MaxUint64, 0),
+			},
+			expectedSlept:  []int64{math.MaxInt64},
+			expectedEvents: [][]byte{event(1, ErrnoSuccess, eventTypeClock, 0)},
+		},
+		{
+			name: "stdio doesn't sleep",
+			subscriptions: [][]byte{
+				clockSubscription(1, clockIDMonotonic, 10, 0),
+				fdSubscription(2, eventTypeFdRead, fdStdin),
+				fdSubscription(3, eventTypeFdWrite, fdStdout),
+				clockSubscription(4, clockIDMonotonic, 0, 0),
+			},
+			expectedEvents: [][]byte{
+				event(2, ErrnoSuccess, eventTypeFdRead, 6), // len("wazero")
+				event(3, ErrnoSuccess, eventTypeFdWrite, 0),
+				event(4, ErrnoSuccess, eventTypeClock, 0),
+			},
+		},
+		{
+			name: "subscription errors",
+			subscriptions: [][]byte{
+				clockSubscription(1, 2 /* PROCESS_CPUTIME_ID */, 10, 0),
+				fdSubscription(2, eventTypeFdWrite, fdStdin),
+				fdSubscription(3, eventTypeFdRead, fdStderr),
+				fdSubscription(4, eventTypeFdRead, 42),
+			},
+			expectedEvents: [][]byte{
+				event(1, ErrnoInval, eventTypeClock, 0),
+				event(2, ErrnoBadf, eventTypeFdWrite, 0),
+				event(3, ErrnoBadf, eventTypeFdRead, 0),
+				event(4, ErrnoBadf, eventTypeFdRead, 0),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			slept = nil
+			maskMemory(t, testCtx, mod, int(resultNevents)+4)
+			require.True(t, mod.Memory().Write(testCtx, in, bytes.Join(tc.subscriptions, nil)))
+
+			results, err := fn.Call(testCtx, uint64(in), uint64(out), uint64(len(tc.subscriptions)), uint64(resultNevents))
+			require.NoError(t, err)
+			errno := Errno(results[0]) // results[0] is the errno
+			require.Equal(t, ErrnoSuccess, errno, ErrnoName(errno))
+
+			require.Equal(t, tc.expectedSlept, slept)
+			nevents, ok := mod.Memory().ReadUint32Le(testCtx, resultNevents)
+			require.True(t, ok)
+			require.Equal(t, uint32(len(tc.expectedEvents)), nevents)
+			actual, ok := mod.Memory().Read(testCtx, out, nevents*eventLen)
+			require.True(t, ok)
+			require.Equal(t, bytes.Join(tc.expectedEvents, nil), actual)
+		})
+	}
+}
+
+func TestSnapshotPreview1_PollOneoff_Files(t *testing.T) {
+	tmpDir := t.TempDir() // open before loop to ensure no locking problems.
+
+	file, testFS := createFile(t, "test_path", []byte("wazero"))
+	dir, dirFS := createFile(t, "dir", nil)
+	writeable, writeableFS := createWriteableFile(t, tmpDir, "writeable", []byte("wazero"))
+	_, err := file.Read(make([]byte, 2)) // advance the offset, to ensure nbytes are the remaining.
+	require.NoError(t, err)
+
+	fdFile, fdDir, fdWriteable := uint32(3), uint32(4), uint32(5)
+	sysCtx, err := newSysContext(nil, nil, map[uint32]*internalsys.FileEntry{
+		fdFile:      {Path: "test_path", FS: testFS, File: file},
+		fdDir:       {Path: "dir", FS: dirFS, File: dir},
+		fdWriteable: {Path: "writeable", FS: writeableFS, File: writeable},
 	})
+	require.NoError(t, err)
 
-	t.Run(functionPollOneoff, func(t *testing.T) {
-		results, err := fn.Call(testCtx, 0, 0, 0, 0)
+	mod, _ := instantiateModule(testCtx, t, functionPollOneoff, importPollOneoff, sysCtx)
+	defer mod.Close(testCtx)
+
+	in, out, resultNevents := uint32(0), uint32(256), uint32(512) // arbitrary, non-overlapping offsets
+	subscriptions := [][]byte{
+		fdSubscription(1, eventTypeFdRead, fdFile),
+		fdSubscription(2, eventTypeFdWrite, fdFile),
+		fdSubscription(3, eventTypeFdRead, fdDir),
+		fdSubscription(4, eventTypeFdRead, fdWriteable),
+		fdSubscription(5, eventTypeFdWrite, fdWriteable),
+	}
+	require.True(t, mod.Memory().Write(testCtx, in, bytes.Join(subscriptions, nil)))
+
+	errno := a.PollOneoff(testCtx, mod, in, out, uint32(len(subscriptions)), resultNevents)
+	require.Equal(t, ErrnoSuccess, errno, ErrnoName(errno))
+
+	nevents, ok := mod.Memory().ReadUint32Le(testCtx, resultNevents)
+	require.True(t, ok)
+	require.Equal(t, uint32(len(subscriptions)), nevents)
+	actual, ok := mod.Memory().Read(testCtx, out, nevents*eventLen)
+	require.True(t, ok)
+	require.Equal(t, bytes.Join([][]byte{
+		event(1, ErrnoSuccess, eventTypeFdRead, 4), // len("zero")
+		event(2, ErrnoBadf, eventTypeFdWrite, 0),   // fstest.MapFS is read-only
+		event(3, ErrnoBadf, eventTypeFdRead, 0),
+		event(4, ErrnoSuccess, eventTypeFdRead, 6), // len("wazero")
+		event(5, ErrnoSuccess, eventTypeFdWrite, 0),
+	}, nil), actual)
+}
+
+// TestSnapshotPreview1_PollOneoff_Stdin ensures stdin which can block, such as an os.File, isn't ready until it has
+// data, so that an event loop can wait for it with a timeout.
+func TestSnapshotPreview1_PollOneoff_Stdin(t *testing.T) {
+	stdin, stdinWriter := io.Pipe()
+	defer stdinWriter.Close()
+	nanosleep := sys.Nanosleep(platform.Nanosleep)
+	sysCtx, err := internalsys.NewContext(
+		math.MaxUint32,
+		nil,
+		nil,
+		stdin,
+		nil,
+		nil,
+		deterministicRandomSource(),
+		nil, 0,
+		nil, 0,
+		&nanosleep,
+		nil,
+	)
+	require.NoError(t, err)
+
+	mod, _ := instantiateModule(testCtx, t, functionPollOneoff, importPollOneoff, sysCtx)
+	defer mod.Close(testCtx)
+
+	in, out, resultNevents := uint32(0), uint32(256), uint32(512) // arbitrary, non-overlapping offsets
+	poll := func(ctx context.Context, subscriptions ...[]byte) ([]byte, Errno) {
+		require.True(t, mod.Memory().Write(testCtx, in, bytes.Join(subscriptions, nil)))
+		if errno := a.PollOneoff(ctx, mod, in, out, uint32(len(subscriptions)), resultNevents); errno != ErrnoSuccess {
+			return nil, errno
+		}
+		nevents, ok := mod.Memory().ReadUint32Le(testCtx, resultNevents)
+		require.True(t, ok)
+		events, ok := mod.Memory().Read(testCtx, out, nevents*eventLen)
+		require.True(t, ok)
+		return events, ErrnoSuccess
+	}
+
+	t.Run("empty stdin times out", func(t *testing.T) {
+		events, errno := poll(testCtx,
+			fdSubscription(1, eventTypeFdRead, fdStdin),
+			clockSubscription(2, clockIDMonotonic, uint64(time.Millisecond), 0),
+		)
+		require.Equal(t, ErrnoSuccess, errno, ErrnoName(errno))
+		require.Equal(t, event(2, ErrnoSuccess, eventTypeClock, 0), events)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(testCtx)
+		cancel()
+		_, errno := poll(ctx, fdSubscription(1, eventTypeFdRead, fdStdin))
+		require.Equal(t, ErrnoIntr, errno, ErrnoName(errno))
+	})
+
+	t.Run("data before the timeout", func(t *testing.T) {
+		go stdinWriter.Write([]byte("wazero"))
+		events, errno := poll(testCtx,
+			fdSubscription(1, eventTypeFdRead, fdStdin),
+			clockSubscription(2, clockIDMonotonic, uint64(time.Minute), 0),
+		)
+		require.Equal(t, ErrnoSuccess, errno, ErrnoName(errno))
+		require.Equal(t, event(1, ErrnoSuccess, eventTypeFdRead, 6), events) // len("wazero")
+
+		// fd_read returns the buffered data.
+		buf := make([]byte, 6)
+		n, err := sysCtx.Stdin().Read(buf)
 		require.NoError(t, err)
-		errno := Errno(results[0]) // results[0] is the errno
-		require.Equal(t, ErrnoNosys, errno, ErrnoName(errno))
+		require.Equal(t, "wazero", string(buf[:n]))
 	})
 }
 
+func TestSnapshotPreview1_PollOneoff_Canceled(t *testing.T) {
+	nanosleep := sys.Nanosleep(platform.Nanosleep)
+	sysCtx, err := internalsys.NewContext(
+		math.MaxUint32,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		deterministicRandomSource(),
+		nil, 0,
+		nil, 0,
+		&nanosleep,
+		nil,
+	)
+	require.NoError(t, err)
+
+	mod, _ := instantiateModule(testCtx, t, functionPollOneoff, importPollOneoff, sysCtx)
+	defer mod.Close(testCtx)
+
+	ctx, cancel := context.WithCancel(testCtx)
+	cancel()
+
+	in, out, resultNevents := uint32(0), uint32(64), uint32(128) // arbitrary, non-overlapping offsets
+	require.True(t, mod.Memory().Write(testCtx, in, clockSubscription(1, clockIDMonotonic, math.MaxInt64, 0)))
+
+	errno := a.PollOneoff(ctx, mod, in, out, 1, resultNevents)
+	require.Equal(t, ErrnoIntr, errno, ErrnoName(errno))
+}
+
+func TestSnapshotPreview1_PollOneoff_Errors(t *testing.T) {
+	mod, _ := instantiateModule(testCtx, t, functionPollOneoff, importPollOneoff, nil)
+	defer mod.Close(testCtx)
+
+	memorySize := mod.Memory().Size(testCtx)
+	require.True(t, mod.Memory().Write(testCtx, 0, fdSubscription(1, 3 /* invalid */, fdStdin)))
+	require.True(t, mod.Memory().Write(testCtx, 48, fdSubscription(1, eventTypeFdRead, fdStdin)))
+
+	tests := []struct {
+		name                                   string
+		in, out, nsubscriptions, resultNevents uint32
+		expectedErrno                          Errno
+	}{
+		{
+			name:           "no subscriptions",
+			nsubscriptions: 0,
+			expectedErrno:  ErrnoInval,
+		},
+		{
+			name:           "in out of memory",
+			in:             memorySize - 47,
+			nsubscriptions: 1,
+			expectedErrno:  ErrnoFault,
+		},
+		{
+			name:           "out out of memory",
+			in:             48,
+			out:            memorySize - 31,
+			nsubscriptions: 1,
+			expectedErrno:  ErrnoFault,
+		},
+		{
+			name:           "resultNevents out of memory",
+			in:             48,
+			out:            96,
+			nsubscriptions: 1,
+			resultNevents:  memorySize - 3,
+			expectedErrno:  ErrnoFault,
+		},
+		{
+			name:           "subscriptions overflow",
+			nsubscriptions: math.MaxUint32,
+			expectedErrno:  ErrnoFault,
+		},
+		{
+			name:           "invalid event type",
+			out:            128,
+			nsubscriptions: 1,
+			expectedErrno:  ErrnoInval,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			errno := a.PollOneoff(testCtx, mod, tc.in, tc.out, tc.nsubscriptions, tc.resultNevents)
+			require.Equal(t, tc.expectedErrno, errno, ErrnoName(errno))
+		})
+	}
+}
+
+// clockSubscription returns a subscription of eventTypeClock in the little-endian layout of WASI.
+func clockSubscription(userdata uint64, id uint32, timeout uint64, flags uint16) []byte {
+	sub := make([]byte, subscriptionLen)
+	binary.LittleEndian.PutUint64(sub, userdata)
+	sub[8] = eventTypeClock
+	binary.LittleEndian.PutUint32(sub[16:], id)
+	binary.LittleEndian.PutUint64(sub[24:], timeout)
+	binary.LittleEndian.PutUint16(sub[40:], flags)
+	return sub
+}
+
+// fdSubscription returns a subscription of eventTypeFdRead or eventTypeFdWrite in the little-endian layout of WASI.
+func fdSubscription(userdata uint64, eventType byte, fd uint32) []byte {
+	sub := make([]byte, subscriptionLen)
+	binary.LittleEndian.PutUint64(sub, userdata)
+	sub[8] = eventType
+	binary.LittleEndian.PutUint32(sub[16:], fd)
+	return sub
+}
+
+// event returns an event in the little-endian layout of WASI.
+func event(userdata uint64, errno Errno, eventType byte, nbytes uint64) []byte {
+	e := make([]byte, eventLen)
+	binary.LittleEndian.PutUint64(e, userdata)
+	binary.LittleEndian.PutUint16(e[8:], uint16(errno))
+	e[10] = eventType
+	binary.LittleEndian.PutUint64(e[16:], nbytes)
+	return e
+}
+
 func TestSnapshotPreview1_ProcExit(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -2193,6 +2712,7 @@ func TestSnapshotPreview1_RandomGet_SourceError(t *testing.T) {
 				nil, 0,
 				nil, 0,
 				nil,
+				nil,
 			)
 			require.NoError(t, err)
 
@@ -2312,6 +2832,7 @@ func newSysContext(args, environ []string, openedFiles map[uint32]*internalsys.F
 		deterministicRandomSource(),
 		nil, 0,
 		nil, 0,
+		nil,
 		openedFiles,
 	)
 }